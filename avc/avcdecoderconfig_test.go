@@ -37,3 +37,43 @@ func TestAvcDecoderConfigRecord(t *testing.T) {
 		t.Error(diff)
 	}
 }
+
+func TestAvcDecoderConfigRecordLengthSize(t *testing.T) {
+	byteData, _ := hex.DecodeString(avcDecoderConfigRecord)
+	adcr, err := DecodeAVCDecConfRec(bytes.NewBuffer(byteData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adcr.NALULengthSize() != 4 {
+		t.Errorf("got NALU length size %d instead of 4", adcr.NALULengthSize())
+	}
+	if err := adcr.SetNALULengthSize(3); err != ErrLengthSize {
+		t.Errorf("length size 3 should give ErrLengthSize")
+	}
+	if err := adcr.SetNALULengthSize(2); err != nil {
+		t.Error(err)
+	}
+	buf := bytes.Buffer{}
+	if err := adcr.Encode(&buf); err != nil {
+		t.Error(err)
+	}
+	got, err := DecodeAVCDecConfRec(&buf)
+	if err != nil {
+		t.Error(err)
+	}
+	if diff := deep.Equal(got, adcr); diff != nil {
+		t.Error(diff)
+	}
+	if got.NALULengthSize() != 2 {
+		t.Errorf("got NALU length size %d instead of 2 after decode", got.NALULengthSize())
+	}
+
+	zero := DecConfRec{AVCProfileIndication: 66}
+	buf.Reset()
+	if err := zero.Encode(&buf); err != nil {
+		t.Error(err)
+	}
+	if buf.Bytes()[4] != 0xff {
+		t.Errorf("zero value should encode 4-byte NALU lengths, got byte 0x%02x", buf.Bytes()[4])
+	}
+}
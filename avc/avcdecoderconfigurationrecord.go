@@ -11,7 +11,7 @@ import (
 // AVC parsing errors
 var (
 	ErrCannotParseAVCExtension = errors.New("Cannot parse SPS extensions")
-	ErrLengthSize              = errors.New("Can only handle 4byte NAL length size")
+	ErrLengthSize              = errors.New("NAL length size must be 1, 2, or 4 bytes")
 )
 
 // DecConfRec - AVCDecoderConfigurationRecord
@@ -26,6 +26,7 @@ type DecConfRec struct {
 	BitDepthChromaMinus1 byte
 	NumSPSExt            byte
 	NoTrailingInfo       bool // To handle strange cases where trailing info is missing
+	naluLengthSize       int  // Size of NAL unit length fields (1, 2, or 4). 0 means 4
 }
 
 // CreateAVCDecConfRec - Create an AVCDecConfRec based on SPS and PPS
@@ -65,8 +66,8 @@ func DecodeAVCDecConfRec(r io.Reader) (DecConfRec, error) {
 	AVCProfileIndication := data[1]
 	ProfileCompatibility := data[2]
 	AVCLevelIndication := data[3]
-	LengthSizeMinus1 := data[4] & 0x03 // The first 5 bits are 1
-	if LengthSizeMinus1 != 0x3 {
+	lengthSizeMinusOne := data[4] & 0x03 // The first 6 bits are 1
+	if lengthSizeMinusOne == 2 {
 		return DecConfRec{}, ErrLengthSize
 	}
 	numSPS := data[5] & 0x1f // 5 bits following 3 reserved bits
@@ -91,6 +92,7 @@ func DecodeAVCDecConfRec(r io.Reader) (DecConfRec, error) {
 		AVCProfileIndication: AVCProfileIndication,
 		ProfileCompatibility: ProfileCompatibility,
 		AVCLevelIndication:   AVCLevelIndication,
+		naluLengthSize:       int(lengthSizeMinusOne) + 1,
 		SPSnalus:             spsNALUs,
 		PPSnalus:             ppsNALUs,
 	}
@@ -169,7 +171,7 @@ func (a *DecConfRec) Encode(w io.Writer) error {
 	writeByte(a.AVCProfileIndication)
 	writeByte(a.ProfileCompatibility)
 	writeByte(a.AVCLevelIndication)
-	writeByte(0xff) // Set length to 4
+	writeByte(0xfc | byte(a.NALULengthSize()-1))
 
 	var nrSPS byte = byte(len(a.SPSnalus)) | 0xe0 // Added reserved 3 bits
 	writeByte(nrSPS)
@@ -200,3 +202,21 @@ func (a *DecConfRec) Encode(w io.Writer) error {
 
 	return errWrite
 }
+
+// NALULengthSize - size in bytes of NAL unit length fields in samples. 4 unless set to 1 or 2
+func (a *DecConfRec) NALULengthSize() int {
+	if a.naluLengthSize == 0 {
+		return 4
+	}
+	return a.naluLengthSize
+}
+
+// SetNALULengthSize - set size in bytes (1, 2, or 4) of NAL unit length fields in samples.
+// The samples must be converted separately, e.g. with ConvertNALULengthSize.
+func (a *DecConfRec) SetNALULengthSize(size int) error {
+	if size != 1 && size != 2 && size != 4 {
+		return ErrLengthSize
+	}
+	a.naluLengthSize = size
+	return nil
+}
@@ -0,0 +1,73 @@
+package avc
+
+import (
+	"fmt"
+	"math"
+)
+
+// levelLimit - limits from ISO/IEC 14496-10 Table A-1
+type levelLimit struct {
+	levelIDC uint
+	maxMBPS  uint // Max macroblock processing rate (MB/s)
+	maxFS    uint // Max frame size (MBs)
+}
+
+var levelLimits = []levelLimit{
+	{10, 1485, 99},
+	{11, 3000, 396},
+	{12, 6000, 396},
+	{13, 11880, 396},
+	{20, 11880, 396},
+	{21, 19800, 792},
+	{22, 20250, 1620},
+	{30, 40500, 1620},
+	{31, 108000, 3600},
+	{32, 216000, 5120},
+	{40, 245760, 8192},
+	{42, 522240, 8704},
+	{50, 589824, 22080},
+	{51, 983040, 36864},
+	{52, 2073600, 36864},
+	{60, 4177920, 139264},
+	{61, 8355840, 139264},
+	{62, 16711680, 139264},
+}
+
+// MinimumLevel - lowest level_idc that supports a picture size and frame rate.
+// Only frame size and macroblock rate limits are checked, not bitrate or DPB size.
+func MinimumLevel(width, height uint, frameRate float64) (uint, error) {
+	if width == 0 || height == 0 || frameRate <= 0 {
+		return 0, fmt.Errorf("bad video size %dx%d or frame rate %f", width, height, frameRate)
+	}
+	widthMbs := (width + 15) / 16
+	heightMbs := (height + 15) / 16
+	frameSize := widthMbs * heightMbs
+	mbRate := float64(frameSize) * frameRate
+	for _, l := range levelLimits {
+		maxDim := uint(math.Sqrt(float64(8 * l.maxFS)))
+		if frameSize <= l.maxFS && widthMbs <= maxDim && heightMbs <= maxDim && mbRate <= float64(l.maxMBPS) {
+			return l.levelIDC, nil
+		}
+	}
+	return 0, fmt.Errorf("no level supports %dx%d at %.2f fps", width, height, frameRate)
+}
+
+// SetSPSLevel - return a copy of the SPS NAL unit sps with level_idc set to level.
+// For Baseline, Main and Extended profile, constraint_set3_flag is cleared since it signals level 1b
+// together with level_idc 11.
+func SetSPSLevel(sps []byte, level uint) ([]byte, error) {
+	if len(sps) < 4 || GetNaluType(sps[0]) != NALU_SPS {
+		return nil, fmt.Errorf("not an SPS NAL unit")
+	}
+	if level == 0 || level > 255 {
+		return nil, fmt.Errorf("bad level_idc %d", level)
+	}
+	out := make([]byte, len(sps))
+	copy(out, sps)
+	switch out[1] { // profile_idc
+	case 66, 77, 88:
+		out[2] &^= 0x10
+	}
+	out[3] = byte(level)
+	return out, nil
+}
@@ -0,0 +1,46 @@
+package avc
+
+import "testing"
+
+func TestMinimumLevel(t *testing.T) {
+	testCases := []struct {
+		width, height uint
+		frameRate     float64
+		wanted        uint
+	}{
+		{176, 144, 15, 10},
+		{640, 360, 30, 30},
+		{1280, 720, 30, 31},
+		{1280, 720, 60, 32},
+		{1920, 1080, 30, 40},
+		{1920, 1080, 60, 42},
+		{3840, 2160, 30, 51},
+		{3840, 2160, 60, 52},
+	}
+	for _, tc := range testCases {
+		got, err := MinimumLevel(tc.width, tc.height, tc.frameRate)
+		if err != nil {
+			t.Error(err)
+		}
+		if got != tc.wanted {
+			t.Errorf("%dx%d@%.0f: got level %d instead of %d", tc.width, tc.height, tc.frameRate, got, tc.wanted)
+		}
+	}
+	if _, err := MinimumLevel(16384, 16384, 60); err == nil {
+		t.Errorf("too big size should give error")
+	}
+}
+
+func TestSetSPSLevel(t *testing.T) {
+	sps := []byte{0x67, 66, 0xd0, 11, 0xac} // Baseline profile level 1b
+	got, err := SetSPSLevel(sps, 30)
+	if err != nil {
+		t.Error(err)
+	}
+	if got[2] != 0xc0 || got[3] != 30 || sps[3] != 11 {
+		t.Errorf("got %x from %x", got, sps)
+	}
+	if _, err := SetSPSLevel([]byte{0x68, 66, 0xc0, 11}, 30); err == nil {
+		t.Errorf("PPS NAL unit should give error")
+	}
+}
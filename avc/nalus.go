@@ -24,3 +24,146 @@ func GetNalusFromSample(sample []byte) ([][]byte, error) {
 	}
 	return naluList, nil
 }
+
+// GetNalusFromSampleWithLengthSize - get nalus by following length fields of lengthSize (1, 2, or 4) bytes
+func GetNalusFromSampleWithLengthSize(sample []byte, lengthSize int) ([][]byte, error) {
+	if lengthSize != 1 && lengthSize != 2 && lengthSize != 4 {
+		return nil, ErrLengthSize
+	}
+	var naluList [][]byte
+	pos := 0
+	for pos < len(sample) {
+		if pos+lengthSize > len(sample) {
+			return nil, fmt.Errorf("NAL length field at %d beyond sample size %d", pos, len(sample))
+		}
+		naluLength := 0
+		for i := 0; i < lengthSize; i++ {
+			naluLength = naluLength<<8 | int(sample[pos+i])
+		}
+		pos += lengthSize
+		if pos+naluLength > len(sample) {
+			return nil, fmt.Errorf("NAL length fields are bad. Not video?")
+		}
+		naluList = append(naluList, sample[pos:pos+naluLength])
+		pos += naluLength
+	}
+	return naluList, nil
+}
+
+// ConvertNALULengthSize - convert NAL unit length fields in sample from oldSize to newSize bytes.
+// Returns error if a NAL unit is too big for the new length size.
+func ConvertNALULengthSize(sample []byte, oldSize, newSize int) ([]byte, error) {
+	if newSize != 1 && newSize != 2 && newSize != 4 {
+		return nil, ErrLengthSize
+	}
+	nalus, err := GetNalusFromSampleWithLengthSize(sample, oldSize)
+	if err != nil {
+		return nil, err
+	}
+	if oldSize == newSize {
+		return sample, nil
+	}
+	maxLength := 1<<(8*newSize) - 1
+	outSize := 0
+	for _, nalu := range nalus {
+		if len(nalu) > maxLength {
+			return nil, fmt.Errorf("NALU size %d too big for %d-byte length field", len(nalu), newSize)
+		}
+		outSize += newSize + len(nalu)
+	}
+	out := make([]byte, 0, outSize)
+	for _, nalu := range nalus {
+		for i := newSize - 1; i >= 0; i-- {
+			out = append(out, byte(len(nalu)>>(8*i)))
+		}
+		out = append(out, nalu...)
+	}
+	return out, nil
+}
+
+// ValidateNALULengths - check that NAL unit length fields of lengthSize bytes exactly cover the sample
+// and that every NAL unit is non-empty with forbidden_zero_bit equal to 0.
+// Works for HEVC samples as well.
+func ValidateNALULengths(sample []byte, lengthSize int) error {
+	nalus, err := GetNalusFromSampleWithLengthSize(sample, lengthSize)
+	if err != nil {
+		return err
+	}
+	for i, nalu := range nalus {
+		if !isPlausibleNaluStart(nalu) {
+			return fmt.Errorf("NALU %d is empty or has forbidden_zero_bit set", i+1)
+		}
+	}
+	return nil
+}
+
+// RepairNALULengths - repair NAL unit length fields of lengthSize bytes in sample.
+// Length fields that are off by up to maxOffBy bytes are corrected, so that all NAL units are plausible
+// and exactly cover the sample. If that fails and the sample has start codes, the lengths are
+// re-derived from the start codes. The returned sample is a new slice if changed is true.
+// Works for HEVC samples as well.
+func RepairNALULengths(sample []byte, lengthSize, maxOffBy int) (repaired []byte, changed bool, err error) {
+	if lengthSize != 1 && lengthSize != 2 && lengthSize != 4 {
+		return nil, false, ErrLengthSize
+	}
+	if ValidateNALULengths(sample, lengthSize) == nil {
+		return sample, false, nil
+	}
+	if lengths, ok := findNaluLengths(sample, 0, lengthSize, maxOffBy, make(map[int]bool)); ok {
+		repaired = make([]byte, len(sample))
+		copy(repaired, sample)
+		pos := 0
+		for _, length := range lengths {
+			for i := 0; i < lengthSize; i++ {
+				repaired[pos+i] = byte(length >> (8 * (lengthSize - 1 - i)))
+			}
+			pos += lengthSize + length
+		}
+		return repaired, true, nil
+	}
+	if len(ExtractNalusFromByteStream(sample)) > 0 {
+		stream := make([]byte, len(sample))
+		copy(stream, sample)
+		repaired, err = ConvertNALULengthSize(ConvertByteStreamToNaluSample(stream), 4, lengthSize)
+		if err == nil && ValidateNALULengths(repaired, lengthSize) == nil {
+			return repaired, true, nil
+		}
+	}
+	return nil, false, fmt.Errorf("could not repair NAL length fields")
+}
+
+// findNaluLengths - find NALU lengths from pos to end of sample, allowing each length field to be off by maxOffBy.
+// Positions that cannot be parsed to the end are stored in failed to avoid repeated work.
+func findNaluLengths(sample []byte, pos, lengthSize, maxOffBy int, failed map[int]bool) ([]int, bool) {
+	if pos == len(sample) {
+		return nil, true
+	}
+	if pos+lengthSize >= len(sample) || failed[pos] || !isPlausibleNaluStart(sample[pos+lengthSize:]) {
+		return nil, false
+	}
+	length := 0
+	for i := 0; i < lengthSize; i++ {
+		length = length<<8 | int(sample[pos+i])
+	}
+	candidates := []int{length}
+	for d := 1; d <= maxOffBy; d++ {
+		candidates = append(candidates, length+d, length-d)
+	}
+	maxLength := 1<<(8*lengthSize) - 1
+	for _, l := range candidates {
+		next := pos + lengthSize + l
+		if l <= 0 || l > maxLength || next > len(sample) {
+			continue
+		}
+		if rest, ok := findNaluLengths(sample, next, lengthSize, maxOffBy, failed); ok {
+			return append([]int{l}, rest...), true
+		}
+	}
+	failed[pos] = true
+	return nil, false
+}
+
+// isPlausibleNaluStart - non-empty and forbidden_zero_bit is 0
+func isPlausibleNaluStart(nalu []byte) bool {
+	return len(nalu) > 0 && nalu[0]&0x80 == 0
+}
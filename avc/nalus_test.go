@@ -0,0 +1,87 @@
+package avc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConvertNALULengthSize(t *testing.T) {
+	sample4 := []byte{0, 0, 0, 2, 9, 16, 0, 0, 0, 3, 0x65, 1, 2}
+	sample2 := []byte{0, 2, 9, 16, 0, 3, 0x65, 1, 2}
+	sample1 := []byte{2, 9, 16, 3, 0x65, 1, 2}
+
+	testCases := []struct {
+		name    string
+		in      []byte
+		oldSize int
+		newSize int
+		wanted  []byte
+	}{
+		{"4 to 2", sample4, 4, 2, sample2},
+		{"4 to 1", sample4, 4, 1, sample1},
+		{"1 to 4", sample1, 1, 4, sample4},
+		{"2 to 2", sample2, 2, 2, sample2},
+	}
+	for _, tc := range testCases {
+		got, err := ConvertNALULengthSize(tc.in, tc.oldSize, tc.newSize)
+		if err != nil {
+			t.Errorf("%s: %s", tc.name, err)
+			continue
+		}
+		if !bytes.Equal(got, tc.wanted) {
+			t.Errorf("%s: got %v instead of %v", tc.name, got, tc.wanted)
+		}
+	}
+
+	bigNalu := append([]byte{0, 0, 1, 0}, make([]byte, 256)...)
+	if _, err := ConvertNALULengthSize(bigNalu, 4, 1); err == nil {
+		t.Errorf("too big NALU for 1-byte length should give error")
+	}
+	if _, err := ConvertNALULengthSize(sample4, 4, 3); err != ErrLengthSize {
+		t.Errorf("3-byte length should give ErrLengthSize")
+	}
+	if _, err := GetNalusFromSampleWithLengthSize(sample4[:8], 4); err == nil {
+		t.Errorf("truncated sample should give error")
+	}
+}
+
+func TestRepairNALULengths(t *testing.T) {
+	valid := []byte{0, 0, 0, 2, 9, 16, 0, 0, 0, 3, 0x65, 1, 2}
+	testCases := []struct {
+		name          string
+		in            []byte
+		lengthSize    int
+		wanted        []byte
+		wantedChanged bool
+		expectError   bool
+	}{
+		{"valid", valid, 4, valid, false, false},
+		{"first off by +1", []byte{0, 0, 0, 3, 9, 16, 0, 0, 0, 3, 0x65, 1, 2}, 4, valid, true, false},
+		{"last off by -2", []byte{0, 0, 0, 2, 9, 16, 0, 0, 0, 1, 0x65, 1, 2}, 4, valid, true, false},
+		{"start codes", []byte{0, 0, 0, 1, 9, 16, 0, 0, 1, 0x65, 1, 2}, 4, valid, true, false},
+		{"2-byte lengths", []byte{0, 3, 9, 16, 0, 3, 0x65, 1, 2}, 2, []byte{0, 2, 9, 16, 0, 3, 0x65, 1, 2}, true, false},
+		{"garbage", []byte{0xff, 0xff, 0xff, 0xff, 0xff}, 4, nil, false, true},
+	}
+	for _, tc := range testCases {
+		got, changed, err := RepairNALULengths(tc.in, tc.lengthSize, 2)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("%s: expected error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tc.name, err)
+			continue
+		}
+		if changed != tc.wantedChanged {
+			t.Errorf("%s: got changed=%t instead of %t", tc.name, changed, tc.wantedChanged)
+		}
+		if !bytes.Equal(got, tc.wanted) {
+			t.Errorf("%s: got %v instead of %v", tc.name, got, tc.wanted)
+		}
+		if err := ValidateNALULengths(got, tc.lengthSize); err != nil {
+			t.Errorf("%s: repaired sample not valid: %s", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Package cli - flags, logging, and batch processing shared by the mp4ff command line tools.
+//
+// All tools take the flags -workers, -progress, and -loglevel, and exit with ExitOK if all input files
+// were processed, ExitFailure if any file failed, and ExitUsage for bad command lines.
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgeware/mp4ff/mp4"
+)
+
+// Exit codes of the tools
+const (
+	ExitOK      = 0 // All input files processed
+	ExitFailure = 1 // Processing of at least one input file failed
+	ExitUsage   = 2 // Bad command line
+)
+
+// Level - log level
+type Level int
+
+// Log levels in increasing verbosity
+const (
+	LevelError Level = iota
+	LevelWarn
+	LevelInfo
+	LevelDebug
+)
+
+var levelNames = []string{"error", "warn", "info", "debug"}
+
+// String - name of log level as used with -loglevel
+func (l Level) String() string {
+	if l < LevelError || l > LevelDebug {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel - parse a log level name
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return Level(i), nil
+		}
+	}
+	return LevelError, fmt.Errorf("unknown log level %q, must be one of %s", name, strings.Join(levelNames, ", "))
+}
+
+// Options - values of the shared flags
+type Options struct {
+	Workers  int    // Number of files processed in parallel
+	Progress bool   // Report each finished file on the log output
+	LogLevel string // error, warn, info, or debug
+}
+
+// AddFlags - register the shared flags on fs and return the options they set
+func AddFlags(fs *flag.FlagSet) *Options {
+	o := &Options{}
+	fs.IntVar(&o.Workers, "workers", 1, "Number of input files processed in parallel")
+	fs.BoolVar(&o.Progress, "progress", false, "Report progress on stderr")
+	fs.StringVar(&o.LogLevel, "loglevel", "warn", "Log level: "+strings.Join(levelNames, ", "))
+	return o
+}
+
+// Logger - check the options and create a logger writing to w at the configured level.
+// The logger is also set as the logger of the mp4 library, so that its warnings and debug messages are shown.
+func (o *Options) Logger(w io.Writer) (*Logger, error) {
+	if o.Workers < 1 {
+		return nil, fmt.Errorf("workers must be at least 1, not %d", o.Workers)
+	}
+	level, err := ParseLevel(o.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	logger := NewLogger(w, level)
+	mp4.SetLogger(logger)
+	return logger, nil
+}
+
+// Logger - leveled logger that is safe for concurrent use
+type Logger struct {
+	level Level
+	mu    sync.Mutex
+	l     *log.Logger
+}
+
+// NewLogger - create logger writing messages up to level to w
+func NewLogger(w io.Writer, level Level) *Logger {
+	return &Logger{level: level, l: log.New(w, "", log.LstdFlags)}
+}
+
+// Enabled - true if messages at level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level <= l.level
+}
+
+func (l *Logger) logf(level Level, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.l.Printf("%s: %s", strings.ToUpper(level.String()), fmt.Sprintf(format, args...))
+}
+
+// Errorf - log at error level
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logf(LevelError, format, args...)
+}
+
+// Warnf - log at warn level
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.logf(LevelWarn, format, args...)
+}
+
+// Infof - log at info level
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logf(LevelInfo, format, args...)
+}
+
+// Debugf - log at debug level
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logf(LevelDebug, format, args...)
+}
+
+// progressf - write progress message regardless of level
+func (l *Logger) progressf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.l.Printf(format, args...)
+}
+
+// ProcessFunc - process one input file and write its output to w
+type ProcessFunc func(path string, w io.Writer) error
+
+type result struct {
+	out  bytes.Buffer
+	err  error
+	done chan struct{}
+}
+
+// Run - process files with o.Workers workers and return the exit code.
+//
+// The output of each file is buffered and written to stdout in input order, so it does not depend on
+// the number of workers. With more than one file, each output starts with a "==> path <==" line.
+// Errors are logged and make the exit code ExitFailure, but do not stop the processing of other files.
+func Run(o *Options, files []string, stdout io.Writer, logger *Logger, process ProcessFunc) int {
+	workers := o.Workers
+	if workers > len(files) {
+		workers = len(files)
+	}
+	results := make([]*result, len(files))
+	for i := range results {
+		results[i] = &result{done: make(chan struct{})}
+	}
+	jobs := make(chan int)
+	var mu sync.Mutex
+	nrDone := 0
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		go func() {
+			for nr := range jobs {
+				r := results[nr]
+				logger.Debugf("start %s", files[nr])
+				fileStart := time.Now()
+				r.err = process(files[nr], &r.out)
+				logger.Debugf("done %s in %s", files[nr], time.Since(fileStart))
+				if o.Progress {
+					mu.Lock()
+					nrDone++
+					status := "ok"
+					if r.err != nil {
+						status = "failed"
+					}
+					logger.progressf("[%d/%d] %s %s (%s)", nrDone, len(files), files[nr], status,
+						time.Since(start).Round(time.Millisecond))
+					mu.Unlock()
+				}
+				close(r.done)
+			}
+		}()
+	}
+	go func() {
+		for nr := range files {
+			jobs <- nr
+		}
+		close(jobs)
+	}()
+
+	exitCode := ExitOK
+	nrFailed := 0
+	for nr, r := range results {
+		<-r.done
+		if len(files) > 1 {
+			fmt.Fprintf(stdout, "==> %s <==\n", files[nr])
+		}
+		_, err := stdout.Write(r.out.Bytes())
+		r.out = bytes.Buffer{}
+		if err != nil {
+			logger.Errorf("writing output: %s", err)
+			return ExitFailure
+		}
+		if r.err != nil {
+			logger.Errorf("%s: %s", files[nr], r.err)
+			nrFailed++
+			exitCode = ExitFailure
+		}
+	}
+	logger.Infof("processed %d files, %d failed, in %s", len(files), nrFailed, time.Since(start))
+	return exitCode
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgeware/mp4ff/mp4"
+)
+
+func TestRunOrderAndExitCode(t *testing.T) {
+	files := []string{"a", "b", "fail", "c"}
+	process := func(path string, w io.Writer) error {
+		if path == "a" {
+			time.Sleep(10 * time.Millisecond) // Finishes last with several workers
+		}
+		if path == "fail" {
+			return fmt.Errorf("bad file")
+		}
+		fmt.Fprintf(w, "output of %s\n", path)
+		return nil
+	}
+	wantedOut := "==> a <==\noutput of a\n==> b <==\noutput of b\n==> fail <==\n==> c <==\noutput of c\n"
+	for _, workers := range []int{1, 3, 10} {
+		var stdout, stderr bytes.Buffer
+		o := &Options{Workers: workers, Progress: true, LogLevel: "warn"}
+		logger, err := o.Logger(&stderr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exitCode := Run(o, files, &stdout, logger, process)
+		if exitCode != ExitFailure {
+			t.Errorf("workers=%d: got exit code %d instead of %d", workers, exitCode, ExitFailure)
+		}
+		if stdout.String() != wantedOut {
+			t.Errorf("workers=%d: got output %q", workers, stdout.String())
+		}
+		log := stderr.String()
+		if !strings.Contains(log, "ERROR: fail: bad file") {
+			t.Errorf("workers=%d: error not logged: %q", workers, log)
+		}
+		if strings.Count(log, "/4] ") != 4 {
+			t.Errorf("workers=%d: progress not reported for all files: %q", workers, log)
+		}
+	}
+}
+
+func TestRunSingleFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	o := &Options{Workers: 4, LogLevel: "error"}
+	logger, err := o.Logger(&stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exitCode := Run(o, []string{"a"}, &stdout, logger, func(path string, w io.Writer) error {
+		_, err := fmt.Fprintf(w, "output of %s\n", path)
+		return err
+	})
+	if exitCode != ExitOK {
+		t.Errorf("got exit code %d instead of %d", exitCode, ExitOK)
+	}
+	if stdout.String() != "output of a\n" {
+		t.Errorf("got output %q", stdout.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("unexpected log output %q", stderr.String())
+	}
+}
+
+func TestFlagsAndLogger(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	o := AddFlags(fs)
+	err := fs.Parse([]string{"-workers", "8", "-progress", "-loglevel", "INFO"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Workers != 8 || !o.Progress || o.LogLevel != "INFO" {
+		t.Errorf("got options %+v", o)
+	}
+	var buf bytes.Buffer
+	logger, err := o.Logger(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debugf("not written")
+	logger.Infof("written")
+	if strings.Contains(buf.String(), "not written") || !strings.Contains(buf.String(), "INFO: written") {
+		t.Errorf("got log output %q", buf.String())
+	}
+	defer mp4.SetLogger(nil)
+	// Unknown box is logged by the library at debug level, which is not enabled
+	_, err = mp4.DecodeBox(0, bytes.NewReader([]byte{0, 0, 0, 8, 'x', 'x', 'x', 'x'}))
+	if err != nil || strings.Contains(buf.String(), "xxxx") {
+		t.Errorf("got error %v and log output %q", err, buf.String())
+	}
+	o.LogLevel = "debug"
+	if _, err = o.Logger(&buf); err != nil {
+		t.Fatal(err)
+	}
+	_, err = mp4.DecodeBox(0, bytes.NewReader([]byte{0, 0, 0, 8, 'x', 'x', 'x', 'x'}))
+	if err != nil || !strings.Contains(buf.String(), `DEBUG: unknown box "xxxx"`) {
+		t.Errorf("library debug message not logged: %q", buf.String())
+	}
+
+	for _, bad := range []Options{{Workers: 0, LogLevel: "warn"}, {Workers: 1, LogLevel: "verbose"}} {
+		if _, err := bad.Logger(&buf); err == nil {
+			t.Errorf("no error for options %+v", bad)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"flag"
-	"log"
+	"fmt"
+	"io"
 	"os"
 
+	"github.com/edgeware/mp4ff/cmd/internal/cli"
 	"github.com/edgeware/mp4ff/mp4"
 )
 
@@ -12,33 +14,31 @@ func main() {
 
 	inFilePath := flag.String("i", "", "Required: Path to input mp4 file")
 	outFilePath := flag.String("o", "", "Required: Output filepath (without extension)")
+	opts := cli.AddFlags(flag.CommandLine)
 	flag.Parse()
 
 	if *inFilePath == "" || *outFilePath == "" {
 		flag.Usage()
-		return
+		os.Exit(cli.ExitUsage)
 	}
-
-	ifd, err := os.Open(*inFilePath)
-	if err != nil {
-		log.Fatalln(err)
-	}
-	defer ifd.Close()
-	parsedMp4, err := mp4.DecodeFile(ifd)
+	logger, err := opts.Logger(os.Stderr)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(cli.ExitUsage)
 	}
 
-	parsedMp4.Init.Moov.Mvex.Trex.TrackID = 3
+	os.Exit(cli.Run(opts, []string{*inFilePath}, os.Stdout, logger, func(inFilePath string, w io.Writer) error {
+		return fixTrex(inFilePath, *outFilePath)
+	}))
+}
 
-	ofd, err := os.Create(*outFilePath)
+func fixTrex(inFilePath, outFilePath string) error {
+	parsedMp4, err := mp4.ReadFile(inFilePath)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer ofd.Close()
 
-	err = parsedMp4.Encode(ofd)
-	if err != nil {
-		log.Fatal(err)
-	}
+	parsedMp4.Init.Moov.Mvex.Trex.TrackID = 3
+
+	return parsedMp4.WriteToFile(outFilePath)
 }
@@ -4,20 +4,22 @@ package main
 import (
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/edgeware/mp4ff/cmd/internal/cli"
 	"github.com/edgeware/mp4ff/mp4"
 )
 
 var usg = `Usage of mp4ff-info:
 
-mp4ff-info prints the box tree of input mp4 (ISOBMFF) file.
+mp4ff-info prints the box tree of input mp4 (ISOBMFF) files.
 For some boxes, more details are available by using -l with a comma-separated list:
   all:1  - level 1 for all boxes
   trun:1 - level 1 only for trun box
   all:1,trun:0 - level 1 for all boxes but trun
+  emsg:1 - list SCTE-35 splice commands and descriptors in emsg boxes
 
 `
 
@@ -25,7 +27,7 @@ var usage = func() {
 	parts := strings.Split(os.Args[0], "/")
 	name := parts[len(parts)-1]
 	fmt.Fprintln(os.Stderr, usg)
-	fmt.Fprintf(os.Stderr, "%s [-l string] <mp4File> \n", name)
+	fmt.Fprintf(os.Stderr, "%s [-l string] [-warnings] [-workers n] [-progress] [-loglevel level] <mp4File> [<mp4File> ...]\n", name)
 	flag.PrintDefaults()
 }
 
@@ -33,31 +35,48 @@ func main() {
 
 	specBoxLevels := flag.String("l", "", "level of details, e.g. all:1 or trun:1,subs:1")
 	version := flag.Bool("version", false, "Get mp4ff version")
+	warnings := flag.Bool("warnings", false, "Print non-fatal spec violations found when decoding")
+	opts := cli.AddFlags(flag.CommandLine)
 
 	flag.Parse()
 
 	if *version {
 		fmt.Printf("mp4ff-info %s\n", mp4.GetVersion())
-		os.Exit(0)
+		os.Exit(cli.ExitOK)
 	}
 
-	var inFilePath = flag.Arg(0)
-	if inFilePath == "" {
+	if flag.NArg() == 0 {
 		usage()
-		os.Exit(1)
+		os.Exit(cli.ExitUsage)
 	}
+	logger, err := opts.Logger(os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(cli.ExitUsage)
+	}
+
+	os.Exit(cli.Run(opts, flag.Args(), os.Stdout, logger, func(inFilePath string, w io.Writer) error {
+		return printInfo(inFilePath, *specBoxLevels, *warnings, w)
+	}))
+}
 
+func printInfo(inFilePath, specBoxLevels string, warnings bool, w io.Writer) error {
 	ifd, err := os.Open(inFilePath)
 	if err != nil {
-		log.Fatalln(err)
+		return err
 	}
 	defer ifd.Close()
 	parsedMp4, err := mp4.DecodeFile(ifd, mp4.WithDecodeMode(mp4.DecModeLazyMdat))
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	err = parsedMp4.Info(os.Stdout, *specBoxLevels, "", "  ")
-	if err != nil {
-		log.Fatal(err)
+	err = parsedMp4.Info(w, specBoxLevels, "", "  ")
+	if err != nil || !warnings {
+		return err
+	}
+	fmt.Fprintf(w, "Warnings: %d\n", len(parsedMp4.Warnings))
+	for _, warning := range parsedMp4.Warnings {
+		fmt.Fprintf(w, "  %s\n", warning)
 	}
+	return nil
 }
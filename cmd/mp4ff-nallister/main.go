@@ -6,11 +6,12 @@ import (
 	"encoding/hex"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/edgeware/mp4ff/avc"
+	"github.com/edgeware/mp4ff/cmd/internal/cli"
 	"github.com/edgeware/mp4ff/hevc"
 	"github.com/edgeware/mp4ff/mp4"
 )
@@ -27,7 +28,7 @@ var usage = func() {
 	parts := strings.Split(os.Args[0], "/")
 	name := parts[len(parts)-1]
 	fmt.Fprintln(os.Stderr, usg)
-	fmt.Fprintf(os.Stderr, "%s [-m <max>] [-c codec] <mp4File>\n", name)
+	fmt.Fprintf(os.Stderr, "%s [-m <max>] [-c codec] [-workers n] [-progress] [-loglevel level] <mp4File> [<mp4File> ...]\n", name)
 	flag.PrintDefaults()
 }
 
@@ -36,48 +37,45 @@ func main() {
 	codec := flag.String("c", "avc", "Codec to parse (avc or hevc)")
 	version := flag.Bool("version", false, "Get mp4ff version")
 	seiLevel := flag.Int("sei", 0, "Level of SEI information (1 is interpret, 2 is dump hex)")
+	opts := cli.AddFlags(flag.CommandLine)
 
 	flag.Parse()
 
 	if *version {
 		fmt.Printf("mp4ff-nallister %s\n", mp4.GetVersion())
-		os.Exit(0)
+		os.Exit(cli.ExitOK)
 	}
 
-	var inFilePath = flag.Arg(0)
-	if inFilePath == "" {
+	if flag.NArg() == 0 {
 		usage()
-		os.Exit(1)
+		os.Exit(cli.ExitUsage)
 	}
-
-	ifd, err := os.Open(inFilePath)
+	logger, err := opts.Logger(os.Stderr)
 	if err != nil {
-		log.Fatalln(err)
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(cli.ExitUsage)
 	}
-	defer ifd.Close()
-	parsedMp4, err := mp4.DecodeFile(ifd)
+
+	os.Exit(cli.Run(opts, flag.Args(), os.Stdout, logger, func(inFilePath string, w io.Writer) error {
+		return listNalus(inFilePath, *maxNrSamples, *codec, *seiLevel, w)
+	}))
+}
+
+func listNalus(inFilePath string, maxNrSamples int, codec string, seiLevel int, w io.Writer) error {
+	parsedMp4, err := mp4.ReadFile(inFilePath)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	// Need to handle progressive files as well as fragmented files
 
 	if !parsedMp4.IsFragmented() {
-		err = parseProgressiveMp4(parsedMp4, *maxNrSamples, *codec, *seiLevel)
-		if err != nil {
-			fmt.Printf("Error: %s\n", err)
-			os.Exit(1)
-		}
-		return
-	}
-	err = parseFragmentedMp4(parsedMp4, *maxNrSamples, *codec, *seiLevel)
-	if err != nil {
-		fmt.Printf("Error: %s\n", err)
-		os.Exit(1)
+		return parseProgressiveMp4(parsedMp4, maxNrSamples, codec, seiLevel, w)
 	}
+	return parseFragmentedMp4(parsedMp4, maxNrSamples, codec, seiLevel, w)
 }
 
-func parseProgressiveMp4(f *mp4.File, maxNrSamples int, codec string, seiLevel int) error {
+func parseProgressiveMp4(f *mp4.File, maxNrSamples int, codec string, seiLevel int, w io.Writer) error {
 	videoTrak, ok := findFirstVideoTrak(f.Moov)
 	if !ok {
 		return fmt.Errorf("No video track found")
@@ -89,7 +87,12 @@ func parseProgressiveMp4(f *mp4.File, maxNrSamples int, codec string, seiLevel i
 	} else if stbl.Stsd.HvcX != nil {
 		codec = "hevc"
 	}
-	nrSamples := stbl.Stsz.SampleNumber
+	lengthSize := naluLengthSize(stbl.Stsd)
+	sizes := stbl.SampleSizes()
+	if sizes == nil {
+		return fmt.Errorf("no stsz or stz2 box")
+	}
+	nrSamples := sizes.GetNrSamples()
 	mdat := f.Mdat
 	mdatPayloadStart := mdat.PayloadAbsoluteOffset()
 	for sampleNr := 1; sampleNr <= int(nrSamples); sampleNr++ {
@@ -99,9 +102,9 @@ func parseProgressiveMp4(f *mp4.File, maxNrSamples int, codec string, seiLevel i
 		}
 		offset := getChunkOffset(stbl, chunkNr)
 		for sNr := sampleNrAtChunkStart; sNr < sampleNr; sNr++ {
-			offset += int64(stbl.Stsz.GetSampleSize(sNr))
+			offset += int64(sizes.GetSampleSize(sNr))
 		}
-		size := stbl.Stsz.GetSampleSize(sampleNr)
+		size := sizes.GetSampleSize(sampleNr)
 		decTime, _ := stbl.Stts.GetDecodeTime(uint32(sampleNr))
 		var cto int32 = 0
 		if stbl.Ctts != nil {
@@ -112,9 +115,9 @@ func parseProgressiveMp4(f *mp4.File, maxNrSamples int, codec string, seiLevel i
 		sample := mdat.Data[offsetInMdatData : offsetInMdatData+uint64(size)]
 		switch codec {
 		case "avc", "h.264", "h264":
-			err = printAVCNalus(sample, sampleNr, decTime+uint64(cto), seiLevel)
+			err = printAVCNalus(sample, lengthSize, sampleNr, decTime+uint64(cto), seiLevel, w)
 		case "hevc", "h.265", "h265":
-			err = printHEVCNalus(sample, sampleNr, decTime+uint64(cto), seiLevel)
+			err = printHEVCNalus(sample, lengthSize, sampleNr, decTime+uint64(cto), seiLevel, w)
 		default:
 			return fmt.Errorf("Unknown codec: %s", codec)
 		}
@@ -139,6 +142,18 @@ func findFirstVideoTrak(moov *mp4.MoovBox) (*mp4.TrakBox, bool) {
 	return nil, false
 }
 
+// naluLengthSize - size of NALU length fields given by avcC or hvcC. 4 if there is none
+func naluLengthSize(stsd *mp4.StsdBox) int {
+	switch {
+	case stsd.AvcX != nil && stsd.AvcX.AvcC != nil:
+		return stsd.AvcX.AvcC.NALULengthSize()
+	case stsd.HvcX != nil && stsd.HvcX.HvcC != nil:
+		return stsd.HvcX.HvcC.NALULengthSize()
+	default:
+		return 4
+	}
+}
+
 func getChunkOffset(stbl *mp4.StblBox, chunkNr int) int64 {
 	if stbl.Stco != nil {
 		return int64(stbl.Stco.ChunkOffset[chunkNr-1])
@@ -149,8 +164,9 @@ func getChunkOffset(stbl *mp4.StblBox, chunkNr int) int64 {
 	panic("Neither stco nor co64 is set")
 }
 
-func parseFragmentedMp4(f *mp4.File, maxNrSamples int, codec string, seiLevel int) error {
-	if f.Init != nil { // Auto-detect codec if moov box is there
+func parseFragmentedMp4(f *mp4.File, maxNrSamples int, codec string, seiLevel int, w io.Writer) error {
+	lengthSize := 4
+	if f.Init != nil { // Auto-detect codec and NALU length size if moov box is there
 		moov := f.Init.Moov
 		videoTrak, ok := findFirstVideoTrak(moov)
 		if !ok {
@@ -162,6 +178,7 @@ func parseFragmentedMp4(f *mp4.File, maxNrSamples int, codec string, seiLevel in
 		} else if stbl.Stsd.HvcX != nil {
 			codec = "hevc"
 		}
+		lengthSize = naluLengthSize(stbl.Stsd)
 	}
 	iSamples := make([]mp4.FullSample, 0)
 	for _, iSeg := range f.Segments {
@@ -177,9 +194,9 @@ func parseFragmentedMp4(f *mp4.File, maxNrSamples int, codec string, seiLevel in
 	for i, s := range iSamples {
 		switch codec {
 		case "avc", "h.264", "h264":
-			err = printAVCNalus(s.Data, i+1, s.PresentationTime(), seiLevel)
+			err = printAVCNalus(s.Data, lengthSize, i+1, s.PresentationTime(), seiLevel, w)
 		case "hevc", "h.265", "h265":
-			err = printHEVCNalus(s.Data, i+1, s.PresentationTime(), seiLevel)
+			err = printHEVCNalus(s.Data, lengthSize, i+1, s.PresentationTime(), seiLevel, w)
 		default:
 			return fmt.Errorf("Unknown codec: %s", codec)
 		}
@@ -194,8 +211,8 @@ func parseFragmentedMp4(f *mp4.File, maxNrSamples int, codec string, seiLevel in
 	return nil
 }
 
-func printAVCNalus(sample []byte, nr int, pts uint64, seiLevel int) error {
-	nalus, err := avc.GetNalusFromSample(sample)
+func printAVCNalus(sample []byte, lengthSize, nr int, pts uint64, seiLevel int, w io.Writer) error {
+	nalus, err := avc.GetNalusFromSampleWithLengthSize(sample, lengthSize)
 	if err != nil {
 		return err
 	}
@@ -220,13 +237,13 @@ func printAVCNalus(sample []byte, nr int, pts uint64, seiLevel int) error {
 		}
 		msg += fmt.Sprintf(" %s %s(%dB)", naluType, imgType, len(nalu))
 	}
-	fmt.Printf("Sample %d, pts=%d (%dB):%s\n", nr, pts, len(sample), msg)
-	printSEINALus(seiNALUs, "avc", seiLevel)
+	fmt.Fprintf(w, "Sample %d, pts=%d (%dB):%s\n", nr, pts, len(sample), msg)
+	printSEINALus(seiNALUs, "avc", seiLevel, w)
 	return nil
 }
 
-func printHEVCNalus(sample []byte, nr int, pts uint64, seiLevel int) error {
-	nalus, err := avc.GetNalusFromSample(sample)
+func printHEVCNalus(sample []byte, lengthSize, nr int, pts uint64, seiLevel int, w io.Writer) error {
+	nalus, err := avc.GetNalusFromSampleWithLengthSize(sample, lengthSize)
 	if err != nil {
 		return err
 	}
@@ -242,20 +259,20 @@ func printHEVCNalus(sample []byte, nr int, pts uint64, seiLevel int) error {
 			seiNALUs = append(seiNALUs, nalu)
 		}
 	}
-	fmt.Printf("Sample %d, pts=%d (%dB):%s\n", nr, pts, len(sample), msg)
-	printSEINALus(seiNALUs, "hevc", seiLevel)
+	fmt.Fprintf(w, "Sample %d, pts=%d (%dB):%s\n", nr, pts, len(sample), msg)
+	printSEINALus(seiNALUs, "hevc", seiLevel, w)
 	return nil
 }
 
 // printSEINALus - print interpreted information if seiLevel is >= 1. Add hex dump if seiLevel >= 2
-func printSEINALus(seiNALUs [][]byte, codec string, seiLevel int) {
+func printSEINALus(seiNALUs [][]byte, codec string, seiLevel int, w io.Writer) {
 	if seiLevel < 1 {
 		return
 	}
 	if len(seiNALUs) > 0 {
 		for _, seiNALU := range seiNALUs {
 			if seiLevel >= 2 {
-				fmt.Printf("%s\n", hex.EncodeToString(seiNALU))
+				fmt.Fprintf(w, "%s\n", hex.EncodeToString(seiNALU))
 			}
 			var seiBytes []byte
 			switch codec {
@@ -269,16 +286,16 @@ func printSEINALus(seiNALUs [][]byte, codec string, seiLevel int) {
 			buf := bytes.NewReader(seiBytes)
 			seiDatas, err := avc.ExtractSEIData(buf)
 			if err != nil {
-				fmt.Printf("  SEI: Got error %q\n", err)
+				fmt.Fprintf(w, "  SEI: Got error %q\n", err)
 				continue
 			}
 			for _, seiData := range seiDatas {
 				sei, err := avc.DecodeSEIMessage(&seiData)
 				if err != nil {
-					fmt.Printf("  SEI: Got error %q\n", err)
+					fmt.Fprintf(w, "  SEI: Got error %q\n", err)
 					continue
 				}
-				fmt.Printf("  %s\n", sei)
+				fmt.Fprintf(w, "  %s\n", sei)
 			}
 		}
 	}
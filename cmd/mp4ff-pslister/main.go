@@ -10,11 +10,11 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"strings"
 
 	"github.com/edgeware/mp4ff/avc"
+	"github.com/edgeware/mp4ff/cmd/internal/cli"
 	"github.com/edgeware/mp4ff/hevc"
 	"github.com/edgeware/mp4ff/mp4"
 )
@@ -24,6 +24,7 @@ var usg = `Usage of mp4ff-pslister:
 mp4ff-pslister lists parameter sets for AVC/H.264 or HEVC/H.265 from mp4 sample description, bytestream, or hex input.
 
 It prints them as hex and in verbose mode it also prints details in JSON format.
+Files given after the flags are processed like the -i file.
 `
 
 var usage = func(msg string) {
@@ -31,7 +32,7 @@ var usage = func(msg string) {
 	name := parts[len(parts)-1]
 	fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
 	fmt.Fprintln(os.Stderr, usg)
-	fmt.Fprintf(os.Stderr, "%s [-v] [-i <mp4File/byte stream file>] [-vps hex] [-sps hex] [-pps hex]  [-codec avc/hevc]\n", name)
+	fmt.Fprintf(os.Stderr, "%s [-v] [-i <mp4File/byte stream file>] [-vps hex] [-sps hex] [-pps hex]  [-codec avc/hevc] [-workers n] [-progress] [-loglevel level] [<file> ...]\n", name)
 	flag.PrintDefaults()
 }
 
@@ -43,150 +44,168 @@ func main() {
 	ppsHex := flag.String("pps", "", "PPS in hex format")
 	codec := flag.String("c", "avc", "Codec to parse (avc or hevc or auto)")
 	version := flag.Bool("version", false, "Get mp4ff version")
+	opts := cli.AddFlags(flag.CommandLine)
 
 	flag.Parse()
 
 	if *version {
 		fmt.Printf("mp4ff-pslister %s\n", mp4.GetVersion())
-		os.Exit(0)
+		os.Exit(cli.ExitOK)
 	}
 
-	if *inFile == "" && *spsHex == "" {
+	var inFiles []string
+	if *inFile != "" {
+		inFiles = append(inFiles, *inFile)
+	}
+	inFiles = append(inFiles, flag.Args()...)
+
+	if len(inFiles) == 0 && *spsHex == "" {
 		usage("Must specify infile or sps")
-		os.Exit(1)
+		os.Exit(cli.ExitUsage)
 	}
 
 	if *ppsHex != "" && *spsHex == "" {
 		usage("pps needs sps")
-		os.Exit(1)
+		os.Exit(cli.ExitUsage)
+	}
+
+	logger, err := opts.Logger(os.Stderr)
+	if err != nil {
+		usage(err.Error())
+		os.Exit(cli.ExitUsage)
 	}
 
 	if *vpsHex != "" {
 		*codec = "hevc"
 	}
 
-	if *spsHex != "" {
-		// Don't just print hex again
-		*verbose = true
+	if len(inFiles) > 0 {
+		os.Exit(cli.Run(opts, inFiles, os.Stdout, logger, func(inFilePath string, w io.Writer) error {
+			return listFileParameterSets(inFilePath, *codec, *verbose, w)
+		}))
 	}
 
+	// Now we have hex case left. Don't just print hex again
+	err = printHexParameterSets(*vpsHex, *spsHex, *ppsHex, *codec, os.Stdout)
+	if err != nil {
+		logger.Errorf("%s", err)
+		os.Exit(cli.ExitFailure)
+	}
+}
+
+func listFileParameterSets(inFilePath, codec string, verbose bool, w io.Writer) error {
+	ifd, err := os.Open(inFilePath)
+	if err != nil {
+		return err
+	}
+	defer ifd.Close()
+	mp4Extensions := []string{".mp4", ".m4v", ".cmfv"}
+	for _, ext := range mp4Extensions {
+		if strings.HasSuffix(inFilePath, ext) {
+			return parseMp4File(ifd, verbose, w)
+		}
+	}
+	// Assume bytestream
+	nalus, err := getNalusFromBytestream(ifd)
+	if err != nil {
+		return err
+	}
 	var vpsNalus [][]byte
 	var spsNalus [][]byte
 	var ppsNalus [][]byte
-
-	if *inFile != "" {
-		ifd, err := os.Open(*inFile)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		defer ifd.Close()
-		mp4Extensions := []string{".mp4", ".m4v", ".cmfv"}
-		for _, ext := range mp4Extensions {
-			if strings.HasSuffix(*inFile, ext) {
-				parseMp4File(ifd, *verbose)
-				return
-			}
-		}
-		// Assume bytestream
-		nalus, err := getNalusFromBytestream(ifd)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		if *codec == "avc" {
-			for _, nalu := range nalus {
-				switch avc.NaluType(nalu[0]) {
-				case avc.NALU_SPS:
-					if len(ppsNalus) > 0 {
-						break // SPS coming back again
-					}
-					spsNalus = append(spsNalus, nalu)
-				case avc.NALU_PPS:
-					ppsNalus = append(ppsNalus, nalu)
-				}
-			}
-			printAvcPS(spsNalus, ppsNalus, *verbose)
-			return
-		}
-
-		// hevc
+	if codec == "avc" {
 		for _, nalu := range nalus {
-			switch hevc.NaluType(nalu[0]) {
-			case hevc.NALU_VPS:
-				if len(spsNalus) > 0 {
-					break // VPS coming back again
+			switch avc.NaluType(nalu[0]) {
+			case avc.NALU_SPS:
+				if len(ppsNalus) > 0 {
+					break // SPS coming back again
 				}
-				vpsNalus = append(vpsNalus, nalu)
-			case hevc.NALU_SPS:
 				spsNalus = append(spsNalus, nalu)
-			case hevc.NALU_PPS:
+			case avc.NALU_PPS:
 				ppsNalus = append(ppsNalus, nalu)
 			}
 		}
-		printHevcPS(vpsNalus, spsNalus, ppsNalus, *verbose)
-		return
+		return printAvcPS(spsNalus, ppsNalus, verbose, w)
 	}
-	// Now we have hex case left
-	switch *codec {
+
+	// hevc
+	for _, nalu := range nalus {
+		switch hevc.NaluType(nalu[0]) {
+		case hevc.NALU_VPS:
+			if len(spsNalus) > 0 {
+				break // VPS coming back again
+			}
+			vpsNalus = append(vpsNalus, nalu)
+		case hevc.NALU_SPS:
+			spsNalus = append(spsNalus, nalu)
+		case hevc.NALU_PPS:
+			ppsNalus = append(ppsNalus, nalu)
+		}
+	}
+	return printHevcPS(vpsNalus, spsNalus, ppsNalus, verbose, w)
+}
+
+func printHexParameterSets(vpsHex, spsHex, ppsHex, codec string, w io.Writer) error {
+	var vpsNalus [][]byte
+	var spsNalus [][]byte
+	var ppsNalus [][]byte
+	switch codec {
 	case "avc":
-		spsNalu, err := hex.DecodeString(*spsHex)
+		spsNalu, err := hex.DecodeString(spsHex)
 		if err != nil {
-			log.Fatalln("Could not parse sps")
+			return fmt.Errorf("could not parse sps")
 		}
 		spsNalus = append(spsNalus, spsNalu)
-		if *ppsHex != "" {
-			ppsNalu, err := hex.DecodeString(*ppsHex)
+		if ppsHex != "" {
+			ppsNalu, err := hex.DecodeString(ppsHex)
 			if err != nil {
-				log.Fatalln("Could not parse pps")
+				return fmt.Errorf("could not parse pps")
 			}
 			ppsNalus = append(ppsNalus, ppsNalu)
 		}
-		printAvcPS(spsNalus, ppsNalus, *verbose)
+		return printAvcPS(spsNalus, ppsNalus, true, w)
 	case "hevc":
-		vpsNalu, err := hex.DecodeString(*vpsHex)
+		vpsNalu, err := hex.DecodeString(vpsHex)
 		if err != nil {
-			log.Fatalln("Could not parse vps")
+			return fmt.Errorf("could not parse vps")
 		}
 		vpsNalus = append(vpsNalus, vpsNalu)
-		spsNalu, err := hex.DecodeString(*spsHex)
+		spsNalu, err := hex.DecodeString(spsHex)
 		if err != nil {
-			log.Fatalln("Could not parse sps")
+			return fmt.Errorf("could not parse sps")
 		}
 		if len(spsNalu) > 0 {
 			spsNalus = append(spsNalus, spsNalu)
 		}
-		ppsNalu, err := hex.DecodeString(*ppsHex)
+		ppsNalu, err := hex.DecodeString(ppsHex)
 		if err != nil {
-			log.Fatalln("Could not parse pps")
+			return fmt.Errorf("could not parse pps")
 		}
 		if len(ppsNalu) > 0 {
 			ppsNalus = append(ppsNalus, ppsNalu)
 		}
-		printHevcPS(vpsNalus, spsNalus, ppsNalus, *verbose)
+		return printHevcPS(vpsNalus, spsNalus, ppsNalus, true, w)
 	default:
-		log.Fatalln("Unknown codec ", *codec)
+		return fmt.Errorf("unknown codec %s", codec)
 	}
 }
 
 func getNalusFromBytestream(f io.Reader) ([][]byte, error) {
 	byteStream, err := ioutil.ReadAll(f)
-	if err != nil {
-		log.Fatalln(err)
-	}
-	nalus := avc.ExtractNalusFromByteStream(byteStream)
 	if err != nil {
 		return nil, err
 	}
-	return nalus, nil
+	return avc.ExtractNalusFromByteStream(byteStream), nil
 }
 
-func parseMp4File(r io.Reader, verbose bool) {
+func parseMp4File(r io.Reader, verbose bool, w io.Writer) error {
 	parsedMp4, err := mp4.DecodeFile(r)
 	if err != nil {
-		log.Fatalln(err)
+		return err
 	}
 
 	if parsedMp4.Moov == nil {
-		log.Fatalln("No moov box found in file")
+		return fmt.Errorf("no moov box found in file")
 	}
 
 	found := false
@@ -204,72 +223,91 @@ func parseMp4File(r io.Reader, verbose bool) {
 			found = true
 			trackID := trak.Tkhd.TrackID
 			if verbose {
-				fmt.Printf("Video %s track ID=%d\n", codec, trackID)
+				fmt.Fprintf(w, "Video %s track ID=%d\n", codec, trackID)
 			}
 			switch codec {
 			case "avc":
 				spsNalus := stsd.AvcX.AvcC.SPSnalus
 				ppsNalus := stsd.AvcX.AvcC.PPSnalus
-				printAvcPS(spsNalus, ppsNalus, verbose)
+				err = printAvcPS(spsNalus, ppsNalus, verbose, w)
 			case "hevc":
 				vpsNalus := stsd.HvcX.HvcC.GetNalusForType(hevc.NALU_VPS)
 				spsNalus := stsd.HvcX.HvcC.GetNalusForType(hevc.NALU_SPS)
 				ppsNalus := stsd.HvcX.HvcC.GetNalusForType(hevc.NALU_PPS)
-				printHevcPS(vpsNalus, spsNalus, ppsNalus, verbose)
+				err = printHevcPS(vpsNalus, spsNalus, ppsNalus, verbose, w)
+			}
+			if err != nil {
+				return err
 			}
 		}
 	}
 	if !found {
-		fmt.Println("No parsable video track found")
+		fmt.Fprintln(w, "No parsable video track found")
 	}
+	return nil
 }
 
-func printAvcPS(spsNalus, ppsNalus [][]byte, verbose bool) {
+func printAvcPS(spsNalus, ppsNalus [][]byte, verbose bool, w io.Writer) error {
 	var spsInfo *avc.SPS
 	for i, spsNalu := range spsNalus {
 		spsInfo, err := avc.ParseSPSNALUnit(spsNalu, true /*fullVui*/)
 		if err != nil {
-			fmt.Println("Could not parse SPS")
-			return
+			return fmt.Errorf("could not parse SPS: %w", err)
+		}
+		err = printPS("SPS", i+1, spsNalu, spsInfo, verbose, w)
+		if err != nil {
+			return err
 		}
-		printPS("SPS", i+1, spsNalu, spsInfo, verbose)
 	}
 	for i, ppsNalu := range ppsNalus {
 		ppsInfo, err := avc.ParsePPSNALUnit(ppsNalu, spsInfo)
 		if err != nil {
-			fmt.Println("Could not parse PPS")
-			return
+			return fmt.Errorf("could not parse PPS: %w", err)
+		}
+		err = printPS("PPS", i+1, ppsNalu, ppsInfo, verbose, w)
+		if err != nil {
+			return err
 		}
-		printPS("PPS", i+1, ppsNalu, ppsInfo, verbose)
 	}
+	return nil
 }
 
-func printHevcPS(vpsNalus, spsNalus, ppsNalus [][]byte, verbose bool) {
+func printHevcPS(vpsNalus, spsNalus, ppsNalus [][]byte, verbose bool, w io.Writer) error {
 	for i, vps := range vpsNalus {
-		printPS("VPS", i+1, vps, nil, false)
+		err := printPS("VPS", i+1, vps, nil, false, w)
+		if err != nil {
+			return err
+		}
 	}
 	for i, sps := range spsNalus {
 		spsInfo, err := hevc.ParseSPSNALUnit(sps)
 		if err != nil {
-			fmt.Println("Could not parse SPS")
-			return
+			return fmt.Errorf("could not parse SPS: %w", err)
+		}
+		err = printPS("SPS", i+1, sps, spsInfo, verbose, w)
+		if err != nil {
+			return err
 		}
-		printPS("SPS", i+1, sps, spsInfo, verbose)
 	}
 	for i, pps := range ppsNalus {
-		printPS("PPS", i+1, pps, nil, false)
+		err := printPS("PPS", i+1, pps, nil, false, w)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func printPS(name string, nr int, ps []byte, psInfo interface{}, verbose bool) {
+func printPS(name string, nr int, ps []byte, psInfo interface{}, verbose bool, w io.Writer) error {
 	hexStr := hex.EncodeToString(ps)
 	length := len(hexStr) / 2
-	fmt.Printf("%s %d len %dB: %+v\n", name, nr, length, hexStr)
+	fmt.Fprintf(w, "%s %d len %dB: %+v\n", name, nr, length, hexStr)
 	if verbose && psInfo != nil {
 		jsonPS, err := json.MarshalIndent(psInfo, "", "  ")
 		if err != nil {
-			log.Fatalf(err.Error())
+			return err
 		}
-		fmt.Printf("%s\n", string(jsonPS))
+		fmt.Fprintf(w, "%s\n", string(jsonPS))
 	}
+	return nil
 }
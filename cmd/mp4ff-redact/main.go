@@ -0,0 +1,81 @@
+// mp4ff-redact removes the sample data of mp4 files to make shareable files for bug reports.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/edgeware/mp4ff/cmd/internal/cli"
+	"github.com/edgeware/mp4ff/mp4"
+)
+
+var usg = `Usage of mp4ff-redact:
+
+mp4ff-redact writes a copy of an mp4 file where the sample data in mdat boxes is removed,
+while all other boxes are kept as they are. The result can be shared in bug reports without
+leaking the content.
+  zero     - replace the sample data with zeros, keeping all sizes and offsets
+  truncate - drop the sample data, keeping empty mdat boxes
+
+`
+
+var usage = func() {
+	parts := strings.Split(os.Args[0], "/")
+	name := parts[len(parts)-1]
+	fmt.Fprintln(os.Stderr, usg)
+	fmt.Fprintf(os.Stderr, "%s [-mode zero|truncate] -i <inFile> -o <outFile>\n", name)
+	flag.PrintDefaults()
+}
+
+func main() {
+
+	inFilePath := flag.String("i", "", "Required: Path to input mp4 file")
+	outFilePath := flag.String("o", "", "Required: Path to output mp4 file")
+	mode := flag.String("mode", "zero", "Redaction mode: zero or truncate")
+	opts := cli.AddFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.Parse()
+
+	if *inFilePath == "" || *outFilePath == "" {
+		usage()
+		os.Exit(cli.ExitUsage)
+	}
+	var redactMode mp4.RedactMode
+	switch *mode {
+	case "zero":
+		redactMode = mp4.RedactZero
+	case "truncate":
+		redactMode = mp4.RedactTruncate
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown mode %q\n", *mode)
+		os.Exit(cli.ExitUsage)
+	}
+	logger, err := opts.Logger(os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(cli.ExitUsage)
+	}
+
+	os.Exit(cli.Run(opts, []string{*inFilePath}, os.Stdout, logger, func(inFilePath string, w io.Writer) error {
+		return redact(inFilePath, *outFilePath, redactMode)
+	}))
+}
+
+func redact(inFilePath, outFilePath string, mode mp4.RedactMode) error {
+	ifd, err := os.Open(inFilePath)
+	if err != nil {
+		return err
+	}
+	defer ifd.Close()
+	// Decode lazily, so that the sample data is never read, and encode the box tree as it is
+	parsedMp4, err := mp4.DecodeFile(ifd, mp4.WithDecodeMode(mp4.DecModeLazyMdat),
+		mp4.WithEncodeMode(mp4.EncModeBoxTree), mp4.WithRedaction(mode))
+	if err != nil {
+		return err
+	}
+
+	return parsedMp4.WriteToFile(outFilePath)
+}
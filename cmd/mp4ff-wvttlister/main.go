@@ -5,10 +5,11 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/edgeware/mp4ff/cmd/internal/cli"
 	"github.com/edgeware/mp4ff/mp4"
 )
 
@@ -22,7 +23,7 @@ var usage = func() {
 	parts := strings.Split(os.Args[0], "/")
 	name := parts[len(parts)-1]
 	fmt.Fprintln(os.Stderr, usg)
-	fmt.Fprintf(os.Stderr, "%s [-m <max>] [-t <trackID> <mp4File>\n", name)
+	fmt.Fprintf(os.Stderr, "%s [-m <max>] [-t <trackID>] [-workers n] [-progress] [-loglevel level] <mp4File> [<mp4File> ...]\n", name)
 	flag.PrintDefaults()
 }
 
@@ -30,45 +31,41 @@ func main() {
 	maxNrSamples := flag.Int("m", -1, "Max nr of samples to parse")
 	trackID := flag.Int("t", 0, "trackID to extract (0 is unspecified)")
 	version := flag.Bool("version", false, "Get mp4ff version")
+	opts := cli.AddFlags(flag.CommandLine)
 
 	flag.Parse()
 
 	if *version {
 		fmt.Printf("mp4ff-wvttlister %s\n", mp4.GetVersion())
-		os.Exit(0)
+		os.Exit(cli.ExitOK)
 	}
 
-	var inFilePath = flag.Arg(0)
-	if inFilePath == "" {
+	if flag.NArg() == 0 {
 		usage()
-		os.Exit(1)
+		os.Exit(cli.ExitUsage)
 	}
-
-	ifd, err := os.Open(inFilePath)
+	logger, err := opts.Logger(os.Stderr)
 	if err != nil {
-		log.Fatalln(err)
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(cli.ExitUsage)
 	}
-	defer ifd.Close()
-	parsedMp4, err := mp4.DecodeFile(ifd)
+
+	os.Exit(cli.Run(opts, flag.Args(), os.Stdout, logger, func(inFilePath string, w io.Writer) error {
+		return listWvttSamples(inFilePath, uint32(*trackID), *maxNrSamples, w)
+	}))
+}
+
+func listWvttSamples(inFilePath string, trackID uint32, maxNrSamples int, w io.Writer) error {
+	parsedMp4, err := mp4.ReadFile(inFilePath)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	if !parsedMp4.IsFragmented() { // Progressive file
-		err = parseProgressiveMp4(parsedMp4, uint32(*trackID), *maxNrSamples)
-		if err != nil {
-			fmt.Printf("Error: %s\n", err)
-			os.Exit(1)
-		}
-		return
+		return parseProgressiveMp4(parsedMp4, trackID, maxNrSamples, w)
 	}
-
 	// Fragmented file
-	err = parseFragmentedMp4(parsedMp4, uint32(*trackID), *maxNrSamples)
-	if err != nil {
-		fmt.Printf("Error: %s\n", err)
-		os.Exit(1)
-	}
+	return parseFragmentedMp4(parsedMp4, trackID, maxNrSamples, w)
 }
 
 func findTrack(moov *mp4.MoovBox, hdlrType string, trackID uint32) (*mp4.TrakBox, error) {
@@ -87,7 +84,7 @@ func findTrack(moov *mp4.MoovBox, hdlrType string, trackID uint32) (*mp4.TrakBox
 	return nil, fmt.Errorf("No matching track found")
 }
 
-func parseProgressiveMp4(f *mp4.File, trackID uint32, maxNrSamples int) error {
+func parseProgressiveMp4(f *mp4.File, trackID uint32, maxNrSamples int, w io.Writer) error {
 	wvttTrak, err := findTrack(f.Moov, "text", trackID)
 	if err != nil {
 		return err
@@ -98,12 +95,16 @@ func parseProgressiveMp4(f *mp4.File, trackID uint32, maxNrSamples int) error {
 		return fmt.Errorf("No wvtt track found")
 	}
 
-	fmt.Printf("Track %d, timescale = %d\n", wvttTrak.Tkhd.TrackID, wvttTrak.Mdia.Mdhd.Timescale)
-	err = stbl.Stsd.Wvtt.VttC.Info(os.Stdout, "", "  ", "  ")
+	fmt.Fprintf(w, "Track %d, timescale = %d\n", wvttTrak.Tkhd.TrackID, wvttTrak.Mdia.Mdhd.Timescale)
+	err = stbl.Stsd.Wvtt.VttC.Info(w, "", "  ", "  ")
 	if err != nil {
 		return err
 	}
-	nrSamples := stbl.Stsz.SampleNumber
+	sizes := stbl.SampleSizes()
+	if sizes == nil {
+		return fmt.Errorf("no stsz or stz2 box")
+	}
+	nrSamples := sizes.GetNrSamples()
 	mdat := f.Mdat
 	mdatPayloadStart := mdat.PayloadAbsoluteOffset()
 	for sampleNr := 1; sampleNr <= int(nrSamples); sampleNr++ {
@@ -118,9 +119,9 @@ func parseProgressiveMp4(f *mp4.File, trackID uint32, maxNrSamples int) error {
 			offset = int64(stbl.Co64.ChunkOffset[chunkNr-1])
 		}
 		for sNr := sampleNrAtChunkStart; sNr < sampleNr; sNr++ {
-			offset += int64(stbl.Stsz.GetSampleSize(sNr))
+			offset += int64(sizes.GetSampleSize(sNr))
 		}
-		size := stbl.Stsz.GetSampleSize(sampleNr)
+		size := sizes.GetSampleSize(sampleNr)
 		decTime, dur := stbl.Stts.GetDecodeTime(uint32(sampleNr))
 		var cto int32 = 0
 		if stbl.Ctts != nil {
@@ -129,7 +130,7 @@ func parseProgressiveMp4(f *mp4.File, trackID uint32, maxNrSamples int) error {
 		// Next find sample bytes as slice in mdat
 		offsetInMdatData := uint64(offset) - mdatPayloadStart
 		sample := mdat.Data[offsetInMdatData : offsetInMdatData+uint64(size)]
-		err = printWvttSample(sample, sampleNr, decTime+uint64(cto), dur)
+		err = printWvttSample(sample, sampleNr, decTime+uint64(cto), dur, w)
 		if err != nil {
 			return err
 		}
@@ -140,7 +141,7 @@ func parseProgressiveMp4(f *mp4.File, trackID uint32, maxNrSamples int) error {
 	return nil
 }
 
-func parseFragmentedMp4(f *mp4.File, trackID uint32, maxNrSamples int) error {
+func parseFragmentedMp4(f *mp4.File, trackID uint32, maxNrSamples int, w io.Writer) error {
 	var wvttTrex *mp4.TrexBox
 	if f.Init != nil { // Print vttC header and timescale if moov-box is present
 		wvttTrak, err := findTrack(f.Init.Moov, "text", trackID)
@@ -153,16 +154,12 @@ func parseFragmentedMp4(f *mp4.File, trackID uint32, maxNrSamples int) error {
 			return fmt.Errorf("No wvtt track found")
 		}
 
-		fmt.Printf("Track %d, timescale = %d\n", wvttTrak.Tkhd.TrackID, wvttTrak.Mdia.Mdhd.Timescale)
-		err = stbl.Stsd.Wvtt.VttC.Info(os.Stdout, "", "  ", "  ")
+		fmt.Fprintf(w, "Track %d, timescale = %d\n", wvttTrak.Tkhd.TrackID, wvttTrak.Mdia.Mdhd.Timescale)
+		err = stbl.Stsd.Wvtt.VttC.Info(w, "", "  ", "  ")
 		if err != nil {
 			return err
 		}
-		for _, trex := range f.Init.Moov.Mvex.Trexs {
-			if trex.TrackID == wvttTrak.Tkhd.TrackID {
-				wvttTrex = trex
-			}
-		}
+		wvttTrex = f.TrexForTrack(wvttTrak.Tkhd.TrackID)
 	}
 	iSamples := make([]mp4.FullSample, 0)
 	for _, iSeg := range f.Segments {
@@ -176,7 +173,7 @@ func parseFragmentedMp4(f *mp4.File, trackID uint32, maxNrSamples int) error {
 	}
 	var err error
 	for i, sample := range iSamples {
-		err = printWvttSample(sample.Data, i+1, sample.PresentationTime(), sample.Dur)
+		err = printWvttSample(sample.Data, i+1, sample.PresentationTime(), sample.Dur, w)
 
 		if err != nil {
 			return err
@@ -188,12 +185,12 @@ func parseFragmentedMp4(f *mp4.File, trackID uint32, maxNrSamples int) error {
 	return nil
 }
 
-func printWvttSample(sample []byte, nr int, pts uint64, dur uint32) error {
-	fmt.Printf("Sample %d, pts=%d, dur=%d\n", nr, pts, dur)
+func printWvttSample(sample []byte, nr int, pts uint64, dur uint32, w io.Writer) error {
+	fmt.Fprintf(w, "Sample %d, pts=%d, dur=%d\n", nr, pts, dur)
 	buf := bytes.NewBuffer(sample)
 	box, err := mp4.DecodeBox(0, buf)
 	if err != nil {
 		return err
 	}
-	return box.Info(os.Stdout, "", "  ", "  ")
+	return box.Info(w, "", "  ", "  ")
 }
@@ -1,6 +1,7 @@
 package hevc
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/go-test/deep"
@@ -108,3 +109,39 @@ func TestGetParameterSets(t *testing.T) {
 		}
 	}
 }
+
+func TestDecConfRecLengthSizeAndCompleteness(t *testing.T) {
+	hdcr := DecConfRec{
+		ConfigurationVersion: 1,
+		LengthSizeMinusOne:   3,
+		NaluArrays: []NaluArray{
+			*NewNaluArray(true, NALU_VPS, [][]byte{{64, 1}}),
+			*NewNaluArray(false, NALU_SPS, [][]byte{{66, 1}}),
+		},
+	}
+	if err := hdcr.SetNALULengthSize(2); err != nil {
+		t.Error(err)
+	}
+	hdcr.SetComplete(NALU_VPS, false)
+	hdcr.SetComplete(NALU_SPS, true)
+	buf := bytes.Buffer{}
+	if err := hdcr.Encode(&buf); err != nil {
+		t.Error(err)
+	}
+	got, err := DecodeHEVCDecConfRec(&buf)
+	if err != nil {
+		t.Error(err)
+	}
+	if got.NALULengthSize() != 2 {
+		t.Errorf("got NALU length size %d instead of 2", got.NALULengthSize())
+	}
+	if got.NaluArrays[0].Complete() != 0 || got.NaluArrays[1].Complete() != 1 {
+		t.Errorf("completeness flags not as set")
+	}
+	if got.NaluArrays[1].NaluType() != NALU_SPS {
+		t.Errorf("nalu type changed by SetComplete")
+	}
+	if err := hdcr.SetNALULengthSize(3); err != ErrLengthSize {
+		t.Errorf("length size 3 should give ErrLengthSize")
+	}
+}
@@ -11,7 +11,7 @@ import (
 
 // HEVC errors
 var (
-	ErrLengthSize = errors.New("Can only handle 4byte NALU length size")
+	ErrLengthSize = errors.New("NALU length size must be 1, 2, or 4 bytes")
 )
 
 // DecConfRec - HEVCDecoderConfigurationRecord
@@ -65,6 +65,15 @@ func (n *NaluArray) Complete() byte {
 	return n.completeAndType >> 7
 }
 
+// SetComplete - set or clear array_completeness flag
+func (n *NaluArray) SetComplete(complete bool) {
+	if complete {
+		n.completeAndType |= 0x80
+	} else {
+		n.completeAndType &= 0x7f
+	}
+}
+
 // CreateHEVCDecConfRec - extract information from vps, sps, pps and fill HEVCDecConfRec with that
 func CreateHEVCDecConfRec(vpsNalus, spsNalus, ppsNalus [][]byte, vpsComplete, spsComplete, ppsComplete bool) (DecConfRec, error) {
 	sps, err := ParseSPSNALUnit(spsNalus[0])
@@ -93,7 +102,7 @@ func CreateHEVCDecConfRec(vpsNalus, spsNalus, ppsNalus [][]byte, vpsComplete, sp
 		ConstantFrameRate:                0,          // Set as default value
 		NumTemporalLayers:                0,          // Set as default value
 		TemporalIDNested:                 0,          // Set as default value
-		LengthSizeMinusOne:               3,          // only support 4-byte length
+		LengthSizeMinusOne:               3,          // 4-byte length by default
 		NaluArrays:                       naluArrays, // VPS, SPS, PPS nalus with complete flag
 	}, nil
 }
@@ -129,7 +138,7 @@ func DecodeHEVCDecConfRec(r io.Reader) (DecConfRec, error) {
 	hdcr.NumTemporalLayers = (aByte >> 3) & 0x7
 	hdcr.TemporalIDNested = (aByte >> 2) & 0x1
 	hdcr.LengthSizeMinusOne = aByte & 0x3
-	if hdcr.LengthSizeMinusOne != 3 {
+	if hdcr.LengthSizeMinusOne == 2 {
 		return hdcr, ErrLengthSize
 	}
 	numArrays := sr.ReadUint8()
@@ -201,3 +210,27 @@ func (h *DecConfRec) GetNalusForType(naluType NaluType) [][]byte {
 	}
 	return nil
 }
+
+// NALULengthSize - size in bytes of NALU length fields in samples
+func (h *DecConfRec) NALULengthSize() int {
+	return int(h.LengthSizeMinusOne) + 1
+}
+
+// SetNALULengthSize - set size in bytes (1, 2, or 4) of NALU length fields in samples.
+// The samples must be converted separately, e.g. with avc.ConvertNALULengthSize.
+func (h *DecConfRec) SetNALULengthSize(size int) error {
+	if size != 1 && size != 2 && size != 4 {
+		return ErrLengthSize
+	}
+	h.LengthSizeMinusOne = byte(size - 1)
+	return nil
+}
+
+// SetComplete - set array_completeness flag for all arrays of naluType
+func (h *DecConfRec) SetComplete(naluType NaluType, complete bool) {
+	for i := range h.NaluArrays {
+		if h.NaluArrays[i].NaluType() == naluType {
+			h.NaluArrays[i].SetComplete(complete)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package hevc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/edgeware/mp4ff/bits"
+)
+
+// levelLimit - limits from ISO/IEC 23008-2 Table A.8 and A.9
+type levelLimit struct {
+	levelIDC  byte   // 30 times the level number
+	maxLumaPs uint64 // Max luma picture size (samples)
+	maxLumaSr uint64 // Max luma sample rate (samples/s)
+}
+
+var levelLimits = []levelLimit{
+	{30, 36864, 552960},
+	{60, 122880, 3686400},
+	{63, 245760, 7372800},
+	{90, 552960, 16588800},
+	{93, 983040, 33177600},
+	{120, 2228224, 66846720},
+	{123, 2228224, 133693440},
+	{150, 8912896, 267386880},
+	{153, 8912896, 534773760},
+	{156, 8912896, 1069547520},
+	{180, 35651584, 1069547520},
+	{183, 35651584, 2139095040},
+	{186, 35651584, 4278190080},
+}
+
+// MinimumLevel - lowest general_level_idc that supports a picture size and frame rate.
+// Only picture size and luma sample rate limits are checked, not bitrate or tier.
+func MinimumLevel(width, height uint, frameRate float64) (byte, error) {
+	if width == 0 || height == 0 || frameRate <= 0 {
+		return 0, fmt.Errorf("bad video size %dx%d or frame rate %f", width, height, frameRate)
+	}
+	pictureSize := uint64(width) * uint64(height)
+	sampleRate := float64(pictureSize) * frameRate
+	for _, l := range levelLimits {
+		maxDim := uint(math.Sqrt(float64(8 * l.maxLumaPs)))
+		if pictureSize <= l.maxLumaPs && width <= maxDim && height <= maxDim && sampleRate <= float64(l.maxLumaSr) {
+			return l.levelIDC, nil
+		}
+	}
+	return 0, fmt.Errorf("no level supports %dx%d at %.2f fps", width, height, frameRate)
+}
+
+// Positions of general_level_idc in the RBSP of VPS and SPS NAL units including the two-byte NAL unit header
+const (
+	vpsGeneralLevelPos = 17
+	spsGeneralLevelPos = 14
+)
+
+// SetVPSLevel - return a copy of the VPS NAL unit vps with general_level_idc set to level.
+// Sub-layer levels are not changed.
+func SetVPSLevel(vps []byte, level byte) ([]byte, error) {
+	if len(vps) < 2 || GetNaluType(vps[0]) != NALU_VPS {
+		return nil, fmt.Errorf("not a VPS NAL unit")
+	}
+	return setRBSPByte(vps, vpsGeneralLevelPos, level)
+}
+
+// SetSPSLevel - return a copy of the SPS NAL unit sps with general_level_idc set to level.
+// Sub-layer levels are not changed.
+func SetSPSLevel(sps []byte, level byte) ([]byte, error) {
+	if len(sps) < 2 || GetNaluType(sps[0]) != NALU_SPS {
+		return nil, fmt.Errorf("not an SPS NAL unit")
+	}
+	return setRBSPByte(sps, spsGeneralLevelPos, level)
+}
+
+// setRBSPByte - return nalu with the RBSP byte at pos set to value and emulation prevention bytes redone
+func setRBSPByte(nalu []byte, pos int, value byte) ([]byte, error) {
+	rbsp := bits.EBSP2rbsp(nalu)
+	if pos >= len(rbsp) {
+		return nil, fmt.Errorf("NAL unit too short: %d bytes", len(rbsp))
+	}
+	rbsp[pos] = value
+	out := make([]byte, 0, len(nalu)+1)
+	nrZeros := 0
+	for _, b := range rbsp {
+		if nrZeros == 2 && b <= 3 {
+			out = append(out, 3)
+			nrZeros = 0
+		}
+		out = append(out, b)
+		if b == 0 {
+			nrZeros++
+		} else {
+			nrZeros = 0
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,58 @@
+package hevc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMinimumLevel(t *testing.T) {
+	testCases := []struct {
+		width, height uint
+		frameRate     float64
+		wanted        byte
+	}{
+		{416, 240, 30, 60},
+		{1280, 720, 30, 93},
+		{1920, 1080, 30, 120},
+		{1920, 1080, 60, 123},
+		{3840, 2160, 30, 150},
+		{3840, 2160, 60, 153},
+	}
+	for _, tc := range testCases {
+		got, err := MinimumLevel(tc.width, tc.height, tc.frameRate)
+		if err != nil {
+			t.Error(err)
+		}
+		if got != tc.wanted {
+			t.Errorf("%dx%d@%.0f: got level %d instead of %d", tc.width, tc.height, tc.frameRate, got, tc.wanted)
+		}
+	}
+	if _, err := MinimumLevel(1920, 1080, 0); err == nil {
+		t.Errorf("zero frame rate should give error")
+	}
+}
+
+func TestSetRBSPByte(t *testing.T) {
+	testCases := []struct {
+		nalu   []byte
+		pos    int
+		value  byte
+		wanted []byte
+	}{
+		{[]byte{0x40, 0x01, 0x00, 0x00, 0x05}, 4, 0x01, []byte{0x40, 0x01, 0x00, 0x00, 0x03, 0x01}},
+		{[]byte{0x40, 0x01, 0x00, 0x00, 0x03, 0x01}, 4, 0x05, []byte{0x40, 0x01, 0x00, 0x00, 0x05}},
+		{[]byte{0x40, 0x01, 0x00, 0x00, 0x03, 0x00, 0x7b}, 3, 0x01, []byte{0x40, 0x01, 0x00, 0x01, 0x00, 0x7b}},
+	}
+	for _, tc := range testCases {
+		got, err := setRBSPByte(tc.nalu, tc.pos, tc.value)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(got, tc.wanted) {
+			t.Errorf("got %x instead of %x", got, tc.wanted)
+		}
+	}
+	if _, err := SetSPSLevel([]byte{0x40, 0x01, 0x0c}, 93); err == nil {
+		t.Errorf("VPS NAL unit should give error")
+	}
+}
@@ -0,0 +1,126 @@
+package hevc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/edgeware/mp4ff/bits"
+)
+
+// PPS - HEVC PPS parameters
+// ISO/IEC 23008-2 Sec. 7.3.2.3
+// Parsing stops at scaling list data and PPS extensions.
+type PPS struct {
+	PicParameterSetID                      uint32
+	SeqParameterSetID                      uint32
+	DependentSliceSegmentsEnabledFlag      bool
+	OutputFlagPresentFlag                  bool
+	NumExtraSliceHeaderBits                byte
+	SignDataHidingEnabledFlag              bool
+	CabacInitPresentFlag                   bool
+	NumRefIdxL0DefaultActiveMinus1         uint32
+	NumRefIdxL1DefaultActiveMinus1         uint32
+	InitQpMinus26                          int32
+	ConstrainedIntraPredFlag               bool
+	TransformSkipEnabledFlag               bool
+	CuQpDeltaEnabledFlag                   bool
+	DiffCuQpDeltaDepth                     uint32
+	CbQpOffset                             int32
+	CrQpOffset                             int32
+	SliceChromaQpOffsetsPresentFlag        bool
+	WeightedPredFlag                       bool
+	WeightedBipredFlag                     bool
+	TransquantBypassEnabledFlag            bool
+	TilesEnabledFlag                       bool
+	EntropyCodingSyncEnabledFlag           bool
+	NumTileColumnsMinus1                   uint32
+	NumTileRowsMinus1                      uint32
+	UniformSpacingFlag                     bool
+	ColumnWidthMinus1                      []uint32
+	RowHeightMinus1                        []uint32
+	LoopFilterAcrossTilesEnabledFlag       bool
+	LoopFilterAcrossSlicesEnabledFlag      bool
+	DeblockingFilterControlPresentFlag     bool
+	DeblockingFilterOverrideEnabledFlag    bool
+	DeblockingFilterDisabledFlag           bool
+	BetaOffsetDiv2                         int32
+	TcOffsetDiv2                           int32
+	ScalingListDataPresentFlag             bool
+	ListsModificationPresentFlag           bool
+	Log2ParallelMergeLevelMinus2           uint32
+	SliceSegmentHeaderExtensionPresentFlag bool
+	ExtensionPresentFlag                   bool
+}
+
+// ParsePPSNALUnit - Parse HEVC PPS NAL unit starting with NAL unit header
+func ParsePPSNALUnit(data []byte) (*PPS, error) {
+	pps := &PPS{}
+
+	rd := bytes.NewReader(data)
+	r := bits.NewAccErrEBSPReader(rd)
+	// Note! First two bytes are NALU Header
+
+	naluHdrBits := r.Read(16)
+	naluType := GetNaluType(byte(naluHdrBits >> 8))
+	if naluType != NALU_PPS {
+		return nil, fmt.Errorf("NALU type is %s not PPS", naluType)
+	}
+	pps.PicParameterSetID = uint32(r.ReadExpGolomb())
+	pps.SeqParameterSetID = uint32(r.ReadExpGolomb())
+	pps.DependentSliceSegmentsEnabledFlag = r.ReadFlag()
+	pps.OutputFlagPresentFlag = r.ReadFlag()
+	pps.NumExtraSliceHeaderBits = byte(r.Read(3))
+	pps.SignDataHidingEnabledFlag = r.ReadFlag()
+	pps.CabacInitPresentFlag = r.ReadFlag()
+	pps.NumRefIdxL0DefaultActiveMinus1 = uint32(r.ReadExpGolomb())
+	pps.NumRefIdxL1DefaultActiveMinus1 = uint32(r.ReadExpGolomb())
+	pps.InitQpMinus26 = int32(r.ReadSignedGolomb())
+	pps.ConstrainedIntraPredFlag = r.ReadFlag()
+	pps.TransformSkipEnabledFlag = r.ReadFlag()
+	pps.CuQpDeltaEnabledFlag = r.ReadFlag()
+	if pps.CuQpDeltaEnabledFlag {
+		pps.DiffCuQpDeltaDepth = uint32(r.ReadExpGolomb())
+	}
+	pps.CbQpOffset = int32(r.ReadSignedGolomb())
+	pps.CrQpOffset = int32(r.ReadSignedGolomb())
+	pps.SliceChromaQpOffsetsPresentFlag = r.ReadFlag()
+	pps.WeightedPredFlag = r.ReadFlag()
+	pps.WeightedBipredFlag = r.ReadFlag()
+	pps.TransquantBypassEnabledFlag = r.ReadFlag()
+	pps.TilesEnabledFlag = r.ReadFlag()
+	pps.EntropyCodingSyncEnabledFlag = r.ReadFlag()
+	if pps.TilesEnabledFlag {
+		pps.NumTileColumnsMinus1 = uint32(r.ReadExpGolomb())
+		pps.NumTileRowsMinus1 = uint32(r.ReadExpGolomb())
+		pps.UniformSpacingFlag = r.ReadFlag()
+		if !pps.UniformSpacingFlag {
+			for i := uint32(0); i < pps.NumTileColumnsMinus1; i++ {
+				pps.ColumnWidthMinus1 = append(pps.ColumnWidthMinus1, uint32(r.ReadExpGolomb()))
+			}
+			for i := uint32(0); i < pps.NumTileRowsMinus1; i++ {
+				pps.RowHeightMinus1 = append(pps.RowHeightMinus1, uint32(r.ReadExpGolomb()))
+			}
+		}
+		pps.LoopFilterAcrossTilesEnabledFlag = r.ReadFlag()
+	}
+	pps.LoopFilterAcrossSlicesEnabledFlag = r.ReadFlag()
+	pps.DeblockingFilterControlPresentFlag = r.ReadFlag()
+	if pps.DeblockingFilterControlPresentFlag {
+		pps.DeblockingFilterOverrideEnabledFlag = r.ReadFlag()
+		pps.DeblockingFilterDisabledFlag = r.ReadFlag()
+		if !pps.DeblockingFilterDisabledFlag {
+			pps.BetaOffsetDiv2 = int32(r.ReadSignedGolomb())
+			pps.TcOffsetDiv2 = int32(r.ReadSignedGolomb())
+		}
+	}
+	pps.ScalingListDataPresentFlag = r.ReadFlag()
+	if pps.ScalingListDataPresentFlag {
+		return pps, r.AccError() // Doesn't get any further now
+	}
+	pps.ListsModificationPresentFlag = r.ReadFlag()
+	pps.Log2ParallelMergeLevelMinus2 = uint32(r.ReadExpGolomb())
+	pps.SliceSegmentHeaderExtensionPresentFlag = r.ReadFlag()
+	pps.ExtensionPresentFlag = r.ReadFlag()
+
+	return pps, r.AccError()
+}
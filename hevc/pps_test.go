@@ -0,0 +1,40 @@
+package hevc
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+const (
+	ppsNalu = "4401c0252f053240"
+)
+
+func TestPPSParser(t *testing.T) {
+	byteData, _ := hex.DecodeString(ppsNalu)
+
+	wanted := PPS{
+		PicParameterSetID:                  0,
+		SeqParameterSetID:                  0,
+		NumRefIdxL0DefaultActiveMinus1:     1,
+		NumRefIdxL1DefaultActiveMinus1:     1,
+		InitQpMinus26:                      0,
+		CuQpDeltaEnabledFlag:               true,
+		DiffCuQpDeltaDepth:                 2,
+		EntropyCodingSyncEnabledFlag:       true,
+		DeblockingFilterControlPresentFlag: true,
+	}
+	got, err := ParsePPSNALUnit(byteData)
+	if err != nil {
+		t.Error("Error parsing PPS")
+	}
+	if diff := deep.Equal(*got, wanted); diff != nil {
+		t.Error(diff)
+	}
+	spsData, _ := hex.DecodeString(spsNalu)
+	_, err = ParsePPSNALUnit(spsData)
+	if err == nil {
+		t.Error("SPS NAL unit should not be parsed as PPS")
+	}
+}
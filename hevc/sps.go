@@ -61,6 +61,44 @@ type ProfileTierLevel struct {
 
 }
 
+// parseProfileTierLevel - parse profile_tier_level(1, maxSubLayersMinus1).
+// Sub-layer profiles and levels are skipped.
+func parseProfileTierLevel(r *bits.AccErrEBSPReader, maxSubLayersMinus1 byte) ProfileTierLevel {
+	ptl := ProfileTierLevel{}
+	ptl.GeneralProfileSpace = byte(r.Read(2))
+	ptl.GeneralTierFlag = r.ReadFlag()
+	ptl.GeneralProfileIDC = byte(r.Read(5))
+	ptl.GeneralProfileCompatibilityFlags = uint32(r.Read(32))
+	ptl.GeneralConstraintIndicatorFlags = uint64(r.Read(48))
+	ptl.GeneralProgressiveSourceFlag = ptl.GeneralConstraintIndicatorFlags&(1<<47) != 0
+	ptl.GeneralInterlacedSourceFlag = ptl.GeneralConstraintIndicatorFlags&(1<<46) != 0
+	ptl.GeneralNonPackedConstraintFlag = ptl.GeneralConstraintIndicatorFlags&(1<<45) != 0
+	ptl.GeneralFrameOnlyConstraintFlag = ptl.GeneralConstraintIndicatorFlags&(1<<44) != 0
+	ptl.GeneralLevelIDC = byte(r.Read(8))
+	if maxSubLayersMinus1 == 0 {
+		return ptl
+	}
+	subLayerProfilePresent := make([]bool, maxSubLayersMinus1)
+	subLayerLevelPresent := make([]bool, maxSubLayersMinus1)
+	for i := byte(0); i < maxSubLayersMinus1; i++ {
+		subLayerProfilePresent[i] = r.ReadFlag()
+		subLayerLevelPresent[i] = r.ReadFlag()
+	}
+	for i := maxSubLayersMinus1; i < 8; i++ {
+		_ = r.Read(2) // reserved_zero_2bits
+	}
+	for i := byte(0); i < maxSubLayersMinus1; i++ {
+		if subLayerProfilePresent[i] {
+			_ = r.Read(44) // 88 bits of sub-layer profile information
+			_ = r.Read(44)
+		}
+		if subLayerLevelPresent[i] {
+			_ = r.Read(8)
+		}
+	}
+	return ptl
+}
+
 // ConformanceWindow according to ISO/IEC 23008-2
 type ConformanceWindow struct {
 	LeftOffset   uint32
@@ -93,15 +131,7 @@ func ParseSPSNALUnit(data []byte) (*SPS, error) {
 	sps.VpsID = byte(r.Read(4))
 	sps.MaxSubLayersMinus1 = byte(r.Read(3))
 	sps.TemporalIDNestingFlag = r.ReadFlag()
-	sps.ProfileTierLevel.GeneralProfileSpace = byte(r.Read(2))
-	sps.ProfileTierLevel.GeneralTierFlag = r.ReadFlag()
-	sps.ProfileTierLevel.GeneralProfileIDC = byte(r.Read(5))
-	sps.ProfileTierLevel.GeneralProfileCompatibilityFlags = uint32(r.Read(32))
-	sps.ProfileTierLevel.GeneralConstraintIndicatorFlags = uint64(r.Read(48))
-	sps.ProfileTierLevel.GeneralLevelIDC = byte(r.Read(8))
-	if sps.MaxSubLayersMinus1 != 0 {
-		return sps, nil // Cannot parse any further
-	}
+	sps.ProfileTierLevel = parseProfileTierLevel(r, sps.MaxSubLayersMinus1)
 	sps.SpsID = byte(r.ReadExpGolomb())
 	sps.ChromaFormatIDC = byte(r.ReadExpGolomb())
 	if sps.ChromaFormatIDC == 3 {
@@ -122,9 +152,9 @@ func ParseSPSNALUnit(data []byte) (*SPS, error) {
 	sps.BitDepthChromaMinus8 = byte(r.ReadExpGolomb())
 	sps.Log2MaxPicOrderCntLsbMinus4 = byte(r.ReadExpGolomb())
 	sps.SubLayerOrderingInfoPresentFlag = r.ReadFlag()
-	startValue := byte(0)
+	startValue := sps.MaxSubLayersMinus1
 	if sps.SubLayerOrderingInfoPresentFlag {
-		startValue = sps.MaxSubLayersMinus1
+		startValue = 0
 	}
 	for i := startValue; i <= sps.MaxSubLayersMinus1; i++ {
 		sps.SubLayeringOrderingInfos = append(
@@ -24,10 +24,10 @@ func TestSPSParser1(t *testing.T) {
 			GeneralProfileIDC:                2,
 			GeneralProfileCompatibilityFlags: 536870912,
 			GeneralConstraintIndicatorFlags:  193514046488576,
-			GeneralProgressiveSourceFlag:     false,
+			GeneralProgressiveSourceFlag:     true,
 			GeneralInterlacedSourceFlag:      false,
-			GeneralNonPackedConstraintFlag:   false,
-			GeneralFrameOnlyConstraintFlag:   false,
+			GeneralNonPackedConstraintFlag:   true,
+			GeneralFrameOnlyConstraintFlag:   true,
 			GeneralLevelIDC:                  123,
 		},
 		SpsID:                   0,
@@ -0,0 +1,88 @@
+package hevc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/edgeware/mp4ff/bits"
+)
+
+// VPS - HEVC VPS parameters
+// ISO/IEC 23008-2 Sec. 7.3.2.1
+// Parsing stops before the HRD parameters.
+type VPS struct {
+	VpsID                           byte
+	BaseLayerInternalFlag           bool
+	BaseLayerAvailableFlag          bool
+	MaxLayersMinus1                 byte
+	MaxSubLayersMinus1              byte
+	TemporalIDNestingFlag           bool
+	ProfileTierLevel                ProfileTierLevel
+	SubLayerOrderingInfoPresentFlag bool
+	SubLayeringOrderingInfos        []SubLayerOrderingInfo
+	MaxLayerID                      byte
+	NumLayerSetsMinus1              uint32
+	TimingInfoPresentFlag           bool
+	NumUnitsInTick                  uint32
+	TimeScale                       uint32
+	PocProportionalToTimingFlag     bool
+	NumTicksPocDiffOneMinus1        uint32
+	NumHrdParameters                uint32
+}
+
+// ParseVPSNALUnit - Parse HEVC VPS NAL unit starting with NAL unit header
+func ParseVPSNALUnit(data []byte) (*VPS, error) {
+	vps := &VPS{}
+
+	rd := bytes.NewReader(data)
+	r := bits.NewAccErrEBSPReader(rd)
+	// Note! First two bytes are NALU Header
+
+	naluHdrBits := r.Read(16)
+	naluType := GetNaluType(byte(naluHdrBits >> 8))
+	if naluType != NALU_VPS {
+		return nil, fmt.Errorf("NALU type is %s not VPS", naluType)
+	}
+	vps.VpsID = byte(r.Read(4))
+	vps.BaseLayerInternalFlag = r.ReadFlag()
+	vps.BaseLayerAvailableFlag = r.ReadFlag()
+	vps.MaxLayersMinus1 = byte(r.Read(6))
+	vps.MaxSubLayersMinus1 = byte(r.Read(3))
+	vps.TemporalIDNestingFlag = r.ReadFlag()
+	if reserved := r.Read(16); reserved != 0xffff && r.AccError() == nil {
+		return nil, fmt.Errorf("VPS reserved bits are 0x%04x, not 0xffff", reserved)
+	}
+	vps.ProfileTierLevel = parseProfileTierLevel(r, vps.MaxSubLayersMinus1)
+	vps.SubLayerOrderingInfoPresentFlag = r.ReadFlag()
+	startValue := vps.MaxSubLayersMinus1
+	if vps.SubLayerOrderingInfoPresentFlag {
+		startValue = 0
+	}
+	for i := startValue; i <= vps.MaxSubLayersMinus1; i++ {
+		vps.SubLayeringOrderingInfos = append(
+			vps.SubLayeringOrderingInfos,
+			SubLayerOrderingInfo{
+				MaxDecPicBufferingMinus1: byte(r.ReadExpGolomb()),
+				MaxNumReorderPics:        byte(r.ReadExpGolomb()),
+				MaxLatencyIncreasePlus1:  byte(r.ReadExpGolomb()),
+			})
+	}
+	vps.MaxLayerID = byte(r.Read(6))
+	vps.NumLayerSetsMinus1 = uint32(r.ReadExpGolomb())
+	for i := uint32(1); i <= vps.NumLayerSetsMinus1; i++ {
+		for j := byte(0); j <= vps.MaxLayerID; j++ {
+			_ = r.ReadFlag() // layer_id_included_flag
+		}
+	}
+	vps.TimingInfoPresentFlag = r.ReadFlag()
+	if vps.TimingInfoPresentFlag {
+		vps.NumUnitsInTick = uint32(r.Read(32))
+		vps.TimeScale = uint32(r.Read(32))
+		vps.PocProportionalToTimingFlag = r.ReadFlag()
+		if vps.PocProportionalToTimingFlag {
+			vps.NumTicksPocDiffOneMinus1 = uint32(r.ReadExpGolomb())
+		}
+		vps.NumHrdParameters = uint32(r.ReadExpGolomb())
+	}
+	return vps, r.AccError()
+}
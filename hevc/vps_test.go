@@ -0,0 +1,60 @@
+package hevc
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+const (
+	vpsNalu = "40010c01ffff022000000300b0000003000003007b18b024"
+)
+
+func TestVPSParser(t *testing.T) {
+	byteData, _ := hex.DecodeString(vpsNalu)
+
+	wanted := VPS{
+		VpsID:                  0,
+		BaseLayerInternalFlag:  true,
+		BaseLayerAvailableFlag: true,
+		MaxLayersMinus1:        0,
+		MaxSubLayersMinus1:     0,
+		TemporalIDNestingFlag:  true,
+		ProfileTierLevel: ProfileTierLevel{
+			GeneralProfileSpace:              0,
+			GeneralTierFlag:                  false,
+			GeneralProfileIDC:                2,
+			GeneralProfileCompatibilityFlags: 536870912,
+			GeneralConstraintIndicatorFlags:  193514046488576,
+			GeneralProgressiveSourceFlag:     true,
+			GeneralInterlacedSourceFlag:      false,
+			GeneralNonPackedConstraintFlag:   true,
+			GeneralFrameOnlyConstraintFlag:   true,
+			GeneralLevelIDC:                  123,
+		},
+		SubLayerOrderingInfoPresentFlag: false,
+		SubLayeringOrderingInfos: []SubLayerOrderingInfo{
+			{
+				MaxDecPicBufferingMinus1: 5,
+				MaxNumReorderPics:        4,
+				MaxLatencyIncreasePlus1:  0,
+			},
+		},
+		MaxLayerID:            0,
+		NumLayerSetsMinus1:    0,
+		TimingInfoPresentFlag: false,
+	}
+	got, err := ParseVPSNALUnit(byteData)
+	if err != nil {
+		t.Error("Error parsing VPS")
+	}
+	if diff := deep.Equal(*got, wanted); diff != nil {
+		t.Error(diff)
+	}
+	spsData, _ := hex.DecodeString(spsNalu)
+	_, err = ParseVPSNALUnit(spsData)
+	if err == nil {
+		t.Error("SPS NAL unit should not be parsed as VPS")
+	}
+}
@@ -0,0 +1,86 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// audioSegmentStats - number of samples and duration of the audio track in a media segment
+type audioSegmentStats struct {
+	nrSamples uint32
+	duration  uint64
+}
+
+// VerifyAudioAlignment - verify that all audio renditions of a ladder are aligned, i.e. that they have
+// the same number of segments and, for each segment index, the same number of audio samples and the
+// same segment duration. Durations are compared in seconds, so the renditions may have different timescales.
+// The audio track of each rendition is the first track with handler type "soun" in its init segment.
+// The first divergence compared to the first rendition is reported as an error.
+func VerifyAudioAlignment(renditions []*File) error {
+	if len(renditions) == 0 {
+		return fmt.Errorf("no renditions")
+	}
+	stats := make([][]audioSegmentStats, len(renditions))
+	timescales := make([]uint32, len(renditions))
+	for i, f := range renditions {
+		var err error
+		stats[i], timescales[i], err = f.audioSegmentStats()
+		if err != nil {
+			return fmt.Errorf("rendition %d: %w", i, err)
+		}
+	}
+	ref := stats[0]
+	for i := 1; i < len(renditions); i++ {
+		for j := 0; j < len(ref) && j < len(stats[i]); j++ {
+			s, r := stats[i][j], ref[j]
+			if s.nrSamples != r.nrSamples {
+				return fmt.Errorf("segment %d: rendition %d has %d samples, but rendition 0 has %d",
+					j, i, s.nrSamples, r.nrSamples)
+			}
+			if s.duration*uint64(timescales[0]) != r.duration*uint64(timescales[i]) {
+				return fmt.Errorf("segment %d: rendition %d has duration %d/%d, but rendition 0 has %d/%d",
+					j, i, s.duration, timescales[i], r.duration, timescales[0])
+			}
+		}
+		if len(stats[i]) != len(ref) {
+			return fmt.Errorf("rendition %d has %d segments, but rendition 0 has %d", i, len(stats[i]), len(ref))
+		}
+	}
+	return nil
+}
+
+// audioSegmentStats - sample count and duration per segment for the first audio track, and its timescale
+func (f *File) audioSegmentStats() ([]audioSegmentStats, uint32, error) {
+	if !f.isFragmented {
+		return nil, 0, fmt.Errorf("file is not fragmented")
+	}
+	if f.Init == nil {
+		return nil, 0, fmt.Errorf("no init segment")
+	}
+	var audioTrak *TrakBox
+	for _, trak := range f.Init.Moov.Traks {
+		if trak.Mdia.Hdlr != nil && trak.Mdia.Hdlr.HandlerType == "soun" {
+			audioTrak = trak
+			break
+		}
+	}
+	if audioTrak == nil {
+		return nil, 0, fmt.Errorf("no audio track")
+	}
+	trackID := audioTrak.Tkhd.TrackID
+	trex := f.TrexForTrack(trackID)
+	stats := make([]audioSegmentStats, len(f.Segments))
+	for i, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			for _, traf := range frag.Moof.Trafs {
+				if traf.Tfhd.TrackID != trackID {
+					continue
+				}
+				for _, trun := range traf.Truns {
+					stats[i].duration += trun.AddSampleDefaultValues(traf.Tfhd, trex)
+					stats[i].nrSamples += trun.SampleCount()
+				}
+			}
+		}
+	}
+	return stats, audioTrak.Mdia.Mdhd.Timescale, nil
+}
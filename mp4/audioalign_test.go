@@ -0,0 +1,49 @@
+package mp4
+
+import (
+	"strings"
+	"testing"
+)
+
+func createAudioRendition(t *testing.T, timescale uint32, nrSamples int, dur uint32, segDur uint64) *File {
+	t.Helper()
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(timescale, "audio", "en")
+	samples := createProgTestSamples(nrSamples, dur, 100, 1, 0x10)
+	f, err := CreateOnDemandFile(init, NewSliceSampleSource(samples), segDur)
+	assertNoError(t, err)
+	return f
+}
+
+func TestVerifyAudioAlignment(t *testing.T) {
+	ref := createAudioRendition(t, 48000, 30, 1024, 10240)
+	testCases := []struct {
+		desc      string
+		rendition *File
+		wantedErr string
+	}{
+		{"other timescale", createAudioRendition(t, 96000, 30, 2048, 20480), ""},
+		{"other segment durations", createAudioRendition(t, 48000, 30, 1024, 20480), "segment 0: rendition 1 has 20 samples"},
+		{"other sample rate", createAudioRendition(t, 44100, 30, 1024, 10240), "segment 0: rendition 1 has duration 10240/44100"},
+		{"fewer segments", createAudioRendition(t, 48000, 20, 1024, 10240), "rendition 1 has 2 segments, but rendition 0 has 3"},
+	}
+	for _, tc := range testCases {
+		err := VerifyAudioAlignment([]*File{ref, tc.rendition})
+		if tc.wantedErr == "" {
+			if err != nil {
+				t.Errorf("%s: got error %q", tc.desc, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantedErr) {
+			t.Errorf("%s: got error %v instead of %q", tc.desc, err, tc.wantedErr)
+		}
+	}
+
+	video := CreateEmptyInit()
+	video.AddEmptyTrack(90000, "video", "und")
+	videoFile, err := CreateOnDemandFile(video, NewSliceSampleSource(createProgTestSamples(10, 3600, 100, 5, 0)), 18000)
+	assertNoError(t, err)
+	err = VerifyAudioAlignment([]*File{ref, videoFile})
+	assertError(t, err, "rendition without audio track should give error")
+}
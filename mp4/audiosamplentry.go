@@ -15,6 +15,13 @@ type AudioSampleEntryBox struct {
 	SampleSize         uint16
 	SampleRate         uint16 // Integer part
 	Esds               *EsdsBox
+	Dac3               *Dac3Box
+	Dec3               *Dec3Box
+	Dac4               *Dac4Box
+	Dops               *DopsBox
+	Dfla               *DflaBox
+	MhaC               *MhaCBox
+	Sinf               *SinfBox
 	Children           []Box
 }
 
@@ -52,11 +59,33 @@ func (a *AudioSampleEntryBox) AddChild(b Box) {
 	switch b.Type() {
 	case "esds":
 		a.Esds = b.(*EsdsBox)
+	case "dac3":
+		a.Dac3 = b.(*Dac3Box)
+	case "dec3":
+		a.Dec3 = b.(*Dec3Box)
+	case "dac4":
+		a.Dac4 = b.(*Dac4Box)
+	case "dOps":
+		a.Dops = b.(*DopsBox)
+	case "dfLa":
+		a.Dfla = b.(*DflaBox)
+	case "mhaC":
+		a.MhaC = b.(*MhaCBox)
+	case "sinf":
+		a.Sinf = b.(*SinfBox)
 	}
 
 	a.Children = append(a.Children, b)
 }
 
+// OriginalFormat - box type of entry, or original format from sinf/frma for encrypted (enca) entry
+func (a *AudioSampleEntryBox) OriginalFormat() string {
+	if a.Sinf != nil && a.Sinf.Frma != nil {
+		return a.Sinf.Frma.DataFormat
+	}
+	return a.name
+}
+
 const nrAudioSampleBytesBeforeChildren = 36
 
 // DecodeAudioSampleEntry - decode mp4a... box
@@ -119,6 +148,21 @@ func (a *AudioSampleEntryBox) Size() uint64 {
 	return totalSize
 }
 
+// GetChildren - list of child boxes
+func (a *AudioSampleEntryBox) GetChildren() []Box {
+	return a.Children
+}
+
+func (a *AudioSampleEntryBox) setChildren(children []Box) error {
+	n := AudioSampleEntryBox{name: a.name, DataReferenceIndex: a.DataReferenceIndex,
+		ChannelCount: a.ChannelCount, SampleSize: a.SampleSize, SampleRate: a.SampleRate}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*a = n
+	return nil
+}
+
 // Encode - write box to w
 func (a *AudioSampleEntryBox) Encode(w io.Writer) error {
 	err := EncodeHeader(a, w)
@@ -0,0 +1,92 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+)
+
+// AudioTransformResult - samples and configuration for a new AAC track created by an AudioTransform
+type AudioTransformResult struct {
+	ObjectType        byte // AAC audio object type, e.g. aac.AAClc
+	SamplingFrequency int  // Also used as track timescale
+	Samples           []FullSample
+}
+
+// AudioTransform - hook creating a new AAC track from the samples of an audio track, e.g. a downmixer.
+// The audio samples are passed as stored in the file, so any decoding and encoding is done by the hook.
+// Returning a nil result means that no new track is created for trak.
+type AudioTransform func(trak *TrakBox, samples []FullSample) (*AudioTransformResult, error)
+
+// SplitAudioByLanguage - split the audio tracks of a progressive or fragmented file into one progressive
+// file per language, as given by elng or mdhd. Sample data is copied as is without decoding.
+// rs is only needed if the mdat box of a progressive file was decoded lazily.
+// If transform is not nil, it is called for every audio track, and a returned new track is added
+// to the output after the original track.
+func SplitAudioByLanguage(f *File, rs io.ReadSeeker, il Interleaving, transform AudioTransform) (map[string]*File, error) {
+	if f.Moov == nil {
+		return nil, fmt.Errorf("no moov box")
+	}
+	inits := make(map[string]*InitSegment)
+	trackSamples := make(map[string]map[uint32][]FullSample)
+	for _, trak := range f.Moov.Traks {
+		if trak.Mdia.Hdlr.HandlerType != "soun" {
+			continue
+		}
+		lang := trak.Mdia.Mdhd.GetLanguage()
+		if trak.Mdia.Elng != nil {
+			lang = trak.Mdia.Elng.Language
+		}
+		var samples []FullSample
+		var err error
+		if f.isFragmented {
+			samples, err = fragmentedTrackSamples(f, trak.Tkhd.TrackID)
+		} else {
+			samples, err = progressiveTrackSamples(f, trak, rs)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("track %d: %w", trak.Tkhd.TrackID, err)
+		}
+		init, ok := inits[lang]
+		if !ok {
+			init = CreateEmptyInit()
+			inits[lang] = init
+			trackSamples[lang] = make(map[uint32][]FullSample)
+		}
+		init.AddEmptyTrack(trak.Mdia.Mdhd.Timescale, "audio", lang)
+		outTrak := init.Moov.Traks[len(init.Moov.Traks)-1]
+		err = outTrak.Mdia.Minf.Stbl.Stsd.copyEntries(trak.Mdia.Minf.Stbl.Stsd)
+		if err != nil {
+			return nil, err
+		}
+		trackSamples[lang][outTrak.Tkhd.TrackID] = samples
+		if transform == nil {
+			continue
+		}
+		res, err := transform(trak, samples)
+		if err != nil {
+			return nil, fmt.Errorf("transform of track %d: %w", trak.Tkhd.TrackID, err)
+		}
+		if res == nil {
+			continue
+		}
+		init.AddEmptyTrack(uint32(res.SamplingFrequency), "audio", lang)
+		newTrak := init.Moov.Traks[len(init.Moov.Traks)-1]
+		err = newTrak.SetAACDescriptor(res.ObjectType, res.SamplingFrequency)
+		if err != nil {
+			return nil, err
+		}
+		trackSamples[lang][newTrak.Tkhd.TrackID] = res.Samples
+	}
+	if len(inits) == 0 {
+		return nil, fmt.Errorf("no audio tracks")
+	}
+	files := make(map[string]*File, len(inits))
+	for lang, init := range inits {
+		out, err := CreateProgressiveFile(init, trackSamples[lang], il)
+		if err != nil {
+			return nil, fmt.Errorf("language %s: %w", lang, err)
+		}
+		files[lang] = out
+	}
+	return files, nil
+}
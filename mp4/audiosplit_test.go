@@ -0,0 +1,85 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSplitAudioByLanguage(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(48000, "audio", "swe")
+	init.AddEmptyTrack(48000, "audio", "eng")
+	init.AddEmptyTrack(48000, "audio", "eng")
+	for _, trak := range init.Moov.Traks[1:] {
+		assertNoError(t, trak.SetAACDescriptor(2, 48000))
+	}
+	inSamples := map[uint32][]FullSample{
+		1: createProgTestSamples(50, 3600, 500, 25, 0x10),
+		2: createProgTestSamples(94, 1024, 100, 1, 0x20),
+		3: createProgTestSamples(94, 1024, 110, 1, 0x30),
+		4: createProgTestSamples(94, 1024, 120, 1, 0x40),
+	}
+	f, err := CreateProgressiveFile(init, inSamples, Interleaving{Mode: InterleaveByDuration, Duration: 500 * time.Millisecond})
+	assertNoError(t, err)
+
+	// Downmix the Swedish track to half-size samples at 24kHz
+	downmix := func(trak *TrakBox, samples []FullSample) (*AudioTransformResult, error) {
+		if trak.Mdia.Mdhd.GetLanguage() != "swe" {
+			return nil, nil
+		}
+		res := &AudioTransformResult{ObjectType: 2, SamplingFrequency: 24000}
+		for _, s := range samples {
+			data := s.Data[:len(s.Data)/2]
+			res.Samples = append(res.Samples, FullSample{Sample: NewSample(s.Flags, s.Dur/2, uint32(len(data)), 0),
+				DecodeTime: s.DecodeTime / 2, Data: data})
+		}
+		return res, nil
+	}
+	files, err := SplitAudioByLanguage(f, nil, Interleaving{Mode: InterleaveBySize, ChunkSize: 1000}, downmix)
+	assertNoError(t, err)
+	if len(files) != 2 {
+		t.Fatalf("got %d files instead of 2", len(files))
+	}
+	wanted := map[string][][]FullSample{
+		"swe": {inSamples[2], nil},
+		"eng": {inSamples[3], inSamples[4]},
+	}
+	for lang, out := range files {
+		buf := bytes.Buffer{}
+		assertNoError(t, out.Encode(&buf))
+		decFile, err := DecodeFile(&buf)
+		assertNoError(t, err)
+		traks := decFile.Moov.Traks
+		if len(traks) != 2 {
+			t.Fatalf("%s: got %d tracks instead of 2", lang, len(traks))
+		}
+		for i, trak := range traks {
+			if trak.Mdia.Hdlr.HandlerType != "soun" || trak.Mdia.Mdhd.GetLanguage() != lang {
+				t.Errorf("%s: track %d is not %s audio", lang, i+1, lang)
+			}
+			samples := wanted[lang][i]
+			if samples == nil {
+				if trak.Mdia.Mdhd.Timescale != 24000 || trak.GetNrSamples() != 94 {
+					t.Errorf("%s: downmixed track has timescale %d and %d samples", lang, trak.Mdia.Mdhd.Timescale, trak.GetNrSamples())
+				}
+				continue
+			}
+			for nr := uint32(1); nr <= uint32(len(samples)); nr++ {
+				data := bytes.Buffer{}
+				assertNoError(t, decFile.CopySampleData(&data, nil, trak, nr, nr))
+				if !bytes.Equal(data.Bytes(), samples[nr-1].Data) {
+					t.Fatalf("%s track %d sample %d: data mismatch", lang, i+1, nr)
+				}
+			}
+		}
+	}
+
+	videoOnly := CreateEmptyInit()
+	videoOnly.AddEmptyTrack(90000, "video", "und")
+	vf, err := CreateProgressiveFile(videoOnly, map[uint32][]FullSample{1: inSamples[1]}, Interleaving{Mode: InterleaveBySize, ChunkSize: 1000})
+	assertNoError(t, err)
+	_, err = SplitAudioByLanguage(vf, nil, Interleaving{Mode: InterleaveBySize, ChunkSize: 1000}, nil)
+	assertError(t, err, "no audio tracks")
+}
@@ -0,0 +1,216 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// AuxInfo - sample auxiliary information for one sample as signaled by saiz/saio boxes.
+//
+// Decoders for specific aux_info_type values (e.g. tile info or depth maps)
+// can be registered with RegisterAuxInfoDecoder. Without a registered decoder,
+// the data is returned as RawAuxInfo.
+type AuxInfo interface {
+	// Size - size in bytes of encoded sample auxiliary information
+	Size() int
+	// Encode - write sample auxiliary information to sw
+	Encode(sw *SliceWriter)
+}
+
+// AuxInfoDecoder - decode sample auxiliary information for one sample
+type AuxInfoDecoder func(auxInfoTypeParameter uint32, data []byte) (AuxInfo, error)
+
+var auxInfoDecoders = struct {
+	sync.RWMutex
+	m map[string]AuxInfoDecoder
+}{m: map[string]AuxInfoDecoder{}}
+
+// RegisterAuxInfoDecoder - register decoder for auxInfoType (4 characters).
+// An earlier registration for the same auxInfoType is replaced.
+func RegisterAuxInfoDecoder(auxInfoType string, decoder AuxInfoDecoder) {
+	auxInfoDecoders.Lock()
+	auxInfoDecoders.m[auxInfoType] = decoder
+	auxInfoDecoders.Unlock()
+}
+
+// UnregisterAuxInfoDecoder - remove decoder for auxInfoType
+func UnregisterAuxInfoDecoder(auxInfoType string) {
+	auxInfoDecoders.Lock()
+	delete(auxInfoDecoders.m, auxInfoType)
+	auxInfoDecoders.Unlock()
+}
+
+// DecodeAuxInfo - decode sample auxiliary information using registered decoder for auxInfoType.
+// Falls back to RawAuxInfo if no decoder is registered.
+func DecodeAuxInfo(auxInfoType string, auxInfoTypeParameter uint32, data []byte) (AuxInfo, error) {
+	auxInfoDecoders.RLock()
+	decoder, ok := auxInfoDecoders.m[auxInfoType]
+	auxInfoDecoders.RUnlock()
+	if !ok {
+		return &RawAuxInfo{Data: data}, nil
+	}
+	return decoder(auxInfoTypeParameter, data)
+}
+
+// RawAuxInfo - sample auxiliary information without registered decoder
+type RawAuxInfo struct {
+	Data []byte
+}
+
+// Size - size in bytes
+func (a *RawAuxInfo) Size() int {
+	return len(a.Data)
+}
+
+// Encode - write data to sw
+func (a *RawAuxInfo) Encode(sw *SliceWriter) {
+	sw.WriteBytes(a.Data)
+}
+
+// auxInfoRef - reference to sample auxiliary information written in mdat of a fragment
+type auxInfoRef struct {
+	saio         *SaioBox
+	offsetInMdat uint64 // Offset relative to mdat payload start
+}
+
+// GetAuxInfos - get sample auxiliary information of auxInfoType for track trackID.
+// auxInfoType "" matches saiz/saio boxes without explicit type (implied by protection scheme).
+// The data must be available in the moof box or in a non-lazy mdat box.
+func (f *Fragment) GetAuxInfos(trackID uint32, auxInfoType string) ([]AuxInfo, error) {
+	moof := f.Moof
+	var traf *TrafBox
+	for _, tf := range moof.Trafs {
+		if tf.Tfhd.TrackID == trackID {
+			traf = tf
+			break
+		}
+	}
+	if traf == nil {
+		return nil, fmt.Errorf("no traf for trackID %d", trackID)
+	}
+	saiz, saio := traf.GetSaizSaio(auxInfoType)
+	if saiz == nil || saio == nil {
+		return nil, fmt.Errorf("no saiz/saio pair for auxInfoType %q", auxInfoType)
+	}
+	if len(saio.Offset) != 1 && len(saio.Offset) != len(traf.Truns) {
+		return nil, fmt.Errorf("saio entry count %d does not match 1 or trun count %d",
+			len(saio.Offset), len(traf.Truns))
+	}
+
+	baseOffset := moof.StartPos
+	if traf.Tfhd.HasBaseDataOffset() {
+		baseOffset = traf.Tfhd.BaseDataOffset
+	}
+	var moofData []byte // Only encoded if aux info is stored inside moof
+	infos := make([]AuxInfo, 0, saiz.SampleCount)
+	sampleNr := uint32(1)
+	for i, offset := range saio.Offset {
+		nrSamples := saiz.SampleCount
+		if len(saio.Offset) > 1 {
+			nrSamples = traf.Truns[i].SampleCount()
+		}
+		pos := uint64(int64(baseOffset) + offset)
+		for j := uint32(0); j < nrSamples; j++ {
+			infoSize, err := saiz.GetSampleInfoSize(sampleNr)
+			if err != nil {
+				return nil, err
+			}
+			size := uint64(infoSize)
+			var data []byte
+			switch {
+			case pos >= moof.StartPos && pos+size <= moof.StartPos+moof.Size():
+				if moofData == nil {
+					buf := bytes.Buffer{}
+					err := moof.Encode(&buf)
+					if err != nil {
+						return nil, err
+					}
+					moofData = buf.Bytes()
+				}
+				data = moofData[pos-moof.StartPos : pos-moof.StartPos+size]
+			case f.Mdat != nil && !f.Mdat.IsLazy() && pos >= f.Mdat.PayloadAbsoluteOffset() &&
+				pos+size <= f.Mdat.PayloadAbsoluteOffset()+uint64(len(f.Mdat.Data)):
+				start := pos - f.Mdat.PayloadAbsoluteOffset()
+				data = f.Mdat.Data[start : start+size]
+			default:
+				return nil, fmt.Errorf("aux info for sample %d at %d not available", sampleNr, pos)
+			}
+			info, err := DecodeAuxInfo(auxInfoType, saiz.AuxInfoTypeParameter, data)
+			if err != nil {
+				return nil, err
+			}
+			infos = append(infos, info)
+			pos += size
+			sampleNr++
+		}
+	}
+	return infos, nil
+}
+
+// AddAuxInfos - add sample auxiliary information of auxInfoType for all samples of track trackID.
+// The data is appended to mdat, and saiz and saio boxes are added to the traf.
+// Must be called after all samples have been added, since data offsets are based on that.
+func (f *Fragment) AddAuxInfos(trackID uint32, auxInfoType string, auxInfoTypeParameter uint32, infos []AuxInfo) error {
+	var traf *TrafBox
+	for _, tf := range f.Moof.Trafs {
+		if tf.Tfhd.TrackID == trackID {
+			traf = tf
+			break
+		}
+	}
+	if traf == nil {
+		return fmt.Errorf("no traf for trackID %d", trackID)
+	}
+	nrSamples := uint32(0)
+	for _, trun := range traf.Truns {
+		nrSamples += trun.SampleCount()
+	}
+	if uint32(len(infos)) != nrSamples {
+		return fmt.Errorf("got %d aux infos for %d samples", len(infos), nrSamples)
+	}
+	if len(auxInfoType) != 4 {
+		return fmt.Errorf("auxInfoType %q is not 4 characters", auxInfoType)
+	}
+	saiz := &SaizBox{
+		Flags:                0x01,
+		AuxInfoType:          auxInfoType,
+		AuxInfoTypeParameter: auxInfoTypeParameter,
+		SampleCount:          nrSamples,
+	}
+	totSize := 0
+	sameSize := true
+	for i, info := range infos {
+		size := info.Size()
+		if size > 255 {
+			return fmt.Errorf("aux info size %d for sample %d larger than 255", size, i+1)
+		}
+		saiz.SampleInfo = append(saiz.SampleInfo, byte(size))
+		if size != infos[0].Size() {
+			sameSize = false
+		}
+		totSize += size
+	}
+	if sameSize && len(infos) > 0 && infos[0].Size() > 0 {
+		saiz.DefaultSampleInfoSize = byte(infos[0].Size())
+		saiz.SampleInfo = nil
+	}
+	saio := &SaioBox{
+		Flags:                0x01,
+		AuxInfoType:          auxInfoType,
+		AuxInfoTypeParameter: auxInfoTypeParameter,
+		Offset:               []int64{0}, // Set in SetTrunDataOffsets
+	}
+	data := make([]byte, totSize)
+	sw := NewSliceWriter(data)
+	for _, info := range infos {
+		info.Encode(sw)
+	}
+	f.auxInfoRefs = append(f.auxInfoRefs, auxInfoRef{saio: saio, offsetInMdat: uint64(len(f.Mdat.Data))})
+	f.Mdat.AddSampleData(data)
+	err := traf.AddChild(saiz)
+	if err != nil {
+		return err
+	}
+	return traf.AddChild(saio)
+}
@@ -0,0 +1,81 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// depthAuxInfo - test aux info with a 16-bit depth value
+type depthAuxInfo struct {
+	depth uint16
+}
+
+func (d *depthAuxInfo) Size() int {
+	return 2
+}
+
+func (d *depthAuxInfo) Encode(sw *SliceWriter) {
+	sw.WriteUint16(d.depth)
+}
+
+func decodeDepthAuxInfo(auxInfoTypeParameter uint32, data []byte) (AuxInfo, error) {
+	if len(data) != 2 {
+		return nil, fmt.Errorf("bad depth aux info size %d", len(data))
+	}
+	return &depthAuxInfo{depth: NewSliceReader(data).ReadUint16()}, nil
+}
+
+func TestAuxInfoWriteAndRead(t *testing.T) {
+	RegisterAuxInfoDecoder("dpth", decodeDepthAuxInfo)
+	defer UnregisterAuxInfoDecoder("dpth")
+
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	var depthInfos, rawInfos []AuxInfo
+	for i := 0; i < 3; i++ {
+		s := FullSample{Sample: NewSample(SyncSampleFlags, 1000, 4, 0),
+			DecodeTime: uint64(1000 * i), Data: []byte{1, 2, 3, 4}}
+		frag.AddFullSample(s)
+		depthInfos = append(depthInfos, &depthAuxInfo{depth: uint16(100 * i)})
+		rawInfos = append(rawInfos, &RawAuxInfo{Data: bytes.Repeat([]byte{0xaa}, i+1)})
+	}
+	assertNoError(t, frag.AddAuxInfos(1, "dpth", 0, depthInfos))
+	assertNoError(t, frag.AddAuxInfos(1, "raw ", 7, rawInfos))
+	assertError(t, frag.AddAuxInfos(1, "dpth", 0, depthInfos[:2]), "wrong number of infos")
+
+	buf := bytes.Buffer{}
+	assertNoError(t, frag.Encode(&buf))
+	seg, err := DecodeMediaSegment(&buf)
+	assertNoError(t, err)
+	decFrag := seg.Fragments[0]
+
+	samples, err := decFrag.GetFullSamples(nil)
+	assertNoError(t, err)
+	if len(samples) != 3 || !bytes.Equal(samples[2].Data, []byte{1, 2, 3, 4}) {
+		t.Errorf("sample data not intact after adding aux info")
+	}
+
+	gotDepth, err := decFrag.GetAuxInfos(1, "dpth")
+	assertNoError(t, err)
+	for i, info := range gotDepth {
+		d, ok := info.(*depthAuxInfo)
+		if !ok || d.depth != uint16(100*i) {
+			t.Errorf("sample %d: got %v instead of depth %d", i+1, info, 100*i)
+		}
+	}
+	gotRaw, err := decFrag.GetAuxInfos(1, "raw ")
+	assertNoError(t, err)
+	for i, info := range gotRaw {
+		r, ok := info.(*RawAuxInfo)
+		if !ok || !bytes.Equal(r.Data, bytes.Repeat([]byte{0xaa}, i+1)) {
+			t.Errorf("sample %d: got %v instead of raw data", i+1, info)
+		}
+	}
+	saiz, _ := decFrag.Moof.Traf.GetSaizSaio("dpth")
+	if saiz.DefaultSampleInfoSize != 2 {
+		t.Errorf("got default sample info size %d instead of 2", saiz.DefaultSampleInfoSize)
+	}
+	_, err = decFrag.GetAuxInfos(1, "none")
+	assertError(t, err, "missing aux info type should give error")
+}
@@ -0,0 +1,132 @@
+package mp4
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Av1CBox - AV1CodecConfigurationBox (av1C)
+// Defined in AV1 Codec ISO Media File Format Binding v1.2.0 Section 2.3
+type Av1CBox struct {
+	SeqProfile                       byte
+	SeqLevelIdx0                     byte
+	SeqTier0                         byte
+	HighBitdepth                     byte
+	TwelveBit                        byte
+	Monochrome                       byte
+	ChromaSubsamplingX               byte
+	ChromaSubsamplingY               byte
+	ChromaSamplePosition             byte
+	InitialPresentationDelayPresent  byte
+	InitialPresentationDelayMinusOne byte
+	ConfigOBUs                       []byte // Sequence header and metadata OBUs in low-overhead format
+}
+
+// DecodeAv1C - box-specific decode
+func DecodeAv1C(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("av1C: too short %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	markerAndVersion := s.ReadUint8()
+	if markerAndVersion != 0x81 {
+		return nil, fmt.Errorf("av1C: marker and version is 0x%02x, not 0x81", markerAndVersion)
+	}
+	b := &Av1CBox{}
+	byte1 := s.ReadUint8()
+	b.SeqProfile = byte1 >> 5
+	b.SeqLevelIdx0 = byte1 & 0x1f
+	byte2 := s.ReadUint8()
+	b.SeqTier0 = byte2 >> 7
+	b.HighBitdepth = (byte2 >> 6) & 1
+	b.TwelveBit = (byte2 >> 5) & 1
+	b.Monochrome = (byte2 >> 4) & 1
+	b.ChromaSubsamplingX = (byte2 >> 3) & 1
+	b.ChromaSubsamplingY = (byte2 >> 2) & 1
+	b.ChromaSamplePosition = byte2 & 0x3
+	byte3 := s.ReadUint8()
+	b.InitialPresentationDelayPresent = (byte3 >> 4) & 1
+	if b.InitialPresentationDelayPresent == 1 {
+		b.InitialPresentationDelayMinusOne = byte3 & 0x0f
+	}
+	if s.NrRemainingBytes() > 0 {
+		b.ConfigOBUs = s.RemainingBytes()
+	}
+	return b, nil
+}
+
+// BitDepth - bit depth derived from HighBitdepth and TwelveBit
+func (b *Av1CBox) BitDepth() int {
+	switch {
+	case b.HighBitdepth == 1 && b.TwelveBit == 1:
+		return 12
+	case b.HighBitdepth == 1:
+		return 10
+	default:
+		return 8
+	}
+}
+
+// CodecString - RFC6381 codec string such as av01.0.04M.08
+func (b *Av1CBox) CodecString() string {
+	tier := "M"
+	if b.SeqTier0 == 1 {
+		tier = "H"
+	}
+	return fmt.Sprintf("av01.%d.%02d%s.%02d", b.SeqProfile, b.SeqLevelIdx0, tier, b.BitDepth())
+}
+
+// Type - return box type
+func (b *Av1CBox) Type() string {
+	return "av1C"
+}
+
+// Size - return calculated size
+func (b *Av1CBox) Size() uint64 {
+	return uint64(boxHeaderSize + 4 + len(b.ConfigOBUs))
+}
+
+// Encode - write box to w
+func (b *Av1CBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint8(0x81) // marker and version 1
+	sw.WriteUint8(b.SeqProfile<<5 | b.SeqLevelIdx0&0x1f)
+	sw.WriteUint8(b.SeqTier0<<7 | b.HighBitdepth<<6 | b.TwelveBit<<5 | b.Monochrome<<4 |
+		b.ChromaSubsamplingX<<3 | b.ChromaSubsamplingY<<2 | b.ChromaSamplePosition&0x3)
+	byte3 := byte(0)
+	if b.InitialPresentationDelayPresent == 1 {
+		byte3 = 0x10 | b.InitialPresentationDelayMinusOne&0x0f
+	}
+	sw.WriteUint8(byte3)
+	sw.WriteBytes(b.ConfigOBUs)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - box-specific Info
+func (b *Av1CBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - SeqProfile: %d", b.SeqProfile)
+	bd.write(" - SeqLevelIdx0: %d", b.SeqLevelIdx0)
+	bd.write(" - SeqTier0: %d", b.SeqTier0)
+	bd.write(" - BitDepth: %d", b.BitDepth())
+	bd.write(" - Monochrome: %d", b.Monochrome)
+	bd.write(" - ChromaSubsampling: %d %d", b.ChromaSubsamplingX, b.ChromaSubsamplingY)
+	bd.write(" - ChromaSamplePosition: %d", b.ChromaSamplePosition)
+	if b.InitialPresentationDelayPresent == 1 {
+		bd.write(" - InitialPresentationDelay: %d", b.InitialPresentationDelayMinusOne+1)
+	}
+	bd.write(" - ConfigOBUs: %s", hex.EncodeToString(b.ConfigOBUs))
+	return bd.err
+}
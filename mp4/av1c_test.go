@@ -0,0 +1,43 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAv1C(t *testing.T) {
+	av1C := &Av1CBox{
+		SeqProfile:         0,
+		SeqLevelIdx0:       8,
+		ChromaSubsamplingX: 1,
+		ChromaSubsamplingY: 1,
+		ConfigOBUs:         []byte{0x0a, 0x0b, 0x00, 0x00, 0x00, 0x24, 0xc6, 0x7f, 0xdf, 0xff, 0x2c, 0x10, 0x4a},
+	}
+	boxDiffAfterEncodeAndDecode(t, av1C)
+	if got := av1C.CodecString(); got != "av01.0.08M.08" {
+		t.Errorf("got codec string %s instead of av01.0.08M.08", got)
+	}
+	hdr := &Av1CBox{SeqProfile: 1, SeqLevelIdx0: 13, SeqTier0: 1, HighBitdepth: 1,
+		InitialPresentationDelayPresent: 1, InitialPresentationDelayMinusOne: 3}
+	boxDiffAfterEncodeAndDecode(t, hdr)
+	if got := hdr.CodecString(); got != "av01.1.13H.10" {
+		t.Errorf("got codec string %s instead of av01.1.13H.10", got)
+	}
+
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	trak := init.Moov.Trak
+	assertError(t, trak.SetAV1Descriptor(1920, 1080, nil), "missing av1C should give error")
+	assertNoError(t, trak.SetAV1Descriptor(1920, 1080, av1C))
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	decFile, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	av01 := decFile.Moov.Trak.Mdia.Minf.Stbl.Stsd.GetAV1()
+	if av01 == nil || av01.Av1C == nil {
+		t.Fatalf("no av01 entry with av1C after decode")
+	}
+	if av01.Width != 1920 || av01.Height != 1080 || av01.Av1C.SeqLevelIdx0 != 8 {
+		t.Errorf("bad av01 entry %dx%d level %d", av01.Width, av01.Height, av01.Av1C.SeqLevelIdx0)
+	}
+}
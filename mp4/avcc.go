@@ -58,6 +58,7 @@ func (a *AvcCBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	bd.write(" - AVCProfileIndication: %d", a.AVCProfileIndication)
 	bd.write(" - profileCompatibility: %02x", a.ProfileCompatibility)
 	bd.write(" - AVCLevelIndication: %d", a.AVCLevelIndication)
+	bd.write(" - NALULengthSize: %d", a.NALULengthSize())
 	for _, sps := range a.SPSnalus {
 		bd.write(" - SPS: %s", hex.EncodeToString(sps))
 	}
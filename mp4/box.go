@@ -18,31 +18,55 @@ var decoders map[string]BoxDecoder
 
 func init() {
 	decoders = map[string]BoxDecoder{
+		"ac-3":    DecodeAudioSampleEntry,
+		"ac-4":    DecodeAudioSampleEntry,
+		"av01":    DecodeVisualSampleEntry,
+		"av1C":    DecodeAv1C,
 		"avc1":    DecodeVisualSampleEntry,
 		"avc3":    DecodeVisualSampleEntry,
 		"avcC":    DecodeAvcC,
 		"btrt":    DecodeBtrt,
 		"cdat":    DecodeCdat,
 		"cdsc":    DecodeTrefType,
+		"chap":    DecodeTrefType,
+		"chpl":    DecodeChpl,
 		"clap":    DecodeClap,
+		"clli":    DecodeClli,
 		"cslg":    DecodeCslg,
 		"co64":    DecodeCo64,
+		"colr":    DecodeColr,
+		"covr":    DecodeCovr,
 		"ctim":    DecodeCtim,
 		"ctts":    DecodeCtts,
+		"dac3":    DecodeDac3,
+		"dac4":    DecodeDac4,
 		"data":    DecodeData,
+		"dec3":    DecodeDec3,
+		"dfLa":    DecodeDfla,
 		"dinf":    DecodeDinf,
+		"dOps":    DecodeDops,
 		"dpnd":    DecodeTrefType,
 		"dref":    DecodeDref,
+		"dva1":    DecodeVisualSampleEntry,
+		"dvav":    DecodeVisualSampleEntry,
+		"dvcC":    DecodeDvcC,
+		"dvh1":    DecodeVisualSampleEntry,
+		"dvhe":    DecodeVisualSampleEntry,
+		"dvvC":    DecodeDvcC,
+		"ec-3":    DecodeAudioSampleEntry,
 		"elng":    DecodeElng,
 		"esds":    DecodeEsds,
 		"edts":    DecodeEdts,
 		"elst":    DecodeElst,
 		"enca":    DecodeAudioSampleEntry,
 		"encv":    DecodeVisualSampleEntry,
+		"enct":    DecodeEnct,
 		"emsg":    DecodeEmsg,
 		"font":    DecodeTrefType,
 		"free":    DecodeFree,
+		"fLaC":    DecodeAudioSampleEntry,
 		"frma":    DecodeFrma,
+		"ftab":    DecodeFtab,
 		"ftyp":    DecodeFtyp,
 		"hdlr":    DecodeHdlr,
 		"hev1":    DecodeVisualSampleEntry,
@@ -50,12 +74,18 @@ func init() {
 		"hint":    DecodeTrefType,
 		"hvcC":    DecodeHvcC,
 		"hvc1":    DecodeVisualSampleEntry,
+		"ID32":    DecodeID32,
+		"idat":    DecodeIdat,
 		"iden":    DecodeIden,
+		"iinf":    DecodeIinf,
+		"iloc":    DecodeIloc,
 		"ilst":    DecodeIlst,
+		"infe":    DecodeInfe,
 		"iods":    DecodeUnknown,
 		"ipir":    DecodeTrefType,
 		"kind":    DecodeKind,
 		"mdat":    DecodeMdat,
+		"mdcv":    DecodeMdcv,
 		"mehd":    DecodeMehd,
 		"mdhd":    DecodeMdhd,
 		"mdia":    DecodeMdia,
@@ -63,17 +93,23 @@ func init() {
 		"mfhd":    DecodeMfhd,
 		"mfra":    DecodeMfra,
 		"mfro":    DecodeMfro,
+		"mha1":    DecodeAudioSampleEntry,
+		"mhaC":    DecodeMhaC,
+		"mhm1":    DecodeAudioSampleEntry,
 		"mime":    DecodeMime,
 		"minf":    DecodeMinf,
 		"moof":    DecodeMoof,
 		"moov":    DecodeMoov,
 		"mpod":    DecodeTrefType,
+		"msrc":    DecodeTrackGroupType,
 		"mvex":    DecodeMvex,
 		"mvhd":    DecodeMvhd,
 		"mp4a":    DecodeAudioSampleEntry,
 		"nmhd":    DecodeNmhd,
+		"Opus":    DecodeAudioSampleEntry,
 		"pasp":    DecodePasp,
 		"payl":    DecodePayl,
+		"pitm":    DecodePitm,
 		"prft":    DecodePrft,
 		"pssh":    DecodePssh,
 		"saio":    DecodeSaio,
@@ -88,9 +124,11 @@ func init() {
 		"sinf":    DecodeSinf,
 		"skip":    DecodeFree,
 		"smhd":    DecodeSmhd,
+		"ssix":    DecodeSsix,
 		"sthd":    DecodeSthd,
 		"stbl":    DecodeStbl,
 		"stco":    DecodeStco,
+		"ster":    DecodeTrackGroupType,
 		"stpp":    DecodeStpp,
 		"stsc":    DecodeStsc,
 		"stsd":    DecodeStsd,
@@ -98,6 +136,8 @@ func init() {
 		"stsz":    DecodeStsz,
 		"sttg":    DecodeSttg,
 		"stts":    DecodeStts,
+		"stz2":    DecodeStz2,
+		"styl":    DecodeStyl,
 		"styp":    DecodeStyp,
 		"subs":    DecodeSubs,
 		"subt":    DecodeTrefType,
@@ -112,13 +152,18 @@ func init() {
 		"tref":    DecodeTref,
 		"trep":    DecodeTrep,
 		"trex":    DecodeTrex,
+		"trgr":    DecodeTrgr,
 		"trun":    DecodeTrun,
+		"tx3g":    DecodeTx3g,
 		"udta":    DecodeUdta,
 		"url ":    DecodeURLBox,
+		"urn ":    DecodeURNBox,
 		"uuid":    DecodeUUIDBox,
 		"vdep":    DecodeTrefType,
 		"vlab":    DecodeVlab,
 		"vmhd":    DecodeVmhd,
+		"vp09":    DecodeVisualSampleEntry,
+		"vpcC":    DecodeVpcC,
 		"vplx":    DecodeTrefType,
 		"vsid":    DecodeVsid,
 		"vtta":    DecodeVtta,
@@ -140,19 +185,19 @@ type boxHeader struct {
 // decodeHeader decodes a box header (size + box type + possiible largeSize)
 func decodeHeader(r io.Reader) (*boxHeader, error) {
 	buf := make([]byte, boxHeaderSize)
-	n, err := r.Read(buf)
+	n, err := io.ReadFull(r, buf)
+	if err == io.ErrUnexpectedEOF || (err == nil && n != boxHeaderSize) {
+		return nil, errors.New("Could not read full 8B header")
+	}
 	if err != nil {
 		return nil, err
 	}
-	if n != boxHeaderSize {
-		return nil, errors.New("Could not read full 8B header")
-	}
 	size := uint64(binary.BigEndian.Uint32(buf[0:4]))
 	headerLen := boxHeaderSize
 	if size == 1 {
 		buf := make([]byte, largeSizeLen)
-		n, err := r.Read(buf)
-		if err != nil {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
 			return nil, err
 		}
 		if n != largeSizeLen {
@@ -237,23 +282,32 @@ func DecodeBox(startPos uint64, r io.Reader) (Box, error) {
 	var b Box
 
 	h, err := decodeHeader(r)
-	if err != nil {
+	if err == io.EOF {
 		return nil, err
 	}
+	if err != nil {
+		return nil, &BoxDecodeError{StartPos: startPos, Err: err}
+	}
 
 	d, ok := decoders[h.name]
 
 	remainingLength := int64(h.size) - int64(h.hdrlen)
+	lr := &io.LimitedReader{R: r, N: remainingLength}
 
 	if !ok {
-		b, err = DecodeUnknown(h, startPos, io.LimitReader(r, remainingLength))
+		logDebugf("unknown box %q at %d with size %d", h.name, startPos, h.size)
+		b, err = DecodeUnknown(h, startPos, lr)
 
 	} else {
-		b, err = d(h, startPos, io.LimitReader(r, remainingLength))
+		b, err = d(h, startPos, lr)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("decode %s: %w", h.name, err)
+		return nil, &BoxDecodeError{BoxType: h.name, StartPos: startPos, Size: h.size, Err: err}
+	}
+	if lr.N > 0 {
+		logWarnf("%s box at %d: %d bytes not decoded", h.name, startPos, lr.N)
 	}
+	logSizeMismatch(h, startPos, b)
 
 	return b, nil
 }
@@ -264,34 +318,55 @@ func DecodeBoxLazyMdat(startPos uint64, r io.ReadSeeker) (Box, error) {
 	var b Box
 
 	h, err := decodeHeader(r)
-	if err != nil {
+	if err == io.EOF {
 		return nil, err
 	}
+	if err != nil {
+		return nil, &BoxDecodeError{StartPos: startPos, Err: err}
+	}
 
 	d, ok := decoders[h.name]
 
 	remainingLength := int64(h.size) - int64(h.hdrlen)
 
+	lr := &io.LimitedReader{R: r, N: remainingLength}
 	if !ok {
-		b, err = DecodeUnknown(h, startPos, io.LimitReader(r, remainingLength))
+		logDebugf("unknown box %q at %d with size %d", h.name, startPos, h.size)
+		b, err = DecodeUnknown(h, startPos, lr)
 	} else {
 		switch h.name {
 		case "mdat":
 			b, err = DecodeMdatLazily(h, startPos)
 			if err == nil {
 				_, err = r.Seek(remainingLength, io.SeekCurrent)
+				lr.N = 0
 			}
 		default:
-			b, err = d(h, startPos, io.LimitReader(r, remainingLength))
+			b, err = d(h, startPos, lr)
 		}
 	}
 	if err != nil {
-		return nil, fmt.Errorf("decode %s: %w", h.name, err)
+		return nil, &BoxDecodeError{BoxType: h.name, StartPos: startPos, Size: h.size, Err: err}
+	}
+	if lr.N > 0 {
+		logWarnf("%s box at %d: %d bytes not decoded", h.name, startPos, lr.N)
 	}
+	logSizeMismatch(h, startPos, b)
 
 	return b, nil
 }
 
+// logSizeMismatch - warn if the size of the decoded box differs from the size in its header,
+// since it will then be encoded with another size.
+func logSizeMismatch(h *boxHeader, startPos uint64, b Box) {
+	if getLogger() == nil {
+		return
+	}
+	if size := b.Size(); size != h.size {
+		logWarnf("%s box at %d: size %d in header, but %d after decode", h.name, startPos, h.size, size)
+	}
+}
+
 // Fixed16 - An 8.8 fixed point number
 type Fixed16 uint16
 
@@ -308,7 +383,7 @@ func (f Fixed32) String() string {
 
 func strtobuf(out []byte, str string, l int) {
 	in := []byte(str)
-	if l < len(in) {
+	if len(in) < l {
 		copy(out, in)
 	} else {
 		copy(out, in[0:l])
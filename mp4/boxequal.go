@@ -0,0 +1,122 @@
+package mp4
+
+import (
+	"bytes"
+	"hash/fnv"
+)
+
+// BoxesEqual - true if a and b have the same type and the same binary encoding, which makes it a
+// deep comparison of sample entries and decoder configuration boxes including all their children.
+// Boxes that cannot be encoded are not equal.
+func BoxesEqual(a, b Box) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if a.Type() != b.Type() || a.Size() != b.Size() {
+		return false
+	}
+	bufA, bufB := bytes.Buffer{}, bytes.Buffer{}
+	if a.Encode(&bufA) != nil || b.Encode(&bufB) != nil {
+		return false
+	}
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes())
+}
+
+// BoxHash - 64-bit FNV-1a hash of the binary encoding of b, so that equal boxes have equal hashes.
+// 0 is returned for nil and for boxes that cannot be encoded.
+func BoxHash(b Box) uint64 {
+	if b == nil {
+		return 0
+	}
+	h := fnv.New64a()
+	if b.Encode(h) != nil {
+		return 0
+	}
+	return h.Sum64()
+}
+
+// SameCodecConfig - true if s and other have the same number of tracks and, in order, the same
+// sample descriptions (stsd) with sample entries and decoder configuration. Bitrate boxes (btrt)
+// are not part of the codec configuration and are ignored. Stream switchers can use this to decide
+// if a new init segment must be sent to a decoder.
+func (s *InitSegment) SameCodecConfig(other *InitSegment) bool {
+	if s.Moov == nil || other.Moov == nil || len(s.Moov.Traks) != len(other.Moov.Traks) {
+		return false
+	}
+	for i, trak := range s.Moov.Traks {
+		if !BoxesEqual(withoutBtrt(trak.Mdia.Minf.Stbl.Stsd), withoutBtrt(other.Moov.Traks[i].Mdia.Minf.Stbl.Stsd)) {
+			return false
+		}
+	}
+	return true
+}
+
+// CodecConfigHash - hash of the sample descriptions of all tracks without bitrate boxes (btrt).
+// Init segments with the same codec configuration have the same hash.
+func (s *InitSegment) CodecConfigHash() uint64 {
+	h := fnv.New64a()
+	if s.Moov != nil {
+		for _, trak := range s.Moov.Traks {
+			if withoutBtrt(trak.Mdia.Minf.Stbl.Stsd).Encode(h) != nil {
+				return 0
+			}
+		}
+	}
+	return h.Sum64()
+}
+
+// Equal - true if other is a visual sample entry with the same codec configuration.
+// A btrt box is ignored since the bitrate may differ between encodings of the same configuration.
+func (b *VisualSampleEntryBox) Equal(other Box) bool {
+	return BoxesEqual(withoutBtrt(b), withoutBtrt(other))
+}
+
+// Hash - hash of the sample entry without btrt box, so that Equal entries have the same hash
+func (b *VisualSampleEntryBox) Hash() uint64 {
+	return BoxHash(withoutBtrt(b))
+}
+
+// Equal - true if other is an audio sample entry with the same codec configuration.
+// A btrt box is ignored since the bitrate may differ between encodings of the same configuration.
+func (a *AudioSampleEntryBox) Equal(other Box) bool {
+	return BoxesEqual(withoutBtrt(a), withoutBtrt(other))
+}
+
+// Hash - hash of the sample entry without btrt box, so that Equal entries have the same hash
+func (a *AudioSampleEntryBox) Hash() uint64 {
+	return BoxHash(withoutBtrt(a))
+}
+
+// withoutBtrt - shallow copy of a stsd box or sample entry without btrt boxes. b is not changed.
+func withoutBtrt(b Box) Box {
+	switch box := b.(type) {
+	case *StsdBox:
+		c := *box
+		c.Children = make([]Box, 0, len(box.Children))
+		for _, child := range box.Children {
+			c.Children = append(c.Children, withoutBtrt(child))
+		}
+		return &c
+	case *VisualSampleEntryBox:
+		c := *box
+		c.Btrt = nil
+		c.Children = removeBtrt(box.Children)
+		return &c
+	case *AudioSampleEntryBox:
+		c := *box
+		c.Children = removeBtrt(box.Children)
+		return &c
+	}
+	return b
+}
+
+// removeBtrt - new slice with all boxes except btrt
+func removeBtrt(boxes []Box) []Box {
+	out := make([]Box, 0, len(boxes))
+	for _, b := range boxes {
+		if b.Type() != "btrt" {
+			out = append(out, b)
+		}
+	}
+	return out
+}
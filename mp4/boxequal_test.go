@@ -0,0 +1,58 @@
+package mp4
+
+import (
+	"testing"
+
+	"github.com/edgeware/mp4ff/aac"
+)
+
+func createAACInit(t *testing.T, samplingFrequency int) *InitSegment {
+	t.Helper()
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(uint32(samplingFrequency), "audio", "en")
+	assertNoError(t, init.Moov.Trak.SetAACDescriptor(aac.AAClc, samplingFrequency))
+	return init
+}
+
+func TestSampleEntryEqualAndHash(t *testing.T) {
+	init1 := createAACInit(t, 48000)
+	init2 := createAACInit(t, 48000)
+	init3 := createAACInit(t, 44100)
+
+	mp4a1 := init1.Moov.Trak.Mdia.Minf.Stbl.Stsd.Mp4a
+	mp4a2 := init2.Moov.Trak.Mdia.Minf.Stbl.Stsd.Mp4a
+	mp4a3 := init3.Moov.Trak.Mdia.Minf.Stbl.Stsd.Mp4a
+	if !mp4a1.Equal(mp4a2) || mp4a1.Hash() != mp4a2.Hash() {
+		t.Errorf("identical sample entries not equal or with different hashes")
+	}
+	if mp4a1.Equal(mp4a3) || mp4a1.Hash() == mp4a3.Hash() {
+		t.Errorf("sample entries with different sampling frequency are equal")
+	}
+	if BoxesEqual(mp4a1.Esds, mp4a3.Esds) {
+		t.Errorf("esds with different sampling frequency are equal")
+	}
+	if mp4a1.Equal(mp4a1.Esds) || BoxesEqual(mp4a1, nil) || !BoxesEqual(nil, nil) {
+		t.Errorf("boxes of different types or nil are equal")
+	}
+
+	if !init1.SameCodecConfig(init2) || init1.CodecConfigHash() != init2.CodecConfigHash() {
+		t.Errorf("init segments with same codec config differ")
+	}
+	if init1.SameCodecConfig(init3) || init1.CodecConfigHash() == init3.CodecConfigHash() {
+		t.Errorf("init segments with different codec config are the same")
+	}
+	init2.Moov.Trak.Tkhd.TrackID = 2 // Not part of codec configuration
+	if !init1.SameCodecConfig(init2) {
+		t.Errorf("track ID changes codec config comparison")
+	}
+	mp4a2.AddChild(&BtrtBox{BufferSizeDB: 1000, MaxBitrate: 128000, AvgBitrate: 128000})
+	if !mp4a1.Equal(mp4a2) || mp4a1.Hash() != mp4a2.Hash() {
+		t.Errorf("btrt box changes sample entry comparison")
+	}
+	if !init1.SameCodecConfig(init2) || init1.CodecConfigHash() != init2.CodecConfigHash() {
+		t.Errorf("btrt box changes codec config comparison")
+	}
+	if len(mp4a2.Children) != 2 {
+		t.Errorf("btrt box removed from sample entry")
+	}
+}
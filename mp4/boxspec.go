@@ -0,0 +1,143 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// BoxSpec - declarative description of a box, e.g. to build reproducible test vectors.
+//
+// A box is described in one of the following ways:
+//   - Fields: JSON object with the exported fields of the box struct, e.g. {"MajorBrand": "isom"} for ftyp.
+//     Only available for the box types listed by BoxSpecTypes. The box is encoded by its Encode method.
+//   - Payload and/or Children: raw payload bytes as a hex string, followed by the child boxes.
+//     This works for any box type, including full-box containers where the payload is version and flags.
+//
+// Size overrides the size in the box header, which makes it possible to create malformed boxes.
+type BoxSpec struct {
+	Type     string          `json:"type"`
+	Size     uint32          `json:"size,omitempty"`
+	Fields   json.RawMessage `json:"fields,omitempty"`
+	Payload  string          `json:"payload,omitempty"`
+	Children []BoxSpec       `json:"children,omitempty"`
+}
+
+// boxSpecTypes - boxes that can be created from fields
+var boxSpecTypes = map[string]func() Box{
+	"co64": func() Box { return &Co64Box{} },
+	"ctts": func() Box { return &CttsBox{} },
+	"elst": func() Box { return &ElstBox{} },
+	"emsg": func() Box { return &EmsgBox{} },
+	"ftyp": func() Box { return &FtypBox{} },
+	"mdhd": func() Box { return &MdhdBox{} },
+	"mehd": func() Box { return &MehdBox{} },
+	"mfhd": func() Box { return &MfhdBox{} },
+	"mvhd": func() Box { return &MvhdBox{} },
+	"prft": func() Box { return &PrftBox{} },
+	"sidx": func() Box { return &SidxBox{} },
+	"smhd": func() Box { return &SmhdBox{} },
+	"stco": func() Box { return &StcoBox{} },
+	"stss": func() Box { return &StssBox{} },
+	"stsz": func() Box { return &StszBox{} },
+	"stts": func() Box { return &SttsBox{} },
+	"styp": func() Box { return &StypBox{} },
+	"tfdt": func() Box { return &TfdtBox{} },
+	"tfhd": func() Box { return &TfhdBox{} },
+	"tkhd": func() Box { return &TkhdBox{} },
+	"trex": func() Box { return &TrexBox{} },
+	"vmhd": func() Box { return &VmhdBox{} },
+}
+
+// BoxSpecTypes - box types that can be described by fields in BoxSpec
+func BoxSpecTypes() []string {
+	types := make([]string, 0, len(boxSpecTypes))
+	for t := range boxSpecTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// BuildBoxes - encode the boxes described by specs
+func BuildBoxes(specs []BoxSpec) ([]byte, error) {
+	buf := bytes.Buffer{}
+	for _, spec := range specs {
+		err := spec.encode(&buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// BuildBoxesFromJSON - encode the boxes described by a JSON array of BoxSpec read from r.
+// Unknown keys in the JSON input are errors to catch misspelled field names.
+func BuildBoxesFromJSON(r io.Reader) ([]byte, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var specs []BoxSpec
+	err := dec.Decode(&specs)
+	if err != nil {
+		return nil, fmt.Errorf("box spec: %w", err)
+	}
+	return BuildBoxes(specs)
+}
+
+// encode - write the box described by s to w
+func (s BoxSpec) encode(w io.Writer) error {
+	if len(s.Type) != 4 {
+		return fmt.Errorf("box spec: type %q is not 4 characters", s.Type)
+	}
+	var data []byte
+	if len(s.Fields) > 0 {
+		if s.Payload != "" || len(s.Children) > 0 {
+			return fmt.Errorf("box spec %s: fields cannot be combined with payload or children", s.Type)
+		}
+		newBox, ok := boxSpecTypes[s.Type]
+		if !ok {
+			return fmt.Errorf("box spec %s: no fields support for box type", s.Type)
+		}
+		box := newBox()
+		dec := json.NewDecoder(bytes.NewReader(s.Fields))
+		dec.DisallowUnknownFields()
+		err := dec.Decode(box)
+		if err != nil {
+			return fmt.Errorf("box spec %s: %w", s.Type, err)
+		}
+		buf := bytes.Buffer{}
+		err = box.Encode(&buf)
+		if err != nil {
+			return fmt.Errorf("box spec %s: %w", s.Type, err)
+		}
+		data = buf.Bytes()
+	} else {
+		payload, err := hex.DecodeString(s.Payload)
+		if err != nil {
+			return fmt.Errorf("box spec %s: payload: %w", s.Type, err)
+		}
+		children, err := BuildBoxes(s.Children)
+		if err != nil {
+			return err
+		}
+		size := uint64(boxHeaderSize + len(payload) + len(children))
+		if size > 0xffffffff {
+			return fmt.Errorf("box spec %s: size %d too big", s.Type, size)
+		}
+		data = make([]byte, 0, size)
+		data = append(data, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(data, uint32(size))
+		data = append(data, s.Type...)
+		data = append(data, payload...)
+		data = append(data, children...)
+	}
+	if s.Size != 0 {
+		binary.BigEndian.PutUint32(data, s.Size)
+	}
+	_, err := w.Write(data)
+	return err
+}
@@ -0,0 +1,68 @@
+package mp4
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBuildBoxesFromJSON(t *testing.T) {
+	spec := `[
+		{"type": "ftyp", "fields": {"MajorBrand": "isom", "CompatibleBrands": ["isom", "iso6"]}},
+		{"type": "moov", "children": [
+			{"type": "mvhd", "fields": {"Timescale": 1000, "NextTrackID": 2}},
+			{"type": "udta", "children": [{"type": "abcd", "payload": "0102"}]}
+		]},
+		{"type": "free", "payload": "00000000"}
+	]`
+	data, err := BuildBoxesFromJSON(strings.NewReader(spec))
+	assertNoError(t, err)
+	f, err := DecodeFile(bytes.NewReader(data))
+	assertNoError(t, err)
+	if f.Ftyp.MajorBrand != "isom" || len(f.Ftyp.CompatibleBrands) != 2 {
+		t.Errorf("bad ftyp %+v", f.Ftyp)
+	}
+	if f.Moov.Mvhd.Timescale != 1000 || f.Moov.Mvhd.NextTrackID != 2 {
+		t.Errorf("bad mvhd %+v", f.Moov.Mvhd)
+	}
+	if len(f.Children) != 3 || f.Children[2].Size() != 12 {
+		t.Errorf("bad top-level boxes")
+	}
+
+	malformed, err := BuildBoxes([]BoxSpec{{Type: "free", Size: 100, Payload: "00"}})
+	assertNoError(t, err)
+	if !bytes.Equal(malformed, []byte{0, 0, 0, 100, 'f', 'r', 'e', 'e', 0}) {
+		t.Errorf("got malformed box %x", malformed)
+	}
+
+	errSpecs := []string{
+		`[{"type": "ftyp", "fields": {"MajorBrnd": "isom"}}]`,
+		`[{"type": "ftyp", "fields": {"MajorBrand": "isom"}, "payload": "00"}]`,
+		`[{"type": "moof", "fields": {}}]`,
+		`[{"type": "free", "payload": "0g"}]`,
+		`[{"type": "fre", "payload": "00"}]`,
+		`[{"type": "free", "data": "00"}]`,
+	}
+	for _, s := range errSpecs {
+		_, err := BuildBoxesFromJSON(strings.NewReader(s))
+		assertError(t, err, s)
+	}
+}
+
+func TestBoxSpecTypes(t *testing.T) {
+	for _, boxType := range BoxSpecTypes() {
+		data, err := BuildBoxes([]BoxSpec{{Type: boxType, Fields: []byte("{}")}})
+		if err != nil {
+			t.Errorf("%s: %s", boxType, err)
+			continue
+		}
+		box, err := DecodeBox(0, bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %s", boxType, err)
+			continue
+		}
+		if box.Type() != boxType || box.Size() != uint64(len(data)) {
+			t.Errorf("%s: decoded %s box with size %d instead of %d", boxType, box.Type(), box.Size(), len(data))
+		}
+	}
+}
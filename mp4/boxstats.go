@@ -0,0 +1,134 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// BoxTypeStats - number of boxes and bytes spent on one box type
+type BoxTypeStats struct {
+	Count     int
+	TotalSize uint64 // Sum of box sizes including child boxes
+	OwnSize   uint64 // Sum of box sizes excluding child boxes of containers
+}
+
+// BoxStats - bytes spent per box type, e.g. to quantify container overhead across a library.
+// Boxes at all levels are counted. The OwnSize of all box types add up to TotalSize.
+type BoxStats struct {
+	TotalSize      uint64                   // Sum of top-level box sizes
+	MediaDataSize  uint64                   // Sum of mdat payload sizes
+	FreeSize       uint64                   // Sum of free and skip box sizes
+	EncryptionSize uint64                   // Sum of senc, saiz, saio, and pssh box sizes
+	Types          map[string]*BoxTypeStats // Statistics per box type
+}
+
+// encryptionBoxTypes - boxes carrying per-sample or per-file encryption information
+var encryptionBoxTypes = map[string]bool{"senc": true, "saiz": true, "saio": true, "pssh": true}
+
+// NewBoxStats - calculate statistics for boxes and all their descendants
+func NewBoxStats(boxes []Box) *BoxStats {
+	s := &BoxStats{Types: make(map[string]*BoxTypeStats)}
+	for _, b := range boxes {
+		s.TotalSize += b.Size()
+		s.addBox(b)
+	}
+	return s
+}
+
+// BoxStats - statistics for all boxes of the file
+func (f *File) BoxStats() *BoxStats {
+	return NewBoxStats(f.Children)
+}
+
+func (s *BoxStats) addBox(b Box) {
+	boxType := b.Type()
+	ts, ok := s.Types[boxType]
+	if !ok {
+		ts = &BoxTypeStats{}
+		s.Types[boxType] = ts
+	}
+	size := b.Size()
+	ts.Count++
+	ts.TotalSize += size
+	ownSize := size
+	if c, ok := b.(ContainerBox); ok {
+		for _, child := range c.GetChildren() {
+			ownSize -= child.Size()
+			s.addBox(child)
+		}
+	}
+	ts.OwnSize += ownSize
+	switch {
+	case boxType == "mdat":
+		s.MediaDataSize += size - b.(*MdatBox).HeaderSize()
+	case boxType == "free" || boxType == "skip":
+		s.FreeSize += size
+	case encryptionBoxTypes[boxType]:
+		s.EncryptionSize += size
+	}
+}
+
+// Add - add the statistics of other to s
+func (s *BoxStats) Add(other *BoxStats) {
+	s.TotalSize += other.TotalSize
+	s.MediaDataSize += other.MediaDataSize
+	s.FreeSize += other.FreeSize
+	s.EncryptionSize += other.EncryptionSize
+	for boxType, ots := range other.Types {
+		ts, ok := s.Types[boxType]
+		if !ok {
+			ts = &BoxTypeStats{}
+			s.Types[boxType] = ts
+		}
+		ts.Count += ots.Count
+		ts.TotalSize += ots.TotalSize
+		ts.OwnSize += ots.OwnSize
+	}
+}
+
+// OverheadSize - bytes not spent on media data
+func (s *BoxStats) OverheadSize() uint64 {
+	return s.TotalSize - s.MediaDataSize
+}
+
+// SortedTypes - box types sorted by decreasing OwnSize, and by name for equal sizes
+func (s *BoxStats) SortedTypes() []string {
+	types := make([]string, 0, len(s.Types))
+	for t := range s.Types {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		si, sj := s.Types[types[i]].OwnSize, s.Types[types[j]].OwnSize
+		if si != sj {
+			return si > sj
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
+
+// WriteText - write a table with bytes per box type and the share of the total size
+func (s *BoxStats) WriteText(w io.Writer) error {
+	share := func(size uint64) float64 {
+		if s.TotalSize == 0 {
+			return 0
+		}
+		return 100 * float64(size) / float64(s.TotalSize)
+	}
+	_, err := fmt.Fprintf(w, "total %d bytes, media data %d (%.2f%%), overhead %d (%.2f%%), free %d, encryption %d\n",
+		s.TotalSize, s.MediaDataSize, share(s.MediaDataSize), s.OverheadSize(), share(s.OverheadSize()),
+		s.FreeSize, s.EncryptionSize)
+	if err != nil {
+		return err
+	}
+	for _, t := range s.SortedTypes() {
+		ts := s.Types[t]
+		_, err = fmt.Fprintf(w, "%s count=%d ownSize=%d (%.2f%%) totalSize=%d\n",
+			t, ts.Count, ts.OwnSize, share(ts.OwnSize), ts.TotalSize)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package mp4
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBoxStats(t *testing.T) {
+	f := createTestOnDemandFile(t)
+	f.Children = append(f.Children, &FreeBox{Name: "free", notDecoded: make([]byte, 12)})
+	s := f.BoxStats()
+
+	var fileSize, sumOwn, sampleBytes uint64
+	for _, c := range f.Children {
+		fileSize += c.Size()
+	}
+	for _, ts := range s.Types {
+		sumOwn += ts.OwnSize
+	}
+	for _, seg := range f.Segments {
+		sampleBytes += uint64(len(seg.Fragments[0].Mdat.Data))
+	}
+	if s.TotalSize != fileSize || sumOwn != fileSize {
+		t.Errorf("got total size %d and own size sum %d instead of %d", s.TotalSize, sumOwn, fileSize)
+	}
+	if s.MediaDataSize != sampleBytes || s.OverheadSize() != fileSize-sampleBytes {
+		t.Errorf("got media data size %d instead of %d", s.MediaDataSize, sampleBytes)
+	}
+	if s.FreeSize != 20 || s.EncryptionSize != 0 {
+		t.Errorf("got free size %d and encryption size %d", s.FreeSize, s.EncryptionSize)
+	}
+	if moof := s.Types["moof"]; moof.Count != 3 || s.Types["trun"].Count != 3 || moof.OwnSize != 3*8 {
+		t.Errorf("got moof stats %+v", moof)
+	}
+	if s.SortedTypes()[0] != "mdat" {
+		t.Errorf("mdat is not the largest box type")
+	}
+
+	total := NewBoxStats(nil)
+	total.Add(s)
+	total.Add(s)
+	if total.TotalSize != 2*s.TotalSize || total.Types["moof"].Count != 6 {
+		t.Errorf("bad aggregated stats")
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, s.WriteText(&buf))
+	if !strings.Contains(buf.String(), "\nmdat count=3 ") {
+		t.Errorf("no mdat line in %q", buf.String())
+	}
+}
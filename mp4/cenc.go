@@ -0,0 +1,454 @@
+package mp4
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/edgeware/mp4ff/avc"
+	"github.com/edgeware/mp4ff/hevc"
+)
+
+// Protection schemes defined in ISO/IEC 23001-7 (Common Encryption)
+const (
+	SchemeCENC = "cenc" // AES-CTR
+	SchemeCBCS = "cbcs" // AES-CBC with pattern and constant IV
+)
+
+// DecryptSample - decrypt sample data in place according to scheme (cenc or cbcs).
+// If subSamples is empty, the full sample is protected.
+// cryptByteBlock and skipByteBlock define the cbcs pattern, where 0:0 means that all full blocks are encrypted.
+func DecryptSample(scheme string, key, iv, data []byte, subSamples []SubSamplePattern, cryptByteBlock, skipByteBlock byte) error {
+	return cryptSample(scheme, false, key, iv, data, subSamples, cryptByteBlock, skipByteBlock)
+}
+
+// EncryptSample - encrypt sample data in place according to scheme (cenc or cbcs).
+// Parameters are the same as for DecryptSample.
+func EncryptSample(scheme string, key, iv, data []byte, subSamples []SubSamplePattern, cryptByteBlock, skipByteBlock byte) error {
+	return cryptSample(scheme, true, key, iv, data, subSamples, cryptByteBlock, skipByteBlock)
+}
+
+func cryptSample(scheme string, encrypt bool, key, iv, data []byte, subSamples []SubSamplePattern,
+	cryptByteBlock, skipByteBlock byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	if len(iv) != 8 && len(iv) != 16 {
+		return fmt.Errorf("iv length %d is not 8 or 16", len(iv))
+	}
+	iv16 := make([]byte, 16)
+	copy(iv16, iv) // 8-byte IVs are padded with zeros
+	ranges, err := protectedRanges(len(data), subSamples)
+	if err != nil {
+		return err
+	}
+	switch scheme {
+	case SchemeCENC:
+		// The counter continues over all protected ranges of the sample
+		stream := cipher.NewCTR(block, iv16)
+		for _, r := range ranges {
+			stream.XORKeyStream(r.of(data), r.of(data))
+		}
+	case SchemeCBCS:
+		// The IV is reset for every subsample
+		for _, r := range ranges {
+			var mode cipher.BlockMode
+			if encrypt {
+				mode = cipher.NewCBCEncrypter(block, iv16)
+			} else {
+				mode = cipher.NewCBCDecrypter(block, iv16)
+			}
+			cryptPattern(mode, r.of(data), int(cryptByteBlock), int(skipByteBlock))
+		}
+	default:
+		return fmt.Errorf("protection scheme %q not supported", scheme)
+	}
+	return nil
+}
+
+// cryptPattern - en/decrypt crypt blocks and leave skip blocks in clear. A partial last block is left in clear.
+func cryptPattern(mode cipher.BlockMode, data []byte, crypt, skip int) {
+	nrFullBytes := len(data) / aes.BlockSize * aes.BlockSize
+	if crypt == 0 && skip == 0 {
+		mode.CryptBlocks(data[:nrFullBytes], data[:nrFullBytes])
+		return
+	}
+	for pos := 0; pos < nrFullBytes; pos += (crypt + skip) * aes.BlockSize {
+		end := pos + crypt*aes.BlockSize
+		if end > nrFullBytes {
+			end = nrFullBytes
+		}
+		mode.CryptBlocks(data[pos:end], data[pos:end])
+	}
+}
+
+type byteRange struct {
+	start, end int
+}
+
+func (r byteRange) of(data []byte) []byte {
+	return data[r.start:r.end]
+}
+
+// protectedRanges - byte ranges of protected data given subsample patterns
+func protectedRanges(size int, subSamples []SubSamplePattern) ([]byteRange, error) {
+	if len(subSamples) == 0 {
+		return []byteRange{{0, size}}, nil
+	}
+	ranges := make([]byteRange, 0, len(subSamples))
+	pos := 0
+	for _, ss := range subSamples {
+		start := pos + int(ss.BytesOfClearData)
+		pos = start + int(ss.BytesOfProtectedData)
+		if pos > size {
+			return nil, fmt.Errorf("subsamples cover %d bytes, but sample has %d", pos, size)
+		}
+		if pos > start {
+			ranges = append(ranges, byteRange{start, pos})
+		}
+	}
+	return ranges, nil
+}
+
+// EncryptSamples - encrypt all samples of the track given by trex in place using full-sample encryption,
+// as is used for text and subtitle tracks. Sample data is found as in GetFullSamples.
+// If tenc.DefaultPerSampleIVSize is non-zero, iv is used for the first sample and incremented for every
+// following sample. The IVs are then stored in a senc box, and saiz and saio boxes are added to the traf.
+// Otherwise tenc.DefaultConstantIV is used for all samples and no boxes are added.
+// The IV to use for the next fragment is returned.
+func (f *Fragment) EncryptSamples(trex *TrexBox, scheme string, key []byte, tenc *TencBox, iv []byte) (nextIV []byte, err error) {
+	return f.EncryptSamplesWithSubSamples(trex, scheme, key, tenc, iv, nil)
+}
+
+// SubSampleFunc - return the subsample patterns to use when encrypting sample data
+type SubSampleFunc func(sample []byte) ([]SubSamplePattern, error)
+
+// EncryptSamplesWithSubSamples - encrypt samples as EncryptSamples, but with subsample patterns given by
+// subSampleFunc for every sample. If subSampleFunc is nil, full-sample encryption is used.
+// With subsamples, a senc box with the subsample patterns is added also if tenc has a constant IV.
+// An empty pattern from subSampleFunc means that the full sample is protected.
+func (f *Fragment) EncryptSamplesWithSubSamples(trex *TrexBox, scheme string, key []byte, tenc *TencBox, iv []byte,
+	subSampleFunc SubSampleFunc) (nextIV []byte, err error) {
+	traf := f.trafForTrack(trex.TrackID)
+	if traf == nil {
+		return nil, fmt.Errorf("no traf for trackID %d", trex.TrackID)
+	}
+	if traf.Senc != nil {
+		return nil, fmt.Errorf("traf for trackID %d already has senc", trex.TrackID)
+	}
+	samples, err := f.GetFullSamples(trex)
+	if err != nil {
+		return nil, err
+	}
+	ivSize := int(tenc.DefaultPerSampleIVSize)
+	var sampleIV []byte
+	if ivSize == 0 {
+		sampleIV = tenc.DefaultConstantIV
+	} else {
+		if len(iv) != ivSize {
+			return nil, fmt.Errorf("iv length %d differs from tenc perSampleIVSize %d", len(iv), ivSize)
+		}
+		sampleIV = make([]byte, ivSize)
+		copy(sampleIV, iv)
+	}
+	senc := CreateSencBox()
+	var sampleInfoSizes []byte
+	for i, s := range samples {
+		var subSamples []SubSamplePattern
+		if subSampleFunc != nil {
+			subSamples, err = subSampleFunc(s.Data)
+			if err != nil {
+				return nil, fmt.Errorf("sample %d: %w", i+1, err)
+			}
+			if len(subSamples) == 0 { // Keep one entry per sample in senc
+				subSamples = []SubSamplePattern{{BytesOfProtectedData: uint32(len(s.Data))}}
+			}
+		}
+		err = EncryptSample(scheme, key, sampleIV, s.Data, subSamples, tenc.DefaultCryptByteBlock, tenc.DefaultSkipByteBlock)
+		if err != nil {
+			return nil, err
+		}
+		sencSample := SencSample{SubSamples: subSamples}
+		if ivSize > 0 {
+			sencSample.IV = sampleIV
+			sampleIV = incrementIV(sampleIV)
+		}
+		err = senc.AddSample(sencSample)
+		if err != nil {
+			return nil, err
+		}
+		infoSize := ivSize
+		if subSampleFunc != nil {
+			infoSize += 2 + 6*len(subSamples)
+		}
+		if infoSize > 255 {
+			return nil, fmt.Errorf("sample %d: aux info size %d larger than 255", i+1, infoSize)
+		}
+		sampleInfoSizes = append(sampleInfoSizes, byte(infoSize))
+	}
+	if ivSize == 0 && subSampleFunc == nil {
+		return sampleIV, nil
+	}
+	sizeBefore := f.Moof.Size()
+	saiz := &SaizBox{SampleCount: senc.SampleCount, DefaultSampleInfoSize: byte(ivSize)}
+	if subSampleFunc != nil {
+		saiz.DefaultSampleInfoSize = 0
+		saiz.SampleInfo = sampleInfoSizes
+	}
+	saio := &SaioBox{Offset: []int64{0}} // Set in SetTrunDataOffsets
+	for _, b := range []Box{senc, saiz, saio} {
+		err = traf.AddChild(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.shiftDataOffsets(int64(f.Moof.Size()) - int64(sizeBefore))
+	f.setSencSaioOffsets()
+	return sampleIV, nil
+}
+
+// DecryptSamples - decrypt all samples of the track given by trex in place.
+// IVs and subsample patterns are taken from the senc box if present, otherwise from the sample auxiliary
+// information given by saiz and saio if present, and otherwise tenc.DefaultConstantIV is used for all samples.
+// The senc box and the saiz and saio boxes for the scheme are removed.
+func (f *Fragment) DecryptSamples(trex *TrexBox, scheme string, key []byte, tenc *TencBox) error {
+	traf := f.trafForTrack(trex.TrackID)
+	if traf == nil {
+		return fmt.Errorf("no traf for trackID %d", trex.TrackID)
+	}
+	samples, err := f.GetFullSamples(trex)
+	if err != nil {
+		return err
+	}
+	senc := traf.Senc
+	if senc != nil && int(senc.SampleCount) != len(samples) {
+		return fmt.Errorf("senc has %d samples, but traf has %d", senc.SampleCount, len(samples))
+	}
+	var sampleInfos []SencSample
+	if senc == nil && traf.hasCencAuxInfo(scheme) {
+		sampleInfos, err = f.GetCencSampleInfos(trex.TrackID, scheme, int(tenc.DefaultPerSampleIVSize))
+		if err != nil {
+			return err
+		}
+		if len(sampleInfos) != len(samples) {
+			return fmt.Errorf("aux info for %d samples, but traf has %d", len(sampleInfos), len(samples))
+		}
+	}
+	for i, s := range samples {
+		iv := tenc.DefaultConstantIV
+		var subSamples []SubSamplePattern
+		switch {
+		case senc != nil:
+			if len(senc.IVs) > i {
+				iv = senc.IVs[i]
+			}
+			if len(senc.SubSamples) > i {
+				subSamples = senc.SubSamples[i]
+			}
+		case sampleInfos != nil:
+			if len(sampleInfos[i].IV) > 0 {
+				iv = sampleInfos[i].IV
+			}
+			subSamples = sampleInfos[i].SubSamples
+		}
+		err = DecryptSample(scheme, key, iv, s.Data, subSamples, tenc.DefaultCryptByteBlock, tenc.DefaultSkipByteBlock)
+		if err != nil {
+			return fmt.Errorf("sample %d: %w", i+1, err)
+		}
+	}
+	sizeBefore := f.Moof.Size()
+	var boxesToRemove []Box
+	if senc != nil {
+		boxesToRemove = append(boxesToRemove, senc)
+	}
+	for _, auxInfoType := range []string{"", scheme} {
+		saiz, saio := traf.GetSaizSaio(auxInfoType)
+		if saiz != nil {
+			boxesToRemove = append(boxesToRemove, saiz)
+		}
+		if saio != nil {
+			boxesToRemove = append(boxesToRemove, saio)
+		}
+	}
+	for _, b := range boxesToRemove {
+		err = RemoveChild(traf, b)
+		if err != nil {
+			return err
+		}
+	}
+	f.shiftDataOffsets(int64(f.Moof.Size()) - int64(sizeBefore))
+	return nil
+}
+
+// hasCencAuxInfo - true if traf has a saiz/saio pair without aux info type or with type scheme
+func (t *TrafBox) hasCencAuxInfo(scheme string) bool {
+	for _, auxInfoType := range []string{"", scheme} {
+		if saiz, saio := t.GetSaizSaio(auxInfoType); saiz != nil && saio != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fragment) trafForTrack(trackID uint32) *TrafBox {
+	for _, traf := range f.Moof.Trafs {
+		if traf.Tfhd.TrackID == trackID {
+			return traf
+		}
+	}
+	return nil
+}
+
+// shiftDataOffsets - update trun data offsets and mdat position after moof changed size by delta bytes
+func (f *Fragment) shiftDataOffsets(delta int64) {
+	if delta == 0 {
+		return
+	}
+	for _, traf := range f.Moof.Trafs {
+		if traf.Tfhd.HasBaseDataOffset() {
+			traf.Tfhd.BaseDataOffset = uint64(int64(traf.Tfhd.BaseDataOffset) + delta)
+			continue
+		}
+		for _, trun := range traf.Truns {
+			if trun.HasDataOffset() {
+				trun.DataOffset += int32(delta)
+			}
+		}
+	}
+	f.Mdat.StartPos = uint64(int64(f.Mdat.StartPos) + delta)
+}
+
+// setSencSaioOffsets - set saio offsets to the start of the sample data in senc for trafs with
+// default-base-is-moof and an saio box without explicit aux info type.
+func (f *Fragment) setSencSaioOffsets() {
+	pos := uint64(boxHeaderSize)
+	for _, c := range f.Moof.Children {
+		traf, ok := c.(*TrafBox)
+		if !ok || traf.Senc == nil || traf.Tfhd == nil || !traf.Tfhd.DefaultBaseIfMoof() || traf.Tfhd.HasBaseDataOffset() {
+			pos += c.Size()
+			continue
+		}
+		_, saio := traf.GetSaizSaio("")
+		childPos := pos + boxHeaderSize
+		for _, tc := range traf.Children {
+			if tc == traf.Senc && saio != nil && len(saio.Offset) == 1 {
+				saio.Offset[0] = int64(childPos) + 16 // Skip header, version and flags, and sample count
+			}
+			childPos += tc.Size()
+		}
+		pos += c.Size()
+	}
+}
+
+// incrementIV - return iv interpreted as a big-endian counter incremented by one
+func incrementIV(iv []byte) []byte {
+	next := make([]byte, len(iv))
+	copy(next, iv)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// NALUSubSamples - subsample patterns for a video sample of length-prefixed AVC ("avc") or HEVC ("hevc") NAL units.
+// Non-VCL NAL units are left in clear. For VCL NAL units, the length field and the NAL unit header are left
+// in clear, and the protected part is a multiple of 16 bytes, with any remainder put in clear data.
+// lengthSize is the size of the NAL unit length fields (1, 2, or 4 bytes) as given by avcC or hvcC.
+func NALUSubSamples(codec string, sample []byte, lengthSize int) ([]SubSamplePattern, error) {
+	if lengthSize != 1 && lengthSize != 2 && lengthSize != 4 {
+		return nil, fmt.Errorf("NAL unit length size %d not 1, 2, or 4", lengthSize)
+	}
+	var naluHdrLen int
+	switch codec {
+	case "avc":
+		naluHdrLen = 1
+	case "hevc":
+		naluHdrLen = 2
+	default:
+		return nil, fmt.Errorf("codec %q not supported for subsamples", codec)
+	}
+	var subSamples []SubSamplePattern
+	clear := 0
+	pos := 0
+	for pos < len(sample) {
+		if pos+lengthSize+naluHdrLen > len(sample) {
+			return nil, fmt.Errorf("NAL unit at %d shorter than header", pos)
+		}
+		var naluLen int
+		switch lengthSize {
+		case 1:
+			naluLen = int(sample[pos])
+		case 2:
+			naluLen = int(binary.BigEndian.Uint16(sample[pos:]))
+		default:
+			naluLen = int(binary.BigEndian.Uint32(sample[pos:]))
+		}
+		hdrPos := pos + lengthSize
+		end := hdrPos + naluLen
+		if naluLen < naluHdrLen || end > len(sample) {
+			return nil, fmt.Errorf("bad NAL unit length %d at %d", naluLen, pos)
+		}
+		isVCL := false
+		if codec == "avc" {
+			naluType := avc.GetNaluType(sample[hdrPos])
+			isVCL = naluType >= avc.NALU_NON_IDR && naluType <= avc.NALU_IDR
+		} else {
+			isVCL = hevc.GetNaluType(sample[hdrPos]) < 32
+		}
+		protected := 0
+		if isVCL {
+			protected = (naluLen - naluHdrLen) / aes.BlockSize * aes.BlockSize
+		}
+		clear += lengthSize + naluLen - protected
+		if protected > 0 {
+			for clear > 0xffff {
+				subSamples = append(subSamples, SubSamplePattern{BytesOfClearData: 0xffff})
+				clear -= 0xffff
+			}
+			subSamples = append(subSamples, SubSamplePattern{uint16(clear), uint32(protected)})
+			clear = 0
+		}
+		pos = end
+	}
+	for clear > 0 {
+		n := clear
+		if n > 0xffff {
+			n = 0xffff
+		}
+		subSamples = append(subSamples, SubSamplePattern{BytesOfClearData: uint16(n)})
+		clear -= n
+	}
+	return subSamples, nil
+}
+
+// ProtectSampleEntry - change the first unprotected visual, audio, or text sample entry in stsd to
+// encv, enca, or enct respectively, and add a sinf box with frma, schm for scheme, and schi with tenc.
+func (s *StsdBox) ProtectSampleEntry(scheme string, tenc *TencBox) error {
+	if scheme != SchemeCENC && scheme != SchemeCBCS {
+		return fmt.Errorf("protection scheme %q not supported", scheme)
+	}
+	for _, c := range s.Children {
+		switch e := c.(type) {
+		case *VisualSampleEntryBox:
+			if e.Sinf == nil {
+				e.AddChild(createSinf(e.Type(), scheme, tenc))
+				e.name = "encv"
+				return nil
+			}
+		case *AudioSampleEntryBox:
+			if e.Sinf == nil {
+				e.AddChild(createSinf(e.Type(), scheme, tenc))
+				e.name = "enca"
+				return nil
+			}
+		case *WvttBox, *StppBox:
+			return s.ProtectTextSampleEntry(scheme, tenc)
+		}
+	}
+	return fmt.Errorf("no unprotected visual, audio, or text sample entry")
+}
@@ -0,0 +1,54 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestEncryptDecryptSample(t *testing.T) {
+	key, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	plain := make([]byte, 100)
+	for i := range plain {
+		plain[i] = byte(i)
+	}
+	testCases := []struct {
+		desc        string
+		scheme      string
+		iv          string
+		subSamples  []SubSamplePattern
+		crypt, skip byte
+	}{
+		{"cenc full sample", SchemeCENC, "0102030405060708", nil, 0, 0},
+		{"cenc subsamples", SchemeCENC, "0102030405060708090a0b0c0d0e0f10",
+			[]SubSamplePattern{{10, 30}, {5, 55}}, 0, 0},
+		{"cbcs full sample", SchemeCBCS, "0102030405060708090a0b0c0d0e0f10", nil, 0, 0},
+		{"cbcs pattern", SchemeCBCS, "0102030405060708090a0b0c0d0e0f10",
+			[]SubSamplePattern{{4, 96}}, 1, 1},
+	}
+	for _, tc := range testCases {
+		iv, _ := hex.DecodeString(tc.iv)
+		data := make([]byte, len(plain))
+		copy(data, plain)
+		err := EncryptSample(tc.scheme, key, iv, data, tc.subSamples, tc.crypt, tc.skip)
+		assertNoError(t, err)
+		if bytes.Equal(data, plain) {
+			t.Errorf("%s: data not encrypted", tc.desc)
+		}
+		if len(tc.subSamples) > 0 && !bytes.Equal(data[:tc.subSamples[0].BytesOfClearData], plain[:tc.subSamples[0].BytesOfClearData]) {
+			t.Errorf("%s: clear bytes changed", tc.desc)
+		}
+		if tc.scheme == SchemeCBCS && !bytes.Equal(data[96:], plain[96:]) {
+			t.Errorf("%s: partial last block not left in clear", tc.desc)
+		}
+		err = DecryptSample(tc.scheme, key, iv, data, tc.subSamples, tc.crypt, tc.skip)
+		assertNoError(t, err)
+		if !bytes.Equal(data, plain) {
+			t.Errorf("%s: decrypted data differs from original", tc.desc)
+		}
+	}
+	err := EncryptSample(SchemeCENC, key, make([]byte, 8), make([]byte, 10), []SubSamplePattern{{5, 10}}, 0, 0)
+	assertError(t, err, "subsamples beyond sample should give error")
+	err = EncryptSample("cens", key, make([]byte, 8), make([]byte, 10), nil, 0, 0)
+	assertError(t, err, "unsupported scheme should give error")
+}
@@ -0,0 +1,68 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// ParseCencSampleInfo - parse CENC sample auxiliary information for one sample, i.e. an IV of
+// perSampleIVSize bytes optionally followed by subsample count and subsample patterns.
+// perSampleIVSize is 0 if a constant IV is used. ISO/IEC 23001-7 Section 7.1
+func ParseCencSampleInfo(data []byte, perSampleIVSize int) (SencSample, error) {
+	var sample SencSample
+	if len(data) < perSampleIVSize {
+		return sample, fmt.Errorf("aux info size %d smaller than IV size %d", len(data), perSampleIVSize)
+	}
+	sr := NewSliceReader(data)
+	if perSampleIVSize > 0 {
+		sample.IV = sr.ReadBytes(perSampleIVSize)
+	}
+	if sr.NrRemainingBytes() == 0 {
+		return sample, nil
+	}
+	if sr.NrRemainingBytes() < 2 {
+		return sample, fmt.Errorf("aux info size %d does not match IV size %d", len(data), perSampleIVSize)
+	}
+	subsampleCount := int(sr.ReadUint16())
+	if sr.NrRemainingBytes() != 6*subsampleCount {
+		return sample, fmt.Errorf("aux info size %d does not match %d subsamples", len(data), subsampleCount)
+	}
+	for i := 0; i < subsampleCount; i++ {
+		sample.SubSamples = append(sample.SubSamples, SubSamplePattern{
+			BytesOfClearData:     sr.ReadUint16(),
+			BytesOfProtectedData: sr.ReadUint32(),
+		})
+	}
+	return sample, nil
+}
+
+// GetCencSampleInfos - per-sample IVs and subsample patterns of track trackID, resolved by following
+// the saio offsets of the saiz/saio pair without aux info type, or with aux info type scheme, into
+// the moof box or the mdat box. This works also when there is no senc box, e.g. when the
+// information is stored in mdat. perSampleIVSize is given by the tenc box.
+func (f *Fragment) GetCencSampleInfos(trackID uint32, scheme string, perSampleIVSize int) ([]SencSample, error) {
+	traf := f.trafForTrack(trackID)
+	if traf == nil {
+		return nil, fmt.Errorf("no traf for trackID %d", trackID)
+	}
+	auxInfoType := ""
+	if saiz, saio := traf.GetSaizSaio(""); saiz == nil || saio == nil {
+		auxInfoType = scheme
+	}
+	infos, err := f.GetAuxInfos(trackID, auxInfoType)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]SencSample, 0, len(infos))
+	for i, info := range infos {
+		raw, ok := info.(*RawAuxInfo)
+		if !ok {
+			return nil, fmt.Errorf("sample %d: aux info of type %T is not raw CENC data", i+1, info)
+		}
+		sample, err := ParseCencSampleInfo(raw.Data, perSampleIVSize)
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %w", i+1, err)
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
@@ -0,0 +1,74 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestCencSampleInfosFromAuxInfo(t *testing.T) {
+	key, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	iv, _ := hex.DecodeString("0102030405060708")
+	tenc := &TencBox{DefaultIsProtected: 1, DefaultPerSampleIVSize: 8, DefaultKID: UUID(make([]byte, 16))}
+	trex := &TrexBox{TrackID: 1}
+	clearSamples := createProgTestSamples(3, 1000, 64, 1, 0x40)
+
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	for _, s := range clearSamples {
+		frag.AddFullSample(s)
+	}
+	frag = fragmentAfterEncodeAndDecode(t, frag)
+	subSampleFunc := func(sample []byte) ([]SubSamplePattern, error) {
+		return []SubSamplePattern{{BytesOfClearData: 16, BytesOfProtectedData: uint32(len(sample) - 16)}}, nil
+	}
+	_, err = frag.EncryptSamplesWithSubSamples(trex, SchemeCENC, key, tenc, iv, subSampleFunc)
+	assertNoError(t, err)
+	frag = fragmentAfterEncodeAndDecode(t, frag)
+
+	infos, err := frag.GetCencSampleInfos(1, SchemeCENC, 8)
+	assertNoError(t, err)
+	senc := frag.Moof.Traf.Senc
+	if len(infos) != 3 {
+		t.Fatalf("got %d sample infos instead of 3", len(infos))
+	}
+	for i, info := range infos {
+		if !bytes.Equal(info.IV, senc.IVs[i]) || len(info.SubSamples) != 1 || info.SubSamples[0] != senc.SubSamples[i][0] {
+			t.Errorf("sample %d: aux info %+v differs from senc", i+1, info)
+		}
+	}
+
+	// Without typed senc, decryption must follow saio offsets to the sample auxiliary information
+	frag.Moof.Traf.Senc = nil
+	assertNoError(t, frag.DecryptSamples(trex, SchemeCENC, key, tenc))
+	samples, err := frag.GetFullSamples(trex)
+	assertNoError(t, err)
+	for i, s := range samples {
+		if !bytes.Equal(s.Data, clearSamples[i].Data) {
+			t.Errorf("sample %d not decrypted", i+1)
+		}
+	}
+}
+
+func TestCencSampleInfoInMdat(t *testing.T) {
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	for _, s := range createProgTestSamples(2, 1000, 10, 1, 0) {
+		frag.AddFullSample(s)
+	}
+	auxInfos := []AuxInfo{
+		&RawAuxInfo{Data: []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		&RawAuxInfo{Data: []byte{1, 2, 3, 4, 5, 6, 7, 9, 0, 1, 0, 2, 0, 0, 0, 8}},
+	}
+	assertNoError(t, frag.AddAuxInfos(1, SchemeCENC, 0, auxInfos))
+	frag = fragmentAfterEncodeAndDecode(t, frag)
+	infos, err := frag.GetCencSampleInfos(1, SchemeCENC, 8)
+	assertNoError(t, err)
+	if len(infos) != 2 || infos[0].SubSamples != nil || infos[1].IV[7] != 9 ||
+		infos[1].SubSamples[0] != (SubSamplePattern{BytesOfClearData: 2, BytesOfProtectedData: 8}) {
+		t.Errorf("got sample infos %+v", infos)
+	}
+
+	_, err = ParseCencSampleInfo([]byte{1, 2, 3, 4, 5, 6, 7, 8, 0, 2, 0}, 8)
+	assertError(t, err, "truncated subsample data should give error")
+}
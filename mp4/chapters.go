@@ -0,0 +1,165 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Chapter - chapter with start time and title
+type Chapter struct {
+	Start time.Duration
+	Title string
+}
+
+// AddChapterTrack - add a disabled text track with tx3g sample entry for chapters, and reference it
+// by chap track references from the tracks refTrackIDs. The chapter samples can be created by
+// CreateChapterSamples and muxed together with the other tracks by CreateProgressiveFile.
+func (s *InitSegment) AddChapterTrack(timescale uint32, language string, refTrackIDs ...uint32) (*TrakBox, error) {
+	moov := s.Moov
+	refTraks := make([]*TrakBox, 0, len(refTrackIDs))
+	for _, trackID := range refTrackIDs {
+		trak := moov.GetTrak(trackID)
+		if trak == nil {
+			return nil, fmt.Errorf("no track with trackID %d", trackID)
+		}
+		refTraks = append(refTraks, trak)
+	}
+	s.AddEmptyTrack(timescale, "text", language)
+	chapTrak := moov.Traks[len(moov.Traks)-1]
+	chapTrak.Tkhd.Flags = 0 // Chapter tracks are disabled and only read through chap references
+	chapTrak.Mdia.Minf.Stbl.Stsd.AddChild(CreateTx3g("Sans-Serif", 18))
+	chapTrackID := chapTrak.Tkhd.TrackID
+	for _, trak := range refTraks {
+		trak.AddTrackReference(TrefChap, chapTrackID)
+	}
+	return chapTrak, nil
+}
+
+// CreateChapterSamples - create tx3g samples in timescale for chapters ordered by start time.
+// Each chapter lasts until the next one, and the last one until duration given in timescale.
+// If the first chapter does not start at 0, an empty sample is inserted before it.
+func CreateChapterSamples(chapters []Chapter, timescale uint32, duration uint64) ([]FullSample, error) {
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no chapters")
+	}
+	starts := make([]uint64, 0, len(chapters)+1)
+	titles := make([]string, 0, len(chapters)+1)
+	for i, c := range chapters {
+		if c.Start < 0 || (i > 0 && c.Start <= chapters[i-1].Start) {
+			return nil, fmt.Errorf("chapter %d: start %s not after previous chapter", i+1, c.Start)
+		}
+		start := uint64(c.Start) * uint64(timescale) / uint64(time.Second)
+		if i == 0 && start > 0 {
+			starts = append(starts, 0)
+			titles = append(titles, "")
+		}
+		starts = append(starts, start)
+		titles = append(titles, c.Title)
+	}
+	if duration <= starts[len(starts)-1] {
+		return nil, fmt.Errorf("duration %d not after last chapter start", duration)
+	}
+	starts = append(starts, duration)
+	samples := make([]FullSample, 0, len(titles))
+	for i, title := range titles {
+		dur := starts[i+1] - starts[i]
+		if dur > 0xffffffff {
+			return nil, fmt.Errorf("chapter %q too long", title)
+		}
+		data, err := (&Tx3gSample{Text: title}).Encode()
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, FullSample{
+			Sample:     NewSample(SyncSampleFlags, uint32(dur), uint32(len(data)), 0),
+			DecodeTime: starts[i],
+			Data:       data,
+		})
+	}
+	return samples, nil
+}
+
+// GetChapters - get chapters from the chapter track referenced by chap of the first track having such
+// a reference, or else from a chpl box in moov/udta. Samples with empty text are skipped.
+// For a lazily decoded file, sample data is read from rs.
+func (f *File) GetChapters(rs io.ReadSeeker) ([]Chapter, error) {
+	if f.Moov == nil {
+		return nil, fmt.Errorf("no moov box")
+	}
+	for _, trak := range f.Moov.Traks {
+		refs := trak.GetTrackReference(TrefChap)
+		if len(refs) == 0 {
+			continue
+		}
+		chapTrak := f.Moov.GetTrak(refs[0])
+		if chapTrak == nil {
+			return nil, fmt.Errorf("chapter track %d not found", refs[0])
+		}
+		return f.chapterTrackChapters(rs, chapTrak)
+	}
+	if chpl := f.Moov.chpl(); chpl != nil {
+		return chpl.GetChapters(), nil
+	}
+	return nil, fmt.Errorf("no chapters")
+}
+
+// chapterTrackChapters - chapters from the tx3g samples of a progressive chapter track
+func (f *File) chapterTrackChapters(rs io.ReadSeeker, trak *TrakBox) ([]Chapter, error) {
+	stts := trak.Mdia.Minf.Stbl.Stts
+	timescale := uint64(trak.Mdia.Mdhd.Timescale)
+	var chapters []Chapter
+	for nr := uint32(1); nr <= trak.GetNrSamples(); nr++ {
+		buf := bytes.Buffer{}
+		err := f.CopySampleData(&buf, rs, trak, nr, nr)
+		if err != nil {
+			return nil, err
+		}
+		sample, err := DecodeTx3gSample(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("chapter sample %d: %w", nr, err)
+		}
+		if sample.Text == "" {
+			continue
+		}
+		decTime, _ := stts.GetDecodeTime(nr)
+		start := time.Duration(decTime * uint64(time.Second) / timescale)
+		chapters = append(chapters, Chapter{Start: start, Title: sample.Text})
+	}
+	return chapters, nil
+}
+
+// SetChpl - set chapters as a chpl box in moov/udta, replacing any existing chpl box.
+// Chunk offsets of progressive files are made consistent by Encode.
+func (f *File) SetChpl(chapters []Chapter) error {
+	if f.Moov == nil {
+		return fmt.Errorf("no moov box")
+	}
+	chpl, err := CreateChpl(chapters)
+	if err != nil {
+		return err
+	}
+	moov := f.Moov
+	if moov.Udta == nil {
+		moov.AddChild(&UdtaBox{})
+	}
+	if old := moov.chpl(); old != nil {
+		moov.Udta.Children = removeBox(moov.Udta.Children, old)
+	}
+	moov.Udta.AddChild(chpl)
+	return nil
+}
+
+// chpl - chpl box in moov/udta or nil
+func (m *MoovBox) chpl() *ChplBox {
+	if m.Udta == nil {
+		return nil
+	}
+	for _, c := range m.Udta.Children {
+		if chpl, ok := c.(*ChplBox); ok {
+			return chpl
+		}
+	}
+	return nil
+}
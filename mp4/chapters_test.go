@@ -0,0 +1,72 @@
+package mp4
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncDecChpl(t *testing.T) {
+	chpl, err := CreateChpl([]Chapter{{0, "Start"}, {90 * time.Second, "Middle"}})
+	assertNoError(t, err)
+	boxDiffAfterEncodeAndDecode(t, chpl)
+	boxDiffAfterEncodeAndDecode(t, &ChplBox{Chapters: []ChplChapter{{StartTime: 10000000, Title: "v0"}}})
+}
+
+func TestChapterTrack(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	chapTrak, err := init.AddChapterTrack(1000, "eng", 1)
+	assertNoError(t, err)
+	_, err = init.AddChapterTrack(1000, "eng", 7)
+	assertError(t, err, "reference from non-existing track should give error")
+
+	chapters := []Chapter{{100 * time.Millisecond, "First"}, {250 * time.Millisecond, "Second"}}
+	chapSamples, err := CreateChapterSamples(chapters, 1000, 400)
+	assertNoError(t, err)
+	if len(chapSamples) != 3 || chapSamples[0].Dur != 100 || chapSamples[2].Dur != 150 {
+		t.Errorf("wrong chapter samples %v", chapSamples)
+	}
+	_, err = CreateChapterSamples(chapters, 1000, 250)
+	assertError(t, err, "duration before last chapter should give error")
+
+	samples := createProgTestSamples(10, 3600, 100, 5, 0x10)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples, chapTrak.Tkhd.TrackID: chapSamples},
+		Interleaving{Mode: InterleaveBySize, ChunkSize: 300})
+	assertNoError(t, err)
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	if refs := decFile.Moov.Traks[0].GetTrackReference("chap"); len(refs) != 1 || refs[0] != 2 {
+		t.Errorf("got chap references %v instead of [2]", refs)
+	}
+	got, err := decFile.GetChapters(nil)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(got, chapters) {
+		t.Errorf("got chapters %v instead of %v", got, chapters)
+	}
+}
+
+func TestSetChpl(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(10, 3600, 100, 5, 0x10)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 300})
+	assertNoError(t, err)
+	_, err = f.GetChapters(nil)
+	assertError(t, err, "file without chapters should give error")
+	assertNoError(t, f.SetChpl([]Chapter{{0, "Old"}}))
+	chapters := []Chapter{{0, "One"}, {200 * time.Millisecond, "Two"}}
+	assertNoError(t, f.SetChpl(chapters))
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	got, err := decFile.GetChapters(nil)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(got, chapters) {
+		t.Errorf("got chapters %v instead of %v", got, chapters)
+	}
+}
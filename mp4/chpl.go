@@ -0,0 +1,139 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// ChplBox - Nero chapter list box (chpl) in moov/udta as written by ffmpeg.
+// Chapter start times are in units of 100 ns.
+type ChplBox struct {
+	Version  byte
+	Flags    uint32
+	Reserved uint32 // Only present for version 1
+	Chapters []ChplChapter
+}
+
+// ChplChapter - chapter entry in ChplBox
+type ChplChapter struct {
+	StartTime uint64 // In units of 100 ns
+	Title     string
+}
+
+// CreateChpl - create version 1 ChplBox from chapters. Titles are truncated to 255 bytes
+func CreateChpl(chapters []Chapter) (*ChplBox, error) {
+	if len(chapters) > 255 {
+		return nil, fmt.Errorf("%d chapters, but chpl supports at most 255", len(chapters))
+	}
+	b := &ChplBox{Version: 1}
+	for _, c := range chapters {
+		title := c.Title
+		if len(title) > 255 {
+			title = title[:255]
+		}
+		b.Chapters = append(b.Chapters, ChplChapter{StartTime: uint64(c.Start / 100), Title: title})
+	}
+	return b, nil
+}
+
+// GetChapters - chapters with start times as time.Duration
+func (b *ChplBox) GetChapters() []Chapter {
+	chapters := make([]Chapter, 0, len(b.Chapters))
+	for _, c := range b.Chapters {
+		chapters = append(chapters, Chapter{Start: time.Duration(c.StartTime) * 100, Title: c.Title})
+	}
+	return chapters
+}
+
+// DecodeChpl - box-specific decode
+func DecodeChpl(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	sr := NewSliceReader(data)
+	if len(data) < 5 {
+		return nil, fmt.Errorf("chpl: payload too short")
+	}
+	versionAndFlags := sr.ReadUint32()
+	b := &ChplBox{
+		Version: byte(versionAndFlags >> 24),
+		Flags:   versionAndFlags & flagsMask,
+	}
+	if b.Version == 1 {
+		if len(data) < 9 {
+			return nil, fmt.Errorf("chpl: payload too short")
+		}
+		b.Reserved = sr.ReadUint32()
+	}
+	nrChapters := int(sr.ReadUint8())
+	for i := 0; i < nrChapters; i++ {
+		if sr.NrRemainingBytes() < 9 {
+			return nil, fmt.Errorf("chpl: chapter %d beyond payload", i+1)
+		}
+		c := ChplChapter{StartTime: sr.ReadUint64()}
+		titleLen := int(sr.ReadUint8())
+		if sr.NrRemainingBytes() < titleLen {
+			return nil, fmt.Errorf("chpl: title of chapter %d beyond payload", i+1)
+		}
+		c.Title = sr.ReadFixedLengthString(titleLen)
+		b.Chapters = append(b.Chapters, c)
+	}
+	return b, nil
+}
+
+// Type - box type
+func (b *ChplBox) Type() string {
+	return "chpl"
+}
+
+// Size - calculated size of box
+func (b *ChplBox) Size() uint64 {
+	size := boxHeaderSize + 4 + 1
+	if b.Version == 1 {
+		size += 4
+	}
+	for _, c := range b.Chapters {
+		size += 8 + 1 + len(c.Title)
+	}
+	return uint64(size)
+}
+
+// Encode - write box to w
+func (b *ChplBox) Encode(w io.Writer) error {
+	if len(b.Chapters) > 255 {
+		return fmt.Errorf("chpl: %d chapters, but at most 255 allowed", len(b.Chapters))
+	}
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint32((uint32(b.Version) << 24) + b.Flags)
+	if b.Version == 1 {
+		sw.WriteUint32(b.Reserved)
+	}
+	sw.WriteUint8(byte(len(b.Chapters)))
+	for _, c := range b.Chapters {
+		if len(c.Title) > 255 {
+			return fmt.Errorf("chpl: title %q longer than 255 bytes", c.Title)
+		}
+		sw.WriteUint64(c.StartTime)
+		sw.WriteUint8(byte(len(c.Title)))
+		sw.WriteString(c.Title, false)
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *ChplBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	for i, c := range b.Chapters {
+		bd.write(" - chapter[%d]: start=%s title=%q", i+1, time.Duration(c.StartTime)*100, c.Title)
+	}
+	return bd.err
+}
@@ -0,0 +1,104 @@
+package mp4
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ChunkInfo - size and timing of a CMAF chunk, i.e. a fragment with optional prft and emsg boxes
+// followed by moof and mdat, for a single track
+type ChunkInfo struct {
+	Index       int    `json:"index"`       // 0-based chunk index in file
+	SegmentNr   int    `json:"segmentNr"`   // 0-based index of segment containing the chunk
+	TrackID     uint32 `json:"trackID"`     // Track described by DecodeTime, Duration, and ContainsIDR
+	DecodeTime  uint64 `json:"decodeTime"`  // Decode time of first sample in track timescale
+	Duration    uint64 `json:"duration"`    // Sum of sample durations in track timescale
+	NrSamples   uint32 `json:"nrSamples"`   // Number of samples of the track
+	Bytes       uint64 `json:"bytes"`       // Size of all boxes in the chunk
+	ContainsIDR bool   `json:"containsIDR"` // True if the chunk contains a sync sample of the track
+}
+
+// GetChunkReport - per-chunk report for track trackID in a fragmented file, e.g. for low-latency tuning.
+// trackID 0 means the first track in the init segment, or in the first fragment if there is no init segment.
+// Sync sample flags are used to find IDR frames, with defaults from the trex given by TrexForTrack.
+func (f *File) GetChunkReport(trackID uint32) ([]ChunkInfo, error) {
+	if !f.isFragmented {
+		return nil, fmt.Errorf("file is not fragmented")
+	}
+	if f.Init != nil && trackID == 0 && len(f.Init.Moov.Traks) > 0 {
+		trackID = f.Init.Moov.Traks[0].Tkhd.TrackID
+	}
+	trex := f.TrexForTrack(trackID)
+	var chunks []ChunkInfo
+	for i, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			if frag.Moof == nil {
+				return nil, fmt.Errorf("segment %d: fragment without moof", i)
+			}
+			if trackID == 0 && len(frag.Moof.Trafs) > 0 {
+				trackID = frag.Moof.Trafs[0].Tfhd.TrackID
+			}
+			chunk := ChunkInfo{
+				Index:     len(chunks),
+				SegmentNr: i,
+				TrackID:   trackID,
+				Bytes:     frag.Size(),
+			}
+			for _, traf := range frag.Moof.Trafs {
+				if traf.Tfhd.TrackID != trackID {
+					continue
+				}
+				if traf.Tfdt != nil {
+					chunk.DecodeTime = traf.Tfdt.BaseMediaDecodeTime
+				}
+				for _, trun := range traf.Truns {
+					chunk.Duration += trun.AddSampleDefaultValues(traf.Tfhd, trex)
+					chunk.NrSamples += trun.SampleCount()
+					for _, s := range trun.Samples {
+						if IsSyncSampleFlags(s.Flags) {
+							chunk.ContainsIDR = true
+						}
+					}
+				}
+			}
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+// WriteChunkReportCSV - write chunks as CSV with a header line
+func WriteChunkReportCSV(w io.Writer, chunks []ChunkInfo) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"index", "segmentNr", "trackID", "decodeTime", "duration", "nrSamples", "bytes", "containsIDR"})
+	if err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		err = cw.Write([]string{
+			strconv.Itoa(c.Index),
+			strconv.Itoa(c.SegmentNr),
+			strconv.FormatUint(uint64(c.TrackID), 10),
+			strconv.FormatUint(c.DecodeTime, 10),
+			strconv.FormatUint(c.Duration, 10),
+			strconv.FormatUint(uint64(c.NrSamples), 10),
+			strconv.FormatUint(c.Bytes, 10),
+			strconv.FormatBool(c.ContainsIDR),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteChunkReportJSON - write chunks as an indented JSON array
+func WriteChunkReportJSON(w io.Writer, chunks []ChunkInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(chunks)
+}
@@ -0,0 +1,57 @@
+package mp4
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestChunkReport(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	// Two segments with two chunks of 5 samples each. Only the first chunk of each segment has an IDR
+	samples := createProgTestSamples(20, 3600, 100, 10, 0x30)
+	for segNr := 0; segNr < 2; segNr++ {
+		seg := NewMediaSegment()
+		for chunkNr := 0; chunkNr < 2; chunkNr++ {
+			frag, err := CreateFragment(uint32(2*segNr+chunkNr+1), 1)
+			assertNoError(t, err)
+			for _, s := range samples[10*segNr+5*chunkNr : 10*segNr+5*chunkNr+5] {
+				frag.AddFullSample(s)
+			}
+			seg.AddFragment(frag)
+		}
+		assertNoError(t, seg.Encode(&buf))
+	}
+	f, err := DecodeFile(&buf)
+	assertNoError(t, err)
+
+	chunks, err := f.GetChunkReport(0)
+	assertNoError(t, err)
+	if len(chunks) != 4 {
+		t.Fatalf("got %d chunks instead of 4", len(chunks))
+	}
+	for i, c := range chunks {
+		frag := f.Segments[i/2].Fragments[i%2]
+		wanted := ChunkInfo{Index: i, SegmentNr: i / 2, TrackID: 1, DecodeTime: uint64(i) * 5 * 3600,
+			Duration: 5 * 3600, NrSamples: 5, Bytes: frag.Size(), ContainsIDR: i%2 == 0}
+		if c != wanted {
+			t.Errorf("chunk %d: got %+v instead of %+v", i, c, wanted)
+		}
+	}
+
+	out := bytes.Buffer{}
+	assertNoError(t, WriteChunkReportCSV(&out, chunks))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 || lines[0] != "index,segmentNr,trackID,decodeTime,duration,nrSamples,bytes,containsIDR" ||
+		!strings.HasSuffix(lines[2], ",false") {
+		t.Errorf("unexpected CSV output:\n%s", out.String())
+	}
+	out.Reset()
+	assertNoError(t, WriteChunkReportJSON(&out, chunks))
+	if !strings.Contains(out.String(), `"containsIDR": true`) {
+		t.Errorf("unexpected JSON output:\n%s", out.String())
+	}
+}
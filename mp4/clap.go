@@ -1,11 +1,15 @@
 package mp4
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 )
 
 // ClapBox - Clean Aperture Box, ISO/IEC 14496-12 2020 Sec. 12.1.4
+// All values are fractions N/D. The offsets give the position of the clean aperture center relative
+// to the center of the full picture. HorizOffN and VertOffN are signed numbers in two's complement,
+// and are available as signed values with SignedHorizOffN and SignedVertOffN.
 type ClapBox struct {
 	CleanApertureWidthN  uint32
 	CleanApertureWidthD  uint32
@@ -17,6 +21,47 @@ type ClapBox struct {
 	VertOffD             uint32
 }
 
+// CreateClap - create ClapBox for a crop of cropWidth x cropHeight pixels starting at (left, top)
+// in a picture of width x height pixels
+func CreateClap(width, height, cropWidth, cropHeight, left, top uint32) *ClapBox {
+	// The offset of the centers is ((2*left + cropWidth) - width) / 2
+	return &ClapBox{
+		CleanApertureWidthN:  cropWidth,
+		CleanApertureWidthD:  1,
+		CleanApertureHeightN: cropHeight,
+		CleanApertureHeightD: 1,
+		HorizOffN:            uint32(int32(2*left+cropWidth) - int32(width)),
+		HorizOffD:            2,
+		VertOffN:             uint32(int32(2*top+cropHeight) - int32(height)),
+		VertOffD:             2,
+	}
+}
+
+// SignedHorizOffN - numerator of the horizontal offset as a signed value
+func (b *ClapBox) SignedHorizOffN() int32 {
+	return int32(b.HorizOffN)
+}
+
+// SignedVertOffN - numerator of the vertical offset as a signed value
+func (b *ClapBox) SignedVertOffN() int32 {
+	return int32(b.VertOffN)
+}
+
+// CropRect - crop rectangle in a picture of width x height pixels as left and top position and size.
+// Fractional values are kept. An error is returned if any denominator is 0.
+func (b *ClapBox) CropRect(width, height uint16) (left, top, cropWidth, cropHeight float64, err error) {
+	if b.CleanApertureWidthD == 0 || b.CleanApertureHeightD == 0 || b.HorizOffD == 0 || b.VertOffD == 0 {
+		return 0, 0, 0, 0, fmt.Errorf("clap: zero denominator")
+	}
+	cropWidth = float64(b.CleanApertureWidthN) / float64(b.CleanApertureWidthD)
+	cropHeight = float64(b.CleanApertureHeightN) / float64(b.CleanApertureHeightD)
+	horizOff := float64(b.SignedHorizOffN()) / float64(b.HorizOffD)
+	vertOff := float64(b.SignedVertOffN()) / float64(b.VertOffD)
+	left = (float64(width)-cropWidth)/2 + horizOff
+	top = (float64(height)-cropHeight)/2 + vertOff
+	return left, top, cropWidth, cropHeight, nil
+}
+
 // DecodeClap - box-specific decode
 func DecodeClap(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	data, err := ioutil.ReadAll(r)
@@ -69,9 +114,9 @@ func (b *ClapBox) Encode(w io.Writer) error {
 // Info - write box-specific information
 func (b *ClapBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	bd := newInfoDumper(w, indent, b, -1, 0)
-	bd.write(" - cleanAperturWidth: %d/%d", b.CleanApertureWidthN, b.CleanApertureWidthD)
+	bd.write(" - cleanApertureWidth: %d/%d", b.CleanApertureWidthN, b.CleanApertureWidthD)
 	bd.write(" - cleanApertureHeight: %d/%d", b.CleanApertureHeightN, b.CleanApertureHeightD)
-	bd.write(" - horizOff: %d/%d", b.HorizOffN, b.HorizOffD)
-	bd.write(" - vertOff: %d/%d", b.VertOffN, b.VertOffD)
+	bd.write(" - horizOff: %d/%d", b.SignedHorizOffN(), b.HorizOffD)
+	bd.write(" - vertOff: %d/%d", b.SignedVertOffN(), b.VertOffD)
 	return bd.err
 }
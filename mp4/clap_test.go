@@ -1,6 +1,8 @@
 package mp4
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -9,8 +11,41 @@ func TestEncDecClap(t *testing.T) {
 	b := &ClapBox{
 		CleanApertureWidthN: 1, CleanApertureWidthD: 2,
 		CleanApertureHeightN: 3, CleanApertureHeightD: 4,
-		HorizOffN: 5, HorizOffD: 6,
+		HorizOffN: 0xfffffffb, HorizOffD: 6,
 		VertOffN: 7, VertOffD: 8,
 	}
 	boxDiffAfterEncodeAndDecode(t, b)
+	if b.SignedHorizOffN() != -5 || b.SignedVertOffN() != 7 {
+		t.Errorf("got signed offsets %d and %d instead of -5 and 7", b.SignedHorizOffN(), b.SignedVertOffN())
+	}
+}
+
+func TestClapCropRect(t *testing.T) {
+	testCases := []struct {
+		width, height, cropWidth, cropHeight, left, top uint32
+	}{
+		{1920, 1088, 1920, 1080, 0, 0},
+		{720, 576, 704, 576, 8, 0},
+		{1280, 720, 1000, 600, 0, 100},
+	}
+	for _, tc := range testCases {
+		clap := CreateClap(tc.width, tc.height, tc.cropWidth, tc.cropHeight, tc.left, tc.top)
+		decClap := boxAfterEncodeAndDecode(t, clap).(*ClapBox)
+		left, top, cropWidth, cropHeight, err := decClap.CropRect(uint16(tc.width), uint16(tc.height))
+		assertNoError(t, err)
+		if left != float64(tc.left) || top != float64(tc.top) || cropWidth != float64(tc.cropWidth) || cropHeight != float64(tc.cropHeight) {
+			t.Errorf("got crop %gx%g at (%g,%g) instead of %dx%d at (%d,%d)", cropWidth, cropHeight, left, top,
+				tc.cropWidth, tc.cropHeight, tc.left, tc.top)
+		}
+	}
+	_, _, _, _, err := (&ClapBox{}).CropRect(100, 100)
+	assertError(t, err, "zero denominators")
+
+	vse := CreateVisualSampleEntryBox("avc1", 1920, 1088, nil)
+	vse.AddChild(CreateClap(1920, 1088, 1920, 1080, 0, 0))
+	buf := bytes.Buffer{}
+	assertNoError(t, vse.Info(&buf, "", "", "  "))
+	if !strings.Contains(buf.String(), " - crop: 1920x1080 at (0,0)") {
+		t.Errorf("crop not in info output:\n%s", buf.String())
+	}
 }
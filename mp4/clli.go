@@ -0,0 +1,62 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// ClliBox - Content Light Level Box, ISO/IEC 23001-8 and CTA-861.3
+// Light levels are in cd/m2.
+type ClliBox struct {
+	MaxContentLightLevel    uint16
+	MaxPicAverageLightLevel uint16
+}
+
+// DecodeClli - box-specific decode
+func DecodeClli(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 4 {
+		return nil, fmt.Errorf("clli: payload size %d instead of 4", len(data))
+	}
+	sr := NewSliceReader(data)
+	b := &ClliBox{}
+	b.MaxContentLightLevel = sr.ReadUint16()
+	b.MaxPicAverageLightLevel = sr.ReadUint16()
+	return b, nil
+}
+
+// Type - box type
+func (b *ClliBox) Type() string {
+	return "clli"
+}
+
+// Size - calculated size of box
+func (b *ClliBox) Size() uint64 {
+	return uint64(boxHeaderSize + 4)
+}
+
+// Encode - write box to w
+func (b *ClliBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint16(b.MaxContentLightLevel)
+	sw.WriteUint16(b.MaxPicAverageLightLevel)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *ClliBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - maxCLL: %d", b.MaxContentLightLevel)
+	bd.write(" - maxFALL: %d", b.MaxPicAverageLightLevel)
+	return bd.err
+}
@@ -0,0 +1,163 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Colour types of ColrBox
+const (
+	ColourTypeNclx = "nclx" // On-screen colours, ISO/IEC 23091-2
+	ColourTypeNclc = "nclc" // QuickTime on-screen colours without full range flag
+	ColourTypeRICC = "rICC" // Restricted ICC profile
+	ColourTypeProf = "prof" // Unrestricted ICC profile
+)
+
+// Colour code points from ISO/IEC 23091-2 used for SDR and HDR signaling
+const (
+	ColourPrimariesBT709           = 1
+	ColourPrimariesBT2020          = 9
+	TransferCharacteristicsBT709   = 1
+	TransferCharacteristicsPQ      = 16 // SMPTE ST 2084 used for HDR10
+	TransferCharacteristicsHLG     = 18 // ARIB STD-B67
+	MatrixCoefficientsBT709        = 1
+	MatrixCoefficientsBT2020NonCon = 9
+)
+
+// ColrBox - Colour Information Box, ISO/IEC 14496-12 2020 Sec. 12.1.5
+// For nclx and nclc, the colour values are used. For rICC and prof, ICCProfile is used.
+// For other colour types, the payload after the colour type is kept in ICCProfile.
+type ColrBox struct {
+	ColourType              string
+	ColourPrimaries         uint16
+	TransferCharacteristics uint16
+	MatrixCoefficients      uint16
+	FullRangeFlag           bool
+	ICCProfile              []byte
+}
+
+// CreateColrNclx - create nclx ColrBox with given code points
+func CreateColrNclx(primaries, transfer, matrix uint16, fullRange bool) *ColrBox {
+	return &ColrBox{
+		ColourType:              ColourTypeNclx,
+		ColourPrimaries:         primaries,
+		TransferCharacteristics: transfer,
+		MatrixCoefficients:      matrix,
+		FullRangeFlag:           fullRange,
+	}
+}
+
+// CreateColrSDR - create nclx ColrBox for BT.709 SDR video with limited range
+func CreateColrSDR() *ColrBox {
+	return CreateColrNclx(ColourPrimariesBT709, TransferCharacteristicsBT709, MatrixCoefficientsBT709, false)
+}
+
+// CreateColrHDR10 - create nclx ColrBox for HDR10 (BT.2020 primaries and PQ transfer) with limited range
+func CreateColrHDR10() *ColrBox {
+	return CreateColrNclx(ColourPrimariesBT2020, TransferCharacteristicsPQ, MatrixCoefficientsBT2020NonCon, false)
+}
+
+// CreateColrHLG - create nclx ColrBox for HLG (BT.2020 primaries and HLG transfer) with limited range
+func CreateColrHLG() *ColrBox {
+	return CreateColrNclx(ColourPrimariesBT2020, TransferCharacteristicsHLG, MatrixCoefficientsBT2020NonCon, false)
+}
+
+// DecodeColr - box-specific decode
+func DecodeColr(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("colr: payload too short")
+	}
+	sr := NewSliceReader(data)
+	b := &ColrBox{ColourType: sr.ReadFixedLengthString(4)}
+	switch b.ColourType {
+	case ColourTypeNclx, ColourTypeNclc:
+		if b.ColourType == ColourTypeNclx && len(data) < 11 || len(data) < 10 {
+			return nil, fmt.Errorf("colr: %s payload too short", b.ColourType)
+		}
+		b.ColourPrimaries = sr.ReadUint16()
+		b.TransferCharacteristics = sr.ReadUint16()
+		b.MatrixCoefficients = sr.ReadUint16()
+		if b.ColourType == ColourTypeNclx {
+			b.FullRangeFlag = sr.ReadUint8()&0x80 != 0
+		}
+	default:
+		b.ICCProfile = sr.ReadBytes(len(data) - 4)
+	}
+	return b, nil
+}
+
+// Type - box type
+func (b *ColrBox) Type() string {
+	return "colr"
+}
+
+// Size - calculated size of box
+func (b *ColrBox) Size() uint64 {
+	switch b.ColourType {
+	case ColourTypeNclx:
+		return uint64(boxHeaderSize + 11)
+	case ColourTypeNclc:
+		return uint64(boxHeaderSize + 10)
+	default:
+		return uint64(boxHeaderSize + 4 + len(b.ICCProfile))
+	}
+}
+
+// Encode - write box to w
+func (b *ColrBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteString(b.ColourType, false)
+	switch b.ColourType {
+	case ColourTypeNclx, ColourTypeNclc:
+		sw.WriteUint16(b.ColourPrimaries)
+		sw.WriteUint16(b.TransferCharacteristics)
+		sw.WriteUint16(b.MatrixCoefficients)
+		if b.ColourType == ColourTypeNclx {
+			var fullRange byte
+			if b.FullRangeFlag {
+				fullRange = 0x80
+			}
+			sw.WriteUint8(fullRange)
+		}
+	default:
+		sw.WriteBytes(b.ICCProfile)
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// IsHDR - true if nclx or nclc transfer characteristics are PQ or HLG
+func (b *ColrBox) IsHDR() bool {
+	if b.ColourType != ColourTypeNclx && b.ColourType != ColourTypeNclc {
+		return false
+	}
+	return b.TransferCharacteristics == TransferCharacteristicsPQ || b.TransferCharacteristics == TransferCharacteristicsHLG
+}
+
+// Info - write box-specific information
+func (b *ColrBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - colourType: %s", b.ColourType)
+	switch b.ColourType {
+	case ColourTypeNclx, ColourTypeNclc:
+		bd.write(" - colourPrimaries: %d", b.ColourPrimaries)
+		bd.write(" - transferCharacteristics: %d", b.TransferCharacteristics)
+		bd.write(" - matrixCoefficients: %d", b.MatrixCoefficients)
+		if b.ColourType == ColourTypeNclx {
+			bd.write(" - fullRangeFlag: %t", b.FullRangeFlag)
+		}
+	default:
+		bd.write(" - profileSize: %d", len(b.ICCProfile))
+	}
+	return bd.err
+}
@@ -0,0 +1,52 @@
+package mp4
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestEncDecColr(t *testing.T) {
+	boxes := []*ColrBox{
+		CreateColrSDR(),
+		CreateColrHDR10(),
+		CreateColrNclx(ColourPrimariesBT2020, TransferCharacteristicsHLG, MatrixCoefficientsBT2020NonCon, true),
+		{ColourType: ColourTypeNclc, ColourPrimaries: 1, TransferCharacteristics: 1, MatrixCoefficients: 1},
+		{ColourType: ColourTypeProf, ICCProfile: []byte{0, 1, 2, 3, 4, 5}},
+		{ColourType: ColourTypeRICC, ICCProfile: []byte{7, 8}},
+	}
+	for _, b := range boxes {
+		boxDiffAfterEncodeAndDecode(t, b)
+	}
+	if CreateColrSDR().IsHDR() || !CreateColrHDR10().IsHDR() || !CreateColrHLG().IsHDR() {
+		t.Errorf("wrong HDR detection")
+	}
+}
+
+func TestSetColourInformation(t *testing.T) {
+	sps, _ := hex.DecodeString(sps1nalu)
+	pps, _ := hex.DecodeString(pps1nalu)
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	trak := init.Moov.Trak
+	assertNoError(t, trak.SetAVCDescriptor("avc1", [][]byte{sps}, [][]byte{pps}))
+	assertNoError(t, trak.SetColourInformation(CreateColrSDR()))
+	assertNoError(t, trak.SetColourInformation(CreateColrHDR10()))
+	avcx := trak.Mdia.Minf.Stbl.Stsd.AvcX
+	nrColr := 0
+	for _, c := range avcx.Children {
+		if c.Type() == "colr" {
+			nrColr++
+		}
+	}
+	if nrColr != 1 || !avcx.Colr.IsHDR() {
+		t.Errorf("expected one HDR colr box, got %d", nrColr)
+	}
+	decBox := boxAfterEncodeAndDecode(t, avcx)
+	if colr := decBox.(*VisualSampleEntryBox).Colr; colr == nil || colr.TransferCharacteristics != TransferCharacteristicsPQ {
+		t.Errorf("colr not decoded in sample entry")
+	}
+
+	audioInit := CreateEmptyInit()
+	audioInit.AddEmptyTrack(48000, "audio", "und")
+	assertError(t, audioInit.Moov.Trak.SetColourInformation(CreateColrSDR()), "audio track has no visual sample entry")
+}
@@ -29,6 +29,9 @@ func DecodeContainerChildren(hdr *boxHeader, startPos, endPos uint64, r io.Reade
 	for {
 		b, err := DecodeBox(pos, r)
 		if err == io.EOF {
+			if pos != endPos {
+				logWarnf("%s box: children end at %d before box end at %d", hdr.name, pos, endPos)
+			}
 			return l, nil
 		}
 		if err != nil {
@@ -0,0 +1,213 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// BoxFilter - called for every box of a filtered type by CopyBoxes.
+// The returned box is written instead of b, so b can be returned as is, changed, or replaced.
+// Returning nil drops the box.
+type BoxFilter func(b Box) (Box, error)
+
+// DropBox - BoxFilter that drops the box
+func DropBox(b Box) (Box, error) {
+	return nil, nil
+}
+
+// ReplaceBox - BoxFilter that replaces the box by newBox
+func ReplaceBox(newBox Box) BoxFilter {
+	return func(b Box) (Box, error) {
+		return newBox, nil
+	}
+}
+
+// copyDecodedBoxes - top-level boxes which are always decoded and filtered recursively by CopyBoxes
+var copyDecodedBoxes = map[string]bool{
+	"moov": true,
+	"moof": true,
+	"meta": true,
+	"mfra": true,
+	"udta": true,
+}
+
+// CopyBoxes - stream boxes from r to w while applying filters given per box type.
+// Top-level boxes are copied without being decoded unless there is a filter for their type,
+// or they are container boxes (moov, moof, meta, mfra, udta), in which filters are applied recursively.
+// mdat boxes are therefore only read into memory if they are filtered themselves.
+//
+// If boxes before the first mdat change size, the chunk offsets in moov are updated.
+// Since a moov box before mdat can only be written when all size changes are known, the boxes from
+// moov up to mdat are kept in memory. If a moof box changes size, its trun data offsets, or tfhd
+// base data offsets, are updated. sidx boxes are not updated.
+func CopyBoxes(r io.Reader, w io.Writer, filters map[string]BoxFilter) error {
+	var inPos, outPos uint64
+	var pendingMoov *MoovBox
+	var pending bytes.Buffer // Output after pendingMoov
+	var mdatDelta int64      // Position change of first mdat
+	mdatFound := false
+	out := w
+	flushMoov := func(delta int64) error {
+		if pendingMoov == nil {
+			return nil
+		}
+		if delta != 0 {
+			err := pendingMoov.shiftChunkOffsets(delta)
+			if err != nil {
+				return err
+			}
+		}
+		err := pendingMoov.Encode(w)
+		if err != nil {
+			return err
+		}
+		_, err = pending.WriteTo(w)
+		pendingMoov = nil
+		out = w
+		return err
+	}
+	for {
+		hdr, err := decodeHeader(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.size < uint64(hdr.hdrlen) {
+			return fmt.Errorf("%s box at pos %d with size %d smaller than header", hdr.name, inPos, hdr.size)
+		}
+		payloadSize := int64(hdr.size) - int64(hdr.hdrlen)
+		filter, isFiltered := filters[hdr.name]
+		if hdr.name == "mdat" && !mdatFound {
+			mdatFound = true
+			mdatDelta = int64(outPos) - int64(inPos)
+			err = flushMoov(mdatDelta)
+			if err != nil {
+				return err
+			}
+		}
+		if !isFiltered && !(len(filters) > 0 && copyDecodedBoxes[hdr.name]) {
+			err = EncodeHeaderWithSize(hdr.name, hdr.size, hdr.hdrlen > boxHeaderSize, out)
+			if err != nil {
+				return err
+			}
+			_, err = io.CopyN(out, r, payloadSize)
+			if err != nil {
+				return err
+			}
+			inPos += hdr.size
+			outPos += hdr.size
+			continue
+		}
+		hdrBuf := bytes.Buffer{}
+		err = EncodeHeaderWithSize(hdr.name, hdr.size, hdr.hdrlen > boxHeaderSize, &hdrBuf)
+		if err != nil {
+			return err
+		}
+		box, err := DecodeBox(inPos, io.MultiReader(&hdrBuf, io.LimitReader(r, payloadSize)))
+		if err != nil {
+			return err
+		}
+		// Positions before filtering, to update offsets into moof and the following media data
+		moofPositions := make(map[Box]uint64)
+		if moof, ok := box.(*MoofBox); ok {
+			addMoofPositions(moofPositions, moof, inPos)
+		}
+		moofStart := inPos
+		inPos += hdr.size
+		if isFiltered {
+			box, err = filter(box)
+			if err != nil {
+				return err
+			}
+			if box == nil {
+				continue
+			}
+		}
+		if c, ok := box.(ContainerBox); ok {
+			err = filterChildren(c, filters)
+			if err != nil {
+				return err
+			}
+		}
+		switch b := box.(type) {
+		case *MoovBox:
+			if pendingMoov != nil {
+				return fmt.Errorf("more than one moov box")
+			}
+			if mdatFound {
+				if mdatDelta != 0 {
+					err = b.shiftChunkOffsets(mdatDelta)
+					if err != nil {
+						return err
+					}
+				}
+				break
+			}
+			pendingMoov = b
+			out = &pending
+			outPos += b.Size()
+			continue
+		case *MoofBox:
+			moofPositions[b] = moofStart // b may be a new moof from a filter
+			shiftMoofOffsets(b, moofPositions, inPos, outPos)
+		}
+		err = box.Encode(out)
+		if err != nil {
+			return err
+		}
+		outPos += box.Size()
+	}
+	return flushMoov(0)
+}
+
+// filterChildren - apply filters recursively to the children of parent
+func filterChildren(parent ContainerBox, filters map[string]BoxFilter) error {
+	for _, child := range parent.GetChildren() {
+		newChild := child
+		if filter, ok := filters[child.Type()]; ok {
+			var err error
+			newChild, err = filter(child)
+			if err != nil {
+				return err
+			}
+		}
+		switch {
+		case newChild == nil:
+			err := RemoveChild(parent, child)
+			if err != nil {
+				return err
+			}
+			continue
+		case newChild != child:
+			err := ReplaceChild(parent, child, newChild)
+			if err != nil {
+				return err
+			}
+		}
+		if c, ok := newChild.(ContainerBox); ok {
+			err := filterChildren(c, filters)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// shiftMoofOffsets - update trun, tfhd, and saio offsets after moof was filtered and moved to newStart.
+// oldPositions are the positions of the moof and its traf children before filtering,
+// and oldDataPos is the input position of the media data following the moof.
+func shiftMoofOffsets(moof *MoofBox, oldPositions map[Box]uint64, oldDataPos, newStart uint64) {
+	newPositions := make(map[Box]uint64)
+	addMoofPositions(newPositions, moof, newStart)
+	mdat := &MdatBox{} // Placeholder for the media data which is not decoded
+	oldPositions[mdat] = oldDataPos
+	newPositions[mdat] = newStart + moof.Size()
+	frag := &Fragment{Moof: moof, Mdat: mdat}
+	for _, traf := range moof.Trafs {
+		shiftTrafOffsets(traf, frag, oldPositions, newPositions)
+	}
+}
@@ -0,0 +1,133 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCopyBoxesWithoutFilters(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/prog_8s.mp4")
+	assertNoError(t, err)
+	buf := bytes.Buffer{}
+	assertNoError(t, CopyBoxes(bytes.NewReader(data), &buf, nil))
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("copy without filters not identical")
+	}
+}
+
+func TestCopyBoxesDropPssh(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(30, 3600, 300, 10, 0x30)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 1000})
+	assertNoError(t, err)
+	pssh := &PsshBox{SystemID: UUID(make([]byte, 16)), Data: make([]byte, 40)}
+	assertNoError(t, f.InsertChild(f.Moov, 1, pssh))
+	in := bytes.Buffer{}
+	assertNoError(t, f.Encode(&in))
+
+	out := bytes.Buffer{}
+	filters := map[string]BoxFilter{"pssh": DropBox, "free": ReplaceBox(&FreeBox{Name: "skip"})}
+	assertNoError(t, CopyBoxes(&in, &out, filters))
+	decFile, err := DecodeFile(bytes.NewReader(out.Bytes()))
+	assertNoError(t, err)
+	for _, c := range decFile.Moov.Children {
+		if c.Type() == "pssh" {
+			t.Errorf("pssh not dropped")
+		}
+	}
+	trak := decFile.Moov.Trak
+	for nr := uint32(1); nr <= uint32(len(samples)); nr++ {
+		data := bytes.Buffer{}
+		assertNoError(t, decFile.CopySampleData(&data, nil, trak, nr, nr))
+		if !bytes.Equal(data.Bytes(), samples[nr-1].Data) {
+			t.Fatalf("sample %d: data mismatch", nr)
+		}
+	}
+}
+
+func TestCopyBoxesTransformFragments(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(50, 3600, 200, 25, 0x40)
+	odFile, err := CreateOnDemandFile(init, NewSliceSampleSource(samples), 90000)
+	assertNoError(t, err)
+	in := bytes.Buffer{}
+	assertNoError(t, odFile.Encode(&in))
+
+	// Add a free box to every traf
+	addFree := func(b Box) (Box, error) {
+		traf := b.(*TrafBox)
+		return traf, InsertChild(traf, len(traf.Children), &FreeBox{Name: "free", notDecoded: make([]byte, 30)})
+	}
+	out := bytes.Buffer{}
+	assertNoError(t, CopyBoxes(&in, &out, map[string]BoxFilter{"traf": addFree}))
+	decFile, err := DecodeFile(&out)
+	assertNoError(t, err)
+	nr := 0
+	for _, seg := range decFile.Segments {
+		for _, frag := range seg.Fragments {
+			fs, err := frag.GetFullSamples(nil)
+			assertNoError(t, err)
+			for _, s := range fs {
+				if !bytes.Equal(s.Data, samples[nr].Data) {
+					t.Fatalf("sample %d: data mismatch", nr+1)
+				}
+				nr++
+			}
+		}
+	}
+	if nr != len(samples) {
+		t.Errorf("got %d samples instead of %d", nr, len(samples))
+	}
+}
+
+func TestCopyBoxesDropPsshInEncryptedMoof(t *testing.T) {
+	key, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	iv, _ := hex.DecodeString("0102030405060708")
+	tenc := &TencBox{DefaultIsProtected: 1, DefaultPerSampleIVSize: 8, DefaultKID: UUID(make([]byte, 16))}
+	trex := &TrexBox{TrackID: 1}
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	var plain [][]byte
+	for i := 0; i < 3; i++ {
+		data := bytes.Repeat([]byte{byte(i + 1)}, 20)
+		plain = append(plain, data)
+		frag.AddFullSample(FullSample{Sample: NewSample(SyncSampleFlags, 1000, 20, 0),
+			DecodeTime: uint64(i) * 1000, Data: append([]byte{}, data...)})
+	}
+	frag = fragmentAfterEncodeAndDecode(t, frag)
+	_, err = frag.EncryptSamples(trex, SchemeCENC, key, tenc, iv)
+	assertNoError(t, err)
+	pssh := &PsshBox{SystemID: UUID(make([]byte, 16)), Data: make([]byte, 40)}
+	assertNoError(t, InsertChild(frag.Moof, 1, pssh))
+	in := bytes.Buffer{}
+	assertNoError(t, frag.Encode(&in))
+
+	out := bytes.Buffer{}
+	assertNoError(t, CopyBoxes(&in, &out, map[string]BoxFilter{"pssh": DropBox}))
+	raw := out.Bytes()
+	decFile, err := DecodeFile(bytes.NewReader(raw))
+	assertNoError(t, err)
+	outFrag := decFile.Segments[0].Fragments[0]
+	for _, c := range outFrag.Moof.Children {
+		if c.Type() == "pssh" {
+			t.Errorf("pssh not dropped")
+		}
+	}
+	_, saio := outFrag.Moof.Traf.GetSaizSaio("")
+	ivPos := int(outFrag.Moof.StartPos) + int(saio.Offset[0])
+	if !bytes.Equal(raw[ivPos:ivPos+8], iv) {
+		t.Errorf("saio offset does not point to first IV after dropping pssh")
+	}
+	assertNoError(t, outFrag.DecryptSamples(trex, SchemeCENC, key, tenc))
+	samples, err := outFrag.GetFullSamples(trex)
+	assertNoError(t, err)
+	for i, s := range samples {
+		if !bytes.Equal(s.Data, plain[i]) {
+			t.Errorf("sample %d: data mismatch after decryption", i+1)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package mp4
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+// BoxDecodeError - error when decoding a box, with the position and size of the box.
+// Errors when decoding child boxes are wrapped, so the chain of BoxDecodeErrors gives
+// the path from the top-level box to the box that failed.
+type BoxDecodeError struct {
+	BoxType  string // Empty if the box header could not be decoded
+	StartPos uint64
+	Size     uint64 // Size given by the box header
+	Err      error
+}
+
+// Error - error message with the box type
+func (e *BoxDecodeError) Error() string {
+	if e.BoxType == "" {
+		return fmt.Sprintf("decode box header at %d: %v", e.StartPos, e.Err)
+	}
+	return fmt.Sprintf("decode %s: %v", e.BoxType, e.Err)
+}
+
+// Unwrap - the wrapped error
+func (e *BoxDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// CorruptRegion - byte range of the innermost box that failed to decode and its enclosing boxes
+type CorruptRegion struct {
+	Start uint64 // Start of the failing box (or header)
+	End   uint64 // End according to the box header, which may be beyond the end of the data
+	// Path - box types from the top-level box to the failing box, e.g. "moov/trak/mdia/minf/stbl/stsz"
+	Path string
+	// Boxes - decode errors from the top-level box to the failing box
+	Boxes []*BoxDecodeError
+	// Err - the innermost error
+	Err error
+}
+
+// FindCorruptRegion - corrupt region given by the BoxDecodeErrors in err, e.g. as returned by DecodeFile.
+// Returns false if err does not contain a BoxDecodeError.
+func FindCorruptRegion(err error) (CorruptRegion, bool) {
+	var cr CorruptRegion
+	var types []string
+	for {
+		var e *BoxDecodeError
+		if !errors.As(err, &e) {
+			break
+		}
+		cr.Boxes = append(cr.Boxes, e)
+		if e.BoxType != "" {
+			types = append(types, e.BoxType)
+		}
+		err = e.Err
+	}
+	if len(cr.Boxes) == 0 {
+		return cr, false
+	}
+	last := cr.Boxes[len(cr.Boxes)-1]
+	cr.Start = last.StartPos
+	cr.End = last.StartPos + last.Size
+	if cr.End < cr.Start+boxHeaderSize {
+		cr.End = cr.Start + boxHeaderSize
+	}
+	cr.Path = strings.Join(types, "/")
+	cr.Err = err
+	return cr, true
+}
+
+// String - region with path and error
+func (cr CorruptRegion) String() string {
+	return fmt.Sprintf("[%d, %d) %s: %v", cr.Start, cr.End, cr.Path, cr.Err)
+}
+
+// ReadRegionBytes - raw bytes of the region extended by margin bytes on both sides, and the position
+// of the first returned byte. The range is limited to the data available in r, and since the region
+// size may come from a corrupt box header, memory is only allocated for data that is actually read.
+func ReadRegionBytes(r io.ReaderAt, cr CorruptRegion, margin uint64) ([]byte, uint64, error) {
+	start := uint64(0)
+	if cr.Start > margin {
+		start = cr.Start - margin
+	}
+	if start > math.MaxInt64 {
+		return nil, 0, fmt.Errorf("region start %d out of range", start)
+	}
+	size := cr.End + margin - start
+	if cr.End+margin < cr.End || size > math.MaxInt64-start {
+		size = math.MaxInt64 - start
+	}
+	data, err := ioutil.ReadAll(io.NewSectionReader(r, int64(start), int64(size)))
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, start, nil
+}
@@ -0,0 +1,54 @@
+package mp4
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFindCorruptRegion(t *testing.T) {
+	data, err := BuildBoxes([]BoxSpec{
+		{Type: "ftyp", Fields: []byte(`{"MajorBrand": "isom"}`)},
+		{Type: "moov", Children: []BoxSpec{
+			{Type: "mvhd", Fields: []byte(`{"Timescale": 1000}`)},
+			{Type: "trak", Payload: "000000"}, // Truncated child box header
+		}},
+	})
+	assertNoError(t, err)
+	_, err = DecodeFile(bytes.NewReader(data))
+	assertError(t, err, "truncated box header should fail")
+	if !strings.HasPrefix(err.Error(), "decode moov: decode trak: ") {
+		t.Errorf("unexpected error message %q", err)
+	}
+	cr, ok := FindCorruptRegion(err)
+	if !ok {
+		t.Fatalf("no corrupt region in %q", err)
+	}
+	childStart := uint64(16 + 8 + 108 + 8)
+	if cr.Path != "moov/trak" || cr.Start != childStart || cr.End != childStart+8 || len(cr.Boxes) != 3 {
+		t.Errorf("got region %s", cr)
+	}
+	raw, start, err := ReadRegionBytes(bytes.NewReader(data), cr, 4)
+	assertNoError(t, err)
+	if start != childStart-4 || !bytes.Equal(raw, data[start:]) {
+		t.Errorf("got %d bytes from %d", len(raw), start)
+	}
+
+	// A forged size must not make ReadRegionBytes allocate more than the available data
+	hugeRegion := CorruptRegion{Start: childStart, End: 1 << 62}
+	raw, start, err = ReadRegionBytes(bytes.NewReader(data), hugeRegion, 1<<63)
+	assertNoError(t, err)
+	if start != 0 || !bytes.Equal(raw, data) {
+		t.Errorf("got %d bytes from %d for huge region", len(raw), start)
+	}
+
+	_, err = DecodeFile(bytes.NewReader(append(data[:16], 0, 0, 0)))
+	cr, ok = FindCorruptRegion(err)
+	if !ok || cr.Start != 16 || cr.Path != "" {
+		t.Errorf("got region %s for truncated header", cr)
+	}
+
+	if _, ok := FindCorruptRegion(bytes.ErrTooLarge); ok {
+		t.Errorf("found region in other error")
+	}
+}
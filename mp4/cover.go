@@ -0,0 +1,282 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// Image - image with MIME type, such as cover art
+type Image struct {
+	MimeType string // image/jpeg, image/png, or image/bmp
+	Data     []byte
+}
+
+// imageDataType - DataBox type for MIME type
+func imageDataType(mimeType string) (uint32, error) {
+	switch mimeType {
+	case "image/jpeg":
+		return DataTypeJPEG, nil
+	case "image/png":
+		return DataTypePNG, nil
+	case "image/bmp":
+		return DataTypeBMP, nil
+	default:
+		return 0, fmt.Errorf("unsupported image type %q", mimeType)
+	}
+}
+
+// GetCover - get cover image. The primary item of a file-level meta box is preferred
+// over the covr atom in moov/udta/meta/ilst.
+func (f *File) GetCover() (*Image, error) {
+	if f.Meta != nil && f.Meta.Pitm != nil {
+		return f.getPrimaryItemImage()
+	}
+	if ilst := f.ilst(); ilst != nil {
+		for _, c := range ilst.Children {
+			covr, ok := c.(*CovrBox)
+			if !ok {
+				continue
+			}
+			img, err := covrImage(covr)
+			if err != nil || img != nil {
+				return img, err
+			}
+		}
+	}
+	return nil, fmt.Errorf("no cover image")
+}
+
+// getPrimaryItemImage - get primary item of file-level meta box as image
+func (f *File) getPrimaryItemImage() (*Image, error) {
+	meta := f.Meta
+	itemID := meta.Pitm.ItemID
+	if meta.Iinf == nil || meta.Iloc == nil {
+		return nil, fmt.Errorf("meta: iinf or iloc missing")
+	}
+	infe := meta.Iinf.GetItem(itemID)
+	if infe == nil {
+		return nil, fmt.Errorf("meta: no infe for primary item %d", itemID)
+	}
+	img := Image{}
+	switch infe.ItemType {
+	case "mime":
+		img.MimeType = infe.ContentType
+	case "jpeg":
+		img.MimeType = "image/jpeg"
+	case "png ":
+		img.MimeType = "image/png"
+	default:
+		return nil, fmt.Errorf("meta: primary item type %q is not an image", infe.ItemType)
+	}
+	loc := meta.Iloc.GetItem(itemID)
+	if loc == nil {
+		return nil, fmt.Errorf("meta: no iloc entry for primary item %d", itemID)
+	}
+	var src []byte
+	var srcOffset uint64
+	switch {
+	case loc.ConstructionMethod == IlocIdatOffset && meta.Idat != nil:
+		src = meta.Idat.Data
+	case loc.ConstructionMethod == IlocFileOffset && loc.DataReferenceIndex == 0 && f.Mdat != nil && !f.Mdat.IsLazy():
+		src = f.Mdat.Data
+		srcOffset = f.Mdat.PayloadAbsoluteOffset()
+	default:
+		return nil, fmt.Errorf("meta: data for primary item %d not available", itemID)
+	}
+	for _, e := range loc.Extents {
+		start := loc.BaseOffset + e.Offset
+		length := e.Length
+		if start < srcOffset {
+			return nil, fmt.Errorf("meta: extent offset %d outside data", start)
+		}
+		start -= srcOffset
+		if length == 0 { // Length 0 means the rest of the data
+			length = uint64(len(src)) - start
+		}
+		if start+length > uint64(len(src)) {
+			return nil, fmt.Errorf("meta: extent offset %d length %d outside data", start, length)
+		}
+		img.Data = append(img.Data, src[start:start+length]...)
+	}
+	return &img, nil
+}
+
+// SetCover - set cover image as the first image of the covr atom in moov/udta/meta/ilst.
+// Other ilst items and other images in covr are kept.
+// For progressive files, the image is also set as primary item of a file-level meta box
+// with the data appended to its idat box. An existing primary item is replaced and other items
+// are kept, but the data of a replaced item in idat is not removed.
+// Chunk offsets are updated if the size of the boxes before mdat changes.
+func (f *File) SetCover(img Image) error {
+	dataType, err := imageDataType(img.MimeType)
+	if err != nil {
+		return err
+	}
+	sizeBefore := f.sizeBeforeMdat()
+	ilst, err := f.createIlst()
+	if err != nil {
+		return err
+	}
+	data := &DataBox{DataType: dataType, Data: img.Data}
+	var covr *CovrBox
+	for _, c := range ilst.Children {
+		if cb, ok := c.(*CovrBox); ok {
+			covr = cb
+			break
+		}
+	}
+	if covr == nil {
+		covr = &CovrBox{}
+		ilst.AddChild(covr)
+	}
+	replaced := false
+	for i, c := range covr.Children {
+		if _, ok := c.(*DataBox); ok {
+			covr.Children[i] = data
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		covr.AddChild(data)
+	}
+
+	if !f.isFragmented {
+		if f.Meta == nil {
+			hdlr, err := CreateHdlr("pict")
+			if err != nil {
+				return err
+			}
+			f.AddChild(CreateMetaBox(0, hdlr), 0) // Last in file, so no chunk offsets change
+		}
+		setPrimaryItemImage(f.Meta, img)
+	}
+
+	if delta := int64(f.sizeBeforeMdat()) - int64(sizeBefore); delta != 0 && f.Mdat != nil {
+		f.Mdat.StartPos = uint64(int64(f.Mdat.StartPos) + delta)
+		return f.Moov.shiftChunkOffsets(delta)
+	}
+	return nil
+}
+
+// setPrimaryItemImage - set img as primary item of meta with its data appended to idat.
+// The current primary item is replaced, and a new item ID is used if there is none.
+func setPrimaryItemImage(meta *MetaBox, img Image) {
+	if meta.Iinf == nil {
+		meta.AddChild(&IinfBox{})
+	}
+	if meta.Iloc == nil {
+		meta.AddChild(&IlocBox{Version: 1, OffsetSize: 4, LengthSize: 4})
+	}
+	if meta.Idat == nil {
+		meta.AddChild(&IdatBox{})
+	}
+	var itemID uint32
+	if meta.Pitm != nil {
+		itemID = meta.Pitm.ItemID
+	} else {
+		for _, infe := range meta.Iinf.Infes {
+			if infe.ItemID > itemID {
+				itemID = infe.ItemID
+			}
+		}
+		for _, item := range meta.Iloc.Items {
+			if item.ItemID > itemID {
+				itemID = item.ItemID
+			}
+		}
+		itemID++
+		meta.AddChild(&PitmBox{ItemID: itemID})
+	}
+	if itemID > 0xffff {
+		meta.Pitm.Version = 1
+	}
+
+	iinf := meta.Iinf
+	infe := CreateMimeInfe(itemID, "cover", img.MimeType)
+	replaced := false
+	for i, c := range iinf.Children {
+		if old, ok := c.(*InfeBox); ok && old.ItemID == itemID {
+			iinf.Children[i] = infe
+			replaced = true
+		}
+	}
+	if replaced {
+		for i, old := range iinf.Infes {
+			if old.ItemID == itemID {
+				iinf.Infes[i] = infe
+			}
+		}
+	} else {
+		iinf.AddChild(infe)
+	}
+
+	iloc := meta.Iloc
+	switch {
+	case itemID > 0xffff:
+		iloc.Version = 2
+	case iloc.Version == 0: // Construction method needs version 1
+		iloc.Version = 1
+	}
+	if iloc.OffsetSize == 0 {
+		iloc.OffsetSize = 4
+	}
+	if iloc.LengthSize == 0 {
+		iloc.LengthSize = 4
+	}
+	if uint64(len(meta.Idat.Data)+len(img.Data)) > 0xffffffff {
+		iloc.OffsetSize, iloc.LengthSize = 8, 8
+	}
+	item := IlocItem{
+		ItemID:             itemID,
+		ConstructionMethod: IlocIdatOffset,
+		Extents:            []IlocExtent{{Offset: uint64(len(meta.Idat.Data)), Length: uint64(len(img.Data))}},
+	}
+	meta.Idat.Data = append(meta.Idat.Data, img.Data...)
+	if loc := iloc.GetItem(itemID); loc != nil {
+		*loc = item
+	} else {
+		iloc.Items = append(iloc.Items, item)
+	}
+}
+
+// sizeBeforeMdat - total size of top-level boxes before mdat. Total size of all boxes if no mdat
+func (f *File) sizeBeforeMdat() uint64 {
+	size := uint64(0)
+	for _, c := range f.Children {
+		if c == f.Mdat && f.Mdat != nil {
+			break
+		}
+		size += c.Size()
+	}
+	return size
+}
+
+// shiftChunkOffsets - add delta to all chunk offsets in stco and co64 boxes
+// Nothing is changed if an offset gets out of range.
+func (m *MoovBox) shiftChunkOffsets(delta int64) error {
+	for _, trak := range m.Traks {
+		stbl := trak.Mdia.Minf.Stbl
+		if stbl.Stco != nil {
+			for _, offset := range stbl.Stco.ChunkOffset {
+				newOffset := int64(offset) + delta
+				if newOffset < 0 || newOffset > 0xffffffff {
+					return fmt.Errorf("trackID %d: chunk offset %d out of range for stco", trak.Tkhd.TrackID, newOffset)
+				}
+			}
+		}
+	}
+	for _, trak := range m.Traks {
+		stbl := trak.Mdia.Minf.Stbl
+		if stbl.Stco != nil {
+			for i, offset := range stbl.Stco.ChunkOffset {
+				stbl.Stco.ChunkOffset[i] = uint32(int64(offset) + delta)
+			}
+		}
+		if stbl.Co64 != nil {
+			for i, offset := range stbl.Co64.ChunkOffset {
+				stbl.Co64.ChunkOffset[i] = uint64(int64(offset) + delta)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestItemBoxes(t *testing.T) {
+	boxDiffAfterEncodeAndDecode(t, &PitmBox{ItemID: 3})
+	boxDiffAfterEncodeAndDecode(t, &PitmBox{Version: 1, ItemID: 70000})
+	boxDiffAfterEncodeAndDecode(t, CreateMimeInfe(1, "cover", "image/png"))
+	boxDiffAfterEncodeAndDecode(t, &InfeBox{Version: 0, ItemID: 2, ItemName: "old", ContentType: "text/plain"})
+	boxDiffAfterEncodeAndDecode(t, &InfeBox{Version: 2, ItemID: 3, ItemType: "uri ", ItemName: "u", ItemURIType: "urn:x"})
+	iinf := &IinfBox{}
+	iinf.AddChild(CreateMimeInfe(1, "a", "image/jpeg"))
+	iinf.AddChild(CreateMimeInfe(2, "b", "image/png"))
+	boxDiffAfterEncodeAndDecode(t, iinf)
+	boxDiffAfterEncodeAndDecode(t, &IdatBox{Data: []byte{1, 2, 3}})
+	for version := byte(0); version <= 2; version++ {
+		iloc := &IlocBox{Version: version, OffsetSize: 4, LengthSize: 8, BaseOffsetSize: 4,
+			Items: []IlocItem{
+				{ItemID: 1, BaseOffset: 100, Extents: []IlocExtent{{Offset: 0, Length: 10}, {Offset: 20, Length: 5}}},
+				{ItemID: 2, DataReferenceIndex: 1, Extents: []IlocExtent{{Offset: 7, Length: 3}}},
+			}}
+		if version > 0 {
+			iloc.IndexSize = 4
+			iloc.Items[0].ConstructionMethod = IlocIdatOffset
+			iloc.Items[0].Extents[1].Index = 2
+		}
+		boxDiffAfterEncodeAndDecode(t, iloc)
+	}
+	boxDiffAfterEncodeAndDecode(t, &DataBox{DataType: DataTypePNG, Data: []byte{0x89, 'P', 'N', 'G'}})
+	boxDiffAfterEncodeAndDecode(t, &DataBox{TypeSet: 2, DataType: 5, Data: []byte{1}})
+}
+
+func TestSetAndGetCover(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(10, 3600, 100, 5, 0x10)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 300})
+	assertNoError(t, err)
+	_, err = f.GetCover()
+	assertError(t, err, "file without cover should give error")
+	assertError(t, f.SetCover(Image{MimeType: "image/gif", Data: []byte{1}}), "gif should not be supported")
+
+	cover := Image{MimeType: "image/jpeg", Data: []byte{0xff, 0xd8, 0xff, 0xe0, 1, 2, 3}}
+	assertNoError(t, f.SetCover(cover))
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	if decFile.Meta == nil || decFile.Children[len(decFile.Children)-1] != decFile.Meta {
+		t.Fatalf("file-level meta box should be last")
+	}
+	got, err := decFile.GetCover()
+	assertNoError(t, err)
+	if got.MimeType != cover.MimeType || !bytes.Equal(got.Data, cover.Data) {
+		t.Errorf("got cover %v instead of %v", got, cover)
+	}
+	trak := decFile.Moov.Trak
+	for nr := uint32(1); nr <= uint32(len(samples)); nr++ {
+		data := bytes.Buffer{}
+		assertNoError(t, decFile.CopySampleData(&data, nil, trak, nr, nr))
+		if !bytes.Equal(data.Bytes(), samples[nr-1].Data) {
+			t.Errorf("sample %d: data mismatch after adding cover", nr)
+		}
+	}
+
+	// Without file-level meta, the covr atom is used
+	decFile.Meta = nil
+	got, err = decFile.GetCover()
+	assertNoError(t, err)
+	if got.MimeType != cover.MimeType || !bytes.Equal(got.Data, cover.Data) {
+		t.Errorf("got ilst cover %v instead of %v", got, cover)
+	}
+}
+
+func TestSetCoverKeepsOtherItems(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(4, 3600, 100, 5, 0x10)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 300})
+	assertNoError(t, err)
+	ilst, err := f.createIlst()
+	assertNoError(t, err)
+	ilst.AddChild(CreateIlstItem(IlstItemTitle, &DataBox{DataType: DataTypeUTF8, Data: []byte("title")}))
+	back := &DataBox{DataType: DataTypePNG, Data: []byte{0x89, 'P', 'N', 'G'}}
+	covr := &CovrBox{}
+	covr.AddChild(&DataBox{DataType: DataTypeBMP, Data: []byte{'B', 'M'}})
+	covr.AddChild(back)
+	ilst.AddChild(covr)
+
+	hdlr, err := CreateHdlr("pict")
+	assertNoError(t, err)
+	f.AddChild(CreateMetaBox(0, hdlr), 0)
+	f.Meta.AddChild(&IinfBox{})
+	f.Meta.Iinf.AddChild(CreateMimeInfe(5, "text", "text/plain"))
+	f.Meta.AddChild(&IlocBox{Version: 1, OffsetSize: 4, LengthSize: 4, Items: []IlocItem{
+		{ItemID: 5, ConstructionMethod: IlocIdatOffset, Extents: []IlocExtent{{Offset: 0, Length: 3}}}}})
+	f.Meta.AddChild(&IdatBox{Data: []byte("abc")})
+
+	cover := Image{MimeType: "image/jpeg", Data: []byte{0xff, 0xd8, 0xff, 0xe0, 1, 2, 3}}
+	assertNoError(t, f.SetCover(cover))
+	assertNoError(t, f.SetCover(cover)) // Replaces the primary item
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+
+	decIlst := decFile.ilst()
+	if len(decIlst.Children) != 2 || decIlst.Children[0].Type() != IlstItemTitle {
+		t.Errorf("ilst items not kept")
+	}
+	decCovr := decIlst.Children[1].(*CovrBox)
+	if len(decCovr.Children) != 2 || !BoxesEqual(decCovr.Children[1], back) {
+		t.Errorf("second covr image not kept")
+	}
+	meta := decFile.Meta
+	if meta.Pitm.ItemID != 6 || len(meta.Iinf.Infes) != 2 || len(meta.Iloc.Items) != 2 {
+		t.Errorf("got primary item %d and %d items instead of 6 and 2", meta.Pitm.ItemID, len(meta.Iinf.Infes))
+	}
+	loc := meta.Iloc.GetItem(5)
+	if loc == nil || !bytes.Equal(meta.Idat.Data[loc.Extents[0].Offset:][:3], []byte("abc")) {
+		t.Errorf("data of other item not kept")
+	}
+	got, err := decFile.GetCover()
+	assertNoError(t, err)
+	if got.MimeType != cover.MimeType || !bytes.Equal(got.Data, cover.Data) {
+		t.Errorf("got cover %v instead of %v", got, cover)
+	}
+}
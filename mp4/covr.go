@@ -0,0 +1,62 @@
+package mp4
+
+import (
+	"io"
+)
+
+// CovrBox - iTunes cover art item (covr) in ilst with one data box per image
+type CovrBox struct {
+	Children []Box
+}
+
+// DecodeCovr - box-specific decode
+func DecodeCovr(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	children, err := DecodeContainerChildren(hdr, startPos+8, startPos+hdr.size, r)
+	if err != nil {
+		return nil, err
+	}
+	b := &CovrBox{}
+	for _, c := range children {
+		b.AddChild(c)
+	}
+	return b, nil
+}
+
+// AddChild - Add a child box
+func (b *CovrBox) AddChild(child Box) {
+	b.Children = append(b.Children, child)
+}
+
+// Type - box type
+func (b *CovrBox) Type() string {
+	return "covr"
+}
+
+// Size - calculated size of box
+func (b *CovrBox) Size() uint64 {
+	return containerSize(b.Children)
+}
+
+// GetChildren - list of child boxes
+func (b *CovrBox) GetChildren() []Box {
+	return b.Children
+}
+
+func (b *CovrBox) setChildren(children []Box) error {
+	n := CovrBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
+// Encode - write covr container to w
+func (b *CovrBox) Encode(w io.Writer) error {
+	return EncodeContainer(b, w)
+}
+
+// Info - box-specific Info
+func (b *CovrBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	return ContainerInfo(b, w, specificBoxLevels, indent, indentStep)
+}
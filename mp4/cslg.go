@@ -1,8 +1,10 @@
 package mp4
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 )
 
 // CslgBox - CompositionToDecodeBox -ISO/IEC 14496-12 2015 Sec. 8.6.1.4
@@ -57,8 +59,16 @@ func (b *CslgBox) Size() uint64 {
 	return uint64(boxHeaderSize + 4 + 20 + 20*b.Version)
 }
 
-// Encode - write box to w
+// Encode - write box to w. Version 0 requires that all values fit in 32 bits
 func (b *CslgBox) Encode(w io.Writer) error {
+	if b.Version == 0 {
+		for _, v := range []int64{b.CompositionToDTSShift, b.LeastDecodeToDisplayDelta,
+			b.GreatestDecodeToDisplayDelta, b.CompositionStartTime, b.CompositionEndTime} {
+			if v < math.MinInt32 || v > math.MaxInt32 {
+				return fmt.Errorf("cslg: value %d does not fit in version 0", v)
+			}
+		}
+	}
 	err := EncodeHeader(b, w)
 	if err != nil {
 		return err
@@ -84,15 +94,13 @@ func (b *CslgBox) Encode(w io.Writer) error {
 	return err
 }
 
-// Info - get details with specificBoxLevels cslg:1 or higher
+// Info - write box-specific information
 func (b *CslgBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
-	if getInfoLevel(b, specificBoxLevels) > 0 {
-		bd.write(" - compositionToDTSShift: %d", b.CompositionToDTSShift)
-		bd.write(" - leastDecodeToDisplayDelta: %d", b.LeastDecodeToDisplayDelta)
-		bd.write(" - greatestDecodeToDisplayDelta: %d", b.GreatestDecodeToDisplayDelta)
-		bd.write(" - compositionStartTime: %d", b.CompositionStartTime)
-		bd.write(" - compositionEndTime: %d", b.CompositionEndTime)
-	}
+	bd.write(" - compositionToDTSShift: %d", b.CompositionToDTSShift)
+	bd.write(" - leastDecodeToDisplayDelta: %d", b.LeastDecodeToDisplayDelta)
+	bd.write(" - greatestDecodeToDisplayDelta: %d", b.GreatestDecodeToDisplayDelta)
+	bd.write(" - compositionStartTime: %d", b.CompositionStartTime)
+	bd.write(" - compositionEndTime: %d", b.CompositionEndTime)
 	return bd.err
 }
@@ -1,6 +1,10 @@
 package mp4
 
-import "testing"
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
 
 func TestCslgEncodeDecode(t *testing.T) {
 	cslg := CslgBox{
@@ -25,3 +29,23 @@ func TestCslgEncodeDecode(t *testing.T) {
 
 	boxDiffAfterEncodeAndDecode(t, &cslg)
 }
+
+func TestCslgInStbl(t *testing.T) {
+	stbl := NewStblBox()
+	stbl.AddChild(&CslgBox{CompositionToDTSShift: 1024, LeastDecodeToDisplayDelta: -1024,
+		GreatestDecodeToDisplayDelta: 2048, CompositionEndTime: 90000})
+	decStbl := boxAfterEncodeAndDecode(t, stbl).(*StblBox)
+	if decStbl.Cslg == nil || decStbl.Cslg.CompositionToDTSShift != 1024 {
+		t.Fatalf("cslg not decoded in stbl")
+	}
+	buf := bytes.Buffer{}
+	err := decStbl.Cslg.Info(&buf, "", "", "  ")
+	assertNoError(t, err)
+	if !strings.Contains(buf.String(), "compositionToDTSShift: 1024") {
+		t.Errorf("shift not in info: %s", buf.String())
+	}
+
+	cslg := &CslgBox{CompositionEndTime: 1 << 32}
+	err = cslg.Encode(&buf)
+	assertError(t, err, "version 0 with 64-bit value should give error")
+}
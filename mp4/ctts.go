@@ -2,8 +2,10 @@ package mp4
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 )
 
 // CttsBox - Composition Time to Sample Box (ctts - optional)
@@ -87,6 +89,54 @@ func (b *CttsBox) GetCompositionTimeOffset(sampleNr uint32) int32 {
 	return 0 // Should never get here, but a harmless return value
 }
 
+// AddSampleCompositionTimeOffset - add composition time offset of next sample.
+// The last entry is extended if the offset is the same. Version is set to 1 for negative offsets.
+func (b *CttsBox) AddSampleCompositionTimeOffset(offset int32) {
+	if offset < 0 {
+		b.Version = 1
+	}
+	n := len(b.SampleCount)
+	if n > 0 && b.SampleOffset[n-1] == offset {
+		b.SampleCount[n-1]++
+		return
+	}
+	b.SampleCount = append(b.SampleCount, 1)
+	b.SampleOffset = append(b.SampleOffset, offset)
+}
+
+// GetNrSamples - get total number of samples
+func (b *CttsBox) GetNrSamples() uint32 {
+	var nrSamples uint32
+	for _, c := range b.SampleCount {
+		nrSamples += c
+	}
+	return nrSamples
+}
+
+// Optimize - merge consecutive entries with the same offset and drop empty entries.
+// An error is returned if the SampleCount and SampleOffset slices have different lengths.
+func (b *CttsBox) Optimize() error {
+	if len(b.SampleCount) != len(b.SampleOffset) {
+		return fmt.Errorf("ctts: %d sample counts but %d sample offsets", len(b.SampleCount), len(b.SampleOffset))
+	}
+	counts := make([]uint32, 0, len(b.SampleCount))
+	offsets := make([]int32, 0, len(b.SampleCount))
+	for i := range b.SampleCount {
+		if b.SampleCount[i] == 0 {
+			continue
+		}
+		n := len(counts)
+		if n > 0 && offsets[n-1] == b.SampleOffset[i] && counts[n-1] <= math.MaxUint32-b.SampleCount[i] {
+			counts[n-1] += b.SampleCount[i]
+			continue
+		}
+		counts = append(counts, b.SampleCount[i])
+		offsets = append(offsets, b.SampleOffset[i])
+	}
+	b.SampleCount, b.SampleOffset = counts, offsets
+	return nil
+}
+
 // Info - get all info with specificBoxLevels ctts:1 or higher
 func (b *CttsBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
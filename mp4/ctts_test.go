@@ -40,3 +40,33 @@ func TestGetCompositionTimeOffset(t *testing.T) {
 		}
 	}
 }
+
+func TestCttsAddSampleCompositionTimeOffsetAndOptimize(t *testing.T) {
+	ctts := &CttsBox{}
+	offsets := []int32{2000, 0, 0, 1000, 1000, -500}
+	for _, offset := range offsets {
+		ctts.AddSampleCompositionTimeOffset(offset)
+	}
+	if len(ctts.SampleCount) != 4 || ctts.GetNrSamples() != 6 {
+		t.Errorf("got %d entries and %d samples instead of 4 and 6", len(ctts.SampleCount), ctts.GetNrSamples())
+	}
+	if ctts.Version != 1 {
+		t.Errorf("negative offset should give version 1")
+	}
+	for i, offset := range offsets {
+		if got := ctts.GetCompositionTimeOffset(uint32(i + 1)); got != offset {
+			t.Errorf("sample %d: got offset %d instead of %d", i+1, got, offset)
+		}
+	}
+
+	perSample := &CttsBox{
+		SampleCount:  []uint32{1, 1, 1, 0, 1},
+		SampleOffset: []int32{0, 0, 1000, 2000, 1000},
+	}
+	assertNoError(t, perSample.Optimize())
+	if len(perSample.SampleCount) != 2 || perSample.SampleCount[0] != 2 || perSample.SampleCount[1] != 2 {
+		t.Errorf("got sample counts %v instead of [2 2]", perSample.SampleCount)
+	}
+	bad := &CttsBox{SampleCount: []uint32{1, 2}, SampleOffset: []int32{1000}}
+	assertError(t, bad.Optimize(), "different slice lengths should give error")
+}
@@ -0,0 +1,104 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// AC3SampleRates - sample rates for AC-3 and E-AC-3 indexed by fscod
+var AC3SampleRates = []int{48000, 44100, 32000}
+
+// ac3ChannelsPerAcmod - number of full-bandwidth channels for acmod (audio coding mode)
+var ac3ChannelsPerAcmod = []int{2, 1, 2, 3, 3, 4, 4, 5}
+
+// ac3BitRates - bit rate in kbps indexed by bit_rate_code
+var ac3BitRates = []int{32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 448, 512, 576, 640}
+
+// Dac3Box - AC3SpecificBox from ETSI TS 102 366 Annex F
+type Dac3Box struct {
+	FSCod       byte
+	BSID        byte
+	BSMod       byte
+	ACMod       byte
+	LFEOn       byte
+	BitRateCode byte
+}
+
+// DecodeDac3 - box-specific decode
+func DecodeDac3(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 3 {
+		return nil, fmt.Errorf("dac3: size %d instead of 3 bytes", len(data))
+	}
+	b := &Dac3Box{
+		FSCod:       data[0] >> 6,
+		BSID:        (data[0] >> 1) & 0x1f,
+		BSMod:       (data[0]&0x1)<<2 | data[1]>>6,
+		ACMod:       (data[1] >> 3) & 0x7,
+		LFEOn:       (data[1] >> 2) & 0x1,
+		BitRateCode: (data[1]&0x3)<<3 | data[2]>>5,
+	}
+	return b, nil
+}
+
+// ChannelCount - number of channels including LFE
+func (b *Dac3Box) ChannelCount() int {
+	return ac3ChannelsPerAcmod[b.ACMod&0x7] + int(b.LFEOn)
+}
+
+// SampleRate - sample rate in Hz. Returns 0 if fscod is reserved
+func (b *Dac3Box) SampleRate() int {
+	if int(b.FSCod) >= len(AC3SampleRates) {
+		return 0
+	}
+	return AC3SampleRates[b.FSCod]
+}
+
+// BitRate - bit rate in bits per second. Returns 0 if bit_rate_code is not valid
+func (b *Dac3Box) BitRate() int {
+	if int(b.BitRateCode) >= len(ac3BitRates) {
+		return 0
+	}
+	return ac3BitRates[b.BitRateCode] * 1000
+}
+
+// Type - box type
+func (b *Dac3Box) Type() string {
+	return "dac3"
+}
+
+// Size - calculated size of box
+func (b *Dac3Box) Size() uint64 {
+	return boxHeaderSize + 3
+}
+
+// Encode - write box to w
+func (b *Dac3Box) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint8(b.FSCod<<6 | (b.BSID&0x1f)<<1 | (b.BSMod>>2)&0x1)
+	sw.WriteUint8(b.BSMod<<6 | (b.ACMod&0x7)<<3 | (b.LFEOn&0x1)<<2 | (b.BitRateCode>>3)&0x3)
+	sw.WriteUint8(b.BitRateCode << 5)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *Dac3Box) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - sampleRateCode=%d => sampleRate=%d", b.FSCod, b.SampleRate())
+	bd.write(" - bitStreamInformation=%d", b.BSID)
+	bd.write(" - audioMode=%d", b.BSMod)
+	bd.write(" - channelMode=%d => nrChannels=%d", b.ACMod, ac3ChannelsPerAcmod[b.ACMod&0x7])
+	bd.write(" - lfeOn=%d", b.LFEOn)
+	bd.write(" - bitRateCode=%d => bitrate=%d", b.BitRateCode, b.BitRate())
+	return bd.err
+}
@@ -0,0 +1,36 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDac3(t *testing.T) {
+	// 5.1 at 48kHz and 384kbps
+	data := []byte{0x00, 0x00, 0x00, 0x0b, 'd', 'a', 'c', '3', 0x10, 0x3d, 0xc0}
+	box, err := DecodeBox(0, bytes.NewReader(data))
+	assertNoError(t, err)
+	dac3 := box.(*Dac3Box)
+	if dac3.ChannelCount() != 6 || dac3.SampleRate() != 48000 || dac3.BitRate() != 384000 {
+		t.Errorf("got %d channels, %dHz, %dbps", dac3.ChannelCount(), dac3.SampleRate(), dac3.BitRate())
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, dac3.Encode(&buf))
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("dac3 not byte-exact after encode")
+	}
+	boxDiffAfterEncodeAndDecode(t, dac3)
+
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(48000, "audio", "und")
+	assertError(t, init.Moov.Trak.SetAC3Descriptor(nil), "missing dac3 should give error")
+	assertNoError(t, init.Moov.Trak.SetAC3Descriptor(dac3))
+	buf.Reset()
+	assertNoError(t, init.Encode(&buf))
+	decFile, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	ac3 := decFile.Moov.Trak.Mdia.Minf.Stbl.Stsd.Children[0].(*AudioSampleEntryBox)
+	if ac3.Type() != "ac-3" || ac3.ChannelCount != 6 || ac3.SampleRate != 48000 || ac3.Dac3 == nil {
+		t.Errorf("bad ac-3 sample entry %s with %d channels", ac3.Type(), ac3.ChannelCount)
+	}
+}
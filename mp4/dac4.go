@@ -0,0 +1,98 @@
+package mp4
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// AC4FrameRates - frame rates for AC-4 indexed by frame_rate_index (for 48kHz base sample rate)
+var AC4FrameRates = []float64{23.976, 24, 25, 29.97, 30, 47.95, 48, 50, 59.94, 60, 100, 119.88, 120, 23.44}
+
+// Dac4Box - AC4SpecificBox from ETSI TS 103 190-2 Annex E.
+// The start of the ac4_dsi_v1 is parsed, and the rest, including the presentations, is kept as is.
+type Dac4Box struct {
+	DSIVersion       byte
+	BitstreamVersion byte
+	FSIndex          byte
+	FrameRateIndex   byte
+	NPresentations   uint16
+	PresentationData []byte // Rest of ac4_dsi_v1 after n_presentations
+}
+
+// DecodeDac4 - box-specific decode
+func DecodeDac4(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 3 {
+		return nil, fmt.Errorf("dac4: too short %d bytes", len(data))
+	}
+	b := &Dac4Box{
+		DSIVersion:       data[0] >> 5,
+		BitstreamVersion: (data[0]&0x1f)<<2 | data[1]>>6,
+		FSIndex:          (data[1] >> 5) & 0x1,
+		FrameRateIndex:   (data[1] >> 1) & 0xf,
+		NPresentations:   uint16(data[1]&0x1)<<8 | uint16(data[2]),
+		PresentationData: data[3:],
+	}
+	return b, nil
+}
+
+// SampleRate - base sample rate 44100 or 48000 Hz given by fs_index
+func (b *Dac4Box) SampleRate() int {
+	if b.FSIndex == 0 {
+		return 44100
+	}
+	return 48000
+}
+
+// FrameRate - frame rate given by frame_rate_index. Returns 0 if not known
+func (b *Dac4Box) FrameRate() float64 {
+	if int(b.FrameRateIndex) >= len(AC4FrameRates) {
+		return 0
+	}
+	return AC4FrameRates[b.FrameRateIndex]
+}
+
+// Type - box type
+func (b *Dac4Box) Type() string {
+	return "dac4"
+}
+
+// Size - calculated size of box
+func (b *Dac4Box) Size() uint64 {
+	return uint64(boxHeaderSize + 3 + len(b.PresentationData))
+}
+
+// Encode - write box to w
+func (b *Dac4Box) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint8(b.DSIVersion<<5 | (b.BitstreamVersion>>2)&0x1f)
+	sw.WriteUint8(b.BitstreamVersion<<6 | (b.FSIndex&0x1)<<5 | (b.FrameRateIndex&0xf)<<1 | byte(b.NPresentations>>8)&0x1)
+	sw.WriteUint8(byte(b.NPresentations))
+	sw.WriteBytes(b.PresentationData)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *Dac4Box) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - dsiVersion=%d", b.DSIVersion)
+	bd.write(" - bitstreamVersion=%d", b.BitstreamVersion)
+	bd.write(" - fsIndex=%d => sampleRate=%d", b.FSIndex, b.SampleRate())
+	bd.write(" - frameRateIndex=%d => frameRate=%.3f", b.FrameRateIndex, b.FrameRate())
+	bd.write(" - nPresentations=%d", b.NPresentations)
+	if getInfoLevel(b, specificBoxLevels) > 0 {
+		bd.write(" - presentationData: %s", hex.EncodeToString(b.PresentationData))
+	}
+	return bd.err
+}
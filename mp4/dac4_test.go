@@ -0,0 +1,36 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDac4(t *testing.T) {
+	// ac4_dsi_v1 with one presentation at 48kHz and 25 fps
+	payload := []byte{0x20, 0xa4, 0x01, 0x80, 0x00, 0x00, 0x00, 0x3f, 0x80, 0x00, 0x00, 0x00, 0x00}
+	entry := CreateAudioSampleEntryBox("ac-4", 2, 16, 48000, nil)
+	hdr := []byte{0x00, 0x00, 0x00, byte(8 + len(payload)), 'd', 'a', 'c', '4'}
+	box, err := DecodeBox(0, bytes.NewReader(append(hdr, payload...)))
+	assertNoError(t, err)
+	entry.AddChild(box)
+
+	decEntry := boxAfterEncodeAndDecode(t, entry).(*AudioSampleEntryBox)
+	dac4 := decEntry.Dac4
+	if decEntry.Type() != "ac-4" || dac4 == nil {
+		t.Fatalf("no ac-4 entry with dac4 after decode")
+	}
+	if dac4.DSIVersion != 1 || dac4.BitstreamVersion != 2 || dac4.SampleRate() != 48000 ||
+		dac4.FrameRate() != 25 || dac4.NPresentations != 1 {
+		t.Errorf("bad decoded dac4 %+v", dac4)
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, dac4.Encode(&buf))
+	if !bytes.Equal(buf.Bytes()[8:], payload) {
+		t.Errorf("dac4 not byte-exact after encode")
+	}
+	info := bytes.Buffer{}
+	assertNoError(t, decEntry.Info(&info, "dac4:1", "", "  "))
+	if !bytes.Contains(info.Bytes(), []byte("frameRate=25.000")) {
+		t.Errorf("unexpected info output:\n%s", info.String())
+	}
+}
@@ -0,0 +1,158 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/edgeware/mp4ff/bits"
+)
+
+// Dec3Box - EC3SpecificBox from ETSI TS 102 366 Annex F
+type Dec3Box struct {
+	DataRate uint16 // Data rate in kbps
+	EC3Subs  []EC3Sub
+	Reserved []byte // Extra bytes such as Dolby Atmos signaling (flag_ec3_extension_type_a)
+}
+
+// EC3Sub - independent substream in EC3SpecificBox
+type EC3Sub struct {
+	FSCod     byte
+	BSID      byte
+	ASVC      byte
+	BSMod     byte
+	ACMod     byte
+	LFEOn     byte
+	NumDepSub byte
+	ChanLoc   uint16 // Only present if NumDepSub > 0
+}
+
+// DecodeDec3 - box-specific decode
+func DecodeDec3(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 {
+		return nil, fmt.Errorf("dec3: too short %d bytes", len(data))
+	}
+	b := &Dec3Box{}
+	br := bits.NewAccErrReader(bytes.NewReader(data))
+	b.DataRate = uint16(br.Read(13))
+	nrIndSubs := int(br.Read(3)) + 1
+	nrBits := 16
+	for i := 0; i < nrIndSubs; i++ {
+		sub := EC3Sub{}
+		sub.FSCod = byte(br.Read(2))
+		sub.BSID = byte(br.Read(5))
+		br.Read(1) // reserved
+		sub.ASVC = byte(br.Read(1))
+		sub.BSMod = byte(br.Read(3))
+		sub.ACMod = byte(br.Read(3))
+		sub.LFEOn = byte(br.Read(1))
+		br.Read(3) // reserved
+		sub.NumDepSub = byte(br.Read(4))
+		if sub.NumDepSub > 0 {
+			sub.ChanLoc = uint16(br.Read(9))
+			nrBits += 32
+		} else {
+			br.Read(1) // reserved
+			nrBits += 24
+		}
+		b.EC3Subs = append(b.EC3Subs, sub)
+	}
+	if br.AccError() != nil {
+		return nil, fmt.Errorf("dec3: too short for %d substreams", nrIndSubs)
+	}
+	if nrBits/8 < len(data) {
+		b.Reserved = data[nrBits/8:]
+	}
+	return b, nil
+}
+
+// ChannelCount - number of channels including LFE in first independent substream.
+// Channels in dependent substreams are not included.
+func (b *Dec3Box) ChannelCount() int {
+	if len(b.EC3Subs) == 0 {
+		return 0
+	}
+	sub := b.EC3Subs[0]
+	return ac3ChannelsPerAcmod[sub.ACMod&0x7] + int(sub.LFEOn)
+}
+
+// SampleRate - sample rate in Hz of first independent substream. Returns 0 if not known
+func (b *Dec3Box) SampleRate() int {
+	if len(b.EC3Subs) == 0 || int(b.EC3Subs[0].FSCod) >= len(AC3SampleRates) {
+		return 0
+	}
+	return AC3SampleRates[b.EC3Subs[0].FSCod]
+}
+
+// Type - box type
+func (b *Dec3Box) Type() string {
+	return "dec3"
+}
+
+// Size - calculated size of box
+func (b *Dec3Box) Size() uint64 {
+	nrBits := 16
+	for _, sub := range b.EC3Subs {
+		nrBits += 24
+		if sub.NumDepSub > 0 {
+			nrBits += 8
+		}
+	}
+	return uint64(boxHeaderSize + nrBits/8 + len(b.Reserved))
+}
+
+// Encode - write box to w
+func (b *Dec3Box) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	if len(b.EC3Subs) == 0 || len(b.EC3Subs) > 8 {
+		return fmt.Errorf("dec3: %d independent substreams not in range 1-8", len(b.EC3Subs))
+	}
+	buf := bytes.Buffer{}
+	bw := bits.NewWriter(&buf)
+	bw.Write(uint(b.DataRate), 13)
+	bw.Write(uint(len(b.EC3Subs)-1), 3)
+	for _, sub := range b.EC3Subs {
+		bw.Write(uint(sub.FSCod), 2)
+		bw.Write(uint(sub.BSID), 5)
+		bw.Write(0, 1) // reserved
+		bw.Write(uint(sub.ASVC), 1)
+		bw.Write(uint(sub.BSMod), 3)
+		bw.Write(uint(sub.ACMod), 3)
+		bw.Write(uint(sub.LFEOn), 1)
+		bw.Write(0, 3) // reserved
+		bw.Write(uint(sub.NumDepSub), 4)
+		if sub.NumDepSub > 0 {
+			bw.Write(uint(sub.ChanLoc), 9)
+		} else {
+			bw.Write(0, 1) // reserved
+		}
+	}
+	if bw.Error() != nil {
+		return bw.Error()
+	}
+	buf.Write(b.Reserved)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// Info - write box-specific information
+func (b *Dec3Box) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - dataRate=%d", b.DataRate)
+	for i, sub := range b.EC3Subs {
+		bd.write(" - ec3Sub[%d]: fscod=%d bsid=%d asvc=%d bsmod=%d acmod=%d lfeon=%d numDepSub=%d chanLoc=%d",
+			i, sub.FSCod, sub.BSID, sub.ASVC, sub.BSMod, sub.ACMod, sub.LFEOn, sub.NumDepSub, sub.ChanLoc)
+	}
+	if len(b.Reserved) > 0 {
+		bd.write(" - reserved: %x", b.Reserved)
+	}
+	return bd.err
+}
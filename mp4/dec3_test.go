@@ -0,0 +1,38 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDec3(t *testing.T) {
+	// 5.1 at 192kbps with Dolby Atmos extension
+	data := []byte{0x00, 0x00, 0x00, 0x0f, 'd', 'e', 'c', '3', 0x06, 0x00, 0x20, 0x0f, 0x00, 0x01, 0x10}
+	box, err := DecodeBox(0, bytes.NewReader(data))
+	assertNoError(t, err)
+	dec3 := box.(*Dec3Box)
+	if dec3.DataRate != 192 || len(dec3.EC3Subs) != 1 || dec3.ChannelCount() != 6 || dec3.SampleRate() != 48000 {
+		t.Errorf("bad decoded dec3 %+v", dec3)
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, dec3.Encode(&buf))
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("dec3 not byte-exact after encode")
+	}
+	withDepSub := &Dec3Box{DataRate: 640, EC3Subs: []EC3Sub{
+		{BSID: 16, ACMod: 7, LFEOn: 1, NumDepSub: 1, ChanLoc: 2},
+		{FSCod: 1, BSID: 16, ACMod: 2}}}
+	boxDiffAfterEncodeAndDecode(t, withDepSub)
+
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(48000, "audio", "und")
+	assertNoError(t, init.Moov.Trak.SetEC3Descriptor(dec3))
+	buf.Reset()
+	assertNoError(t, init.Encode(&buf))
+	decFile, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	ec3 := decFile.Moov.Trak.Mdia.Minf.Stbl.Stsd.Children[0].(*AudioSampleEntryBox)
+	if ec3.Type() != "ec-3" || ec3.ChannelCount != 6 || ec3.Dec3 == nil || len(ec3.Dec3.Reserved) != 2 {
+		t.Errorf("bad ec-3 sample entry %s with %d channels", ec3.Type(), ec3.ChannelCount)
+	}
+}
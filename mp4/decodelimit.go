@@ -0,0 +1,64 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+)
+
+// DecodeSizeError - error returned by DecodeFile when more bytes than allowed by WithMaxDecodeSize are read
+type DecodeSizeError struct {
+	Limit uint64
+}
+
+// Error - error message with the limit
+func (e *DecodeSizeError) Error() string {
+	return fmt.Sprintf("decode size limit of %d bytes exceeded", e.Limit)
+}
+
+// WithMaxDecodeSize sets up a hard limit on the number of bytes DecodeFile reads into memory.
+// When the limit is exceeded, decoding stops with an error wrapping *DecodeSizeError,
+// which can be found with errors.As. Since box data is read incrementally, memory use stays bounded
+// even if box sizes are large or forged. Mdat data skipped with DecModeLazyMdat is not counted.
+// 0 means no limit.
+func WithMaxDecodeSize(maxBytes uint64) Option {
+	return func(f *File) { f.decMaxSize = maxBytes }
+}
+
+// quotaReader - reader that fails with *DecodeSizeError when more than limit bytes are read
+type quotaReader struct {
+	r     io.Reader
+	n     uint64
+	limit uint64
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	// Read at most one byte beyond the limit, to tell if there is more data
+	if max := q.limit - q.n + 1; uint64(len(p)) > max {
+		p = p[:max]
+	}
+	n, err := q.r.Read(p)
+	q.n += uint64(n)
+	if q.n > q.limit {
+		return n - int(q.n-q.limit), &DecodeSizeError{Limit: q.limit}
+	}
+	return n, err
+}
+
+// quotaReadSeeker - quotaReader for lazy decoding. Seeking past data does not count.
+type quotaReadSeeker struct {
+	quotaReader
+	s io.Seeker
+}
+
+func (q *quotaReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return q.s.Seek(offset, whence)
+}
+
+// newQuotaReader - wrap r so that at most limit bytes can be read. The result is an io.ReadSeeker if r is.
+func newQuotaReader(r io.Reader, limit uint64) io.Reader {
+	q := quotaReader{r: r, limit: limit}
+	if s, ok := r.(io.Seeker); ok {
+		return &quotaReadSeeker{quotaReader: q, s: s}
+	}
+	return &q
+}
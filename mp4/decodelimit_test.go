@@ -0,0 +1,47 @@
+package mp4
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func TestDecodeFileWithMaxDecodeSize(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/prog_8s.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := uint64(len(data))
+
+	testCases := []struct {
+		desc    string
+		limit   uint64
+		options []Option
+		wantErr bool
+	}{
+		{"exact size", size, nil, false},
+		{"one byte short", size - 1, nil, true},
+		{"small", 100, nil, true},
+		{"lazy mdat not counted", size / 2, []Option{WithDecodeMode(DecModeLazyMdat)}, false},
+		{"lazy small", 100, []Option{WithDecodeMode(DecModeLazyMdat)}, true},
+	}
+	for _, tc := range testCases {
+		options := append(tc.options, WithMaxDecodeSize(tc.limit))
+		_, err := DecodeFile(bytes.NewReader(data), options...)
+		if !tc.wantErr {
+			if err != nil {
+				t.Errorf("%s: %s", tc.desc, err)
+			}
+			continue
+		}
+		var sizeErr *DecodeSizeError
+		if !errors.As(err, &sizeErr) {
+			t.Errorf("%s: expected DecodeSizeError, got %v", tc.desc, err)
+			continue
+		}
+		if sizeErr.Limit != tc.limit {
+			t.Errorf("%s: got limit %d instead of %d", tc.desc, sizeErr.Limit, tc.limit)
+		}
+	}
+}
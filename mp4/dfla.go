@@ -0,0 +1,172 @@
+package mp4
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// FLAC metadata block types
+const (
+	FlacStreamInfoType = 0
+	FlacPaddingType    = 1
+)
+
+const flacStreamInfoSize = 34
+
+// FlacStreamInfo - FLAC METADATA_BLOCK_STREAMINFO
+type FlacStreamInfo struct {
+	MinBlockSize  uint16
+	MaxBlockSize  uint16
+	MinFrameSize  uint32 // 24 bits
+	MaxFrameSize  uint32 // 24 bits
+	SampleRate    uint32 // 20 bits
+	NrChannels    byte   // 1-8
+	BitsPerSample byte   // 4-32
+	TotalSamples  uint64 // 36 bits
+	MD5           []byte // 16 bytes
+}
+
+// FlacMetadataBlock - FLAC metadata block other than STREAMINFO, kept as raw data
+type FlacMetadataBlock struct {
+	BlockType byte
+	Data      []byte
+}
+
+// DflaBox - FLACSpecificBox (dfLa) as defined in Encapsulation of FLAC in ISO Base Media File Format.
+// The first metadata block is always STREAMINFO. Other blocks are kept as raw data.
+type DflaBox struct {
+	Version        byte
+	Flags          uint32
+	StreamInfo     FlacStreamInfo
+	MetadataBlocks []FlacMetadataBlock
+}
+
+// DecodeDfla - box-specific decode
+func DecodeDfla(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4+4+flacStreamInfoSize {
+		return nil, fmt.Errorf("dfLa: too short %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
+	b := &DflaBox{
+		Version: byte(versionAndFlags >> 24),
+		Flags:   versionAndFlags & flagsMask,
+	}
+	for nr := 0; ; nr++ {
+		if s.NrRemainingBytes() < 4 {
+			return nil, fmt.Errorf("dfLa: no last metadata block")
+		}
+		blockHeader := s.ReadUint32()
+		isLast := blockHeader>>31 == 1
+		blockType := byte(blockHeader>>24) & 0x7f
+		length := int(blockHeader & 0xffffff)
+		if length > s.NrRemainingBytes() {
+			return nil, fmt.Errorf("dfLa: metadata block length %d, but %d bytes left", length, s.NrRemainingBytes())
+		}
+		if nr == 0 {
+			if blockType != FlacStreamInfoType || length != flacStreamInfoSize {
+				return nil, fmt.Errorf("dfLa: first metadata block is not STREAMINFO")
+			}
+			b.StreamInfo = decodeFlacStreamInfo(s)
+		} else {
+			b.MetadataBlocks = append(b.MetadataBlocks, FlacMetadataBlock{BlockType: blockType, Data: s.ReadBytes(length)})
+		}
+		if isLast {
+			break
+		}
+	}
+	if s.NrRemainingBytes() != 0 {
+		return nil, fmt.Errorf("dfLa: %d bytes after last metadata block", s.NrRemainingBytes())
+	}
+	return b, nil
+}
+
+func decodeFlacStreamInfo(s *SliceReader) FlacStreamInfo {
+	si := FlacStreamInfo{}
+	si.MinBlockSize = s.ReadUint16()
+	si.MaxBlockSize = s.ReadUint16()
+	si.MinFrameSize = s.ReadUint24()
+	si.MaxFrameSize = s.ReadUint24()
+	packed := s.ReadUint64()
+	si.SampleRate = uint32(packed >> 44)
+	si.NrChannels = byte(packed>>41)&0x7 + 1
+	si.BitsPerSample = byte(packed>>36)&0x1f + 1
+	si.TotalSamples = packed & 0xfffffffff
+	si.MD5 = s.ReadBytes(16)
+	return si
+}
+
+// Type - box type
+func (b *DflaBox) Type() string {
+	return "dfLa"
+}
+
+// Size - calculated size of box
+func (b *DflaBox) Size() uint64 {
+	size := uint64(boxHeaderSize + 4 + 4 + flacStreamInfoSize)
+	for _, mb := range b.MetadataBlocks {
+		size += 4 + uint64(len(mb.Data))
+	}
+	return size
+}
+
+// Encode - write box to w
+func (b *DflaBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
+	sw.WriteUint32(versionAndFlags)
+	sw.WriteUint32(flacBlockHeader(len(b.MetadataBlocks) == 0, FlacStreamInfoType, flacStreamInfoSize))
+	si := b.StreamInfo
+	sw.WriteUint16(si.MinBlockSize)
+	sw.WriteUint16(si.MaxBlockSize)
+	sw.WriteUint24(si.MinFrameSize)
+	sw.WriteUint24(si.MaxFrameSize)
+	packed := uint64(si.SampleRate&0xfffff)<<44 | uint64((si.NrChannels-1)&0x7)<<41 |
+		uint64((si.BitsPerSample-1)&0x1f)<<36 | si.TotalSamples&0xfffffffff
+	sw.WriteUint64(packed)
+	md5 := make([]byte, 16)
+	copy(md5, si.MD5)
+	sw.WriteBytes(md5)
+	for i, mb := range b.MetadataBlocks {
+		sw.WriteUint32(flacBlockHeader(i == len(b.MetadataBlocks)-1, mb.BlockType, len(mb.Data)))
+		sw.WriteBytes(mb.Data)
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func flacBlockHeader(isLast bool, blockType byte, length int) uint32 {
+	header := uint32(blockType&0x7f)<<24 | uint32(length)&0xffffff
+	if isLast {
+		header |= 1 << 31
+	}
+	return header
+}
+
+// Info - write box-specific information
+func (b *DflaBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	si := b.StreamInfo
+	bd.write(" - blockSize: %d-%d", si.MinBlockSize, si.MaxBlockSize)
+	bd.write(" - frameSize: %d-%d", si.MinFrameSize, si.MaxFrameSize)
+	bd.write(" - sampleRate: %d", si.SampleRate)
+	bd.write(" - nrChannels: %d", si.NrChannels)
+	bd.write(" - bitsPerSample: %d", si.BitsPerSample)
+	bd.write(" - totalSamples: %d", si.TotalSamples)
+	bd.write(" - md5: %s", hex.EncodeToString(si.MD5))
+	for _, mb := range b.MetadataBlocks {
+		bd.write(" - metadataBlock: type=%d size=%d", mb.BlockType, len(mb.Data))
+	}
+	return bd.err
+}
@@ -0,0 +1,45 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestDfla(t *testing.T) {
+	// STREAMINFO for 44.1kHz stereo 16-bit followed by 4 bytes of padding
+	data, _ := hex.DecodeString("0000003a64664c6100000000000000221000100000000e002ee00ac442f00006baa8" +
+		"000102030405060708090a0b0c0d0e0f8100000400000000")
+	box, err := DecodeBox(0, bytes.NewReader(data))
+	assertNoError(t, err)
+	dfla := box.(*DflaBox)
+	si := dfla.StreamInfo
+	if si.SampleRate != 44100 || si.NrChannels != 2 || si.BitsPerSample != 16 || si.TotalSamples != 441000 {
+		t.Errorf("got STREAMINFO %+v", si)
+	}
+	if si.MinBlockSize != 4096 || si.MinFrameSize != 14 || si.MaxFrameSize != 12000 {
+		t.Errorf("got STREAMINFO %+v", si)
+	}
+	if len(dfla.MetadataBlocks) != 1 || dfla.MetadataBlocks[0].BlockType != FlacPaddingType {
+		t.Errorf("padding block not decoded")
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, dfla.Encode(&buf))
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("dfLa not byte-exact after encode")
+	}
+	dfla.MetadataBlocks = nil
+	boxDiffAfterEncodeAndDecode(t, dfla)
+
+	_, err = DecodeBox(0, bytes.NewReader(data[:len(data)-4]))
+	assertError(t, err, "truncated metadata block should give error")
+
+	flac := CreateAudioSampleEntryBox("fLaC", 2, 16, 44100, dfla)
+	buf.Reset()
+	assertNoError(t, flac.Encode(&buf))
+	box, err = DecodeBox(0, &buf)
+	assertNoError(t, err)
+	if decFlac := box.(*AudioSampleEntryBox); decFlac.Dfla == nil || decFlac.Type() != "fLaC" {
+		t.Errorf("fLaC sample entry without dfLa")
+	}
+}
@@ -0,0 +1,166 @@
+package mp4
+
+import (
+	"fmt"
+
+	"github.com/edgeware/mp4ff/avc"
+	"github.com/edgeware/mp4ff/hevc"
+)
+
+// VideoDimensions - width and height of a video track as signaled in different places
+type VideoDimensions struct {
+	TkhdWidth   Fixed32 // Display width in tkhd
+	TkhdHeight  Fixed32 // Display height in tkhd
+	EntryWidth  uint16  // Width in visual sample entry
+	EntryHeight uint16  // Height in visual sample entry
+	HSpacing    uint32  // Horizontal pixel aspect from pasp. 1 if no pasp
+	VSpacing    uint32  // Vertical pixel aspect from pasp. 1 if no pasp
+	SPSWidth    uint    // Width after cropping from SPS. 0 if no AVC or HEVC SPS
+	SPSHeight   uint    // Height after cropping from SPS. 0 if no AVC or HEVC SPS
+	SARWidth    uint    // Sample aspect ratio width from AVC SPS VUI. 0 if not present
+	SARHeight   uint    // Sample aspect ratio height from AVC SPS VUI. 0 if not present
+}
+
+// GetVideoDimensions - collect dimensions from tkhd, the first visual sample entry, its pasp box, and SPS
+func (t *TrakBox) GetVideoDimensions() (VideoDimensions, error) {
+	d := VideoDimensions{TkhdWidth: t.Tkhd.Width, TkhdHeight: t.Tkhd.Height, HSpacing: 1, VSpacing: 1}
+	vse := t.Mdia.Minf.Stbl.Stsd.firstVisualSampleEntry()
+	if vse == nil {
+		return d, fmt.Errorf("no visual sample entry")
+	}
+	d.EntryWidth, d.EntryHeight = vse.Width, vse.Height
+	if vse.Pasp != nil {
+		d.HSpacing, d.VSpacing = vse.Pasp.HSpacing, vse.Pasp.VSpacing
+	}
+	switch {
+	case vse.AvcC != nil && len(vse.AvcC.SPSnalus) > 0:
+		sps, err := avc.ParseSPSNALUnit(vse.AvcC.SPSnalus[0], false)
+		if err != nil {
+			return d, err
+		}
+		d.SPSWidth, d.SPSHeight = sps.Width, sps.Height
+		if sps.VUI != nil {
+			d.SARWidth, d.SARHeight = sps.VUI.SampleAspectRatioWidth, sps.VUI.SampleAspectRatioHeight
+		}
+	case vse.HvcC != nil:
+		if spss := vse.HvcC.GetNalusForType(hevc.NALU_SPS); len(spss) > 0 {
+			sps, err := hevc.ParseSPSNALUnit(spss[0])
+			if err != nil {
+				return d, err
+			}
+			width, height := sps.ImageSize()
+			d.SPSWidth, d.SPSHeight = uint(width), uint(height)
+		}
+	}
+	return d, nil
+}
+
+// DisplaySize - display width and height in 16.16 fixed point from sample entry size and pasp.
+// The width is scaled by the pixel aspect ratio.
+func (d VideoDimensions) DisplaySize() (width, height Fixed32) {
+	hSpacing, vSpacing := uint64(d.HSpacing), uint64(d.VSpacing)
+	if hSpacing == 0 || vSpacing == 0 {
+		hSpacing, vSpacing = 1, 1
+	}
+	width = Fixed32((uint64(d.EntryWidth) << 16) * hSpacing / vSpacing)
+	height = Fixed32(uint32(d.EntryHeight) << 16)
+	return width, height
+}
+
+// DisplayAspectRatio - display aspect ratio reduced to lowest terms from sample entry size and pasp,
+// e.g. 16:9. Returns 0:0 if the sample entry size is not set.
+func (d VideoDimensions) DisplayAspectRatio() (num, den uint64) {
+	hSpacing, vSpacing := uint64(d.HSpacing), uint64(d.VSpacing)
+	if hSpacing == 0 || vSpacing == 0 {
+		hSpacing, vSpacing = 1, 1
+	}
+	num, den = uint64(d.EntryWidth)*hSpacing, uint64(d.EntryHeight)*vSpacing
+	if num == 0 || den == 0 {
+		return 0, 0
+	}
+	g := gcd(num, den)
+	return num / g, den / g
+}
+
+// GetDisplayAspectRatio - display aspect ratio of the first visual sample entry of the track, taking pasp into account
+func (t *TrakBox) GetDisplayAspectRatio() (num, den uint64, err error) {
+	d, err := t.GetVideoDimensions()
+	if err != nil {
+		return 0, 0, err
+	}
+	num, den = d.DisplayAspectRatio()
+	if num == 0 {
+		return 0, 0, fmt.Errorf("sample entry size not set")
+	}
+	return num, den, nil
+}
+
+// Mismatches - descriptions of inconsistencies between the dimensions. Empty if consistent
+func (d VideoDimensions) Mismatches() []string {
+	var msgs []string
+	width, height := d.DisplaySize()
+	if d.TkhdWidth != width || d.TkhdHeight != height {
+		msgs = append(msgs, fmt.Sprintf("tkhd size %.2fx%.2f differs from display size %.2fx%.2f given by sample entry and pasp",
+			fixed32ToFloat(d.TkhdWidth), fixed32ToFloat(d.TkhdHeight), fixed32ToFloat(width), fixed32ToFloat(height)))
+	}
+	if d.SPSWidth != 0 && (d.SPSWidth != uint(d.EntryWidth) || d.SPSHeight != uint(d.EntryHeight)) {
+		msgs = append(msgs, fmt.Sprintf("sample entry size %dx%d differs from SPS size %dx%d",
+			d.EntryWidth, d.EntryHeight, d.SPSWidth, d.SPSHeight))
+	}
+	if d.SARWidth != 0 && d.SARHeight != 0 && uint64(d.SARWidth)*uint64(d.VSpacing) != uint64(d.SARHeight)*uint64(d.HSpacing) {
+		msgs = append(msgs, fmt.Sprintf("pasp %d:%d differs from SPS sample aspect ratio %d:%d",
+			d.HSpacing, d.VSpacing, d.SARWidth, d.SARHeight))
+	}
+	return msgs
+}
+
+// FixVideoDimensions - make tkhd, sample entry, and pasp dimensions consistent.
+// If trustBitstream is true, the sample entry size is set from the SPS and the pasp box from the
+// SPS sample aspect ratio (if signaled). The tkhd display size is then always derived from the
+// sample entry size and pasp.
+func (t *TrakBox) FixVideoDimensions(trustBitstream bool) error {
+	d, err := t.GetVideoDimensions()
+	if err != nil {
+		return err
+	}
+	vse := t.Mdia.Minf.Stbl.Stsd.firstVisualSampleEntry()
+	if trustBitstream {
+		if d.SPSWidth == 0 || d.SPSHeight == 0 {
+			return fmt.Errorf("no SPS to get dimensions from")
+		}
+		vse.Width, vse.Height = uint16(d.SPSWidth), uint16(d.SPSHeight)
+		if d.SARWidth != 0 && d.SARHeight != 0 {
+			if vse.Pasp == nil {
+				vse.AddChild(&PaspBox{})
+			}
+			vse.Pasp.HSpacing, vse.Pasp.VSpacing = uint32(d.SARWidth), uint32(d.SARHeight)
+		}
+		d, err = t.GetVideoDimensions()
+		if err != nil {
+			return err
+		}
+	}
+	t.Tkhd.Width, t.Tkhd.Height = d.DisplaySize()
+	return nil
+}
+
+// firstVisualSampleEntry - first visual sample entry in stsd or nil
+func (s *StsdBox) firstVisualSampleEntry() *VisualSampleEntryBox {
+	for _, c := range s.Children {
+		if vse, ok := c.(*VisualSampleEntryBox); ok {
+			return vse
+		}
+	}
+	return nil
+}
+
+func fixed32ToFloat(f Fixed32) float64 {
+	return float64(f) / 65536
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,75 @@
+package mp4
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestFixVideoDimensions(t *testing.T) {
+	sps, _ := hex.DecodeString(sps1nalu)
+	pps, _ := hex.DecodeString(pps1nalu)
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	trak := init.Moov.Trak
+	assertNoError(t, trak.SetAVCDescriptor("avc1", [][]byte{sps}, [][]byte{pps}))
+	d, err := trak.GetVideoDimensions()
+	assertNoError(t, err)
+	if msgs := d.Mismatches(); len(msgs) != 0 {
+		t.Errorf("unexpected mismatches %v", msgs)
+	}
+
+	avcx := trak.Mdia.Minf.Stbl.Stsd.AvcX
+	avcx.Width = 320
+	avcx.AddChild(&PaspBox{HSpacing: 4, VSpacing: 3})
+	d, err = trak.GetVideoDimensions()
+	assertNoError(t, err)
+	if msgs := d.Mismatches(); len(msgs) != 3 {
+		t.Errorf("got mismatches %v instead of tkhd, SPS size, and SPS aspect ratio", msgs)
+	}
+	assertNoError(t, trak.FixVideoDimensions(false))
+	if trak.Tkhd.Width != Fixed32((320<<16)*4/3) || trak.Tkhd.Height != Fixed32(360<<16) {
+		t.Errorf("got tkhd size %.2fx%.2f", fixed32ToFloat(trak.Tkhd.Width), fixed32ToFloat(trak.Tkhd.Height))
+	}
+
+	assertNoError(t, trak.FixVideoDimensions(true))
+	d, err = trak.GetVideoDimensions()
+	assertNoError(t, err)
+	if msgs := d.Mismatches(); len(msgs) != 0 || avcx.Width != 640 || avcx.Pasp.HSpacing != 1 || trak.Tkhd.Width != Fixed32(640<<16) {
+		t.Errorf("not consistent after trusting bitstream: %v", msgs)
+	}
+
+	init.AddEmptyTrack(48000, "audio", "und")
+	assertError(t, init.Moov.Traks[1].FixVideoDimensions(false), "audio track should give error")
+}
+
+func TestDisplayAspectRatio(t *testing.T) {
+	testCases := []struct {
+		width, height      uint16
+		pasp               *PaspBox
+		wantedN, wantedDen uint64
+	}{
+		{1920, 1080, nil, 16, 9},
+		{1440, 1080, &PaspBox{HSpacing: 4, VSpacing: 3}, 16, 9},
+		{720, 576, &PaspBox{HSpacing: 16, VSpacing: 15}, 4, 3},
+		{640, 480, &PaspBox{HSpacing: 0, VSpacing: 0}, 4, 3},
+	}
+	for _, tc := range testCases {
+		init := CreateEmptyInit()
+		init.AddEmptyTrack(90000, "video", "und")
+		trak := init.Moov.Trak
+		vse := CreateVisualSampleEntryBox("avc1", tc.width, tc.height, nil)
+		if tc.pasp != nil {
+			vse.AddChild(tc.pasp)
+		}
+		trak.Mdia.Minf.Stbl.Stsd.AddChild(vse)
+		num, den, err := trak.GetDisplayAspectRatio()
+		assertNoError(t, err)
+		if num != tc.wantedN || den != tc.wantedDen {
+			t.Errorf("%dx%d: got %d:%d instead of %d:%d", tc.width, tc.height, num, den, tc.wantedN, tc.wantedDen)
+		}
+	}
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(48000, "audio", "und")
+	_, _, err := init.Moov.Trak.GetDisplayAspectRatio()
+	assertError(t, err, "audio track has no aspect ratio")
+}
@@ -50,6 +50,15 @@ func (d *DinfBox) GetChildren() []Box {
 	return d.Children
 }
 
+func (d *DinfBox) setChildren(children []Box) error {
+	n := DinfBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*d = n
+	return nil
+}
+
 // Encode - write dinf container to w
 func (d *DinfBox) Encode(w io.Writer) error {
 	return EncodeContainer(d, w)
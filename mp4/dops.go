@@ -0,0 +1,119 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// DopsBox - OpusSpecificBox (dOps) as defined in Encapsulation of Opus in ISO Base Media File Format v0.8.1
+type DopsBox struct {
+	Version              byte
+	OutputChannelCount   byte
+	PreSkip              uint16
+	InputSampleRate      uint32
+	OutputGain           int16 // Q7.8 dB
+	ChannelMappingFamily byte
+	StreamCount          byte   // Present if ChannelMappingFamily != 0
+	CoupledCount         byte   // Present if ChannelMappingFamily != 0
+	ChannelMapping       []byte // OutputChannelCount bytes present if ChannelMappingFamily != 0
+}
+
+// CreateDops - create dOps box for mono or stereo with channel mapping family 0
+func CreateDops(outputChannelCount byte, preSkip uint16, inputSampleRate uint32) *DopsBox {
+	return &DopsBox{
+		OutputChannelCount: outputChannelCount,
+		PreSkip:            preSkip,
+		InputSampleRate:    inputSampleRate,
+	}
+}
+
+// DecodeDops - box-specific decode
+func DecodeDops(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 11 {
+		return nil, fmt.Errorf("dOps: too short %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	b := &DopsBox{}
+	b.Version = s.ReadUint8()
+	if b.Version != 0 {
+		return nil, fmt.Errorf("dOps: version %d not supported", b.Version)
+	}
+	b.OutputChannelCount = s.ReadUint8()
+	b.PreSkip = s.ReadUint16()
+	b.InputSampleRate = s.ReadUint32()
+	b.OutputGain = s.ReadInt16()
+	b.ChannelMappingFamily = s.ReadUint8()
+	if b.ChannelMappingFamily != 0 {
+		if s.NrRemainingBytes() != 2+int(b.OutputChannelCount) {
+			return nil, fmt.Errorf("dOps: %d bytes left for channel mapping table with %d channels",
+				s.NrRemainingBytes(), b.OutputChannelCount)
+		}
+		b.StreamCount = s.ReadUint8()
+		b.CoupledCount = s.ReadUint8()
+		b.ChannelMapping = s.ReadBytes(int(b.OutputChannelCount))
+	}
+	return b, nil
+}
+
+// Type - box type
+func (b *DopsBox) Type() string {
+	return "dOps"
+}
+
+// Size - calculated size of box
+func (b *DopsBox) Size() uint64 {
+	size := uint64(boxHeaderSize + 11)
+	if b.ChannelMappingFamily != 0 {
+		size += 2 + uint64(b.OutputChannelCount)
+	}
+	return size
+}
+
+// Encode - write box to w
+func (b *DopsBox) Encode(w io.Writer) error {
+	if b.ChannelMappingFamily != 0 && len(b.ChannelMapping) != int(b.OutputChannelCount) {
+		return fmt.Errorf("dOps: channel mapping has %d entries for %d channels",
+			len(b.ChannelMapping), b.OutputChannelCount)
+	}
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint8(b.Version)
+	sw.WriteUint8(b.OutputChannelCount)
+	sw.WriteUint16(b.PreSkip)
+	sw.WriteUint32(b.InputSampleRate)
+	sw.WriteInt16(b.OutputGain)
+	sw.WriteUint8(b.ChannelMappingFamily)
+	if b.ChannelMappingFamily != 0 {
+		sw.WriteUint8(b.StreamCount)
+		sw.WriteUint8(b.CoupledCount)
+		sw.WriteBytes(b.ChannelMapping)
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *DopsBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - version: %d", b.Version)
+	bd.write(" - outputChannelCount: %d", b.OutputChannelCount)
+	bd.write(" - preSkip: %d", b.PreSkip)
+	bd.write(" - inputSampleRate: %d", b.InputSampleRate)
+	bd.write(" - outputGain: %d", b.OutputGain)
+	bd.write(" - channelMappingFamily: %d", b.ChannelMappingFamily)
+	if b.ChannelMappingFamily != 0 {
+		bd.write(" - streamCount: %d", b.StreamCount)
+		bd.write(" - coupledCount: %d", b.CoupledCount)
+		bd.write(" - channelMapping: %v", b.ChannelMapping)
+	}
+	return bd.err
+}
@@ -0,0 +1,43 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDops(t *testing.T) {
+	// Stereo with pre-skip 312 and input sample rate 48kHz
+	data := []byte{0x00, 0x00, 0x00, 0x13, 'd', 'O', 'p', 's', 0x00, 0x02, 0x01, 0x38,
+		0x00, 0x00, 0xbb, 0x80, 0xff, 0x00, 0x00}
+	box, err := DecodeBox(0, bytes.NewReader(data))
+	assertNoError(t, err)
+	dops := box.(*DopsBox)
+	if dops.OutputChannelCount != 2 || dops.PreSkip != 312 || dops.InputSampleRate != 48000 || dops.OutputGain != -256 {
+		t.Errorf("got %+v", dops)
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, dops.Encode(&buf))
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("dOps not byte-exact after encode")
+	}
+
+	surround := CreateDops(6, 312, 48000)
+	surround.ChannelMappingFamily = 1
+	surround.StreamCount = 4
+	surround.CoupledCount = 2
+	surround.ChannelMapping = []byte{0, 4, 1, 2, 3, 5}
+	boxDiffAfterEncodeAndDecode(t, surround)
+
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(48000, "audio", "und")
+	assertError(t, init.Moov.Trak.SetOpusDescriptor(nil), "missing dOps should give error")
+	assertNoError(t, init.Moov.Trak.SetOpusDescriptor(surround))
+	buf.Reset()
+	assertNoError(t, init.Encode(&buf))
+	decFile, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	opus := decFile.Moov.Trak.Mdia.Minf.Stbl.Stsd.Children[0].(*AudioSampleEntryBox)
+	if opus.Type() != "Opus" || opus.ChannelCount != 6 || opus.SampleRate != 48000 || opus.Dops == nil {
+		t.Errorf("bad Opus sample entry %s with %d channels", opus.Type(), opus.ChannelCount)
+	}
+}
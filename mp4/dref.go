@@ -65,6 +65,57 @@ func DecodeDref(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	return dref, nil
 }
 
+// GetEntry - get data entry (url or urn box) for one-based dataReferenceIndex
+func (d *DrefBox) GetEntry(dataReferenceIndex uint16) (Box, error) {
+	if dataReferenceIndex == 0 || int(dataReferenceIndex) > len(d.Children) {
+		return nil, fmt.Errorf("dataReferenceIndex %d outside range 1-%d", dataReferenceIndex, len(d.Children))
+	}
+	return d.Children[dataReferenceIndex-1], nil
+}
+
+// GetLocation - get location of media data for one-based dataReferenceIndex.
+// selfContained is true if the data is in the same file as the moov box.
+// For urn entries without location, the name is returned.
+func (d *DrefBox) GetLocation(dataReferenceIndex uint16) (location string, selfContained bool, err error) {
+	entry, err := d.GetEntry(dataReferenceIndex)
+	if err != nil {
+		return "", false, err
+	}
+	switch e := entry.(type) {
+	case *URLBox:
+		return e.Location, e.IsSelfContained(), nil
+	case *URNBox:
+		if e.IsSelfContained() {
+			return "", true, nil
+		}
+		if e.Location != "" {
+			return e.Location, false, nil
+		}
+		return e.Name, false, nil
+	default:
+		return "", false, fmt.Errorf("unknown data entry type %q", entry.Type())
+	}
+}
+
+// allSelfContained - true if all entries refer to data in the same file
+func (d *DrefBox) allSelfContained() bool {
+	for _, c := range d.Children {
+		switch e := c.(type) {
+		case *URLBox:
+			if !e.IsSelfContained() {
+				return false
+			}
+		case *URNBox:
+			if !e.IsSelfContained() {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Type - box type
 func (d *DrefBox) Type() string {
 	return "dref"
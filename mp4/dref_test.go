@@ -8,3 +8,34 @@ func TestDref(t *testing.T) {
 	dref := CreateDref()
 	boxDiffAfterEncodeAndDecode(t, dref)
 }
+
+func TestDrefMultipleEntries(t *testing.T) {
+	dref := CreateDref()
+	dref.AddChild(CreateExternalURLBox("media.mp4"))
+	dref.AddChild(CreateURNBox("urn:example:media", ""))
+	dref.AddChild(CreateURNBox("urn:example:other", "other.mp4"))
+	boxDiffAfterEncodeAndDecode(t, dref)
+
+	testCases := []struct {
+		index         uint16
+		location      string
+		selfContained bool
+	}{
+		{1, "", true},
+		{2, "media.mp4", false},
+		{3, "urn:example:media", false},
+		{4, "other.mp4", false},
+	}
+	for _, tc := range testCases {
+		location, selfContained, err := dref.GetLocation(tc.index)
+		assertNoError(t, err)
+		if location != tc.location || selfContained != tc.selfContained {
+			t.Errorf("entry %d: got (%q, %t) instead of (%q, %t)", tc.index, location,
+				selfContained, tc.location, tc.selfContained)
+		}
+	}
+	_, _, err := dref.GetLocation(5)
+	assertError(t, err, "index beyond entries should give error")
+	_, _, err = dref.GetLocation(0)
+	assertError(t, err, "index 0 should give error")
+}
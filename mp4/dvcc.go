@@ -0,0 +1,120 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// DvcCBox - Dolby Vision configuration box (dvcC or dvvC) with a DOVIDecoderConfigurationRecord
+// Defined in Dolby Vision Streams Within the ISO Base Media File Format v2.1.
+// dvcC is used for profiles up to 7 and dvvC for profiles 8 to 10.
+type DvcCBox struct {
+	Name                    string
+	VersionMajor            byte
+	VersionMinor            byte
+	Profile                 byte
+	Level                   byte
+	RpuPresentFlag          byte
+	ElPresentFlag           byte
+	BlPresentFlag           byte
+	BlSignalCompatibilityID byte
+	reserved                []byte
+}
+
+// CreateDvcC - create Dolby Vision configuration box of type dvcC or dvvC depending on profile
+func CreateDvcC(profile, level, blSignalCompatibilityID byte, rpu, el, bl bool) *DvcCBox {
+	b := &DvcCBox{
+		Name:                    "dvcC",
+		VersionMajor:            1,
+		Profile:                 profile,
+		Level:                   level,
+		BlSignalCompatibilityID: blSignalCompatibilityID,
+	}
+	if profile > 7 {
+		b.Name = "dvvC"
+	}
+	b.RpuPresentFlag = boolToByte(rpu)
+	b.ElPresentFlag = boolToByte(el)
+	b.BlPresentFlag = boolToByte(bl)
+	return b
+}
+
+func boolToByte(v bool) byte {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// DecodeDvcC - box-specific decode of dvcC and dvvC
+func DecodeDvcC(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 24 {
+		return nil, fmt.Errorf("%s: too short %d bytes", hdr.name, len(data))
+	}
+	s := NewSliceReader(data)
+	b := &DvcCBox{Name: hdr.name}
+	b.VersionMajor = s.ReadUint8()
+	b.VersionMinor = s.ReadUint8()
+	flags := s.ReadUint16()
+	b.Profile = byte(flags >> 9)
+	b.Level = byte(flags>>3) & 0x3f
+	b.RpuPresentFlag = byte(flags>>2) & 1
+	b.ElPresentFlag = byte(flags>>1) & 1
+	b.BlPresentFlag = byte(flags) & 1
+	b.BlSignalCompatibilityID = s.ReadUint8() >> 4
+	b.reserved = s.RemainingBytes() // Kept to write back the same bytes
+	return b, nil
+}
+
+// Type - return box type
+func (b *DvcCBox) Type() string {
+	return b.Name
+}
+
+// Size - return calculated size
+func (b *DvcCBox) Size() uint64 {
+	if b.reserved != nil {
+		return uint64(boxHeaderSize + 5 + len(b.reserved))
+	}
+	return boxHeaderSize + 24
+}
+
+// Encode - write box to w
+func (b *DvcCBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint8(b.VersionMajor)
+	sw.WriteUint8(b.VersionMinor)
+	sw.WriteUint16(uint16(b.Profile&0x7f)<<9 | uint16(b.Level&0x3f)<<3 |
+		uint16(b.RpuPresentFlag&1)<<2 | uint16(b.ElPresentFlag&1)<<1 | uint16(b.BlPresentFlag&1))
+	sw.WriteUint8(b.BlSignalCompatibilityID << 4)
+	if b.reserved != nil {
+		sw.WriteBytes(b.reserved)
+	} else {
+		sw.WriteZeroBytes(19)
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - box-specific Info
+func (b *DvcCBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - DVVersion: %d.%d", b.VersionMajor, b.VersionMinor)
+	bd.write(" - DVProfile: %d", b.Profile)
+	bd.write(" - DVLevel: %d", b.Level)
+	bd.write(" - RPUPresent: %d", b.RpuPresentFlag)
+	bd.write(" - ELPresent: %d", b.ElPresentFlag)
+	bd.write(" - BLPresent: %d", b.BlPresentFlag)
+	bd.write(" - BLSignalCompatibilityID: %d", b.BlSignalCompatibilityID)
+	return bd.err
+}
@@ -0,0 +1,40 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDvcC(t *testing.T) {
+	dvcC := CreateDvcC(5, 6, 0, true, false, true)
+	boxDiffAfterEncodeAndDecode(t, dvcC)
+	dvvC := CreateDvcC(8, 9, 1, true, false, true)
+	if dvvC.Type() != "dvvC" {
+		t.Errorf("got type %s instead of dvvC for profile 8", dvvC.Type())
+	}
+	boxDiffAfterEncodeAndDecode(t, dvvC)
+
+	// Record for profile 8.1 level 9 from a real stream
+	data := []byte{0x00, 0x00, 0x00, 0x20, 'd', 'v', 'v', 'C', 0x01, 0x00, 0x10, 0x4d, 0x10,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	box, err := DecodeBox(0, bytes.NewReader(data))
+	assertNoError(t, err)
+	dec := box.(*DvcCBox)
+	if dec.Profile != 8 || dec.Level != 9 || dec.BlSignalCompatibilityID != 1 || dec.ElPresentFlag != 0 {
+		t.Errorf("bad decoded dvvC %+v", dec)
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, dec.Encode(&buf))
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("dvvC not byte-exact after encode")
+	}
+
+	dvh1 := CreateVisualSampleEntryBox("dvh1", 3840, 2160, dvvC)
+	stsd := NewStsdBox()
+	stsd.AddChild(dvh1)
+	decStsd := boxAfterEncodeAndDecode(t, stsd).(*StsdBox)
+	entry := decStsd.GetDolbyVision()
+	if entry == nil || entry.Type() != "dvh1" || entry.DvcC == nil || entry.DvcC.Profile != 8 {
+		t.Errorf("no dvh1 entry with dvvC after decode")
+	}
+}
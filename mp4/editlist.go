@@ -0,0 +1,150 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// AddEntry - add edit list entry. Version is set to 1 if the values do not fit in version 0.
+// segmentDuration is in movie timescale and mediaTime in media timescale, with -1 for an empty edit.
+func (b *ElstBox) AddEntry(segmentDuration uint64, mediaTime int64, mediaRateInteger, mediaRateFraction int16) {
+	b.SegmentDuration = append(b.SegmentDuration, segmentDuration)
+	b.MediaTime = append(b.MediaTime, mediaTime)
+	b.MediaRateInteger = append(b.MediaRateInteger, mediaRateInteger)
+	b.MediaRateFraction = append(b.MediaRateFraction, mediaRateFraction)
+	if segmentDuration > 0xffffffff || mediaTime > 0x7fffffff || mediaTime < -0x80000000 {
+		b.Version = 1
+	}
+}
+
+// MediaToPresentationTime - map composition time ct in media timescale to presentation time in media
+// timescale through the edit list. Segment durations are in movieTimescale.
+// Returns false if ct is not presented. A segment duration of 0 in the last entry means the rest of the media,
+// as used for fragmented files. Entries with media rate 0 (dwell) present their media time during the whole
+// segment, and ct is then mapped to the start of the segment.
+func (b *ElstBox) MediaToPresentationTime(ct int64, movieTimescale, mediaTimescale uint32) (int64, bool) {
+	var presStart int64 // Start of current entry in media timescale
+	for i, dur := range b.SegmentDuration {
+		mediaDur := int64(scaleTime(dur, movieTimescale, mediaTimescale))
+		mediaTime := b.MediaTime[i]
+		isLast := i == len(b.SegmentDuration)-1
+		switch {
+		case mediaTime == -1:
+		case b.MediaRateInteger[i] == 0:
+			if ct == mediaTime {
+				return presStart, true
+			}
+		case ct >= mediaTime && (ct < mediaTime+mediaDur || (dur == 0 && isLast)):
+			return presStart + ct - mediaTime, true
+		}
+		presStart += mediaDur
+	}
+	return 0, false
+}
+
+// PresentationToMediaTime - map presentation time pt in media timescale to composition time in
+// media timescale through the edit list. Segment durations are in movieTimescale.
+// Returns false if pt is in an empty edit or after the end of the edit list.
+func (b *ElstBox) PresentationToMediaTime(pt int64, movieTimescale, mediaTimescale uint32) (int64, bool) {
+	var presStart int64
+	for i, dur := range b.SegmentDuration {
+		mediaDur := int64(scaleTime(dur, movieTimescale, mediaTimescale))
+		isLast := i == len(b.SegmentDuration)-1
+		if pt >= presStart && (pt < presStart+mediaDur || (dur == 0 && isLast)) {
+			switch {
+			case b.MediaTime[i] == -1:
+				return 0, false
+			case b.MediaRateInteger[i] == 0:
+				return b.MediaTime[i], true
+			default:
+				return b.MediaTime[i] + pt - presStart, true
+			}
+		}
+		presStart += mediaDur
+	}
+	return 0, false
+}
+
+// MediaToPresentationTime - map composition time ct of trackID through its edit list.
+// Times are in media timescale. Without edit list, ct is returned unchanged.
+func (m *MoovBox) MediaToPresentationTime(trackID uint32, ct int64) (int64, bool, error) {
+	trak, elst, err := m.trakElst(trackID)
+	if err != nil {
+		return 0, false, err
+	}
+	if elst == nil {
+		return ct, true, nil
+	}
+	pt, ok := elst.MediaToPresentationTime(ct, m.Mvhd.Timescale, trak.Mdia.Mdhd.Timescale)
+	return pt, ok, nil
+}
+
+// PresentationToMediaTime - map presentation time pt of trackID through its edit list.
+// Times are in media timescale. Without edit list, pt is returned unchanged.
+func (m *MoovBox) PresentationToMediaTime(trackID uint32, pt int64) (int64, bool, error) {
+	trak, elst, err := m.trakElst(trackID)
+	if err != nil {
+		return 0, false, err
+	}
+	if elst == nil {
+		return pt, true, nil
+	}
+	ct, ok := elst.PresentationToMediaTime(pt, m.Mvhd.Timescale, trak.Mdia.Mdhd.Timescale)
+	return ct, ok, nil
+}
+
+// SetEditList - replace the edit list of trackID by an optional empty edit of length delay, followed by
+// an edit starting at mediaTime with length duration. All values are in media timescale, and delay and duration
+// are converted to the movie timescale. A duration of 0 means the rest of the media, as for fragmented files.
+// For example, mediaTime set to the number of priming samples of an AAC track removes the encoder delay,
+// and a delay gives a presentation offset.
+func (m *MoovBox) SetEditList(trackID uint32, delay uint64, mediaTime int64, duration uint64) error {
+	trak := m.GetTrak(trackID)
+	if trak == nil {
+		return fmt.Errorf("no trak with trackID=%d", trackID)
+	}
+	if m.Mvhd == nil || m.Mvhd.Timescale == 0 {
+		return fmt.Errorf("no mvhd timescale")
+	}
+	if mediaTime < 0 {
+		return fmt.Errorf("negative media time %d", mediaTime)
+	}
+	movieTimescale, mediaTimescale := m.Mvhd.Timescale, trak.Mdia.Mdhd.Timescale
+	elst := &ElstBox{}
+	if delay > 0 {
+		elst.AddEntry(scaleTime(delay, mediaTimescale, movieTimescale), -1, 1, 0)
+	}
+	elst.AddEntry(scaleTime(duration, mediaTimescale, movieTimescale), mediaTime, 1, 0)
+	edts := &EdtsBox{}
+	edts.AddChild(elst)
+	if trak.Edts != nil {
+		return ReplaceChild(trak, trak.Edts, edts)
+	}
+	// edts is placed after tkhd and any tref box
+	idx := 0
+	for i, c := range trak.Children {
+		if c.Type() == "tkhd" || c.Type() == "tref" {
+			idx = i + 1
+		}
+	}
+	return InsertChild(trak, idx, edts)
+}
+
+// SetEditList - set edit list of trackID as described for MoovBox.SetEditList
+func (s *InitSegment) SetEditList(trackID uint32, delay uint64, mediaTime int64, duration uint64) error {
+	return s.Moov.SetEditList(trackID, delay, mediaTime, duration)
+}
+
+// trakElst - trak and first elst box for trackID. elst is nil if there is no edit list
+func (m *MoovBox) trakElst(trackID uint32) (*TrakBox, *ElstBox, error) {
+	trak := m.GetTrak(trackID)
+	if trak == nil {
+		return nil, nil, fmt.Errorf("no trak with trackID=%d", trackID)
+	}
+	if trak.Edts == nil || len(trak.Edts.Elst) == 0 {
+		return trak, nil, nil
+	}
+	if m.Mvhd == nil || m.Mvhd.Timescale == 0 {
+		return nil, nil, fmt.Errorf("no mvhd timescale")
+	}
+	return trak, trak.Edts.Elst[0], nil
+}
@@ -34,6 +34,14 @@ func DecodeEdts(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	return e, nil
 }
 
+// AddChild - Add a child box
+func (b *EdtsBox) AddChild(box Box) {
+	if elst, ok := box.(*ElstBox); ok {
+		b.Elst = append(b.Elst, elst)
+	}
+	b.Children = append(b.Children, box)
+}
+
 // Type - box type
 func (b *EdtsBox) Type() string {
 	return "edts"
@@ -49,6 +57,15 @@ func (b *EdtsBox) GetChildren() []Box {
 	return b.Children
 }
 
+func (b *EdtsBox) setChildren(children []Box) error {
+	n := EdtsBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - write edts container to w
 func (b *EdtsBox) Encode(w io.Writer) error {
 	return EncodeContainer(b, w)
@@ -29,3 +29,65 @@ func TestElst(t *testing.T) {
 		boxDiffAfterEncodeAndDecode(t, elst)
 	}
 }
+
+func TestElstTimeMapping(t *testing.T) {
+	elst := &ElstBox{}
+	elst.AddEntry(1000, -1, 1, 0)   // 1s empty edit in movie timescale 1000
+	elst.AddEntry(2000, 2048, 1, 0) // 2s starting after 2048 priming samples
+	if elst.Version != 0 {
+		t.Errorf("version 0 should be enough")
+	}
+	testCases := []struct {
+		ct, pt    int64
+		presented bool
+	}{
+		{1000, 0, false},
+		{2048, 48000, true},
+		{2058, 48010, true},
+		{2048 + 96000, 0, false},
+	}
+	for _, tc := range testCases {
+		pt, ok := elst.MediaToPresentationTime(tc.ct, 1000, 48000)
+		if ok != tc.presented || pt != tc.pt {
+			t.Errorf("ct %d: got pt %d %t instead of %d %t", tc.ct, pt, ok, tc.pt, tc.presented)
+		}
+		if !tc.presented {
+			continue
+		}
+		ct, ok := elst.PresentationToMediaTime(tc.pt, 1000, 48000)
+		if !ok || ct != tc.ct {
+			t.Errorf("pt %d: got ct %d %t instead of %d", tc.pt, ct, ok, tc.ct)
+		}
+	}
+	if _, ok := elst.PresentationToMediaTime(100, 1000, 48000); ok {
+		t.Errorf("time in empty edit should not map to media")
+	}
+	elst.AddEntry(0, 1<<32, 1, 0)
+	if elst.Version != 1 {
+		t.Errorf("version should be 1 for large media time")
+	}
+	boxDiffAfterEncodeAndDecode(t, elst)
+}
+
+func TestSetEditList(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(48000, "audio", "und")
+	assertNoError(t, init.SetEditList(1, 0, 2048, 0))
+	assertNoError(t, init.SetEditList(1, 48000, 2048, 0))
+	assertError(t, init.SetEditList(2, 0, 2048, 0), "non-existing track should give error")
+	decInit := boxAfterEncodeAndDecode(t, init.Moov).(*MoovBox)
+	trak := decInit.Trak
+	if trak.Children[1] != trak.Edts || len(trak.Edts.Elst) != 1 || len(trak.Edts.Elst[0].MediaTime) != 2 {
+		t.Fatalf("edts not after tkhd with two edits")
+	}
+	offset, err := decInit.PresentationTimeOffset(1)
+	assertNoError(t, err)
+	if offset != 2048-48000 {
+		t.Errorf("got presentation time offset %d instead of %d", offset, 2048-48000)
+	}
+	pt, ok, err := decInit.MediaToPresentationTime(1, 2048+480000)
+	assertNoError(t, err)
+	if !ok || pt != 48000+480000 {
+		t.Errorf("got presentation time %d %t instead of %d", pt, ok, 48000+480000)
+	}
+}
@@ -17,6 +17,7 @@ type EmsgBox struct {
 	ID                    uint32
 	SchemeIDURI           string
 	Value                 string
+	MessageData           []byte
 }
 
 // DecodeEmsg - box-specific decode
@@ -62,6 +63,9 @@ func DecodeEmsg(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	} else {
 		return nil, fmt.Errorf("Unknown version for emsg")
 	}
+	if s.NrRemainingBytes() > 0 {
+		b.MessageData = s.RemainingBytes()
+	}
 	return b, nil
 }
 
@@ -73,9 +77,11 @@ func (b *EmsgBox) Type() string {
 // Size - calculated size of box
 func (b *EmsgBox) Size() uint64 {
 	if b.Version == 1 {
-		return uint64(boxHeaderSize + 4 + 4 + 8 + 4 + 4 + len(b.SchemeIDURI) + 1 + len(b.Value) + 1)
+		return uint64(boxHeaderSize + 4 + 4 + 8 + 4 + 4 + len(b.SchemeIDURI) + 1 + len(b.Value) + 1 +
+			len(b.MessageData))
 	}
-	return uint64(boxHeaderSize + 4 + len(b.SchemeIDURI) + 1 + len(b.Value) + 1 + 4 + 4 + 4 + 4) // m.Version == 0
+	return uint64(boxHeaderSize + 4 + len(b.SchemeIDURI) + 1 + len(b.Value) + 1 + 4 + 4 + 4 + 4 +
+		len(b.MessageData)) // m.Version == 0
 }
 
 // Encode - write box to w
@@ -103,12 +109,13 @@ func (b *EmsgBox) Encode(w io.Writer) error {
 		sw.WriteUint32(b.EventDuration)
 		sw.WriteUint32(b.ID)
 	}
+	sw.WriteBytes(b.MessageData)
 
 	_, err = w.Write(buf)
 	return err
 }
 
-// Info - write box-specific information
+// Info - write box-specific information. Level 1 shows SCTE-35 splice info
 func (b *EmsgBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
 	bd.write(" - timeScale: %d", b.TimeScale)
@@ -122,5 +129,20 @@ func (b *EmsgBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	if b.Version == 0 {
 		bd.write(" - presentationTimeDelta: %d", b.PresentationTimeDelta)
 	}
+	if len(b.MessageData) > 0 {
+		bd.write(" - messageData: %d bytes", len(b.MessageData))
+	}
+	level := getInfoLevel(b, specificBoxLevels)
+	if level >= 1 && b.SchemeIDURI == SCTE35SchemeIDURI {
+		s, err := b.SpliceInfo()
+		if err != nil {
+			bd.write(" - scte35: %s", err)
+			return bd.err
+		}
+		bd.write(" - scte35: %s", s)
+		for _, d := range s.Descriptors {
+			bd.write("   - descriptor %s", d)
+		}
+	}
 	return bd.err
 }
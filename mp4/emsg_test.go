@@ -14,6 +14,7 @@ func TestEmsg(t *testing.T) {
 			ID:               42,
 			SchemeIDURI:      "https://aomedia.org/emsg/ID3",
 			Value:            "relative",
+			MessageData:      []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 0},
 		},
 		&EmsgBox{Version: 0,
 			TimeScale:             90000,
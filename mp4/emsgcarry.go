@@ -0,0 +1,117 @@
+package mp4
+
+import (
+	"sort"
+)
+
+// EmsgCarrier - carry emsg boxes from input fragments to the output fragments when re-chunking.
+// Events are deduplicated by scheme_id_uri, value, and id as specified in ISO/IEC 23009-1 Sec. 5.10.3.3,
+// so that events repeated in several input fragments are only output once.
+// Each event is output in the first output fragment that ends after its presentation time.
+// Version 0 boxes get a presentation_time_delta relative to the new earliest presentation time,
+// and are changed to version 1 if the event starts before the output fragment.
+type EmsgCarrier struct {
+	timescale uint32 // Track timescale used for fragment times
+	pending   []carriedEmsg
+	seen      map[emsgKey]bool
+}
+
+type emsgKey struct {
+	schemeIDURI string
+	value       string
+	id          uint32
+}
+
+// carriedEmsg - event with absolute presentation time in emsg timescale
+type carriedEmsg struct {
+	emsg     *EmsgBox
+	presTime uint64
+}
+
+// NewEmsgCarrier - create carrier for fragments with times in track timescale
+func NewEmsgCarrier(timescale uint32) *EmsgCarrier {
+	return &EmsgCarrier{
+		timescale: timescale,
+		seen:      make(map[emsgKey]bool),
+	}
+}
+
+// Add - add emsg from an input fragment with earliest presentation time ept in track timescale.
+// Returns false if the event is a duplicate of an already added event.
+func (c *EmsgCarrier) Add(emsg *EmsgBox, ept uint64) bool {
+	key := emsgKey{emsg.SchemeIDURI, emsg.Value, emsg.ID}
+	if c.seen[key] {
+		return false
+	}
+	c.seen[key] = true
+	presTime := emsg.PresentationTime
+	if emsg.Version == 0 {
+		presTime = scaleTime(ept, c.timescale, emsg.TimeScale) + uint64(emsg.PresentationTimeDelta)
+	}
+	c.pending = append(c.pending, carriedEmsg{emsg, presTime})
+	return true
+}
+
+// AddFromFragment - add all emsg boxes of an input fragment with earliest presentation time ept.
+// Returns the number of non-duplicate events.
+func (c *EmsgCarrier) AddFromFragment(frag *Fragment, ept uint64) int {
+	nrAdded := 0
+	for _, emsg := range frag.Emsgs {
+		if c.Add(emsg, ept) {
+			nrAdded++
+		}
+	}
+	return nrAdded
+}
+
+// Emit - add pending events with presentation time before ept + dur to frag, in presentation time order.
+// ept and dur are given in track timescale. Returns the number of emitted events.
+func (c *EmsgCarrier) Emit(frag *Fragment, ept, dur uint64) int {
+	sort.SliceStable(c.pending, func(i, j int) bool {
+		return c.presTimeInTrack(c.pending[i]) < c.presTimeInTrack(c.pending[j])
+	})
+	nrEmitted := 0
+	for _, e := range c.pending {
+		if c.presTimeInTrack(e) >= ept+dur {
+			break
+		}
+		frag.AddEmsg(retimeEmsg(e, scaleTime(ept, c.timescale, e.emsg.TimeScale)))
+		nrEmitted++
+	}
+	c.pending = c.pending[nrEmitted:]
+	return nrEmitted
+}
+
+// Pending - number of events not yet emitted
+func (c *EmsgCarrier) Pending() int {
+	return len(c.pending)
+}
+
+func (c *EmsgCarrier) presTimeInTrack(e carriedEmsg) uint64 {
+	return scaleTime(e.presTime, e.emsg.TimeScale, c.timescale)
+}
+
+// retimeEmsg - copy of emsg with time relative to ept, both in emsg timescale
+func retimeEmsg(e carriedEmsg, ept uint64) *EmsgBox {
+	emsg := *e.emsg
+	if emsg.Version == 0 {
+		if e.presTime < ept || e.presTime-ept > 0xffffffff {
+			emsg.Version = 1
+			emsg.PresentationTimeDelta = 0
+			emsg.PresentationTime = e.presTime
+		} else {
+			emsg.PresentationTimeDelta = uint32(e.presTime - ept)
+		}
+	}
+	return &emsg
+}
+
+// scaleTime - convert t from timescale from to timescale to, rounding down.
+// Quotient and remainder are scaled separately, so that t*to does not need to fit in 64 bits.
+func scaleTime(t uint64, from, to uint32) uint64 {
+	if from == to || from == 0 {
+		return t
+	}
+	q, r := t/uint64(from), t%uint64(from)
+	return q*uint64(to) + r*uint64(to)/uint64(from)
+}
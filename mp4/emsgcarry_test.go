@@ -0,0 +1,87 @@
+package mp4
+
+import (
+	"testing"
+)
+
+func TestEmsgCarrier(t *testing.T) {
+	ev1 := &EmsgBox{TimeScale: 1000, PresentationTimeDelta: 500, EventDuration: 100, ID: 1, SchemeIDURI: "urn:test", Value: "1"}
+	ev1Repeated := &EmsgBox{TimeScale: 1000, PresentationTimeDelta: 0, EventDuration: 100, ID: 1, SchemeIDURI: "urn:test", Value: "1"}
+	ev2 := &EmsgBox{TimeScale: 1000, PresentationTimeDelta: 250, ID: 2, SchemeIDURI: "urn:test", Value: "1"}
+	ev3 := &EmsgBox{Version: 1, TimeScale: 1000, PresentationTime: 3500, ID: 3, SchemeIDURI: "urn:test", Value: "1"}
+
+	in1, _ := CreateFragment(1, 1)
+	in1.AddEmsg(ev1)
+	in2, _ := CreateFragment(2, 1)
+	in2.AddEmsg(ev1Repeated)
+	in2.AddEmsg(ev2)
+	in2.AddEmsg(ev3)
+	if in2.Children[3].Type() != "moof" {
+		t.Errorf("emsg boxes not before moof")
+	}
+
+	c := NewEmsgCarrier(90000)
+	if n := c.AddFromFragment(in1, 0); n != 1 {
+		t.Errorf("got %d events added instead of 1", n)
+	}
+	if n := c.AddFromFragment(in2, 180000); n != 2 {
+		t.Errorf("got %d events added instead of 2 (duplicate not removed)", n)
+	}
+
+	wantedIDs := [][]uint32{{1}, nil, {2}, {3}}
+	var outFrags []*Fragment
+	for i := range wantedIDs {
+		out, _ := CreateFragment(uint32(i+1), 1)
+		c.Emit(out, uint64(i)*90000, 90000)
+		outFrags = append(outFrags, out)
+		if len(out.Emsgs) != len(wantedIDs[i]) {
+			t.Fatalf("fragment %d: got %d emsg instead of %d", i, len(out.Emsgs), len(wantedIDs[i]))
+		}
+		for j, emsg := range out.Emsgs {
+			if emsg.ID != wantedIDs[i][j] {
+				t.Errorf("fragment %d: got emsg id %d instead of %d", i, emsg.ID, wantedIDs[i][j])
+			}
+		}
+	}
+	if c.Pending() != 0 {
+		t.Errorf("%d events still pending", c.Pending())
+	}
+	if d := outFrags[0].Emsgs[0].PresentationTimeDelta; d != 500 {
+		t.Errorf("event 1: got delta %d instead of 500", d)
+	}
+	if d := outFrags[2].Emsgs[0].PresentationTimeDelta; d != 250 {
+		t.Errorf("event 2: got delta %d instead of 250", d)
+	}
+	if e := outFrags[3].Emsgs[0]; e.Version != 1 || e.PresentationTime != 3500 {
+		t.Errorf("event 3: got version %d and time %d instead of 1 and 3500", e.Version, e.PresentationTime)
+	}
+
+	// Late event is converted to version 1 with absolute time
+	late := &EmsgBox{TimeScale: 1000, PresentationTimeDelta: 100, ID: 4, SchemeIDURI: "urn:test"}
+	c.Add(late, 0)
+	out, _ := CreateFragment(5, 1)
+	c.Emit(out, 360000, 90000)
+	if len(out.Emsgs) != 1 || out.Emsgs[0].Version != 1 || out.Emsgs[0].PresentationTime != 100 {
+		t.Errorf("late event not converted to version 1")
+	}
+	if late.Version != 0 {
+		t.Errorf("input emsg changed")
+	}
+}
+
+func TestScaleTimeLargeValues(t *testing.T) {
+	testCases := []struct {
+		t        uint64
+		from, to uint32
+		wanted   uint64
+	}{
+		{90000 * 3600 * 24 * 365 * 100, 90000, 10000000, 10000000 * 3600 * 24 * 365 * 100},
+		{1<<62 + 1, 1 << 30, 1 << 31, 1<<63 + 2},
+		{1001, 1000, 999, 999},
+	}
+	for _, tc := range testCases {
+		if got := scaleTime(tc.t, tc.from, tc.to); got != tc.wanted {
+			t.Errorf("scaleTime(%d, %d, %d) = %d instead of %d", tc.t, tc.from, tc.to, got, tc.wanted)
+		}
+	}
+}
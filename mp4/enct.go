@@ -0,0 +1,102 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// DecodeEnct - decode encrypted text sample entry (enct) with the decoder registered for its original format.
+// The original format is given by the frma box of the sinf box. Entries without frma, or with an original
+// format without registered decoder, such as tx3g, are kept as UnknownBox.
+func DecodeEnct(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if start, end := findSinf(data); start >= 0 {
+		sinf, err := DecodeBox(0, bytes.NewReader(data[start:end]))
+		if err == nil && sinf.(*SinfBox).Frma != nil {
+			if decoder, ok := protectedTextFormats[sinf.(*SinfBox).Frma.DataFormat]; ok {
+				entry, err := decoder(hdr, startPos, bytes.NewReader(data))
+				if err == nil {
+					return entry, nil
+				}
+			}
+		}
+	}
+	return DecodeUnknown(hdr, startPos, bytes.NewReader(data))
+}
+
+// ProtectTextSampleEntry - change the first wvtt or stpp sample entry in stsd to enct.
+// A sinf box with frma, schm for scheme, and schi with tenc is added to the entry.
+func (s *StsdBox) ProtectTextSampleEntry(scheme string, tenc *TencBox) error {
+	if scheme != SchemeCENC && scheme != SchemeCBCS {
+		return fmt.Errorf("protection scheme %q not supported", scheme)
+	}
+	for _, c := range s.Children {
+		switch e := c.(type) {
+		case *WvttBox:
+			if e.Sinf == nil {
+				e.AddChild(createSinf(e.Type(), scheme, tenc))
+				e.name = "enct"
+				return nil
+			}
+		case *StppBox:
+			if e.Sinf == nil {
+				e.AddChild(createSinf(e.Type(), scheme, tenc))
+				e.name = "enct"
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("no unprotected wvtt or stpp sample entry")
+}
+
+// createSinf - create sinf box with frma, schm, and schi containing tenc
+func createSinf(originalFormat, scheme string, tenc *TencBox) *SinfBox {
+	sinf := &SinfBox{}
+	sinf.AddChild(&FrmaBox{DataFormat: originalFormat})
+	sinf.AddChild(&SchmBox{SchemeType: scheme, SchemeVersion: 0x00010000})
+	schi := &SchiBox{}
+	schi.AddChild(tenc)
+	sinf.AddChild(schi)
+	return sinf
+}
+
+// UnprotectTextSampleEntry - change the first enct sample entry in stsd back to its original format
+// and remove its sinf box. The removed sinf box is returned.
+func (s *StsdBox) UnprotectTextSampleEntry() (*SinfBox, error) {
+	for _, c := range s.Children {
+		if c.Type() == "enct" {
+			return RestoreOriginalFormat(c)
+		}
+	}
+	return nil, fmt.Errorf("no enct sample entry")
+}
+
+// GetTextTenc - get tenc box of the first enct sample entry. Returns nil if not found.
+func (s *StsdBox) GetTextTenc() *TencBox {
+	for _, c := range s.Children {
+		var sinf *SinfBox
+		switch e := c.(type) {
+		case *WvttBox:
+			sinf = e.Sinf
+		case *StppBox:
+			sinf = e.Sinf
+		}
+		if sinf != nil && sinf.Schi != nil {
+			return sinf.Schi.Tenc
+		}
+	}
+	return nil
+}
+
+func removeBox(boxes []Box, box Box) []Box {
+	idx := childIndex(boxes, box)
+	if idx < 0 {
+		return boxes
+	}
+	return append(boxes[:idx:idx], boxes[idx+1:]...)
+}
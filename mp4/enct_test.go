@@ -0,0 +1,174 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestProtectTextSampleEntry(t *testing.T) {
+	for _, format := range []string{"wvtt", "stpp"} {
+		init := CreateEmptyInit()
+		init.AddEmptyTrack(1000, "text", "en")
+		trak := init.Moov.Trak
+		if format == "wvtt" {
+			assertNoError(t, trak.SetWvttDescriptor(""))
+		} else {
+			assertNoError(t, trak.SetStppDescriptor("", "", ""))
+		}
+		clearBuf := bytes.Buffer{}
+		assertNoError(t, init.Encode(&clearBuf))
+
+		stsd := trak.Mdia.Minf.Stbl.Stsd
+		tenc := &TencBox{DefaultIsProtected: 1, DefaultPerSampleIVSize: 8, DefaultKID: UUID(make([]byte, 16))}
+		assertNoError(t, stsd.ProtectTextSampleEntry(SchemeCENC, tenc))
+		buf := bytes.Buffer{}
+		assertNoError(t, init.Encode(&buf))
+		decFile, err := DecodeFile(&buf)
+		assertNoError(t, err)
+		decStsd := decFile.Init.Moov.Trak.Mdia.Minf.Stbl.Stsd
+		entry := decStsd.Children[0]
+		if entry.Type() != "enct" {
+			t.Errorf("%s: got sample entry type %s instead of enct", format, entry.Type())
+		}
+		var origFormat string
+		switch e := entry.(type) {
+		case *WvttBox:
+			origFormat = e.OriginalFormat()
+		case *StppBox:
+			origFormat = e.OriginalFormat()
+		}
+		if origFormat != format {
+			t.Errorf("got original format %q instead of %q", origFormat, format)
+		}
+		if decStsd.GetTextTenc() == nil {
+			t.Errorf("%s: no tenc found", format)
+		}
+
+		_, err = decStsd.UnprotectTextSampleEntry()
+		assertNoError(t, err)
+		buf.Reset()
+		assertNoError(t, decFile.Init.Encode(&buf))
+		if !bytes.Equal(buf.Bytes(), clearBuf.Bytes()) {
+			t.Errorf("%s: init segment differs after unprotect", format)
+		}
+	}
+}
+
+func TestDecodeEnct(t *testing.T) {
+	tenc := &TencBox{DefaultIsProtected: 1, DefaultPerSampleIVSize: 8, DefaultKID: UUID(make([]byte, 16))}
+	// The strings contain "frma" followed by another format, which must not be taken as the frma box
+	stpp := NewStppBox(TTMLNamespace, "http://example.com/frmawvtt", "")
+	stpp.AddChild(createSinf("stpp", SchemeCENC, tenc))
+	stpp.name = "enct"
+	wvtt := NewWvttBox()
+	wvtt.AddChild(&VttCBox{Config: "WEBVTT frmastpp"})
+	wvtt.AddChild(createSinf("wvtt", SchemeCENC, tenc))
+	wvtt.name = "enct"
+	for _, entry := range []Box{stpp, wvtt} {
+		buf := bytes.Buffer{}
+		assertNoError(t, entry.Encode(&buf))
+		decoded, err := DecodeBox(0, &buf)
+		assertNoError(t, err)
+		if diff := deep.Equal(decoded, entry); diff != nil {
+			t.Errorf("%T: %v", entry, diff)
+		}
+	}
+
+	noSinf := NewStppBox(TTMLNamespace, "http://example.com/frmastpp", "")
+	noSinf.name = "enct"
+	buf := bytes.Buffer{}
+	assertNoError(t, noSinf.Encode(&buf))
+	box, err := DecodeBox(0, &buf)
+	assertNoError(t, err)
+	if _, ok := box.(*UnknownBox); !ok {
+		t.Errorf("enct without sinf decoded as %T instead of UnknownBox", box)
+	}
+
+	// Encrypted tx3g has no registered decoder, but is kept and can be restored
+	tx3g := CreateTx3g("Serif", 18)
+	tx3g.AddChild(createSinf("tx3g", SchemeCENC, tenc))
+	buf.Reset()
+	assertNoError(t, tx3g.Encode(&buf))
+	data := buf.Bytes()
+	copy(data[4:8], "enct")
+	box, err = DecodeBox(0, bytes.NewReader(data))
+	assertNoError(t, err)
+	out := bytes.Buffer{}
+	assertNoError(t, box.Encode(&out))
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("encrypted tx3g changed after decode and encode")
+	}
+	sinf, err := RestoreOriginalFormat(box)
+	assertNoError(t, err)
+	if sinf.Frma.DataFormat != "tx3g" || box.Type() != "tx3g" {
+		t.Errorf("encrypted tx3g not restored")
+	}
+}
+
+func TestEncryptDecryptTextSamples(t *testing.T) {
+	key, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	iv, _ := hex.DecodeString("0102030405060708")
+	tenc := &TencBox{DefaultIsProtected: 1, DefaultPerSampleIVSize: 8, DefaultKID: UUID(make([]byte, 16))}
+	trex := &TrexBox{TrackID: 1}
+	texts := []string{"<tt>first</tt>", "<tt>second sample</tt>", "<tt>third</tt>"}
+
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	for i, text := range texts {
+		data := []byte(text)
+		frag.AddFullSample(FullSample{Sample: NewSample(SyncSampleFlags, 1000, uint32(len(data)), 0),
+			DecodeTime: uint64(i) * 1000, Data: data})
+	}
+	frag = fragmentAfterEncodeAndDecode(t, frag)
+	nextIV, err := frag.EncryptSamples(trex, SchemeCENC, key, tenc, iv)
+	assertNoError(t, err)
+	if hex.EncodeToString(nextIV) != "010203040506070b" {
+		t.Errorf("got next IV %x", nextIV)
+	}
+
+	buf := bytes.Buffer{}
+	assertNoError(t, frag.Encode(&buf))
+	raw := buf.Bytes()
+	frag = fragmentAfterEncodeAndDecode(t, frag)
+	traf := frag.Moof.Traf
+	if traf.Senc == nil || len(traf.Senc.IVs) != len(texts) {
+		t.Fatalf("senc with %d IVs missing", len(texts))
+	}
+	_, saio := traf.GetSaizSaio("")
+	ivPos := int(frag.Moof.StartPos) + int(saio.Offset[0])
+	if !bytes.Equal(raw[ivPos:ivPos+8], iv) {
+		t.Errorf("saio offset does not point to first IV")
+	}
+	samples, err := frag.GetFullSamples(trex)
+	assertNoError(t, err)
+	for i, s := range samples {
+		if string(s.Data) == texts[i] {
+			t.Errorf("sample %d not encrypted", i+1)
+		}
+	}
+
+	assertNoError(t, frag.DecryptSamples(trex, SchemeCENC, key, tenc))
+	if traf.Senc != nil || len(traf.Saizs) != 0 || len(traf.Saios) != 0 {
+		t.Errorf("senc, saiz, or saio not removed")
+	}
+	frag = fragmentAfterEncodeAndDecode(t, frag)
+	samples, err = frag.GetFullSamples(trex)
+	assertNoError(t, err)
+	for i, s := range samples {
+		if string(s.Data) != texts[i] {
+			t.Errorf("sample %d: got %q instead of %q", i+1, s.Data, texts[i])
+		}
+	}
+}
+
+func fragmentAfterEncodeAndDecode(t *testing.T, frag *Fragment) *Fragment {
+	t.Helper()
+	buf := bytes.Buffer{}
+	assertNoError(t, frag.Encode(&buf))
+	f, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	return f.Segments[0].Fragments[0]
+}
@@ -0,0 +1,76 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// PresentationTimeOffset - offset in media timescale to subtract from composition times of trackID
+// to get presentation times according to the edit list.
+// An initial empty edit (media time -1) delays the presentation and gives a negative offset,
+// while the media time of the first non-empty edit (e.g. to skip encoder delay) gives a positive offset.
+// The offset is 0 if there is no edit list.
+func (m *MoovBox) PresentationTimeOffset(trackID uint32) (int64, error) {
+	trak := m.GetTrak(trackID)
+	if trak == nil {
+		return 0, fmt.Errorf("no trak with trackID=%d", trackID)
+	}
+	if trak.Edts == nil || len(trak.Edts.Elst) == 0 {
+		return 0, nil
+	}
+	elst := trak.Edts.Elst[0]
+	offset := int64(0)
+	for i, mediaTime := range elst.MediaTime {
+		if mediaTime == -1 { // Empty edit
+			if m.Mvhd == nil || m.Mvhd.Timescale == 0 {
+				return 0, fmt.Errorf("no mvhd timescale for empty edit")
+			}
+			delay := elst.SegmentDuration[i] * uint64(trak.Mdia.Mdhd.Timescale) / uint64(m.Mvhd.Timescale)
+			offset -= int64(delay)
+			continue
+		}
+		return offset + mediaTime, nil
+	}
+	return offset, nil
+}
+
+// EarliestPresentationTime - earliest presentation time of the samples of trackID in the fragment.
+// It is the minimum of decode time plus composition time offset over all samples, minus
+// presTimeOffset as given by MoovBox.PresentationTimeOffset. This is the value to use as
+// earliest_presentation_time in sidx. Negative values are clipped to 0.
+// trex provides default values and may be nil if all sample values are in tfhd and trun.
+func (f *Fragment) EarliestPresentationTime(trackID uint32, trex *TrexBox, presTimeOffset int64) (uint64, error) {
+	var traf *TrafBox
+	for _, tr := range f.Moof.Trafs {
+		if tr.Tfhd.TrackID == trackID {
+			traf = tr
+			break
+		}
+	}
+	if traf == nil {
+		return 0, fmt.Errorf("no traf with trackID=%d", trackID)
+	}
+	if traf.Tfdt == nil {
+		return 0, fmt.Errorf("no tfdt for trackID=%d", trackID)
+	}
+	decTime := traf.Tfdt.BaseMediaDecodeTime
+	var ept int64
+	found := false
+	for _, trun := range traf.Truns {
+		trun.AddSampleDefaultValues(traf.Tfhd, trex)
+		for _, s := range trun.Samples {
+			presTime := int64(decTime) + int64(s.CompositionTimeOffset) - presTimeOffset
+			if !found || presTime < ept {
+				ept = presTime
+				found = true
+			}
+			decTime += uint64(s.Dur)
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("no samples for trackID=%d", trackID)
+	}
+	if ept < 0 {
+		ept = 0
+	}
+	return uint64(ept), nil
+}
@@ -0,0 +1,70 @@
+package mp4
+
+import (
+	"testing"
+)
+
+func TestEarliestPresentationTime(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(48000, "audio", "und")
+	moov := init.Moov
+	moov.Mvhd.Timescale = 1000
+	trak := moov.Trak
+
+	offset, err := moov.PresentationTimeOffset(1)
+	assertNoError(t, err)
+	if offset != 0 {
+		t.Errorf("got offset %d without edit list", offset)
+	}
+	_, err = moov.PresentationTimeOffset(2)
+	assertError(t, err, "unknown track should give error")
+
+	// Encoder delay of 2112 samples skipped by edit list
+	elst := &ElstBox{SegmentDuration: []uint64{10000}, MediaTime: []int64{2112},
+		MediaRateInteger: []int16{1}, MediaRateFraction: []int16{0}}
+	trak.AddChild(&EdtsBox{Elst: []*ElstBox{elst}, Children: []Box{elst}})
+	offset, err = moov.PresentationTimeOffset(1)
+	assertNoError(t, err)
+	if offset != 2112 {
+		t.Errorf("got offset %d instead of 2112", offset)
+	}
+
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	for i := 0; i < 4; i++ {
+		frag.AddFullSample(FullSample{Sample: NewSample(SyncSampleFlags, 1024, 10, 0),
+			DecodeTime: uint64(48000 + 1024*i), Data: make([]byte, 10)})
+	}
+	ept, err := frag.EarliestPresentationTime(1, nil, offset)
+	assertNoError(t, err)
+	if ept != 48000-2112 {
+		t.Errorf("got ept %d instead of %d", ept, 48000-2112)
+	}
+
+	// Initial empty edit of 100ms delays the presentation
+	elst.SegmentDuration = []uint64{100, 10000}
+	elst.MediaTime = []int64{-1, 0}
+	elst.MediaRateInteger = []int16{1, 1}
+	elst.MediaRateFraction = []int16{0, 0}
+	offset, err = moov.PresentationTimeOffset(1)
+	assertNoError(t, err)
+	if offset != -4800 {
+		t.Errorf("got offset %d instead of -4800", offset)
+	}
+
+	// Composition time offsets (B-frames) move the earliest presentation time
+	vFrag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	ctos := []int32{1000, 3000, 0, 1000}
+	for i, cto := range ctos {
+		vFrag.AddFullSample(FullSample{Sample: NewSample(NonSyncSampleFlags, 1000, 10, cto),
+			DecodeTime: uint64(1000 * i), Data: make([]byte, 10)})
+	}
+	ept, err = vFrag.EarliestPresentationTime(1, nil, 1000)
+	assertNoError(t, err)
+	if ept != 0 {
+		t.Errorf("got ept %d instead of 0", ept)
+	}
+	_, err = vFrag.EarliestPresentationTime(2, nil, 0)
+	assertError(t, err, "unknown track should give error")
+}
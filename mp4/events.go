@@ -0,0 +1,137 @@
+package mp4
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// Event - DASH event from an emsg box with absolute presentation time
+type Event struct {
+	SchemeIDURI string
+	Value       string
+	ID          uint32
+	Timescale   uint32
+	Start       uint64 // Presentation time in Timescale
+	Duration    uint32 // In Timescale. 0xffffffff means unknown duration
+	MessageData []byte
+}
+
+// EventStream - events with the same scheme_id_uri and value, as in an MPD EventStream element.
+// All event times are in Timescale.
+type EventStream struct {
+	SchemeIDURI string
+	Value       string
+	Timescale   uint32
+	Events      []Event
+}
+
+// GetEvents - get the events of all emsg boxes in a fragmented file, ordered by start time.
+// The presentation time of a version 0 emsg box is relative to the earliest presentation time of
+// its media segment, which is calculated from the track of the first traf in the segment.
+// For a file without styp boxes, decode with WithSegmentPerFragment so that each fragment is a media segment.
+// Events repeated in several segments are only included once, as identified by scheme_id_uri,
+// value, and id.
+func (f *File) GetEvents() ([]Event, error) {
+	if !f.isFragmented {
+		return nil, fmt.Errorf("file is not fragmented")
+	}
+	var events []Event
+	seen := make(map[emsgKey]bool)
+	for i, seg := range f.Segments {
+		var segEPT uint64
+		var trackTimescale uint32
+		eptFound := false
+		for _, frag := range seg.Fragments {
+			for _, emsg := range frag.Emsgs {
+				key := emsgKey{emsg.SchemeIDURI, emsg.Value, emsg.ID}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				start := emsg.PresentationTime
+				if emsg.Version == 0 {
+					if !eptFound {
+						var err error
+						segEPT, trackTimescale, err = f.segmentEPT(seg)
+						if err != nil {
+							return nil, fmt.Errorf("segment %d: %w", i, err)
+						}
+						eptFound = true
+					}
+					start = scaleTime(segEPT, trackTimescale, emsg.TimeScale) + uint64(emsg.PresentationTimeDelta)
+				}
+				events = append(events, Event{
+					SchemeIDURI: emsg.SchemeIDURI,
+					Value:       emsg.Value,
+					ID:          emsg.ID,
+					Timescale:   emsg.TimeScale,
+					Start:       start,
+					Duration:    emsg.EventDuration,
+					MessageData: emsg.MessageData,
+				})
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].before(events[j])
+	})
+	return events, nil
+}
+
+// GetEventStreams - group events by scheme_id_uri and value in order of first appearance.
+// Event times are converted to the timescale of the first event in each stream.
+func GetEventStreams(events []Event) []EventStream {
+	var streams []EventStream
+	streamIdx := make(map[[2]string]int)
+	for _, e := range events {
+		key := [2]string{e.SchemeIDURI, e.Value}
+		idx, ok := streamIdx[key]
+		if !ok {
+			idx = len(streams)
+			streamIdx[key] = idx
+			streams = append(streams, EventStream{SchemeIDURI: e.SchemeIDURI, Value: e.Value, Timescale: e.Timescale})
+		}
+		s := &streams[idx]
+		if e.Timescale != s.Timescale {
+			e.Start = scaleTime(e.Start, e.Timescale, s.Timescale)
+			if e.Duration != 0xffffffff {
+				e.Duration = uint32(scaleTime(uint64(e.Duration), e.Timescale, s.Timescale))
+			}
+			e.Timescale = s.Timescale
+		}
+		s.Events = append(s.Events, e)
+	}
+	return streams
+}
+
+// before - true if e starts before o, comparing times in different timescales with 128-bit products
+func (e Event) before(o Event) bool {
+	eHi, eLo := bits.Mul64(e.Start, uint64(o.Timescale))
+	oHi, oLo := bits.Mul64(o.Start, uint64(e.Timescale))
+	return eHi < oHi || (eHi == oHi && eLo < oLo)
+}
+
+// segmentEPT - earliest presentation time and timescale of the track of the first traf in seg
+func (f *File) segmentEPT(seg *MediaSegment) (uint64, uint32, error) {
+	if len(seg.Fragments) == 0 || seg.Fragments[0].Moof == nil || len(seg.Fragments[0].Moof.Trafs) == 0 {
+		return 0, 0, fmt.Errorf("no traf for earliest presentation time")
+	}
+	if f.Moov == nil {
+		return 0, 0, fmt.Errorf("no moov box")
+	}
+	trackID := seg.Fragments[0].Moof.Trafs[0].Tfhd.TrackID
+	trak := f.Moov.GetTrak(trackID)
+	if trak == nil {
+		return 0, 0, fmt.Errorf("no trak with trackID=%d", trackID)
+	}
+	presTimeOffset, err := f.Moov.PresentationTimeOffset(trackID)
+	if err != nil {
+		return 0, 0, err
+	}
+	ept, err := seg.Fragments[0].EarliestPresentationTime(trackID, f.TrexForTrack(trackID), presTimeOffset)
+	if err != nil {
+		return 0, 0, err
+	}
+	return ept, trak.Mdia.Mdhd.Timescale, nil
+}
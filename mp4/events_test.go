@@ -0,0 +1,105 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGetEvents(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(60, 3600, 500, 25, 0x20) // Segments at 0s, 1s, 2s
+	f, err := CreateOnDemandFile(init, NewSliceSampleSource(samples), 90000)
+	assertNoError(t, err)
+	frags := []*Fragment{f.Segments[0].Fragments[0], f.Segments[1].Fragments[0], f.Segments[2].Fragments[0]}
+	// Event 1 is repeated in the first two segments, and event 3 is signaled before event 2
+	frags[0].AddEmsg(&EmsgBox{Version: 1, TimeScale: 1000, PresentationTime: 1500, EventDuration: 200, ID: 1,
+		SchemeIDURI: "urn:scte:scte35:2013:bin", MessageData: []byte{1}})
+	frags[0].AddEmsg(&EmsgBox{TimeScale: 1000, PresentationTimeDelta: 2500, EventDuration: 100, ID: 3,
+		SchemeIDURI: "urn:scte:scte35:2013:bin"})
+	frags[1].AddEmsg(&EmsgBox{Version: 1, TimeScale: 1000, PresentationTime: 1500, EventDuration: 200, ID: 1,
+		SchemeIDURI: "urn:scte:scte35:2013:bin", MessageData: []byte{1}})
+	frags[1].AddEmsg(&EmsgBox{TimeScale: 90000, PresentationTimeDelta: 90000, ID: 2, SchemeIDURI: ID3EmsgSchemeIDURI})
+	f.FragEncMode = EncModeSegment
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithSegmentPerFragment())
+	assertNoError(t, err)
+
+	events, err := decFile.GetEvents()
+	assertNoError(t, err)
+	wanted := []struct {
+		id    uint32
+		start uint64
+	}{{1, 1500}, {2, 180000}, {3, 2500}}
+	if len(events) != len(wanted) {
+		t.Fatalf("got %d events instead of %d", len(events), len(wanted))
+	}
+	for i, w := range wanted {
+		if events[i].ID != w.id || events[i].Start != w.start {
+			t.Errorf("event %d: got id %d start %d instead of id %d start %d", i, events[i].ID, events[i].Start, w.id, w.start)
+		}
+	}
+	if !bytes.Equal(events[0].MessageData, []byte{1}) {
+		t.Errorf("message data not kept")
+	}
+
+	streams := GetEventStreams(events)
+	if len(streams) != 2 || len(streams[0].Events) != 2 || streams[0].Timescale != 1000 {
+		t.Fatalf("wrong event streams %+v", streams)
+	}
+	if e := streams[0].Events[1]; e.ID != 3 || e.Start != 2500 {
+		t.Errorf("wrong second SCTE-35 event %+v", e)
+	}
+}
+
+func TestEmsgAfterLastFragment(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	for _, s := range createProgTestSamples(4, 3600, 50, 4, 0) {
+		frag.AddFullSample(s)
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	assertNoError(t, frag.Encode(&buf))
+	emsg := &EmsgBox{Version: 1, TimeScale: 1000, PresentationTime: 500, ID: 7, SchemeIDURI: SCTE35SchemeIDURI}
+	assertNoError(t, emsg.Encode(&buf))
+	inSize := buf.Len()
+
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithSegmentPerFragment())
+	assertNoError(t, err)
+	lastFrag := decFile.LastSegment().LastFragment()
+	if len(lastFrag.Emsgs) != 1 || lastFrag.Children[len(lastFrag.Children)-1] != lastFrag.Emsgs[0] {
+		t.Fatalf("emsg after last fragment not kept in it")
+	}
+	events, err := decFile.GetEvents()
+	assertNoError(t, err)
+	if len(events) != 1 || events[0].ID != 7 {
+		t.Errorf("got events %+v instead of event 7", events)
+	}
+	out := bytes.Buffer{}
+	assertNoError(t, decFile.Encode(&out))
+	if out.Len() != inSize {
+		t.Errorf("encoded %d bytes instead of %d", out.Len(), inSize)
+	}
+
+	// Without fragments, the emsg box is only kept in Children
+	buf.Reset()
+	assertNoError(t, init.Encode(&buf))
+	assertNoError(t, emsg.Encode(&buf))
+	decFile, err = DecodeFile(bytes.NewReader(buf.Bytes()), WithSegmentPerFragment())
+	assertNoError(t, err)
+	if len(decFile.Warnings) == 0 || decFile.Children[len(decFile.Children)-1].Type() != "emsg" {
+		t.Errorf("emsg without fragment not in Children with warning")
+	}
+}
+
+func TestEventBeforeLargeTimes(t *testing.T) {
+	early := Event{Start: 1 << 60, Timescale: 1000}          // About 36 million years
+	late := Event{Start: (1<<60 + 1) * 10, Timescale: 10000} // One millisecond later
+	if !early.before(late) || late.before(early) || early.before(early) {
+		t.Errorf("wrong order of events with large start times")
+	}
+}
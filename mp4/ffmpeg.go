@@ -2,6 +2,7 @@ package mp4
 
 // ffmpeg boxes according to https://kdenlive.org/en/project/adding-meta-data-to-mp4-video
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 )
@@ -44,6 +45,15 @@ func (b *CTooBox) GetChildren() []Box {
 	return b.Children
 }
 
+func (b *CTooBox) setChildren(children []Box) error {
+	n := CTooBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - box-specific encode of stsd - not a usual container
 func (b *CTooBox) Encode(w io.Writer) error {
 	err := EncodeHeader(b, w)
@@ -64,18 +74,39 @@ func (b *CTooBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	return ContainerInfo(b, w, specificBoxLevels, indent, indentStep)
 }
 
-// DataBox - data box used by ffmpeg for providing information.
+// DataBox - data box used by ffmpeg and iTunes metadata (ilst) for providing information.
 type DataBox struct {
-	Data []byte
+	Data     []byte
+	TypeSet  byte   // Set of types that DataType belongs to. 0 for the well-known types
+	DataType uint32 // 24-bit type such as DataTypeUTF8 or DataTypeJPEG. 0 is written as UTF-8
+	Locale   uint32
 }
 
+// Well-known data types for DataBox
+const (
+	DataTypeUTF8 = 1
+	DataTypeJPEG = 13
+	DataTypePNG  = 14
+	DataTypeBMP  = 27
+)
+
 // DecodeData - decode Data (from mov_write_string_data_tag in movenc.c in ffmpeg)
 func DecodeData(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	data, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
-	b := DataBox{data[8:]}
+	if len(data) < 8 {
+		return nil, fmt.Errorf("data box too short: %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	typeIndicator := s.ReadUint32()
+	b := DataBox{
+		TypeSet:  byte(typeIndicator >> 24),
+		DataType: typeIndicator & 0xffffff,
+		Locale:   s.ReadUint32(),
+	}
+	b.Data = s.RemainingBytes()
 	return &b, nil
 }
 
@@ -97,8 +128,12 @@ func (b *DataBox) Encode(w io.Writer) error {
 	}
 	buf := makebuf(b)
 	sw := NewSliceWriter(buf)
-	sw.WriteUint32(0x00000001)
-	sw.WriteUint32(0x00000000)
+	dataType := b.DataType
+	if dataType == 0 {
+		dataType = DataTypeUTF8
+	}
+	sw.WriteUint32(uint32(b.TypeSet)<<24 | dataType&0xffffff)
+	sw.WriteUint32(b.Locale)
 	sw.WriteBytes(b.Data)
 	_, err = w.Write(buf)
 	return err
@@ -107,6 +142,15 @@ func (b *DataBox) Encode(w io.Writer) error {
 // Info - box-specific Info
 func (b *DataBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	bd := newInfoDumper(w, indent, b, -1, 0)
-	bd.write(" - data: %s", string(b.Data))
+	switch {
+	case b.TypeSet == 0 && (b.DataType == 0 || b.DataType == DataTypeUTF8):
+		bd.write(" - data: %s", string(b.Data))
+	default:
+		if b.TypeSet != 0 {
+			bd.write(" - typeSet: %d", b.TypeSet)
+		}
+		bd.write(" - dataType: %d", b.DataType)
+		bd.write(" - data: %d bytes", len(b.Data))
+	}
 	return bd.err
 }
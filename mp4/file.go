@@ -18,6 +18,8 @@ import (
 // where mdat may come before moov.
 // If fragmented, there are many more boxes and they are collected
 // in the InitSegment, Segment and Segments structures.
+// A media segment starts at each styp box. Fragments (moof + mdat) before any styp are collected
+// in one media segment with a generated styp, unless WithSegmentPerFragment is used.
 // The sample metadata in thefragments in the Segments will be
 // optimized unless EncodeVerbatim is set.
 // To Encode the same data as Decoded, this flag must therefore be set.
@@ -25,17 +27,44 @@ import (
 type File struct {
 	Ftyp         *FtypBox
 	Moov         *MoovBox
+	Meta         *MetaBox        // File-level meta box with items such as cover art
 	Mdat         *MdatBox        // Only used for non-fragmented files
 	Init         *InitSegment    // Init data (ftyp + moov for fragmented file)
-	Sidx         *SidxBox        // SidxBox for a DASH OnDemand file
+	Sidx         *SidxBox        // SidxBox for a DASH OnDemand file (first of Sidxs)
+	Sidxs        []*SidxBox      // All sidx boxes before the first media segment. Only used if Sidxs[0] is Sidx
+	Ssix         *SsixBox        // SsixBox following the last sidx before the first media segment
 	Segments     []*MediaSegment // Media segments
+	Mfra         *MfraBox        // MfraBox at the end of a fragmented file
 	Children     []Box           // All top-level boxes in order
 	FragEncMode  EncFragFileMode // Determine how fragmented files are encoded
 	EncOptimize  EncOptimize     // Bit field with optimizations being done at encoding
+	Redact       RedactMode      // Zero or drop mdat sample data at encoding
+	MoovFit      MoovFitMode     // How to keep chunk offsets valid if moov changed size in a progressive file
+	Warnings     []Warning       // Non-fatal spec violations found by DecodeFile
 	isFragmented bool
 	fileDecMode  DecFileMode
+	decTrackIDs  []uint32 // If non-empty, only keep these tracks when decoding
+	segPerFrag   bool     // Put fragments without styp in media segments of their own when decoding
+	dataResolver DataRefResolver
+	fragPrefix   []Box  // prft and emsg boxes waiting for the next moof
+	decMaxSize   uint64 // If non-zero, max number of bytes read by DecodeFile
 }
 
+// topSidxs - sidx boxes before the first media segment. Sidx is the primary field, so Sidxs is
+// only used if it starts with Sidx. Otherwise Sidx has been changed, and it is the only sidx box.
+func (f *File) topSidxs() []*SidxBox {
+	if f.Sidx == nil {
+		return nil
+	}
+	if len(f.Sidxs) > 0 && f.Sidxs[0] == f.Sidx {
+		return f.Sidxs
+	}
+	return []*SidxBox{f.Sidx}
+}
+
+// DataRefResolver - opens the media data at location referenced by a url or urn entry in dref.
+type DataRefResolver func(location string) (io.ReadSeeker, error)
+
 // EncFragFileMode - mode for writing file
 type EncFragFileMode byte
 
@@ -107,15 +136,10 @@ type BoxStructure interface {
 	Encode(w io.Writer) error
 }
 
-// WriteToFile - write a box structure to a file at filePath
-func WriteToFile(boxStructure BoxStructure, filePath string) error {
-	ofd, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer ofd.Close()
-	err = boxStructure.Encode(ofd)
-	return err
+// WriteToFile - write a box structure to a file at filePath with buffered IO.
+// Options can be given for fsync and atomic rename.
+func WriteToFile(boxStructure BoxStructure, filePath string, options ...WriteOption) error {
+	return writeFile(boxStructure, filePath, options...)
 }
 
 // AddMediaSegment - add a mediasegment to file f
@@ -134,6 +158,10 @@ func DecodeFile(r io.Reader, options ...Option) (*File, error) {
 	var boxStartPos uint64 = 0
 	lastBoxType := ""
 
+	if f.decMaxSize > 0 {
+		r = newQuotaReader(r, f.decMaxSize)
+	}
+
 	var rs io.ReadSeeker
 	if f.fileDecMode == DecModeLazyMdat {
 		ok := false
@@ -169,13 +197,45 @@ LoopBoxes:
 				}
 			}
 		}
+		f.checkTopLevelOrder(box, boxStartPos)
 		f.AddChild(box, boxStartPos)
+		if boxType == "mdat" && f.isFragmented && len(f.decTrackIDs) > 0 {
+			err = f.filterLastFragment()
+			if err != nil {
+				return nil, err
+			}
+		}
 		lastBoxType = boxType
 		boxStartPos += boxSize
 	}
+	f.flushFragPrefix()
+	if len(f.decTrackIDs) > 0 {
+		f.removeEmptySegments()
+	}
+	f.checkBoxes()
 	return f, nil
 }
 
+// flushFragPrefix - add prft and emsg boxes after the last moof to the last fragment,
+// so that they are not lost when encoding segments. Without fragments, they are only in Children.
+func (f *File) flushFragPrefix() {
+	if len(f.fragPrefix) == 0 {
+		return
+	}
+	if len(f.Segments) == 0 || len(f.LastSegment().Fragments) == 0 {
+		for _, b := range f.fragPrefix {
+			f.addWarning(b.Type(), "not part of any fragment")
+		}
+		f.fragPrefix = nil
+		return
+	}
+	lastFragment := f.LastSegment().LastFragment()
+	for _, b := range f.fragPrefix {
+		lastFragment.AddChild(b)
+	}
+	f.fragPrefix = nil
+}
+
 // AddChild - add child with start position
 func (f *File) AddChild(box Box, boxStartPos uint64) {
 	switch box.Type() {
@@ -183,19 +243,46 @@ func (f *File) AddChild(box Box, boxStartPos uint64) {
 		f.Ftyp = box.(*FtypBox)
 	case "moov":
 		f.Moov = box.(*MoovBox)
-		if len(f.Moov.Trak.Mdia.Minf.Stbl.Stts.SampleCount) == 0 {
+		// Classify before filtering, since a progressive file may have no tracks left
+		fragmented := f.Moov.Trak == nil || len(f.Moov.Trak.Mdia.Minf.Stbl.Stts.SampleCount) == 0
+		if len(f.decTrackIDs) > 0 {
+			f.Moov.keepTracks(f.decTrackIDs)
+			if len(f.Moov.Traks) == 0 {
+				f.addWarning("moov", "none of trackIDs %v found", f.decTrackIDs)
+			}
+		}
+		if fragmented {
 			f.isFragmented = true
 			f.Init = NewMP4Init()
 			f.Init.AddChild(f.Ftyp)
 			f.Init.AddChild(f.Moov)
 		}
+	case "meta":
+		f.Meta = box.(*MetaBox)
 	case "sidx":
 		if len(f.Segments) == 0 { // sidx before first styp
-			f.Sidx = box.(*SidxBox)
+			sidx := box.(*SidxBox)
+			if f.Sidx == nil {
+				f.Sidx = sidx
+			}
+			f.Sidxs = append(f.Sidxs, sidx)
 		} else {
 			currSeg := f.Segments[len(f.Segments)-1]
 			currSeg.Sidx = box.(*SidxBox)
 		}
+	case "ssix":
+		if len(f.Segments) == 0 {
+			f.Ssix = box.(*SsixBox)
+		} else {
+			currSeg := f.Segments[len(f.Segments)-1]
+			currSeg.Ssix = box.(*SsixBox)
+		}
+	case "mfra":
+		f.Mfra = box.(*MfraBox)
+	case "prft", "emsg":
+		if f.isFragmented {
+			f.fragPrefix = append(f.fragPrefix, box)
+		}
 	case "styp":
 		f.isFragmented = true
 		newSeg := NewMediaSegment()
@@ -208,15 +295,23 @@ func (f *File) AddChild(box Box, boxStartPos uint64) {
 
 		var currentSegment *MediaSegment
 
-		if len(f.Segments) == 0 || f.Segments[0].Styp == nil {
-			// No styp present, so one fragment per segment
+		switch {
+		case f.segPerFrag && (len(f.Segments) == 0 || f.Segments[0].Styp == nil):
+			currentSegment = NewMediaSegmentWithoutStyp()
+			f.AddMediaSegment(currentSegment)
+		case len(f.Segments) == 0:
+			// No styp present, so one segment with a generated styp
 			currentSegment = NewMediaSegment()
 			f.AddMediaSegment(currentSegment)
-		} else {
+		default:
 			currentSegment = f.LastSegment()
 		}
 		newFragment := NewFragment()
 		currentSegment.AddFragment(newFragment)
+		for _, b := range f.fragPrefix {
+			newFragment.AddChild(b)
+		}
+		f.fragPrefix = nil
 		newFragment.AddChild(moof)
 	case "mdat":
 		mdat := box.(*MdatBox)
@@ -230,6 +325,145 @@ func (f *File) AddChild(box Box, boxStartPos uint64) {
 	f.Children = append(f.Children, box)
 }
 
+// filterLastFragment - remove tracks not in decTrackIDs from last fragment.
+// If no track is left, the fragment (moof + mdat) is removed.
+func (f *File) filterLastFragment() error {
+	var trexs []*TrexBox
+	if f.Moov != nil && f.Moov.Mvex != nil {
+		trexs = f.Moov.Mvex.Trexs
+	}
+	seg := f.LastSegment()
+	frag := seg.LastFragment()
+	tracksLeft, err := frag.keepTracks(f.decTrackIDs, trexs)
+	if err != nil {
+		return err
+	}
+	if !tracksLeft {
+		seg.Fragments = seg.Fragments[:len(seg.Fragments)-1]
+		for _, b := range frag.Children {
+			f.Children = removeBox(f.Children, b)
+		}
+	}
+	return nil
+}
+
+// removeEmptySegments - remove segments left without fragments after track filtering,
+// together with their styp, sidx and ssix boxes.
+func (f *File) removeEmptySegments() {
+	segments := f.Segments[:0]
+	for _, seg := range f.Segments {
+		if len(seg.Fragments) > 0 {
+			segments = append(segments, seg)
+			continue
+		}
+		if seg.Styp != nil {
+			f.Children = removeBox(f.Children, seg.Styp)
+		}
+		if seg.Sidx != nil {
+			f.Children = removeBox(f.Children, seg.Sidx)
+		}
+		if seg.Ssix != nil {
+			f.Children = removeBox(f.Children, seg.Ssix)
+		}
+	}
+	f.Segments = segments
+}
+
+// TrexForTrack - trex box for trackID from mvex. For fragmented files without mvex or without trex
+// for the track, a trex with defaults from the first traf of the track is created by CreateTrexFromTraf.
+// Returns nil if there is neither a trex nor a traf for trackID.
+func (f *File) TrexForTrack(trackID uint32) *TrexBox {
+	if f.Moov != nil && f.Moov.Mvex != nil {
+		if trex := f.Moov.Mvex.GetTrex(trackID); trex != nil {
+			return trex
+		}
+	}
+	for _, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			if traf := frag.trafForTrack(trackID); traf != nil {
+				return CreateTrexFromTraf(traf)
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveTrack - remove a track from moov and from all fragments.
+// mvhd.NextTrackID and trex boxes are updated. Fragments without any remaining track are removed.
+func (f *File) RemoveTrack(trackID uint32) error {
+	if f.Moov == nil {
+		return fmt.Errorf("no moov box")
+	}
+	var trexs []*TrexBox
+	if f.Moov.Mvex != nil {
+		trexs = f.Moov.Mvex.Trexs
+	}
+	err := f.Moov.RemoveTrack(trackID)
+	if err != nil {
+		return err
+	}
+	if !f.isFragmented {
+		return nil
+	}
+	trackIDs := make([]uint32, 0, len(f.Moov.Traks))
+	for _, trak := range f.Moov.Traks {
+		trackIDs = append(trackIDs, trak.Tkhd.TrackID)
+	}
+	removed := make(map[Box]bool)
+	for _, seg := range f.Segments {
+		frags := seg.Fragments[:0]
+		for _, frag := range seg.Fragments {
+			tracksLeft, err := frag.keepTracks(trackIDs, trexs)
+			if err != nil {
+				return err
+			}
+			if !tracksLeft {
+				for _, c := range frag.Children {
+					removed[c] = true
+				}
+				continue
+			}
+			frags = append(frags, frag)
+		}
+		seg.Fragments = frags
+	}
+	if len(removed) > 0 {
+		children := f.Children[:0]
+		for _, c := range f.Children {
+			if !removed[c] {
+				children = append(children, c)
+			}
+		}
+		f.Children = children
+	}
+	return nil
+}
+
+// SetTrackID - change trackID of a track in moov as MoovBox.SetTrackID does, and in the tfhd boxes
+// of all fragments and the tfra boxes of mfra.
+func (f *File) SetTrackID(oldTrackID, newTrackID uint32) error {
+	if f.Moov == nil {
+		return fmt.Errorf("no moov box")
+	}
+	err := f.Moov.SetTrackID(oldTrackID, newTrackID)
+	if err != nil {
+		return err
+	}
+	for _, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			frag.SetTrackID(oldTrackID, newTrackID)
+		}
+	}
+	if f.Mfra != nil {
+		for _, tfra := range f.Mfra.Tfras {
+			if tfra.TrackID == oldTrackID {
+				tfra.TrackID = newTrackID
+			}
+		}
+	}
+	return nil
+}
+
 // DumpWithSampleData - print information about file and its children boxes
 func (f *File) DumpWithSampleData(w io.Writer, specificBoxLevels string) error {
 	if f.isFragmented {
@@ -272,6 +506,11 @@ func (f *File) DumpWithSampleData(w io.Writer, specificBoxLevels string) error {
 // Encode - encode a file to a Writer
 // Fragmented files are encoded based on InitSegment and MediaSegments, unless EncodeVerbatim is set.
 func (f *File) Encode(w io.Writer) error {
+	children, segments := f.Children, f.Segments
+	if f.Redact != RedactNone {
+		children = redactedBoxes(children, f.Redact)
+		segments = redactedSegments(segments, f.Redact)
+	}
 	if f.isFragmented {
 		switch f.FragEncMode {
 		case EncModeSegment:
@@ -281,13 +520,19 @@ func (f *File) Encode(w io.Writer) error {
 					return err
 				}
 			}
-			if f.Sidx != nil {
-				err := f.Sidx.Encode(w)
+			for _, sidx := range f.topSidxs() {
+				err := sidx.Encode(w)
 				if err != nil {
 					return err
 				}
 			}
-			for _, seg := range f.Segments {
+			if f.Ssix != nil {
+				err := f.Ssix.Encode(w)
+				if err != nil {
+					return err
+				}
+			}
+			for _, seg := range segments {
 				if f.EncOptimize&OptimizeTrun != 0 {
 					seg.EncOptimize = f.EncOptimize
 				}
@@ -296,8 +541,14 @@ func (f *File) Encode(w io.Writer) error {
 					return err
 				}
 			}
+			if f.Mfra != nil {
+				err := f.Mfra.Encode(w)
+				if err != nil {
+					return err
+				}
+			}
 		case EncModeBoxTree:
-			for _, b := range f.Children {
+			for _, b := range children {
 				err := b.Encode(w)
 				if err != nil {
 					return err
@@ -309,7 +560,11 @@ func (f *File) Encode(w io.Writer) error {
 		return nil
 	}
 	// Progressive file
-	for _, b := range f.Children {
+	children, err := f.fittedForEncode(children)
+	if err != nil {
+		return err
+	}
+	for _, b := range children {
 		err := b.Encode(w)
 		if err != nil {
 			return err
@@ -360,19 +615,48 @@ func WithDecodeMode(mode DecFileMode) Option {
 	return func(f *File) { f.fileDecMode = mode }
 }
 
+// WithTrackIDs sets up a filter so that only the tracks with the listed trackIDs are kept during decode.
+// trak and trex boxes of other tracks are dropped from moov, and traf boxes of other tracks
+// are dropped from moof together with their sample data in mdat. Fragments without any
+// of the tracks are dropped completely. For lazy mdat, only the traf boxes are removed.
+// The mdat of a progressive file is kept as is, since chunk offsets refer to it.
+func WithTrackIDs(trackIDs ...uint32) Option {
+	return func(f *File) { f.decTrackIDs = trackIDs }
+}
+
+// WithSegmentPerFragment sets up decoding so that fragments without a preceding styp box are put
+// in media segments of their own without styp, e.g. the subsegments of a DASH OnDemand file.
+// By default, such fragments are collected in one media segment with a generated styp box.
+func WithSegmentPerFragment() Option {
+	return func(f *File) { f.segPerFrag = true }
+}
+
+// WithMoovFitMode sets up how chunk offsets are kept valid when moov changed size in a progressive file
+func WithMoovFitMode(mode MoovFitMode) Option {
+	return func(f *File) { f.MoovFit = mode }
+}
+
+// WithDataRefResolver sets up a resolver for media data in other files than the moov box.
+// It is used by CopySampleData for chunks whose dref entry is not self-contained.
+func WithDataRefResolver(resolver DataRefResolver) Option {
+	return func(f *File) { f.dataResolver = resolver }
+}
+
 // CopySampleData - copy sample data from a track in a progressive mp4 file to w. Use rs if lazy read.
+// Sample data referenced via external url or urn entries in dref is read using the
+// DataRefResolver set by WithDataRefResolver. Each location is resolved once per call,
+// and the returned reader is closed before returning if it implements io.Closer.
 func (f *File) CopySampleData(w io.Writer, rs io.ReadSeeker, trak *TrakBox, startSampleNr, endSampleNr uint32) error {
 	if f.isFragmented {
 		return fmt.Errorf("only available for progressive files")
 	}
 	mdat := f.Mdat
 
-	if mdat.IsLazy() && rs == nil {
-		return fmt.Errorf("no ReadSeeker for lazy mdat")
-	}
-	mdatPayloadStart := mdat.PayloadAbsoluteOffset()
-
 	stbl := trak.Mdia.Minf.Stbl
+	sizes := stbl.SampleSizes()
+	if sizes == nil {
+		return fmt.Errorf("no stsz or stz2 box")
+	}
 	chunks, err := stbl.Stsc.GetContainingChunks(startSampleNr, endSampleNr)
 	if err != nil {
 		return err
@@ -388,6 +672,15 @@ func (f *File) CopySampleData(w io.Writer, rs io.ReadSeeker, trak *TrakBox, star
 	} else {
 		return fmt.Errorf("neither stco nor co64 available")
 	}
+	// Each external location is opened once, and closed when done if it is an io.Closer
+	resolved := make(map[string]io.ReadSeeker)
+	defer func() {
+		for _, ers := range resolved {
+			if c, ok := ers.(io.Closer); ok {
+				c.Close()
+			}
+		}
+	}()
 	var startNr, endNr uint32
 	var offset uint64
 	for i, chunk := range chunks {
@@ -396,7 +689,7 @@ func (f *File) CopySampleData(w io.Writer, rs io.ReadSeeker, trak *TrakBox, star
 		offset = chunkOffsets[chunk.ChunkNr-1]
 		if i == 0 {
 			for sNr := chunk.StartSampleNr; sNr < startSampleNr; sNr++ {
-				offset += uint64(stbl.Stsz.GetSampleSize(int(sNr)))
+				offset += uint64(sizes.GetSampleSize(int(sNr)))
 			}
 			startNr = startSampleNr
 		}
@@ -406,22 +699,43 @@ func (f *File) CopySampleData(w io.Writer, rs io.ReadSeeker, trak *TrakBox, star
 		}
 		var size int64
 		for sNr := startNr; sNr <= endNr; sNr++ {
-			size += int64(stbl.Stsz.GetSampleSize(int(sNr)))
+			size += int64(sizes.GetSampleSize(int(sNr)))
 		}
-		if mdat.IsLazy() {
-			_, err := rs.Seek(int64(offset), io.SeekStart)
+		location, selfContained, err := trak.GetChunkDataLocation(chunk.ChunkNr)
+		if err != nil {
+			return err
+		}
+		if !selfContained {
+			if f.dataResolver == nil {
+				return fmt.Errorf("no DataRefResolver for external data at %q", location)
+			}
+			ers, ok := resolved[location]
+			if !ok {
+				ers, err = f.dataResolver(location)
+				if err != nil {
+					return err
+				}
+				resolved[location] = ers
+			}
+			err = copyRange(w, ers, offset, size)
 			if err != nil {
 				return err
 			}
-			n, err := io.CopyN(w, rs, size)
+			continue
+		}
+		if mdat == nil {
+			return fmt.Errorf("no mdat for self-contained data")
+		}
+		if mdat.IsLazy() {
+			if rs == nil {
+				return fmt.Errorf("no ReadSeeker for lazy mdat")
+			}
+			err = copyRange(w, rs, offset, size)
 			if err != nil {
 				return err
 			}
-			if n != size {
-				return fmt.Errorf("copied %d bytes instead of %d", n, size)
-			}
 		} else {
-			offsetInMdatData := offset - mdatPayloadStart
+			offsetInMdatData := offset - mdat.PayloadAbsoluteOffset()
 			n, err := w.Write(mdat.Data[offsetInMdatData : offsetInMdatData+uint64(size)])
 			if err != nil {
 				return err
@@ -434,3 +748,19 @@ func (f *File) CopySampleData(w io.Writer, rs io.ReadSeeker, trak *TrakBox, star
 
 	return nil
 }
+
+// copyRange - copy size bytes starting at offset in rs to w
+func copyRange(w io.Writer, rs io.ReadSeeker, offset uint64, size int64) error {
+	_, err := rs.Seek(int64(offset), io.SeekStart)
+	if err != nil {
+		return err
+	}
+	n, err := io.CopyN(w, rs, size)
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("copied %d bytes instead of %d", n, size)
+	}
+	return nil
+}
@@ -2,8 +2,12 @@ package mp4
 
 import (
 	"bytes"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestDecodeFileWithLazyMdatOption(t *testing.T) {
@@ -97,3 +101,445 @@ func TestCopyTrackSampleData(t *testing.T) {
 		}
 	}
 }
+
+func TestCopySampleDataExternalDataRef(t *testing.T) {
+	data, err := ioutil.ReadFile("./testdata/prog_8s.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp4f, err := DecodeFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trak := mp4f.Moov.Trak
+	selfContained := bytes.Buffer{}
+	err = mp4f.CopySampleData(&selfContained, nil, trak, 1, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Point the track to an external file with the same content
+	dref := &DrefBox{}
+	dref.AddChild(CreateExternalURLBox("media.mp4"))
+	trak.Mdia.Minf.Dinf.Dref = dref
+	trak.Mdia.Minf.Dinf.Children = []Box{dref}
+
+	external := bytes.Buffer{}
+	err = mp4f.CopySampleData(&external, nil, trak, 1, 30)
+	assertError(t, err, "no resolver should give error")
+
+	var resolvedLocation string
+	nrResolved := 0
+	var readers []*closeCountingReader
+	mp4f.ApplyOptions(WithDataRefResolver(func(location string) (io.ReadSeeker, error) {
+		resolvedLocation = location
+		nrResolved++
+		r := &closeCountingReader{ReadSeeker: bytes.NewReader(data)}
+		readers = append(readers, r)
+		return r, nil
+	}))
+	err = mp4f.CopySampleData(&external, nil, trak, 1, 30)
+	assertNoError(t, err)
+	if resolvedLocation != "media.mp4" {
+		t.Errorf("got location %q instead of %q", resolvedLocation, "media.mp4")
+	}
+	chunks, err := trak.Mdia.Minf.Stbl.Stsc.GetContainingChunks(1, 30)
+	assertNoError(t, err)
+	if len(chunks) < 2 {
+		t.Errorf("test data should span several chunks, got %d", len(chunks))
+	}
+	if nrResolved != 1 {
+		t.Errorf("location resolved %d times instead of once", nrResolved)
+	}
+	for _, r := range readers {
+		if r.nrClosed != 1 {
+			t.Errorf("resolved reader closed %d times instead of once", r.nrClosed)
+		}
+	}
+	if !bytes.Equal(external.Bytes(), selfContained.Bytes()) {
+		t.Errorf("external data differs from self-contained data")
+	}
+}
+
+// closeCountingReader - io.ReadSeeker that counts calls to Close
+type closeCountingReader struct {
+	io.ReadSeeker
+	nrClosed int
+}
+
+func (r *closeCountingReader) Close() error {
+	r.nrClosed++
+	return nil
+}
+
+func TestDecodeFileWithTrackIDs(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(48000, "audio", "und")
+	buf := bytes.Buffer{}
+	err := init.Encode(&buf)
+	if err != nil {
+		t.Error(err)
+	}
+	for nr := uint32(1); nr <= 2; nr++ {
+		frag, err := CreateMultiTrackFragment(nr, []uint32{1, 2})
+		if err != nil {
+			t.Error(err)
+		}
+		for i := 0; i < 3; i++ {
+			vs := FullSample{Sample: NewSample(SyncSampleFlags, 3000, 4, 0),
+				DecodeTime: uint64(3000 * i), Data: []byte{1, 1, 1, 1}}
+			err = frag.AddFullSampleToTrack(vs, 1)
+			if err != nil {
+				t.Error(err)
+			}
+			as := FullSample{Sample: NewSample(SyncSampleFlags, 1024, 2, 0),
+				DecodeTime: uint64(1024 * i), Data: []byte{2, 2}}
+			err = frag.AddFullSampleToTrack(as, 2)
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		err = frag.Encode(&buf)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	for _, mode := range []DecFileMode{DecModeNormal, DecModeLazyMdat} {
+		f, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithTrackIDs(2), WithDecodeMode(mode))
+		if err != nil {
+			t.Error(err)
+		}
+		if len(f.Moov.Traks) != 1 || f.Moov.Trak.Tkhd.TrackID != 2 {
+			t.Errorf("expected only track 2 in moov")
+		}
+		if len(f.Moov.Mvex.Trexs) != 1 || f.Moov.Mvex.Trex.TrackID != 2 {
+			t.Errorf("expected only trex for track 2")
+		}
+		var frags []*Fragment
+		for _, seg := range f.Segments {
+			frags = append(frags, seg.Fragments...)
+		}
+		if len(frags) != 2 {
+			t.Errorf("got %d fragments instead of 2", len(frags))
+		}
+		for _, frag := range frags {
+			if len(frag.Moof.Trafs) != 1 || frag.Moof.Traf.Tfhd.TrackID != 2 {
+				t.Errorf("expected only traf for track 2")
+			}
+			if mode == DecModeLazyMdat {
+				continue
+			}
+			if len(frag.Mdat.Data) != 6 {
+				t.Errorf("got %d bytes of mdat data instead of 6", len(frag.Mdat.Data))
+			}
+			samples, err := frag.GetFullSamples(f.Moov.Mvex.Trex)
+			if err != nil {
+				t.Error(err)
+			}
+			for _, s := range samples {
+				if !bytes.Equal(s.Data, []byte{2, 2}) {
+					t.Errorf("wrong sample data %v", s.Data)
+				}
+			}
+		}
+	}
+
+	f, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithTrackIDs(3))
+	if err != nil {
+		t.Error(err)
+	}
+	if len(f.Moov.Traks) != 0 || len(f.Segments) != 0 {
+		t.Errorf("expected no tracks or segments to be left")
+	}
+	for _, c := range f.Children {
+		if c.Type() == "moof" || c.Type() == "mdat" {
+			t.Errorf("%s box left after all tracks were removed", c.Type())
+		}
+	}
+	if len(f.Warnings) != 1 {
+		t.Errorf("got %d warnings instead of 1 when no tracks are left", len(f.Warnings))
+	}
+}
+
+func TestDecodeEncryptedFileWithTrackIDs(t *testing.T) {
+	key, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	iv, _ := hex.DecodeString("0102030405060708")
+	tenc := &TencBox{DefaultIsProtected: 1, DefaultPerSampleIVSize: 8, DefaultKID: UUID(make([]byte, 16))}
+	trex := &TrexBox{TrackID: 2}
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(48000, "audio", "und")
+	frag, err := CreateMultiTrackFragment(1, []uint32{1, 2})
+	assertNoError(t, err)
+	for i := 0; i < 3; i++ {
+		vs := FullSample{Sample: NewSample(SyncSampleFlags, 3000, 30, 0),
+			DecodeTime: uint64(3000 * i), Data: bytes.Repeat([]byte{1}, 30)}
+		assertNoError(t, frag.AddFullSampleToTrack(vs, 1))
+	}
+	var plain [][]byte
+	for i := 0; i < 3; i++ {
+		data := bytes.Repeat([]byte{byte(i + 2)}, 20)
+		plain = append(plain, data)
+		as := FullSample{Sample: NewSample(SyncSampleFlags, 1024, 20, 0),
+			DecodeTime: uint64(1024 * i), Data: append([]byte{}, data...)}
+		assertNoError(t, frag.AddFullSampleToTrack(as, 2))
+	}
+	frag = fragmentAfterEncodeAndDecode(t, frag)
+	_, err = frag.EncryptSamples(trex, SchemeCENC, key, tenc, iv)
+	assertNoError(t, err)
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	assertNoError(t, frag.Encode(&buf))
+
+	f, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithTrackIDs(2))
+	assertNoError(t, err)
+	outFrag := f.Segments[0].Fragments[0]
+	_, saio := outFrag.Moof.Traf.GetSaizSaio("")
+	ivPos := int(saio.Offset[0])
+	out := bytes.Buffer{}
+	assertNoError(t, outFrag.Encode(&out))
+	raw := out.Bytes()
+	if !bytes.Equal(raw[ivPos:ivPos+8], iv) {
+		t.Errorf("saio offset does not point to first IV after removing track")
+	}
+	assertNoError(t, outFrag.DecryptSamples(trex, SchemeCENC, key, tenc))
+	samples, err := outFrag.GetFullSamples(trex)
+	assertNoError(t, err)
+	for i, s := range samples {
+		if !bytes.Equal(s.Data, plain[i]) {
+			t.Errorf("sample %d: data mismatch after decryption", i+1)
+		}
+	}
+}
+
+func TestDecodeProgressiveFileWithoutMatchingTrackIDs(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(4, 3600, 100, 5, 0x10)
+	pf, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 300})
+	assertNoError(t, err)
+	buf := bytes.Buffer{}
+	assertNoError(t, pf.Encode(&buf))
+	f, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithTrackIDs(2))
+	assertNoError(t, err)
+	if f.IsFragmented() || f.Init != nil || len(f.Segments) != 0 {
+		t.Errorf("progressive file without tracks left classified as fragmented")
+	}
+	if len(f.Moov.Traks) != 0 || len(f.Warnings) != 1 {
+		t.Errorf("got %d tracks and %d warnings instead of 0 and 1", len(f.Moov.Traks), len(f.Warnings))
+	}
+	assertNoError(t, f.Encode(&bytes.Buffer{}))
+}
+
+func TestFileRemoveTrack(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(48000, "audio", "und")
+	buf := bytes.Buffer{}
+	err := init.Encode(&buf)
+	if err != nil {
+		t.Error(err)
+	}
+	frag, err := CreateMultiTrackFragment(1, []uint32{1, 2})
+	if err != nil {
+		t.Error(err)
+	}
+	err = frag.AddFullSampleToTrack(FullSample{Sample: NewSample(SyncSampleFlags, 3000, 2, 0), Data: []byte{1, 1}}, 1)
+	if err != nil {
+		t.Error(err)
+	}
+	err = frag.AddFullSampleToTrack(FullSample{Sample: NewSample(SyncSampleFlags, 1024, 1, 0), Data: []byte{2}}, 2)
+	if err != nil {
+		t.Error(err)
+	}
+	err = frag.Encode(&buf)
+	if err != nil {
+		t.Error(err)
+	}
+	f, err := DecodeFile(&buf)
+	if err != nil {
+		t.Error(err)
+	}
+	err = f.RemoveTrack(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if f.Moov.Mvhd.NextTrackID != 3 || len(f.Moov.Traks) != 1 {
+		t.Errorf("moov not updated after track removal")
+	}
+	outFrag := f.Segments[0].Fragments[0]
+	if len(outFrag.Moof.Trafs) != 1 || !bytes.Equal(outFrag.Mdat.Data, []byte{2}) {
+		t.Errorf("fragment not updated after track removal")
+	}
+	err = f.RemoveTrack(2)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(f.Segments[0].Fragments) != 0 || len(f.Children) != 2 {
+		t.Errorf("fragment not removed when no tracks left")
+	}
+}
+
+func TestReproducibleEncode(t *testing.T) {
+	SetReproducibleEncode(true)
+	defer SetReproducibleEncode(false)
+	ntp := TimeToNTPTimestamp(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	encodeOnce := func(now time.Time) (fileData, segData []byte) {
+		init := CreateEmptyInit()
+		init.AddEmptyTrack(90000, "video", "und")
+		init.Moov.SetTimes(now)
+		frag, err := CreateFragment(1, 1)
+		assertNoError(t, err)
+		frag.AddFullSample(FullSample{Sample: NewSample(SyncSampleFlags, 3600, 4, 0), Data: []byte{1, 2, 3, 4}})
+		frag.Children = append([]Box{CreatePrftBox(0, ntp, 0)}, frag.Children...)
+		buf := bytes.Buffer{}
+		assertNoError(t, init.Encode(&buf))
+		assertNoError(t, frag.Encode(&buf))
+		segData = buf.Bytes()
+		f, err := DecodeFile(bytes.NewReader(segData), WithEncodeMode(EncModeBoxTree))
+		assertNoError(t, err)
+		out := bytes.Buffer{}
+		assertNoError(t, f.Encode(&out))
+		if init.Moov.Mvhd.CreationTime != TimeToMP4Time(now) {
+			t.Errorf("encoding changed creation time in box tree")
+		}
+		if f.Moov.Mvhd.CreationTime != 0 {
+			t.Errorf("creation time %d not zeroed in output", f.Moov.Mvhd.CreationTime)
+		}
+		prft := f.Segments[0].Fragments[0].Children[0].(*PrftBox)
+		if prft.NTPTimestamp != ntp {
+			t.Errorf("prft NTP timestamp %d changed to %d in output", ntp, prft.NTPTimestamp)
+		}
+		return out.Bytes(), segData
+	}
+	first, firstSeg := encodeOnce(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	second, secondSeg := encodeOnce(time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC))
+	if !bytes.Equal(first, second) {
+		t.Errorf("reproducible file encodings differ")
+	}
+	if !bytes.Equal(firstSeg, secondSeg) {
+		t.Errorf("reproducible init segment and fragment encodings differ")
+	}
+}
+
+func TestFragmentedFileWithoutMvex(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(48000, "audio", "und")
+	assertNoError(t, RemoveChild(init.Moov, init.Moov.Mvex))
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	frag, err := CreateMultiTrackFragment(1, []uint32{1, 2})
+	assertNoError(t, err)
+	for i := 0; i < 3; i++ {
+		s := FullSample{Sample: NewSample(NonSyncSampleFlags, 3000, 2, 0), DecodeTime: uint64(i * 3000), Data: []byte{1, 1}}
+		assertNoError(t, frag.AddFullSampleToTrack(s, 1))
+	}
+	for i := 0; i < 2; i++ {
+		s := FullSample{Sample: NewSample(SyncSampleFlags, 1024, 1, 0), DecodeTime: uint64(i * 1024), Data: []byte{2}}
+		assertNoError(t, frag.AddFullSampleToTrack(s, 2))
+	}
+	assertNoError(t, frag.Encode(&buf))
+	f, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	if f.Moov.Mvex != nil {
+		t.Fatalf("mvex should be missing")
+	}
+	trex := f.TrexForTrack(2)
+	if trex == nil || trex.TrackID != 2 || trex.DefaultSampleDuration != 1024 || trex.DefaultSampleSize != 1 ||
+		trex.DefaultSampleFlags != SyncSampleFlags {
+		t.Errorf("got synthesized trex %+v", trex)
+	}
+	if f.TrexForTrack(3) != nil {
+		t.Errorf("trex for unknown track")
+	}
+	samples, err := fragmentedTrackSamples(f, 2)
+	assertNoError(t, err)
+	if len(samples) != 2 || samples[1].DecodeTime != 1024 || !bytes.Equal(samples[1].Data, []byte{2}) {
+		t.Errorf("got audio samples %v", samples)
+	}
+}
+
+func TestDecodeSegmentsWithAndWithoutStyp(t *testing.T) {
+	encode := func(e interface{ Encode(w io.Writer) error }) []byte {
+		buf := bytes.Buffer{}
+		assertNoError(t, e.Encode(&buf))
+		return buf.Bytes()
+	}
+	var noStyp, withStyp bytes.Buffer
+	withStyp.Write(encode(CreateStyp()))
+	for i := 0; i < 3; i++ {
+		frag, err := CreateFragment(uint32(i+1), 1)
+		assertNoError(t, err)
+		frag.AddFullSample(FullSample{Sample: Sample{Flags: SyncSampleFlags, Dur: 1000, Size: 1},
+			DecodeTime: uint64(i) * 1000, Data: []byte{byte(i)}})
+		data := encode(frag)
+		noStyp.Write(data)
+		withStyp.Write(data)
+	}
+	testCases := []struct {
+		desc         string
+		data         []byte
+		opts         []Option
+		nrSegments   int
+		nrFragsInSeg int
+		wanted       []byte // encoded output
+	}{
+		{"without styp", noStyp.Bytes(), nil, 1, 3, withStyp.Bytes()},
+		{"without styp, segment per fragment", noStyp.Bytes(), []Option{WithSegmentPerFragment()}, 3, 1, noStyp.Bytes()},
+		{"with styp", withStyp.Bytes(), nil, 1, 3, withStyp.Bytes()},
+		{"with styp, segment per fragment", withStyp.Bytes(), []Option{WithSegmentPerFragment()}, 1, 3, withStyp.Bytes()},
+	}
+	for _, tc := range testCases {
+		f, err := DecodeFile(bytes.NewReader(tc.data), tc.opts...)
+		assertNoError(t, err)
+		if len(f.Segments) != tc.nrSegments {
+			t.Fatalf("%s: got %d segments instead of %d", tc.desc, len(f.Segments), tc.nrSegments)
+		}
+		for i, seg := range f.Segments {
+			if len(seg.Fragments) != tc.nrFragsInSeg {
+				t.Errorf("%s: segment %d has %d fragments instead of %d", tc.desc, i, len(seg.Fragments), tc.nrFragsInSeg)
+			}
+		}
+		out := encode(f)
+		if !bytes.Equal(out, tc.wanted) {
+			t.Errorf("%s: encoded file differs from expected output", tc.desc)
+		}
+	}
+}
+
+func TestFileSetTrackID(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(48000, "audio", "und")
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	frag, err := CreateMultiTrackFragment(1, []uint32{1, 2})
+	assertNoError(t, err)
+	assertNoError(t, frag.AddFullSampleToTrack(FullSample{Sample: NewSample(SyncSampleFlags, 3000, 2, 0), Data: []byte{1, 1}}, 1))
+	assertNoError(t, frag.AddFullSampleToTrack(FullSample{Sample: NewSample(SyncSampleFlags, 1024, 1, 0), Data: []byte{2}}, 2))
+	assertNoError(t, frag.Encode(&buf))
+	f, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	assertNoError(t, f.UpdateMfra())
+
+	assertNoError(t, f.SetTrackID(2, 5))
+	assertError(t, f.SetTrackID(1, 5), "trackID in use should give error")
+	if f.Moov.GetTrak(5) == nil || f.Moov.Mvex.GetTrex(5) == nil {
+		t.Errorf("trackID not changed in moov")
+	}
+	if f.Mfra.Tfras[1].TrackID != 5 {
+		t.Errorf("trackID not changed in tfra")
+	}
+	buf.Reset()
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	samples, err := decFile.Segments[0].Fragments[0].GetFullSamples(decFile.Moov.Mvex.GetTrex(5))
+	assertNoError(t, err)
+	if len(samples) != 1 || !bytes.Equal(samples[0].Data, []byte{2}) {
+		t.Errorf("got samples %v for new trackID", samples)
+	}
+}
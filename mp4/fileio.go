@@ -0,0 +1,152 @@
+package mp4
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// WriteOption is function signature of options for writing box structures to files
+type WriteOption func(o *writeOptions)
+
+type writeOptions struct {
+	fsync  bool
+	atomic bool
+	perm   os.FileMode
+}
+
+// WithFsync - sync the file to stable storage before it is closed
+func WithFsync() WriteOption {
+	return func(o *writeOptions) { o.fsync = true }
+}
+
+// WithAtomicRename - write to a temporary file in the same directory and rename it to the
+// final path when done, so that readers never see a partially written file. The temporary file
+// is synced before the rename, and the directory after it, so that the new file survives a crash.
+func WithAtomicRename() WriteOption {
+	return func(o *writeOptions) { o.atomic = true }
+}
+
+// WithFileMode - permissions of a created file. The default is 0644
+func WithFileMode(perm os.FileMode) WriteOption {
+	return func(o *writeOptions) { o.perm = perm }
+}
+
+// WriteToFile - write f to a file at filePath with buffered IO
+func (f *File) WriteToFile(filePath string, options ...WriteOption) error {
+	return WriteToFile(f, filePath, options...)
+}
+
+// WriteToFile - write s to a file at filePath with buffered IO
+func (s *InitSegment) WriteToFile(filePath string, options ...WriteOption) error {
+	return WriteToFile(s, filePath, options...)
+}
+
+// WriteToFile - write s to a file at filePath with buffered IO
+func (s *MediaSegment) WriteToFile(filePath string, options ...WriteOption) error {
+	return WriteToFile(s, filePath, options...)
+}
+
+// writeFile - write boxStructure to filePath according to options
+func writeFile(boxStructure BoxStructure, filePath string, options ...WriteOption) error {
+	opts := writeOptions{perm: 0644}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	var ofd *os.File
+	var err error
+	if opts.atomic {
+		ofd, err = ioutil.TempFile(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp*")
+		if err == nil {
+			err = ofd.Chmod(opts.perm)
+		}
+	} else {
+		ofd, err = os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, opts.perm)
+	}
+	if err != nil {
+		if ofd != nil {
+			ofd.Close()
+			os.Remove(ofd.Name())
+		}
+		return err
+	}
+	err = encodeToFile(ofd, boxStructure, opts.fsync || opts.atomic)
+	if closeErr := ofd.Close(); err == nil {
+		err = closeErr
+	}
+	if opts.atomic {
+		if err == nil {
+			err = os.Rename(ofd.Name(), filePath)
+		}
+		if err != nil {
+			os.Remove(ofd.Name())
+			return err
+		}
+		return syncDir(filepath.Dir(filePath))
+	}
+	return err
+}
+
+// syncDir - sync directory dir, so that a rename in it is stored
+func syncDir(dir string) error {
+	dfd, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = dfd.Sync()
+	if closeErr := dfd.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// encodeToFile - encode boxStructure to ofd via a buffer and optionally sync
+func encodeToFile(ofd *os.File, boxStructure BoxStructure, fsync bool) error {
+	bw := bufio.NewWriter(ofd)
+	if err := boxStructure.Encode(bw); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if fsync {
+		return ofd.Sync()
+	}
+	return nil
+}
+
+// ReadFile - read and decode a file at filePath with optional file options.
+// The whole file is read into memory and the file is closed before returning. Lazy decode modes
+// therefore save no memory, and lazily decoded media data cannot be read afterwards, since there
+// is no reader to read it from. Use os.Open and DecodeFile for lazy decoding.
+func ReadFile(filePath string, options ...Option) (*File, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeFile(bytes.NewReader(data), options...)
+}
+
+// ReadInitSegment - read and decode an init segment file at filePath
+func ReadInitSegment(filePath string) (*InitSegment, error) {
+	f, err := ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if f.Init == nil {
+		return nil, fmt.Errorf("%s: no init segment", filePath)
+	}
+	return f.Init, nil
+}
+
+// ReadMediaSegment - read and decode a media segment file at filePath without any init segment
+func ReadMediaSegment(filePath string) (*MediaSegment, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeMediaSegment(bytes.NewReader(data))
+}
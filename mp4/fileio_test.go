@@ -0,0 +1,66 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type failingEncoder struct{}
+
+func (failingEncoder) Encode(w io.Writer) error {
+	_, _ = w.Write([]byte{1, 2, 3})
+	return fmt.Errorf("encode failed")
+}
+
+func TestWriteAndReadFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mp4ff-fileio")
+	assertNoError(t, err)
+	defer os.RemoveAll(dir)
+
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	initPath := filepath.Join(dir, "init.mp4")
+	assertNoError(t, init.WriteToFile(initPath, WithAtomicRename()))
+	decInit, err := ReadInitSegment(initPath)
+	assertNoError(t, err)
+	if decInit.Moov.Trak.Mdia.Mdhd.Timescale != 90000 {
+		t.Errorf("init segment not read back")
+	}
+
+	seg := NewMediaSegment()
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	for _, s := range createProgTestSamples(5, 3600, 100, 5, 0) {
+		frag.AddFullSample(s)
+	}
+	seg.AddFragment(frag)
+	segPath := filepath.Join(dir, "1.m4s")
+	assertNoError(t, seg.WriteToFile(segPath))
+	decSeg, err := ReadMediaSegment(segPath)
+	assertNoError(t, err)
+	if len(decSeg.Fragments) != 1 || decSeg.Fragments[0].Moof.Traf.Trun.SampleCount() != 5 {
+		t.Errorf("media segment not read back")
+	}
+
+	f, err := ReadFile(initPath, WithDecodeMode(DecModeLazyMdat))
+	assertNoError(t, err)
+	filePath := filepath.Join(dir, "copy.mp4")
+	assertNoError(t, f.WriteToFile(filePath, WithFileMode(0600)))
+	if fi, err := os.Stat(filePath); err != nil || fi.Size() != int64(init.Size()) || fi.Mode().Perm() != 0600 {
+		t.Errorf("file not written with size %d and mode 0600: %v %v", init.Size(), fi, err)
+	}
+
+	// Failed atomic write must neither create the file nor leave a temporary file
+	failPath := filepath.Join(dir, "fail.mp4")
+	err = WriteToFile(failingEncoder{}, failPath, WithAtomicRename())
+	assertError(t, err, "failing encode should give error")
+	entries, err := ioutil.ReadDir(dir)
+	assertNoError(t, err)
+	if len(entries) != 3 {
+		t.Errorf("got %d files instead of 3 after failed atomic write", len(entries))
+	}
+}
@@ -7,14 +7,17 @@ import (
 	"sort"
 )
 
-// Fragment - MP4 Fragment ([prft] + moof + mdat)
+// Fragment - MP4 Fragment ([prft] + [emsg] + moof + mdat)
 type Fragment struct {
-	Prft        *PrftBox
+	Prft        *PrftBox   // The first prft box
+	Prfts       []*PrftBox // All prft boxes, e.g. from encoder and packager
+	Emsgs       []*EmsgBox
 	Moof        *MoofBox
 	Mdat        *MdatBox
-	Children    []Box       // All top-level boxes in order
-	nextTrunNr  uint32      // To handle multi-trun cases
-	EncOptimize EncOptimize // Bit field with optimizations being done at encoding
+	Children    []Box        // All top-level boxes in order
+	nextTrunNr  uint32       // To handle multi-trun cases
+	auxInfoRefs []auxInfoRef // Sample auxiliary information added to mdat
+	EncOptimize EncOptimize  // Bit field with optimizations being done at encoding
 }
 
 // NewFragment - New empty one-track MP4 Fragment
@@ -70,7 +73,12 @@ func CreateMultiTrackFragment(seqNumber uint32, trackIDs []uint32) (*Fragment, e
 func (f *Fragment) AddChild(b Box) {
 	switch b.Type() {
 	case "prft":
-		f.Prft = b.(*PrftBox)
+		if f.Prft == nil {
+			f.Prft = b.(*PrftBox)
+		}
+		f.Prfts = append(f.Prfts, b.(*PrftBox))
+	case "emsg":
+		f.Emsgs = append(f.Emsgs, b.(*EmsgBox))
 	case "moof":
 		f.Moof = b.(*MoofBox)
 	case "mdat":
@@ -79,6 +87,48 @@ func (f *Fragment) AddChild(b Box) {
 	f.Children = append(f.Children, b)
 }
 
+// SetPrft - set prft box as first box of fragment, replacing all existing prft boxes
+func (f *Fragment) SetPrft(prft *PrftBox) {
+	for _, p := range f.Prfts {
+		f.Children = removeBox(f.Children, p)
+	}
+	f.Prft = prft
+	f.Prfts = []*PrftBox{prft}
+	f.Children = append([]Box{prft}, f.Children...)
+}
+
+// AddPrft - add prft box after any existing prft boxes, e.g. a packager prft after an encoder prft
+func (f *Fragment) AddPrft(prft *PrftBox) {
+	if f.Prft == nil {
+		f.Prft = prft
+	}
+	f.Prfts = append(f.Prfts, prft)
+	idx := 0
+	for i, c := range f.Children {
+		if c.Type() == "prft" {
+			idx = i + 1
+		}
+	}
+	f.Children = append(f.Children, nil)
+	copy(f.Children[idx+1:], f.Children[idx:])
+	f.Children[idx] = prft
+}
+
+// AddEmsg - add emsg box after any prft and emsg boxes, but before moof
+func (f *Fragment) AddEmsg(emsg *EmsgBox) {
+	f.Emsgs = append(f.Emsgs, emsg)
+	idx := len(f.Children)
+	for i, c := range f.Children {
+		if c.Type() == "moof" {
+			idx = i
+			break
+		}
+	}
+	f.Children = append(f.Children, nil)
+	copy(f.Children[idx+1:], f.Children[idx:])
+	f.Children[idx] = emsg
+}
+
 // Size - return size of fragment including all boxes.
 // Be aware that TrafBox.OptimizeTfhdTrun() can change size
 func (f *Fragment) Size() uint64 {
@@ -302,11 +352,34 @@ func (f *Fragment) SetTrunDataOffsets() {
 	sort.Slice(truns, func(i, j int) bool {
 		return truns[i].writeOrderNr < truns[j].writeOrderNr
 	})
-	dataOffset := f.Moof.Size() + f.Mdat.HeaderSize()
+	payloadOffset := f.mdatOffset() + f.Mdat.HeaderSize()
+	dataOffset := payloadOffset
 	for _, trun := range truns {
 		trun.DataOffset = int32(dataOffset)
 		dataOffset += trun.SizeOfData()
 	}
+	for _, ref := range f.auxInfoRefs {
+		ref.saio.Offset[0] = int64(payloadOffset + ref.offsetInMdat)
+	}
+	f.setSencSaioOffsets()
+}
+
+// mdatOffset - offset of mdat relative to moof start, including any free or skip boxes between them
+func (f *Fragment) mdatOffset() uint64 {
+	var offset uint64
+	inside := false
+	for _, c := range f.Children {
+		switch {
+		case c == f.Moof:
+			inside = true
+		case c == f.Mdat:
+			return offset
+		}
+		if inside {
+			offset += c.Size()
+		}
+	}
+	return f.Moof.Size()
 }
 
 // GetSampleNrFromTime - look up sample number from a specified time. Return error if no matching time
@@ -368,3 +441,237 @@ func (f *Fragment) AddSampleInterval(sItvl SampleInterval) error {
 	f.Mdat.AddSampleData(sItvl.Data)
 	return nil
 }
+
+// SetTrackID - change trackID in the tfhd boxes of the fragment from oldTrackID to newTrackID
+func (f *Fragment) SetTrackID(oldTrackID, newTrackID uint32) {
+	if f.Moof == nil {
+		return
+	}
+	for _, traf := range f.Moof.Trafs {
+		if traf.Tfhd.TrackID == oldTrackID {
+			traf.Tfhd.TrackID = newTrackID
+		}
+	}
+}
+
+// keepTracks - remove traf boxes and their sample data for tracks not in trackIDs.
+// Returns false if there is no track left. For a lazy mdat, only the traf boxes are removed.
+func (f *Fragment) keepTracks(trackIDs []uint32, trexs []*TrexBox) (tracksLeft bool, err error) {
+	moof := f.Moof
+	var keptTrafs []*TrafBox
+	for _, traf := range moof.Trafs {
+		if containsTrackID(trackIDs, traf.Tfhd.TrackID) {
+			keptTrafs = append(keptTrafs, traf)
+		}
+	}
+	if len(keptTrafs) == 0 {
+		return false, nil
+	}
+	if len(keptTrafs) == len(moof.Trafs) {
+		return true, nil
+	}
+	oldPositions := make(map[Box]uint64)
+	addMoofPositions(oldPositions, moof, moof.StartPos)
+	oldBases := make(map[*TrafBox]uint64, len(keptTrafs))
+	for _, traf := range keptTrafs {
+		oldBases[traf] = trafBaseOffset(moof, traf)
+	}
+
+	type trunRange struct {
+		trun   *TrunBox
+		offset uint64 // offset in mdat payload
+		size   uint64
+	}
+	var ranges []trunRange
+	mdat := f.Mdat
+	if mdat != nil && !mdat.IsLazy() {
+		mdatDataLength := uint64(len(mdat.Data))
+		for _, traf := range keptTrafs {
+			tfhd := traf.Tfhd
+			var trex *TrexBox
+			for _, tr := range trexs {
+				if tr.TrackID == tfhd.TrackID {
+					trex = tr
+					break
+				}
+			}
+			for _, trun := range traf.Truns {
+				trun.AddSampleDefaultValues(tfhd, trex)
+				var baseOffset uint64
+				if tfhd.HasBaseDataOffset() {
+					baseOffset = tfhd.BaseDataOffset
+				} else if tfhd.DefaultBaseIfMoof() {
+					baseOffset = moof.StartPos
+				}
+				if trun.HasDataOffset() {
+					baseOffset = uint64(int64(trun.DataOffset) + int64(baseOffset))
+				}
+				offsetInMdat := baseOffset - mdat.PayloadAbsoluteOffset()
+				size := trun.SizeOfData()
+				if offsetInMdat+size > mdatDataLength {
+					return false, fmt.Errorf("trun data for track %d beyond mdat size", tfhd.TrackID)
+				}
+				ranges = append(ranges, trunRange{trun, offsetInMdat, size})
+			}
+		}
+	}
+
+	moof.Traf = nil
+	moof.Trafs = nil
+	children := make([]Box, 0, len(moof.Children))
+	for _, child := range moof.Children {
+		if traf, ok := child.(*TrafBox); ok {
+			if !containsTrackID(trackIDs, traf.Tfhd.TrackID) {
+				continue
+			}
+			if moof.Traf == nil {
+				moof.Traf = traf
+			}
+			moof.Trafs = append(moof.Trafs, traf)
+		}
+		children = append(children, child)
+	}
+	moof.Children = children
+
+	if mdat == nil || mdat.IsLazy() {
+		shiftSaioOffsets(moof, oldPositions, oldBases)
+		return true, nil
+	}
+
+	// Data offsets are now relative to moof start, so base data offsets must be removed
+	for _, traf := range moof.Trafs {
+		tfhd := traf.Tfhd
+		if tfhd.HasBaseDataOffset() {
+			tfhd.Flags &= ^baseDataOffsetPresent
+			tfhd.BaseDataOffset = 0
+		}
+		tfhd.Flags |= defaultBaseIsMoof
+		for _, trun := range traf.Truns {
+			trun.flags |= dataOffsetPresentFlag
+		}
+	}
+	shiftSaioOffsets(moof, oldPositions, oldBases)
+
+	// Keep the data in the original order, but only the kept ranges
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].offset < ranges[j].offset
+	})
+	var totSize uint64
+	for _, r := range ranges {
+		totSize += r.size
+	}
+	data := make([]byte, 0, totSize)
+	moofSize := moof.Size()
+	mdat.StartPos = moof.StartPos + moofSize
+	dataOffset := moofSize + mdat.HeaderSize()
+	for i, r := range ranges {
+		data = append(data, mdat.Data[r.offset:r.offset+r.size]...)
+		r.trun.DataOffset = int32(dataOffset)
+		r.trun.writeOrderNr = uint32(i)
+		dataOffset += r.size
+	}
+	mdat.Data = data
+	return true, nil
+}
+
+// trafBaseOffset - absolute position that trun and saio offsets in traf are relative to
+func trafBaseOffset(moof *MoofBox, traf *TrafBox) uint64 {
+	if traf.Tfhd.HasBaseDataOffset() {
+		return traf.Tfhd.BaseDataOffset
+	}
+	return moof.StartPos
+}
+
+// shiftSaioOffsets - update saio offsets pointing into traf children (typically senc)
+// after moof has changed. oldPositions and oldBases are the box positions and offset bases before the change.
+func shiftSaioOffsets(moof *MoofBox, oldPositions map[Box]uint64, oldBases map[*TrafBox]uint64) {
+	newPositions := make(map[Box]uint64)
+	addMoofPositions(newPositions, moof, moof.StartPos)
+	for _, traf := range moof.Trafs {
+		oldBase, newBase := int64(oldBases[traf]), int64(trafBaseOffset(moof, traf))
+		for _, saio := range traf.Saios {
+			for i, offset := range saio.Offset {
+				oldAbs := oldBase + offset
+				for _, c := range traf.Children {
+					start, ok := oldPositions[c]
+					if ok && oldAbs >= int64(start) && oldAbs < int64(start+c.Size()) {
+						saio.Offset[i] = oldAbs + int64(newPositions[c]) - int64(start) - newBase
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// GetTrackFragmentInfos - get timing and size information for all trafs without using trex.
+func (f *Fragment) GetTrackFragmentInfos() []TrackFragmentInfo {
+	moof := f.Moof
+	infos := make([]TrackFragmentInfo, 0, len(moof.Trafs))
+	for _, traf := range moof.Trafs {
+		info := TrackFragmentInfo{
+			TrackID: traf.Tfhd.TrackID,
+		}
+		if moof.Mfhd != nil {
+			info.SequenceNumber = moof.Mfhd.SequenceNumber
+		}
+		if traf.Tfdt != nil {
+			info.BaseMediaDecodeTime = traf.Tfdt.BaseMediaDecodeTime
+		}
+		for _, trun := range traf.Truns {
+			info.Duration += trun.AddSampleDefaultValues(traf.Tfhd, nil)
+			info.NrSamples += trun.SampleCount()
+			info.DataSize += trun.SizeOfData()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// SubSampleRange - subsample with its byte offset relative to the start of the sample
+type SubSampleRange struct {
+	Offset uint32
+	SubsSample
+}
+
+// GetSubSampleRanges - subsample boundaries for the samples of track trackID given by the subs box in traf.
+// Element i corresponds to sample i+1 of the track fragment and is nil if that sample has no
+// subsample information. Sample sizes are resolved using trex, which may be nil.
+// nil is returned if the track has no traf or no subs box in this fragment.
+func (f *Fragment) GetSubSampleRanges(trackID uint32, trex *TrexBox) ([][]SubSampleRange, error) {
+	var traf *TrafBox
+	for _, tr := range f.Moof.Trafs {
+		if tr.Tfhd.TrackID == trackID {
+			traf = tr
+			break
+		}
+	}
+	if traf == nil || traf.Subs == nil {
+		return nil, nil
+	}
+	var sizes []uint32
+	for _, trun := range traf.Truns {
+		trun.AddSampleDefaultValues(traf.Tfhd, trex)
+		for _, s := range trun.Samples {
+			sizes = append(sizes, s.Size)
+		}
+	}
+	ranges := make([][]SubSampleRange, len(sizes))
+	var sampleNr uint32
+	for _, e := range traf.Subs.Entries {
+		sampleNr += e.SampleDelta
+		if sampleNr == 0 || int(sampleNr) > len(sizes) {
+			return nil, fmt.Errorf("subs: sample %d not in track fragment with %d samples", sampleNr, len(sizes))
+		}
+		var offset uint32
+		for _, ss := range e.SubSamples {
+			ranges[sampleNr-1] = append(ranges[sampleNr-1], SubSampleRange{Offset: offset, SubsSample: ss})
+			offset += ss.SubsampleSize
+		}
+		if offset > sizes[sampleNr-1] {
+			return nil, fmt.Errorf("subs: subsamples of sample %d have size %d > sample size %d",
+				sampleNr, offset, sizes[sampleNr-1])
+		}
+	}
+	return ranges, nil
+}
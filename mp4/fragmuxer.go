@@ -0,0 +1,265 @@
+package mp4
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FragmentMuxerConfig - configuration for FragmentMuxer
+type FragmentMuxerConfig struct {
+	FragmentDurationMS  uint32 // Duration of muxed fragments. Fragment boundaries are multiples of it
+	MaxSkewMS           uint32 // Max time a track may be ahead of a pending fragment before it is emitted without lagging tracks. 0 means no limit
+	StartSequenceNumber uint32
+}
+
+// muxQueue - queue of received samples for one track of FragmentMuxer
+type muxQueue struct {
+	muxTrack
+	samples []FullSample // Not yet emitted samples sorted by decode time
+	started bool         // True when next is valid
+	next    uint64       // Decode time where the next sample is expected
+	resync  bool         // True if a gap was skipped, so the next run of samples may start after next
+}
+
+// FragmentMuxer - mux single-track fragments or samples from live sources into multi-track fragments.
+// As for MuxFragments, source number i provides the samples of init.Moov.Traks[i], and the trackIDs
+// of the source fragments are not used. The fragments have one traf per track with samples in track order.
+//
+// Samples of each track are queued and sorted by decode time, so fragments may arrive out of order and
+// tracks may be skewed, e.g. with audio arriving later than video. A muxed fragment is emitted once all
+// tracks have contiguous samples up to its end. With MaxSkewMS set, a fragment is emitted without waiting
+// any longer when some track is more than MaxSkewMS ahead of its end. Samples of lagging tracks that arrive
+// after their part of the timeline has been emitted are dropped and counted in DroppedSamples.
+//
+// A track is not considered to have started until it has a sample before the end of the pending fragment,
+// so tracks starting later than the others are waited for, at most MaxSkewMS.
+type FragmentMuxer struct {
+	config         FragmentMuxerConfig
+	tracks         []*muxQueue
+	seqNr          uint32
+	started        bool
+	endNr          uint64 // The pending fragment ends at endNr * FragmentDurationMS
+	DroppedSamples int    // Number of samples that arrived too late to be muxed
+}
+
+// NewFragmentMuxer - create FragmentMuxer for the tracks of init, which is the init segment of the muxed output
+func NewFragmentMuxer(init *InitSegment, config FragmentMuxerConfig) (*FragmentMuxer, error) {
+	if config.FragmentDurationMS == 0 {
+		return nil, fmt.Errorf("fragment duration must be positive")
+	}
+	if init.Moov == nil || len(init.Moov.Traks) == 0 {
+		return nil, fmt.Errorf("no tracks in init segment")
+	}
+	m := &FragmentMuxer{config: config, seqNr: config.StartSequenceNumber}
+	for _, tr := range muxOutputTracks(init) {
+		m.tracks = append(m.tracks, &muxQueue{muxTrack: tr})
+	}
+	return m, nil
+}
+
+// AddFragment - queue the samples of a decoded single-track fragment from source sourceNr and
+// return the muxed fragments that are complete
+func (m *FragmentMuxer) AddFragment(sourceNr int, frag *Fragment) ([]*Fragment, error) {
+	tr, err := m.track(sourceNr)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := muxSourceSamples(frag, tr.trex)
+	if err != nil {
+		return nil, fmt.Errorf("source %d: %w", sourceNr, err)
+	}
+	tr.add(samples, &m.DroppedSamples)
+	return m.emit(false)
+}
+
+// AddSamples - queue samples from source sourceNr and return the muxed fragments that are complete
+func (m *FragmentMuxer) AddSamples(sourceNr int, samples []FullSample) ([]*Fragment, error) {
+	tr, err := m.track(sourceNr)
+	if err != nil {
+		return nil, err
+	}
+	tr.add(samples, &m.DroppedSamples)
+	return m.emit(false)
+}
+
+// Flush - return muxed fragments with all queued samples, e.g. at the end of the stream
+func (m *FragmentMuxer) Flush() ([]*Fragment, error) {
+	return m.emit(true)
+}
+
+// track - queue of source sourceNr
+func (m *FragmentMuxer) track(sourceNr int) (*muxQueue, error) {
+	if sourceNr < 0 || sourceNr >= len(m.tracks) {
+		return nil, fmt.Errorf("source %d not in range [0, %d)", sourceNr, len(m.tracks))
+	}
+	return m.tracks[sourceNr], nil
+}
+
+// add - insert samples in decode time order. Late and duplicate samples are dropped.
+func (tr *muxQueue) add(samples []FullSample, dropped *int) {
+	for _, s := range samples {
+		if tr.started && s.DecodeTime < tr.next {
+			*dropped++
+			continue
+		}
+		i := sort.Search(len(tr.samples), func(i int) bool { return tr.samples[i].DecodeTime >= s.DecodeTime })
+		if i < len(tr.samples) && tr.samples[i].DecodeTime == s.DecodeTime {
+			*dropped++
+			continue
+		}
+		tr.samples = append(tr.samples, FullSample{})
+		copy(tr.samples[i+1:], tr.samples[i:])
+		tr.samples[i] = s
+	}
+}
+
+// coveredUntil - end of the contiguous run of queued samples from where the next sample is expected.
+// ok is false if the track has not started and has no samples.
+func (tr *muxQueue) coveredUntil() (end uint64, ok bool) {
+	if !tr.started && len(tr.samples) == 0 {
+		return 0, false
+	}
+	end = tr.next
+	if len(tr.samples) > 0 && (!tr.started || (tr.resync && tr.samples[0].DecodeTime > end)) {
+		end = tr.samples[0].DecodeTime
+	}
+	for _, s := range tr.samples {
+		if s.DecodeTime > end {
+			break
+		}
+		if sEnd := s.DecodeTime + uint64(s.Dur); sEnd > end {
+			end = sEnd
+		}
+	}
+	return end, true
+}
+
+// covers - true if the track has contiguous samples up to boundary. A track that has not started,
+// or that has skipped a gap, must have a sample before boundary.
+func (tr *muxQueue) covers(boundary uint64) bool {
+	end, ok := tr.coveredUntil()
+	if !ok || end < boundary {
+		return false
+	}
+	if tr.started && !tr.resync {
+		return true
+	}
+	return len(tr.samples) > 0 && tr.samples[0].DecodeTime < boundary
+}
+
+// emit - create muxed fragments for all complete intervals, or for all queued samples if flush is set
+func (m *FragmentMuxer) emit(flush bool) ([]*Fragment, error) {
+	var frags []*Fragment
+	for {
+		if !m.started {
+			firstMS, ok := m.firstSampleMS()
+			if !ok {
+				return frags, nil
+			}
+			m.endNr = firstMS/uint64(m.config.FragmentDurationMS) + 1
+		}
+		endMS := m.endNr * uint64(m.config.FragmentDurationMS)
+		if flush {
+			if !m.hasSamples() {
+				return frags, nil
+			}
+		} else if !m.intervalReady(endMS) {
+			return frags, nil
+		}
+		frag, err := m.muxInterval(endMS)
+		if err != nil {
+			return nil, err
+		}
+		m.started = true
+		m.endNr++
+		if frag != nil {
+			frags = append(frags, frag)
+		} else if firstMS, ok := m.firstSampleMS(); ok {
+			// Skip empty intervals in gaps
+			if nr := firstMS/uint64(m.config.FragmentDurationMS) + 1; nr > m.endNr {
+				m.endNr = nr
+			}
+		}
+	}
+}
+
+// intervalReady - true if all tracks cover the interval ending at endMS, or some track is too far ahead
+func (m *FragmentMuxer) intervalReady(endMS uint64) bool {
+	allCovered := true
+	var maxAheadMS uint64
+	for _, tr := range m.tracks {
+		if !tr.covers(scaleTime(endMS, 1000, tr.timescale)) {
+			allCovered = false
+		}
+		end, ok := tr.coveredUntil()
+		if trackEndMS := scaleTime(end, tr.timescale, 1000); ok && trackEndMS > endMS+maxAheadMS {
+			maxAheadMS = trackEndMS - endMS
+		}
+	}
+	if allCovered {
+		return true
+	}
+	return m.config.MaxSkewMS > 0 && maxAheadMS > uint64(m.config.MaxSkewMS)
+}
+
+// muxInterval - create a fragment with the queued samples before endMS. Returns nil if there are none.
+func (m *FragmentMuxer) muxInterval(endMS uint64) (*Fragment, error) {
+	tracks := make([]muxTrack, len(m.tracks))
+	trackSamples := make([][]FullSample, len(m.tracks))
+	nrSamples := 0
+	for i, tr := range m.tracks {
+		tracks[i] = tr.muxTrack
+		boundary := scaleTime(endMS, 1000, tr.timescale)
+		covered := tr.covers(boundary)
+		n := sort.Search(len(tr.samples), func(i int) bool { return tr.samples[i].DecodeTime >= boundary })
+		if n > 0 {
+			trackSamples[i] = tr.samples[:n]
+			nrSamples += n
+			last := tr.samples[n-1]
+			tr.next = last.DecodeTime + uint64(last.Dur)
+			tr.samples = tr.samples[n:]
+		}
+		if !covered && tr.next < boundary {
+			tr.next = boundary
+			tr.resync = true
+		} else if covered {
+			tr.resync = false
+		}
+		tr.started = true
+	}
+	if nrSamples == 0 {
+		return nil, nil
+	}
+	frag, err := createMuxedFragment(m.seqNr, tracks, trackSamples)
+	if err != nil {
+		return nil, err
+	}
+	m.seqNr++
+	return frag, nil
+}
+
+// firstSampleMS - earliest queued decode time in milliseconds
+func (m *FragmentMuxer) firstSampleMS() (uint64, bool) {
+	var first uint64
+	found := false
+	for _, tr := range m.tracks {
+		if len(tr.samples) == 0 {
+			continue
+		}
+		ms := scaleTime(tr.samples[0].DecodeTime, tr.timescale, 1000)
+		if !found || ms < first {
+			first = ms
+			found = true
+		}
+	}
+	return first, found
+}
+
+func (m *FragmentMuxer) hasSamples() bool {
+	for _, tr := range m.tracks {
+		if len(tr.samples) > 0 {
+			return true
+		}
+	}
+	return false
+}
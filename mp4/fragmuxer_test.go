@@ -0,0 +1,110 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+// muxTestFragments - samples of nrSecs one-second source fragments
+func muxTestFragments(nrSecs int, timescale, dur uint32, marker byte) [][]FullSample {
+	perSec := int(timescale / dur)
+	samples := createProgTestSamples(nrSecs*perSec, dur, 10, perSec, marker)
+	frags := make([][]FullSample, nrSecs)
+	for i := range frags {
+		frags[i] = samples[i*perSec : (i+1)*perSec]
+	}
+	return frags
+}
+
+func muxTestInit() *InitSegment {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(48000, "audio", "und")
+	return init
+}
+
+func TestFragmentMuxerOutOfOrder(t *testing.T) {
+	video := muxTestFragments(3, 90000, 3600, 0x10)
+	audio := muxTestFragments(3, 48000, 960, 0x80)
+	m, err := NewFragmentMuxer(muxTestInit(), FragmentMuxerConfig{FragmentDurationMS: 1000, StartSequenceNumber: 1})
+	assertNoError(t, err)
+	var out []*Fragment
+	add := func(sourceNr int, samples []FullSample) {
+		t.Helper()
+		frags, err := m.AddSamples(sourceNr, samples)
+		assertNoError(t, err)
+		out = append(out, frags...)
+	}
+	for _, a := range audio {
+		add(1, a)
+	}
+	add(0, video[1])
+	if len(out) != 0 {
+		t.Fatalf("got %d fragments before first video fragment", len(out))
+	}
+	add(0, video[0])
+	if len(out) != 2 {
+		t.Fatalf("got %d fragments after out-of-order video, wanted 2", len(out))
+	}
+	frags, err := m.Flush()
+	assertNoError(t, err)
+	out = append(out, frags...)
+	if len(out) != 3 {
+		t.Fatalf("got %d fragments after flush, wanted 3", len(out))
+	}
+	wantedNrSamples := [][]int{{25, 50}, {25, 50}, {50}}
+	for i, frag := range out {
+		if seqNr := frag.Moof.Mfhd.SequenceNumber; seqNr != uint32(i+1) {
+			t.Errorf("fragment %d: sequence number %d", i, seqNr)
+		}
+		if len(frag.Moof.Trafs) != len(wantedNrSamples[i]) {
+			t.Fatalf("fragment %d: %d trafs instead of %d", i, len(frag.Moof.Trafs), len(wantedNrSamples[i]))
+		}
+		for j, traf := range frag.Moof.Trafs {
+			if nr := traf.Trun.SampleCount(); nr != uint32(wantedNrSamples[i][j]) {
+				t.Errorf("fragment %d traf %d: %d samples instead of %d", i, j, nr, wantedNrSamples[i][j])
+			}
+		}
+	}
+	var buf bytes.Buffer
+	for _, frag := range out {
+		assertNoError(t, frag.Encode(&buf))
+	}
+	if bmdt := out[1].Moof.Trafs[0].Tfdt.BaseMediaDecodeTime; bmdt != 90000 {
+		t.Errorf("second video tfdt %d instead of 90000", bmdt)
+	}
+	add(0, video[0])
+	if m.DroppedSamples != 25 {
+		t.Errorf("%d dropped samples instead of 25", m.DroppedSamples)
+	}
+}
+
+func TestFragmentMuxerMaxSkew(t *testing.T) {
+	video := muxTestFragments(3, 90000, 3600, 0x10)
+	audio := muxTestFragments(3, 48000, 960, 0x80)
+	m, err := NewFragmentMuxer(muxTestInit(), FragmentMuxerConfig{FragmentDurationMS: 1000, MaxSkewMS: 1500})
+	assertNoError(t, err)
+	var out []*Fragment
+	for _, v := range video {
+		frags, err := m.AddSamples(0, v)
+		assertNoError(t, err)
+		out = append(out, frags...)
+	}
+	if len(out) != 1 || len(out[0].Moof.Trafs) != 1 || out[0].Moof.Traf.Tfhd.TrackID != 1 {
+		t.Fatalf("wanted one video-only fragment when audio lags more than max skew")
+	}
+	for _, a := range audio {
+		frags, err := m.AddSamples(1, a)
+		assertNoError(t, err)
+		out = append(out, frags...)
+	}
+	if m.DroppedSamples != 50 {
+		t.Errorf("%d dropped audio samples instead of 50", m.DroppedSamples)
+	}
+	if len(out) != 3 || len(out[1].Moof.Trafs) != 2 || len(out[2].Moof.Trafs) != 2 {
+		t.Fatalf("wanted two more fragments with both tracks, got %d fragments", len(out))
+	}
+	if bmdt := out[1].Moof.Trafs[1].Tfdt.BaseMediaDecodeTime; bmdt != 48000 {
+		t.Errorf("audio tfdt %d instead of 48000", bmdt)
+	}
+}
@@ -11,6 +11,11 @@ type FreeBox struct {
 	notDecoded []byte
 }
 
+// CreateFreeBox - create free box with payloadSize zero bytes
+func CreateFreeBox(payloadSize int) *FreeBox {
+	return &FreeBox{Name: "free", notDecoded: make([]byte, payloadSize)}
+}
+
 // DecodeFree - box-specific decode
 func DecodeFree(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	data, err := ioutil.ReadAll(r)
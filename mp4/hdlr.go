@@ -1,6 +1,7 @@
 package mp4
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -22,6 +23,7 @@ type HdlrBox struct {
 	HandlerType          string
 	Name                 string // Null-terminated UTF-8 string according to ISO/IEC 14496-12 Sec. 8.4.3.3
 	LacksNullTermination bool   // This should be true, but we allow false as well
+	padding              []byte // Bytes after null termination, kept for exact round-trip
 }
 
 // CreateHdlr - create mediaType-specific hdlr box
@@ -34,7 +36,7 @@ func CreateHdlr(mediaOrHdlrType string) (*HdlrBox, error) {
 	case "audio", "soun":
 		hdlr.HandlerType = "soun"
 		hdlr.Name = "mp4ff audio handler"
-	case "subtitle", "subt":
+	case "subtitle", "subt", "stpp":
 		hdlr.HandlerType = "subt"
 		hdlr.Name = "mp4ff subtitle handler"
 	case "text", "wvtt":
@@ -54,6 +56,23 @@ func CreateHdlr(mediaOrHdlrType string) (*HdlrBox, error) {
 	return hdlr, nil
 }
 
+// CreateHdlrWithName - create mediaType-specific hdlr box with a human-readable handler name
+func CreateHdlrWithName(mediaOrHdlrType, name string) (*HdlrBox, error) {
+	hdlr, err := CreateHdlr(mediaOrHdlrType)
+	if err != nil {
+		return nil, err
+	}
+	hdlr.SetName(name)
+	return hdlr, nil
+}
+
+// SetName - set handler name. It will be null-terminated without padding when encoded
+func (b *HdlrBox) SetName(name string) {
+	b.Name = name
+	b.LacksNullTermination = false
+	b.padding = nil
+}
+
 // DecodeHdlr - box-specific decode
 func DecodeHdlr(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	data, err := ioutil.ReadAll(r)
@@ -69,13 +88,18 @@ func DecodeHdlr(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 		HandlerType: string(data[8:12]),
 	}
 	if len(data) > 24 {
-		endPoint := len(data) - 1
-		lastChar := data[endPoint]
-		if lastChar != 0 {
-			endPoint++
+		// The name ends at the first null byte. Some encoders pad with extra bytes after it
+		nameBytes := data[24:]
+		nullPos := bytes.IndexByte(nameBytes, 0)
+		if nullPos < 0 {
+			h.Name = string(nameBytes)
 			h.LacksNullTermination = true
+		} else {
+			h.Name = string(nameBytes[:nullPos])
+			if nullPos < len(nameBytes)-1 {
+				h.padding = nameBytes[nullPos+1:]
+			}
 		}
-		h.Name = string(data[24:endPoint])
 	} else {
 		h.LacksNullTermination = true
 	}
@@ -89,7 +113,7 @@ func (b *HdlrBox) Type() string {
 
 // Size - calculated size of box
 func (b *HdlrBox) Size() uint64 {
-	size := uint64(boxHeaderSize + 24 + len(b.Name) + 1)
+	size := uint64(boxHeaderSize + 24 + len(b.Name) + 1 + len(b.padding))
 	if b.LacksNullTermination {
 		size--
 	}
@@ -109,7 +133,8 @@ func (b *HdlrBox) Encode(w io.Writer) error {
 	strtobuf(buf[8:], b.HandlerType, 4)
 	strtobuf(buf[24:], b.Name, len(b.Name))
 	if !b.LacksNullTermination {
-		buf[len(buf)-1] = 0 // null-termination of string
+		buf[24+len(b.Name)] = 0 // null-termination of string
+		copy(buf[24+len(b.Name)+1:], b.padding)
 	}
 	_, err = w.Write(buf)
 	return err
@@ -120,5 +145,8 @@ func (b *HdlrBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
 	bd.write(" - handlerType: %s", b.HandlerType)
 	bd.write(" - handlerName: %q", b.Name)
+	if len(b.padding) > 0 {
+		bd.write(" - paddingAfterName: %d bytes", len(b.padding))
+	}
 	return bd.err
 }
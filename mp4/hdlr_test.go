@@ -39,3 +39,32 @@ func TestHdlrDecodeMissingNullTermination(t *testing.T) {
 		t.Errorf("Expected empty name, but got %s", hdlr.Name)
 	}
 }
+
+func TestHdlrName(t *testing.T) {
+	hdlr, err := CreateHdlrWithName("video", "My Video Handler")
+	assertNoError(t, err)
+	if hdlr.Name != "My Video Handler" || hdlr.HandlerType != "vide" {
+		t.Errorf("got name %q and type %s", hdlr.Name, hdlr.HandlerType)
+	}
+	boxDiffAfterEncodeAndDecode(t, hdlr)
+
+	// Name padded with extra null bytes and spaces after the null termination
+	hdlrPadded := "0000002b68646c720000000000000000766964650000000000000000000000005669646500000020202000"
+	byteData, _ := hex.DecodeString(hdlrPadded)
+	box, err := DecodeBox(0, bytes.NewBuffer(byteData))
+	assertNoError(t, err)
+	hdlr = box.(*HdlrBox)
+	if hdlr.Name != "Vide" {
+		t.Errorf("got name %q instead of %q", hdlr.Name, "Vide")
+	}
+	buf := bytes.Buffer{}
+	err = hdlr.Encode(&buf)
+	assertNoError(t, err)
+	if !bytes.Equal(buf.Bytes(), byteData) {
+		t.Errorf("padded hdlr not preserved on round trip")
+	}
+	hdlr.SetName("Video")
+	if hdlr.Size() != 8+24+6 {
+		t.Errorf("got size %d after SetName", hdlr.Size())
+	}
+}
@@ -72,6 +72,7 @@ func (b *HvcCBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	bd.write(" - ConstantFrameRate: %d", hdcr.ConstantFrameRate)
 	bd.write(" - NumTemporalLayers: %d", hdcr.NumTemporalLayers)
 	bd.write(" - temporalIDNested: %d", hdcr.TemporalIDNested)
+	bd.write(" - NALULengthSize: %d", hdcr.NALULengthSize())
 	for _, array := range hdcr.NaluArrays {
 		bd.write("   - %s complete: %d", array.NaluType(), array.Complete())
 		for _, nalu := range array.Nalus {
@@ -0,0 +1,281 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// ID3EmsgSchemeIDURI - emsg scheme for ID3 timed metadata as used by Apple HLS with fragmented MP4,
+// see https://aomedia.org/emsg/ID3
+const ID3EmsgSchemeIDURI = "https://aomedia.org/emsg/ID3"
+
+// id3HeaderSize - size of ID3v2 tag and frame headers
+const id3HeaderSize = 10
+
+// ID3Frame - ID3v2.4 frame with 4-character ID and raw frame data
+type ID3Frame struct {
+	ID   string
+	Data []byte
+}
+
+// ID3 text encodings
+const (
+	id3EncodingISO88591 = 0
+	id3EncodingUTF16BOM = 1
+	id3EncodingUTF16BE  = 2
+	id3EncodingUTF8     = 3
+)
+
+func (fr ID3Frame) String() string {
+	switch {
+	case fr.ID == "TXXX":
+		if desc, value, err := fr.Txxx(); err == nil {
+			return fmt.Sprintf("TXXX: %s=%q", desc, value)
+		}
+	case fr.ID == "PRIV":
+		if owner, data, err := fr.Priv(); err == nil {
+			return fmt.Sprintf("PRIV: %s (%d bytes)", owner, len(data))
+		}
+	case strings.HasPrefix(fr.ID, "T"):
+		if text, err := fr.Text(); err == nil {
+			return fmt.Sprintf("%s: %q", fr.ID, text)
+		}
+	}
+	return fmt.Sprintf("%s (%d bytes)", fr.ID, len(fr.Data))
+}
+
+// Text - text of a text information frame like TIT2 or TPE1. Several strings are separated by "/"
+func (fr ID3Frame) Text() (string, error) {
+	if !strings.HasPrefix(fr.ID, "T") || fr.ID == "TXXX" {
+		return "", fmt.Errorf("ID3 frame %s is not a text information frame", fr.ID)
+	}
+	if len(fr.Data) == 0 {
+		return "", fmt.Errorf("ID3 frame %s: no text encoding", fr.ID)
+	}
+	strs, err := splitID3Strings(fr.Data[0], fr.Data[1:], -1)
+	if err != nil {
+		return "", err
+	}
+	for len(strs) > 1 && strs[len(strs)-1] == "" {
+		strs = strs[:len(strs)-1]
+	}
+	return strings.Join(strs, "/"), nil
+}
+
+// Txxx - description and value of a TXXX user-defined text frame
+func (fr ID3Frame) Txxx() (description, value string, err error) {
+	if fr.ID != "TXXX" || len(fr.Data) == 0 {
+		return "", "", fmt.Errorf("ID3 frame %s is not a TXXX frame", fr.ID)
+	}
+	strs, err := splitID3Strings(fr.Data[0], fr.Data[1:], 2)
+	if err != nil {
+		return "", "", err
+	}
+	if len(strs) < 2 {
+		return "", "", fmt.Errorf("ID3 frame TXXX: no value")
+	}
+	return strs[0], strings.TrimRight(strs[1], "\x00"), nil
+}
+
+// Priv - owner identifier and private data of a PRIV frame
+func (fr ID3Frame) Priv() (owner string, data []byte, err error) {
+	if fr.ID != "PRIV" {
+		return "", nil, fmt.Errorf("ID3 frame %s is not a PRIV frame", fr.ID)
+	}
+	nullPos := bytes.IndexByte(fr.Data, 0)
+	if nullPos < 0 {
+		return "", nil, fmt.Errorf("ID3 frame PRIV: owner not null-terminated")
+	}
+	return string(fr.Data[:nullPos]), fr.Data[nullPos+1:], nil
+}
+
+// splitID3Strings - split null-terminated strings with text encoding into at most n strings (all if n < 0)
+func splitID3Strings(encoding byte, data []byte, n int) ([]string, error) {
+	var parts [][]byte
+	switch encoding {
+	case id3EncodingISO88591, id3EncodingUTF8:
+		parts = bytes.SplitN(data, []byte{0}, n)
+	case id3EncodingUTF16BOM, id3EncodingUTF16BE:
+		start := 0
+		for i := 0; i+1 < len(data); i += 2 {
+			if n >= 0 && len(parts) == n-1 {
+				break
+			}
+			if data[i] == 0 && data[i+1] == 0 {
+				parts = append(parts, data[start:i])
+				start = i + 2
+			}
+		}
+		parts = append(parts, data[start:])
+	default:
+		return nil, fmt.Errorf("ID3 text encoding %d not supported", encoding)
+	}
+	strs := make([]string, len(parts))
+	for i, p := range parts {
+		strs[i] = decodeID3String(encoding, p)
+	}
+	return strs, nil
+}
+
+// decodeID3String - convert one string with text encoding to UTF-8
+func decodeID3String(encoding byte, data []byte) string {
+	switch encoding {
+	case id3EncodingISO88591:
+		runes := make([]rune, len(data))
+		for i, c := range data {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	case id3EncodingUTF16BOM, id3EncodingUTF16BE:
+		var order binary.ByteOrder = binary.BigEndian
+		if encoding == id3EncodingUTF16BOM && len(data) >= 2 {
+			switch {
+			case data[0] == 0xff && data[1] == 0xfe:
+				order = binary.LittleEndian
+				data = data[2:]
+			case data[0] == 0xfe && data[1] == 0xff:
+				data = data[2:]
+			}
+		}
+		u16 := make([]uint16, len(data)/2)
+		for i := range u16 {
+			u16[i] = order.Uint16(data[2*i:])
+		}
+		return string(utf16.Decode(u16))
+	default:
+		return string(data)
+	}
+}
+
+// CreateID3PrivFrame - create PRIV frame with owner identifier and private data
+func CreateID3PrivFrame(owner string, data []byte) ID3Frame {
+	frameData := make([]byte, 0, len(owner)+1+len(data))
+	frameData = append(frameData, owner...)
+	frameData = append(frameData, 0)
+	frameData = append(frameData, data...)
+	return ID3Frame{ID: "PRIV", Data: frameData}
+}
+
+// CreateID3TxxxFrame - create TXXX user-defined text frame with UTF-8 description and value
+func CreateID3TxxxFrame(description, value string) ID3Frame {
+	frameData := make([]byte, 0, 1+len(description)+1+len(value))
+	frameData = append(frameData, 3) // UTF-8 text encoding
+	frameData = append(frameData, description...)
+	frameData = append(frameData, 0)
+	frameData = append(frameData, value...)
+	return ID3Frame{ID: "TXXX", Data: frameData}
+}
+
+// EncodeID3Tag - encode frames as an ID3v2.4 tag without extended header, padding, or footer
+func EncodeID3Tag(frames []ID3Frame) ([]byte, error) {
+	size := 0
+	for _, fr := range frames {
+		if len(fr.ID) != 4 {
+			return nil, fmt.Errorf("ID3 frame ID %q does not have 4 characters", fr.ID)
+		}
+		size += id3HeaderSize + len(fr.Data)
+	}
+	if size >= 1<<28 {
+		return nil, fmt.Errorf("ID3 tag size %d too big", size)
+	}
+	buf := make([]byte, id3HeaderSize+size)
+	sw := NewSliceWriter(buf)
+	sw.WriteString("ID3", false)
+	sw.WriteUint16(0x0400) // Version 2.4.0
+	sw.WriteUint8(0)       // Flags
+	sw.WriteUint32(syncSafe(uint32(size)))
+	for _, fr := range frames {
+		sw.WriteString(fr.ID, false)
+		sw.WriteUint32(syncSafe(uint32(len(fr.Data))))
+		sw.WriteUint16(0) // Flags
+		sw.WriteBytes(fr.Data)
+	}
+	return buf, nil
+}
+
+// DecodeID3Tag - decode frames of an ID3v2.3 or ID3v2.4 tag. An extended header is skipped.
+// Tags with unsynchronisation are not supported.
+func DecodeID3Tag(data []byte) ([]ID3Frame, error) {
+	if len(data) < id3HeaderSize || !bytes.Equal(data[:3], []byte("ID3")) {
+		return nil, fmt.Errorf("no ID3 tag header")
+	}
+	sr := NewSliceReader(data)
+	sr.SkipBytes(3)
+	majorVersion := sr.ReadUint16() >> 8
+	if majorVersion != 3 && majorVersion != 4 {
+		return nil, fmt.Errorf("ID3 version 2.%d not supported", majorVersion)
+	}
+	flags := sr.ReadUint8()
+	if flags&0x80 != 0 {
+		return nil, fmt.Errorf("ID3 unsynchronisation not supported")
+	}
+	size := int(unSyncSafe(sr.ReadUint32()))
+	end := id3HeaderSize + size
+	if end > len(data) {
+		return nil, fmt.Errorf("ID3 tag size %d beyond data", size)
+	}
+	// frameSize - ID3v2.4 frame sizes are sync-safe, but ID3v2.3 sizes are plain 32-bit integers
+	frameSize := func(b []byte) int {
+		n := binary.BigEndian.Uint32(b)
+		if majorVersion == 4 {
+			n = unSyncSafe(n)
+		}
+		return int(n)
+	}
+	pos := id3HeaderSize
+	if flags&0x40 != 0 { // Extended header
+		if pos+4 > end {
+			return nil, fmt.Errorf("ID3 extended header beyond tag")
+		}
+		extSize := frameSize(data[pos : pos+4])
+		if majorVersion == 3 {
+			extSize += 4 // Size excludes itself in ID3v2.3
+		}
+		pos += extSize
+	}
+	var frames []ID3Frame
+	for pos+id3HeaderSize <= end {
+		if data[pos] == 0 { // Padding
+			break
+		}
+		size := frameSize(data[pos+4 : pos+8])
+		frameEnd := pos + id3HeaderSize + size
+		if size < 0 || frameEnd > end {
+			return nil, fmt.Errorf("ID3 frame %s size %d beyond tag", string(data[pos:pos+4]), size)
+		}
+		frames = append(frames, ID3Frame{ID: string(data[pos : pos+4]), Data: data[pos+id3HeaderSize : frameEnd]})
+		pos = frameEnd
+	}
+	return frames, nil
+}
+
+// CreateID3Emsg - create version 1 emsg with ID3 tag at presentation time presTime in timescale.
+// The box can be added to an EmsgCarrier, which outputs it in the fragment covering presTime.
+func CreateID3Emsg(id, timescale uint32, presTime uint64, duration uint32, frames []ID3Frame) (*EmsgBox, error) {
+	tag, err := EncodeID3Tag(frames)
+	if err != nil {
+		return nil, err
+	}
+	return &EmsgBox{
+		Version:          1,
+		TimeScale:        timescale,
+		PresentationTime: presTime,
+		EventDuration:    duration,
+		ID:               id,
+		SchemeIDURI:      ID3EmsgSchemeIDURI,
+		MessageData:      tag,
+	}, nil
+}
+
+// syncSafe - ID3 28-bit integer with 7 bits per byte
+func syncSafe(n uint32) uint32 {
+	return (n & 0x7f) | (n&0x3f80)<<1 | (n&0x1fc000)<<2 | (n&0xfe00000)<<3
+}
+
+// unSyncSafe - inverse of syncSafe
+func unSyncSafe(n uint32) uint32 {
+	return (n & 0x7f) | (n&0x7f00)>>1 | (n&0x7f0000)>>2 | (n&0x7f000000)>>3
+}
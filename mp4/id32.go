@@ -0,0 +1,145 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// ID32HandlerType - handler type of meta boxes with ID32 boxes
+const ID32HandlerType = "ID32"
+
+// ID32Box - ID3v2 Box (ID32) as registered by MP4RA
+//
+// Contained in : Meta Box (meta) with handler type ID32
+//
+// Carries a complete ID3v2 tag. Language is packed ISO-639-2/T as in mdhd.
+type ID32Box struct {
+	Version   byte
+	Flags     uint32
+	Language  uint16
+	ID3v2Data []byte
+}
+
+// CreateID32 - create ID32 box with language and ID3v2 tag
+func CreateID32(language string, tag []byte) *ID32Box {
+	b := &ID32Box{ID3v2Data: tag}
+	b.SetLanguage(language)
+	return b
+}
+
+// CreateID32Meta - create meta box with ID32 handler and an ID32 box with the ID3v2 tag, e.g. to add to udta
+func CreateID32Meta(language string, tag []byte) (*MetaBox, error) {
+	hdlr, err := CreateHdlr(ID32HandlerType)
+	if err != nil {
+		return nil, err
+	}
+	meta := CreateMetaBox(0, hdlr)
+	meta.AddChild(CreateID32(language, tag))
+	return meta, nil
+}
+
+// DecodeID32 - box-specific decode
+func DecodeID32(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 6 {
+		return nil, fmt.Errorf("ID32: too short: %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
+	b := &ID32Box{
+		Version:  byte(versionAndFlags >> 24),
+		Flags:    versionAndFlags & flagsMask,
+		Language: s.ReadUint16() & 0x7fff,
+	}
+	b.ID3v2Data = s.ReadBytes(s.NrRemainingBytes())
+	return b, nil
+}
+
+// Type - box type
+func (b *ID32Box) Type() string {
+	return "ID32"
+}
+
+// Size - calculated size of box
+func (b *ID32Box) Size() uint64 {
+	return uint64(boxHeaderSize + 6 + len(b.ID3v2Data))
+}
+
+// Encode - write box to w
+func (b *ID32Box) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
+	sw.WriteUint32(versionAndFlags)
+	sw.WriteUint16(b.Language & 0x7fff)
+	sw.WriteBytes(b.ID3v2Data)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information. Level 1 lists the ID3 frames
+func (b *ID32Box) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	bd.write(" - language: %s", b.GetLanguage())
+	bd.write(" - ID3v2 tag: %d bytes", len(b.ID3v2Data))
+	level := getInfoLevel(b, specificBoxLevels)
+	if level >= 1 {
+		frames, err := b.Frames()
+		if err != nil {
+			bd.write(" - frames: %s", err)
+			return bd.err
+		}
+		for _, fr := range frames {
+			bd.write(" - frame %s", fr)
+		}
+	}
+	return bd.err
+}
+
+// GetLanguage - Get three-byte language string
+func (b *ID32Box) GetLanguage() string {
+	l1 := (b.Language >> 10) & 0x1f
+	l2 := (b.Language >> 5) & 0x1f
+	l3 := b.Language & 0x1f
+	return fmt.Sprintf("%c%c%c", l1+charOffset, l2+charOffset, l3+charOffset)
+}
+
+// SetLanguage - Set three-byte language string
+func (b *ID32Box) SetLanguage(lang string) {
+	var l uint16 = 0
+	for i, c := range lang {
+		l += uint16(((c - charOffset) & 0x1f) << (5 * (2 - i)))
+	}
+	b.Language = l
+}
+
+// Frames - decode the frames of the ID3v2 tag
+func (b *ID32Box) Frames() ([]ID3Frame, error) {
+	return DecodeID3Tag(b.ID3v2Data)
+}
+
+// GetID32Boxes - all ID32 boxes in the meta boxes of the file, moov, and tracks, including those in udta
+func (f *File) GetID32Boxes() []*ID32Box {
+	var boxes []*ID32Box
+	var find func(children []Box)
+	find = func(children []Box) {
+		for _, c := range children {
+			switch box := c.(type) {
+			case *ID32Box:
+				boxes = append(boxes, box)
+			case *MetaBox, *UdtaBox, *MoovBox, *TrakBox:
+				find(box.(ContainerBox).GetChildren())
+			}
+		}
+	}
+	find(f.Children)
+	return boxes
+}
@@ -0,0 +1,51 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestID32(t *testing.T) {
+	tag, err := EncodeID3Tag([]ID3Frame{CreateID3TxxxFrame("com.example.id", "1234")})
+	assertNoError(t, err)
+	id32 := CreateID32("swe", tag)
+	if id32.GetLanguage() != "swe" {
+		t.Errorf("got language %q instead of swe", id32.GetLanguage())
+	}
+	boxDiffAfterEncodeAndDecode(t, id32)
+
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	meta, err := CreateID32Meta("und", tag)
+	assertNoError(t, err)
+	udta := &UdtaBox{}
+	udta.AddChild(meta)
+	init.Moov.AddChild(udta)
+	var buf bytes.Buffer
+	err = init.Encode(&buf)
+	assertNoError(t, err)
+
+	f, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	id32s := f.GetID32Boxes()
+	if len(id32s) != 1 {
+		t.Fatalf("got %d ID32 boxes instead of 1", len(id32s))
+	}
+	if f.Moov.Udta.Meta.Hdlr.HandlerType != ID32HandlerType {
+		t.Errorf("got handler type %q", f.Moov.Udta.Meta.Hdlr.HandlerType)
+	}
+	frames, err := id32s[0].Frames()
+	assertNoError(t, err)
+	desc, value, err := frames[0].Txxx()
+	assertNoError(t, err)
+	if desc != "com.example.id" || value != "1234" {
+		t.Errorf("got TXXX %s=%s", desc, value)
+	}
+
+	var info bytes.Buffer
+	err = id32s[0].Info(&info, "ID32:1", "", "  ")
+	assertNoError(t, err)
+	if !bytes.Contains(info.Bytes(), []byte(`frame TXXX: com.example.id="1234"`)) {
+		t.Errorf("frame not in info: %s", info.String())
+	}
+}
@@ -0,0 +1,96 @@
+package mp4
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestID3Tag(t *testing.T) {
+	frames := []ID3Frame{
+		CreateID3PrivFrame("com.example.priv", bytes.Repeat([]byte{0xff}, 200)),
+		CreateID3TxxxFrame("title", "Some title"),
+	}
+	tag, err := EncodeID3Tag(frames)
+	assertNoError(t, err)
+	// Size 10+217 + 10+17 = 254 is encoded as 0x01 0x7e with 7 bits per byte
+	if !bytes.Equal(tag[:10], []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0x01, 0x7e}) {
+		t.Errorf("wrong ID3 header % x", tag[:10])
+	}
+	decFrames, err := DecodeID3Tag(tag)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(decFrames, frames) {
+		t.Errorf("got frames %v instead of %v", decFrames, frames)
+	}
+	_, err = EncodeID3Tag([]ID3Frame{{ID: "TXX"}})
+	assertError(t, err, "short frame ID should give error")
+	_, err = DecodeID3Tag(tag[:20])
+	assertError(t, err, "truncated tag should give error")
+}
+
+func TestID3v23Tag(t *testing.T) {
+	// ID3v2.3 with extended header, TIT2 in UTF-16 with BOM, TPE1 in ISO-8859-1,
+	// and a PRIV frame with a 200-byte plain (not sync-safe) size
+	tit2 := []byte{1, 0xff, 0xfe, 'H', 0, 'e', 0, 'j', 0}
+	tpe1 := []byte{0, 'B', 'j', 0xf6, 'r', 'k', 0}
+	priv := append([]byte("owner\x00"), bytes.Repeat([]byte{1}, 194)...)
+	var frames []byte
+	for _, fr := range []ID3Frame{{"TIT2", tit2}, {"TPE1", tpe1}, {"PRIV", priv}} {
+		frames = append(frames, fr.ID...)
+		frames = append(frames, 0, 0, 0, byte(len(fr.Data)), 0, 0)
+		frames = append(frames, fr.Data...)
+	}
+	extHeader := []byte{0, 0, 0, 6, 0, 0, 0, 0, 0, 0}
+	size := len(extHeader) + len(frames) + 4 // 4 bytes padding
+	tag := []byte{'I', 'D', '3', 3, 0, 0x40, 0, 0, byte(size >> 7), byte(size & 0x7f)}
+	tag = append(tag, extHeader...)
+	tag = append(tag, frames...)
+	tag = append(tag, 0, 0, 0, 0)
+
+	decFrames, err := DecodeID3Tag(tag)
+	assertNoError(t, err)
+	if len(decFrames) != 3 {
+		t.Fatalf("got %d frames instead of 3", len(decFrames))
+	}
+	for i, wanted := range []string{`TIT2: "Hej"`, `TPE1: "Björk"`, "PRIV: owner (194 bytes)"} {
+		if decFrames[i].String() != wanted {
+			t.Errorf("frame %d: got %q instead of %q", i+1, decFrames[i].String(), wanted)
+		}
+	}
+	_, _, err = decFrames[0].Txxx()
+	assertError(t, err, "TIT2 is not TXXX")
+
+	tag[5] = 0x80
+	_, err = DecodeID3Tag(tag)
+	assertError(t, err, "unsynchronisation should give error")
+}
+
+func TestID3EmsgInFragments(t *testing.T) {
+	c := NewEmsgCarrier(90000)
+	for i, presTime := range []uint64{135000, 10000} {
+		emsg, err := CreateID3Emsg(uint32(i+1), 90000, presTime, 0, []ID3Frame{CreateID3TxxxFrame("nr", "x")})
+		assertNoError(t, err)
+		c.Add(emsg, 0)
+	}
+	var emsgs []*EmsgBox
+	for i := 0; i < 2; i++ {
+		frag, _ := CreateFragment(uint32(i+1), 1)
+		c.Emit(frag, uint64(i)*90000, 90000)
+		if len(frag.Emsgs) != 1 {
+			t.Fatalf("fragment %d: got %d emsg instead of 1", i, len(frag.Emsgs))
+		}
+		emsgs = append(emsgs, frag.Emsgs[0])
+	}
+	if emsgs[0].ID != 2 || emsgs[1].ID != 1 || emsgs[1].PresentationTime != 135000 {
+		t.Errorf("ID3 events in wrong fragments")
+	}
+	decEmsg := boxAfterEncodeAndDecode(t, emsgs[1]).(*EmsgBox)
+	if decEmsg.SchemeIDURI != ID3EmsgSchemeIDURI {
+		t.Errorf("got scheme %s", decEmsg.SchemeIDURI)
+	}
+	frames, err := DecodeID3Tag(decEmsg.MessageData)
+	assertNoError(t, err)
+	if len(frames) != 1 || frames[0].ID != "TXXX" {
+		t.Errorf("got ID3 frames %v", frames)
+	}
+}
@@ -0,0 +1,50 @@
+package mp4
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// IdatBox - Item Data Box (idat) ISO/IEC 14496-12 Ed. 6 2020 Section 8.11.11
+//
+// Contained in : MetaBox (meta)
+// Item data referred to by iloc entries with construction method 1.
+type IdatBox struct {
+	Data []byte
+}
+
+// DecodeIdat - box-specific decode
+func DecodeIdat(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return &IdatBox{Data: data}, nil
+}
+
+// Type - box type
+func (b *IdatBox) Type() string {
+	return "idat"
+}
+
+// Size - calculated size of box
+func (b *IdatBox) Size() uint64 {
+	return uint64(boxHeaderSize + len(b.Data))
+}
+
+// Encode - write box to w
+func (b *IdatBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b.Data)
+	return err
+}
+
+// Info - write box-specific information
+func (b *IdatBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - size: %d", len(b.Data))
+	return bd.err
+}
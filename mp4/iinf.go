@@ -0,0 +1,144 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// IinfBox - Item Information Box (iinf) ISO/IEC 14496-12 Ed. 6 2020 Section 8.11.6
+//
+// Contained in : MetaBox (meta)
+type IinfBox struct {
+	Version  byte
+	Flags    uint32
+	Infes    []*InfeBox
+	Children []Box
+}
+
+// AddChild - Add a child box
+func (b *IinfBox) AddChild(box Box) {
+	if infe, ok := box.(*InfeBox); ok {
+		b.Infes = append(b.Infes, infe)
+	}
+	b.Children = append(b.Children, box)
+}
+
+// DecodeIinf - box-specific decode
+func DecodeIinf(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	var versionAndFlags uint32
+	err := binary.Read(r, binary.BigEndian, &versionAndFlags)
+	if err != nil {
+		return nil, err
+	}
+	version := byte(versionAndFlags >> 24)
+	var entryCount uint32
+	headerLen := uint64(14)
+	if version == 0 {
+		var count16 uint16
+		err = binary.Read(r, binary.BigEndian, &count16)
+		entryCount = uint32(count16)
+	} else {
+		err = binary.Read(r, binary.BigEndian, &entryCount)
+		headerLen = 16
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Note higher startPos for children since not simple container.
+	children, err := DecodeContainerChildren(hdr, startPos+headerLen, startPos+hdr.size, r)
+	if err != nil {
+		return nil, err
+	}
+	b := &IinfBox{
+		Version: version,
+		Flags:   versionAndFlags & flagsMask,
+	}
+	for _, c := range children {
+		b.AddChild(c)
+	}
+	if int(entryCount) != len(b.Infes) {
+		return nil, fmt.Errorf("iinf: entry count %d but %d infe boxes", entryCount, len(b.Infes))
+	}
+	return b, nil
+}
+
+// GetItem - get infe box for itemID. Returns nil if not found
+func (b *IinfBox) GetItem(itemID uint32) *InfeBox {
+	for _, infe := range b.Infes {
+		if infe.ItemID == itemID {
+			return infe
+		}
+	}
+	return nil
+}
+
+// Type - box type
+func (b *IinfBox) Type() string {
+	return "iinf"
+}
+
+// Size - calculated size of box
+func (b *IinfBox) Size() uint64 {
+	if b.Version == 0 {
+		return containerSize(b.Children) + 6
+	}
+	return containerSize(b.Children) + 8
+}
+
+// GetChildren - list of child boxes
+func (b *IinfBox) GetChildren() []Box {
+	return b.Children
+}
+
+func (b *IinfBox) setChildren(children []Box) error {
+	n := IinfBox{Version: b.Version, Flags: b.Flags}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
+// Encode - write iinf box to w including children
+func (b *IinfBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
+	err = binary.Write(w, binary.BigEndian, versionAndFlags)
+	if err != nil {
+		return err
+	}
+	if b.Version == 0 {
+		err = binary.Write(w, binary.BigEndian, uint16(len(b.Infes)))
+	} else {
+		err = binary.Write(w, binary.BigEndian, uint32(len(b.Infes)))
+	}
+	if err != nil {
+		return err
+	}
+	for _, c := range b.Children {
+		err = c.Encode(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Info - write box-specific information
+func (b *IinfBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	if bd.err != nil {
+		return bd.err
+	}
+	for _, c := range b.Children {
+		err := c.Info(w, specificBoxLevels, indent+indentStep, indentStep)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// IlocBox - Item Location Box (iloc) ISO/IEC 14496-12 Ed. 6 2020 Section 8.11.3
+//
+// Contained in : MetaBox (meta)
+//
+// Offset, length, base offset and index sizes are 0, 4, or 8 bytes.
+type IlocBox struct {
+	Version        byte
+	Flags          uint32
+	OffsetSize     byte
+	LengthSize     byte
+	BaseOffsetSize byte
+	IndexSize      byte // Only for version 1 and 2
+	Items          []IlocItem
+}
+
+// IlocItem - location of one item
+type IlocItem struct {
+	ItemID             uint32
+	ConstructionMethod byte // 0: file offset, 1: idat offset, 2: item offset. Only for version 1 and 2
+	DataReferenceIndex uint16
+	BaseOffset         uint64
+	Extents            []IlocExtent
+}
+
+// IlocExtent - one extent of an item
+type IlocExtent struct {
+	Index  uint64
+	Offset uint64
+	Length uint64
+}
+
+// Construction methods for iloc items
+const (
+	IlocFileOffset = 0
+	IlocIdatOffset = 1
+	IlocItemOffset = 2
+)
+
+// DecodeIloc - box-specific decode
+func DecodeIloc(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
+	b := &IlocBox{
+		Version: byte(versionAndFlags >> 24),
+		Flags:   versionAndFlags & flagsMask,
+	}
+	if b.Version > 2 {
+		return nil, fmt.Errorf("iloc: unknown version %d", b.Version)
+	}
+	sizes := s.ReadUint16()
+	b.OffsetSize = byte(sizes >> 12)
+	b.LengthSize = byte(sizes>>8) & 0xf
+	b.BaseOffsetSize = byte(sizes>>4) & 0xf
+	if b.Version > 0 {
+		b.IndexSize = byte(sizes) & 0xf
+	}
+	for _, size := range []byte{b.OffsetSize, b.LengthSize, b.BaseOffsetSize, b.IndexSize} {
+		if size != 0 && size != 4 && size != 8 {
+			return nil, fmt.Errorf("iloc: bad field size %d", size)
+		}
+	}
+	var itemCount uint32
+	if b.Version < 2 {
+		itemCount = uint32(s.ReadUint16())
+	} else {
+		itemCount = s.ReadUint32()
+	}
+	for i := uint32(0); i < itemCount; i++ {
+		var item IlocItem
+		if b.Version < 2 {
+			item.ItemID = uint32(s.ReadUint16())
+		} else {
+			item.ItemID = s.ReadUint32()
+		}
+		if b.Version > 0 {
+			item.ConstructionMethod = byte(s.ReadUint16() & 0xf)
+		}
+		item.DataReferenceIndex = s.ReadUint16()
+		item.BaseOffset = readSizedUint(s, b.BaseOffsetSize)
+		extentCount := s.ReadUint16()
+		for j := uint16(0); j < extentCount; j++ {
+			var e IlocExtent
+			if b.Version > 0 {
+				e.Index = readSizedUint(s, b.IndexSize)
+			}
+			e.Offset = readSizedUint(s, b.OffsetSize)
+			e.Length = readSizedUint(s, b.LengthSize)
+			item.Extents = append(item.Extents, e)
+		}
+		b.Items = append(b.Items, item)
+	}
+	return b, nil
+}
+
+// readSizedUint - read 0, 4, or 8 byte unsigned value
+func readSizedUint(s *SliceReader, size byte) uint64 {
+	switch size {
+	case 4:
+		return uint64(s.ReadUint32())
+	case 8:
+		return s.ReadUint64()
+	default:
+		return 0
+	}
+}
+
+// writeSizedUint - write 0, 4, or 8 byte unsigned value
+func writeSizedUint(sw *SliceWriter, size byte, value uint64) {
+	switch size {
+	case 4:
+		sw.WriteUint32(uint32(value))
+	case 8:
+		sw.WriteUint64(value)
+	}
+}
+
+// GetItem - get location of itemID. Returns nil if not found
+func (b *IlocBox) GetItem(itemID uint32) *IlocItem {
+	for i := range b.Items {
+		if b.Items[i].ItemID == itemID {
+			return &b.Items[i]
+		}
+	}
+	return nil
+}
+
+// Type - box type
+func (b *IlocBox) Type() string {
+	return "iloc"
+}
+
+// Size - calculated size of box
+func (b *IlocBox) Size() uint64 {
+	size := uint64(boxHeaderSize + 4 + 2 + 2)
+	idSize := uint64(2)
+	if b.Version == 2 {
+		size += 2
+		idSize = 4
+	}
+	for _, item := range b.Items {
+		size += idSize + 2 + uint64(b.BaseOffsetSize) + 2
+		if b.Version > 0 {
+			size += 2
+		}
+		extentSize := uint64(b.OffsetSize) + uint64(b.LengthSize)
+		if b.Version > 0 {
+			extentSize += uint64(b.IndexSize)
+		}
+		size += uint64(len(item.Extents)) * extentSize
+	}
+	return size
+}
+
+// Encode - write box to w
+func (b *IlocBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
+	sw.WriteUint32(versionAndFlags)
+	sizes := uint16(b.OffsetSize)<<12 | uint16(b.LengthSize)<<8 | uint16(b.BaseOffsetSize)<<4
+	if b.Version > 0 {
+		sizes |= uint16(b.IndexSize)
+	}
+	sw.WriteUint16(sizes)
+	if b.Version < 2 {
+		sw.WriteUint16(uint16(len(b.Items)))
+	} else {
+		sw.WriteUint32(uint32(len(b.Items)))
+	}
+	for _, item := range b.Items {
+		if b.Version < 2 {
+			sw.WriteUint16(uint16(item.ItemID))
+		} else {
+			sw.WriteUint32(item.ItemID)
+		}
+		if b.Version > 0 {
+			sw.WriteUint16(uint16(item.ConstructionMethod))
+		}
+		sw.WriteUint16(item.DataReferenceIndex)
+		writeSizedUint(sw, b.BaseOffsetSize, item.BaseOffset)
+		sw.WriteUint16(uint16(len(item.Extents)))
+		for _, e := range item.Extents {
+			if b.Version > 0 {
+				writeSizedUint(sw, b.IndexSize, e.Index)
+			}
+			writeSizedUint(sw, b.OffsetSize, e.Offset)
+			writeSizedUint(sw, b.LengthSize, e.Length)
+		}
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *IlocBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	bd.write(" - offsetSize: %d lengthSize: %d baseOffsetSize: %d indexSize: %d",
+		b.OffsetSize, b.LengthSize, b.BaseOffsetSize, b.IndexSize)
+	for _, item := range b.Items {
+		bd.write(" - item: ID=%d constructionMethod=%d dataReferenceIndex=%d baseOffset=%d",
+			item.ItemID, item.ConstructionMethod, item.DataReferenceIndex, item.BaseOffset)
+		for _, e := range item.Extents {
+			bd.write("   - extent: index=%d offset=%d length=%d", e.Index, e.Offset, e.Length)
+		}
+	}
+	return bd.err
+}
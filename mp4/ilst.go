@@ -15,9 +15,14 @@ func (b *IlstBox) AddChild(child Box) {
 	b.Children = append(b.Children, child)
 }
 
-// DecodeIlst - box-specific decode
+// DecodeIlst - box-specific decode. Items without a specific decoder are decoded as IlstItemBox
 func DecodeIlst(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
-	children, err := DecodeContainerChildren(hdr, startPos+8, startPos+hdr.size, r)
+	children, err := decodeChildrenWith(startPos+8, startPos+hdr.size, r, func(name string) BoxDecoder {
+		if _, ok := decoders[name]; !ok {
+			return DecodeIlstItem
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +48,15 @@ func (b *IlstBox) GetChildren() []Box {
 	return b.Children
 }
 
+func (b *IlstBox) setChildren(children []Box) error {
+	n := IlstBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - box-specific encode of stsd - not a usual container
 func (b *IlstBox) Encode(w io.Writer) error {
 	err := EncodeHeader(b, w)
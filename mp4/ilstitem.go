@@ -0,0 +1,207 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Well-known iTunes metadata item types in ilst
+const (
+	IlstItemTitle     = "\xa9nam"
+	IlstItemArtist    = "\xa9ART"
+	IlstItemAlbum     = "\xa9alb"
+	IlstItemComment   = "\xa9cmt"
+	IlstItemGenre     = "\xa9gen"
+	IlstItemDate      = "\xa9day"
+	IlstItemComposer  = "\xa9wrt"
+	IlstItemEncoder   = "\xa9too"
+	IlstItemCover     = "covr"
+	IlstItemCustomTag = "----"
+)
+
+// IlstItemBox - iTunes metadata item in ilst, such as ©nam or ©ART, with values in data children.
+// A custom tag (----) also has mean and name children with the reverse-DNS domain and the tag name.
+// Items of types with their own decoder, such as covr and ©too, are not decoded as IlstItemBox.
+type IlstItemBox struct {
+	name     string
+	Mean     *IlstTextBox
+	Name     *IlstTextBox
+	Data     *DataBox // First data child
+	Children []Box
+}
+
+// CreateIlstItem - create ilst item of type itemType with a single data child
+func CreateIlstItem(itemType string, data *DataBox) *IlstItemBox {
+	b := &IlstItemBox{name: itemType}
+	b.AddChild(data)
+	return b
+}
+
+// CreateIlstCustomTag - create custom tag (----) item with UTF-8 value
+func CreateIlstCustomTag(mean, name, value string) *IlstItemBox {
+	b := &IlstItemBox{name: IlstItemCustomTag}
+	b.AddChild(&IlstTextBox{name: "mean", Text: mean})
+	b.AddChild(&IlstTextBox{name: "name", Text: name})
+	b.AddChild(&DataBox{DataType: DataTypeUTF8, Data: []byte(value)})
+	return b
+}
+
+// AddChild - add a child box
+func (b *IlstItemBox) AddChild(child Box) {
+	switch box := child.(type) {
+	case *IlstTextBox:
+		switch box.Type() {
+		case "mean":
+			b.Mean = box
+		case "name":
+			b.Name = box
+		}
+	case *DataBox:
+		if b.Data == nil {
+			b.Data = box
+		}
+	}
+	b.Children = append(b.Children, child)
+}
+
+// DecodeIlstItem - box-specific decode of an ilst item. mean and name children are decoded as IlstTextBox
+func DecodeIlstItem(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	children, err := decodeChildrenWith(startPos+8, startPos+hdr.size, r, func(name string) BoxDecoder {
+		if name == "mean" || name == "name" {
+			return DecodeIlstText
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	b := &IlstItemBox{name: hdr.name}
+	for _, c := range children {
+		b.AddChild(c)
+	}
+	return b, nil
+}
+
+// Type - box type
+func (b *IlstItemBox) Type() string {
+	return b.name
+}
+
+// Size - calculated size of box
+func (b *IlstItemBox) Size() uint64 {
+	return containerSize(b.Children)
+}
+
+// GetChildren - list of child boxes
+func (b *IlstItemBox) GetChildren() []Box {
+	return b.Children
+}
+
+func (b *IlstItemBox) setChildren(children []Box) error {
+	n := IlstItemBox{name: b.name}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
+// Encode - write ilst item container to w
+func (b *IlstItemBox) Encode(w io.Writer) error {
+	return EncodeContainer(b, w)
+}
+
+// Info - box-specific Info
+func (b *IlstItemBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	return ContainerInfo(b, w, specificBoxLevels, indent, indentStep)
+}
+
+// IlstTextBox - mean or name child of a custom tag (----) item in ilst
+type IlstTextBox struct {
+	name    string
+	Version byte
+	Flags   uint32
+	Text    string
+}
+
+// DecodeIlstText - box-specific decode of mean and name
+func DecodeIlstText(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("%s: payload too short", hdr.name)
+	}
+	sr := NewSliceReader(data)
+	versionAndFlags := sr.ReadUint32()
+	return &IlstTextBox{
+		name:    hdr.name,
+		Version: byte(versionAndFlags >> 24),
+		Flags:   versionAndFlags & flagsMask,
+		Text:    sr.ReadFixedLengthString(len(data) - 4),
+	}, nil
+}
+
+// Type - box type
+func (b *IlstTextBox) Type() string {
+	return b.name
+}
+
+// Size - calculated size of box
+func (b *IlstTextBox) Size() uint64 {
+	return uint64(boxHeaderSize + 4 + len(b.Text))
+}
+
+// Encode - write box to w
+func (b *IlstTextBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint32((uint32(b.Version) << 24) + b.Flags)
+	sw.WriteString(b.Text, false)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *IlstTextBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	bd.write(" - text: %s", b.Text)
+	return bd.err
+}
+
+// decodeChildrenWith - decode children like DecodeContainerChildren, but use the decoder
+// returned by pick if it is not nil
+func decodeChildrenWith(startPos, endPos uint64, r io.Reader, pick func(name string) BoxDecoder) ([]Box, error) {
+	var l []Box
+	pos := startPos
+	for pos < endPos {
+		h, err := decodeHeader(r)
+		if err != nil {
+			return nil, &BoxDecodeError{StartPos: pos, Err: err}
+		}
+		d := pick(h.name)
+		if d == nil {
+			var ok bool
+			d, ok = decoders[h.name]
+			if !ok {
+				d = DecodeUnknown
+			}
+		}
+		b, err := d(h, pos, io.LimitReader(r, int64(h.size)-int64(h.hdrlen)))
+		if err != nil {
+			return nil, &BoxDecodeError{BoxType: h.name, StartPos: pos, Size: h.size, Err: err}
+		}
+		l = append(l, b)
+		pos += b.Size()
+	}
+	if pos != endPos {
+		return nil, fmt.Errorf("Non-matching children box sizes")
+	}
+	return l, nil
+}
@@ -0,0 +1,200 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// InfeBox - Item Info Entry Box (infe) ISO/IEC 14496-12 Ed. 6 2020 Section 8.11.6
+//
+// Contained in : ItemInfoBox (iinf)
+//
+// Versions 0 and 1 without extension, and versions 2 and 3 are supported.
+// ContentType and ContentEncoding are used for versions 0 and 1 and for item type "mime".
+// ItemURIType is used for item type "uri ".
+type InfeBox struct {
+	Version             byte
+	Flags               uint32
+	ItemID              uint32
+	ItemProtectionIndex uint16
+	ItemType            string
+	ItemName            string
+	ContentType         string
+	ContentEncoding     string // Optional
+	ItemURIType         string
+}
+
+// CreateMimeInfe - create version 2 infe box for an item with item type "mime" and contentType
+func CreateMimeInfe(itemID uint32, itemName, contentType string) *InfeBox {
+	b := &InfeBox{
+		Version:     2,
+		ItemID:      itemID,
+		ItemType:    "mime",
+		ItemName:    itemName,
+		ContentType: contentType,
+	}
+	if itemID > 0xffff {
+		b.Version = 3
+	}
+	return b
+}
+
+// DecodeInfe - box-specific decode
+func DecodeInfe(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
+	b := &InfeBox{
+		Version: byte(versionAndFlags >> 24),
+		Flags:   versionAndFlags & flagsMask,
+	}
+	switch b.Version {
+	case 0, 1:
+		b.ItemID = uint32(s.ReadUint16())
+		b.ItemProtectionIndex = s.ReadUint16()
+		if b.ItemName, err = s.ReadZeroTerminatedString(); err != nil {
+			return nil, err
+		}
+		if b.ContentType, err = s.ReadZeroTerminatedString(); err != nil {
+			return nil, err
+		}
+		if s.NrRemainingBytes() > 0 {
+			if b.ContentEncoding, err = s.ReadZeroTerminatedString(); err != nil {
+				return nil, err
+			}
+		}
+		if s.NrRemainingBytes() > 0 {
+			return nil, fmt.Errorf("infe: version 1 extensions not supported")
+		}
+		return b, nil
+	case 2:
+		b.ItemID = uint32(s.ReadUint16())
+	case 3:
+		b.ItemID = s.ReadUint32()
+	default:
+		return nil, fmt.Errorf("infe: unknown version %d", b.Version)
+	}
+	b.ItemProtectionIndex = s.ReadUint16()
+	b.ItemType = s.ReadFixedLengthString(4)
+	if b.ItemName, err = s.ReadZeroTerminatedString(); err != nil {
+		return nil, err
+	}
+	switch b.ItemType {
+	case "mime":
+		if b.ContentType, err = s.ReadZeroTerminatedString(); err != nil {
+			return nil, err
+		}
+		if s.NrRemainingBytes() > 0 {
+			if b.ContentEncoding, err = s.ReadZeroTerminatedString(); err != nil {
+				return nil, err
+			}
+		}
+	case "uri ":
+		if b.ItemURIType, err = s.ReadZeroTerminatedString(); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Type - box type
+func (b *InfeBox) Type() string {
+	return "infe"
+}
+
+// Size - calculated size of box
+func (b *InfeBox) Size() uint64 {
+	size := boxHeaderSize + 4 + 2 + len(b.ItemName) + 1 // versionAndFlags, protection index, name
+	switch b.Version {
+	case 0, 1:
+		size += 2 + len(b.ContentType) + 1
+		if b.ContentEncoding != "" {
+			size += len(b.ContentEncoding) + 1
+		}
+		return uint64(size)
+	case 2:
+		size += 2 + 4
+	default:
+		size += 4 + 4
+	}
+	switch b.ItemType {
+	case "mime":
+		size += len(b.ContentType) + 1
+		if b.ContentEncoding != "" {
+			size += len(b.ContentEncoding) + 1
+		}
+	case "uri ":
+		size += len(b.ItemURIType) + 1
+	}
+	return uint64(size)
+}
+
+// Encode - write box to w
+func (b *InfeBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
+	sw.WriteUint32(versionAndFlags)
+	if b.Version < 2 {
+		sw.WriteUint16(uint16(b.ItemID))
+		sw.WriteUint16(b.ItemProtectionIndex)
+		sw.WriteString(b.ItemName, true)
+		sw.WriteString(b.ContentType, true)
+		if b.ContentEncoding != "" {
+			sw.WriteString(b.ContentEncoding, true)
+		}
+		_, err = w.Write(buf)
+		return err
+	}
+	if b.Version == 2 {
+		sw.WriteUint16(uint16(b.ItemID))
+	} else {
+		sw.WriteUint32(b.ItemID)
+	}
+	sw.WriteUint16(b.ItemProtectionIndex)
+	if len(b.ItemType) != 4 {
+		return fmt.Errorf("infe: item type %q is not 4 characters", b.ItemType)
+	}
+	sw.WriteString(b.ItemType, false)
+	sw.WriteString(b.ItemName, true)
+	switch b.ItemType {
+	case "mime":
+		sw.WriteString(b.ContentType, true)
+		if b.ContentEncoding != "" {
+			sw.WriteString(b.ContentEncoding, true)
+		}
+	case "uri ":
+		sw.WriteString(b.ItemURIType, true)
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *InfeBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	bd.write(" - itemID: %d", b.ItemID)
+	bd.write(" - itemProtectionIndex: %d", b.ItemProtectionIndex)
+	if b.Version >= 2 {
+		bd.write(" - itemType: %q", b.ItemType)
+	}
+	bd.write(" - itemName: %q", b.ItemName)
+	if b.ContentType != "" {
+		bd.write(" - contentType: %q", b.ContentType)
+	}
+	if b.ContentEncoding != "" {
+		bd.write(" - contentEncoding: %q", b.ContentEncoding)
+	}
+	if b.ItemURIType != "" {
+		bd.write(" - itemURIType: %q", b.ItemURIType)
+	}
+	return bd.err
+}
@@ -81,13 +81,60 @@ func CreateEmptyInit() *InitSegment {
 }
 
 // AddEmptyTrack - add trak + trex box with appropriate trackID value
+// The trackID is one higher than the highest existing trackID and mvhd.NextTrackID is updated.
 func (s *InitSegment) AddEmptyTrack(timeScale uint32, mediaType, language string) {
 	moov := s.Moov
-	trackID := uint32(len(moov.Traks) + 1)
-	moov.Mvhd.NextTrackID = trackID + 1
+	trackID := moov.NextFreeTrackID()
 	newTrak := CreateEmptyTrak(trackID, timeScale, mediaType, language)
 	moov.AddChild(newTrak)
-	moov.Mvex.AddChild(CreateTrex(trackID))
+	moov.SyncTrackIDs()
+	moov.bumpModificationTime()
+}
+
+// RemoveTrack - remove trak and trex boxes for trackID and update mvhd.NextTrackID
+func (s *InitSegment) RemoveTrack(trackID uint32) error {
+	return s.Moov.RemoveTrack(trackID)
+}
+
+// SetTrackID - change trackID of a track as MoovBox.SetTrackID does
+func (s *InitSegment) SetTrackID(oldTrackID, newTrackID uint32) error {
+	return s.Moov.SetTrackID(oldTrackID, newTrackID)
+}
+
+// AddTrackReference - add a track reference of type refType from fromTrackID to toTrackID
+func (s *InitSegment) AddTrackReference(fromTrackID uint32, refType string, toTrackID uint32) error {
+	if fromTrackID == toTrackID {
+		return fmt.Errorf("trackID=%d cannot reference itself", fromTrackID)
+	}
+	from := s.Moov.GetTrak(fromTrackID)
+	if from == nil {
+		return fmt.Errorf("no trak with trackID=%d", fromTrackID)
+	}
+	if s.Moov.GetTrak(toTrackID) == nil {
+		return fmt.Errorf("no trak with trackID=%d", toTrackID)
+	}
+	from.AddTrackReference(refType, toTrackID)
+	return nil
+}
+
+// AddTrackRole - add DASH role like main, caption, or description to the track with trackID
+func (s *InitSegment) AddTrackRole(trackID uint32, role string) error {
+	trak := s.Moov.GetTrak(trackID)
+	if trak == nil {
+		return fmt.Errorf("no trak with trackID=%d", trackID)
+	}
+	trak.AddRole(role)
+	return nil
+}
+
+// LinkSubtitleTrack - link subtitle track to the main track it belongs to with a subt reference
+func (s *InitSegment) LinkSubtitleTrack(subtitleTrackID, mainTrackID uint32) error {
+	return s.AddTrackReference(subtitleTrackID, TrefSubt, mainTrackID)
+}
+
+// LinkMetadataTrack - link timed metadata track to the track it describes with a cdsc reference
+func (s *InitSegment) LinkMetadataTrack(metadataTrackID, mainTrackID uint32) error {
+	return s.AddTrackReference(metadataTrackID, TrefCdsc, mainTrackID)
 }
 
 // CreateEmptyTrak - create a full trak-tree for an empty (fragmented) track with no samples or stsd content
@@ -144,7 +191,7 @@ func CreateEmptyTrak(trackID, timeScale uint32, mediaType, language string) *Tra
 		minf.AddChild(CreateVmhd())
 	case "audio":
 		minf.AddChild(CreateSmhd())
-	case "subtitle", "subtitles":
+	case "subtitle", "subtitles", "stpp":
 		minf.AddChild(&SthdBox{})
 	case "text", "wvtt":
 		minf.AddChild(&NmhdBox{})
@@ -211,6 +258,70 @@ func (t *TrakBox) SetHEVCDescriptor(sampleDescriptorType string, vpsNALUs, spsNA
 	return nil
 }
 
+// SetAV1Descriptor - Set AV1 SampleDescriptor (av01) with av1C and display size
+func (t *TrakBox) SetAV1Descriptor(width, height uint16, av1C *Av1CBox) error {
+	if av1C == nil {
+		return fmt.Errorf("no av1C box")
+	}
+	if width == 0 || height == 0 {
+		return fmt.Errorf("bad AV1 size %dx%d", width, height)
+	}
+	t.Tkhd.Width = Fixed32(uint32(width) << 16)   // This is display width
+	t.Tkhd.Height = Fixed32(uint32(height) << 16) // This is display height
+	stsd := t.Mdia.Minf.Stbl.Stsd
+	av01 := CreateVisualSampleEntryBox("av01", width, height, av1C)
+	stsd.AddChild(av01)
+	return nil
+}
+
+// SetVP9Descriptor - Set VP9 SampleDescriptor (vp09) with vpcC and display size
+func (t *TrakBox) SetVP9Descriptor(width, height uint16, vpcC *VpcCBox) error {
+	if vpcC == nil {
+		return fmt.Errorf("no vpcC box")
+	}
+	if width == 0 || height == 0 {
+		return fmt.Errorf("bad VP9 size %dx%d", width, height)
+	}
+	t.Tkhd.Width = Fixed32(uint32(width) << 16)   // This is display width
+	t.Tkhd.Height = Fixed32(uint32(height) << 16) // This is display height
+	stsd := t.Mdia.Minf.Stbl.Stsd
+	vp09 := CreateVisualSampleEntryBox("vp09", width, height, vpcC)
+	stsd.AddChild(vp09)
+	return nil
+}
+
+// SetColourInformation - set colr box in all visual sample entries of the track to signal SDR or HDR
+// colours, e.g. using CreateColrSDR, CreateColrHDR10, or CreateColrHLG.
+func (t *TrakBox) SetColourInformation(colr *ColrBox) error {
+	found := false
+	for _, c := range t.Mdia.Minf.Stbl.Stsd.Children {
+		if vse, ok := c.(*VisualSampleEntryBox); ok {
+			vse.SetColr(colr)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no visual sample entry")
+	}
+	return nil
+}
+
+// SetHDRStaticMetadata - set mdcv and clli boxes in all visual sample entries of the track.
+// Together with an HDR10 colr box, see SetColourInformation, this gives complete HDR10 signaling.
+func (t *TrakBox) SetHDRStaticMetadata(mdcv *MdcvBox, clli *ClliBox) error {
+	found := false
+	for _, c := range t.Mdia.Minf.Stbl.Stsd.Children {
+		if vse, ok := c.(*VisualSampleEntryBox); ok {
+			vse.SetHDRStaticMetadata(mdcv, clli)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no visual sample entry")
+	}
+	return nil
+}
+
 // GetMediaType - should return video or audio (at present)
 func (s *InitSegment) GetMediaType() string {
 	switch s.Moov.Trak.Mdia.Hdlr.HandlerType {
@@ -261,6 +372,53 @@ func (t *TrakBox) SetAACDescriptor(objType byte, samplingFrequency int) error {
 	return nil
 }
 
+// SetAC3Descriptor - Set AC-3 (Dolby Digital) SampleDescriptor (ac-3) with dac3.
+// Channel count and sample rate are derived from dac3.
+func (t *TrakBox) SetAC3Descriptor(dac3 *Dac3Box) error {
+	if dac3 == nil {
+		return fmt.Errorf("no dac3 box")
+	}
+	sampleRate := dac3.SampleRate()
+	if sampleRate == 0 {
+		return fmt.Errorf("bad AC-3 sample rate code %d", dac3.FSCod)
+	}
+	stsd := t.Mdia.Minf.Stbl.Stsd
+	ac3 := CreateAudioSampleEntryBox("ac-3", uint16(dac3.ChannelCount()), 16, uint16(sampleRate), dac3)
+	stsd.AddChild(ac3)
+	return nil
+}
+
+// SetEC3Descriptor - Set E-AC-3 (Dolby Digital Plus) SampleDescriptor (ec-3) with dec3.
+// Channel count and sample rate are derived from the first independent substream in dec3.
+func (t *TrakBox) SetEC3Descriptor(dec3 *Dec3Box) error {
+	if dec3 == nil {
+		return fmt.Errorf("no dec3 box")
+	}
+	sampleRate := dec3.SampleRate()
+	if sampleRate == 0 {
+		return fmt.Errorf("bad E-AC-3 sample rate in dec3")
+	}
+	stsd := t.Mdia.Minf.Stbl.Stsd
+	ec3 := CreateAudioSampleEntryBox("ec-3", uint16(dec3.ChannelCount()), 16, uint16(sampleRate), dec3)
+	stsd.AddChild(ec3)
+	return nil
+}
+
+// SetOpusDescriptor - Set Opus SampleDescriptor (Opus) with dOps.
+// The sample rate of the entry is always 48kHz, which should also be the media timescale.
+func (t *TrakBox) SetOpusDescriptor(dops *DopsBox) error {
+	if dops == nil {
+		return fmt.Errorf("no dOps box")
+	}
+	if dops.OutputChannelCount == 0 {
+		return fmt.Errorf("dOps output channel count is 0")
+	}
+	stsd := t.Mdia.Minf.Stbl.Stsd
+	opus := CreateAudioSampleEntryBox("Opus", uint16(dops.OutputChannelCount), 16, 48000, dops)
+	stsd.AddChild(opus)
+	return nil
+}
+
 // SetWvttDescriptor - Set wvtt descriptor with a vttC box. config should start with WEBVTT or be empty.
 func (t *TrakBox) SetWvttDescriptor(config string) error {
 	if config == "" {
@@ -277,9 +435,19 @@ func (t *TrakBox) SetWvttDescriptor(config string) error {
 // The utf8-lists have space-separated items, but no zero-termination
 func (t *TrakBox) SetStppDescriptor(namespace, schemaLocation, auxiliaryMimeTypes string) error {
 	if namespace == "" {
-		namespace = "http://www.w3.org/ns/ttml"
+		namespace = TTMLNamespace
 	}
 	stpp := NewStppBox(namespace, schemaLocation, auxiliaryMimeTypes)
 	t.Mdia.Minf.Stbl.Stsd.AddChild(stpp)
 	return nil
 }
+
+// SetIMSC1Descriptor - add stpp box for TTML IMSC1 text or image profile subtitles as specified by CMAF.
+// The image profile has PNG images as auxiliary resources.
+func (t *TrakBox) SetIMSC1Descriptor(imageProfile bool) error {
+	auxiliaryMimeTypes := ""
+	if imageProfile {
+		auxiliaryMimeTypes = "image/png"
+	}
+	return t.SetStppDescriptor(TTMLNamespace, "", auxiliaryMimeTypes)
+}
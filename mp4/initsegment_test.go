@@ -211,3 +211,68 @@ func TestGenerateInitSegment(t *testing.T) {
 		t.Errorf("Generated init segment different from %s", goldenAssetPath)
 	}
 }
+
+func TestTrackIDMaintenance(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(48000, "audio", "und")
+	init.AddEmptyTrack(1000, "wvtt", "und")
+	if init.Moov.Mvhd.NextTrackID != 4 {
+		t.Errorf("NextTrackID %d instead of 4", init.Moov.Mvhd.NextTrackID)
+	}
+	err := init.RemoveTrack(2)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(init.Moov.Traks) != 2 || len(init.Moov.Mvex.Trexs) != 2 {
+		t.Errorf("expected 2 traks and trexs after removal")
+	}
+	if init.Moov.Mvex.GetTrex(2) != nil {
+		t.Errorf("trex for trackID 2 still present")
+	}
+	init.AddEmptyTrack(48000, "audio", "und")
+	if trackID := init.Moov.Traks[2].Tkhd.TrackID; trackID != 4 {
+		t.Errorf("new trackID %d instead of 4", trackID)
+	}
+	err = init.SetTrackID(4, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if init.Moov.Mvhd.NextTrackID != 11 {
+		t.Errorf("NextTrackID %d instead of 11", init.Moov.Mvhd.NextTrackID)
+	}
+	if init.Moov.Mvex.GetTrex(10) == nil {
+		t.Errorf("trex not updated with new trackID")
+	}
+	err = init.SetTrackID(10, 1)
+	assertError(t, err, "expected error when setting trackID already in use")
+	err = init.RemoveTrack(7)
+	assertError(t, err, "expected error when removing non-existing track")
+}
+
+func TestGenerateHEVCInitSegment(t *testing.T) {
+	vps, _ := hex.DecodeString("40010c01ffff022000000300b0000003000003007b18b024")
+	sps, _ := hex.DecodeString("420101022000000300b0000003000003007ba0078200887db6718b92448053888892cf24a69272c9124922dc91aa48fca223ff000100016a02020201")
+	pps, _ := hex.DecodeString("4401c0252f053240")
+
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(180000, "video", "und")
+	trak := init.Moov.Trak
+	assertError(t, trak.SetHEVCDescriptor("avc1", [][]byte{vps}, [][]byte{sps}, [][]byte{pps}), "avc1 is not an HEVC sample entry")
+	assertNoError(t, trak.SetHEVCDescriptor("hvc1", [][]byte{vps}, [][]byte{sps}, [][]byte{pps}))
+
+	var buf bytes.Buffer
+	assertNoError(t, init.Encode(&buf))
+	initRead, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	hvcx := initRead.Moov.Trak.Mdia.Minf.Stbl.Stsd.HvcX
+	if hvcx == nil || hvcx.Type() != "hvc1" {
+		t.Fatalf("no hvc1 sample entry after decode")
+	}
+	if hvcx.Width != 960 || hvcx.Height != 540 {
+		t.Errorf("got %dx%d instead of 960x540", hvcx.Width, hvcx.Height)
+	}
+	if hvcx.HvcC == nil || len(hvcx.HvcC.NaluArrays) != 3 {
+		t.Errorf("hvcC should have VPS, SPS, and PPS arrays")
+	}
+}
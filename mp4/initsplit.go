@@ -0,0 +1,105 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// SplitByTrack - create one single-track init segment per trak, e.g. to demux a muxed CMAF stream.
+// Each init segment is a deep copy with the trak, the matching trex, and mvhd.NextTrackID updated.
+// Track IDs are kept, so that the fragments of the muxed stream can be split without renumbering.
+// A pssh box is kept if it has no key IDs or a key ID matching a default KID of the track,
+// so unprotected tracks get no pssh boxes.
+func (s *InitSegment) SplitByTrack() ([]*InitSegment, error) {
+	if s.Moov == nil || len(s.Moov.Traks) == 0 {
+		return nil, fmt.Errorf("no tracks in init segment")
+	}
+	buf := bytes.Buffer{}
+	err := s.Encode(&buf)
+	if err != nil {
+		return nil, err
+	}
+	var inits []*InitSegment
+	for _, trak := range s.Moov.Traks {
+		init, err := decodeInitSegment(buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		trackID := trak.Tkhd.TrackID
+		moov := init.Moov
+		moov.keepTracks([]uint32{trackID})
+		moov.SyncTrackIDs()
+		kids := trakDefaultKIDs(moov.Trak)
+		children := make([]Box, 0, len(moov.Children))
+		for _, c := range moov.Children {
+			if pssh, ok := c.(*PsshBox); ok && !psshMatchesKIDs(pssh, kids) {
+				continue
+			}
+			children = append(children, c)
+		}
+		moov.Children = children
+		init.MediaType = init.GetMediaType()
+		inits = append(inits, init)
+	}
+	return inits, nil
+}
+
+// decodeInitSegment - decode the top-level boxes of an init segment
+func decodeInitSegment(data []byte) (*InitSegment, error) {
+	init := NewMP4Init()
+	r := bytes.NewReader(data)
+	pos := uint64(0)
+	for {
+		box, err := DecodeBox(pos, r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		init.AddChild(box)
+		pos += box.Size()
+	}
+	return init, nil
+}
+
+// trakDefaultKIDs - default KIDs in tenc boxes of the protected sample entries of trak
+func trakDefaultKIDs(trak *TrakBox) []UUID {
+	var kids []UUID
+	for _, entry := range trak.Mdia.Minf.Stbl.Stsd.Children {
+		var sinf *SinfBox
+		switch e := entry.(type) {
+		case *VisualSampleEntryBox:
+			sinf = e.Sinf
+		case *AudioSampleEntryBox:
+			sinf = e.Sinf
+		case *WvttBox:
+			sinf = e.Sinf
+		case *StppBox:
+			sinf = e.Sinf
+		}
+		if sinf != nil && sinf.Schi != nil && sinf.Schi.Tenc != nil {
+			kids = append(kids, sinf.Schi.Tenc.DefaultKID)
+		}
+	}
+	return kids
+}
+
+// psshMatchesKIDs - true if pssh has no key IDs or one of them is in kids, and kids is not empty
+func psshMatchesKIDs(pssh *PsshBox, kids []UUID) bool {
+	if len(kids) == 0 {
+		return false
+	}
+	if len(pssh.KIDs) == 0 {
+		return true
+	}
+	for _, k := range pssh.KIDs {
+		for _, kid := range kids {
+			if bytes.Equal(k, kid) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/edgeware/mp4ff/aac"
+)
+
+func TestSplitByTrack(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(48000, "audio", "en")
+	init.AddEmptyTrack(1000, "text", "en")
+	audio, text := init.Moov.Traks[0], init.Moov.Traks[1]
+	assertNoError(t, audio.SetAACDescriptor(aac.AAClc, 48000))
+	assertNoError(t, text.SetWvttDescriptor(""))
+	kidA, kidB := UUID(bytes.Repeat([]byte{0xa}, 16)), UUID(bytes.Repeat([]byte{0xb}, 16))
+	tencA := &TencBox{DefaultIsProtected: 1, DefaultPerSampleIVSize: 16, DefaultKID: kidA}
+	tencB := &TencBox{DefaultIsProtected: 1, DefaultPerSampleIVSize: 16, DefaultKID: kidB}
+	assertNoError(t, audio.Mdia.Minf.Stbl.Stsd.ProtectSampleEntry(SchemeCENC, tencA))
+	assertNoError(t, text.Mdia.Minf.Stbl.Stsd.ProtectSampleEntry(SchemeCENC, tencB))
+	systemID := UUID(bytes.Repeat([]byte{1}, 16))
+	init.Moov.AddChild(&PsshBox{Version: 1, SystemID: systemID, KIDs: []UUID{kidA}, Data: []byte{1}})
+	init.Moov.AddChild(&PsshBox{Version: 1, SystemID: systemID, KIDs: []UUID{kidB}, Data: []byte{2}})
+	init.Moov.AddChild(&PsshBox{SystemID: systemID, Data: []byte{3}})
+
+	inits, err := init.SplitByTrack()
+	assertNoError(t, err)
+	if len(inits) != 2 || len(init.Moov.Traks) != 2 {
+		t.Fatalf("got %d init segments from %d tracks", len(inits), len(init.Moov.Traks))
+	}
+	wantedPsshData := [][]byte{{1, 3}, {2, 3}}
+	for i, si := range inits {
+		moov := si.Moov
+		trackID := uint32(i + 1)
+		if len(moov.Traks) != 1 || moov.Trak.Tkhd.TrackID != trackID {
+			t.Fatalf("init %d: bad tracks", i)
+		}
+		if len(moov.Mvex.Trexs) != 1 || moov.Mvex.Trex.TrackID != trackID || moov.Mvhd.NextTrackID != trackID+1 {
+			t.Errorf("init %d: bad trex or next track ID", i)
+		}
+		var psshData []byte
+		for _, c := range moov.Children {
+			if pssh, ok := c.(*PsshBox); ok {
+				psshData = append(psshData, pssh.Data...)
+			}
+		}
+		if !bytes.Equal(psshData, wantedPsshData[i]) {
+			t.Errorf("init %d: got pssh data %v instead of %v", i, psshData, wantedPsshData[i])
+		}
+	}
+	if inits[0].MediaType != "audio" || inits[0].Moov.Trak == audio {
+		t.Errorf("first init is not a copy of the audio track")
+	}
+
+	_, err = (&InitSegment{}).SplitByTrack()
+	assertError(t, err, "init without moov should fail")
+}
@@ -1,12 +1,33 @@
 package mp4
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 )
 
-// KindBox - Track Kind Box
+// DASH roles (ISO/IEC 23009-1) for kind boxes with DASHRoleSchemeURI
+const (
+	RoleMain           = "main"
+	RoleAlternate      = "alternate"
+	RoleSupplementary  = "supplementary"
+	RoleCommentary     = "commentary"
+	RoleDub            = "dub"
+	RoleCaption        = "caption"
+	RoleSubtitle       = "subtitle"
+	RoleForcedSubtitle = "forced-subtitle"
+	RoleDescription    = "description"
+	RoleSign           = "sign"
+	RoleMetadata       = "metadata"
+	RoleEmergency      = "emergency"
+)
+
+// KindBox - Track Kind Box - ISO/IEC 14496-12 Ed. 9 Sec. 8.10.4
+//
+// Contained in : User Data Box (udta) in trak
 type KindBox struct {
+	Version   byte
+	Flags     uint32
 	SchemeURI string
 	Value     string
 }
@@ -17,7 +38,11 @@ func DecodeKind(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("kind: too short: %d bytes", len(data))
+	}
 	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
 	schemeURI, err := s.ReadZeroTerminatedString()
 	if err != nil {
 		return nil, err
@@ -27,6 +52,8 @@ func DecodeKind(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 		return nil, err
 	}
 	b := &KindBox{
+		Version:   byte(versionAndFlags >> 24),
+		Flags:     versionAndFlags & flagsMask,
 		SchemeURI: schemeURI,
 		Value:     value,
 	}
@@ -40,7 +67,7 @@ func (b *KindBox) Type() string {
 
 // Size - calculated size of box
 func (b *KindBox) Size() uint64 {
-	return uint64(boxHeaderSize + len(b.SchemeURI) + 1 + len(b.Value) + 1)
+	return uint64(boxHeaderSize + 4 + len(b.SchemeURI) + 1 + len(b.Value) + 1)
 }
 
 // Encode - write box to w
@@ -51,6 +78,8 @@ func (b *KindBox) Encode(w io.Writer) error {
 	}
 	buf := makebuf(b)
 	sw := NewSliceWriter(buf)
+	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
+	sw.WriteUint32(versionAndFlags)
 	sw.WriteString(b.SchemeURI, true)
 	sw.WriteString(b.Value, true)
 	_, err = w.Write(buf)
@@ -59,8 +88,41 @@ func (b *KindBox) Encode(w io.Writer) error {
 
 // Info - write box-specific information
 func (b *KindBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
-	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
 	bd.write(" - schemeURI: %s", b.SchemeURI)
 	bd.write(" - value: %s", b.Value)
 	return bd.err
 }
+
+// AddKind - add kind box with schemeURI and value to udta, creating udta if needed.
+// Nothing is added if the track already has the same kind.
+func (t *TrakBox) AddKind(schemeURI, value string) {
+	if t.Udta == nil {
+		t.AddChild(&UdtaBox{})
+	}
+	for _, kind := range t.GetKinds() {
+		if kind.SchemeURI == schemeURI && kind.Value == value {
+			return
+		}
+	}
+	t.Udta.AddChild(&KindBox{SchemeURI: schemeURI, Value: value})
+}
+
+// AddRole - add DASH role like main, caption, or description as kind box in udta
+func (t *TrakBox) AddRole(role string) {
+	t.AddKind(DASHRoleSchemeURI, role)
+}
+
+// GetKinds - all kind boxes in udta
+func (t *TrakBox) GetKinds() []*KindBox {
+	var kinds []*KindBox
+	if t.Udta == nil {
+		return kinds
+	}
+	for _, c := range t.Udta.Children {
+		if kind, ok := c.(*KindBox); ok {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
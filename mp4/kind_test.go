@@ -1,10 +1,46 @@
 package mp4
 
 import (
+	"bytes"
 	"testing"
+
+	"github.com/go-test/deep"
 )
 
 func TestKind(t *testing.T) {
 	kind := &KindBox{SchemeURI: "urn:mpeg:dash:role:2011", Value: "forced-subtitle"}
 	boxDiffAfterEncodeAndDecode(t, kind)
+
+	// kind is a FullBox with version and flags before the strings
+	data := []byte{0, 0, 0, 23, 'k', 'i', 'n', 'd', 0, 0, 0, 0, 'u', 'r', 'n', ':', 'x', 0, 'm', 'a', 'i', 'n', 0}
+	box, err := DecodeBox(0, bytes.NewBuffer(data))
+	assertNoError(t, err)
+	if diff := deep.Equal(box, &KindBox{SchemeURI: "urn:x", Value: "main"}); diff != nil {
+		t.Error(diff)
+	}
+	var buf bytes.Buffer
+	err = box.Encode(&buf)
+	assertNoError(t, err)
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("got encoded kind %v instead of %v", buf.Bytes(), data)
+	}
+}
+
+func TestAddTrackRole(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(1000, "wvtt", "en")
+	assertNoError(t, init.AddTrackRole(1, RoleCaption))
+	assertNoError(t, init.AddTrackRole(1, RoleCaption))
+	assertNoError(t, init.AddTrackRole(1, RoleDescription))
+	assertError(t, init.AddTrackRole(2, RoleMain), "no error for missing track")
+	trak := init.Moov.GetTrak(1)
+	trak.AddKind("urn:other", "other")
+
+	trak = boxAfterEncodeAndDecode(t, trak).(*TrakBox)
+	if diff := deep.Equal(trak.Roles(), []string{RoleCaption, RoleDescription}); diff != nil {
+		t.Error(diff)
+	}
+	if len(trak.GetKinds()) != 3 {
+		t.Errorf("got %d kind boxes instead of 3", len(trak.GetKinds()))
+	}
 }
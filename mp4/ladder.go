@@ -0,0 +1,393 @@
+package mp4
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// LadderRendition - input rendition for PackageLadder.
+// File can be progressive or fragmented. If TrackID is 0, the first track is used.
+// Source is only needed for progressive files decoded with lazy mdat.
+type LadderRendition struct {
+	Name    string
+	File    *File
+	TrackID uint32
+	Source  io.ReadSeeker
+}
+
+// LadderEncryption - common encryption parameters for PackageLadder
+type LadderEncryption struct {
+	Scheme string // SchemeCENC or SchemeCBCS
+	KeyID  UUID
+	Key    []byte
+	// IV - constant 16-byte IV for cbcs. For cenc, a random 8-byte start IV is generated per rendition,
+	// so that the same key can be used for all renditions.
+	IV    []byte
+	Psshs []*PsshBox // Added to the moov box of all init segments
+}
+
+// LadderConfig - configuration for PackageLadder
+type LadderConfig struct {
+	SegmentDurationMS   uint32 // Target segment duration in milliseconds
+	StartSequenceNumber uint32 // Sequence number of first segment. 0 means 1
+	Encryption          *LadderEncryption
+}
+
+// LadderOutput - CMAF init and media segments for one rendition
+type LadderOutput struct {
+	Name     string
+	Init     *InitSegment
+	Segments []*MediaSegment
+}
+
+// ladderTrack - samples and metadata of the packaged track of a rendition
+type ladderTrack struct {
+	trak      *TrakBox
+	timescale uint32
+	samples   []FullSample
+	starts    []int // Sample index where each segment starts, -1 for empty segment
+}
+
+// PackageLadder - package renditions into time-aligned CMAF tracks with one init segment and
+// a list of media segments each.
+//
+// Segment boundaries are given by the first video rendition, or by the first rendition if there
+// is no video. Its segments start at the first sync sample at or after every multiple of
+// config.SegmentDurationMS. The segments of all other renditions start at their first sample
+// at or after the same points in time. For video, that sample must be a sync sample, otherwise
+// the renditions are not aligned and an error is returned.
+// Segment number n has sequence number config.StartSequenceNumber + n in all renditions.
+//
+// With config.Encryption set, the sample entries are protected, and samples are encrypted using
+// subsample encryption for AVC and HEVC, and full-sample encryption otherwise.
+func PackageLadder(renditions []LadderRendition, config LadderConfig) ([]*LadderOutput, error) {
+	if len(renditions) == 0 {
+		return nil, fmt.Errorf("no renditions")
+	}
+	if config.SegmentDurationMS == 0 {
+		return nil, fmt.Errorf("segment duration is 0")
+	}
+	startSeqNr := config.StartSequenceNumber
+	if startSeqNr == 0 {
+		startSeqNr = 1
+	}
+	tracks := make([]*ladderTrack, len(renditions))
+	refIdx := -1
+	for i, r := range renditions {
+		tr, err := newLadderTrack(r)
+		if err != nil {
+			return nil, fmt.Errorf("rendition %s: %w", r.Name, err)
+		}
+		tracks[i] = tr
+		if refIdx < 0 && tr.trak.Mdia.Hdlr.HandlerType == "vide" {
+			refIdx = i
+		}
+	}
+	if refIdx < 0 {
+		refIdx = 0
+	}
+	ref := tracks[refIdx]
+	segStartTimes := ref.syncSegmentStarts(config.SegmentDurationMS)
+	if len(segStartTimes) == 0 {
+		return nil, fmt.Errorf("rendition %s: no samples", renditions[refIdx].Name)
+	}
+	outputs := make([]*LadderOutput, len(renditions))
+	for i, tr := range tracks {
+		err := tr.alignSegments(segStartTimes, ref.timescale)
+		if err != nil {
+			return nil, fmt.Errorf("rendition %s: %w", renditions[i].Name, err)
+		}
+		out, err := tr.createOutput(renditions[i].Name, startSeqNr, config.Encryption)
+		if err != nil {
+			return nil, fmt.Errorf("rendition %s: %w", renditions[i].Name, err)
+		}
+		outputs[i] = out
+	}
+	return outputs, nil
+}
+
+// newLadderTrack - find the track of rendition and read all its samples
+func newLadderTrack(r LadderRendition) (*ladderTrack, error) {
+	f := r.File
+	if f == nil || f.Moov == nil {
+		return nil, fmt.Errorf("no moov box")
+	}
+	trak := f.Moov.Trak
+	if r.TrackID != 0 {
+		trak = f.Moov.GetTrak(r.TrackID)
+	}
+	if trak == nil {
+		return nil, fmt.Errorf("track %d not found", r.TrackID)
+	}
+	tr := &ladderTrack{trak: trak, timescale: trak.Mdia.Mdhd.Timescale}
+	var err error
+	if f.IsFragmented() {
+		tr.samples, err = fragmentedTrackSamples(f, trak.Tkhd.TrackID)
+	} else {
+		tr.samples, err = progressiveTrackSamples(f, trak, r.Source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// progressiveTrackSamples - all samples of trak in a progressive file
+func progressiveTrackSamples(f *File, trak *TrakBox, rs io.ReadSeeker) ([]FullSample, error) {
+	stbl := trak.Mdia.Minf.Stbl
+	sizes := stbl.SampleSizes()
+	if sizes == nil {
+		return nil, fmt.Errorf("no stsz or stz2 box")
+	}
+	nrSamples := sizes.GetNrSamples()
+	if nrSamples == 0 {
+		return nil, nil
+	}
+	data := bytes.Buffer{}
+	err := f.CopySampleData(&data, rs, trak, 1, nrSamples)
+	if err != nil {
+		return nil, err
+	}
+	sampleData := data.Bytes()
+	samples := make([]FullSample, 0, nrSamples)
+	var decTime, pos uint64
+	nr := uint32(1)
+	for i, count := range stbl.Stts.SampleCount {
+		dur := stbl.Stts.SampleTimeDelta[i]
+		for j := uint32(0); j < count && nr <= nrSamples; j++ {
+			size := sizes.GetSampleSize(int(nr))
+			var cto int32
+			if stbl.Ctts != nil {
+				cto = stbl.Ctts.GetCompositionTimeOffset(nr)
+			}
+			samples = append(samples, FullSample{
+				Sample: Sample{
+					Flags:                 createSampleFlagsFromProgressiveBoxes(stbl.Stss, stbl.Sdtp, nr),
+					Dur:                   dur,
+					Size:                  size,
+					CompositionTimeOffset: cto,
+				},
+				DecodeTime: decTime,
+				Data:       sampleData[pos : pos+uint64(size)],
+			})
+			decTime += uint64(dur)
+			pos += uint64(size)
+			nr++
+		}
+	}
+	if len(samples) != int(nrSamples) {
+		return nil, fmt.Errorf("stts has %d samples, but stsz has %d", len(samples), nrSamples)
+	}
+	return samples, nil
+}
+
+// fragmentedTrackSamples - all samples of track trackID in a fragmented file
+func fragmentedTrackSamples(f *File, trackID uint32) ([]FullSample, error) {
+	trex := f.TrexForTrack(trackID)
+	if trex == nil {
+		return nil, fmt.Errorf("no trex or traf for track %d", trackID)
+	}
+	var samples []FullSample
+	for _, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			fragSamples, err := frag.GetFullSamples(trex)
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, fragSamples...)
+		}
+	}
+	return samples, nil
+}
+
+// syncSegmentStarts - decode times of segment starts at the first sync sample at or after every
+// multiple of segDurMS
+func (tr *ladderTrack) syncSegmentStarts(segDurMS uint32) []uint64 {
+	var starts []uint64
+	var nextStart uint64 // In milliseconds relative to first sample
+	if len(tr.samples) == 0 {
+		return nil
+	}
+	firstTime := tr.samples[0].DecodeTime
+	for i, s := range tr.samples {
+		if i > 0 && !IsSyncSampleFlags(s.Flags) {
+			continue
+		}
+		relTimeMS := (s.DecodeTime - firstTime) * 1000 / uint64(tr.timescale)
+		if i > 0 && relTimeMS < nextStart {
+			continue
+		}
+		starts = append(starts, s.DecodeTime)
+		nextStart = (relTimeMS/uint64(segDurMS) + 1) * uint64(segDurMS)
+	}
+	return starts
+}
+
+// alignSegments - set segment start samples to the first sample at or after every start time.
+// The first segment always starts with the first sample.
+func (tr *ladderTrack) alignSegments(startTimes []uint64, timescale uint32) error {
+	tr.starts = make([]int, len(startTimes))
+	idx := 0
+	for n, startTime := range startTimes {
+		for n > 0 && idx < len(tr.samples) && tr.samples[idx].DecodeTime*uint64(timescale) < startTime*uint64(tr.timescale) {
+			idx++
+		}
+		if idx == len(tr.samples) {
+			tr.starts[n] = -1
+			continue
+		}
+		if n > 0 && tr.starts[n-1] == idx {
+			tr.starts[n-1] = -1 // No sample in segment n-1
+		}
+		if tr.trak.Mdia.Hdlr.HandlerType == "vide" && !IsSyncSampleFlags(tr.samples[idx].Flags) {
+			return fmt.Errorf("segment %d does not start with sync sample", n+1)
+		}
+		tr.starts[n] = idx
+	}
+	return nil
+}
+
+// segmentSamples - samples of segment n, or nil if empty
+func (tr *ladderTrack) segmentSamples(n int) []FullSample {
+	start := tr.starts[n]
+	if start < 0 {
+		return nil
+	}
+	end := len(tr.samples)
+	for _, next := range tr.starts[n+1:] {
+		if next >= 0 {
+			end = next
+			break
+		}
+	}
+	return tr.samples[start:end]
+}
+
+// createOutput - create init segment and media segments for track with optional encryption
+func (tr *ladderTrack) createOutput(name string, startSeqNr uint32, enc *LadderEncryption) (*LadderOutput, error) {
+	init, err := tr.createInit()
+	if err != nil {
+		return nil, err
+	}
+	outTrak := init.Moov.Trak
+	trackID := outTrak.Tkhd.TrackID
+	trex := init.Moov.Mvex.GetTrex(trackID)
+	var tenc *TencBox
+	var iv []byte
+	var subSampleFunc SubSampleFunc
+	if enc != nil {
+		tenc, iv, err = createLadderTenc(enc, outTrak.Mdia.Hdlr.HandlerType)
+		if err != nil {
+			return nil, err
+		}
+		stsd := outTrak.Mdia.Minf.Stbl.Stsd
+		err = stsd.ProtectSampleEntry(enc.Scheme, tenc)
+		if err != nil {
+			return nil, err
+		}
+		for _, pssh := range enc.Psshs {
+			init.Moov.AddChild(pssh)
+		}
+		switch {
+		case stsd.AvcX != nil && stsd.AvcX.AvcC != nil:
+			lengthSize := stsd.AvcX.AvcC.NALULengthSize()
+			subSampleFunc = func(sample []byte) ([]SubSamplePattern, error) {
+				return NALUSubSamples("avc", sample, lengthSize)
+			}
+		case stsd.HvcX != nil && stsd.HvcX.HvcC != nil:
+			lengthSize := stsd.HvcX.HvcC.NALULengthSize()
+			subSampleFunc = func(sample []byte) ([]SubSamplePattern, error) {
+				return NALUSubSamples("hevc", sample, lengthSize)
+			}
+		}
+	}
+	out := &LadderOutput{Name: name, Init: init}
+	for n := range tr.starts {
+		samples := tr.segmentSamples(n)
+		if len(samples) == 0 {
+			continue
+		}
+		seg := NewMediaSegment()
+		frag, err := CreateFragment(startSeqNr+uint32(n), trackID)
+		if err != nil {
+			return nil, err
+		}
+		seg.AddFragment(frag)
+		for _, s := range samples {
+			data := make([]byte, len(s.Data)) // Copy since data may be encrypted in place
+			copy(data, s.Data)
+			s.Data = data
+			frag.AddFullSample(s)
+		}
+		if enc != nil {
+			// Set offsets and mdat position to find sample data as in a decoded fragment
+			frag.SetTrunDataOffsets()
+			frag.Mdat.StartPos = frag.Moof.StartPos + frag.Moof.Size()
+			iv, err = frag.EncryptSamplesWithSubSamples(trex, enc.Scheme, enc.Key, tenc, iv, subSampleFunc)
+			if err != nil {
+				return nil, fmt.Errorf("segment %d: %w", n+1, err)
+			}
+		}
+		out.Segments = append(out.Segments, seg)
+	}
+	return out, nil
+}
+
+// createInit - create a CMAF init segment with a copy of the sample descriptions of the track
+func (tr *ladderTrack) createInit() (*InitSegment, error) {
+	inMdia := tr.trak.Mdia
+	mediaType := inMdia.Hdlr.HandlerType
+	switch mediaType {
+	case "vide":
+		mediaType = "video"
+	case "soun":
+		mediaType = "audio"
+	case "subt":
+		mediaType = "subtitle"
+	}
+	lang := inMdia.Mdhd.GetLanguage()
+	if inMdia.Elng != nil {
+		lang = inMdia.Elng.Language
+	}
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(tr.timescale, mediaType, lang)
+	outTrak := init.Moov.Trak
+	outTrak.Tkhd.Width = tr.trak.Tkhd.Width
+	outTrak.Tkhd.Height = tr.trak.Tkhd.Height
+	// Copy sample entries, so that the input is not changed by encryption
+	err := outTrak.Mdia.Minf.Stbl.Stsd.copyEntries(inMdia.Minf.Stbl.Stsd)
+	if err != nil {
+		return nil, err
+	}
+	return init, nil
+}
+
+// createLadderTenc - create tenc and the start IV for a rendition with handler type hdlrType
+func createLadderTenc(enc *LadderEncryption, hdlrType string) (*TencBox, []byte, error) {
+	tenc := &TencBox{DefaultIsProtected: 1, DefaultKID: enc.KeyID}
+	switch enc.Scheme {
+	case SchemeCENC:
+		iv := make([]byte, 8)
+		_, err := rand.Read(iv)
+		if err != nil {
+			return nil, nil, err
+		}
+		tenc.DefaultPerSampleIVSize = 8
+		return tenc, iv, nil
+	case SchemeCBCS:
+		if len(enc.IV) != 16 {
+			return nil, nil, fmt.Errorf("cbcs constant IV length %d is not 16", len(enc.IV))
+		}
+		tenc.Version = 1
+		if hdlrType == "vide" {
+			tenc.DefaultCryptByteBlock = 1
+			tenc.DefaultSkipByteBlock = 9
+		}
+		tenc.DefaultConstantIV = enc.IV
+		return tenc, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("protection scheme %q not supported", enc.Scheme)
+	}
+}
@@ -0,0 +1,196 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// createAVCTestSamples - samples with one length-prefixed slice NAL unit each
+func createAVCTestSamples(nrSamples int, dur uint32, size int, syncInterval int) []FullSample {
+	samples := make([]FullSample, nrSamples)
+	for i := range samples {
+		flags := NonSyncSampleFlags
+		naluHdr := byte(0x41) // Non-IDR slice
+		if i%syncInterval == 0 {
+			flags = SyncSampleFlags
+			naluHdr = 0x65 // IDR slice
+		}
+		data := make([]byte, 5, 5+size)
+		binary.BigEndian.PutUint32(data, uint32(1+size))
+		data[4] = naluHdr
+		data = append(data, bytes.Repeat([]byte{byte(i)}, size)...)
+		samples[i] = FullSample{Sample: NewSample(flags, dur, uint32(len(data)), 0),
+			DecodeTime: uint64(i) * uint64(dur), Data: data}
+	}
+	return samples
+}
+
+func createLadderTestRenditions(t *testing.T) ([]LadderRendition, map[string][]FullSample) {
+	t.Helper()
+	sps, _ := hex.DecodeString(sps1nalu)
+	pps, _ := hex.DecodeString(pps1nalu)
+	inSamples := map[string][]FullSample{
+		"video_high": createAVCTestSamples(100, 3600, 500, 25), // 4s at 25fps
+		"video_low":  createAVCTestSamples(100, 3600, 100, 25),
+		"audio":      createProgTestSamples(188, 1024, 50, 1, 0x80),
+	}
+	var renditions []LadderRendition
+	for _, name := range []string{"video_high", "video_low", "audio"} {
+		init := CreateEmptyInit()
+		if name == "audio" {
+			init.AddEmptyTrack(48000, "audio", "swe")
+			assertNoError(t, init.Moov.Trak.SetAACDescriptor(2, 48000))
+		} else {
+			init.AddEmptyTrack(90000, "video", "und")
+			assertNoError(t, init.Moov.Trak.SetAVCDescriptor("avc1", [][]byte{sps}, [][]byte{pps}))
+		}
+		f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: inSamples[name]},
+			Interleaving{Mode: InterleaveBySize, ChunkSize: 2000})
+		assertNoError(t, err)
+		renditions = append(renditions, LadderRendition{Name: name, File: f})
+	}
+	return renditions, inSamples
+}
+
+// decodeLadderOutput - encode and decode init and media segments of output
+func decodeLadderOutput(t *testing.T, out *LadderOutput) *File {
+	t.Helper()
+	buf := bytes.Buffer{}
+	assertNoError(t, out.Init.Encode(&buf))
+	assertNoError(t, EncodeSegments(&buf, out.Segments, 2))
+	f, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	return f
+}
+
+func TestPackageLadder(t *testing.T) {
+	renditions, inSamples := createLadderTestRenditions(t)
+	outputs, err := PackageLadder(renditions, LadderConfig{SegmentDurationMS: 1000, StartSequenceNumber: 10})
+	assertNoError(t, err)
+	if len(outputs) != 3 {
+		t.Fatalf("got %d outputs instead of 3", len(outputs))
+	}
+	for _, out := range outputs {
+		if len(out.Segments) != 4 {
+			t.Fatalf("%s: got %d segments instead of 4", out.Name, len(out.Segments))
+		}
+		timescale := out.Init.Moov.Trak.Mdia.Mdhd.Timescale
+		for i, seg := range out.Segments {
+			frag := seg.Fragments[0]
+			if seqNr := frag.Moof.Mfhd.SequenceNumber; seqNr != uint32(10+i) {
+				t.Errorf("%s: segment %d has sequence number %d", out.Name, i, seqNr)
+			}
+			// All segments start within one audio frame of the video segment start
+			startMS := frag.Moof.Traf.Tfdt.BaseMediaDecodeTime * 1000 / uint64(timescale)
+			if startMS < uint64(i)*1000 || startMS > uint64(i)*1000+22 {
+				t.Errorf("%s: segment %d starts at %dms", out.Name, i, startMS)
+			}
+		}
+		f := decodeLadderOutput(t, out)
+		samples, err := fragmentedTrackSamples(f, f.Moov.Trak.Tkhd.TrackID)
+		assertNoError(t, err)
+		wanted := inSamples[out.Name]
+		if len(samples) != len(wanted) {
+			t.Fatalf("%s: got %d samples instead of %d", out.Name, len(samples), len(wanted))
+		}
+		for i := range samples {
+			if samples[i].DecodeTime != wanted[i].DecodeTime || !bytes.Equal(samples[i].Data, wanted[i].Data) {
+				t.Fatalf("%s: sample %d differs", out.Name, i+1)
+			}
+		}
+	}
+}
+
+func TestPackageLadderEncrypted(t *testing.T) {
+	key, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	kid := UUID(bytes.Repeat([]byte{0x11}, 16))
+	for _, scheme := range []string{SchemeCENC, SchemeCBCS} {
+		renditions, inSamples := createLadderTestRenditions(t)
+		enc := &LadderEncryption{Scheme: scheme, KeyID: kid, Key: key, IV: bytes.Repeat([]byte{0x22}, 16),
+			Psshs: []*PsshBox{{SystemID: UUID(bytes.Repeat([]byte{0x33}, 16)), Data: []byte{1, 2, 3}}}}
+		outputs, err := PackageLadder(renditions, LadderConfig{SegmentDurationMS: 2000, Encryption: enc})
+		assertNoError(t, err)
+		for _, out := range outputs {
+			f := decodeLadderOutput(t, out)
+			stsd := f.Moov.Trak.Mdia.Minf.Stbl.Stsd
+			entry := stsd.Children[0]
+			if entry.Type() != "encv" && entry.Type() != "enca" {
+				t.Fatalf("%s %s: sample entry %s is not protected", scheme, out.Name, entry.Type())
+			}
+			var tenc *TencBox
+			switch e := entry.(type) {
+			case *VisualSampleEntryBox:
+				tenc = e.Sinf.Schi.Tenc
+			case *AudioSampleEntryBox:
+				tenc = e.Sinf.Schi.Tenc
+			}
+			trex := f.Moov.Mvex.Trex
+			var samples []FullSample
+			for _, seg := range f.Segments {
+				for _, frag := range seg.Fragments {
+					assertNoError(t, frag.DecryptSamples(trex, scheme, key, tenc))
+					fragSamples, err := frag.GetFullSamples(trex)
+					assertNoError(t, err)
+					samples = append(samples, fragSamples...)
+				}
+			}
+			wanted := inSamples[out.Name]
+			for i := range samples {
+				if !bytes.Equal(samples[i].Data, wanted[i].Data) {
+					t.Fatalf("%s %s: decrypted sample %d differs", scheme, out.Name, i+1)
+				}
+			}
+			if len(samples) != len(wanted) {
+				t.Errorf("%s %s: got %d samples instead of %d", scheme, out.Name, len(samples), len(wanted))
+			}
+		}
+		// Input is not changed
+		if renditions[0].File.Moov.Trak.Mdia.Minf.Stbl.Stsd.Children[0].Type() != "avc1" {
+			t.Errorf("input sample entry changed")
+		}
+	}
+}
+
+func TestPackageLadderNotAligned(t *testing.T) {
+	renditions, _ := createLadderTestRenditions(t)
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: createAVCTestSamples(100, 3600, 100, 30)},
+		Interleaving{Mode: InterleaveBySize, ChunkSize: 2000})
+	assertNoError(t, err)
+	renditions = append(renditions, LadderRendition{Name: "video_other_gop", File: f})
+	_, err = PackageLadder(renditions, LadderConfig{SegmentDurationMS: 1000})
+	assertError(t, err, "renditions with different GOP structure should not be aligned")
+}
+
+func TestNALUSubSamples(t *testing.T) {
+	sample := []byte{0, 0, 0, 2, 0x09, 0xf0} // AUD
+	slice := make([]byte, 4+1+40)
+	binary.BigEndian.PutUint32(slice, 41)
+	slice[4] = 0x65
+	sample = append(sample, slice...)
+	subSamples, err := NALUSubSamples("avc", sample, 4)
+	assertNoError(t, err)
+	wanted := []SubSamplePattern{{BytesOfClearData: 6 + 5 + 8, BytesOfProtectedData: 32}}
+	if len(subSamples) != 1 || subSamples[0] != wanted[0] {
+		t.Errorf("got subsamples %v instead of %v", subSamples, wanted)
+	}
+	_, err = NALUSubSamples("avc", sample[:10], 4)
+	assertError(t, err, "truncated NAL unit should give error")
+	_, err = NALUSubSamples("vp9", sample, 4)
+	assertError(t, err, "vp9 should not be supported")
+	_, err = NALUSubSamples("avc", sample, 3)
+	assertError(t, err, "length size 3 should not be supported")
+
+	// Same NAL units with 2-byte length fields
+	sample2 := []byte{0, 2, 0x09, 0xf0, 0, 41, 0x65}
+	sample2 = append(sample2, make([]byte, 40)...)
+	subSamples, err = NALUSubSamples("avc", sample2, 2)
+	assertNoError(t, err)
+	wanted = []SubSamplePattern{{BytesOfClearData: 4 + 3 + 8, BytesOfProtectedData: 32}}
+	if len(subSamples) != 1 || subSamples[0] != wanted[0] {
+		t.Errorf("2-byte lengths: got subsamples %v instead of %v", subSamples, wanted)
+	}
+}
@@ -0,0 +1,144 @@
+package mp4
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ProducerMetadataMean - reverse-DNS domain of the custom tags with producer metadata in moov/udta/meta/ilst
+const ProducerMetadataMean = "com.edgeware.mp4ff.producer"
+
+// ProducerInfo - optional producer metadata for low-latency workflows.
+// It is stored as custom tags (----) in moov/udta/meta/ilst, which are passed through by decode and encode.
+type ProducerInfo struct {
+	Name          string        // Name of encoder or packager
+	Version       string        // Version of encoder or packager
+	TargetLatency time.Duration // Target latency as in a DASH ServiceDescription. 0 if not set
+}
+
+// SetProducerInfo - replace the producer metadata custom tags in moov/udta/meta/ilst by the values in p.
+// Empty values are not written. Other ilst items are kept.
+func (f *File) SetProducerInfo(p *ProducerInfo) error {
+	ilst, err := f.createIlst()
+	if err != nil {
+		return err
+	}
+	var children []Box
+	for _, c := range ilst.Children {
+		if item, ok := c.(*IlstItemBox); ok && item.Mean != nil && item.Mean.Text == ProducerMetadataMean {
+			continue
+		}
+		children = append(children, c)
+	}
+	ilst.Children = children
+	if p.Name != "" {
+		ilst.AddChild(CreateIlstCustomTag(ProducerMetadataMean, "name", p.Name))
+	}
+	if p.Version != "" {
+		ilst.AddChild(CreateIlstCustomTag(ProducerMetadataMean, "version", p.Version))
+	}
+	if p.TargetLatency != 0 {
+		ms := strconv.FormatInt(p.TargetLatency.Milliseconds(), 10)
+		ilst.AddChild(CreateIlstCustomTag(ProducerMetadataMean, "targetLatencyMs", ms))
+	}
+	return nil
+}
+
+// GetProducerInfo - producer metadata from custom tags in moov/udta/meta/ilst. nil if there are none
+func (f *File) GetProducerInfo() (*ProducerInfo, error) {
+	ilst := f.ilst()
+	if ilst == nil {
+		return nil, nil
+	}
+	var p *ProducerInfo
+	for _, c := range ilst.Children {
+		item, ok := c.(*IlstItemBox)
+		if !ok || item.Mean == nil || item.Mean.Text != ProducerMetadataMean || item.Name == nil || item.Data == nil {
+			continue
+		}
+		if p == nil {
+			p = &ProducerInfo{}
+		}
+		value := string(item.Data.Data)
+		switch item.Name.Text {
+		case "name":
+			p.Name = value
+		case "version":
+			p.Version = value
+		case "targetLatencyMs":
+			ms, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("producer target latency %q: %w", value, err)
+			}
+			p.TargetLatency = time.Duration(ms) * time.Millisecond
+		}
+	}
+	return p, nil
+}
+
+// SegmentLatency - encoder-to-packager latency of a media segment given by prft boxes
+type SegmentLatency struct {
+	SegmentNr    int       // 0-based index in File.Segments
+	TrackID      uint32    // Reference track of the prft boxes
+	MediaTime    uint64    // Media time of the packager prft in the track timescale
+	EncoderTime  time.Time // Wall-clock time when the encoder handled MediaTime
+	PackagerTime time.Time // Wall-clock time when the moof box was finalized or written
+	Latency      time.Duration
+}
+
+// LatencyAudit - encoder-to-packager latency for all segments of a fragmented file with prft boxes.
+// Encoder prft boxes (captured, encoder input, or encoder output) relate wall-clock time to media time,
+// and the encoder time of a packager prft box (moof finalized or moof written) is extrapolated from the
+// latest encoder prft box for the same track in the same or an earlier segment.
+// Segments without a packager prft box or a preceding encoder prft box are not reported.
+// The track timescales are taken from the init segment.
+func (f *File) LatencyAudit() ([]SegmentLatency, error) {
+	if !f.isFragmented {
+		return nil, fmt.Errorf("file is not fragmented")
+	}
+	if f.Init == nil {
+		return nil, fmt.Errorf("no init segment")
+	}
+	var report []SegmentLatency
+	encoderPrfts := make(map[uint32]*PrftBox)
+	for i, seg := range f.Segments {
+		var packagerPrft *PrftBox
+		for _, frag := range seg.Fragments {
+			for _, prft := range frag.Prfts {
+				switch {
+				case prft.IsEncoderTime():
+					encoderPrfts[prft.ReferenceTrackID] = prft
+				case prft.IsPackagerTime() && packagerPrft == nil:
+					packagerPrft = prft
+				}
+			}
+		}
+		if packagerPrft == nil {
+			continue
+		}
+		trackID := packagerPrft.ReferenceTrackID
+		encoderPrft, ok := encoderPrfts[trackID]
+		if !ok {
+			continue
+		}
+		trak := f.Init.Moov.GetTrak(trackID)
+		if trak == nil {
+			return nil, fmt.Errorf("segment %d: prft reference track %d not in init segment", i, trackID)
+		}
+		timescale := trak.Mdia.Mdhd.Timescale
+		mediaDiff := int64(packagerPrft.MediaTime) - int64(encoderPrft.MediaTime)
+		offset := time.Duration(float64(mediaDiff) / float64(timescale) * float64(time.Second))
+		encoderTime := encoderPrft.WallClockTime().Add(offset)
+		packagerTime := packagerPrft.WallClockTime()
+		report = append(report, SegmentLatency{
+			SegmentNr:    i,
+			TrackID:      trackID,
+			MediaTime:    packagerPrft.MediaTime,
+			EncoderTime:  encoderTime,
+			PackagerTime: packagerTime,
+			Latency:      packagerTime.Sub(encoderTime),
+		})
+	}
+	return report, nil
+}
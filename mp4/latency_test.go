@@ -0,0 +1,52 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestLatencyAudit(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(75, 3600, 500, 25, 0x20) // Segments at 0s, 1s, 2s
+	f, err := CreateOnDemandFile(init, NewSliceSampleSource(samples), 90000)
+	assertNoError(t, err)
+	t0 := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	frags := []*Fragment{f.Segments[0].Fragments[0], f.Segments[1].Fragments[0], f.Segments[2].Fragments[0]}
+	frags[0].AddPrft(CreatePrftBoxFromTime(PrftFlagsEncoderInput, 1, t0, 0))
+	frags[0].AddPrft(CreatePrftBoxFromTime(PrftFlagsMoofWritten, 1, t0.Add(300*time.Millisecond), 0))
+	frags[1].AddPrft(CreatePrftBoxFromTime(PrftFlagsMoofFinalized, 1, t0.Add(1400*time.Millisecond), 90000))
+	if frags[0].Prft.Flags != PrftFlagsEncoderInput || len(frags[0].Prfts) != 2 || frags[0].Children[1].Type() != "prft" {
+		t.Fatalf("prft boxes not added in order")
+	}
+	assertNoError(t, f.SetProducerInfo(&ProducerInfo{Name: "packager", TargetLatency: 3 * time.Second}))
+	f.FragEncMode = EncModeSegment
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithSegmentPerFragment())
+	assertNoError(t, err)
+
+	report, err := decFile.LatencyAudit()
+	assertNoError(t, err)
+	wanted := []struct {
+		segNr   int
+		latency time.Duration
+	}{{0, 300 * time.Millisecond}, {1, 400 * time.Millisecond}}
+	if len(report) != len(wanted) {
+		t.Fatalf("got %d segment latencies instead of %d", len(report), len(wanted))
+	}
+	for i, w := range wanted {
+		diff := report[i].Latency - w.latency
+		if report[i].SegmentNr != w.segNr || diff > time.Millisecond || diff < -time.Millisecond {
+			t.Errorf("got segment %d latency %s instead of segment %d latency %s",
+				report[i].SegmentNr, report[i].Latency, w.segNr, w.latency)
+		}
+	}
+
+	p, err := decFile.GetProducerInfo()
+	assertNoError(t, err)
+	if p == nil || p.Name != "packager" || p.Version != "" || p.TargetLatency != 3*time.Second {
+		t.Errorf("got producer info %+v", p)
+	}
+}
@@ -0,0 +1,46 @@
+package mp4
+
+import (
+	"sync/atomic"
+)
+
+// Logger - interface for logging suspicious but recoverable conditions found by the library,
+// such as unknown boxes, boxes with unexpected sizes, or box data that is not decoded.
+//
+// It is satisfied by many logging libraries, e.g. the sugared zap logger and logrus loggers.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+type loggerHolder struct {
+	l Logger
+}
+
+var libLogger atomic.Value // loggerHolder
+
+// SetLogger - set the logger used by the library. nil, which is the default, turns logging off.
+func SetLogger(l Logger) {
+	libLogger.Store(loggerHolder{l})
+}
+
+// getLogger - the logger set by SetLogger or nil
+func getLogger() Logger {
+	h, ok := libLogger.Load().(loggerHolder)
+	if !ok {
+		return nil
+	}
+	return h.l
+}
+
+func logDebugf(format string, args ...interface{}) {
+	if l := getLogger(); l != nil {
+		l.Debugf(format, args...)
+	}
+}
+
+func logWarnf(format string, args ...interface{}) {
+	if l := getLogger(); l != nil {
+		l.Warnf(format, args...)
+	}
+}
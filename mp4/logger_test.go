@@ -0,0 +1,73 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testLogger struct {
+	msgs []string
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{}) {
+	l.msgs = append(l.msgs, "DEBUG "+fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) Warnf(format string, args ...interface{}) {
+	l.msgs = append(l.msgs, "WARN "+fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) contains(msg string) bool {
+	for _, m := range l.msgs {
+		if strings.Contains(m, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLogger(t *testing.T) {
+	l := &testLogger{}
+	SetLogger(l)
+	defer SetLogger(nil)
+
+	testCases := []struct {
+		desc    string
+		data    []byte
+		wantMsg string
+	}{
+		{
+			desc:    "unknown box",
+			data:    []byte{0, 0, 0, 12, 'a', 'b', 'c', 'd', 1, 2, 3, 4},
+			wantMsg: `DEBUG unknown box "abcd" at 0 with size 12`,
+		},
+		{
+			desc:    "sthd with extra bytes",
+			data:    []byte{0, 0, 0, 16, 's', 't', 'h', 'd', 0, 0, 0, 0, 1, 2, 3, 4},
+			wantMsg: "WARN sthd box at 0: size 16 in header, but 12 after decode",
+		},
+		{
+			desc:    "truncated udta",
+			data:    []byte{0, 0, 0, 32, 'u', 'd', 't', 'a', 0, 0, 0, 8, 'f', 'r', 'e', 'e'},
+			wantMsg: "WARN udta box: children end at 16 before box end at 32",
+		},
+	}
+	for _, tc := range testCases {
+		l.msgs = nil
+		_, err := DecodeBox(0, bytes.NewBuffer(tc.data))
+		assertNoError(t, err)
+		if !l.contains(tc.wantMsg) {
+			t.Errorf("%s: got log messages %q, want %q", tc.desc, l.msgs, tc.wantMsg)
+		}
+	}
+
+	SetLogger(nil)
+	l.msgs = nil
+	_, err := DecodeBox(0, bytes.NewBuffer(testCases[0].data))
+	assertNoError(t, err)
+	if len(l.msgs) != 0 {
+		t.Errorf("got log messages %q after logger was removed", l.msgs)
+	}
+}
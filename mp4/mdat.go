@@ -14,6 +14,8 @@ type MdatBox struct {
 	Data         []byte
 	lazyDataSize uint64
 	LargeSize    bool
+	redact       RedactMode // How the payload is written by Encode
+	decoded      bool       // StartPos is the position in the decoded file
 }
 
 const maxNormalPayloadSize = (1 << 32) - 1 - 8
@@ -25,7 +27,7 @@ func DecodeMdat(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 		return nil, err
 	}
 	largeSize := hdr.hdrlen > boxHeaderSize
-	return &MdatBox{startPos, data, 0, largeSize}, nil
+	return &MdatBox{StartPos: startPos, Data: data, LargeSize: largeSize, decoded: true}, nil
 }
 
 // IsLazy - is the mdat data handled lazily (with separate writer/reader).
@@ -37,7 +39,7 @@ func (m *MdatBox) IsLazy() bool {
 func DecodeMdatLazily(hdr *boxHeader, startPos uint64) (Box, error) {
 	largeSize := hdr.hdrlen > boxHeaderSize
 	decLazyDataSize := hdr.size - uint64(hdr.hdrlen)
-	return &MdatBox{startPos, nil, decLazyDataSize, largeSize}, nil
+	return &MdatBox{StartPos: startPos, lazyDataSize: decLazyDataSize, LargeSize: largeSize, decoded: true}, nil
 }
 
 // SetLazyDataSize - set size of mdat lazy data so that the data can be written separately
@@ -62,6 +64,9 @@ func (m *MdatBox) Size() uint64 {
 	if m.lazyDataSize > 0 {
 		dataSize = m.lazyDataSize
 	}
+	if m.redact == RedactTruncate {
+		dataSize = 0
+	}
 	if dataSize > maxNormalPayloadSize {
 		m.LargeSize = true
 	}
@@ -83,12 +88,20 @@ func (m *MdatBox) SetData(data []byte) {
 	m.lazyDataSize = 0
 }
 
-// Encode - write box to w. If m.lazyDataSize > 0, the mdat data needs to be written separately
+// Encode - write box to w. If m.lazyDataSize > 0, the mdat data needs to be written separately,
+// unless the box is redacted.
 func (m *MdatBox) Encode(w io.Writer) error {
 	err := EncodeHeaderWithSize("mdat", m.Size(), m.LargeSize, w)
 	if err != nil {
 		return err
 	}
+	switch m.redact {
+	case RedactZero:
+		_, err = io.CopyN(w, zeroReader{}, int64(m.Size()-m.HeaderSize()))
+		return err
+	case RedactTruncate:
+		return nil
+	}
 	_, err = w.Write(m.Data)
 	return err
 }
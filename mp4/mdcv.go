@@ -0,0 +1,97 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// MdcvBox - Mastering Display Colour Volume Box, ISO/IEC 23001-8 and SMPTE ST 2086
+// Chromaticity coordinates are in units of 0.00002 and luminances in units of 0.0001 cd/m2.
+// The display primaries are in the order green, blue, red as in the HEVC SEI message.
+type MdcvBox struct {
+	DisplayPrimariesX [3]uint16
+	DisplayPrimariesY [3]uint16
+	WhitePointX       uint16
+	WhitePointY       uint16
+	MaxLuminance      uint32
+	MinLuminance      uint32
+}
+
+// CreateMdcvBT2020 - create MdcvBox for a BT.2020 mastering display with D65 white point.
+// maxLuminance and minLuminance are given in units of 0.0001 cd/m2.
+func CreateMdcvBT2020(maxLuminance, minLuminance uint32) *MdcvBox {
+	return &MdcvBox{
+		DisplayPrimariesX: [3]uint16{8500, 6550, 35400},
+		DisplayPrimariesY: [3]uint16{39850, 2300, 14600},
+		WhitePointX:       15635,
+		WhitePointY:       16450,
+		MaxLuminance:      maxLuminance,
+		MinLuminance:      minLuminance,
+	}
+}
+
+// DecodeMdcv - box-specific decode
+func DecodeMdcv(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 24 {
+		return nil, fmt.Errorf("mdcv: payload size %d instead of 24", len(data))
+	}
+	b := &MdcvBox{}
+	sr := NewSliceReader(data)
+	for i := 0; i < 3; i++ {
+		b.DisplayPrimariesX[i] = sr.ReadUint16()
+		b.DisplayPrimariesY[i] = sr.ReadUint16()
+	}
+	b.WhitePointX = sr.ReadUint16()
+	b.WhitePointY = sr.ReadUint16()
+	b.MaxLuminance = sr.ReadUint32()
+	b.MinLuminance = sr.ReadUint32()
+	return b, nil
+}
+
+// Type - box type
+func (b *MdcvBox) Type() string {
+	return "mdcv"
+}
+
+// Size - calculated size of box
+func (b *MdcvBox) Size() uint64 {
+	return uint64(boxHeaderSize + 24)
+}
+
+// Encode - write box to w
+func (b *MdcvBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	for i := 0; i < 3; i++ {
+		sw.WriteUint16(b.DisplayPrimariesX[i])
+		sw.WriteUint16(b.DisplayPrimariesY[i])
+	}
+	sw.WriteUint16(b.WhitePointX)
+	sw.WriteUint16(b.WhitePointY)
+	sw.WriteUint32(b.MaxLuminance)
+	sw.WriteUint32(b.MinLuminance)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *MdcvBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	for i, name := range []string{"green", "blue", "red"} {
+		bd.write(" - %s: (%.5f, %.5f)", name, float64(b.DisplayPrimariesX[i])*0.00002,
+			float64(b.DisplayPrimariesY[i])*0.00002)
+	}
+	bd.write(" - whitePoint: (%.5f, %.5f)", float64(b.WhitePointX)*0.00002, float64(b.WhitePointY)*0.00002)
+	bd.write(" - maxLuminance: %.4f cd/m2", float64(b.MaxLuminance)*0.0001)
+	bd.write(" - minLuminance: %.4f cd/m2", float64(b.MinLuminance)*0.0001)
+	return bd.err
+}
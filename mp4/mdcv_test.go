@@ -0,0 +1,45 @@
+package mp4
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestEncDecMdcvClli(t *testing.T) {
+	boxDiffAfterEncodeAndDecode(t, CreateMdcvBT2020(10000000, 50))
+	boxDiffAfterEncodeAndDecode(t, &ClliBox{MaxContentLightLevel: 1000, MaxPicAverageLightLevel: 400})
+}
+
+func TestHDR10Signaling(t *testing.T) {
+	sps, _ := hex.DecodeString(sps1nalu)
+	pps, _ := hex.DecodeString(pps1nalu)
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	trak := init.Moov.Trak
+	assertNoError(t, trak.SetAVCDescriptor("avc1", [][]byte{sps}, [][]byte{pps}))
+	avcx := trak.Mdia.Minf.Stbl.Stsd.AvcX
+	assertError(t, avcx.CheckHDR10(), "no colr box")
+	assertNoError(t, trak.SetColourInformation(CreateColrHDR10()))
+	assertError(t, avcx.CheckHDR10(), "no mdcv box")
+	assertNoError(t, trak.SetHDRStaticMetadata(CreateMdcvBT2020(10000000, 50), &ClliBox{1000, 400}))
+	assertNoError(t, trak.SetHDRStaticMetadata(CreateMdcvBT2020(40000000, 50), &ClliBox{4000, 400}))
+	assertNoError(t, avcx.CheckHDR10())
+
+	decAvcx := boxAfterEncodeAndDecode(t, avcx).(*VisualSampleEntryBox)
+	assertNoError(t, decAvcx.CheckHDR10())
+	if decAvcx.Mdcv.MaxLuminance != 40000000 || decAvcx.Clli.MaxContentLightLevel != 4000 {
+		t.Errorf("mdcv or clli not replaced: %+v %+v", decAvcx.Mdcv, decAvcx.Clli)
+	}
+	nrBoxes := 0
+	for _, c := range decAvcx.Children {
+		if c.Type() == "mdcv" || c.Type() == "clli" {
+			nrBoxes++
+		}
+	}
+	if nrBoxes != 2 {
+		t.Errorf("got %d mdcv and clli boxes instead of 2", nrBoxes)
+	}
+
+	trak.SetColourInformation(CreateColrSDR())
+	assertError(t, avcx.CheckHDR10(), "colour primaries 1 instead of BT.2020")
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"time"
 )
 
 const charOffset = 0x60 // According to Section 8.4.2.3 of 14496-12
@@ -76,6 +77,28 @@ func (m *MdhdBox) SetLanguage(lang string) {
 	m.Language = l
 }
 
+// GetCreationTime - creation time as UTC time
+func (m *MdhdBox) GetCreationTime() time.Time {
+	return MP4TimeToTime(m.CreationTime)
+}
+
+// SetCreationTime - set creation time. Version is changed to 1 if needed
+func (m *MdhdBox) SetCreationTime(t time.Time) {
+	m.CreationTime = TimeToMP4Time(t)
+	m.Version = timeVersion(m.Version, m.CreationTime)
+}
+
+// GetModificationTime - modification time as UTC time
+func (m *MdhdBox) GetModificationTime() time.Time {
+	return MP4TimeToTime(m.ModificationTime)
+}
+
+// SetModificationTime - set modification time. Version is changed to 1 if needed
+func (m *MdhdBox) SetModificationTime(t time.Time) {
+	m.ModificationTime = TimeToMP4Time(t)
+	m.Version = timeVersion(m.Version, m.ModificationTime)
+}
+
 // Type - box type
 func (m *MdhdBox) Type() string {
 	return "mdhd"
@@ -101,13 +124,13 @@ func (m *MdhdBox) Encode(w io.Writer) error {
 	versionAndFlags := (uint32(m.Version) << 24) + m.Flags
 	sw.WriteUint32(versionAndFlags)
 	if m.Version == 1 {
-		sw.WriteUint64(m.CreationTime)
-		sw.WriteUint64(m.ModificationTime)
+		sw.WriteUint64(encodedTime(m.CreationTime))
+		sw.WriteUint64(encodedTime(m.ModificationTime))
 		sw.WriteUint32(m.Timescale)
 		sw.WriteUint64(m.Duration)
 	} else {
-		sw.WriteUint32(uint32(m.CreationTime))
-		sw.WriteUint32(uint32(m.ModificationTime))
+		sw.WriteUint32(uint32(encodedTime(m.CreationTime)))
+		sw.WriteUint32(uint32(encodedTime(m.ModificationTime)))
 		sw.WriteUint32(m.Timescale)
 		sw.WriteUint32(uint32(m.Duration))
 	}
@@ -66,6 +66,15 @@ func (m *MdiaBox) GetChildren() []Box {
 	return m.Children
 }
 
+func (m *MdiaBox) setChildren(children []Box) error {
+	n := MdiaBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*m = n
+	return nil
+}
+
 // Encode - write mdia container to w
 func (m *MdiaBox) Encode(w io.Writer) error {
 	return EncodeContainer(m, w)
@@ -1,6 +1,7 @@
 package mp4
 
 import (
+	"fmt"
 	"io"
 )
 
@@ -8,6 +9,7 @@ import (
 type MediaSegment struct {
 	Styp        *StypBox
 	Sidx        *SidxBox // Sidx for a segment
+	Ssix        *SsixBox // Ssix following Sidx
 	Fragments   []*Fragment
 	EncOptimize EncOptimize
 }
@@ -30,6 +32,105 @@ func NewMediaSegmentWithoutStyp() *MediaSegment {
 	}
 }
 
+// DecodeMediaSegment - decode a single media segment without any init segment.
+// The segment may start with styp and one sidx box, followed by fragments
+// consisting of optional prft and emsg boxes followed by moof and mdat.
+// free and skip boxes are kept in the fragment they precede or, after the last mdat, in the last fragment.
+// free and skip boxes between moof and mdat are kept in place.
+// A second sidx and any other top-level box result in an error.
+func DecodeMediaSegment(r io.Reader) (*MediaSegment, error) {
+	s := NewMediaSegmentWithoutStyp()
+	var frag *Fragment
+	var pending []Box // free and skip boxes before the next fragment
+	var pos uint64
+	for {
+		box, err := DecodeBox(pos, r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		boxType := box.Type()
+		switch boxType {
+		case "styp":
+			if s.Styp != nil || len(s.Fragments) > 0 {
+				return nil, fmt.Errorf("styp at position %d not first in segment", pos)
+			}
+			s.Styp = box.(*StypBox)
+		case "sidx":
+			if len(s.Fragments) > 0 {
+				return nil, fmt.Errorf("sidx at position %d after first fragment", pos)
+			}
+			if s.Sidx != nil {
+				return nil, fmt.Errorf("second sidx at position %d not supported", pos)
+			}
+			s.Sidx = box.(*SidxBox)
+		case "ssix":
+			if s.Sidx == nil || len(s.Fragments) > 0 {
+				return nil, fmt.Errorf("ssix at position %d not following sidx", pos)
+			}
+			s.Ssix = box.(*SsixBox)
+		case "prft", "emsg", "moof":
+			if frag == nil || frag.Moof != nil {
+				frag = NewFragment()
+				s.AddFragment(frag)
+				for _, b := range pending {
+					frag.AddChild(b)
+				}
+				pending = nil
+			}
+			frag.AddChild(box)
+		case "mdat":
+			if frag == nil || frag.Moof == nil || frag.Mdat != nil {
+				return nil, fmt.Errorf("mdat at position %d not preceded by moof", pos)
+			}
+			frag.AddChild(box)
+		case "free", "skip":
+			if frag != nil && frag.Mdat == nil {
+				frag.AddChild(box) // Before or after moof in the current fragment
+			} else {
+				pending = append(pending, box)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected box %s at position %d in media segment", boxType, pos)
+		}
+		pos += box.Size()
+	}
+	if len(s.Fragments) == 0 {
+		return nil, fmt.Errorf("no fragment in media segment")
+	}
+	lastFrag := s.LastFragment()
+	if lastFrag.Mdat == nil {
+		return nil, fmt.Errorf("last fragment has no mdat")
+	}
+	for _, b := range pending {
+		lastFrag.AddChild(b)
+	}
+	return s, nil
+}
+
+// TrackFragmentInfo - timing and size information for one traf in a fragment.
+// Values are based on moof only, so sample durations and sizes only given as defaults
+// in the trex box of the init segment are counted as zero.
+type TrackFragmentInfo struct {
+	TrackID             uint32
+	SequenceNumber      uint32
+	BaseMediaDecodeTime uint64
+	Duration            uint64 // Sum of sample durations in track timescale
+	NrSamples           uint32
+	DataSize            uint64 // Sum of sample sizes in bytes
+}
+
+// GetTrackFragmentInfos - get timing and size information for all trafs in all fragments
+func (s *MediaSegment) GetTrackFragmentInfos() []TrackFragmentInfo {
+	var infos []TrackFragmentInfo
+	for _, f := range s.Fragments {
+		infos = append(infos, f.GetTrackFragmentInfos()...)
+	}
+	return infos
+}
+
 // AddFragment - Add a fragment to a MediaSegment
 func (s *MediaSegment) AddFragment(f *Fragment) {
 	s.Fragments = append(s.Fragments, f)
@@ -49,6 +150,9 @@ func (s *MediaSegment) Size() uint64 {
 	if s.Sidx != nil {
 		size += s.Sidx.Size()
 	}
+	if s.Ssix != nil {
+		size += s.Ssix.Size()
+	}
 	for _, f := range s.Fragments {
 		size += f.Size()
 	}
@@ -69,6 +173,12 @@ func (s *MediaSegment) Encode(w io.Writer) error {
 			return err
 		}
 	}
+	if s.Ssix != nil {
+		err := s.Ssix.Encode(w)
+		if err != nil {
+			return err
+		}
+	}
 	for _, f := range s.Fragments {
 		f.EncOptimize = s.EncOptimize
 		err := f.Encode(w)
@@ -93,6 +203,12 @@ func (s *MediaSegment) Info(w io.Writer, specificBoxLevels, indent, indentStep s
 			return err
 		}
 	}
+	if s.Ssix != nil {
+		err := s.Ssix.Info(w, specificBoxLevels, indent, indentStep)
+		if err != nil {
+			return err
+		}
+	}
 	for _, f := range s.Fragments {
 		err := f.Info(w, specificBoxLevels, indent, indentStep)
 		if err != nil {
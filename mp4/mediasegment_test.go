@@ -208,3 +208,93 @@ func BenchmarkDecodeEncode(b *testing.B) {
 		_ = f.Encode(&bufInSeg)
 	}
 }
+
+func TestDecodeMediaSegment(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/1.m4s")
+	if err != nil {
+		t.Error(err)
+	}
+	seg, err := DecodeMediaSegment(bytes.NewReader(data))
+	if err != nil {
+		t.Error(err)
+	}
+	if seg.Styp == nil || len(seg.Fragments) != 1 {
+		t.Errorf("expected styp and one fragment")
+	}
+	if seg.Size() != uint64(len(data)) {
+		t.Errorf("segment size %d instead of %d", seg.Size(), len(data))
+	}
+	infos := seg.GetTrackFragmentInfos()
+	expected := []TrackFragmentInfo{{TrackID: 2, SequenceNumber: 1, BaseMediaDecodeTime: 0,
+		Duration: 180000, NrSamples: 60, DataSize: uint64(len(seg.Fragments[0].Mdat.Data))}}
+	if diff := deep.Equal(infos, expected); diff != nil {
+		t.Error(diff)
+	}
+	var buf bytes.Buffer
+	err = seg.Encode(&buf)
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("re-encoded segment differs from input")
+	}
+
+	_, err = DecodeMediaSegment(bytes.NewReader(data[:24]))
+	if err == nil {
+		t.Errorf("expected error for segment without fragment")
+	}
+
+	encode := func(boxes ...Box) []byte {
+		t.Helper()
+		var out bytes.Buffer
+		for _, b := range boxes {
+			assertNoError(t, b.Encode(&out))
+		}
+		return out.Bytes()
+	}
+	frag := seg.Fragments[0]
+	free, skip := CreateFreeBox(4), &FreeBox{Name: "skip"}
+	withFree := encode(seg.Styp, free, frag.Moof, frag.Mdat, skip)
+	freeSeg, err := DecodeMediaSegment(bytes.NewReader(withFree))
+	assertNoError(t, err)
+	buf.Reset()
+	assertNoError(t, freeSeg.Encode(&buf))
+	if !bytes.Equal(buf.Bytes(), withFree) {
+		t.Errorf("free and skip boxes not kept")
+	}
+	twoFrags := encode(seg.Styp, frag.Moof, frag.Mdat, skip, frag.Moof, frag.Mdat)
+	twoSeg, err := DecodeMediaSegment(bytes.NewReader(twoFrags))
+	assertNoError(t, err)
+	if len(twoSeg.Fragments) != 2 || twoSeg.Fragments[1].Children[0].Type() != "skip" {
+		t.Errorf("skip box after mdat not kept in the following fragment")
+	}
+	sidx := &SidxBox{ReferenceID: 2, Timescale: 90000}
+	_, err = DecodeMediaSegment(bytes.NewReader(encode(seg.Styp, sidx, sidx, frag.Moof, frag.Mdat)))
+	assertError(t, err, "second sidx should give error")
+
+	trex := &TrexBox{TrackID: 2}
+	wantedSamples, err := frag.GetFullSamples(trex)
+	assertNoError(t, err)
+	freeFrag := NewFragment()
+	for _, b := range []Box{frag.Moof, free, frag.Mdat} {
+		freeFrag.AddChild(b)
+	}
+	buf.Reset()
+	assertNoError(t, freeFrag.Encode(&buf))
+	moofFreeMdat := append([]byte{}, buf.Bytes()...)
+	freeSeg, err = DecodeMediaSegment(bytes.NewReader(moofFreeMdat))
+	assertNoError(t, err)
+	if len(freeSeg.Fragments[0].Children) != 3 {
+		t.Errorf("free box between moof and mdat not kept")
+	}
+	samples, err := freeSeg.Fragments[0].GetFullSamples(trex)
+	assertNoError(t, err)
+	if len(samples) != len(wantedSamples) || !bytes.Equal(samples[0].Data, wantedSamples[0].Data) {
+		t.Errorf("wrong samples with free box between moof and mdat")
+	}
+	buf.Reset()
+	assertNoError(t, freeSeg.Encode(&buf))
+	if !bytes.Equal(buf.Bytes(), moofFreeMdat) {
+		t.Errorf("re-encoded segment with free between moof and mdat differs")
+	}
+}
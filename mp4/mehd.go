@@ -1,6 +1,7 @@
 package mp4
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 )
@@ -13,6 +14,15 @@ type MehdBox struct {
 	FragmentDuration int64
 }
 
+// NewMehdBox - create mehd box with version 0 if fragmentDuration fits in 32 bits, otherwise version 1
+func NewMehdBox(fragmentDuration uint64) *MehdBox {
+	b := &MehdBox{FragmentDuration: int64(fragmentDuration)}
+	if fragmentDuration > 0xffffffff {
+		b.Version = 1
+	}
+	return b
+}
+
 // DecodeMehd - box-specific decode
 func DecodeMehd(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	data, err := ioutil.ReadAll(r)
@@ -27,7 +37,7 @@ func DecodeMehd(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 		Flags:   versionAndFlags & flagsMask,
 	}
 	if version == 0 {
-		b.FragmentDuration = int64(s.ReadInt32())
+		b.FragmentDuration = int64(s.ReadUint32())
 	} else {
 		b.FragmentDuration = s.ReadInt64()
 	}
@@ -52,6 +62,9 @@ func (b *MehdBox) Size() uint64 {
 
 // Encode - write box to w
 func (b *MehdBox) Encode(w io.Writer) error {
+	if b.Version == 0 && (b.FragmentDuration < 0 || b.FragmentDuration > 0xffffffff) {
+		return fmt.Errorf("mehd fragment duration %d does not fit in version 0", b.FragmentDuration)
+	}
 	err := EncodeHeader(b, w)
 	if err != nil {
 		return err
@@ -62,7 +75,6 @@ func (b *MehdBox) Encode(w io.Writer) error {
 	sw.WriteUint32(versionAndFlags)
 	if b.Version == 0 {
 		sw.WriteUint32(uint32(b.FragmentDuration))
-
 	} else {
 		sw.WriteUint64(uint64(b.FragmentDuration))
 	}
@@ -76,3 +88,59 @@ func (b *MehdBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	bd.write(" - fragmentDuration: %d", b.FragmentDuration)
 	return bd.err
 }
+
+// SetMehd - set the mehd box, which is placed first in mvex
+func (m *MvexBox) SetMehd(mehd *MehdBox) {
+	if m.Mehd != nil {
+		for i, c := range m.Children {
+			if c == m.Mehd {
+				m.Children[i] = mehd
+				m.Mehd = mehd
+				return
+			}
+		}
+	}
+	m.Mehd = mehd
+	m.Children = append([]Box{mehd}, m.Children...)
+}
+
+// SetFragmentDuration - set overall duration of the fragmented movie (in movie timescale) in mvex/mehd
+func (s *InitSegment) SetFragmentDuration(duration uint64) error {
+	if s.Moov == nil || s.Moov.Mvex == nil {
+		return fmt.Errorf("no mvex box in init segment")
+	}
+	s.Moov.Mvex.SetMehd(NewMehdBox(duration))
+	return nil
+}
+
+// SetFragmentDuration - set mehd in the init segment to the duration of the longest track in the
+// fragments of the file, converted to movie timescale. The file must be completely decoded.
+func (f *File) SetFragmentDuration() error {
+	if !f.IsFragmented() || f.Init == nil {
+		return fmt.Errorf("not a fragmented file with init segment")
+	}
+	moov := f.Init.Moov
+	var duration uint64
+	for _, trak := range moov.Traks {
+		trackID := trak.Tkhd.TrackID
+		trex := f.TrexForTrack(trackID)
+		var trackDur uint64
+		for _, seg := range f.Segments {
+			for _, frag := range seg.Fragments {
+				for _, traf := range frag.Moof.Trafs {
+					if traf.Tfhd.TrackID != trackID {
+						continue
+					}
+					for _, trun := range traf.Truns {
+						trackDur += trun.AddSampleDefaultValues(traf.Tfhd, trex)
+					}
+				}
+			}
+		}
+		dur := trackDur * uint64(moov.Mvhd.Timescale) / uint64(trak.Mdia.Mdhd.Timescale)
+		if dur > duration {
+			duration = dur
+		}
+	}
+	return f.Init.SetFragmentDuration(duration)
+}
@@ -1,6 +1,7 @@
 package mp4
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -8,3 +9,40 @@ func TestMehd(t *testing.T) {
 	mehd := &MehdBox{FragmentDuration: 1234}
 	boxDiffAfterEncodeAndDecode(t, mehd)
 }
+
+func TestMehdVersions(t *testing.T) {
+	mehd := NewMehdBox(1 << 32)
+	if mehd.Version != 1 {
+		t.Errorf("got version %d instead of 1", mehd.Version)
+	}
+	boxDiffAfterEncodeAndDecode(t, mehd)
+	mehd = NewMehdBox(0xffffffff)
+	if mehd.Version != 0 {
+		t.Errorf("got version %d instead of 0", mehd.Version)
+	}
+	boxDiffAfterEncodeAndDecode(t, mehd)
+	mehd.FragmentDuration = 1 << 32
+	err := mehd.Encode(&bytes.Buffer{})
+	assertError(t, err, "too large version 0 duration should fail")
+}
+
+func TestSetFragmentDuration(t *testing.T) {
+	f := createTestOnDemandFile(t)
+	assertNoError(t, f.SetFragmentDuration())
+	mvex := f.Init.Moov.Mvex
+	if mvex.Mehd == nil || mvex.Children[0] != mvex.Mehd {
+		t.Fatalf("mehd not first in mvex")
+	}
+	wanted := int64(60 * 3600 * uint64(f.Init.Moov.Mvhd.Timescale) / 90000)
+	if mvex.Mehd.FragmentDuration != wanted {
+		t.Errorf("got fragment duration %d instead of %d", mvex.Mehd.FragmentDuration, wanted)
+	}
+	nrChildren := len(mvex.Children)
+	assertNoError(t, f.Init.SetFragmentDuration(42))
+	if len(mvex.Children) != nrChildren || mvex.Mehd.FragmentDuration != 42 {
+		t.Errorf("mehd not replaced")
+	}
+
+	err := (&File{}).SetFragmentDuration()
+	assertError(t, err, "non-fragmented file should fail")
+}
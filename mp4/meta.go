@@ -10,6 +10,11 @@ type MetaBox struct {
 	Version  byte
 	Flags    uint32
 	Hdlr     *HdlrBox
+	Pitm     *PitmBox
+	Iinf     *IinfBox
+	Iloc     *IlocBox
+	Idat     *IdatBox
+	Ilst     *IlstBox
 	Children []Box
 }
 
@@ -29,6 +34,16 @@ func (b *MetaBox) AddChild(box Box) {
 	switch box.Type() {
 	case "hdlr":
 		b.Hdlr = box.(*HdlrBox)
+	case "pitm":
+		b.Pitm = box.(*PitmBox)
+	case "iinf":
+		b.Iinf = box.(*IinfBox)
+	case "iloc":
+		b.Iloc = box.(*IlocBox)
+	case "idat":
+		b.Idat = box.(*IdatBox)
+	case "ilst":
+		b.Ilst = box.(*IlstBox)
 	}
 	b.Children = append(b.Children, box)
 }
@@ -70,6 +85,15 @@ func (b *MetaBox) GetChildren() []Box {
 	return b.Children
 }
 
+func (b *MetaBox) setChildren(children []Box) error {
+	n := MetaBox{Version: b.Version, Flags: b.Flags}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - write minf container to w
 func (b *MetaBox) Encode(w io.Writer) error {
 	err := EncodeHeader(b, w)
@@ -0,0 +1,166 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// CustomTag - iTunes custom tag (----) with reverse-DNS domain (mean), name, and UTF-8 value
+type CustomTag struct {
+	Mean  string
+	Name  string
+	Value string
+}
+
+// Metadata - iTunes-style metadata stored as items in moov/udta/meta/ilst.
+// Empty strings and a nil Cover mean that the corresponding item is not present.
+type Metadata struct {
+	Title   string
+	Artist  string
+	Album   string
+	Comment string
+	Genre   string
+	Date    string
+	Cover   *Image
+	Custom  []CustomTag
+}
+
+// textItems - item types with UTF-8 values and the corresponding Metadata fields
+func (m *Metadata) textItems() map[string]*string {
+	return map[string]*string{
+		IlstItemTitle:   &m.Title,
+		IlstItemArtist:  &m.Artist,
+		IlstItemAlbum:   &m.Album,
+		IlstItemComment: &m.Comment,
+		IlstItemGenre:   &m.Genre,
+		IlstItemDate:    &m.Date,
+	}
+}
+
+// GetMetadata - get iTunes-style metadata from moov/udta/meta/ilst.
+// An empty Metadata is returned if there is no ilst box.
+func (f *File) GetMetadata() (*Metadata, error) {
+	m := &Metadata{}
+	ilst := f.ilst()
+	if ilst == nil {
+		return m, nil
+	}
+	textItems := m.textItems()
+	for _, c := range ilst.Children {
+		switch item := c.(type) {
+		case *IlstItemBox:
+			if field, ok := textItems[item.Type()]; ok && item.Data != nil {
+				*field = string(item.Data.Data)
+				continue
+			}
+			if item.Type() == IlstItemCustomTag {
+				if item.Mean == nil || item.Name == nil || item.Data == nil {
+					return nil, fmt.Errorf("custom tag without mean, name, or data")
+				}
+				m.Custom = append(m.Custom, CustomTag{item.Mean.Text, item.Name.Text, string(item.Data.Data)})
+			}
+		case *CovrBox:
+			img, err := covrImage(item)
+			if err != nil {
+				return nil, err
+			}
+			if img != nil {
+				m.Cover = img
+			}
+		}
+	}
+	return m, nil
+}
+
+// SetMetadata - replace the title, artist, album, comment, genre, date, cover, and custom tag items
+// in moov/udta/meta/ilst by the values in m. Other items are kept. The udta, meta, and ilst boxes
+// are created if needed. Chunk offsets of progressive files are made consistent by Encode.
+func (f *File) SetMetadata(m *Metadata) error {
+	ilst, err := f.createIlst()
+	if err != nil {
+		return err
+	}
+	textItems := m.textItems()
+	var children []Box
+	for _, c := range ilst.Children {
+		_, isText := textItems[c.Type()]
+		if isText || c.Type() == IlstItemCustomTag || c.Type() == IlstItemCover {
+			continue
+		}
+		children = append(children, c)
+	}
+	ilst.Children = children
+	for _, itemType := range []string{IlstItemTitle, IlstItemArtist, IlstItemAlbum, IlstItemComment,
+		IlstItemGenre, IlstItemDate} {
+		if value := *textItems[itemType]; value != "" {
+			ilst.AddChild(CreateIlstItem(itemType, &DataBox{DataType: DataTypeUTF8, Data: []byte(value)}))
+		}
+	}
+	if m.Cover != nil {
+		dataType, err := imageDataType(m.Cover.MimeType)
+		if err != nil {
+			return err
+		}
+		covr := &CovrBox{}
+		covr.AddChild(&DataBox{DataType: dataType, Data: m.Cover.Data})
+		ilst.AddChild(covr)
+	}
+	for _, tag := range m.Custom {
+		ilst.AddChild(CreateIlstCustomTag(tag.Mean, tag.Name, tag.Value))
+	}
+	return nil
+}
+
+// ilst - ilst box in moov/udta/meta or nil
+func (f *File) ilst() *IlstBox {
+	if f.Moov == nil || f.Moov.Udta == nil || f.Moov.Udta.Meta == nil {
+		return nil
+	}
+	return f.Moov.Udta.Meta.Ilst
+}
+
+// createIlst - get ilst box in moov/udta/meta, creating udta, meta with mdir handler, and ilst if needed
+func (f *File) createIlst() (*IlstBox, error) {
+	if f.Moov == nil {
+		return nil, fmt.Errorf("no moov box")
+	}
+	moov := f.Moov
+	if moov.Udta == nil {
+		moov.AddChild(&UdtaBox{})
+	}
+	udta := moov.Udta
+	if udta.Meta == nil {
+		hdlr, err := CreateHdlr("mdir")
+		if err != nil {
+			return nil, err
+		}
+		udta.AddChild(CreateMetaBox(0, hdlr))
+	}
+	if udta.Meta.Ilst == nil {
+		udta.Meta.AddChild(&IlstBox{})
+	}
+	return udta.Meta.Ilst, nil
+}
+
+// covrImage - first image in covr item. nil if there is no data box
+func covrImage(covr *CovrBox) (*Image, error) {
+	for _, c := range covr.Children {
+		data, ok := c.(*DataBox)
+		if !ok {
+			continue
+		}
+		if data.TypeSet != 0 {
+			return nil, fmt.Errorf("covr: unknown type set %d", data.TypeSet)
+		}
+		switch data.DataType {
+		case DataTypeJPEG:
+			return &Image{MimeType: "image/jpeg", Data: data.Data}, nil
+		case DataTypePNG:
+			return &Image{MimeType: "image/png", Data: data.Data}, nil
+		case DataTypeBMP:
+			return &Image{MimeType: "image/bmp", Data: data.Data}, nil
+		default:
+			return nil, fmt.Errorf("covr: unknown data type %d", data.DataType)
+		}
+	}
+	return nil, nil
+}
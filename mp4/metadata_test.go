@@ -0,0 +1,60 @@
+package mp4
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestIlstItemBoxes(t *testing.T) {
+	ilst := &IlstBox{}
+	ilst.AddChild(CreateIlstItem(IlstItemTitle, &DataBox{DataType: DataTypeUTF8, Data: []byte("A title")}))
+	ilst.AddChild(CreateIlstCustomTag("com.apple.iTunes", "iTunSMPB", "0 1 2"))
+	boxDiffAfterEncodeAndDecode(t, ilst)
+}
+
+func TestSetAndGetMetadata(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(10, 3600, 100, 5, 0x10)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 300})
+	assertNoError(t, err)
+	md, err := f.GetMetadata()
+	assertNoError(t, err)
+	if !reflect.DeepEqual(md, &Metadata{}) {
+		t.Errorf("got metadata %+v for file without ilst", md)
+	}
+
+	md = &Metadata{
+		Title:  "Title",
+		Artist: "Artist",
+		Date:   "2021",
+		Cover:  &Image{MimeType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}},
+		Custom: []CustomTag{{Mean: "com.example", Name: "tag", Value: "value"}},
+	}
+	assertNoError(t, f.SetMetadata(md))
+	md.Title = "New title"
+	md.Artist = ""
+	assertNoError(t, f.SetMetadata(md))
+
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	got, err := decFile.GetMetadata()
+	assertNoError(t, err)
+	if !reflect.DeepEqual(got, md) {
+		t.Errorf("got metadata %+v instead of %+v", got, md)
+	}
+	if nrItems := len(decFile.Moov.Udta.Meta.Ilst.Children); nrItems != 4 {
+		t.Errorf("got %d ilst items instead of 4", nrItems)
+	}
+	trak := decFile.Moov.Trak
+	for nr := uint32(1); nr <= uint32(len(samples)); nr++ {
+		data := bytes.Buffer{}
+		assertNoError(t, decFile.CopySampleData(&data, nil, trak, nr, nr))
+		if !bytes.Equal(data.Bytes(), samples[nr-1].Data) {
+			t.Errorf("sample %d: data mismatch after setting metadata", nr)
+		}
+	}
+}
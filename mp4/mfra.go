@@ -76,6 +76,17 @@ func (m *MfraBox) GetChildren() []Box {
 	return m.Children
 }
 
+func (m *MfraBox) setChildren(children []Box) error {
+	n := MfraBox{StartPos: m.StartPos}
+	for _, c := range children {
+		if err := n.AddChild(c); err != nil {
+			return err
+		}
+	}
+	*m = n
+	return nil
+}
+
 // Info - write box-specific information
 func (m *MfraBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	return ContainerInfo(m, w, specificBoxLevels, indent, indentStep)
@@ -0,0 +1,75 @@
+package mp4
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// MhaCBox - MHADecoderConfigurationRecord box (mhaC) defined in ISO/IEC 23008-3 Section 20.5
+type MhaCBox struct {
+	ConfigVersion          byte
+	ProfileLevelIndicator  byte
+	ReferenceChannelLayout byte
+	Config                 []byte // mpegh3daConfig
+}
+
+// DecodeMhaC - box-specific decode
+func DecodeMhaC(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 5 {
+		return nil, fmt.Errorf("mhaC: too short %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	b := &MhaCBox{}
+	b.ConfigVersion = s.ReadUint8()
+	b.ProfileLevelIndicator = s.ReadUint8()
+	b.ReferenceChannelLayout = s.ReadUint8()
+	configLength := int(s.ReadUint16())
+	if configLength != s.NrRemainingBytes() {
+		return nil, fmt.Errorf("mhaC: config length %d, but %d bytes left", configLength, s.NrRemainingBytes())
+	}
+	b.Config = s.ReadBytes(configLength)
+	return b, nil
+}
+
+// Type - box type
+func (b *MhaCBox) Type() string {
+	return "mhaC"
+}
+
+// Size - calculated size of box
+func (b *MhaCBox) Size() uint64 {
+	return uint64(boxHeaderSize + 5 + len(b.Config))
+}
+
+// Encode - write box to w
+func (b *MhaCBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint8(b.ConfigVersion)
+	sw.WriteUint8(b.ProfileLevelIndicator)
+	sw.WriteUint8(b.ReferenceChannelLayout)
+	sw.WriteUint16(uint16(len(b.Config)))
+	sw.WriteBytes(b.Config)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *MhaCBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - configVersion: %d", b.ConfigVersion)
+	bd.write(" - profileLevelIndicator: %d", b.ProfileLevelIndicator)
+	bd.write(" - referenceChannelLayout: %d", b.ReferenceChannelLayout)
+	bd.write(" - mpegh3daConfig: %s", hex.EncodeToString(b.Config))
+	return bd.err
+}
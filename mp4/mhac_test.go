@@ -0,0 +1,33 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMhaC(t *testing.T) {
+	// Low complexity profile level 3 with stereo reference layout and 4 bytes of config
+	data := []byte{0x00, 0x00, 0x00, 0x11, 'm', 'h', 'a', 'C', 0x01, 0x0d, 0x02, 0x00, 0x04, 0x5d, 0x90, 0x00, 0x44}
+	box, err := DecodeBox(0, bytes.NewReader(data))
+	assertNoError(t, err)
+	mhaC := box.(*MhaCBox)
+	if mhaC.ConfigVersion != 1 || mhaC.ProfileLevelIndicator != 0x0d || mhaC.ReferenceChannelLayout != 2 || len(mhaC.Config) != 4 {
+		t.Errorf("got %+v", mhaC)
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, mhaC.Encode(&buf))
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("mhaC not byte-exact after encode")
+	}
+
+	mha1 := CreateAudioSampleEntryBox("mha1", 2, 16, 48000, mhaC)
+	decMha1 := boxAfterEncodeAndDecode(t, mha1).(*AudioSampleEntryBox)
+	if decMha1.Type() != "mha1" || decMha1.MhaC == nil {
+		t.Errorf("mha1 without mhaC after decode")
+	}
+	mhm1 := CreateAudioSampleEntryBox("mhm1", 0, 16, 48000, nil) // Configuration is in-band
+	decMhm1 := boxAfterEncodeAndDecode(t, mhm1).(*AudioSampleEntryBox)
+	if decMhm1.Type() != "mhm1" || decMhm1.SampleRate != 48000 {
+		t.Errorf("bad mhm1 after decode")
+	}
+}
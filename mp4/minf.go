@@ -64,6 +64,15 @@ func (m *MinfBox) GetChildren() []Box {
 	return m.Children
 }
 
+func (m *MinfBox) setChildren(children []Box) error {
+	n := MinfBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*m = n
+	return nil
+}
+
 // Encode - write minf container to w
 func (m *MinfBox) Encode(w io.Writer) error {
 	return EncodeContainer(m, w)
@@ -0,0 +1,99 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// MmapFile - File decoded from a read-only memory mapping of an input file.
+// The Data of the top-level mdat boxes, including those of fragments, refer into the mapping,
+// so sample data is not copied into memory until it is used. All other boxes are decoded into
+// memory as by DecodeFile. The mdat data must not be modified, and must not be used after Close.
+// On platforms or file systems without mmap support, the file is read into memory instead.
+type MmapFile struct {
+	*File
+	data   []byte
+	mapped bool // data is a memory mapping that must be released
+}
+
+// OpenMmapFile - memory map the file at path and decode it with top-level mdat data referring into the mapping.
+// options are applied as for DecodeFile, but the decode mode is always lazy mdat.
+func OpenMmapFile(path string, options ...Option) (*MmapFile, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	fi, err := fd.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size == 0 {
+		return nil, fmt.Errorf("file %s is empty", path)
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("file %s too large to map", path)
+	}
+	data, mapped, err := mmapFile(fd, int(size))
+	if err != nil {
+		return nil, fmt.Errorf("mmap %s: %w", path, err)
+	}
+	m := &MmapFile{data: data, mapped: mapped}
+	options = append(options, WithDecodeMode(DecModeLazyMdat))
+	f, err := DecodeFile(bytes.NewReader(data), options...)
+	if err != nil {
+		_ = m.Close()
+		return nil, err
+	}
+	for _, mdat := range f.mdats() {
+		start := mdat.StartPos + mdat.HeaderSize()
+		end := start + mdat.GetLazyDataSize()
+		if end > uint64(len(data)) {
+			_ = m.Close()
+			return nil, fmt.Errorf("mdat at %d extends beyond end of file", mdat.StartPos)
+		}
+		mdat.SetData(data[start:end:end])
+	}
+	m.File = f
+	return m, nil
+}
+
+// IsMapped - true if the file data is memory mapped and not read into memory, and Close has not been called
+func (m *MmapFile) IsMapped() bool {
+	return m.mapped
+}
+
+// Close - release the mapping. Box and sample data referring into the mapping must not be used afterwards.
+func (m *MmapFile) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	var err error
+	if m.mapped {
+		err = munmapFile(m.data)
+	}
+	m.data = nil
+	m.mapped = false
+	return err
+}
+
+// readFileData - read size bytes of fd into memory
+func readFileData(fd *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	_, err := io.ReadFull(fd, data)
+	return data, err
+}
+
+// mdats - all top-level mdat boxes in the file, including those of fragments
+func (f *File) mdats() []*MdatBox {
+	var mdats []*MdatBox
+	for _, c := range f.Children {
+		if mdat, ok := c.(*MdatBox); ok {
+			mdats = append(mdats, mdat)
+		}
+	}
+	return mdats
+}
@@ -0,0 +1,19 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package mp4
+
+import (
+	"os"
+)
+
+// mmapFile - read size bytes of fd into memory since mmap is not supported on this platform
+func mmapFile(fd *os.File, size int) (data []byte, mapped bool, err error) {
+	data, err = readFileData(fd, size)
+	return data, false, err
+}
+
+// munmapFile - nothing to release since data is normal memory
+func munmapFile(data []byte) error {
+	return nil
+}
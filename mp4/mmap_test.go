@@ -0,0 +1,48 @@
+package mp4
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestOpenMmapFile(t *testing.T) {
+	for _, fileName := range []string{"testdata/prog_8s.mp4", "testdata/1.m4s"} {
+		raw, err := os.ReadFile(fileName)
+		assertNoError(t, err)
+		mf, err := OpenMmapFile(fileName)
+		assertNoError(t, err)
+
+		var buf bytes.Buffer
+		err = mf.Encode(&buf)
+		assertNoError(t, err)
+		if !bytes.Equal(buf.Bytes(), raw) {
+			t.Errorf("%s: encoded mmap file differs from input", fileName)
+		}
+		for _, mdat := range mf.mdats() {
+			if mdat.IsLazy() {
+				t.Errorf("%s: mdat at %d is still lazy", fileName, mdat.StartPos)
+			}
+		}
+
+		if mf.Mdat != nil {
+			ranges, err := mf.Moov.Trak.GetRangesForSampleInterval(1, 10)
+			assertNoError(t, err)
+			for _, r := range ranges {
+				data, err := mf.Mdat.ReadData(int64(r.Offset), int64(r.Size), nil)
+				assertNoError(t, err)
+				if !bytes.Equal(data, raw[r.Offset:r.Offset+r.Size]) {
+					t.Errorf("%s: data at %d differs", fileName, r.Offset)
+				}
+			}
+		}
+
+		err = mf.Close()
+		assertNoError(t, err)
+		if mf.IsMapped() {
+			t.Errorf("%s: file still mapped after Close", fileName)
+		}
+		err = mf.Close()
+		assertNoError(t, err)
+	}
+}
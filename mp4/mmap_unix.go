@@ -0,0 +1,25 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package mp4
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile - map size bytes of fd read-only into memory. If the file system does not support
+// memory mapping, the data is read into memory instead and mapped is false.
+func mmapFile(fd *os.File, size int) (data []byte, mapped bool, err error) {
+	data, err = syscall.Mmap(int(fd.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err == syscall.ENODEV {
+		data, err = readFileData(fd, size)
+		return data, false, err
+	}
+	return data, err == nil, err
+}
+
+// munmapFile - release mapping made by mmapFile
+func munmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -84,6 +84,17 @@ func (m *MoofBox) GetChildren() []Box {
 	return m.Children
 }
 
+func (m *MoofBox) setChildren(children []Box) error {
+	n := MoofBox{StartPos: m.StartPos}
+	for _, c := range children {
+		if err := n.AddChild(c); err != nil {
+			return err
+		}
+	}
+	*m = n
+	return nil
+}
+
 // Info - write box-specific information
 func (m *MoofBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	return ContainerInfo(m, w, specificBoxLevels, indent, indentStep)
@@ -1,6 +1,7 @@
 package mp4
 
 import (
+	"fmt"
 	"io"
 )
 
@@ -12,6 +13,7 @@ type MoovBox struct {
 	Trak     *TrakBox // The first trak box
 	Traks    []*TrakBox
 	Mvex     *MvexBox
+	Udta     *UdtaBox
 	Children []Box
 }
 
@@ -43,6 +45,8 @@ func (m *MoovBox) AddChild(box Box) {
 		}
 	case "mvex":
 		m.Mvex = box.(*MvexBox)
+	case "udta":
+		m.Udta = box.(*UdtaBox)
 	}
 	m.Children = append(m.Children, box)
 }
@@ -75,6 +79,16 @@ func (m *MoovBox) GetChildren() []Box {
 	return m.Children
 }
 
+func (m *MoovBox) setChildren(children []Box) error {
+	n := MoovBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	n.Children = children // AddChild moves trak boxes before mvex, but keep the given order
+	*m = n
+	return nil
+}
+
 // Encode - write moov container to w
 func (m *MoovBox) Encode(w io.Writer) error {
 	return EncodeContainer(m, w)
@@ -84,3 +98,134 @@ func (m *MoovBox) Encode(w io.Writer) error {
 func (m *MoovBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	return ContainerInfo(m, w, specificBoxLevels, indent, indentStep)
 }
+
+// keepTracks - remove trak and trex boxes for tracks not in trackIDs
+func (m *MoovBox) keepTracks(trackIDs []uint32) {
+	children := make([]Box, 0, len(m.Children))
+	m.Trak = nil
+	m.Traks = nil
+	for _, child := range m.Children {
+		if trak, ok := child.(*TrakBox); ok {
+			if !containsTrackID(trackIDs, trak.Tkhd.TrackID) {
+				continue
+			}
+			if m.Trak == nil {
+				m.Trak = trak
+			}
+			m.Traks = append(m.Traks, trak)
+		}
+		children = append(children, child)
+	}
+	m.Children = children
+	for _, trak := range m.Traks {
+		trak.updateTrackReferences(func(trackID uint32) (uint32, bool) {
+			return trackID, containsTrackID(trackIDs, trackID)
+		})
+	}
+	if m.Mvex != nil {
+		m.Mvex.keepTracks(trackIDs)
+	}
+}
+
+func containsTrackID(trackIDs []uint32, trackID uint32) bool {
+	for _, id := range trackIDs {
+		if id == trackID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTrak - get trak with trackID. Returns nil if not found
+func (m *MoovBox) GetTrak(trackID uint32) *TrakBox {
+	for _, trak := range m.Traks {
+		if trak.Tkhd.TrackID == trackID {
+			return trak
+		}
+	}
+	return nil
+}
+
+// NextFreeTrackID - one higher than the highest trackID among the trak boxes
+func (m *MoovBox) NextFreeTrackID() uint32 {
+	var maxTrackID uint32
+	for _, trak := range m.Traks {
+		if trak.Tkhd.TrackID > maxTrackID {
+			maxTrackID = trak.Tkhd.TrackID
+		}
+	}
+	return maxTrackID + 1
+}
+
+// SyncTrackIDs - make mvhd.NextTrackID and trex boxes consistent with the tkhd trackIDs.
+// If mvex is present, missing trex boxes are created and trex boxes without trak are removed.
+func (m *MoovBox) SyncTrackIDs() {
+	if m.Mvhd != nil {
+		m.Mvhd.NextTrackID = m.NextFreeTrackID()
+	}
+	if m.Mvex == nil {
+		return
+	}
+	trackIDs := make([]uint32, 0, len(m.Traks))
+	for _, trak := range m.Traks {
+		trackIDs = append(trackIDs, trak.Tkhd.TrackID)
+	}
+	m.Mvex.keepTracks(trackIDs)
+	for _, trackID := range trackIDs {
+		if m.Mvex.GetTrex(trackID) == nil {
+			m.Mvex.AddChild(CreateTrex(trackID))
+		}
+	}
+}
+
+// RemoveTrack - remove trak and trex boxes for trackID and update mvhd.NextTrackID
+func (m *MoovBox) RemoveTrack(trackID uint32) error {
+	if m.GetTrak(trackID) == nil {
+		return fmt.Errorf("no trak with trackID=%d", trackID)
+	}
+	var trackIDs []uint32
+	for _, trak := range m.Traks {
+		if trak.Tkhd.TrackID != trackID {
+			trackIDs = append(trackIDs, trak.Tkhd.TrackID)
+		}
+	}
+	m.keepTracks(trackIDs)
+	m.SyncTrackIDs()
+	m.bumpModificationTime()
+	return nil
+}
+
+// SetTrackID - change trackID of a track in tkhd, trex and the tref boxes of all tracks,
+// and update mvhd.NextTrackID. Use File.SetTrackID to also change the fragments of a file.
+func (m *MoovBox) SetTrackID(oldTrackID, newTrackID uint32) error {
+	if newTrackID == 0 {
+		return fmt.Errorf("trackID 0 is not allowed")
+	}
+	trak := m.GetTrak(oldTrackID)
+	if trak == nil {
+		return fmt.Errorf("no trak with trackID=%d", oldTrackID)
+	}
+	if oldTrackID == newTrackID {
+		return nil
+	}
+	if m.GetTrak(newTrackID) != nil {
+		return fmt.Errorf("trackID=%d already in use", newTrackID)
+	}
+	trak.Tkhd.TrackID = newTrackID
+	if m.Mvex != nil {
+		if trex := m.Mvex.GetTrex(oldTrackID); trex != nil {
+			trex.TrackID = newTrackID
+		}
+	}
+	for _, t := range m.Traks {
+		t.updateTrackReferences(func(trackID uint32) (uint32, bool) {
+			if trackID == oldTrackID {
+				return newTrackID, true
+			}
+			return trackID, true
+		})
+	}
+	m.SyncTrackIDs()
+	m.bumpModificationTime()
+	return nil
+}
@@ -0,0 +1,137 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// MoovFitMode - how to handle a moov box before mdat that changed size in a progressive file
+type MoovFitMode byte
+
+const (
+	// MoovFitShiftOffsets - move mdat and change chunk offsets in stco/co64 accordingly
+	MoovFitShiftOffsets MoovFitMode = iota
+	// MoovFitPadding - keep mdat in place by adding, growing, or shrinking a free box just before mdat.
+	// Chunk offsets are shifted if the moov box grew more than the free space available.
+	MoovFitPadding
+)
+
+// FitMoov - make chunk offsets consistent after the size of boxes before mdat has changed.
+// This is typically the case after editing moov in a progressive file.
+// Mdat.StartPos is the position that the chunk offsets refer to. Depending on f.MoovFit,
+// either the chunk offsets are shifted, or free padding is used to keep mdat at that position.
+// Nothing is done if the position is unknown, i.e. if mdat was not decoded and StartPos is not set,
+// since the chunk offsets of a file built by hand are expected to be final.
+// Encode fits moov in the same way, but on copies of the changed boxes, so that f is not changed.
+// For a lazily decoded mdat, call FitMoov only when all sample data has been read, since shifted
+// offsets no longer point into the source file.
+func (f *File) FitMoov() error {
+	_, err := f.fitMoov()
+	return err
+}
+
+// moovFitDelta - how much the chunk offsets are off since the size of the boxes before mdat changed.
+// ok is false if there is nothing to fit.
+func (f *File) moovFitDelta() (delta int64, ok bool) {
+	if f.isFragmented || f.Mdat == nil || f.Moov == nil {
+		return 0, false
+	}
+	if !f.Mdat.decoded && f.Mdat.StartPos == 0 {
+		return 0, false
+	}
+	delta = int64(f.sizeBeforeMdat()) - int64(f.Mdat.StartPos)
+	return delta, delta != 0
+}
+
+// fitMoov - FitMoov returning by how much the chunk offsets were shifted
+func (f *File) fitMoov() (shift int64, err error) {
+	delta, ok := f.moovFitDelta()
+	if !ok {
+		return 0, nil
+	}
+	if f.MoovFit == MoovFitPadding {
+		if children, ok := paddedBeforeMdat(f.Children, childIndex(f.Children, f.Mdat), delta); ok {
+			f.Children = children
+			return 0, nil
+		}
+	}
+	err = f.Moov.shiftChunkOffsets(delta)
+	if err != nil {
+		return 0, err
+	}
+	f.Mdat.StartPos = uint64(int64(f.Mdat.StartPos) + delta)
+	return delta, nil
+}
+
+// fittedForEncode - top-level boxes to encode so that chunk offsets are consistent as after FitMoov.
+// children must have the same order as f.Children, but may have copies of some boxes.
+// f is not changed. A moov box with shifted chunk offsets is a copy, and changed padding is a new free box.
+func (f *File) fittedForEncode(children []Box) ([]Box, error) {
+	delta, ok := f.moovFitDelta()
+	if !ok {
+		return children, nil
+	}
+	if f.MoovFit == MoovFitPadding {
+		if padded, ok := paddedBeforeMdat(children, childIndex(f.Children, f.Mdat), delta); ok {
+			return padded, nil
+		}
+	}
+	moovIdx := childIndex(f.Children, f.Moov)
+	if moovIdx < 0 {
+		return nil, fmt.Errorf("moov not among top-level boxes")
+	}
+	moov, err := copyMoov(f.Moov)
+	if err != nil {
+		return nil, err
+	}
+	err = moov.shiftChunkOffsets(delta)
+	if err != nil {
+		return nil, err
+	}
+	fitted := append([]Box(nil), children...)
+	fitted[moovIdx] = moov
+	return fitted, nil
+}
+
+// copyMoov - deep copy of moov made by encoding and decoding it
+func copyMoov(moov *MoovBox) (*MoovBox, error) {
+	buf := bytes.Buffer{}
+	err := moov.Encode(&buf)
+	if err != nil {
+		return nil, err
+	}
+	box, err := DecodeBox(0, &buf)
+	if err != nil {
+		return nil, err
+	}
+	return box.(*MoovBox), nil
+}
+
+// paddedBeforeMdat - copy of children where the free space just before the mdat box at mdatIdx
+// compensates for delta more bytes before mdat. A changed free box is replaced by a new one.
+// Returns false if not possible.
+func paddedBeforeMdat(children []Box, mdatIdx int, delta int64) ([]Box, bool) {
+	if mdatIdx < 0 {
+		return nil, false
+	}
+	var free *FreeBox
+	if mdatIdx > 0 {
+		if b, ok := children[mdatIdx-1].(*FreeBox); ok {
+			free = b
+		}
+	}
+	padded := make([]Box, 0, len(children)+1)
+	switch {
+	case free != nil && int64(len(free.notDecoded)) >= delta:
+		padded = append(padded, children[:mdatIdx-1]...)
+		padded = append(padded, &FreeBox{Name: free.Name, notDecoded: make([]byte, int64(len(free.notDecoded))-delta)})
+	case free != nil && int64(free.Size()) == delta:
+		padded = append(padded, children[:mdatIdx-1]...)
+	case free == nil && delta <= -boxHeaderSize:
+		padded = append(padded, children[:mdatIdx]...)
+		padded = append(padded, CreateFreeBox(int(-delta)-boxHeaderSize))
+	default:
+		return nil, false
+	}
+	return append(padded, children[mdatIdx:]...), true
+}
@@ -0,0 +1,94 @@
+package mp4
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestFitMoov(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(20, 3600, 300, 10, 0x50)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 1000})
+	assertNoError(t, err)
+
+	// Growing moov without free space should shift chunk offsets
+	f.MoovFit = MoovFitPadding
+	origMdatPos := f.Mdat.StartPos
+	f.Moov.AddChild(CreateFreeBox(100))
+	assertNoError(t, f.FitMoov())
+	mdatPos := f.Mdat.StartPos
+	if mdatPos != origMdatPos+108 {
+		t.Errorf("mdat StartPos %d instead of %d", mdatPos, origMdatPos+108)
+	}
+	checkProgSampleData(t, f, samples)
+
+	// Shrinking moov with padding should insert a free box and keep mdat in place
+	f.Moov.Children = f.Moov.Children[:len(f.Moov.Children)-1]
+	assertNoError(t, f.FitMoov())
+	if f.Mdat.StartPos != mdatPos || f.sizeBeforeMdat() != mdatPos {
+		t.Errorf("mdat moved from %d to %d", mdatPos, f.sizeBeforeMdat())
+	}
+	if f.Children[2].Type() != "free" {
+		t.Errorf("no free box inserted before mdat")
+	}
+	checkProgSampleData(t, f, samples)
+
+	// Growing moov within the free space should shrink the free box
+	f.Moov.AddChild(&UdtaBox{})
+	assertNoError(t, f.FitMoov())
+	if f.Mdat.StartPos != mdatPos || f.sizeBeforeMdat() != mdatPos {
+		t.Errorf("mdat moved from %d to %d", mdatPos, f.sizeBeforeMdat())
+	}
+	checkProgSampleData(t, f, samples)
+
+	// Growing moov beyond the free space should shift chunk offsets
+	f.Moov.AddChild(CreateFreeBox(1000))
+	assertNoError(t, f.FitMoov())
+	if f.Mdat.StartPos != f.sizeBeforeMdat() || f.Mdat.StartPos <= mdatPos {
+		t.Errorf("mdat StartPos %d not updated", f.Mdat.StartPos)
+	}
+	checkProgSampleData(t, f, samples)
+
+	// Default mode shifts chunk offsets when encoding, but f is left unchanged
+	f.MoovFit = MoovFitShiftOffsets
+	f.Moov.Children = f.Moov.Children[:len(f.Moov.Children)-1]
+	mdatPos = f.Mdat.StartPos
+	offsets := append([]uint32(nil), f.Moov.Trak.Mdia.Minf.Stbl.Stco.ChunkOffset...)
+	checkProgSampleData(t, f, samples)
+	if f.Mdat.StartPos != mdatPos || f.Moov.Trak.Mdia.Minf.Stbl.Stco.ChunkOffset[0] != offsets[0] {
+		t.Errorf("file changed by encode")
+	}
+
+	// Padding mode changes free space when encoding, but f is left unchanged
+	f.MoovFit = MoovFitPadding
+	assertNoError(t, f.FitMoov())
+	f.Moov.AddChild(&UdtaBox{})
+	children := append([]Box(nil), f.Children...)
+	free := f.Children[2].(*FreeBox)
+	freeSize := free.Size()
+	checkProgSampleData(t, f, samples)
+	if diff := deep.Equal(f.Children, children); diff != nil || free.Size() != freeSize {
+		t.Errorf("file changed by encode with padding: %v", diff)
+	}
+}
+
+func TestEncodeHandBuiltProgressiveFile(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(20, 3600, 300, 10, 0x50)
+	created, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 1000})
+	assertNoError(t, err)
+
+	// Build a file with final chunk offsets without setting mdat StartPos
+	f := NewFile()
+	f.AddChild(created.Ftyp, 0)
+	f.AddChild(created.Moov, 0)
+	f.AddChild(&MdatBox{Data: created.Mdat.Data}, 0)
+	offsets := append([]uint32(nil), f.Moov.Trak.Mdia.Minf.Stbl.Stco.ChunkOffset...)
+	checkProgSampleData(t, f, samples)
+	if diff := deep.Equal(f.Moov.Trak.Mdia.Minf.Stbl.Stco.ChunkOffset, offsets); diff != nil {
+		t.Errorf("chunk offsets changed: %v", diff)
+	}
+}
@@ -0,0 +1,128 @@
+package mp4
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// mp4Epoch - start of time for creation and modification times in mvhd, tkhd, and mdhd
+var mp4Epoch = time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// timeNow - current time. Can be replaced in tests
+var timeNow = time.Now
+
+var reproducibleEncode int32 // 1 if wall-clock times are zeroed when encoding
+
+// SetReproducibleEncode - turn on or off reproducible encoding for the whole library.
+// The setting is process-global and applies to encoding in all goroutines.
+// When on, creation and modification times in mvhd, tkhd, and mdhd are written as 0 without
+// changing the boxes. Other timestamps, such as NTP timestamps in prft, are media data and are
+// written unchanged. The library does not generate any random IDs and box order only depends on
+// the box tree, so the same content then always gives byte-identical output, whether encoded as
+// File, InitSegment, MediaSegment, Fragment, or single boxes.
+func SetReproducibleEncode(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&reproducibleEncode, v)
+}
+
+// encodedTime - wall-clock time t to write, which is 0 for reproducible encoding
+func encodedTime(t uint64) uint64 {
+	if atomic.LoadInt32(&reproducibleEncode) != 0 {
+		return 0
+	}
+	return t
+}
+
+// MP4TimeToTime - convert seconds since 1904-01-01 UTC to time.Time in UTC.
+// Times beyond what time.Time can represent give the latest representable time.
+func MP4TimeToTime(mp4Time uint64) time.Time {
+	// time.Time counts int64 seconds from year 1, which is before mp4Epoch
+	maxMP4Time := uint64(math.MaxInt64 - (mp4Epoch.Unix() - time.Time{}.Unix()))
+	if mp4Time > maxMP4Time {
+		mp4Time = maxMP4Time
+	}
+	return time.Unix(mp4Epoch.Unix()+int64(mp4Time), 0).UTC()
+}
+
+// TimeToMP4Time - convert time.Time to seconds since 1904-01-01 UTC. Times before 1904 give 0.
+func TimeToMP4Time(t time.Time) uint64 {
+	if t.Before(mp4Epoch) {
+		return 0
+	}
+	return uint64(t.Unix() - mp4Epoch.Unix())
+}
+
+// ntpEpoch - start of time for NTP timestamps
+var ntpEpoch = time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// NTPTimestampToTime - convert 64-bit NTP timestamp (32 bits seconds since 1900-01-01 UTC and 32 bits fraction)
+// to time.Time in UTC
+func NTPTimestampToTime(ntp uint64) time.Time {
+	secs := int64(ntp >> 32)
+	nanos := int64((ntp & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(ntpEpoch.Unix()+secs, nanos).UTC()
+}
+
+// TimeToNTPTimestamp - convert time.Time to 64-bit NTP timestamp. Times before 1900 give 0.
+func TimeToNTPTimestamp(t time.Time) uint64 {
+	if t.Before(ntpEpoch) {
+		return 0
+	}
+	secs := uint64(t.Unix() - ntpEpoch.Unix())
+	frac := (uint64(t.Nanosecond()) << 32) / 1e9
+	return secs<<32 | frac
+}
+
+// timeVersion - version needed for time value in mvhd, tkhd, or mdhd
+func timeVersion(currVersion byte, mp4Time uint64) byte {
+	if mp4Time > math.MaxUint32 {
+		return 1
+	}
+	return currVersion
+}
+
+// ZeroTimes - set creation and modification times to 0 in mvhd and all tkhd and mdhd boxes.
+// Useful for reproducible output.
+func (m *MoovBox) ZeroTimes() {
+	if m.Mvhd != nil {
+		m.Mvhd.CreationTime, m.Mvhd.ModificationTime = 0, 0
+	}
+	for _, trak := range m.Traks {
+		if trak.Tkhd != nil {
+			trak.Tkhd.CreationTime, trak.Tkhd.ModificationTime = 0, 0
+		}
+		if trak.Mdia != nil && trak.Mdia.Mdhd != nil {
+			trak.Mdia.Mdhd.CreationTime, trak.Mdia.Mdhd.ModificationTime = 0, 0
+		}
+	}
+}
+
+// SetTimes - set creation and modification times to t in mvhd and all tkhd and mdhd boxes
+func (m *MoovBox) SetTimes(t time.Time) {
+	if m.Mvhd != nil {
+		m.Mvhd.SetCreationTime(t)
+		m.Mvhd.SetModificationTime(t)
+	}
+	for _, trak := range m.Traks {
+		if trak.Tkhd != nil {
+			trak.Tkhd.SetCreationTime(t)
+			trak.Tkhd.SetModificationTime(t)
+		}
+		if trak.Mdia != nil && trak.Mdia.Mdhd != nil {
+			trak.Mdia.Mdhd.SetCreationTime(t)
+			trak.Mdia.Mdhd.SetModificationTime(t)
+		}
+	}
+}
+
+// bumpModificationTime - set mvhd modification time to now after an edit.
+// Zero times are kept, so that output with zeroed times stays reproducible.
+func (m *MoovBox) bumpModificationTime() {
+	if m.Mvhd != nil && m.Mvhd.ModificationTime != 0 {
+		m.Mvhd.SetModificationTime(timeNow())
+	}
+}
@@ -0,0 +1,75 @@
+package mp4
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMP4TimeConversion(t *testing.T) {
+	tm := time.Date(2021, time.March, 4, 12, 30, 15, 0, time.UTC)
+	mp4Time := TimeToMP4Time(tm)
+	if mp4Time != 3697705815 {
+		t.Errorf("got mp4 time %d instead of 3697705815", mp4Time)
+	}
+	if got := MP4TimeToTime(mp4Time); !got.Equal(tm) {
+		t.Errorf("got time %s instead of %s", got, tm)
+	}
+	if got := TimeToMP4Time(time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)); got != 0 {
+		t.Errorf("time before 1904 should give 0, not %d", got)
+	}
+	// More than 292 years would overflow time.Duration
+	late := time.Date(2300, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if got := MP4TimeToTime(TimeToMP4Time(late)); !got.Equal(late) {
+		t.Errorf("got time %s instead of %s", got, late)
+	}
+	if got := MP4TimeToTime(math.MaxUint64); got.Before(late) {
+		t.Errorf("max mp4 time gave %s", got)
+	}
+}
+
+func TestSetTimesVersion(t *testing.T) {
+	mdhd := &MdhdBox{Timescale: 1000}
+	late := time.Date(2050, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mdhd.SetCreationTime(late)
+	if mdhd.Version != 1 {
+		t.Errorf("time after 2040 should give version 1")
+	}
+	decMdhd := boxAfterEncodeAndDecode(t, mdhd).(*MdhdBox)
+	if got := decMdhd.GetCreationTime(); !got.Equal(late) {
+		t.Errorf("got creation time %s instead of %s", got, late)
+	}
+}
+
+func TestModificationTimeBump(t *testing.T) {
+	now := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	if init.Moov.Mvhd.ModificationTime != 0 {
+		t.Errorf("zero modification time should not be bumped")
+	}
+	created := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	init.Moov.SetTimes(created)
+	init.AddEmptyTrack(48000, "audio", "und")
+	if got := init.Moov.Mvhd.GetModificationTime(); !got.Equal(now) {
+		t.Errorf("got modification time %s instead of %s", got, now)
+	}
+	if got := init.Moov.Mvhd.GetCreationTime(); !got.Equal(created) {
+		t.Errorf("creation time should not change")
+	}
+
+	init.Moov.ZeroTimes()
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	decInit, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	for _, trak := range decInit.Moov.Traks {
+		if trak.Tkhd.CreationTime != 0 || trak.Mdia.Mdhd.ModificationTime != 0 {
+			t.Errorf("times should be zero after ZeroTimes")
+		}
+	}
+}
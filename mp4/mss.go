@@ -0,0 +1,49 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// ConvertMSSTiming - convert Microsoft Smooth Streaming timing in the fragment to CMAF.
+// For every traf with a tfxd box, the tfdt base media decode time is set to the tfxd absolute time,
+// which is in track timescale, and a tfdt box is added after tfhd if missing.
+// The tfxd and tfrf boxes are then removed, and data offsets are updated for the new moof size.
+func (f *Fragment) ConvertMSSTiming() error {
+	sizeBefore := f.Moof.Size()
+	for _, traf := range f.Moof.Trafs {
+		if traf.Tfxd == nil {
+			if traf.Tfdt == nil {
+				return fmt.Errorf("traf without tfxd and tfdt")
+			}
+			continue
+		}
+		absTime := traf.Tfxd.Tfxd.FragmentAbsoluteTime
+		if traf.Tfdt == nil {
+			tfdt := CreateTfdt(absTime)
+			idx := 0
+			for i, c := range traf.Children {
+				if c == traf.Tfhd {
+					idx = i + 1
+				}
+			}
+			err := InsertChild(traf, idx, tfdt)
+			if err != nil {
+				return err
+			}
+		} else {
+			traf.Tfdt.SetBaseMediaDecodeTime(absTime)
+		}
+		for _, u := range []*UUIDBox{traf.Tfxd, traf.Tfrf} {
+			if u == nil {
+				continue
+			}
+			err := RemoveChild(traf, u)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	f.shiftDataOffsets(int64(f.Moof.Size()) - int64(sizeBefore))
+	f.setSencSaioOffsets()
+	return nil
+}
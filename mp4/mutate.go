@@ -0,0 +1,217 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// InsertChild - insert child at position index among the children of parent.
+// Typed child fields of parent (e.g. MoovBox.Mvex or TrafBox.Truns) are updated.
+// Sizes are always calculated from the children, so all ancestors get correct sizes.
+// Use File.InsertChild to also update offsets in stco/co64, tfhd, trun, and saio boxes.
+func InsertChild(parent ContainerBox, index int, child Box) error {
+	children := parent.GetChildren()
+	if index < 0 || index > len(children) {
+		return fmt.Errorf("index %d out of range for %s with %d children", index, parent.Type(), len(children))
+	}
+	newChildren := make([]Box, 0, len(children)+1)
+	newChildren = append(newChildren, children[:index]...)
+	newChildren = append(newChildren, child)
+	newChildren = append(newChildren, children[index:]...)
+	return setChildren(parent, newChildren)
+}
+
+// RemoveChild - remove child from the children of parent and update typed child fields.
+// Use File.RemoveChild to also update offsets.
+func RemoveChild(parent ContainerBox, child Box) error {
+	children := parent.GetChildren()
+	idx := childIndex(children, child)
+	if idx < 0 {
+		return fmt.Errorf("%s box is not a child of %s", child.Type(), parent.Type())
+	}
+	newChildren := make([]Box, 0, len(children)-1)
+	newChildren = append(newChildren, children[:idx]...)
+	newChildren = append(newChildren, children[idx+1:]...)
+	return setChildren(parent, newChildren)
+}
+
+// ReplaceChild - replace oldChild by newChild at the same position and update typed child fields.
+// Use File.ReplaceChild to also update offsets.
+func ReplaceChild(parent ContainerBox, oldChild, newChild Box) error {
+	children := parent.GetChildren()
+	idx := childIndex(children, oldChild)
+	if idx < 0 {
+		return fmt.Errorf("%s box is not a child of %s", oldChild.Type(), parent.Type())
+	}
+	newChildren := make([]Box, len(children))
+	copy(newChildren, children)
+	newChildren[idx] = newChild
+	return setChildren(parent, newChildren)
+}
+
+func childIndex(children []Box, child Box) int {
+	for i, c := range children {
+		if c == child {
+			return i
+		}
+	}
+	return -1
+}
+
+// childSetter - container whose children can be replaced.
+// setChildren rebuilds the box from its own header fields (e.g. version, flags, or sample entry values)
+// and the new children, which are added one by one with AddChild. Typed child fields (e.g. MoovBox.Mvex)
+// therefore only refer to the new children, and any other state derived from the old children is dropped.
+type childSetter interface {
+	setChildren(children []Box) error
+}
+
+// setChildren - set children of parent in the given order, and set typed child fields
+func setChildren(parent ContainerBox, children []Box) error {
+	p, ok := parent.(childSetter)
+	if !ok {
+		return fmt.Errorf("changing children of %s is not supported", parent.Type())
+	}
+	return p.setChildren(children)
+}
+
+// InsertChild - insert child at position index among the children of parent, which must be a box in f.
+// Dependent offsets are updated as described for File.RemoveChild.
+func (f *File) InsertChild(parent ContainerBox, index int, child Box) error {
+	positions := f.boxPositions()
+	err := InsertChild(parent, index, child)
+	if err != nil {
+		return err
+	}
+	return f.updateOffsets(positions)
+}
+
+// RemoveChild - remove child from parent, which must be a box in f, and update dependent offsets.
+// If media data moves, the chunk offsets in stco/co64 and the base data offsets in tfhd are changed
+// accordingly. Data offsets in trun and offsets in saio are changed so that they point to the same data.
+// StartPos of top-level boxes is also updated.
+func (f *File) RemoveChild(parent ContainerBox, child Box) error {
+	positions := f.boxPositions()
+	err := RemoveChild(parent, child)
+	if err != nil {
+		return err
+	}
+	return f.updateOffsets(positions)
+}
+
+// ReplaceChild - replace oldChild by newChild in parent, which must be a box in f.
+// Dependent offsets are updated as described for File.RemoveChild.
+func (f *File) ReplaceChild(parent ContainerBox, oldChild, newChild Box) error {
+	positions := f.boxPositions()
+	err := ReplaceChild(parent, oldChild, newChild)
+	if err != nil {
+		return err
+	}
+	return f.updateOffsets(positions)
+}
+
+// boxPositions - absolute positions of top-level boxes and traf children calculated from sizes
+func (f *File) boxPositions() map[Box]uint64 {
+	positions := make(map[Box]uint64, len(f.Children))
+	pos := uint64(0)
+	for _, c := range f.Children {
+		positions[c] = pos
+		if moof, ok := c.(*MoofBox); ok {
+			addMoofPositions(positions, moof, pos)
+		}
+		pos += c.Size()
+	}
+	return positions
+}
+
+// addMoofPositions - add absolute positions of moof starting at pos and of its traf children to positions
+func addMoofPositions(positions map[Box]uint64, moof *MoofBox, pos uint64) {
+	positions[moof] = pos
+	childPos := pos + boxHeaderSize
+	for _, mc := range moof.Children {
+		if traf, ok := mc.(*TrafBox); ok {
+			trafChildPos := childPos + boxHeaderSize
+			for _, tc := range traf.Children {
+				positions[tc] = trafChildPos
+				trafChildPos += tc.Size()
+			}
+		}
+		childPos += mc.Size()
+	}
+}
+
+// updateOffsets - update offsets to media data and StartPos given box positions before a change
+func (f *File) updateOffsets(oldPositions map[Box]uint64) error {
+	newPositions := f.boxPositions()
+	shift := func(b Box) int64 {
+		return int64(newPositions[b]) - int64(oldPositions[b])
+	}
+	if f.Mdat != nil && f.Moov != nil {
+		if delta := shift(f.Mdat); delta != 0 {
+			err := f.Moov.shiftChunkOffsets(delta)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			if frag.Moof == nil || frag.Mdat == nil {
+				continue
+			}
+			if _, ok := oldPositions[frag.Mdat]; !ok {
+				continue
+			}
+			for _, traf := range frag.Moof.Trafs {
+				shiftTrafOffsets(traf, frag, oldPositions, newPositions)
+			}
+		}
+	}
+	for _, c := range f.Children {
+		switch b := c.(type) {
+		case *MoofBox:
+			b.StartPos = newPositions[c]
+		case *MdatBox:
+			b.StartPos = newPositions[c]
+		case *MfraBox:
+			b.StartPos = newPositions[c]
+		}
+	}
+	return nil
+}
+
+// shiftTrafOffsets - update offsets in traf after media data and boxes in the fragment have moved.
+// trun data offsets follow the mdat box, and saio offsets follow the box they point into,
+// which is either the mdat box or a traf child such as senc.
+func shiftTrafOffsets(traf *TrafBox, frag *Fragment, oldPositions, newPositions map[Box]uint64) {
+	oldBase, newBase := oldPositions[frag.Moof], newPositions[frag.Moof]
+	explicitBase := traf.Tfhd != nil && traf.Tfhd.HasBaseDataOffset()
+	if explicitBase {
+		oldBase = traf.Tfhd.BaseDataOffset
+		newBase = uint64(int64(oldBase) + int64(newPositions[frag.Mdat]) - int64(oldPositions[frag.Mdat]))
+		traf.Tfhd.BaseDataOffset = newBase
+	}
+	// newOffset - offset relative to newBase for data at oldOffset relative to oldBase
+	newOffset := func(oldOffset int64) int64 {
+		oldAbs := int64(oldBase) + oldOffset
+		target := Box(frag.Mdat)
+		for _, c := range traf.Children {
+			start, ok := oldPositions[c]
+			if ok && oldAbs >= int64(start) && oldAbs < int64(start+c.Size()) {
+				target = c
+				break
+			}
+		}
+		newAbs := oldAbs + int64(newPositions[target]) - int64(oldPositions[target])
+		return newAbs - int64(newBase)
+	}
+	for _, trun := range traf.Truns {
+		if trun.HasDataOffset() {
+			trun.DataOffset = int32(newOffset(int64(trun.DataOffset)))
+		}
+	}
+	for _, saio := range traf.Saios {
+		for i := range saio.Offset {
+			saio.Offset[i] = newOffset(saio.Offset[i])
+		}
+	}
+}
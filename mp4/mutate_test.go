@@ -0,0 +1,178 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestInsertRemoveReplaceChild(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	moov := init.Moov
+	trak := &TrakBox{}
+	trak.AddChild(&TkhdBox{TrackID: 2})
+	assertNoError(t, InsertChild(moov, len(moov.Children), trak))
+	if len(moov.Traks) != 2 || moov.Traks[1] != trak || moov.Children[len(moov.Children)-1] != trak {
+		t.Errorf("trak not inserted last with typed fields updated")
+	}
+	assertNoError(t, RemoveChild(moov, moov.Mvex))
+	if moov.Mvex != nil {
+		t.Errorf("mvex not removed")
+	}
+	mvhd := &MvhdBox{Timescale: 1000}
+	assertNoError(t, ReplaceChild(moov, moov.Mvhd, mvhd))
+	if moov.Mvhd != mvhd || moov.Children[0] != mvhd {
+		t.Errorf("mvhd not replaced")
+	}
+	assertError(t, RemoveChild(moov, &UdtaBox{}), "removing non-child should give error")
+	assertError(t, InsertChild(moov, 10, &UdtaBox{}), "index out of range should give error")
+}
+
+func TestFileInsertChildProgressive(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(30, 3600, 300, 10, 0x30)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 1000})
+	assertNoError(t, err)
+
+	free := &FreeBox{Name: "free", notDecoded: make([]byte, 100)}
+	assertNoError(t, f.InsertChild(f.Moov, 1, free))
+	checkProgSampleData(t, f, samples)
+	assertNoError(t, f.ReplaceChild(f.Moov, free, &UdtaBox{}))
+	checkProgSampleData(t, f, samples)
+	assertNoError(t, f.RemoveChild(f.Moov, f.Moov.Udta))
+	checkProgSampleData(t, f, samples)
+}
+
+func checkProgSampleData(t *testing.T, f *File, samples []FullSample) {
+	t.Helper()
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	trak := decFile.Moov.Trak
+	for nr := uint32(1); nr <= uint32(len(samples)); nr++ {
+		data := bytes.Buffer{}
+		assertNoError(t, decFile.CopySampleData(&data, nil, trak, nr, nr))
+		if !bytes.Equal(data.Bytes(), samples[nr-1].Data) {
+			t.Fatalf("sample %d: data mismatch", nr)
+		}
+	}
+}
+
+func TestFileInsertChildFragmented(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(50, 3600, 200, 25, 0x40)
+	odFile, err := CreateOnDemandFile(init, NewSliceSampleSource(samples), 90000)
+	assertNoError(t, err)
+	buf := bytes.Buffer{}
+	assertNoError(t, odFile.Encode(&buf))
+	f, err := DecodeFile(&buf)
+	assertNoError(t, err)
+
+	// Encode box tree as is to make sure that the updated offsets are used
+	f.FragEncMode = EncModeBoxTree
+	frag := f.Segments[0].Fragments[0]
+	traf := frag.Moof.Traf
+	assertNoError(t, f.InsertChild(traf, len(traf.Children), &FreeBox{Name: "free", notDecoded: make([]byte, 20)}))
+	assertNoError(t, f.InsertChild(f.Moov, 1, &FreeBox{Name: "skip", notDecoded: make([]byte, 50)}))
+	wantedPos := f.Ftyp.Size() + f.Moov.Size() + f.Sidx.Size()
+	if frag.Moof.StartPos != wantedPos {
+		t.Errorf("moof StartPos not updated")
+	}
+
+	buf.Reset()
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	nr := 0
+	for _, seg := range decFile.Segments {
+		for _, frag := range seg.Fragments {
+			fs, err := frag.GetFullSamples(nil)
+			assertNoError(t, err)
+			for _, s := range fs {
+				if !bytes.Equal(s.Data, samples[nr].Data) {
+					t.Fatalf("sample %d: data mismatch", nr+1)
+				}
+				nr++
+			}
+		}
+	}
+	if nr != len(samples) {
+		t.Errorf("got %d samples instead of %d", nr, len(samples))
+	}
+}
+
+func TestSetChildrenOfAllContainerBoxes(t *testing.T) {
+	// Each registered box type is decoded from the shortest zero payload that can be decoded
+	var creators []func() Box
+	for name := range decoders {
+		name := name
+		for n := 0; n <= 100; n++ {
+			if decodeZeroPayload(name, n) != nil {
+				n := n
+				creators = append(creators, func() Box { return decodeZeroPayload(name, n) })
+				break
+			}
+		}
+	}
+	creators = append(creators,
+		func() Box {
+			enct := NewWvttBox()
+			enct.AddChild(createSinf("wvtt", SchemeCENC, &TencBox{}))
+			enct.name = "enct"
+			return enct
+		},
+		func() Box { return CreateIlstCustomTag("com.example", "tag", "value") })
+
+	deep.NilSlicesAreEmpty = true
+	defer func() { deep.NilSlicesAreEmpty = false }()
+	nrContainers := 0
+	for _, create := range creators {
+		c, ok := create().(ContainerBox)
+		if !ok {
+			continue
+		}
+		nrContainers++
+		if _, ok := c.(childSetter); !ok {
+			t.Errorf("%s (%T): setChildren not implemented", c.Type(), c)
+			continue
+		}
+		free := CreateFreeBox(4)
+		assertNoError(t, InsertChild(c, len(c.GetChildren()), free))
+		children := c.GetChildren()
+		if len(children) == 0 || children[len(children)-1] != free {
+			t.Errorf("%s (%T): free box not inserted", c.Type(), c)
+			continue
+		}
+		assertNoError(t, RemoveChild(c, free))
+		if diff := deep.Equal(c, create()); diff != nil {
+			t.Errorf("%s (%T): changed after inserting and removing a child: %v", c.Type(), c, diff)
+		}
+	}
+	if nrContainers < 40 {
+		t.Errorf("only %d container boxes tested", nrContainers)
+	}
+}
+
+// decodeZeroPayload - decode box of type name with n zero bytes as payload. Returns nil on failure.
+func decodeZeroPayload(name string, n int) (box Box) {
+	defer func() {
+		if r := recover(); r != nil {
+			box = nil
+		}
+	}()
+	buf := bytes.Buffer{}
+	if err := EncodeHeaderWithSize(name, uint64(boxHeaderSize+n), false, &buf); err != nil {
+		return nil
+	}
+	buf.Write(make([]byte, n))
+	box, err := DecodeBox(0, &buf)
+	if err != nil {
+		return nil
+	}
+	return box
+}
@@ -0,0 +1,223 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// MergeInitSegments - create a muxed init segment with the tracks of several init segments,
+// e.g. from separate audio and video CMAF sources. This is the opposite of SplitByTrack.
+// The result is a deep copy of the first init segment with the trak and trex boxes of the other
+// init segments added in order. Tracks whose trackID is already taken get the next free trackID.
+// The fragments of such a track must be changed accordingly, e.g. with Fragment.SetTrackID.
+// pssh boxes of all init segments are kept, but identical boxes only once.
+func MergeInitSegments(inits []*InitSegment) (*InitSegment, error) {
+	if len(inits) == 0 {
+		return nil, fmt.Errorf("no init segments to merge")
+	}
+	var merged *InitSegment
+	for i, init := range inits {
+		if init.Moov == nil || len(init.Moov.Traks) == 0 {
+			return nil, fmt.Errorf("init segment %d: no tracks", i)
+		}
+		buf := bytes.Buffer{}
+		err := init.Encode(&buf)
+		if err != nil {
+			return nil, err
+		}
+		initCopy, err := decodeInitSegment(buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = initCopy
+			continue
+		}
+		moov := initCopy.Moov
+		for _, trak := range moov.Traks {
+			trackID := trak.Tkhd.TrackID
+			if merged.Moov.GetTrak(trackID) != nil {
+				newTrackID := merged.Moov.NextFreeTrackID()
+				if next := moov.NextFreeTrackID(); next > newTrackID {
+					newTrackID = next
+				}
+				err = moov.SetTrackID(trackID, newTrackID)
+				if err != nil {
+					return nil, err
+				}
+				trackID = newTrackID
+			}
+			merged.Moov.AddChild(trak)
+			if merged.Moov.Mvex != nil && moov.Mvex != nil {
+				if trex := moov.Mvex.GetTrex(trackID); trex != nil {
+					merged.Moov.Mvex.AddChild(trex)
+				}
+			}
+		}
+		for _, c := range moov.Children {
+			if pssh, ok := c.(*PsshBox); ok && !moovHasPssh(merged.Moov, pssh) {
+				merged.Moov.AddChild(pssh)
+			}
+		}
+	}
+	merged.Moov.SyncTrackIDs()
+	return merged, nil
+}
+
+// moovHasPssh - true if moov has a pssh box with the same content as pssh
+func moovHasPssh(moov *MoovBox, pssh *PsshBox) bool {
+	var want, got bytes.Buffer
+	if err := pssh.Encode(&want); err != nil {
+		return false
+	}
+	for _, c := range moov.Children {
+		if p, ok := c.(*PsshBox); ok {
+			got.Reset()
+			if err := p.Encode(&got); err == nil && bytes.Equal(got.Bytes(), want.Bytes()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MuxFragments - combine the fragments of single-track sources into fragments with one traf per track.
+// init is the muxed init segment, e.g. from MergeInitSegments, and sources[i] are the fragments of
+// init.Moov.Traks[i] in decode order. The trackIDs of the source fragments are not used.
+// FragmentMuxer does the same for live sources, with fragments of fixed duration instead.
+//
+// The fragment boundaries of the first source, e.g. video, are kept and given by the earliest
+// presentation time of its fragments. The samples of the other sources are put in the fragment
+// covering their presentation time, so the sources may have different fragment durations.
+// Samples before the first boundary end up in the first fragment, and samples after the last in the last.
+// The output fragments get the sequence numbers of the fragments of the first source.
+// Sample encryption data (senc) is not carried over.
+func MuxFragments(init *InitSegment, sources [][]*Fragment) ([]*Fragment, error) {
+	if init.Moov == nil || len(init.Moov.Traks) != len(sources) {
+		return nil, fmt.Errorf("number of sources and tracks in init segment differ")
+	}
+	if len(sources[0]) == 0 {
+		return nil, fmt.Errorf("no fragments in first source")
+	}
+	tracks := muxOutputTracks(init)
+
+	leadTimescale := uint64(tracks[0].timescale)
+	var boundaries []uint64 // In leadTimescale
+	var seqNrs []uint32
+	var fragSamples [][][]FullSample // Samples per output fragment and track
+	for _, frag := range sources[0] {
+		samples, err := muxSourceSamples(frag, tracks[0].trex)
+		if err != nil {
+			return nil, err
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		boundary := samples[0].PresentationTime()
+		for _, s := range samples {
+			if pt := s.PresentationTime(); pt < boundary {
+				boundary = pt
+			}
+		}
+		boundaries = append(boundaries, boundary)
+		seqNrs = append(seqNrs, frag.Moof.Mfhd.SequenceNumber)
+		trackSamples := make([][]FullSample, len(tracks))
+		trackSamples[0] = samples
+		fragSamples = append(fragSamples, trackSamples)
+	}
+	if len(fragSamples) == 0 {
+		return nil, fmt.Errorf("no samples in first source")
+	}
+
+	for i := 1; i < len(sources); i++ {
+		timescale := uint64(tracks[i].timescale)
+		fragNr := 0
+		for _, frag := range sources[i] {
+			samples, err := muxSourceSamples(frag, tracks[i].trex)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range samples {
+				// Compare s.PresentationTime()/timescale with boundaries/leadTimescale
+				pt := s.PresentationTime() * leadTimescale
+				for fragNr+1 < len(boundaries) && pt >= boundaries[fragNr+1]*timescale {
+					fragNr++
+				}
+				fragSamples[fragNr][i] = append(fragSamples[fragNr][i], s)
+			}
+		}
+	}
+
+	outFrags := make([]*Fragment, 0, len(fragSamples))
+	for n, trackSamples := range fragSamples {
+		outFrag, err := createMuxedFragment(seqNrs[n], tracks, trackSamples)
+		if err != nil {
+			return nil, err
+		}
+		outFrags = append(outFrags, outFrag)
+	}
+	return outFrags, nil
+}
+
+// muxTrack - output track of MuxFragments and FragmentMuxer
+type muxTrack struct {
+	trackID   uint32
+	timescale uint32
+	trex      *TrexBox
+}
+
+// muxOutputTracks - the tracks of init in order, with the trex defaults to use for the source samples
+func muxOutputTracks(init *InitSegment) []muxTrack {
+	tracks := make([]muxTrack, 0, len(init.Moov.Traks))
+	for _, trak := range init.Moov.Traks {
+		trackID := trak.Tkhd.TrackID
+		var trex *TrexBox
+		if init.Moov.Mvex != nil {
+			trex = init.Moov.Mvex.GetTrex(trackID)
+		}
+		if trex == nil {
+			trex = &TrexBox{TrackID: trackID}
+		}
+		tracks = append(tracks, muxTrack{trackID: trackID, timescale: trak.Mdia.Mdhd.Timescale, trex: trex})
+	}
+	return tracks
+}
+
+// muxSourceSamples - samples of the single traf of a source fragment with the defaults of trex.
+// The trackID of the source fragment is not used.
+func muxSourceSamples(frag *Fragment, trex *TrexBox) ([]FullSample, error) {
+	if frag.Moof == nil || len(frag.Moof.Trafs) != 1 {
+		return nil, fmt.Errorf("source fragments must have exactly one traf")
+	}
+	// Use a copy with the trackID of the source fragment to find its traf
+	trexCopy := *trex
+	trexCopy.TrackID = frag.Moof.Traf.Tfhd.TrackID
+	return frag.GetFullSamples(&trexCopy)
+}
+
+// createMuxedFragment - fragment with one traf for each track with samples, in track order.
+// trackSamples[i] are the samples of tracks[i].
+func createMuxedFragment(seqNr uint32, tracks []muxTrack, trackSamples [][]FullSample) (*Fragment, error) {
+	var trackIDs []uint32
+	for i, tr := range tracks {
+		if len(trackSamples[i]) > 0 {
+			trackIDs = append(trackIDs, tr.trackID)
+		}
+	}
+	if len(trackIDs) == 0 {
+		return nil, fmt.Errorf("no samples for fragment %d", seqNr)
+	}
+	frag, err := CreateMultiTrackFragment(seqNr, trackIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i, tr := range tracks {
+		for _, s := range trackSamples[i] {
+			err = frag.AddFullSampleToTrack(s, tr.trackID)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return frag, nil
+}
@@ -0,0 +1,100 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/edgeware/mp4ff/aac"
+)
+
+// muxSourceFragments - decoded single-track fragments with nrSamples samples of duration dur each.
+// The sample data is the sample number.
+func muxSourceFragments(t *testing.T, nrFrags, nrSamples int, dur uint32) []*Fragment {
+	t.Helper()
+	var frags []*Fragment
+	for i := 0; i < nrFrags; i++ {
+		frag, err := CreateFragment(uint32(i+1), 1)
+		assertNoError(t, err)
+		for j := 0; j < nrSamples; j++ {
+			nr := i*nrSamples + j
+			frag.AddFullSample(FullSample{
+				Sample:     Sample{Flags: SyncSampleFlags, Dur: dur, Size: 1},
+				DecodeTime: uint64(nr) * uint64(dur),
+				Data:       []byte{byte(nr)},
+			})
+		}
+		buf := bytes.Buffer{}
+		assertNoError(t, frag.Encode(&buf))
+		decFile, err := DecodeFile(&buf)
+		assertNoError(t, err)
+		frags = append(frags, decFile.Segments[0].Fragments[0])
+	}
+	return frags
+}
+
+func TestMergeAndMuxFragments(t *testing.T) {
+	videoInit := CreateEmptyInit()
+	videoInit.AddEmptyTrack(90000, "video", "und")
+	audioInit := CreateEmptyInit()
+	audioInit.AddEmptyTrack(48000, "audio", "en")
+	assertNoError(t, audioInit.Moov.Trak.SetAACDescriptor(aac.AAClc, 48000))
+	systemID := UUID(bytes.Repeat([]byte{1}, 16))
+	videoInit.Moov.AddChild(&PsshBox{SystemID: systemID, Data: []byte{1}})
+	audioInit.Moov.AddChild(&PsshBox{SystemID: systemID, Data: []byte{1}})
+
+	init, err := MergeInitSegments([]*InitSegment{videoInit, audioInit})
+	assertNoError(t, err)
+	moov := init.Moov
+	if len(moov.Traks) != 2 || moov.Traks[1].Tkhd.TrackID != 2 || moov.Mvex.GetTrex(2) == nil {
+		t.Fatalf("bad tracks in merged init")
+	}
+	if moov.Mvhd.NextTrackID != 3 || audioInit.Moov.Trak.Tkhd.TrackID != 1 {
+		t.Errorf("bad trackIDs after merge")
+	}
+	nrPssh := 0
+	for _, c := range moov.Children {
+		if c.Type() == "pssh" {
+			nrPssh++
+		}
+	}
+	if nrPssh != 1 {
+		t.Errorf("got %d pssh boxes instead of 1", nrPssh)
+	}
+
+	// 3 video fragments of 2s and 8 audio fragments of 40 AAC frames (0.853s)
+	video := muxSourceFragments(t, 3, 50, 3600)
+	audio := muxSourceFragments(t, 8, 40, 1024)
+	frags, err := MuxFragments(init, [][]*Fragment{video, audio})
+	assertNoError(t, err)
+	if len(frags) != 3 {
+		t.Fatalf("got %d fragments instead of 3", len(frags))
+	}
+	wantedNrAudio := []int{94, 94, 132}
+	for i, frag := range frags {
+		if len(frag.Moof.Trafs) != 2 || frag.Moof.Mfhd.SequenceNumber != uint32(i+1) {
+			t.Fatalf("fragment %d: bad moof", i)
+		}
+		buf := bytes.Buffer{}
+		assertNoError(t, frag.Encode(&buf))
+		decFile, err := DecodeFile(&buf)
+		assertNoError(t, err)
+		decFrag := decFile.Segments[0].Fragments[0]
+		videoSamples, err := decFrag.GetFullSamples(moov.Mvex.GetTrex(1))
+		assertNoError(t, err)
+		audioSamples, err := decFrag.GetFullSamples(moov.Mvex.GetTrex(2))
+		assertNoError(t, err)
+		if len(videoSamples) != 50 || len(audioSamples) != wantedNrAudio[i] {
+			t.Fatalf("fragment %d: got %d video and %d audio samples", i, len(videoSamples), len(audioSamples))
+		}
+		firstAudioNr := 94 * i
+		if audioSamples[0].DecodeTime != uint64(firstAudioNr)*1024 || audioSamples[0].Data[0] != byte(firstAudioNr) {
+			t.Errorf("fragment %d: bad first audio sample %d", i, audioSamples[0].DecodeTime)
+		}
+		if videoSamples[0].DecodeTime != uint64(i)*50*3600 {
+			t.Errorf("fragment %d: bad first video sample %d", i, videoSamples[0].DecodeTime)
+		}
+	}
+
+	_, err = MuxFragments(init, [][]*Fragment{video})
+	assertError(t, err, "should fail with fewer sources than tracks")
+}
@@ -62,6 +62,15 @@ func (m *MvexBox) GetChildren() []Box {
 	return m.Children
 }
 
+func (m *MvexBox) setChildren(children []Box) error {
+	n := MvexBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*m = n
+	return nil
+}
+
 // Encode - write mvex container to w
 func (m *MvexBox) Encode(w io.Writer) error {
 	return EncodeContainer(m, w)
@@ -71,3 +80,33 @@ func (m *MvexBox) Encode(w io.Writer) error {
 func (m *MvexBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	return ContainerInfo(m, w, specificBoxLevels, indent, indentStep)
 }
+
+// GetTrex - get trex box for trackID. Returns nil if not found
+func (m *MvexBox) GetTrex(trackID uint32) *TrexBox {
+	for _, trex := range m.Trexs {
+		if trex.TrackID == trackID {
+			return trex
+		}
+	}
+	return nil
+}
+
+// keepTracks - remove trex boxes for tracks not in trackIDs
+func (m *MvexBox) keepTracks(trackIDs []uint32) {
+	children := make([]Box, 0, len(m.Children))
+	m.Trex = nil
+	m.Trexs = nil
+	for _, child := range m.Children {
+		if trex, ok := child.(*TrexBox); ok {
+			if !containsTrackID(trackIDs, trex.TrackID) {
+				continue
+			}
+			if m.Trex == nil {
+				m.Trex = trex
+			}
+			m.Trexs = append(m.Trexs, trex)
+		}
+		children = append(children, child)
+	}
+	m.Children = children
+}
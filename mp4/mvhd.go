@@ -3,6 +3,7 @@ package mp4
 import (
 	"io"
 	"io/ioutil"
+	"time"
 )
 
 // MvhdBox - Movie Header Box (mvhd - mandatory)
@@ -70,6 +71,28 @@ func DecodeMvhd(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	return m, nil
 }
 
+// GetCreationTime - creation time as UTC time
+func (b *MvhdBox) GetCreationTime() time.Time {
+	return MP4TimeToTime(b.CreationTime)
+}
+
+// SetCreationTime - set creation time. Version is changed to 1 if needed
+func (b *MvhdBox) SetCreationTime(t time.Time) {
+	b.CreationTime = TimeToMP4Time(t)
+	b.Version = timeVersion(b.Version, b.CreationTime)
+}
+
+// GetModificationTime - modification time as UTC time
+func (b *MvhdBox) GetModificationTime() time.Time {
+	return MP4TimeToTime(b.ModificationTime)
+}
+
+// SetModificationTime - set modification time. Version is changed to 1 if needed
+func (b *MvhdBox) SetModificationTime(t time.Time) {
+	b.ModificationTime = TimeToMP4Time(t)
+	b.Version = timeVersion(b.Version, b.ModificationTime)
+}
+
 // Type - return box type
 func (b *MvhdBox) Type() string {
 	return "mvhd"
@@ -94,13 +117,13 @@ func (b *MvhdBox) Encode(w io.Writer) error {
 	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
 	sw.WriteUint32(versionAndFlags)
 	if b.Version == 0 {
-		sw.WriteUint32(uint32(b.CreationTime))
-		sw.WriteUint32(uint32(b.ModificationTime))
+		sw.WriteUint32(uint32(encodedTime(b.CreationTime)))
+		sw.WriteUint32(uint32(encodedTime(b.ModificationTime)))
 		sw.WriteUint32(b.Timescale)
 		sw.WriteUint32(uint32(b.Duration))
 	} else {
-		sw.WriteUint64(b.CreationTime)
-		sw.WriteUint64(b.ModificationTime)
+		sw.WriteUint64(encodedTime(b.CreationTime))
+		sw.WriteUint64(encodedTime(b.ModificationTime))
 		sw.WriteUint32(b.Timescale)
 		sw.WriteUint64(b.Duration)
 	}
@@ -0,0 +1,108 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+)
+
+// SampleSource - source of samples in decode order for one track
+type SampleSource interface {
+	// NextSample - return next sample. Returns io.EOF when there are no more samples
+	NextSample() (FullSample, error)
+}
+
+// SliceSampleSource - SampleSource for samples available in a slice
+type SliceSampleSource struct {
+	Samples []FullSample
+	pos     int
+}
+
+// NewSliceSampleSource - create SampleSource from samples in decode order
+func NewSliceSampleSource(samples []FullSample) *SliceSampleSource {
+	return &SliceSampleSource{Samples: samples}
+}
+
+// NextSample - return next sample or io.EOF
+func (s *SliceSampleSource) NextSample() (FullSample, error) {
+	if s.pos >= len(s.Samples) {
+		return FullSample{}, io.EOF
+	}
+	s.pos++
+	return s.Samples[s.pos-1], nil
+}
+
+// CreateOnDemandFile - create an indexed single-file representation (DASH OnDemand profile)
+// with the layout ftyp, moov, sidx, and then one moof/mdat pair per subsegment.
+// The init segment must have exactly one track and the samples are read from src.
+// A new subsegment is started at the first sync sample at least subSegDur (in track timescale)
+// after the start of the current subsegment. The sidx box covers all subsegments, and its
+// earliest presentation time takes composition time offsets and the edit list into account.
+func CreateOnDemandFile(init *InitSegment, src SampleSource, subSegDur uint64) (*File, error) {
+	if len(init.Moov.Traks) != 1 {
+		return nil, fmt.Errorf("init segment has %d tracks, must have 1", len(init.Moov.Traks))
+	}
+	if subSegDur == 0 {
+		return nil, fmt.Errorf("subsegment duration is 0")
+	}
+	trak := init.Moov.Trak
+	trackID := trak.Tkhd.TrackID
+
+	var frags []*Fragment
+	var frag *Fragment
+	var fragStart uint64
+	for {
+		s, err := src.NextSample()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if frag == nil || (s.DecodeTime >= fragStart+subSegDur && IsSyncSampleFlags(s.Flags)) {
+			frag, err = CreateFragment(uint32(len(frags)+1), trackID)
+			if err != nil {
+				return nil, err
+			}
+			frags = append(frags, frag)
+			fragStart = s.DecodeTime
+		}
+		frag.AddFullSample(s)
+	}
+	if len(frags) == 0 {
+		return nil, fmt.Errorf("no samples from source")
+	}
+
+	sb, err := NewSidxBuilder(init, trackID)
+	if err != nil {
+		return nil, err
+	}
+	var segs []*MediaSegment
+	for _, frag := range frags {
+		seg := NewMediaSegmentWithoutStyp()
+		seg.AddFragment(frag)
+		err = sb.AddSegment(seg)
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+	}
+	sidx := sb.Sidx()
+
+	f := NewFile()
+	pos := uint64(0)
+	for _, b := range init.Children {
+		f.AddChild(b, pos)
+		pos += b.Size()
+	}
+	f.AddChild(sidx, pos)
+	pos += sidx.Size()
+	for _, seg := range segs {
+		f.AddMediaSegment(seg)
+		frag := seg.Fragments[0]
+		frag.Moof.StartPos = pos
+		frag.Mdat.StartPos = pos + frag.Moof.Size()
+		f.Children = append(f.Children, frag.Moof, frag.Mdat)
+		pos += frag.Size()
+	}
+	return f, nil
+}
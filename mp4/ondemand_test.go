@@ -0,0 +1,72 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCreateOnDemandFile(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(60, 3600, 500, 25, 0x20) // Sync samples at 0s, 1s, 2s
+	f, err := CreateOnDemandFile(init, NewSliceSampleSource(samples), 90000)
+	assertNoError(t, err)
+
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	data := buf.Bytes()
+	decFile, err := DecodeFile(bytes.NewReader(data), WithSegmentPerFragment())
+	assertNoError(t, err)
+
+	var boxTypes []string
+	for _, b := range decFile.Children {
+		boxTypes = append(boxTypes, b.Type())
+	}
+	wantedTypes := []string{"ftyp", "moov", "sidx", "moof", "mdat", "moof", "mdat", "moof", "mdat"}
+	if len(boxTypes) != len(wantedTypes) {
+		t.Fatalf("got boxes %v instead of %v", boxTypes, wantedTypes)
+	}
+	for i := range boxTypes {
+		if boxTypes[i] != wantedTypes[i] {
+			t.Fatalf("got boxes %v instead of %v", boxTypes, wantedTypes)
+		}
+	}
+
+	sidx := decFile.Sidx
+	if sidx == nil || len(sidx.SidxRefs) != 3 {
+		t.Fatalf("expected sidx with 3 references")
+	}
+	wantedDurs := []uint32{25 * 3600, 25 * 3600, 10 * 3600}
+	offset := decFile.Ftyp.Size() + decFile.Moov.Size() + sidx.Size() + sidx.FirstOffset
+	for i, ref := range sidx.SidxRefs {
+		if ref.SubSegmentDuration != wantedDurs[i] {
+			t.Errorf("ref %d: got duration %d instead of %d", i+1, ref.SubSegmentDuration, wantedDurs[i])
+		}
+		if ref.StartsWithSAP != 1 || ref.SAPType != 1 {
+			t.Errorf("ref %d: should start with SAP type 1", i+1)
+		}
+		if frag := decFile.Segments[i].Fragments[0]; frag.Moof.StartPos != offset {
+			t.Errorf("ref %d: moof at %d instead of %d", i+1, frag.Moof.StartPos, offset)
+		}
+		offset += uint64(ref.ReferencedSize)
+	}
+	if offset != uint64(len(data)) {
+		t.Errorf("sidx covers %d bytes instead of file size %d", offset, len(data))
+	}
+
+	fs, err := decFile.Segments[1].Fragments[0].GetFullSamples(nil)
+	assertNoError(t, err)
+	if !bytes.Equal(fs[0].Data, samples[25].Data) || fs[0].DecodeTime != samples[25].DecodeTime {
+		t.Errorf("first sample of second subsegment differs from source")
+	}
+}
+
+func TestCreateOnDemandFileErrors(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	_, err := CreateOnDemandFile(init, NewSliceSampleSource(nil), 90000)
+	assertError(t, err, "no samples should give error")
+	init.AddEmptyTrack(48000, "audio", "und")
+	_, err = CreateOnDemandFile(init, NewSliceSampleSource(nil), 90000)
+	assertError(t, err, "two tracks should give error")
+}
@@ -0,0 +1,74 @@
+package mp4
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// PitmBox - Primary Item Box (pitm) ISO/IEC 14496-12 Ed. 6 2020 Section 8.11.4
+//
+// Contained in : MetaBox (meta)
+type PitmBox struct {
+	Version byte
+	Flags   uint32
+	ItemID  uint32
+}
+
+// DecodePitm - box-specific decode
+func DecodePitm(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
+	b := &PitmBox{
+		Version: byte(versionAndFlags >> 24),
+		Flags:   versionAndFlags & flagsMask,
+	}
+	if b.Version == 0 {
+		b.ItemID = uint32(s.ReadUint16())
+	} else {
+		b.ItemID = s.ReadUint32()
+	}
+	return b, nil
+}
+
+// Type - box type
+func (b *PitmBox) Type() string {
+	return "pitm"
+}
+
+// Size - calculated size of box
+func (b *PitmBox) Size() uint64 {
+	if b.Version == 0 {
+		return uint64(boxHeaderSize + 6)
+	}
+	return uint64(boxHeaderSize + 8)
+}
+
+// Encode - write box to w
+func (b *PitmBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
+	sw.WriteUint32(versionAndFlags)
+	if b.Version == 0 {
+		sw.WriteUint16(uint16(b.ItemID))
+	} else {
+		sw.WriteUint32(b.ItemID)
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *PitmBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	bd.write(" - itemID: %d", b.ItemID)
+	return bd.err
+}
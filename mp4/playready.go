@@ -0,0 +1,253 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// PlayReady Object record types
+const (
+	PlayReadyRecordRightsManagementHeader = 1
+	PlayReadyRecordLicenseStore           = 3
+)
+
+const playReadyHeaderNamespace = "http://schemas.microsoft.com/DRM/2007/03/PlayReadyHeader"
+
+// PlayReadyKeyID - key ID with algorithm and checksum from a PlayReady header
+type PlayReadyKeyID struct {
+	KeyID    UUID   // In the byte order of the pssh box, not the little-endian GUID order of the header
+	AlgID    string // AESCTR, AESCBC, or COCKTAIL. Empty if not specified
+	Checksum []byte
+}
+
+// PlayReadyHeader - content of the PlayReady Rights Management Header (WRMHEADER XML)
+type PlayReadyHeader struct {
+	Version string // WRMHEADER version, e.g. 4.0.0.0
+	KeyIDs  []PlayReadyKeyID
+	LAURL   string
+	LUIURL  string
+	DSID    string
+}
+
+// wrmHeader - WRMHEADER XML of versions 4.0 to 4.3
+type wrmHeader struct {
+	XMLName xml.Name `xml:"WRMHEADER"`
+	Version string   `xml:"version,attr"`
+	Data    struct {
+		ProtectInfo struct {
+			AlgID string       `xml:"ALGID"`    // 4.0
+			KID   *wrmKIDAttr  `xml:"KID"`      // 4.1
+			KIDs  []wrmKIDAttr `xml:"KIDS>KID"` // 4.2 and 4.3
+		} `xml:"PROTECTINFO"`
+		KID      string `xml:"KID"`      // 4.0
+		Checksum string `xml:"CHECKSUM"` // 4.0
+		LAURL    string `xml:"LA_URL"`
+		LUIURL   string `xml:"LUI_URL"`
+		DSID     string `xml:"DS_ID"`
+	} `xml:"DATA"`
+}
+
+type wrmKIDAttr struct {
+	Value    string `xml:"VALUE,attr"`
+	AlgID    string `xml:"ALGID,attr"`
+	Checksum string `xml:"CHECKSUM,attr"`
+}
+
+// IsPlayReady - true if the pssh box has the PlayReady system ID
+func (b *PsshBox) IsPlayReady() bool {
+	return b.SystemID.String() == UUIDPlayReady
+}
+
+// PlayReadyHeader - parse the Rights Management Header from the PlayReady Object in the pssh box
+func (b *PsshBox) PlayReadyHeader() (*PlayReadyHeader, error) {
+	if !b.IsPlayReady() {
+		return nil, fmt.Errorf("pssh system ID %s is not PlayReady", b.SystemID)
+	}
+	return ParsePlayReadyObject(b.Data)
+}
+
+// PlayReadySystemID - PlayReady system ID as used in pssh boxes
+func PlayReadySystemID() UUID {
+	id, _ := hex.DecodeString(strings.Replace(UUIDPlayReady, "-", "", -1))
+	return UUID(id)
+}
+
+// NewPlayReadyPsshBox - create a version 1 PlayReady pssh box with a version 4.0.0.0 header for AES-CTR.
+// The checksum is the first 8 bytes of the key ID encrypted with the content key (AES-ECB).
+func NewPlayReadyPsshBox(keyID UUID, laURL string, checksum []byte) (*PsshBox, error) {
+	if len(keyID) != 16 {
+		return nil, fmt.Errorf("key ID length %d is not 16", len(keyID))
+	}
+	h := &PlayReadyHeader{
+		Version: "4.0.0.0",
+		KeyIDs:  []PlayReadyKeyID{{KeyID: keyID, AlgID: "AESCTR", Checksum: checksum}},
+		LAURL:   laURL,
+	}
+	pro, err := h.MarshalPlayReadyObject()
+	if err != nil {
+		return nil, err
+	}
+	return &PsshBox{
+		Version:  1,
+		SystemID: PlayReadySystemID(),
+		KIDs:     []UUID{keyID},
+		Data:     pro,
+	}, nil
+}
+
+// ParsePlayReadyObject - parse the Rights Management Header record of a PlayReady Object (PRO)
+func ParsePlayReadyObject(data []byte) (*PlayReadyHeader, error) {
+	if len(data) < 6 {
+		return nil, fmt.Errorf("playready object too short: %d bytes", len(data))
+	}
+	length := binary.LittleEndian.Uint32(data[0:4])
+	if int(length) > len(data) {
+		return nil, fmt.Errorf("playready object length %d beyond data %d", length, len(data))
+	}
+	nrRecords := int(binary.LittleEndian.Uint16(data[4:6]))
+	pos := 6
+	for i := 0; i < nrRecords; i++ {
+		if pos+4 > int(length) {
+			return nil, fmt.Errorf("playready object record %d: header beyond object", i+1)
+		}
+		recordType := binary.LittleEndian.Uint16(data[pos : pos+2])
+		recordLength := int(binary.LittleEndian.Uint16(data[pos+2 : pos+4]))
+		pos += 4
+		if pos+recordLength > int(length) {
+			return nil, fmt.Errorf("playready object record %d: length %d beyond object", i+1, recordLength)
+		}
+		if recordType == PlayReadyRecordRightsManagementHeader {
+			return ParsePlayReadyHeader(data[pos : pos+recordLength])
+		}
+		pos += recordLength
+	}
+	return nil, fmt.Errorf("no rights management header in playready object")
+}
+
+// ParsePlayReadyHeader - parse a Rights Management Header given as UTF-16LE WRMHEADER XML
+func ParsePlayReadyHeader(data []byte) (*PlayReadyHeader, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("playready header: odd UTF-16 length %d", len(data))
+	}
+	u16 := make([]uint16, len(data)/2)
+	for i := range u16 {
+		u16[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+	var wrm wrmHeader
+	err := xml.Unmarshal([]byte(string(utf16.Decode(u16))), &wrm)
+	if err != nil {
+		return nil, fmt.Errorf("playready header: %w", err)
+	}
+	h := &PlayReadyHeader{
+		Version: wrm.Version,
+		LAURL:   strings.TrimSpace(wrm.Data.LAURL),
+		LUIURL:  strings.TrimSpace(wrm.Data.LUIURL),
+		DSID:    strings.TrimSpace(wrm.Data.DSID),
+	}
+	kids := wrm.Data.ProtectInfo.KIDs
+	if wrm.Data.ProtectInfo.KID != nil {
+		kids = append(kids, *wrm.Data.ProtectInfo.KID)
+	}
+	if wrm.Data.KID != "" {
+		kids = append(kids, wrmKIDAttr{
+			Value:    wrm.Data.KID,
+			AlgID:    wrm.Data.ProtectInfo.AlgID,
+			Checksum: wrm.Data.Checksum,
+		})
+	}
+	for _, k := range kids {
+		guid, err := base64.StdEncoding.DecodeString(strings.TrimSpace(k.Value))
+		if err != nil || len(guid) != 16 {
+			return nil, fmt.Errorf("playready header: bad KID %q", k.Value)
+		}
+		kid := PlayReadyKeyID{KeyID: swapGUIDByteOrder(guid), AlgID: k.AlgID}
+		if k.Checksum != "" {
+			kid.Checksum, err = base64.StdEncoding.DecodeString(strings.TrimSpace(k.Checksum))
+			if err != nil {
+				return nil, fmt.Errorf("playready header: bad CHECKSUM %q", k.Checksum)
+			}
+		}
+		h.KeyIDs = append(h.KeyIDs, kid)
+	}
+	return h, nil
+}
+
+// MarshalPlayReadyObject - PlayReady Object with h as its only record. Only version 4.0.0.0 is supported.
+func (h *PlayReadyHeader) MarshalPlayReadyObject() ([]byte, error) {
+	rmh, err := h.marshalUTF16()
+	if err != nil {
+		return nil, err
+	}
+	if len(rmh) > 0xffff-10 {
+		return nil, fmt.Errorf("playready header too big: %d bytes", len(rmh))
+	}
+	pro := make([]byte, 10, 10+len(rmh))
+	binary.LittleEndian.PutUint32(pro[0:4], uint32(10+len(rmh)))
+	binary.LittleEndian.PutUint16(pro[4:6], 1)
+	binary.LittleEndian.PutUint16(pro[6:8], PlayReadyRecordRightsManagementHeader)
+	binary.LittleEndian.PutUint16(pro[8:10], uint16(len(rmh)))
+	return append(pro, rmh...), nil
+}
+
+// marshalUTF16 - WRMHEADER XML version 4.0.0.0 encoded as UTF-16LE
+func (h *PlayReadyHeader) marshalUTF16() ([]byte, error) {
+	if h.Version != "4.0.0.0" {
+		return nil, fmt.Errorf("playready header version %q not supported", h.Version)
+	}
+	if len(h.KeyIDs) != 1 {
+		return nil, fmt.Errorf("playready header version 4.0.0.0 needs exactly one KID, not %d", len(h.KeyIDs))
+	}
+	kid := h.KeyIDs[0]
+	algID := kid.AlgID
+	if algID == "" {
+		algID = "AESCTR"
+	}
+	var sb strings.Builder
+	writeElement := func(name, value string) {
+		sb.WriteString("<" + name + ">")
+		_ = xml.EscapeText(&sb, []byte(value))
+		sb.WriteString("</" + name + ">")
+	}
+	fmt.Fprintf(&sb, `<WRMHEADER xmlns="%s" version="%s"><DATA>`, playReadyHeaderNamespace, h.Version)
+	sb.WriteString("<PROTECTINFO>")
+	writeElement("KEYLEN", "16")
+	writeElement("ALGID", algID)
+	sb.WriteString("</PROTECTINFO>")
+	writeElement("KID", base64.StdEncoding.EncodeToString(swapGUIDByteOrder(kid.KeyID)))
+	if len(kid.Checksum) > 0 {
+		writeElement("CHECKSUM", base64.StdEncoding.EncodeToString(kid.Checksum))
+	}
+	if h.LAURL != "" {
+		writeElement("LA_URL", h.LAURL)
+	}
+	if h.LUIURL != "" {
+		writeElement("LUI_URL", h.LUIURL)
+	}
+	if h.DSID != "" {
+		writeElement("DS_ID", h.DSID)
+	}
+	sb.WriteString("</DATA></WRMHEADER>")
+	u16 := utf16.Encode([]rune(sb.String()))
+	var buf bytes.Buffer
+	for _, c := range u16 {
+		buf.WriteByte(byte(c))
+		buf.WriteByte(byte(c >> 8))
+	}
+	return buf.Bytes(), nil
+}
+
+// swapGUIDByteOrder - convert between a big-endian UUID and the little-endian GUID byte order used by PlayReady
+func swapGUIDByteOrder(id []byte) UUID {
+	s := make([]byte, 16)
+	copy(s, id)
+	s[0], s[1], s[2], s[3] = id[3], id[2], id[1], id[0]
+	s[4], s[5] = id[5], id[4]
+	s[6], s[7] = id[7], id[6]
+	return UUID(s)
+}
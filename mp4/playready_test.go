@@ -0,0 +1,60 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestPlayReadyPssh(t *testing.T) {
+	kid, _ := hex.DecodeString("f057639d928733158bf550999c4945f7")
+	checksum, _ := hex.DecodeString("922d076c7b702705")
+	laURL := "https://license.example.com/rightsmanager.asmx?a=1&b=2"
+	pssh, err := NewPlayReadyPsshBox(kid, laURL, checksum)
+	assertNoError(t, err)
+	if !pssh.IsPlayReady() || pssh.IsWidevine() {
+		t.Errorf("pssh box not identified as PlayReady")
+	}
+	boxDiffAfterEncodeAndDecode(t, pssh)
+
+	h, err := pssh.PlayReadyHeader()
+	assertNoError(t, err)
+	if h.Version != "4.0.0.0" || h.LAURL != laURL || len(h.KeyIDs) != 1 {
+		t.Fatalf("got header %+v", h)
+	}
+	if !bytes.Equal(h.KeyIDs[0].KeyID, kid) || h.KeyIDs[0].AlgID != "AESCTR" ||
+		!bytes.Equal(h.KeyIDs[0].Checksum, checksum) {
+		t.Errorf("got key ID %+v", h.KeyIDs[0])
+	}
+
+	_, err = ParsePlayReadyObject(pssh.Data[:len(pssh.Data)-2])
+	assertError(t, err, "truncated PlayReady object")
+	_, err = NewPlayReadyPsshBox(kid[:8], laURL, checksum)
+	assertError(t, err, "short key ID")
+}
+
+func TestParsePlayReadyHeaderV42(t *testing.T) {
+	xmlHeader := `<WRMHEADER xmlns="http://schemas.microsoft.com/DRM/2007/03/PlayReadyHeader" version="4.2.0.0">` +
+		`<DATA><PROTECTINFO><KIDS>` +
+		`<KID ALGID="AESCTR" CHECKSUM="ki0HbHtwJwU=" VALUE="nWNX8IeSFTOL9VCZnElF9w=="></KID>` +
+		`<KID ALGID="AESCBC" VALUE="AAAAAAAAAAAAAAAAAAAAAQ=="></KID>` +
+		`</KIDS></PROTECTINFO><LA_URL>https://lic.example.com</LA_URL></DATA></WRMHEADER>`
+	u16 := utf16.Encode([]rune(xmlHeader))
+	data := make([]byte, 2*len(u16))
+	for i, c := range u16 {
+		binary.LittleEndian.PutUint16(data[2*i:], c)
+	}
+	h, err := ParsePlayReadyHeader(data)
+	assertNoError(t, err)
+	if h.Version != "4.2.0.0" || h.LAURL != "https://lic.example.com" || len(h.KeyIDs) != 2 {
+		t.Fatalf("got header %+v", h)
+	}
+	if got := h.KeyIDs[0].KeyID.String(); got != "f057639d-9287-3315-8bf5-50999c4945f7" {
+		t.Errorf("got first key ID %s", got)
+	}
+	if h.KeyIDs[1].AlgID != "AESCBC" || h.KeyIDs[1].Checksum != nil {
+		t.Errorf("got second key ID %+v", h.KeyIDs[1])
+	}
+}
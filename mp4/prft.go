@@ -3,16 +3,29 @@ package mp4
 import (
 	"io"
 	"io/ioutil"
+	"time"
+)
+
+// Flag values for PrftBox telling when the NTP timestamp was taken (ISO/IEC 14496-12 Section 8.16.5)
+const (
+	PrftFlagsEncoderInput        = 0x00 // Sample input to encoder
+	PrftFlagsEncoderOutput       = 0x01 // Sample output from encoder
+	PrftFlagsMoofFinalized       = 0x02 // moof box finalized
+	PrftFlagsMoofWritten         = 0x04 // moof box written
+	PrftFlagsArbitraryConsistent = 0x08 // Arbitrary but consistent time
+	PrftFlagsCaptured            = 0x18 // Sample captured
 )
 
 // PrftBox - Producer Reference Box (prft)
 //
-// Contained in File before moof box
+// Contained in File before moof box.
+// Relates the wall-clock time NTPTimestamp to MediaTime of the reference track in the following moof.
 type PrftBox struct {
-	Version      byte
-	Flags        uint32
-	NTPTimestamp uint64
-	MediaTime    uint64
+	Version          byte
+	Flags            uint32
+	ReferenceTrackID uint32
+	NTPTimestamp     uint64
+	MediaTime        uint64
 }
 
 // CreatePrftBox - Create a new PrftBox
@@ -25,6 +38,22 @@ func CreatePrftBox(version byte, ntp uint64, mediatime uint64) *PrftBox {
 	}
 }
 
+// CreatePrftBoxFromTime - create PrftBox for trackID relating wall-clock time t to mediaTime.
+// Version 1 is used if mediaTime does not fit in 32 bits.
+func CreatePrftBoxFromTime(flags uint32, trackID uint32, t time.Time, mediaTime uint64) *PrftBox {
+	var version byte
+	if mediaTime > 0xffffffff {
+		version = 1
+	}
+	return &PrftBox{
+		Version:          version,
+		Flags:            flags,
+		ReferenceTrackID: trackID,
+		NTPTimestamp:     TimeToNTPTimestamp(t),
+		MediaTime:        mediaTime,
+	}
+}
+
 // DecodePrft - box-specific decode
 func DecodePrft(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	data, err := ioutil.ReadAll(r)
@@ -35,6 +64,7 @@ func DecodePrft(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	versionAndFlags := s.ReadUint32()
 	version := byte(versionAndFlags >> 24)
 	flags := versionAndFlags & flagsMask
+	trackID := s.ReadUint32()
 	ntp := s.ReadUint64()
 	var mediatime uint64
 	if version == 0 {
@@ -44,10 +74,11 @@ func DecodePrft(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	}
 
 	p := &PrftBox{
-		Version:      version,
-		Flags:        flags,
-		NTPTimestamp: ntp,
-		MediaTime:    mediatime,
+		Version:          version,
+		Flags:            flags,
+		ReferenceTrackID: trackID,
+		NTPTimestamp:     ntp,
+		MediaTime:        mediatime,
 	}
 	return p, nil
 }
@@ -59,7 +90,7 @@ func (p *PrftBox) Type() string {
 
 // Size - return calculated size
 func (p *PrftBox) Size() uint64 {
-	return uint64(boxHeaderSize + 16 + 4*int(p.Version))
+	return uint64(boxHeaderSize + 20 + 4*int(p.Version))
 }
 
 // Encode - write box to w
@@ -72,6 +103,7 @@ func (p *PrftBox) Encode(w io.Writer) error {
 	sw := NewSliceWriter(buf)
 	versionAndFlags := (uint32(p.Version) << 24) + p.Flags
 	sw.WriteUint32(versionAndFlags)
+	sw.WriteUint32(p.ReferenceTrackID)
 	sw.WriteUint64(p.NTPTimestamp)
 	if p.Version == 0 {
 		sw.WriteUint32(uint32(p.MediaTime))
@@ -82,10 +114,47 @@ func (p *PrftBox) Encode(w io.Writer) error {
 	return err
 }
 
+// FlagsName - description of when the NTP timestamp was taken according to Flags
+func (p *PrftBox) FlagsName() string {
+	switch p.Flags {
+	case PrftFlagsEncoderInput:
+		return "encoder input"
+	case PrftFlagsEncoderOutput:
+		return "encoder output"
+	case PrftFlagsMoofFinalized:
+		return "moof finalized"
+	case PrftFlagsMoofWritten:
+		return "moof written"
+	case PrftFlagsArbitraryConsistent:
+		return "arbitrary consistent"
+	case PrftFlagsCaptured:
+		return "captured"
+	default:
+		return "unknown"
+	}
+}
+
+// IsEncoderTime - true if the NTP timestamp was taken at capture or encoding
+func (p *PrftBox) IsEncoderTime() bool {
+	return p.Flags == PrftFlagsEncoderInput || p.Flags == PrftFlagsEncoderOutput || p.Flags == PrftFlagsCaptured
+}
+
+// IsPackagerTime - true if the NTP timestamp was taken when the moof box was finalized or written
+func (p *PrftBox) IsPackagerTime() bool {
+	return p.Flags == PrftFlagsMoofFinalized || p.Flags == PrftFlagsMoofWritten
+}
+
+// WallClockTime - NTPTimestamp as time.Time in UTC
+func (p *PrftBox) WallClockTime() time.Time {
+	return NTPTimestampToTime(p.NTPTimestamp)
+}
+
 // Info - write box-specific information
 func (p *PrftBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	bd := newInfoDumper(w, indent, p, int(p.Version), p.Flags)
-	bd.write(" - ntpTimestamp: %d", p.NTPTimestamp)
+	bd.write(" - referenceTrackID: %d", p.ReferenceTrackID)
+	bd.write(" - timestamp taken at: %s", p.FlagsName())
+	bd.write(" - ntpTimestamp: %d (%s)", p.NTPTimestamp, p.WallClockTime().Format(time.RFC3339Nano))
 	bd.write(" - mediaTime: %d", p.MediaTime)
 	return bd.err
 }
@@ -1,14 +1,60 @@
 package mp4
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+	"time"
+)
 
 func TestPrft(t *testing.T) {
 	prfts := []*PrftBox{
 		CreatePrftBox(0, 8998, 98),
 		CreatePrftBox(1, 8998, 98),
+		CreatePrftBoxFromTime(PrftFlagsEncoderOutput, 2, time.Now(), 1<<33),
 	}
 	for _, prft := range prfts {
 		boxDiffAfterEncodeAndDecode(t, prft)
 	}
+	if prfts[2].Version != 1 {
+		t.Errorf("version 1 needed for 64-bit media time")
+	}
+}
+
+func TestNTPTimestamp(t *testing.T) {
+	wallClock := time.Date(2021, time.March, 4, 12, 30, 15, 250_000_000, time.UTC)
+	ntp := TimeToNTPTimestamp(wallClock)
+	if secs := ntp >> 32; secs != 3823849815 {
+		t.Errorf("got NTP seconds %d instead of 3823849815", secs)
+	}
+	if frac := ntp & 0xffffffff; frac != 1<<30 {
+		t.Errorf("got NTP fraction %d instead of %d", frac, 1<<30)
+	}
+	if got := NTPTimestampToTime(ntp); !got.Equal(wallClock) {
+		t.Errorf("got time %s instead of %s", got, wallClock)
+	}
+	if TimeToNTPTimestamp(time.Date(1899, time.December, 31, 0, 0, 0, 0, time.UTC)) != 0 {
+		t.Errorf("time before 1900 should give 0")
+	}
+}
 
+func TestFragmentSetPrft(t *testing.T) {
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	frag.AddFullSample(FullSample{Sample: NewSample(SyncSampleFlags, 1000, 4, 0), DecodeTime: 9000, Data: []byte{1, 2, 3, 4}})
+	wallClock := time.Date(2021, time.March, 4, 12, 30, 15, 0, time.UTC)
+	frag.SetPrft(CreatePrftBoxFromTime(PrftFlagsEncoderInput, 1, wallClock.Add(-time.Second), 0))
+	frag.SetPrft(CreatePrftBoxFromTime(PrftFlagsEncoderInput, 1, wallClock, 9000))
+	seg := NewMediaSegmentWithoutStyp()
+	seg.AddFragment(frag)
+	buf := bytes.Buffer{}
+	assertNoError(t, seg.Encode(&buf))
+	decSeg, err := DecodeMediaSegment(&buf)
+	assertNoError(t, err)
+	decFrag := decSeg.Fragments[0]
+	if len(decFrag.Children) != 3 || decFrag.Children[0] != decFrag.Prft {
+		t.Fatalf("prft not single first box of fragment")
+	}
+	if !decFrag.Prft.WallClockTime().Equal(wallClock) || decFrag.Prft.MediaTime != 9000 {
+		t.Errorf("got prft time %s and media time %d", decFrag.Prft.WallClockTime(), decFrag.Prft.MediaTime)
+	}
 }
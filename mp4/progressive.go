@@ -0,0 +1,262 @@
+package mp4
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// InterleaveMode - strategy for grouping samples of different tracks into chunks in a progressive mdat
+type InterleaveMode byte
+
+const (
+	// InterleaveByDuration - chunks cover time windows of fixed duration aligned across tracks
+	InterleaveByDuration InterleaveMode = iota
+	// InterleaveBySize - chunks are filled with samples up to a maximum size in bytes
+	InterleaveBySize
+)
+
+// Interleaving - interleaving strategy for progressive muxing.
+// Chunks of all tracks are written in order of their start time, so that a player
+// reading the file sequentially (e.g. over HTTP) gets all tracks in parallel.
+type Interleaving struct {
+	Mode      InterleaveMode
+	Duration  time.Duration // Chunk duration for InterleaveByDuration (e.g. 500ms)
+	ChunkSize uint32        // Max chunk size in bytes for InterleaveBySize. Single larger samples get their own chunk
+}
+
+// maxStcoOffset - largest chunk offset that fits in stco. Variable to allow testing without huge files
+var maxStcoOffset uint64 = math.MaxUint32
+
+// progChunk - chunk of consecutive samples from one track
+type progChunk struct {
+	trackIdx  int
+	startTime float64 // Start time in seconds
+	samples   []FullSample
+}
+
+// CreateProgressiveFile - create a progressive file (ftyp, moov, mdat) from an init segment
+// and samples for each track given by trackID.
+// The traks must have sample descriptions but empty sample tables, and mvex is removed.
+// The sample tables (stts, ctts, stsc, stsz, stss, stco/co64) are generated from the samples
+// and the chunk layout given by il. co64 is used instead of stco if chunk offsets exceed 32 bits.
+// The init segment is modified and reused.
+func CreateProgressiveFile(init *InitSegment, trackSamples map[uint32][]FullSample, il Interleaving) (*File, error) {
+	moov := init.Moov
+	var chunks []progChunk
+	for i, trak := range moov.Traks {
+		samples, ok := trackSamples[trak.Tkhd.TrackID]
+		if !ok {
+			return nil, fmt.Errorf("no samples for trackID %d", trak.Tkhd.TrackID)
+		}
+		trackChunks, err := createProgChunks(i, trak.Mdia.Mdhd.Timescale, samples, il)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, trackChunks...)
+	}
+	sort.SliceStable(chunks, func(i, j int) bool {
+		if chunks[i].startTime != chunks[j].startTime {
+			return chunks[i].startTime < chunks[j].startTime
+		}
+		return chunks[i].trackIdx < chunks[j].trackIdx
+	})
+
+	if moov.Mvex != nil {
+		for i, c := range moov.Children {
+			if c == moov.Mvex {
+				moov.Children = append(moov.Children[:i], moov.Children[i+1:]...)
+				break
+			}
+		}
+		moov.Mvex = nil
+	}
+
+	// Offsets relative to mdat payload start while building tables
+	relOffsets := make([][]uint64, len(moov.Traks))
+	trackChunks := make([][]progChunk, len(moov.Traks))
+	mdat := &MdatBox{}
+	for _, c := range chunks {
+		relOffsets[c.trackIdx] = append(relOffsets[c.trackIdx], uint64(len(mdat.Data)))
+		trackChunks[c.trackIdx] = append(trackChunks[c.trackIdx], c)
+		for _, s := range c.samples {
+			mdat.AddSampleData(s.Data)
+		}
+	}
+	stbls := make([]*StblBox, len(moov.Traks))
+	for i, trak := range moov.Traks {
+		stbl, err := createProgStbl(trak.Mdia.Minf.Stbl, trackChunks[i])
+		if err != nil {
+			return nil, err
+		}
+		replaceStbl(trak.Mdia.Minf, stbl)
+		stbls[i] = stbl
+		setProgDurations(moov, trak)
+	}
+
+	ftyp := init.Ftyp
+	if ftyp == nil {
+		ftyp = CreateFtyp()
+	}
+	mdat.StartPos = ftyp.Size() + moov.Size()
+	var maxRelOffset uint64
+	for i := range relOffsets {
+		if n := len(relOffsets[i]); n > 0 && relOffsets[i][n-1] > maxRelOffset {
+			maxRelOffset = relOffsets[i][n-1]
+		}
+	}
+	if mdat.PayloadAbsoluteOffset()+maxRelOffset > maxStcoOffset {
+		// Switch to co64, which makes moov bigger and moves mdat
+		for _, stbl := range stbls {
+			stbl.ConvertStcoToCo64()
+		}
+		mdat.StartPos = ftyp.Size() + moov.Size()
+	}
+	base := mdat.PayloadAbsoluteOffset()
+	for i, stbl := range stbls {
+		for j, relOffset := range relOffsets[i] {
+			if stbl.Co64 != nil {
+				stbl.Co64.ChunkOffset[j] = base + relOffset
+			} else {
+				stbl.Stco.ChunkOffset[j] = uint32(base + relOffset)
+			}
+		}
+	}
+
+	f := NewFile()
+	f.AddChild(ftyp, 0)
+	f.AddChild(moov, ftyp.Size())
+	f.AddChild(mdat, mdat.StartPos)
+	return f, nil
+}
+
+// createProgChunks - split samples of one track into chunks according to il
+func createProgChunks(trackIdx int, timescale uint32, samples []FullSample, il Interleaving) ([]progChunk, error) {
+	if timescale == 0 {
+		return nil, fmt.Errorf("timescale is 0 for track %d", trackIdx+1)
+	}
+	var windowTicks uint64
+	switch il.Mode {
+	case InterleaveByDuration:
+		windowTicks = uint64(il.Duration) * uint64(timescale) / uint64(time.Second)
+		if windowTicks == 0 {
+			return nil, fmt.Errorf("interleave duration %s too short for timescale %d", il.Duration, timescale)
+		}
+	case InterleaveBySize:
+		if il.ChunkSize == 0 {
+			return nil, fmt.Errorf("interleave chunk size is 0")
+		}
+	default:
+		return nil, fmt.Errorf("unknown interleave mode %d", il.Mode)
+	}
+	var chunks []progChunk
+	var curr *progChunk
+	var currSize uint32
+	var currWindow uint64
+	for _, s := range samples {
+		newChunk := curr == nil
+		if !newChunk {
+			switch il.Mode {
+			case InterleaveByDuration:
+				newChunk = s.DecodeTime/windowTicks != currWindow
+			case InterleaveBySize:
+				newChunk = currSize+uint32(len(s.Data)) > il.ChunkSize
+			}
+		}
+		if newChunk {
+			chunks = append(chunks, progChunk{
+				trackIdx:  trackIdx,
+				startTime: float64(s.DecodeTime) / float64(timescale),
+			})
+			curr = &chunks[len(chunks)-1]
+			currSize = 0
+			if windowTicks > 0 {
+				currWindow = s.DecodeTime / windowTicks
+			}
+		}
+		curr.samples = append(curr.samples, s)
+		currSize += uint32(len(s.Data))
+	}
+	return chunks, nil
+}
+
+// createProgStbl - create sample tables from chunks, keeping stsd from oldStbl.
+// Chunk offsets are set later in an stco box, which is replaced by co64 if needed.
+func createProgStbl(oldStbl *StblBox, chunks []progChunk) (*StblBox, error) {
+	if oldStbl.Stsd == nil {
+		return nil, fmt.Errorf("no stsd in stbl")
+	}
+	stts := &SttsBox{}
+	ctts := &CttsBox{}
+	stsc := &StscBox{}
+	stsz := &StszBox{}
+	stss := &StssBox{}
+	hasCtts := false
+	allSync := true
+	sampleNr := uint32(0)
+	for i, c := range chunks {
+		n := len(stsc.SamplesPerChunk)
+		if n == 0 || stsc.SamplesPerChunk[n-1] != uint32(len(c.samples)) {
+			stsc.FirstChunk = append(stsc.FirstChunk, uint32(i+1))
+			stsc.SamplesPerChunk = append(stsc.SamplesPerChunk, uint32(len(c.samples)))
+		}
+		for _, s := range c.samples {
+			sampleNr++
+			stts.AddSampleDur(s.Dur)
+			ctts.AddSampleCompositionTimeOffset(s.CompositionTimeOffset)
+			if s.CompositionTimeOffset != 0 {
+				hasCtts = true
+			}
+			stsz.SampleSize = append(stsz.SampleSize, uint32(len(s.Data)))
+			if DecodeSampleFlags(s.Flags).SampleIsNonSync {
+				allSync = false
+			} else {
+				stss.SampleNumber = append(stss.SampleNumber, sampleNr)
+			}
+		}
+	}
+	stsc.SetSingleSampleDescriptionID(1)
+	stsz.SampleNumber = sampleNr
+
+	stbl := NewStblBox()
+	stbl.AddChild(oldStbl.Stsd)
+	stbl.AddChild(stts)
+	if hasCtts {
+		stbl.AddChild(ctts)
+	}
+	stbl.AddChild(stsc)
+	stbl.AddChild(stsz)
+	if !allSync {
+		stbl.AddChild(stss)
+	}
+	stbl.AddChild(&StcoBox{ChunkOffset: make([]uint32, len(chunks))})
+	for _, b := range oldStbl.Children {
+		switch b.Type() {
+		case "stsd", "stts", "ctts", "stsc", "stsz", "stz2", "stss", "stco", "co64":
+		default:
+			stbl.AddChild(b) // Keep other boxes such as sgpd
+		}
+	}
+	return stbl, nil
+}
+
+// replaceStbl - replace stbl box in minf
+func replaceStbl(minf *MinfBox, stbl *StblBox) {
+	for i, c := range minf.Children {
+		if c == minf.Stbl {
+			minf.Children[i] = stbl
+		}
+	}
+	minf.Stbl = stbl
+}
+
+// setProgDurations - set mdhd, tkhd, and mvhd durations from stts
+func setProgDurations(moov *MoovBox, trak *TrakBox) {
+	mdhd := trak.Mdia.Mdhd
+	mdhd.Duration = trak.Mdia.Minf.Stbl.Stts.GetTotalDur()
+	trak.Tkhd.Duration = mdhd.Duration * uint64(moov.Mvhd.Timescale) / uint64(mdhd.Timescale)
+	if trak.Tkhd.Duration > moov.Mvhd.Duration {
+		moov.Mvhd.Duration = trak.Tkhd.Duration
+	}
+}
@@ -0,0 +1,119 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func createProgTestSamples(nrSamples int, dur uint32, size int, syncInterval int, marker byte) []FullSample {
+	samples := make([]FullSample, nrSamples)
+	for i := range samples {
+		flags := NonSyncSampleFlags
+		if i%syncInterval == 0 {
+			flags = SyncSampleFlags
+		}
+		data := bytes.Repeat([]byte{marker + byte(i)}, size+i%3)
+		samples[i] = FullSample{Sample: NewSample(flags, dur, uint32(len(data)), 0),
+			DecodeTime: uint64(i) * uint64(dur), Data: data}
+	}
+	return samples
+}
+
+func TestCreateProgressiveFile(t *testing.T) {
+	testCases := []struct {
+		name           string
+		il             Interleaving
+		wantedNrChunks []int
+	}{
+		{"duration 500ms", Interleaving{Mode: InterleaveByDuration, Duration: 500 * time.Millisecond}, []int{4, 4}},
+		{"size 4000 bytes", Interleaving{Mode: InterleaveBySize, ChunkSize: 4000}, []int{17, 5}},
+	}
+	for _, tc := range testCases {
+		init := CreateEmptyInit()
+		init.AddEmptyTrack(90000, "video", "und")
+		init.AddEmptyTrack(48000, "audio", "und")
+		trackSamples := map[uint32][]FullSample{
+			1: createProgTestSamples(50, 3600, 1000, 25, 0x10), // 2s at 25fps
+			2: createProgTestSamples(94, 1024, 200, 1, 0x80),   // ~2s of AAC frames
+		}
+		f, err := CreateProgressiveFile(init, trackSamples, tc.il)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		buf := bytes.Buffer{}
+		assertNoError(t, f.Encode(&buf))
+		decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+		assertNoError(t, err)
+		if decFile.IsFragmented() || decFile.Moov.Mvex != nil {
+			t.Errorf("%s: file should be progressive without mvex", tc.name)
+		}
+		for i, trak := range decFile.Moov.Traks {
+			stbl := trak.Mdia.Minf.Stbl
+			if got := len(stbl.Stco.ChunkOffset); got != tc.wantedNrChunks[i] {
+				t.Errorf("%s: track %d: got %d chunks instead of %d", tc.name, i+1, got, tc.wantedNrChunks[i])
+			}
+			samples := trackSamples[trak.Tkhd.TrackID]
+			for nr := uint32(1); nr <= uint32(len(samples)); nr++ {
+				data := bytes.Buffer{}
+				assertNoError(t, decFile.CopySampleData(&data, nil, trak, nr, nr))
+				if !bytes.Equal(data.Bytes(), samples[nr-1].Data) {
+					t.Errorf("%s: track %d sample %d: data mismatch", tc.name, i+1, nr)
+				}
+			}
+			if trak.Mdia.Mdhd.Duration != stbl.Stts.GetTotalDur() {
+				t.Errorf("%s: track %d: mdhd duration %d differs from stts", tc.name, i+1, trak.Mdia.Mdhd.Duration)
+			}
+		}
+		videoStbl := decFile.Moov.Traks[0].Mdia.Minf.Stbl
+		if videoStbl.Stss == nil || len(videoStbl.Stss.SampleNumber) != 2 {
+			t.Errorf("%s: video track should have stss with 2 entries", tc.name)
+		}
+		if decFile.Moov.Traks[1].Mdia.Minf.Stbl.Stss != nil {
+			t.Errorf("%s: audio track should not have stss", tc.name)
+		}
+		// Chunks should be interleaved, so audio data must start before video data ends
+		videoOffsets := videoStbl.Stco.ChunkOffset
+		audioOffsets := decFile.Moov.Traks[1].Mdia.Minf.Stbl.Stco.ChunkOffset
+		if audioOffsets[0] > videoOffsets[len(videoOffsets)-1] {
+			t.Errorf("%s: tracks are not interleaved", tc.name)
+		}
+	}
+}
+
+func TestCreateProgressiveFileErrors(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := map[uint32][]FullSample{1: createProgTestSamples(2, 3600, 10, 1, 0)}
+	_, err := CreateProgressiveFile(init, samples, Interleaving{Mode: InterleaveByDuration})
+	assertError(t, err, "zero duration should give error")
+	_, err = CreateProgressiveFile(init, map[uint32][]FullSample{}, Interleaving{Mode: InterleaveBySize, ChunkSize: 100})
+	assertError(t, err, "missing track samples should give error")
+}
+
+func TestCreateProgressiveFileCo64(t *testing.T) {
+	defer func(max uint64) { maxStcoOffset = max }(maxStcoOffset)
+	maxStcoOffset = 20000 // Offsets beyond this need co64 in this test
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(50, 3600, 1000, 25, 0x10)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples},
+		Interleaving{Mode: InterleaveByDuration, Duration: 500 * time.Millisecond})
+	assertNoError(t, err)
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	trak := decFile.Moov.Trak
+	stbl := trak.Mdia.Minf.Stbl
+	if stbl.Stco != nil || stbl.Co64 == nil || len(stbl.Co64.ChunkOffset) != 4 {
+		t.Fatalf("wanted co64 with 4 chunk offsets instead of stco")
+	}
+	for nr := uint32(1); nr <= uint32(len(samples)); nr++ {
+		data := bytes.Buffer{}
+		assertNoError(t, decFile.CopySampleData(&data, nil, trak, nr, nr))
+		if !bytes.Equal(data.Bytes(), samples[nr-1].Data) {
+			t.Errorf("sample %d: data mismatch", nr)
+		}
+	}
+}
@@ -128,6 +128,29 @@ func (b *PsshBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	level := getInfoLevel(b, specificBoxLevels)
 	if level > 0 {
 		bd.write(" - data: %s", hex.EncodeToString(b.Data))
+		if b.IsWidevine() {
+			if wv, err := b.WidevineData(); err == nil {
+				for i, kid := range wv.KeyIDs {
+					bd.write(" - widevine keyID[%d]=%s", i+1, kid)
+				}
+				bd.write(" - widevine provider=%q contentID=%s", wv.Provider, hex.EncodeToString(wv.ContentID))
+				if wv.ProtectionScheme != "" {
+					bd.write(" - widevine protectionScheme=%s", wv.ProtectionScheme)
+				}
+			}
+		}
+		if b.IsPlayReady() {
+			if pr, err := b.PlayReadyHeader(); err == nil {
+				bd.write(" - playready version=%s", pr.Version)
+				for i, kid := range pr.KeyIDs {
+					bd.write(" - playready keyID[%d]=%s algID=%s checksum=%s", i+1, kid.KeyID, kid.AlgID,
+						hex.EncodeToString(kid.Checksum))
+				}
+				if pr.LAURL != "" {
+					bd.write(" - playready LA_URL=%s", pr.LAURL)
+				}
+			}
+		}
 	}
 	return bd.err
 }
@@ -0,0 +1,86 @@
+package mp4
+
+// RedactMode - how sample data in mdat boxes is removed when encoding a file
+type RedactMode byte
+
+const (
+	// RedactNone - keep the sample data
+	RedactNone RedactMode = iota
+	// RedactZero - write zeros instead of the mdat payload bytes. Sizes and offsets are unchanged.
+	// Lazily decoded mdat boxes are filled with zeros, so there is no data to write separately.
+	RedactZero
+	// RedactTruncate - write empty mdat boxes without payload. Sample sizes and offsets
+	// are unchanged and refer to data that is no longer there, so the file can be analyzed but not played.
+	RedactTruncate
+)
+
+// String - name of redact mode
+func (m RedactMode) String() string {
+	switch m {
+	case RedactNone:
+		return "none"
+	case RedactZero:
+		return "zero"
+	case RedactTruncate:
+		return "truncate"
+	default:
+		return "unknown"
+	}
+}
+
+// WithRedaction - remove sample data when encoding, e.g. to share the structure of a file in a bug report
+// without its content. The sample data of the decoded file is not changed.
+// Decode with DecModeLazyMdat to avoid reading the sample data at all.
+func WithRedaction(mode RedactMode) Option {
+	return func(f *File) { f.Redact = mode }
+}
+
+// Redact - set how Encode writes the payload of the mdat box. The data itself is not changed,
+// so it can still be read, e.g. from a read-only memory mapping.
+func (m *MdatBox) Redact(mode RedactMode) {
+	m.redact = mode
+}
+
+// redactedBoxes - copy of boxes where every mdat box is replaced by a copy encoded according to mode
+func redactedBoxes(boxes []Box, mode RedactMode) []Box {
+	redacted := make([]Box, len(boxes))
+	for i, b := range boxes {
+		if mdat, ok := b.(*MdatBox); ok {
+			mdatCopy := *mdat
+			mdatCopy.redact = mode
+			b = &mdatCopy
+		}
+		redacted[i] = b
+	}
+	return redacted
+}
+
+// redactedSegments - copies of segs with fragments where every mdat box is replaced by a
+// copy encoded according to mode. Other boxes are shared with segs.
+func redactedSegments(segs []*MediaSegment, mode RedactMode) []*MediaSegment {
+	redacted := make([]*MediaSegment, len(segs))
+	for i, seg := range segs {
+		segCopy := *seg
+		segCopy.Fragments = make([]*Fragment, len(seg.Fragments))
+		for j, frag := range seg.Fragments {
+			fragCopy := *frag
+			fragCopy.Children = redactedBoxes(frag.Children, mode)
+			if idx := childIndex(frag.Children, frag.Mdat); idx >= 0 {
+				fragCopy.Mdat = fragCopy.Children[idx].(*MdatBox)
+			}
+			segCopy.Fragments[j] = &fragCopy
+		}
+		redacted[i] = &segCopy
+	}
+	return redacted
+}
+
+// zeroReader - reader of an endless stream of zeros
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,118 @@
+package mp4
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestRedaction(t *testing.T) {
+	testCases := []struct {
+		file string
+		mode RedactMode
+		lazy bool
+	}{
+		{"testdata/prog_8s.mp4", RedactZero, false},
+		{"testdata/prog_8s.mp4", RedactZero, true},
+		{"testdata/prog_8s.mp4", RedactTruncate, true},
+		{"testdata/1.m4s", RedactZero, true},
+		{"testdata/1.m4s", RedactTruncate, false},
+	}
+	for _, tc := range testCases {
+		data, err := ioutil.ReadFile(tc.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		options := []Option{WithRedaction(tc.mode), WithEncodeMode(EncModeBoxTree)}
+		if tc.lazy {
+			options = append(options, WithDecodeMode(DecModeLazyMdat))
+		}
+		f, err := DecodeFile(bytes.NewReader(data), options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		err = f.Encode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orig, err := DecodeFile(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		redacted, err := DecodeFile(&buf)
+		if err != nil {
+			t.Fatalf("%s %s: %s", tc.file, tc.mode, err)
+		}
+		if len(orig.Children) != len(redacted.Children) {
+			t.Fatalf("%s %s: got %d boxes instead of %d", tc.file, tc.mode, len(redacted.Children), len(orig.Children))
+		}
+		for i, b := range redacted.Children {
+			mdat, ok := b.(*MdatBox)
+			if !ok {
+				if diff := deep.Equal(b, orig.Children[i]); diff != nil {
+					t.Errorf("%s %s: %s box changed: %v", tc.file, tc.mode, b.Type(), diff)
+				}
+				continue
+			}
+			origMdat := orig.Children[i].(*MdatBox)
+			switch tc.mode {
+			case RedactZero:
+				if !bytes.Equal(mdat.Data, make([]byte, len(origMdat.Data))) {
+					t.Errorf("%s %s: mdat is not %d zero bytes", tc.file, tc.mode, len(origMdat.Data))
+				}
+			case RedactTruncate:
+				if len(mdat.Data) != 0 {
+					t.Errorf("%s %s: mdat has %d bytes", tc.file, tc.mode, len(mdat.Data))
+				}
+			}
+		}
+	}
+}
+
+func TestRedactionKeepsSampleData(t *testing.T) {
+	fileName := "testdata/prog_8s.mp4"
+	raw, err := ioutil.ReadFile(fileName)
+	assertNoError(t, err)
+	mf, err := OpenMmapFile(fileName, WithRedaction(RedactZero))
+	assertNoError(t, err)
+	defer mf.Close()
+	var buf bytes.Buffer
+	// Must not write to the read-only mapping
+	assertNoError(t, mf.Encode(&buf))
+	if len(buf.Bytes()) != len(raw) {
+		t.Errorf("got %d bytes instead of %d", len(buf.Bytes()), len(raw))
+	}
+	payloadStart := mf.Mdat.StartPos + mf.Mdat.HeaderSize()
+	if !bytes.Equal(mf.Mdat.Data, raw[payloadStart:payloadStart+uint64(len(mf.Mdat.Data))]) {
+		t.Error("sample data changed by redacted encode")
+	}
+
+	f, err := DecodeFile(bytes.NewReader(raw), WithRedaction(RedactTruncate))
+	assertNoError(t, err)
+	origSize := f.Mdat.Size()
+	buf.Reset()
+	assertNoError(t, f.Encode(&buf))
+	if f.Mdat.Size() != origSize || !bytes.Equal(f.Mdat.Data, raw[payloadStart:payloadStart+uint64(len(f.Mdat.Data))]) {
+		t.Error("mdat changed by redacted encode")
+	}
+	if uint64(buf.Len()) != uint64(len(raw))-origSize+f.Mdat.HeaderSize() {
+		t.Errorf("got %d bytes after truncation", buf.Len())
+	}
+
+	segData, err := ioutil.ReadFile("testdata/1.m4s")
+	assertNoError(t, err)
+	f, err = DecodeFile(bytes.NewReader(segData), WithRedaction(RedactTruncate))
+	assertNoError(t, err)
+	fragMdat := f.Segments[0].Fragments[0].Mdat
+	buf.Reset()
+	assertNoError(t, f.Encode(&buf))
+	if fragMdat.redact != RedactNone || uint64(len(fragMdat.Data))+fragMdat.HeaderSize() != fragMdat.Size() {
+		t.Error("fragment mdat changed by redacted encode")
+	}
+	if buf.Len() != len(segData)-len(fragMdat.Data) {
+		t.Errorf("got %d bytes after truncating fragmented file", buf.Len())
+	}
+}
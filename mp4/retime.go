@@ -0,0 +1,127 @@
+package mp4
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SampleTiming - duration and composition time offset of a sample in track timescale
+type SampleTiming struct {
+	Dur                   uint32
+	CompositionTimeOffset int32
+}
+
+// ParseSampleTimings - parse a list of sample timings with one sample per line in decode order.
+// Every line has the duration followed by an optional composition time offset, separated by white space.
+// Empty lines and lines starting with # are ignored.
+func ParseSampleTimings(r io.Reader) ([]SampleTiming, error) {
+	var timings []SampleTiming
+	scanner := bufio.NewScanner(r)
+	lineNr := 0
+	for scanner.Scan() {
+		lineNr++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: more than 2 values", lineNr)
+		}
+		dur, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad duration: %w", lineNr, err)
+		}
+		timing := SampleTiming{Dur: uint32(dur)}
+		if len(fields) == 2 {
+			cto, err := strconv.ParseInt(fields[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad composition time offset: %w", lineNr, err)
+			}
+			timing.CompositionTimeOffset = int32(cto)
+		}
+		timings = append(timings, timing)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return timings, nil
+}
+
+// SampleRetimer - override durations and composition time offsets of the samples of one track
+// with values from a list, in decode order over consecutive fragments.
+// The base media decode time of the first retimed fragment is kept, and the tfdt of the following
+// fragments is set to the accumulated duration of the retimed samples.
+type SampleRetimer struct {
+	TrackID        uint32
+	Timings        []SampleTiming
+	next           int
+	nextDecodeTime uint64
+	started        bool
+}
+
+// NewSampleRetimer - create SampleRetimer for track trackID
+func NewSampleRetimer(trackID uint32, timings []SampleTiming) *SampleRetimer {
+	return &SampleRetimer{TrackID: trackID, Timings: timings}
+}
+
+// RemainingTimings - number of timings not yet used
+func (r *SampleRetimer) RemainingTimings() int {
+	return len(r.Timings) - r.next
+}
+
+// RetimeMoof - set sample durations and composition time offsets in the traf of the track in moof.
+// Values only given as defaults in tfhd or trex are written explicitly in trun.
+// Fragments without the track are left unchanged. It is an error if there are not enough timings.
+func (r *SampleRetimer) RetimeMoof(moof *MoofBox, trex *TrexBox) error {
+	for _, traf := range moof.Trafs {
+		if traf.Tfhd.TrackID != r.TrackID {
+			continue
+		}
+		if traf.Tfdt == nil {
+			return fmt.Errorf("traf for track %d has no tfdt", r.TrackID)
+		}
+		if !r.started {
+			r.nextDecodeTime = traf.Tfdt.BaseMediaDecodeTime
+			r.started = true
+		}
+		traf.Tfdt.SetBaseMediaDecodeTime(r.nextDecodeTime)
+		for _, trun := range traf.Truns {
+			trun.AddSampleDefaultValues(traf.Tfhd, trex)
+			if r.next+len(trun.Samples) > len(r.Timings) {
+				return fmt.Errorf("only %d timings for %d samples", len(r.Timings), r.next+len(trun.Samples))
+			}
+			for i := range trun.Samples {
+				timing := r.Timings[r.next]
+				trun.Samples[i].Dur = timing.Dur
+				trun.Samples[i].CompositionTimeOffset = timing.CompositionTimeOffset
+				if timing.CompositionTimeOffset < 0 {
+					trun.Version = 1
+				}
+				r.nextDecodeTime += uint64(timing.Dur)
+				r.next++
+			}
+			trun.flags |= sampleDurationPresentFlag | sampleCompositionTimeOffsetPresentFlag
+		}
+	}
+	return nil
+}
+
+// Filter - BoxFilter for moof boxes to retime samples with CopyBoxes.
+// trex gives default values for the track and may be nil if tfhd or trun has all values.
+func (r *SampleRetimer) Filter(trex *TrexBox) BoxFilter {
+	return func(b Box) (Box, error) {
+		moof, ok := b.(*MoofBox)
+		if !ok {
+			return nil, fmt.Errorf("retime filter got %s box instead of moof", b.Type())
+		}
+		err := r.RetimeMoof(moof, trex)
+		if err != nil {
+			return nil, err
+		}
+		return moof, nil
+	}
+}
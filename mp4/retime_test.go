@@ -0,0 +1,75 @@
+package mp4
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseSampleTimings(t *testing.T) {
+	input := "# dur cto\n1000 2000\n\n1001\n  999 -500 \n"
+	timings, err := ParseSampleTimings(strings.NewReader(input))
+	assertNoError(t, err)
+	wanted := []SampleTiming{{1000, 2000}, {1001, 0}, {999, -500}}
+	if len(timings) != len(wanted) {
+		t.Fatalf("got %d timings instead of %d", len(timings), len(wanted))
+	}
+	for i := range wanted {
+		if timings[i] != wanted[i] {
+			t.Errorf("timing %d: got %v instead of %v", i, timings[i], wanted[i])
+		}
+	}
+	for _, bad := range []string{"1 2 3\n", "x\n", "1000 y\n", "-1\n"} {
+		_, err = ParseSampleTimings(strings.NewReader(bad))
+		assertError(t, err, "bad line "+bad)
+	}
+}
+
+func TestCopyBoxesRetime(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(30, 3000, 100, 10, 0x20)
+	in := bytes.Buffer{}
+	assertNoError(t, init.Encode(&in))
+	for i := 0; i < 3; i++ {
+		frag, err := CreateFragment(uint32(i+1), 1)
+		assertNoError(t, err)
+		for _, s := range samples[i*10 : (i+1)*10] {
+			frag.AddFullSample(s)
+		}
+		assertNoError(t, frag.Encode(&in))
+	}
+
+	timings := make([]SampleTiming, len(samples))
+	for i := range timings {
+		timings[i] = SampleTiming{Dur: 3003, CompositionTimeOffset: int32(i%2) * -3003}
+	}
+	retimer := NewSampleRetimer(1, timings)
+	out := bytes.Buffer{}
+	filters := map[string]BoxFilter{"moof": retimer.Filter(init.Moov.Mvex.Trex)}
+	assertNoError(t, CopyBoxes(&in, &out, filters))
+	if retimer.RemainingTimings() != 0 {
+		t.Errorf("%d timings not used", retimer.RemainingTimings())
+	}
+
+	f, err := DecodeFile(&out)
+	assertNoError(t, err)
+	outSamples, err := fragmentedTrackSamples(f, 1)
+	assertNoError(t, err)
+	if len(outSamples) != len(samples) {
+		t.Fatalf("got %d samples instead of %d", len(outSamples), len(samples))
+	}
+	for i, s := range outSamples {
+		if s.DecodeTime != uint64(i)*3003 || s.Dur != 3003 || s.CompositionTimeOffset != timings[i].CompositionTimeOffset {
+			t.Errorf("sample %d: got time %d dur %d cto %d", i+1, s.DecodeTime, s.Dur, s.CompositionTimeOffset)
+		}
+		if !bytes.Equal(s.Data, samples[i].Data) {
+			t.Errorf("sample %d: data differs", i+1)
+		}
+	}
+
+	retimer = NewSampleRetimer(1, timings[:15])
+	moof := f.Segments[0].Fragments[0].Moof
+	assertNoError(t, retimer.RetimeMoof(moof, f.Moov.Mvex.Trex))
+	assertError(t, retimer.RetimeMoof(f.Segments[0].Fragments[1].Moof, f.Moov.Mvex.Trex), "too few timings")
+}
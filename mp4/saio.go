@@ -5,11 +5,11 @@ import (
 	"io/ioutil"
 )
 
-// SaioBox - Sample Auxiliary Information Offsets Box (saiz)
+// SaioBox - Sample Auxiliary Information Offsets Box (saio)
 type SaioBox struct {
 	Version              byte
 	Flags                uint32
-	AuxInfoType          string // Used for Common Encryption Scheme (4-bytes uint32 according to spec)
+	AuxInfoType          string // Present if flags&1. Implied by protection scheme if absent (e.g. cenc)
 	AuxInfoTypeParameter uint32
 	Offset               []int64
 }
@@ -1,6 +1,7 @@
 package mp4
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 )
@@ -9,7 +10,7 @@ import (
 type SaizBox struct {
 	Version               byte
 	Flags                 uint32
-	AuxInfoType           string // Used for Common Encryption Scheme (4-bytes uint32 according to spec)
+	AuxInfoType           string // Present if flags&1. Implied by protection scheme if absent (e.g. cenc)
 	AuxInfoTypeParameter  uint32
 	SampleCount           uint32
 	SampleInfo            []byte
@@ -43,6 +44,20 @@ func DecodeSaiz(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	return b, nil
 }
 
+// GetSampleInfoSize - get size of sample auxiliary information for sampleNr (one-based)
+func (b *SaizBox) GetSampleInfoSize(sampleNr uint32) (byte, error) {
+	if sampleNr == 0 || sampleNr > b.SampleCount {
+		return 0, fmt.Errorf("saiz: sampleNr %d not in range [1, %d]", sampleNr, b.SampleCount)
+	}
+	if b.DefaultSampleInfoSize != 0 {
+		return b.DefaultSampleInfoSize, nil
+	}
+	if int(sampleNr) > len(b.SampleInfo) {
+		return 0, fmt.Errorf("saiz: no size for sampleNr %d, only %d entries", sampleNr, len(b.SampleInfo))
+	}
+	return b.SampleInfo[sampleNr-1], nil
+}
+
 // Type - return box type
 func (b *SaizBox) Type() string {
 	return "saiz"
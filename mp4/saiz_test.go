@@ -1,6 +1,7 @@
 package mp4
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -8,3 +9,25 @@ func TestSaiz(t *testing.T) {
 	saiz := &SaizBox{}
 	boxDiffAfterEncodeAndDecode(t, saiz)
 }
+
+func TestSaizGetSampleInfoSize(t *testing.T) {
+	saiz := &SaizBox{SampleCount: 2, SampleInfo: []byte{8, 16}}
+	size, err := saiz.GetSampleInfoSize(2)
+	assertNoError(t, err)
+	if size != 16 {
+		t.Errorf("got size %d instead of 16", size)
+	}
+	for _, sampleNr := range []uint32{0, 3} {
+		_, err = saiz.GetSampleInfoSize(sampleNr)
+		assertError(t, err, fmt.Sprintf("sampleNr %d out of range", sampleNr))
+	}
+	saiz.SampleInfo = saiz.SampleInfo[:1]
+	_, err = saiz.GetSampleInfoSize(2)
+	assertError(t, err, "sampleNr beyond SampleInfo")
+	saiz = &SaizBox{SampleCount: 2, DefaultSampleInfoSize: 8}
+	size, err = saiz.GetSampleInfoSize(2)
+	assertNoError(t, err)
+	if size != 8 {
+		t.Errorf("got default size %d instead of 8", size)
+	}
+}
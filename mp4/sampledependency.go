@@ -0,0 +1,123 @@
+package mp4
+
+import (
+	"fmt"
+
+	"github.com/edgeware/mp4ff/avc"
+	"github.com/edgeware/mp4ff/hevc"
+)
+
+// AVCSampleDependency - sdtp entry derived from the NAL unit types of an AVC sample.
+// lengthSize is the size of the NALU length fields given by NALULengthSize() of avcC. Samples with IDR slices do not depend on others, and samples where all slices have nal_ref_idc 0
+// are not depended on (disposable). Leading and redundancy information is unknown.
+func AVCSampleDependency(sample []byte, lengthSize int) (SdtpEntry, error) {
+	nalus, err := avc.GetNalusFromSampleWithLengthSize(sample, lengthSize)
+	if err != nil {
+		return 0, err
+	}
+	var dependsOn, isDependedOn uint8
+	for _, nalu := range nalus {
+		if len(nalu) == 0 {
+			continue
+		}
+		switch avc.GetNaluType(nalu[0]) {
+		case avc.NALU_IDR:
+			dependsOn = 2
+		case avc.NALU_NON_IDR:
+			if dependsOn == 0 {
+				dependsOn = 1
+			}
+		default:
+			continue
+		}
+		if nalu[0]>>5 != 0 { // nal_ref_idc
+			isDependedOn = 1
+		} else if isDependedOn == 0 {
+			isDependedOn = 2
+		}
+	}
+	if dependsOn == 0 {
+		return 0, fmt.Errorf("no slice NAL unit in AVC sample")
+	}
+	return NewSdtpEntry(0, dependsOn, isDependedOn, 0), nil
+}
+
+// HEVCSampleDependency - sdtp entry derived from the NAL unit types of an HEVC sample.
+// lengthSize is the size of the NALU length fields given by NALULengthSize() of hvcC.
+// IRAP pictures do not depend on others, sub-layer non-reference pictures are not depended on (disposable),
+// RASL pictures are leading pictures that cannot be decoded after random access, and RADL pictures
+// are decodable leading pictures. Redundancy information is unknown.
+func HEVCSampleDependency(sample []byte, lengthSize int) (SdtpEntry, error) {
+	nalus, err := avc.GetNalusFromSampleWithLengthSize(sample, lengthSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, nalu := range nalus {
+		if len(nalu) == 0 {
+			continue
+		}
+		naluType := hevc.GetNaluType(nalu[0])
+		switch {
+		case naluType >= hevc.NALU_BLA_W_LP && naluType <= 23: // IRAP
+			return NewSdtpEntry(2, 2, 1, 0), nil
+		case naluType <= 14: // Non-IRAP VCL
+			isLeading := uint8(2)
+			switch naluType {
+			case hevc.NALU_RASL_N, hevc.NALU_RASL_R:
+				isLeading = 1
+			case hevc.NALU_RADL_N, hevc.NALU_RADL_R:
+				isLeading = 3
+			}
+			isDependedOn := uint8(1)
+			if naluType%2 == 0 { // Sub-layer non-reference picture
+				isDependedOn = 2
+			}
+			return NewSdtpEntry(isLeading, 1, isDependedOn, 0), nil
+		}
+	}
+	return 0, fmt.Errorf("no VCL NAL unit in HEVC sample")
+}
+
+// SampleFlags - set the dependency fields of sample flags, e.g. in trun, to the values of entry
+func (entry SdtpEntry) SampleFlags(flags uint32) uint32 {
+	sf := DecodeSampleFlags(flags)
+	sf.IsLeading = entry.IsLeading()
+	sf.SampleDependsOn = entry.SampleDependsOn()
+	sf.SampleIsDependedOn = entry.SampleIsDependedOn()
+	sf.SampleHasRedundancy = entry.SampleHasRedundancy()
+	return sf.Encode()
+}
+
+// SetAVCSampleDependencyFlags - set dependency fields of the sample flags of AVC samples before fragmenting
+// to make the generated segments friendlier for trick play. lengthSize is given by NALULengthSize() of avcC.
+func SetAVCSampleDependencyFlags(samples []FullSample, lengthSize int) error {
+	return setSampleDependencyFlags(samples, lengthSize, AVCSampleDependency)
+}
+
+// SetHEVCSampleDependencyFlags - set dependency fields of the sample flags of HEVC samples before fragmenting
+// to make the generated segments friendlier for trick play. lengthSize is given by NALULengthSize() of hvcC.
+func SetHEVCSampleDependencyFlags(samples []FullSample, lengthSize int) error {
+	return setSampleDependencyFlags(samples, lengthSize, HEVCSampleDependency)
+}
+
+func setSampleDependencyFlags(samples []FullSample, lengthSize int,
+	dependency func(sample []byte, lengthSize int) (SdtpEntry, error)) error {
+	for i := range samples {
+		entry, err := dependency(samples[i].Data, lengthSize)
+		if err != nil {
+			return fmt.Errorf("sample %d: %w", i+1, err)
+		}
+		samples[i].Flags = entry.SampleFlags(samples[i].Flags)
+	}
+	return nil
+}
+
+// CreateSdtpBoxFromSamples - create sdtp box for a progressive track from the dependency fields of sample flags
+func CreateSdtpBoxFromSamples(samples []FullSample) *SdtpBox {
+	entries := make([]SdtpEntry, len(samples))
+	for i, s := range samples {
+		sf := DecodeSampleFlags(s.Flags)
+		entries[i] = NewSdtpEntry(sf.IsLeading, sf.SampleDependsOn, sf.SampleIsDependedOn, sf.SampleHasRedundancy)
+	}
+	return CreateSdtpBox(entries)
+}
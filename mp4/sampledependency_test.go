@@ -0,0 +1,85 @@
+package mp4
+
+import (
+	"testing"
+)
+
+// lengthPrefixed - sample with NAL units prefixed by 4-byte lengths
+func lengthPrefixed(nalus ...[]byte) []byte {
+	var sample []byte
+	for _, nalu := range nalus {
+		n := len(nalu)
+		sample = append(sample, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+		sample = append(sample, nalu...)
+	}
+	return sample
+}
+
+func TestAVCSampleDependency(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sample []byte
+		wanted SdtpEntry
+	}{
+		{"IDR", lengthPrefixed([]byte{0x09, 0xf0}, []byte{0x65, 0x88}), NewSdtpEntry(0, 2, 1, 0)},
+		{"reference P", lengthPrefixed([]byte{0x41, 0x9a}), NewSdtpEntry(0, 1, 1, 0)},
+		{"non-reference B", lengthPrefixed([]byte{0x01, 0x9e}, []byte{0x01, 0x9e}), NewSdtpEntry(0, 1, 2, 0)},
+	}
+	for _, tc := range testCases {
+		entry, err := AVCSampleDependency(tc.sample, 4)
+		assertNoError(t, err)
+		if entry != tc.wanted {
+			t.Errorf("%s: got entry %08b instead of %08b", tc.desc, entry, tc.wanted)
+		}
+	}
+	_, err := AVCSampleDependency(lengthPrefixed([]byte{0x67, 0x64}), 4)
+	assertError(t, err, "sample without slice should give error")
+	entry, err := AVCSampleDependency([]byte{0, 2, 0x65, 0x88}, 2)
+	assertNoError(t, err)
+	if entry != NewSdtpEntry(0, 2, 1, 0) {
+		t.Errorf("2-byte lengths: got entry %08b", entry)
+	}
+}
+
+func TestHEVCSampleDependency(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sample []byte
+		wanted SdtpEntry
+	}{
+		{"IDR", lengthPrefixed([]byte{0x40, 0x01}, []byte{0x26, 0x01}), NewSdtpEntry(2, 2, 1, 0)},
+		{"CRA", lengthPrefixed([]byte{0x2a, 0x01}), NewSdtpEntry(2, 2, 1, 0)},
+		{"TRAIL_R", lengthPrefixed([]byte{0x02, 0x01}), NewSdtpEntry(2, 1, 1, 0)},
+		{"TRAIL_N", lengthPrefixed([]byte{0x00, 0x01}), NewSdtpEntry(2, 1, 2, 0)},
+		{"RASL_N", lengthPrefixed([]byte{0x10, 0x01}), NewSdtpEntry(1, 1, 2, 0)},
+		{"RADL_R", lengthPrefixed([]byte{0x0e, 0x01}), NewSdtpEntry(3, 1, 1, 0)},
+	}
+	for _, tc := range testCases {
+		entry, err := HEVCSampleDependency(tc.sample, 4)
+		assertNoError(t, err)
+		if entry != tc.wanted {
+			t.Errorf("%s: got entry %08b instead of %08b", tc.desc, entry, tc.wanted)
+		}
+	}
+}
+
+func TestSetSampleDependencyFlags(t *testing.T) {
+	samples := []FullSample{
+		{Sample: Sample{Flags: SyncSampleFlags}, Data: lengthPrefixed([]byte{0x65, 0x88})},
+		{Sample: Sample{Flags: NonSyncSampleFlags}, Data: lengthPrefixed([]byte{0x01, 0x9e})},
+	}
+	err := SetAVCSampleDependencyFlags(samples, 4)
+	assertNoError(t, err)
+	sf := DecodeSampleFlags(samples[1].Flags)
+	if sf.SampleDependsOn != 1 || sf.SampleIsDependedOn != 2 || !sf.SampleIsNonSync {
+		t.Errorf("got sample flags %s", sf)
+	}
+	if !IsSyncSampleFlags(samples[0].Flags) {
+		t.Errorf("IDR sample is not sync sample")
+	}
+	sdtp := CreateSdtpBoxFromSamples(samples)
+	if len(sdtp.Entries) != 2 || sdtp.Entries[1].SampleIsDependedOn() != 2 || sdtp.Entries[0].SampleDependsOn() != 2 {
+		t.Errorf("got sdtp entries %v", sdtp.Entries)
+	}
+	boxDiffAfterEncodeAndDecode(t, sdtp)
+}
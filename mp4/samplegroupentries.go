@@ -27,6 +27,7 @@ func init() {
 	sgeDecoders = map[string]SampleGroupEntryDecoder{
 		"seig": DecodeSeigSampleGroupEntry,
 		"roll": DecodeRollSampleGroupEntry,
+		"prol": DecodeRollSampleGroupEntry,
 		"rap ": DecodeRapSampleGroupEntry,
 		"alst": DecodeAlstSampleGroupEntry,
 	}
@@ -58,7 +59,7 @@ func DecodeSeigSampleGroupEntry(name string, length uint32, sr *SliceReader) (Sa
 	_ = sr.ReadUint8() // Reserved
 	byteTwo := sr.ReadUint8()
 	s.CryptByteBlock = byteTwo >> 4
-	s.SkipByteBlock = byteTwo % 0xf
+	s.SkipByteBlock = byteTwo & 0xf
 	s.IsProtected = sr.ReadUint8()
 	s.PerSampleIVSize = sr.ReadUint8()
 	s.KID = UUID(sr.ReadBytes(16))
@@ -165,20 +166,29 @@ func (s *UnknownSampleGroupEntry) Info(w io.Writer, specificBoxLevels, indent, i
 // ISO/IEC 14496-12 Ed. 6 2020 Section 10.1
 //
 // VisualRollRecoveryEntry / AudioRollRecoveryEntry / AudioPreRollEntry
+//
+// The same entry is used for audio pre-roll "prol", which is signaled by GroupingType "prol".
 type RollSampleGroupEntry struct {
+	GroupingType string // "roll" if empty
 	RollDistance int16
 }
 
-// DecodeRollSampleGroupEntry - decode Roll Sample Group Entry
+// DecodeRollSampleGroupEntry - decode Roll or Pre-roll Sample Group Entry
 func DecodeRollSampleGroupEntry(name string, length uint32, sr *SliceReader) (SampleGroupEntry, error) {
 	entry := &RollSampleGroupEntry{}
+	if name != "roll" {
+		entry.GroupingType = name
+	}
 	entry.RollDistance = sr.ReadInt16()
 	return entry, nil
 }
 
 // Type - GroupingType SampleGroupEntry (uint32 according to spec)
 func (s *RollSampleGroupEntry) Type() string {
-	return "roll"
+	if s.GroupingType == "" {
+		return "roll"
+	}
+	return s.GroupingType
 }
 
 // Size of sample group entry
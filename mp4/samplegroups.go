@@ -0,0 +1,89 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// trafGroupDescriptionIndexBase - group description indices above this value in a traf sbgp
+// refer to the sgpd in the same traf, ISO/IEC 14496-12 Ed. 6 2020 Section 8.9.4
+const trafGroupDescriptionIndexBase = 0x10000
+
+// GetSampleGroupEntry - sample group entry of type groupingType for sample sampleNr (1-based).
+// For samples not mapped by an sbgp box, the default index of a version 2 sgpd box is used.
+// Returns nil if the sample is not a member of a group of that type.
+func (s *StblBox) GetSampleGroupEntry(groupingType string, sampleNr uint32) (SampleGroupEntry, error) {
+	sgpd := findSgpd(s.Sgpds, groupingType)
+	idx := uint32(0)
+	if sbgp := findSbgp(s.Sbgps, groupingType); sbgp != nil {
+		idx = sbgp.GroupDescriptionIndex(sampleNr)
+	}
+	if idx == 0 && sgpd != nil && sgpd.Version >= 2 {
+		idx = sgpd.DefaultGroupDescriptionIndex
+	}
+	if idx == 0 {
+		return nil, nil
+	}
+	return sgpdEntry(sgpd, groupingType, idx)
+}
+
+// GetSampleGroupEntry - sample group entry of type groupingType for sample sampleNr (1-based) in the fragment.
+// Indices above 0x10000 refer to the sgpd box in the traf, e.g. for key rotation with seig entries,
+// and lower indices refer to the sgpd box in stbl, which may be nil if there is none.
+// For samples not mapped by an sbgp box, the default index of a version 2 sgpd box is used.
+// Returns nil if the sample is not a member of a group of that type.
+func (t *TrafBox) GetSampleGroupEntry(groupingType string, sampleNr uint32, stbl *StblBox) (SampleGroupEntry, error) {
+	var idx uint32
+	if sbgp := findSbgp(t.Sbgps, groupingType); sbgp != nil {
+		idx = sbgp.GroupDescriptionIndex(sampleNr)
+	}
+	if idx == 0 {
+		if stbl == nil {
+			return nil, nil
+		}
+		if sgpd := findSgpd(stbl.Sgpds, groupingType); sgpd != nil && sgpd.Version >= 2 {
+			idx = sgpd.DefaultGroupDescriptionIndex
+		}
+	}
+	switch {
+	case idx == 0:
+		return nil, nil
+	case idx > trafGroupDescriptionIndexBase:
+		return sgpdEntry(findSgpd(t.Sgpds, groupingType), groupingType, idx-trafGroupDescriptionIndexBase)
+	default:
+		var sgpd *SgpdBox
+		if stbl != nil {
+			sgpd = findSgpd(stbl.Sgpds, groupingType)
+		}
+		return sgpdEntry(sgpd, groupingType, idx)
+	}
+}
+
+// sgpdEntry - entry with 1-based index idx in sgpd
+func sgpdEntry(sgpd *SgpdBox, groupingType string, idx uint32) (SampleGroupEntry, error) {
+	if sgpd == nil {
+		return nil, fmt.Errorf("no sgpd box for grouping type %q", groupingType)
+	}
+	if idx > uint32(len(sgpd.SampleGroupEntries)) {
+		return nil, fmt.Errorf("sgpd %q: group description index %d beyond %d entries",
+			groupingType, idx, len(sgpd.SampleGroupEntries))
+	}
+	return sgpd.SampleGroupEntries[idx-1], nil
+}
+
+func findSbgp(sbgps []*SbgpBox, groupingType string) *SbgpBox {
+	for _, sbgp := range sbgps {
+		if sbgp.GroupingType == groupingType {
+			return sbgp
+		}
+	}
+	return nil
+}
+
+func findSgpd(sgpds []*SgpdBox, groupingType string) *SgpdBox {
+	for _, sgpd := range sgpds {
+		if sgpd.GroupingType == groupingType {
+			return sgpd
+		}
+	}
+	return nil
+}
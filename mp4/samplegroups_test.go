@@ -0,0 +1,73 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTrafSampleGroupEntry(t *testing.T) {
+	kid1 := UUID{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	kid2 := UUID{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2}
+	stbl := NewStblBox()
+	stbl.AddChild(&SgpdBox{Version: 2, GroupingType: "seig", DefaultLength: 20, DefaultGroupDescriptionIndex: 1,
+		SampleGroupEntries: []SampleGroupEntry{&SeigSampleGroupEntry{IsProtected: 1, PerSampleIVSize: 8, KID: kid1}}})
+
+	// Key rotation: samples 3 and 4 use a key signaled in the traf
+	traf := &TrafBox{}
+	_ = traf.AddChild(&TfhdBox{TrackID: 1})
+	_ = traf.AddChild(&SbgpBox{GroupingType: "seig", SampleCounts: []uint32{2, 2},
+		GroupDescriptionIndices: []uint32{0, 0x10001}})
+	_ = traf.AddChild(&SgpdBox{Version: 1, GroupingType: "seig", DefaultLength: 20,
+		SampleGroupEntries: []SampleGroupEntry{&SeigSampleGroupEntry{IsProtected: 1, PerSampleIVSize: 8, KID: kid2}}})
+
+	buf := bytes.Buffer{}
+	err := traf.Encode(&buf)
+	assertNoError(t, err)
+	box, err := DecodeBox(0, &buf)
+	assertNoError(t, err)
+	decTraf := box.(*TrafBox)
+	if decTraf.Sbgp == nil || decTraf.Sgpd == nil || len(decTraf.Sgpds) != 1 {
+		t.Fatalf("sbgp and sgpd not decoded as traf children")
+	}
+
+	expectedKIDs := []UUID{kid1, kid1, kid2, kid2, kid1}
+	for i, kid := range expectedKIDs {
+		entry, err := decTraf.GetSampleGroupEntry("seig", uint32(i+1), stbl)
+		assertNoError(t, err)
+		seig, ok := entry.(*SeigSampleGroupEntry)
+		if !ok {
+			t.Fatalf("sample %d: got entry %v instead of seig", i+1, entry)
+		}
+		if !bytes.Equal(seig.KID, kid) {
+			t.Errorf("sample %d: got KID %s instead of %s", i+1, seig.KID, kid)
+		}
+	}
+
+	entry, err := decTraf.GetSampleGroupEntry("roll", 1, stbl)
+	assertNoError(t, err)
+	if entry != nil {
+		t.Errorf("got roll entry %v for sample without roll group", entry)
+	}
+	_, err = decTraf.GetSampleGroupEntry("seig", 3, nil)
+	assertNoError(t, err)
+	decTraf.Sbgp.GroupDescriptionIndices[1] = 0x10002
+	_, err = decTraf.GetSampleGroupEntry("seig", 3, stbl)
+	assertError(t, err, "index beyond traf sgpd entries should give error")
+}
+
+func TestStblSampleGroupEntry(t *testing.T) {
+	stbl := NewStblBox()
+	stbl.AddChild(&SbgpBox{GroupingType: "prol", SampleCounts: []uint32{1, 4}, GroupDescriptionIndices: []uint32{1, 0}})
+	stbl.AddChild(&SgpdBox{Version: 1, GroupingType: "prol", DefaultLength: 2,
+		SampleGroupEntries: []SampleGroupEntry{&RollSampleGroupEntry{GroupingType: "prol", RollDistance: 2}}})
+	entry, err := stbl.GetSampleGroupEntry("prol", 1)
+	assertNoError(t, err)
+	if prol, ok := entry.(*RollSampleGroupEntry); !ok || prol.Type() != "prol" || prol.RollDistance != 2 {
+		t.Errorf("got %v instead of prol entry with roll distance 2", entry)
+	}
+	entry, err = stbl.GetSampleGroupEntry("prol", 2)
+	assertNoError(t, err)
+	if entry != nil {
+		t.Errorf("got entry %v for sample not in group", entry)
+	}
+}
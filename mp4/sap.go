@@ -0,0 +1,111 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// StreamAccessPoint - stream access point (SAP) as defined in ISO/IEC 14496-12 Annex I
+type StreamAccessPoint struct {
+	SampleNr         uint32 // One-based sample number in track or sample slice
+	DecodeTime       uint64
+	PresentationTime int64 // DecodeTime + composition time offset. Edit lists are not applied
+	SAPType          byte  // 1, 2, or 3
+}
+
+// sapSample - timing and random access information needed to classify SAPs
+type sapSample struct {
+	decodeTime uint64
+	presTime   int64
+	isSync     bool
+	isRap      bool // Member of 'rap ' sample group (open GOP random access point)
+}
+
+// GetStreamAccessPoints - list stream access points of type 1, 2, or 3 in a progressive track.
+// Sync samples are SAP type 1 if no later sample before the next access point is presented
+// before them, and SAP type 2 otherwise (leading samples). Non-sync samples that are members
+// of the 'rap ' sample group (e.g. HEVC CRA pictures) are SAP type 3.
+func (t *TrakBox) GetStreamAccessPoints() ([]StreamAccessPoint, error) {
+	stbl := t.Mdia.Minf.Stbl
+	sizes := stbl.SampleSizes()
+	if stbl.Stts == nil || sizes == nil {
+		return nil, fmt.Errorf("no stts or stsz box")
+	}
+	nrSamples := sizes.GetNrSamples()
+	if sttsNr := stbl.Stts.GetNrSamples(); sttsNr != nrSamples {
+		return nil, fmt.Errorf("stts has %d samples but stsz has %d", sttsNr, nrSamples)
+	}
+	samples := make([]sapSample, 0, nrSamples)
+	decTime := uint64(0)
+	for i, count := range stbl.Stts.SampleCount {
+		for j := uint32(0); j < count; j++ {
+			nr := uint32(len(samples)) + 1
+			s := sapSample{decodeTime: decTime, presTime: int64(decTime)}
+			if stbl.Ctts != nil {
+				s.presTime += int64(stbl.Ctts.GetCompositionTimeOffset(nr))
+			}
+			s.isSync = stbl.Stss == nil || stbl.Stss.IsSyncSample(nr)
+			samples = append(samples, s)
+			decTime += uint64(stbl.Stts.SampleTimeDelta[i])
+		}
+	}
+	for _, sbgp := range stbl.Sbgps {
+		if sbgp.GroupingType != "rap " {
+			continue
+		}
+		sampleIdx := 0
+		for i, count := range sbgp.SampleCounts {
+			for j := uint32(0); j < count && sampleIdx < len(samples); j++ {
+				if sbgp.GroupDescriptionIndices[i] != 0 {
+					samples[sampleIdx].isRap = true
+				}
+				sampleIdx++
+			}
+		}
+	}
+	return classifySAPs(samples), nil
+}
+
+// GetStreamAccessPointsFromSamples - list stream access points of type 1 and 2 in samples,
+// e.g. from fragments. Type 3 cannot be detected since sample group information is not available.
+func GetStreamAccessPointsFromSamples(fullSamples []FullSample) []StreamAccessPoint {
+	samples := make([]sapSample, len(fullSamples))
+	for i, fs := range fullSamples {
+		samples[i] = sapSample{
+			decodeTime: fs.DecodeTime,
+			presTime:   int64(fs.DecodeTime) + int64(fs.CompositionTimeOffset),
+			isSync:     IsSyncSampleFlags(fs.Flags),
+		}
+	}
+	return classifySAPs(samples)
+}
+
+// classifySAPs - find access points and determine their SAP type
+func classifySAPs(samples []sapSample) []StreamAccessPoint {
+	var saps []StreamAccessPoint
+	for i, s := range samples {
+		if !s.isSync && !s.isRap {
+			continue
+		}
+		sap := StreamAccessPoint{
+			SampleNr:         uint32(i + 1),
+			DecodeTime:       s.decodeTime,
+			PresentationTime: s.presTime,
+			SAPType:          1,
+		}
+		if !s.isSync {
+			sap.SAPType = 3
+		} else {
+			for _, next := range samples[i+1:] {
+				if next.isSync || next.isRap {
+					break
+				}
+				if next.presTime < s.presTime {
+					sap.SAPType = 2
+					break
+				}
+			}
+		}
+		saps = append(saps, sap)
+	}
+	return saps
+}
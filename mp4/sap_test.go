@@ -0,0 +1,41 @@
+package mp4
+
+import (
+	"testing"
+)
+
+func TestGetStreamAccessPoints(t *testing.T) {
+	samples := createProgTestSamples(12, 1000, 10, 4, 0)
+	// Second GOP has a leading sample presented before the sync sample
+	samples[4].CompositionTimeOffset = 2000
+	samples[5].CompositionTimeOffset = -1000
+	samples[8].Flags = NonSyncSampleFlags
+	wanted := []StreamAccessPoint{
+		{SampleNr: 1, DecodeTime: 0, PresentationTime: 0, SAPType: 1},
+		{SampleNr: 5, DecodeTime: 4000, PresentationTime: 6000, SAPType: 2},
+		{SampleNr: 9, DecodeTime: 8000, PresentationTime: 8000, SAPType: 3},
+	}
+
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(1000, "video", "und")
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 100})
+	assertNoError(t, err)
+	stbl := f.Moov.Trak.Mdia.Minf.Stbl
+	stbl.AddChild(&SbgpBox{GroupingType: "rap ", SampleCounts: []uint32{8, 1, 3}, GroupDescriptionIndices: []uint32{0, 1, 0}})
+	saps, err := f.Moov.Trak.GetStreamAccessPoints()
+	assertNoError(t, err)
+	if len(saps) != len(wanted) {
+		t.Fatalf("got %d SAPs instead of %d", len(saps), len(wanted))
+	}
+	for i := range wanted {
+		if saps[i] != wanted[i] {
+			t.Errorf("SAP %d: got %+v instead of %+v", i, saps[i], wanted[i])
+		}
+	}
+
+	// Without sample groups, the open-GOP access point is not found
+	saps = GetStreamAccessPointsFromSamples(samples)
+	if len(saps) != 2 || saps[0] != wanted[0] || saps[1] != wanted[1] {
+		t.Errorf("got SAPs %+v from samples", saps)
+	}
+}
@@ -97,3 +97,16 @@ func (b *SbgpBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	}
 	return bd.err
 }
+
+// GroupDescriptionIndex - group description index for sample sampleNr (1-based).
+// 0 means that the sample is not a member of a group of this type, also if it is beyond the entries.
+func (b *SbgpBox) GroupDescriptionIndex(sampleNr uint32) uint32 {
+	var last uint32
+	for i, count := range b.SampleCounts {
+		last += count
+		if sampleNr <= last {
+			return b.GroupDescriptionIndices[i]
+		}
+	}
+	return 0
+}
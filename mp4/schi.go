@@ -4,11 +4,15 @@ import "io"
 
 // SchiBox -  Schema Information Box
 type SchiBox struct {
+	Tenc     *TencBox
 	Children []Box
 }
 
 // AddChild - Add a child box
 func (b *SchiBox) AddChild(box Box) {
+	if tenc, ok := box.(*TencBox); ok {
+		b.Tenc = tenc
+	}
 	b.Children = append(b.Children, box)
 }
 
@@ -40,6 +44,15 @@ func (b *SchiBox) GetChildren() []Box {
 	return b.Children
 }
 
+func (b *SchiBox) setChildren(children []Box) error {
+	n := SchiBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - write minf container to w
 func (b *SchiBox) Encode(w io.Writer) error {
 	return EncodeContainer(b, w)
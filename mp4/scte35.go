@@ -0,0 +1,466 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/edgeware/mp4ff/bits"
+)
+
+// SCTE35SchemeIDURI - emsg scheme for binary SCTE-35 splice_info_section messages as in SCTE 214-3
+const SCTE35SchemeIDURI = "urn:scte:scte35:2013:bin"
+
+// SCTE-35 splice_command_type values
+const (
+	SpliceNull           = 0x00
+	SpliceSchedule       = 0x04
+	SpliceInsertType     = 0x05
+	TimeSignal           = 0x06
+	BandwidthReservation = 0x07
+	PrivateCommand       = 0xff
+)
+
+// SegmentationDescriptorTag - splice_descriptor_tag of segmentation_descriptor
+const SegmentationDescriptorTag = 0x02
+
+const (
+	spliceInfoTableID          = 0xfc
+	spliceInfoHeaderSize       = 14 // Up to and including splice_command_type
+	spliceCommandLengthUnknown = 0xfff
+	cueIdentifier              = 0x43554549 // CUEI
+)
+
+// SpliceInfoSection - SCTE-35 splice_info_section (ANSI/SCTE 35 Sec. 9.6)
+//
+// The splice command is always available as raw bytes in SpliceCommand.
+// splice_insert and time_signal commands are also decoded. Encrypted sections are not decoded beyond the header.
+type SpliceInfoSection struct {
+	SAPType             byte
+	ProtocolVersion     byte
+	EncryptedPacket     bool
+	EncryptionAlgorithm byte
+	PTSAdjustment       uint64
+	CWIndex             byte
+	Tier                uint16
+	SpliceCommandType   byte
+	SpliceCommand       []byte
+	SpliceInsert        *SpliceInsert // Set for splice_insert
+	TimeSignal          *SpliceTime   // Set for time_signal
+	Descriptors         []SpliceDescriptor
+	CRC32               uint32
+}
+
+// SpliceTime - splice_time() with PTSTime in 90kHz only valid if TimeSpecified
+type SpliceTime struct {
+	TimeSpecified bool
+	PTSTime       uint64
+}
+
+// String - PTS time or "unspecified"
+func (t SpliceTime) String() string {
+	if !t.TimeSpecified {
+		return "unspecified"
+	}
+	return fmt.Sprintf("%d", t.PTSTime)
+}
+
+// SpliceInsert - splice_insert() command
+type SpliceInsert struct {
+	SpliceEventID    uint32
+	CancelIndicator  bool
+	OutOfNetwork     bool
+	ProgramSplice    bool
+	SpliceImmediate  bool
+	SpliceTime       SpliceTime // Only valid for program splice that is not immediate
+	Components       []SpliceComponent
+	HasBreakDuration bool
+	AutoReturn       bool
+	BreakDuration    uint64 // In 90kHz
+	UniqueProgramID  uint16
+	AvailNum         byte
+	AvailsExpected   byte
+}
+
+// SpliceComponent - component splice time in splice_insert. SpliceTime only valid if not immediate.
+type SpliceComponent struct {
+	ComponentTag byte
+	SpliceTime   SpliceTime
+}
+
+// SpliceDescriptor - splice_descriptor() with data after the identifier
+type SpliceDescriptor struct {
+	Tag          byte
+	Identifier   uint32
+	Data         []byte
+	Segmentation *SegmentationDescriptor // Set for segmentation_descriptor with identifier CUEI
+}
+
+// SegmentationDescriptor - segmentation_descriptor() (ANSI/SCTE 35 Sec. 10.3.3)
+type SegmentationDescriptor struct {
+	EventID               uint32
+	CancelIndicator       bool
+	ProgramSegmentation   bool
+	DeliveryNotRestricted bool
+	WebDeliveryAllowed    bool
+	NoRegionalBlackout    bool
+	ArchiveAllowed        bool
+	DeviceRestrictions    byte
+	Components            []SegmentationComponent
+	HasDuration           bool
+	Duration              uint64 // In 90kHz
+	UPIDType              byte
+	UPID                  []byte
+	TypeID                byte
+	SegmentNum            byte
+	SegmentsExpected      byte
+	SubSegmentNum         byte // Only present for some TypeID values
+	SubSegmentsExpected   byte // Only present for some TypeID values
+}
+
+// SegmentationComponent - component PTS offset in segmentation_descriptor
+type SegmentationComponent struct {
+	ComponentTag byte
+	PTSOffset    uint64
+}
+
+// IsSCTE35 - true if the emsg scheme signals SCTE-35 (binary or XML)
+func (b *EmsgBox) IsSCTE35() bool {
+	return strings.HasPrefix(b.SchemeIDURI, "urn:scte:scte35")
+}
+
+// SpliceInfo - decode MessageData as a binary SCTE-35 splice_info_section
+func (b *EmsgBox) SpliceInfo() (*SpliceInfoSection, error) {
+	if b.SchemeIDURI != SCTE35SchemeIDURI {
+		return nil, fmt.Errorf("emsg scheme %q is not %s", b.SchemeIDURI, SCTE35SchemeIDURI)
+	}
+	return DecodeSpliceInfoSection(b.MessageData)
+}
+
+// DecodeSpliceInfoSection - decode binary splice_info_section and check its CRC_32
+func DecodeSpliceInfoSection(data []byte) (*SpliceInfoSection, error) {
+	if len(data) < spliceInfoHeaderSize+2+4 {
+		return nil, fmt.Errorf("splice_info_section: too short: %d bytes", len(data))
+	}
+	br := bits.NewAccErrReader(bytes.NewReader(data))
+	if tableID := br.Read(8); tableID != spliceInfoTableID {
+		return nil, fmt.Errorf("splice_info_section: table_id 0x%02x is not 0x%02x", tableID, spliceInfoTableID)
+	}
+	br.Read(2) // section_syntax_indicator and private_indicator
+	s := &SpliceInfoSection{}
+	s.SAPType = byte(br.Read(2))
+	sectionLength := int(br.Read(12))
+	if sectionLength+3 != len(data) {
+		return nil, fmt.Errorf("splice_info_section: section_length %d does not match %d bytes", sectionLength, len(data))
+	}
+	s.ProtocolVersion = byte(br.Read(8))
+	s.EncryptedPacket = br.ReadFlag()
+	s.EncryptionAlgorithm = byte(br.Read(6))
+	s.PTSAdjustment = uint64(br.Read(33))
+	s.CWIndex = byte(br.Read(8))
+	s.Tier = uint16(br.Read(12))
+	commandLength := int(br.Read(12))
+	s.SpliceCommandType = byte(br.Read(8))
+	if br.AccError() != nil {
+		return nil, fmt.Errorf("splice_info_section: %w", br.AccError())
+	}
+	end := len(data) - 4
+	s.CRC32 = binary.BigEndian.Uint32(data[end:])
+	if crc := crc32MPEG2(data[:end]); crc != s.CRC32 {
+		return nil, fmt.Errorf("splice_info_section: CRC_32 0x%08x does not match 0x%08x", s.CRC32, crc)
+	}
+	pos := spliceInfoHeaderSize
+	if s.EncryptedPacket {
+		s.SpliceCommand = data[pos:end]
+		return s, nil
+	}
+	n, err := s.decodeSpliceCommand(data[pos:end])
+	if err != nil {
+		return nil, err
+	}
+	if commandLength == spliceCommandLengthUnknown {
+		// Legacy value, so the length is given by the decoded command
+		if n < 0 {
+			return nil, fmt.Errorf("splice_info_section: unknown length of splice command 0x%02x", s.SpliceCommandType)
+		}
+		commandLength = n
+	}
+	if pos+commandLength+2 > end {
+		return nil, fmt.Errorf("splice_info_section: splice_command_length %d too big", commandLength)
+	}
+	s.SpliceCommand = data[pos : pos+commandLength]
+	pos += commandLength
+	descLoopLength := int(binary.BigEndian.Uint16(data[pos:]))
+	pos += 2
+	if pos+descLoopLength > end {
+		return nil, fmt.Errorf("splice_info_section: descriptor_loop_length %d too big", descLoopLength)
+	}
+	s.Descriptors, err = decodeSpliceDescriptors(data[pos : pos+descLoopLength])
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// decodeSpliceCommand - decode splice_insert and time_signal and return the number of bytes read, or -1 for
+// commands that are not decoded
+func (s *SpliceInfoSection) decodeSpliceCommand(data []byte) (int, error) {
+	r := bytes.NewReader(data)
+	br := bits.NewAccErrReader(r)
+	switch s.SpliceCommandType {
+	case SpliceNull:
+		return 0, nil
+	case SpliceInsertType:
+		s.SpliceInsert = decodeSpliceInsert(br)
+	case TimeSignal:
+		t := readSpliceTime(br)
+		s.TimeSignal = &t
+	default:
+		return -1, nil
+	}
+	if br.AccError() != nil {
+		return 0, fmt.Errorf("%s: %w", spliceCommandName(s.SpliceCommandType), br.AccError())
+	}
+	return len(data) - r.Len(), nil
+}
+
+func decodeSpliceInsert(br *bits.AccErrReader) *SpliceInsert {
+	c := &SpliceInsert{}
+	c.SpliceEventID = uint32(br.Read(32))
+	c.CancelIndicator = br.ReadFlag()
+	br.Read(7) // reserved
+	if c.CancelIndicator {
+		return c
+	}
+	c.OutOfNetwork = br.ReadFlag()
+	c.ProgramSplice = br.ReadFlag()
+	c.HasBreakDuration = br.ReadFlag()
+	c.SpliceImmediate = br.ReadFlag()
+	br.Read(4) // reserved
+	if c.ProgramSplice && !c.SpliceImmediate {
+		c.SpliceTime = readSpliceTime(br)
+	}
+	if !c.ProgramSplice {
+		componentCount := int(br.Read(8))
+		for i := 0; i < componentCount; i++ {
+			comp := SpliceComponent{ComponentTag: byte(br.Read(8))}
+			if !c.SpliceImmediate {
+				comp.SpliceTime = readSpliceTime(br)
+			}
+			c.Components = append(c.Components, comp)
+		}
+	}
+	if c.HasBreakDuration {
+		c.AutoReturn = br.ReadFlag()
+		br.Read(6) // reserved
+		c.BreakDuration = uint64(br.Read(33))
+	}
+	c.UniqueProgramID = uint16(br.Read(16))
+	c.AvailNum = byte(br.Read(8))
+	c.AvailsExpected = byte(br.Read(8))
+	return c
+}
+
+func readSpliceTime(br *bits.AccErrReader) SpliceTime {
+	t := SpliceTime{TimeSpecified: br.ReadFlag()}
+	if !t.TimeSpecified {
+		br.Read(7) // reserved
+		return t
+	}
+	br.Read(6) // reserved
+	t.PTSTime = uint64(br.Read(33))
+	return t
+}
+
+func decodeSpliceDescriptors(data []byte) ([]SpliceDescriptor, error) {
+	var descs []SpliceDescriptor
+	s := NewSliceReader(data)
+	for s.NrRemainingBytes() > 0 {
+		if s.NrRemainingBytes() < 6 {
+			return nil, fmt.Errorf("splice_descriptor: too short: %d bytes", s.NrRemainingBytes())
+		}
+		d := SpliceDescriptor{Tag: s.ReadUint8()}
+		length := int(s.ReadUint8())
+		if length < 4 || length > s.NrRemainingBytes() {
+			return nil, fmt.Errorf("splice_descriptor: bad descriptor_length %d", length)
+		}
+		d.Identifier = s.ReadUint32()
+		d.Data = s.ReadBytes(length - 4)
+		if d.Tag == SegmentationDescriptorTag && d.Identifier == cueIdentifier {
+			seg, err := decodeSegmentationDescriptor(d.Data)
+			if err != nil {
+				return nil, err
+			}
+			d.Segmentation = seg
+		}
+		descs = append(descs, d)
+	}
+	return descs, nil
+}
+
+func decodeSegmentationDescriptor(data []byte) (*SegmentationDescriptor, error) {
+	r := bytes.NewReader(data)
+	br := bits.NewAccErrReader(r)
+	d := &SegmentationDescriptor{}
+	d.EventID = uint32(br.Read(32))
+	d.CancelIndicator = br.ReadFlag()
+	br.Read(7) // segmentation_event_id_compliance_indicator and reserved
+	if !d.CancelIndicator {
+		d.ProgramSegmentation = br.ReadFlag()
+		d.HasDuration = br.ReadFlag()
+		d.DeliveryNotRestricted = br.ReadFlag()
+		if d.DeliveryNotRestricted {
+			br.Read(5) // reserved
+		} else {
+			d.WebDeliveryAllowed = br.ReadFlag()
+			d.NoRegionalBlackout = br.ReadFlag()
+			d.ArchiveAllowed = br.ReadFlag()
+			d.DeviceRestrictions = byte(br.Read(2))
+		}
+		if !d.ProgramSegmentation {
+			componentCount := int(br.Read(8))
+			for i := 0; i < componentCount; i++ {
+				comp := SegmentationComponent{ComponentTag: byte(br.Read(8))}
+				br.Read(7) // reserved
+				comp.PTSOffset = uint64(br.Read(33))
+				d.Components = append(d.Components, comp)
+			}
+		}
+		if d.HasDuration {
+			d.Duration = uint64(br.Read(40))
+		}
+		d.UPIDType = byte(br.Read(8))
+		upidLength := int(br.Read(8))
+		for i := 0; i < upidLength; i++ {
+			d.UPID = append(d.UPID, byte(br.Read(8)))
+		}
+		d.TypeID = byte(br.Read(8))
+		d.SegmentNum = byte(br.Read(8))
+		d.SegmentsExpected = byte(br.Read(8))
+		if r.Len() >= 2 {
+			d.SubSegmentNum = byte(br.Read(8))
+			d.SubSegmentsExpected = byte(br.Read(8))
+		}
+	}
+	if br.AccError() != nil {
+		return nil, fmt.Errorf("segmentation_descriptor: %w", br.AccError())
+	}
+	return d, nil
+}
+
+// String - one-line description of the splice command and descriptors, e.g. for listing ad markers
+func (s *SpliceInfoSection) String() string {
+	var sb strings.Builder
+	sb.WriteString(spliceCommandName(s.SpliceCommandType))
+	if s.EncryptedPacket {
+		sb.WriteString(" (encrypted)")
+		return sb.String()
+	}
+	if s.PTSAdjustment != 0 {
+		fmt.Fprintf(&sb, " ptsAdjustment=%d", s.PTSAdjustment)
+	}
+	switch {
+	case s.SpliceInsert != nil:
+		c := s.SpliceInsert
+		fmt.Fprintf(&sb, " eventID=%d", c.SpliceEventID)
+		if c.CancelIndicator {
+			sb.WriteString(" cancel")
+			break
+		}
+		fmt.Fprintf(&sb, " outOfNetwork=%t", c.OutOfNetwork)
+		if c.SpliceImmediate {
+			sb.WriteString(" immediate")
+		} else if c.ProgramSplice {
+			fmt.Fprintf(&sb, " pts=%s", c.SpliceTime)
+		}
+		if c.HasBreakDuration {
+			fmt.Fprintf(&sb, " duration=%d autoReturn=%t", c.BreakDuration, c.AutoReturn)
+		}
+		fmt.Fprintf(&sb, " uniqueProgramID=%d avail=%d/%d", c.UniqueProgramID, c.AvailNum, c.AvailsExpected)
+	case s.TimeSignal != nil:
+		fmt.Fprintf(&sb, " pts=%s", s.TimeSignal)
+	}
+	return sb.String()
+}
+
+// String - one-line description of descriptor
+func (d SpliceDescriptor) String() string {
+	if d.Segmentation == nil {
+		return fmt.Sprintf("tag=%d identifier=0x%08x data=%d bytes", d.Tag, d.Identifier, len(d.Data))
+	}
+	seg := d.Segmentation
+	if seg.CancelIndicator {
+		return fmt.Sprintf("segmentation eventID=%d cancel", seg.EventID)
+	}
+	str := fmt.Sprintf("segmentation eventID=%d type=0x%02x (%s) segment=%d/%d", seg.EventID, seg.TypeID,
+		segmentationTypeName(seg.TypeID), seg.SegmentNum, seg.SegmentsExpected)
+	if seg.HasDuration {
+		str += fmt.Sprintf(" duration=%d", seg.Duration)
+	}
+	if len(seg.UPID) > 0 {
+		str += fmt.Sprintf(" upidType=%d upid=%s", seg.UPIDType, hex.EncodeToString(seg.UPID))
+	}
+	return str
+}
+
+func spliceCommandName(commandType byte) string {
+	switch commandType {
+	case SpliceNull:
+		return "splice_null"
+	case SpliceSchedule:
+		return "splice_schedule"
+	case SpliceInsertType:
+		return "splice_insert"
+	case TimeSignal:
+		return "time_signal"
+	case BandwidthReservation:
+		return "bandwidth_reservation"
+	case PrivateCommand:
+		return "private_command"
+	default:
+		return fmt.Sprintf("splice_command 0x%02x", commandType)
+	}
+}
+
+// segmentationTypeNames - the most common segmentation_type_id values
+var segmentationTypeNames = map[byte]string{
+	0x10: "Program Start",
+	0x11: "Program End",
+	0x20: "Chapter Start",
+	0x21: "Chapter End",
+	0x22: "Break Start",
+	0x23: "Break End",
+	0x30: "Provider Advertisement Start",
+	0x31: "Provider Advertisement End",
+	0x32: "Distributor Advertisement Start",
+	0x33: "Distributor Advertisement End",
+	0x34: "Provider Placement Opportunity Start",
+	0x35: "Provider Placement Opportunity End",
+	0x36: "Distributor Placement Opportunity Start",
+	0x37: "Distributor Placement Opportunity End",
+}
+
+func segmentationTypeName(typeID byte) string {
+	if name, ok := segmentationTypeNames[typeID]; ok {
+		return name
+	}
+	return "other"
+}
+
+// crc32MPEG2 - CRC_32 of MPEG-2 sections (polynomial 0x04c11db7, no reflection)
+func crc32MPEG2(data []byte) uint32 {
+	crc := uint32(0xffffffff)
+	for _, b := range data {
+		crc ^= uint32(b) << 24
+		for i := 0; i < 8; i++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
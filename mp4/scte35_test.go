@@ -0,0 +1,91 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+// Examples from ANSI/SCTE 35 Sec. 14
+const (
+	scte35TimeSignal   = "/DA0AAAAAAAA///wBQb+cr0AUAAeAhxDVUVJSAAAjn/PAAGlmbAICAAAAAAsoKGKNAIAmsnRfg=="
+	scte35SpliceInsert = "/DAvAAAAAAAA///wFAVIAACPf+/+c2nALv4AUsz1AAAAAAAKAAhDVUVJAAABNWLbowo="
+)
+
+func TestSpliceInfoTimeSignal(t *testing.T) {
+	data, _ := base64.StdEncoding.DecodeString(scte35TimeSignal)
+	s, err := DecodeSpliceInfoSection(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SpliceCommandType != TimeSignal || s.TimeSignal == nil || s.TimeSignal.PTSTime != 0x072bd0050 {
+		t.Errorf("bad time_signal: %+v", s.TimeSignal)
+	}
+	if len(s.Descriptors) != 1 || s.Descriptors[0].Segmentation == nil {
+		t.Fatalf("expected one segmentation descriptor, got %+v", s.Descriptors)
+	}
+	wantSeg := &SegmentationDescriptor{
+		EventID:             0x4800008e,
+		ProgramSegmentation: true,
+		NoRegionalBlackout:  true,
+		ArchiveAllowed:      true,
+		DeviceRestrictions:  3,
+		HasDuration:         true,
+		Duration:            0x0001a599b0,
+		UPIDType:            8,
+		UPID:                []byte{0, 0, 0, 0, 0x2c, 0xa0, 0xa1, 0x8a},
+		TypeID:              0x34,
+		SegmentNum:          2,
+	}
+	if diff := deep.Equal(s.Descriptors[0].Segmentation, wantSeg); diff != nil {
+		t.Error(diff)
+	}
+	wantDesc := "segmentation eventID=1207959694 type=0x34 (Provider Placement Opportunity Start) segment=2/0 " +
+		"duration=27630000 upidType=8 upid=000000002ca0a18a"
+	if got := s.Descriptors[0].String(); got != wantDesc {
+		t.Errorf("got %q instead of %q", got, wantDesc)
+	}
+}
+
+func TestSpliceInfoSpliceInsert(t *testing.T) {
+	data, _ := base64.StdEncoding.DecodeString(scte35SpliceInsert)
+	s, err := DecodeSpliceInfoSection(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "splice_insert eventID=1207959695 outOfNetwork=true pts=1936310318 duration=5426421 autoReturn=true " +
+		"uniqueProgramID=0 avail=0/0"
+	if got := s.String(); got != want {
+		t.Errorf("got %q instead of %q", got, want)
+	}
+	if len(s.SpliceCommand) != 20 || len(s.Descriptors) != 1 || s.Descriptors[0].Tag != 0 {
+		t.Errorf("bad command or descriptors: %d bytes, %+v", len(s.SpliceCommand), s.Descriptors)
+	}
+
+	bad := append([]byte{}, data...)
+	bad[20]++
+	_, err = DecodeSpliceInfoSection(bad)
+	assertError(t, err, "expected CRC error")
+	_, err = DecodeSpliceInfoSection(data[:30])
+	assertError(t, err, "expected length error")
+}
+
+func TestEmsgSCTE35Info(t *testing.T) {
+	data, _ := base64.StdEncoding.DecodeString(scte35SpliceInsert)
+	emsg := &EmsgBox{Version: 1, TimeScale: 90000, PresentationTime: 1936310318, ID: 1,
+		SchemeIDURI: SCTE35SchemeIDURI, MessageData: data}
+	if !emsg.IsSCTE35() {
+		t.Error("expected SCTE-35 emsg")
+	}
+	buf := bytes.Buffer{}
+	err := emsg.Info(&buf, "emsg:1", "", "  ")
+	assertNoError(t, err)
+	out := buf.String()
+	if !strings.Contains(out, " - scte35: splice_insert eventID=1207959695") ||
+		!strings.Contains(out, "   - descriptor tag=0 identifier=0x43554549 data=4 bytes") {
+		t.Errorf("unexpected info:\n%s", out)
+	}
+}
@@ -0,0 +1,111 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// UpdateMfra - create an mfra box with one tfra box per track and set it as the last top-level box.
+// There is one tfra entry per fragment where the track starts with a sync sample.
+// The entry time is the presentation time of that sample and the moof offsets are given
+// by the current top-level boxes, so UpdateMfra should be called after other changes to the file.
+func (f *File) UpdateMfra() error {
+	if !f.IsFragmented() || f.Moov == nil {
+		return fmt.Errorf("not a fragmented file with moov")
+	}
+	if f.Mfra != nil {
+		for i, c := range f.Children {
+			if c == f.Mfra {
+				f.Children = append(f.Children[:i], f.Children[i+1:]...)
+				break
+			}
+		}
+	}
+	positions := f.boxPositions()
+	mfra := &MfraBox{}
+	for _, trak := range f.Moov.Traks {
+		trackID := trak.Tkhd.TrackID
+		trex := f.TrexForTrack(trackID)
+		tfra := &TfraBox{TrackID: trackID}
+		for _, seg := range f.Segments {
+			for _, frag := range seg.Fragments {
+				for i, traf := range frag.Moof.Trafs {
+					if traf.Tfhd.TrackID != trackID || len(traf.Truns) == 0 || traf.Tfdt == nil {
+						continue
+					}
+					trun := traf.Truns[0]
+					if trun.SampleCount() == 0 {
+						continue
+					}
+					trun.AddSampleDefaultValues(traf.Tfhd, trex)
+					first := trun.Samples[0]
+					if !IsSyncSampleFlags(first.Flags) {
+						continue
+					}
+					entry := TfraEntry{
+						Time:        int64(traf.Tfdt.BaseMediaDecodeTime) + int64(first.CompositionTimeOffset),
+						MoofOffset:  int64(positions[frag.Moof]),
+						TrafNumber:  uint32(i + 1),
+						TrunNumber:  1,
+						SampleDelta: 1,
+					}
+					if entry.Time > 0x7fffffff || entry.MoofOffset > 0x7fffffff {
+						tfra.Version = 1
+					}
+					tfra.Entries = append(tfra.Entries, entry)
+				}
+			}
+		}
+		_ = mfra.AddChild(tfra)
+	}
+	mfro := &MfroBox{}
+	_ = mfra.AddChild(mfro)
+	mfro.ParentSize = uint32(mfra.Size())
+	f.Mfra = mfra
+	f.Children = append(f.Children, mfra)
+	return nil
+}
+
+// SeekToTime - byte offset of the moof box of the fragment to start from to play trackID at time
+// (in track timescale). The tfra box for the track is used if present, giving the last random access
+// point at or before time. Otherwise, the moofs are scanned for the last fragment starting at or
+// before time, and the offset is given by the current top-level boxes.
+func (f *File) SeekToTime(trackID uint32, time uint64) (uint64, error) {
+	if !f.IsFragmented() {
+		return 0, fmt.Errorf("not a fragmented file")
+	}
+	if f.Mfra != nil {
+		for _, tfra := range f.Mfra.Tfras {
+			if tfra.TrackID != trackID || len(tfra.Entries) == 0 {
+				continue
+			}
+			var offset int64 = -1
+			for _, e := range tfra.Entries {
+				if e.Time > int64(time) {
+					break
+				}
+				offset = e.MoofOffset
+			}
+			if offset < 0 {
+				return 0, fmt.Errorf("no random access point at or before time %d for track %d", time, trackID)
+			}
+			return uint64(offset), nil
+		}
+	}
+	var moof *MoofBox
+	for _, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			for _, traf := range frag.Moof.Trafs {
+				if traf.Tfhd.TrackID != trackID || traf.Tfdt == nil {
+					continue
+				}
+				if traf.Tfdt.BaseMediaDecodeTime <= time {
+					moof = frag.Moof
+				}
+			}
+		}
+	}
+	if moof == nil {
+		return 0, fmt.Errorf("no fragment starting at or before time %d for track %d", time, trackID)
+	}
+	return f.boxPositions()[moof], nil
+}
@@ -0,0 +1,55 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUpdateMfraAndSeekToTime(t *testing.T) {
+	f := createTestOnDemandFile(t) // Fragments at 0s, 1s, 2s in timescale 90000
+	positions := f.boxPositions()
+	var moofPositions []uint64
+	for _, seg := range f.Segments {
+		moofPositions = append(moofPositions, positions[seg.Fragments[0].Moof])
+	}
+
+	// Scanning moofs without mfra
+	offset, err := f.SeekToTime(1, 95000)
+	assertNoError(t, err)
+	if offset != moofPositions[1] {
+		t.Errorf("got offset %d instead of %d", offset, moofPositions[1])
+	}
+
+	assertNoError(t, f.UpdateMfra())
+	assertNoError(t, f.UpdateMfra()) // Replaces the previous mfra
+	if f.Children[len(f.Children)-1] != f.Mfra || f.Children[len(f.Children)-2] == f.Mfra {
+		t.Fatalf("mfra not single last top-level box")
+	}
+	tfra := f.Mfra.Tfra
+	if len(tfra.Entries) != 3 || tfra.Entries[2].Time != 2*90000 {
+		t.Fatalf("bad tfra entries %+v", tfra.Entries)
+	}
+
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	if f.Mfra.Mfro.ParentSize != uint32(f.Mfra.Size()) {
+		t.Errorf("mfro parent size %d instead of %d", f.Mfra.Mfro.ParentSize, f.Mfra.Size())
+	}
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithSegmentPerFragment())
+	assertNoError(t, err)
+	if decFile.Mfra == nil {
+		t.Fatalf("mfra not decoded")
+	}
+	for i, time := range []uint64{0, 90000, 250000} {
+		offset, err := decFile.SeekToTime(1, time)
+		assertNoError(t, err)
+		if offset != moofPositions[i] {
+			t.Errorf("time %d: got offset %d instead of %d", time, offset, moofPositions[i])
+		}
+		if moof := decFile.Segments[i].Fragments[0].Moof; moof.StartPos != offset {
+			t.Errorf("time %d: offset %d does not match moof at %d", time, offset, moof.StartPos)
+		}
+	}
+	_, err = decFile.SeekToTime(2, 0)
+	assertError(t, err, "unknown track should fail")
+}
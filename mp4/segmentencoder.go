@@ -0,0 +1,157 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// SegmentBuilder - function creating a media segment. Builders are called concurrently by SegmentEncoder
+// workers, so they must not share mutable state without synchronization.
+type SegmentBuilder func() (*MediaSegment, error)
+
+// SegmentEncoder - build and encode media segments concurrently with a pool of workers.
+// The encoded segments are written to the output in the order they were submitted.
+// At most 2*nrWorkers segments are kept in memory at the same time.
+// Submit and Close must be called from the same goroutine.
+type SegmentEncoder struct {
+	w       io.Writer
+	jobs    chan segmentJob
+	order   chan chan segmentResult
+	workers sync.WaitGroup
+	done    chan struct{}
+	mu      sync.Mutex
+	err     error
+	closed  bool
+}
+
+type segmentJob struct {
+	build  SegmentBuilder
+	result chan segmentResult
+}
+
+type segmentResult struct {
+	data []byte
+	err  error
+}
+
+// NewSegmentEncoder - create SegmentEncoder writing to w with nrWorkers workers.
+// If nrWorkers < 1, one worker is used.
+func NewSegmentEncoder(w io.Writer, nrWorkers int) *SegmentEncoder {
+	if nrWorkers < 1 {
+		nrWorkers = 1
+	}
+	e := &SegmentEncoder{
+		w:     w,
+		jobs:  make(chan segmentJob),
+		order: make(chan chan segmentResult, 2*nrWorkers),
+		done:  make(chan struct{}),
+	}
+	e.workers.Add(nrWorkers)
+	for i := 0; i < nrWorkers; i++ {
+		go e.work()
+	}
+	go e.write()
+	return e
+}
+
+// Submit - add a segment builder. Blocks if too many segments are waiting to be written.
+// The first error from building, encoding, or writing a segment is returned, after which
+// no more segments are written.
+func (e *SegmentEncoder) Submit(build SegmentBuilder) error {
+	if e.closed {
+		return fmt.Errorf("segment encoder is closed")
+	}
+	if err := e.getErr(); err != nil {
+		return err
+	}
+	result := make(chan segmentResult, 1)
+	e.order <- result
+	e.jobs <- segmentJob{build, result}
+	return nil
+}
+
+// SubmitSegment - add an already built segment to be encoded
+func (e *SegmentEncoder) SubmitSegment(seg *MediaSegment) error {
+	return e.Submit(func() (*MediaSegment, error) { return seg, nil })
+}
+
+// Close - wait for all submitted segments to be written and return the first error, if any
+func (e *SegmentEncoder) Close() error {
+	if !e.closed {
+		e.closed = true
+		close(e.jobs)
+		close(e.order)
+		e.workers.Wait()
+		<-e.done
+	}
+	return e.getErr()
+}
+
+func (e *SegmentEncoder) work() {
+	defer e.workers.Done()
+	for job := range e.jobs {
+		if e.getErr() != nil {
+			job.result <- segmentResult{}
+			continue
+		}
+		job.result <- buildAndEncodeSegment(job.build)
+	}
+}
+
+func (e *SegmentEncoder) write() {
+	defer close(e.done)
+	for result := range e.order {
+		res := <-result
+		if e.getErr() != nil {
+			continue // Drain remaining results
+		}
+		err := res.err
+		if err == nil {
+			_, err = e.w.Write(res.data)
+		}
+		if err != nil {
+			e.setErr(err)
+		}
+	}
+}
+
+func (e *SegmentEncoder) getErr() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+func (e *SegmentEncoder) setErr(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func buildAndEncodeSegment(build SegmentBuilder) segmentResult {
+	seg, err := build()
+	if err != nil {
+		return segmentResult{err: err}
+	}
+	buf := bytes.Buffer{}
+	buf.Grow(int(seg.Size()))
+	err = seg.Encode(&buf)
+	if err != nil {
+		return segmentResult{err: err}
+	}
+	return segmentResult{data: buf.Bytes()}
+}
+
+// EncodeSegments - encode segments to w in order using nrWorkers concurrent workers
+func EncodeSegments(w io.Writer, segs []*MediaSegment, nrWorkers int) error {
+	e := NewSegmentEncoder(w, nrWorkers)
+	for _, seg := range segs {
+		if err := e.SubmitSegment(seg); err != nil {
+			break
+		}
+	}
+	return e.Close()
+}
@@ -0,0 +1,90 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func createTestSegment(seqNr uint32) (*MediaSegment, error) {
+	seg := NewMediaSegment()
+	frag, err := CreateFragment(seqNr, 1)
+	if err != nil {
+		return nil, err
+	}
+	seg.AddFragment(frag)
+	for i := 0; i < 10; i++ {
+		data := bytes.Repeat([]byte{byte(seqNr), byte(i)}, 50)
+		frag.AddFullSample(FullSample{Sample: NewSample(SyncSampleFlags, 1000, uint32(len(data)), 0),
+			DecodeTime: uint64(seqNr*10+uint32(i)) * 1000, Data: data})
+	}
+	return seg, nil
+}
+
+func TestSegmentEncoder(t *testing.T) {
+	nrSegs := 20
+	var wanted bytes.Buffer
+	for i := 0; i < nrSegs; i++ {
+		seg, err := createTestSegment(uint32(i + 1))
+		assertNoError(t, err)
+		err = seg.Encode(&wanted)
+		assertNoError(t, err)
+	}
+	for _, nrWorkers := range []int{0, 1, 4} {
+		var out bytes.Buffer
+		e := NewSegmentEncoder(&out, nrWorkers)
+		for i := 0; i < nrSegs; i++ {
+			seqNr := uint32(i + 1)
+			err := e.Submit(func() (*MediaSegment, error) { return createTestSegment(seqNr) })
+			assertNoError(t, err)
+		}
+		err := e.Close()
+		assertNoError(t, err)
+		if !bytes.Equal(out.Bytes(), wanted.Bytes()) {
+			t.Errorf("%d workers: output differs from sequential encoding", nrWorkers)
+		}
+		err = e.Submit(func() (*MediaSegment, error) { return createTestSegment(1) })
+		assertError(t, err, "submit after close should fail")
+	}
+
+	segs := make([]*MediaSegment, 0, nrSegs)
+	for i := 0; i < nrSegs; i++ {
+		seg, err := createTestSegment(uint32(i + 1))
+		assertNoError(t, err)
+		segs = append(segs, seg)
+	}
+	var out bytes.Buffer
+	err := EncodeSegments(&out, segs, 3)
+	assertNoError(t, err)
+	if !bytes.Equal(out.Bytes(), wanted.Bytes()) {
+		t.Errorf("EncodeSegments output differs from sequential encoding")
+	}
+}
+
+func TestSegmentEncoderError(t *testing.T) {
+	var out bytes.Buffer
+	e := NewSegmentEncoder(&out, 2)
+	var wanted bytes.Buffer
+	for i := 0; i < 10; i++ {
+		seqNr := uint32(i + 1)
+		build := func() (*MediaSegment, error) { return createTestSegment(seqNr) }
+		if seqNr == 4 {
+			build = func() (*MediaSegment, error) { return nil, fmt.Errorf("build failed") }
+		}
+		if seqNr < 4 {
+			seg, err := build()
+			assertNoError(t, err)
+			assertNoError(t, seg.Encode(&wanted))
+		}
+		if e.Submit(build) != nil {
+			break
+		}
+	}
+	err := e.Close()
+	if err == nil || err.Error() != "build failed" {
+		t.Errorf("expected build error, got %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), wanted.Bytes()) {
+		t.Errorf("expected only segments before error to be written")
+	}
+}
@@ -88,10 +88,10 @@ func DecodeSenc(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 		switch perSampleIVSize {
 		case 0:
 			// Nothing to do
-		case 8:
-			senc.IVs = append(senc.IVs, s.ReadBytes(8))
-		case 16:
-			senc.IVs = append(senc.IVs, s.ReadBytes(16))
+		case 8, 16:
+			for i := uint32(0); i < senc.SampleCount; i++ {
+				senc.IVs = append(senc.IVs, s.ReadBytes(int(perSampleIVSize)))
+			}
 		default:
 			return nil, fmt.Errorf("Strange derived PerSampleIvSize: %d", perSampleIVSize)
 		}
@@ -43,6 +43,18 @@ func TestSencDirectValues(t *testing.T) {
 			IVs:         []InitializationVector{iv16, iv16},
 			SubSamples:  [][]SubSamplePattern{{{10, 1000}}, {{20, 2000}}},
 		},
+		{
+			Version:     0,
+			Flags:       0,
+			SampleCount: 3,
+			IVs:         []InitializationVector{iv8, iv8, iv8},
+		},
+		{
+			Version:     0,
+			Flags:       0,
+			SampleCount: 2,
+			IVs:         []InitializationVector{iv16, iv16},
+		},
 	}
 
 	for _, senc := range sencBoxes {
@@ -13,7 +13,7 @@ type SgpdBox struct {
 	GroupingType                 string // uint32, but takes values such as seig
 	DefaultLength                uint32
 	DefaultGroupDescriptionIndex uint32
-	DescriptionLengths           []uint32
+	DescriptionLengths           []uint32 // Decoded lengths. Encode uses the entry sizes
 	SampleGroupEntries           []SampleGroupEntry
 }
 
@@ -78,14 +78,16 @@ func (b *SgpdBox) Size() uint64 {
 	if b.Version >= 2 {
 		size += 4 // DefaultGroupDescriptionIndex
 	}
-	if b.Version >= 1 {
-		entryCount := len(b.SampleGroupEntries)
-		if b.DefaultLength != 0 {
-			size += uint64(entryCount * int(b.DefaultLength))
-		} else {
-			for _, descLen := range b.DescriptionLengths {
-				size += uint64(4 + descLen)
-			}
+	switch {
+	case b.Version >= 1 && b.DefaultLength != 0:
+		size += uint64(len(b.SampleGroupEntries) * int(b.DefaultLength))
+	case b.Version >= 1:
+		for _, entry := range b.SampleGroupEntries {
+			size += 4 + entry.Size() // DescriptionLength + entry
+		}
+	default: // Version 0 has no lengths, so entry sizes are given by the grouping type
+		for _, entry := range b.SampleGroupEntries {
+			size += entry.Size()
 		}
 	}
 	return size
@@ -111,8 +113,8 @@ func (b *SgpdBox) Encode(w io.Writer) error {
 	entryCount := len(b.SampleGroupEntries)
 	sw.WriteUint32(uint32(entryCount))
 	for i := 0; i < entryCount; i++ {
-		if b.DefaultLength == 0 {
-			sw.WriteUint32(b.DescriptionLengths[i])
+		if b.Version >= 1 && b.DefaultLength == 0 {
+			sw.WriteUint32(uint32(b.SampleGroupEntries[i].Size()))
 		}
 		b.SampleGroupEntries[i].Encode(sw)
 	}
@@ -12,7 +12,20 @@ func TestSgpd(t *testing.T) {
 	unknownEntry := &UnknownSampleGroupEntry{Name: "tele", Data: []byte{0x80}}
 	unknownEntry2 := &UnknownSampleGroupEntry{Name: "tele", Data: []byte{0x00}}
 
+	prolEntry := &RollSampleGroupEntry{GroupingType: "prol", RollDistance: 1}
+	kid := UUID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	seigEntry := &SeigSampleGroupEntry{CryptByteBlock: 1, SkipByteBlock: 9, IsProtected: 1, PerSampleIVSize: 8, KID: kid}
+	seigConstIVEntry := &SeigSampleGroupEntry{CryptByteBlock: 1, SkipByteBlock: 9, IsProtected: 1, KID: kid,
+		ConstantIV: []byte{1, 2, 3, 4, 5, 6, 7, 8}}
+
 	sgpds := []*SgpdBox{
+		{Version: 0, GroupingType: "roll", SampleGroupEntries: []SampleGroupEntry{rollEntry}},
+		{Version: 1, GroupingType: "prol", DefaultLength: 2, SampleGroupEntries: []SampleGroupEntry{prolEntry}},
+		{Version: 1, GroupingType: "seig", DefaultLength: 20, SampleGroupEntries: []SampleGroupEntry{seigEntry}},
+		{Version: 1, GroupingType: "seig", DescriptionLengths: []uint32{20, 29},
+			SampleGroupEntries: []SampleGroupEntry{seigEntry, seigConstIVEntry}},
+		{Version: 2, GroupingType: "rap ", DefaultLength: 1, DefaultGroupDescriptionIndex: 1,
+			SampleGroupEntries: []SampleGroupEntry{rapEntry}},
 		{Version: 1, GroupingType: "roll", DefaultLength: 2, SampleGroupEntries: []SampleGroupEntry{rollEntry}},
 		{Version: 1, GroupingType: "rap ", DefaultLength: 1, SampleGroupEntries: []SampleGroupEntry{rapEntry}},
 		{Version: 1, GroupingType: "alst", DefaultLength: 12, SampleGroupEntries: []SampleGroupEntry{alstEntry}},
@@ -0,0 +1,155 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// SidxBuilder - builds a sidx box with one reference per media segment of a track.
+// Segments can be added one at a time, e.g. as they are produced by a packager.
+type SidxBuilder struct {
+	trackID        uint32
+	trex           *TrexBox
+	presTimeOffset int64
+	sidx           *SidxBox
+}
+
+// NewSidxBuilder - create a SidxBuilder for trackID in init. The earliest presentation time
+// takes composition time offsets and the edit list of the track into account.
+func NewSidxBuilder(init *InitSegment, trackID uint32) (*SidxBuilder, error) {
+	trak := init.Moov.GetTrak(trackID)
+	if trak == nil {
+		return nil, fmt.Errorf("no trak with trackID=%d", trackID)
+	}
+	presTimeOffset, err := init.Moov.PresentationTimeOffset(trackID)
+	if err != nil {
+		return nil, err
+	}
+	var trex *TrexBox
+	if init.Moov.Mvex != nil {
+		trex = init.Moov.Mvex.GetTrex(trackID)
+	}
+	return &SidxBuilder{
+		trackID:        trackID,
+		trex:           trex,
+		presTimeOffset: presTimeOffset,
+		sidx: &SidxBox{
+			ReferenceID: trackID,
+			Timescale:   trak.Mdia.Mdhd.Timescale,
+		},
+	}, nil
+}
+
+// AddSegment - add a reference to seg, which must have samples of the track.
+// The referenced size is the size of the whole segment, and the duration is that of the track samples.
+func (b *SidxBuilder) AddSegment(seg *MediaSegment) error {
+	nr := len(b.sidx.SidxRefs) + 1
+	var ref SidxRef
+	var dur uint64
+	var ept uint64
+	found := false
+	for _, frag := range seg.Fragments {
+		traf := frag.trafForTrack(b.trackID)
+		if traf == nil {
+			continue
+		}
+		fragEPT, err := frag.EarliestPresentationTime(b.trackID, b.trex, b.presTimeOffset)
+		if err != nil {
+			return fmt.Errorf("segment %d: %w", nr, err)
+		}
+		if !found || fragEPT < ept {
+			ept = fragEPT
+		}
+		for _, trun := range traf.Truns {
+			if !found && trun.SampleCount() > 0 {
+				if IsSyncSampleFlags(trun.Samples[0].Flags) {
+					ref.StartsWithSAP, ref.SAPType = 1, 1
+				}
+				found = true
+			}
+			for _, s := range trun.Samples { // Default values added by EarliestPresentationTime
+				dur += uint64(s.Dur)
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("segment %d: no samples for trackID=%d", nr, b.trackID)
+	}
+	size := seg.Size()
+	if dur > 0xffffffff || size >= 1<<31 {
+		return fmt.Errorf("segment %d too big for sidx", nr)
+	}
+	ref.ReferencedSize = uint32(size)
+	ref.SubSegmentDuration = uint32(dur)
+	if nr == 1 {
+		b.sidx.EarliestPresentationTime = ept
+		if ept >= 1<<32 {
+			b.sidx.Version = 1
+		}
+	}
+	b.sidx.SidxRefs = append(b.sidx.SidxRefs, ref)
+	return nil
+}
+
+// Sidx - the sidx box with references to all added segments.
+// FirstOffset is 0, so the first segment should follow directly after the sidx box.
+func (b *SidxBuilder) Sidx() *SidxBox {
+	return b.sidx
+}
+
+// CreateSidxFromSegments - create a sidx box for trackID with one reference per segment
+func CreateSidxFromSegments(init *InitSegment, segs []*MediaSegment, trackID uint32) (*SidxBox, error) {
+	b, err := NewSidxBuilder(init, trackID)
+	if err != nil {
+		return nil, err
+	}
+	for _, seg := range segs {
+		err = b.AddSegment(seg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b.Sidx(), nil
+}
+
+// GenerateSidx - create a sidx box for trackID indexing all media segments of the file and place it
+// before the first segment, replacing any top-level sidx and ssix boxes. Media data offsets and StartPos
+// of top-level boxes are updated. An mfra box should be updated afterwards using UpdateMfra.
+func (f *File) GenerateSidx(trackID uint32) error {
+	if !f.IsFragmented() || f.Init == nil || len(f.Segments) == 0 {
+		return fmt.Errorf("not a fragmented file with init and media segments")
+	}
+	sidx, err := CreateSidxFromSegments(f.Init, f.Segments, trackID)
+	if err != nil {
+		return err
+	}
+	positions := f.boxPositions()
+	firstSegBox := segmentBoxes(f.Segments[0])[0]
+	children := make([]Box, 0, len(f.Children)+1)
+	for _, c := range f.Children {
+		if c == firstSegBox {
+			children = append(children, sidx)
+		}
+		switch c.(type) {
+		case *SidxBox, *SsixBox:
+			if !segmentHasBox(f.Segments, c) {
+				continue
+			}
+		}
+		children = append(children, c)
+	}
+	f.Children = children
+	f.Sidx = sidx
+	f.Sidxs = []*SidxBox{sidx}
+	f.Ssix = nil
+	return f.updateOffsets(positions)
+}
+
+// segmentHasBox - true if b is a sidx or ssix box inside one of segs
+func segmentHasBox(segs []*MediaSegment, b Box) bool {
+	for _, seg := range segs {
+		if Box(seg.Sidx) == b || Box(seg.Ssix) == b {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateSidx(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(75, 3600, 500, 25, 0x20)
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	for i := 0; i < 3; i++ {
+		seg := NewMediaSegment()
+		frag, err := CreateFragment(uint32(i+1), 1)
+		assertNoError(t, err)
+		for _, s := range samples[25*i : 25*(i+1)] {
+			frag.AddFullSample(s)
+		}
+		seg.AddFragment(frag)
+		assertNoError(t, seg.Encode(&buf))
+	}
+	f, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+
+	err = f.GenerateSidx(2)
+	assertError(t, err, "unknown track should fail")
+	assertNoError(t, f.GenerateSidx(1))
+	assertNoError(t, f.GenerateSidx(1)) // Replaces the previous sidx
+	assertNoError(t, f.VerifySidx())
+
+	out := bytes.Buffer{}
+	assertNoError(t, f.Encode(&out))
+	decFile, err := DecodeFile(bytes.NewReader(out.Bytes()))
+	assertNoError(t, err)
+	if len(decFile.Sidxs) != 1 {
+		t.Fatalf("got %d sidx boxes instead of 1", len(decFile.Sidxs))
+	}
+	assertNoError(t, decFile.VerifySidx())
+	sidx := decFile.Sidx
+	if sidx.EarliestPresentationTime != 0 || sidx.Timescale != 90000 || len(sidx.SidxRefs) != 3 {
+		t.Fatalf("bad sidx %+v", sidx)
+	}
+	for i, ref := range sidx.SidxRefs {
+		if uint64(ref.ReferencedSize) != decFile.Segments[i].Size() || ref.SubSegmentDuration != 25*3600 {
+			t.Errorf("ref %d: got size %d and duration %d", i+1, ref.ReferencedSize, ref.SubSegmentDuration)
+		}
+		if ref.StartsWithSAP != 1 || ref.SAPType != 1 {
+			t.Errorf("ref %d: should start with SAP type 1", i+1)
+		}
+	}
+}
+
+func TestSidxBuilder(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	b, err := NewSidxBuilder(init, 1)
+	assertNoError(t, err)
+	seg := NewMediaSegment()
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	seg.AddFragment(frag)
+	err = b.AddSegment(seg)
+	assertError(t, err, "segment without samples should fail")
+
+	frag.AddFullSample(FullSample{
+		Sample:     NewSample(NonSyncSampleFlags, 3600, 10, 7200),
+		DecodeTime: 1 << 32,
+		Data:       make([]byte, 10),
+	})
+	assertNoError(t, b.AddSegment(seg))
+	sidx := b.Sidx()
+	if sidx.Version != 1 || sidx.EarliestPresentationTime != 1<<32+7200 {
+		t.Errorf("got version %d and ept %d", sidx.Version, sidx.EarliestPresentationTime)
+	}
+	if ref := sidx.SidxRefs[0]; ref.StartsWithSAP != 0 || ref.SubSegmentDuration != 3600 {
+		t.Errorf("bad reference %+v", ref)
+	}
+}
@@ -0,0 +1,116 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// SidxSubSegment - media subsegment given by a sidx reference of type 0
+type SidxSubSegment struct {
+	ByteRange
+	EarliestPresentationTime uint64 // In Timescale
+	Duration                 uint32 // In Timescale
+	Timescale                uint32
+	StartsWithSAP            uint8
+	SAPType                  uint8
+	SAPDeltaTime             uint32
+}
+
+// SidxGetter - returns the sidx box starting at pos
+type SidxGetter func(pos uint64) (*SidxBox, error)
+
+// FlattenSidx - ordered media subsegments indexed by sidx, which starts at sidxPos.
+// Index references (type 1), as used for hierarchical and daisy-chained sidx boxes,
+// are resolved recursively using getSidx.
+func FlattenSidx(sidx *SidxBox, sidxPos uint64, getSidx SidxGetter) ([]SidxSubSegment, error) {
+	visited := make(map[uint64]bool)
+	return flattenSidx(sidx, sidxPos, getSidx, visited)
+}
+
+func flattenSidx(sidx *SidxBox, sidxPos uint64, getSidx SidxGetter, visited map[uint64]bool) ([]SidxSubSegment, error) {
+	if visited[sidxPos] {
+		return nil, fmt.Errorf("sidx at %d referenced more than once", sidxPos)
+	}
+	visited[sidxPos] = true
+	var subSegs []SidxSubSegment
+	start := sidxPos + sidx.Size() + sidx.FirstOffset
+	ept := sidx.EarliestPresentationTime
+	for i, ref := range sidx.SidxRefs {
+		end := start + uint64(ref.ReferencedSize)
+		switch ref.ReferenceType {
+		case 0:
+			subSegs = append(subSegs, SidxSubSegment{
+				ByteRange:                ByteRange{Start: start, End: end},
+				EarliestPresentationTime: ept,
+				Duration:                 ref.SubSegmentDuration,
+				Timescale:                sidx.Timescale,
+				StartsWithSAP:            ref.StartsWithSAP,
+				SAPType:                  ref.SAPType,
+				SAPDeltaTime:             ref.SAPDeltaTime,
+			})
+		case 1:
+			child, err := getSidx(start)
+			if err != nil {
+				return nil, fmt.Errorf("sidx at %d reference %d: %w", sidxPos, i+1, err)
+			}
+			childSubSegs, err := flattenSidx(child, start, getSidx, visited)
+			if err != nil {
+				return nil, err
+			}
+			for _, ss := range childSubSegs {
+				if ss.End > end {
+					return nil, fmt.Errorf("sidx at %d reference %d: subsegment at %d ends after reference",
+						sidxPos, i+1, ss.Start)
+				}
+			}
+			subSegs = append(subSegs, childSubSegs...)
+		}
+		ept += uint64(ref.SubSegmentDuration)
+		start = end
+	}
+	return subSegs, nil
+}
+
+// SidxSubSegments - media subsegments indexed by the first top-level sidx box of the file,
+// with hierarchical and daisy-chained sidx boxes resolved among the top-level boxes.
+func (f *File) SidxSubSegments() ([]SidxSubSegment, error) {
+	if f.Sidx == nil {
+		return nil, fmt.Errorf("no sidx box in file")
+	}
+	positions := f.boxPositions()
+	getSidx := func(pos uint64) (*SidxBox, error) {
+		for _, c := range f.Children {
+			if sidx, ok := c.(*SidxBox); ok && positions[c] == pos {
+				return sidx, nil
+			}
+		}
+		return nil, fmt.Errorf("no sidx box at %d", pos)
+	}
+	return FlattenSidx(f.Sidx, positions[f.Sidx], getSidx)
+}
+
+// NewSidxReaderAtGetter - SidxGetter that decodes sidx boxes from r, e.g. a file or an HTTP range reader
+func NewSidxReaderAtGetter(r io.ReaderAt) SidxGetter {
+	return func(pos uint64) (*SidxBox, error) {
+		hdr := make([]byte, boxHeaderSize)
+		_, err := r.ReadAt(hdr, int64(pos))
+		if err != nil {
+			return nil, err
+		}
+		if boxType := string(hdr[4:8]); boxType != "sidx" {
+			return nil, fmt.Errorf("%s box instead of sidx at %d", boxType, pos)
+		}
+		data := make([]byte, binary.BigEndian.Uint32(hdr[0:4]))
+		_, err = r.ReadAt(data, int64(pos))
+		if err != nil {
+			return nil, err
+		}
+		box, err := DecodeBox(pos, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return box.(*SidxBox), nil
+	}
+}
@@ -0,0 +1,86 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+// createHierarchicalSidxFile - file with a root sidx referencing one sidx for the first two
+// subsegments and one for the last subsegment
+func createHierarchicalSidxFile(t *testing.T) *File {
+	t.Helper()
+	buf := bytes.Buffer{}
+	assertNoError(t, createTestOnDemandFile(t).Encode(&buf)) // Sets trun data offsets
+	f, err := DecodeFile(&buf, WithSegmentPerFragment())
+	assertNoError(t, err)
+	var segSizes []uint32
+	for _, seg := range f.Segments {
+		segSizes = append(segSizes, uint32(seg.Size()))
+	}
+	mediaRef := func(i int) SidxRef {
+		return SidxRef{ReferencedSize: segSizes[i], SubSegmentDuration: 90000, StartsWithSAP: 1, SAPType: 1}
+	}
+	sidxA := &SidxBox{ReferenceID: 1, Timescale: 90000, SidxRefs: []SidxRef{mediaRef(0), mediaRef(1)}}
+	sidxB := &SidxBox{ReferenceID: 1, Timescale: 90000, EarliestPresentationTime: 180000,
+		SidxRefs: []SidxRef{mediaRef(2)}}
+	root := &SidxBox{ReferenceID: 1, Timescale: 90000, SidxRefs: []SidxRef{
+		{ReferenceType: 1, ReferencedSize: uint32(sidxA.Size()) + segSizes[0] + segSizes[1], SubSegmentDuration: 180000},
+		{ReferenceType: 1, ReferencedSize: uint32(sidxB.Size()) + segSizes[2], SubSegmentDuration: 90000},
+	}}
+	children := []Box{f.Ftyp, f.Moov, root, sidxA}
+	for i, seg := range f.Segments {
+		if i == 2 {
+			children = append(children, sidxB)
+		}
+		children = append(children, seg.Fragments[0].Children...)
+	}
+	f.Children = children
+	f.Sidx = root
+	f.Sidxs = []*SidxBox{root, sidxA}
+	f.FragEncMode = EncModeBoxTree
+	return f
+}
+
+func TestFlattenHierarchicalSidx(t *testing.T) {
+	f := createHierarchicalSidxFile(t)
+	assertNoError(t, f.VerifySidx())
+	positions := f.boxPositions()
+
+	subSegs, err := f.SidxSubSegments()
+	assertNoError(t, err)
+	if len(subSegs) != 3 {
+		t.Fatalf("got %d subsegments instead of 3", len(subSegs))
+	}
+	for i, ss := range subSegs {
+		frag := f.Segments[i].Fragments[0]
+		start := positions[frag.Moof]
+		if ss.Start != start || ss.End != start+frag.Size() {
+			t.Errorf("subsegment %d: got %v instead of [%d, %d)", i, ss.ByteRange, start, start+frag.Size())
+		}
+		if ss.EarliestPresentationTime != uint64(i)*90000 || ss.Duration != 90000 || ss.SAPType != 1 {
+			t.Errorf("subsegment %d: got %+v", i, ss)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	r := bytes.NewReader(buf.Bytes())
+	getSidx := NewSidxReaderAtGetter(r)
+	root, err := getSidx(positions[f.Sidx])
+	assertNoError(t, err)
+	readSubSegs, err := FlattenSidx(root, positions[f.Sidx], getSidx)
+	assertNoError(t, err)
+	if len(readSubSegs) != len(subSegs) || readSubSegs[2] != subSegs[2] {
+		t.Errorf("got %+v from reader instead of %+v", readSubSegs, subSegs)
+	}
+	_, err = getSidx(0)
+	assertError(t, err, "ftyp is not a sidx box")
+}
+
+func TestFlattenSidxLoop(t *testing.T) {
+	sidx := &SidxBox{SidxRefs: []SidxRef{{ReferenceType: 1, ReferencedSize: 100}}}
+	sidx.FirstOffset = ^uint64(0) - sidx.Size() + 1 // Reference starts at the sidx itself
+	getSidx := func(pos uint64) (*SidxBox, error) { return sidx, nil }
+	_, err := FlattenSidx(sidx, 0, getSidx)
+	assertError(t, err, "sidx referencing itself should fail")
+}
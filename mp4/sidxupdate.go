@@ -0,0 +1,303 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// VerifySidx - verify that the references of all top-level sidx boxes match the actual bytes of the file.
+// Every reference must start and end at a box boundary within the file. Media references (type 0)
+// must contain a moof box, and index references (type 1) must start with a sidx box.
+func (f *File) VerifySidx() error {
+	positions := f.boxPositions()
+	nr := 0
+	for _, c := range f.Children {
+		sidx, ok := c.(*SidxBox)
+		if !ok {
+			continue
+		}
+		nr++
+		refBoxes, err := f.sidxRefBoxes(sidx, positions)
+		if err != nil {
+			return fmt.Errorf("sidx %d: %w", nr, err)
+		}
+		for i, ref := range sidx.SidxRefs {
+			boxes := refBoxes[i]
+			switch ref.ReferenceType {
+			case 0:
+				if !containsMoof(boxes) {
+					return fmt.Errorf("sidx %d: reference %d has no moof box", nr, i+1)
+				}
+			case 1:
+				if boxes[0].Type() != "sidx" {
+					return fmt.Errorf("sidx %d: reference %d does not start with sidx box", nr, i+1)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveSegments - remove the media segments with index in [start, end) from a fragmented file.
+// For a file without styp boxes, e.g. DASH OnDemand, decode with WithSegmentPerFragment to get one
+// media segment per fragment.
+// All sidx boxes are updated so that they only index the remaining data. References to removed
+// data only are dropped, which reduces the subsegment count, and the earliest presentation time
+// is increased by the duration of dropped leading data, including the removed start of the first kept
+// reference. References that lose part of their data get new sizes, durations, and SAP information. The first offset of each sidx is recalculated.
+// An ssix box directly following a sidx box keeps the subsegments of the remaining references.
+// Dependent offsets and StartPos of top-level boxes are updated as described for File.RemoveChild.
+func (f *File) RemoveSegments(start, end int) error {
+	if !f.isFragmented {
+		return fmt.Errorf("file is not fragmented")
+	}
+	if start < 0 || end > len(f.Segments) || start >= end {
+		return fmt.Errorf("segment range [%d, %d) not within %d segments", start, end, len(f.Segments))
+	}
+	positions := f.boxPositions()
+	var sidxs []*SidxBox
+	refBoxes := make(map[*SidxBox][][]Box)
+	for _, c := range f.Children {
+		if sidx, ok := c.(*SidxBox); ok {
+			boxes, err := f.sidxRefBoxes(sidx, positions)
+			if err != nil {
+				return fmt.Errorf("cannot update sidx: %w", err)
+			}
+			sidxs = append(sidxs, sidx)
+			refBoxes[sidx] = boxes
+		}
+	}
+
+	removed := make(map[Box]bool)
+	for i, seg := range f.Segments[start:end] {
+		boxes := segmentBoxes(seg)
+		if len(boxes) == 0 {
+			continue
+		}
+		first, last := boxes[0], boxes[len(boxes)-1]
+		from, okFirst := positions[first]
+		to, okLast := positions[last]
+		if !okFirst || !okLast {
+			return fmt.Errorf("segment %d is not part of the file children", start+i)
+		}
+		to += last.Size()
+		for _, c := range f.Children {
+			if pos := positions[c]; pos >= from && pos < to {
+				removed[c] = true
+			}
+		}
+	}
+
+	ssixs := make(map[*SidxBox]*SsixBox) // ssix boxes directly following a sidx box
+	for i, c := range f.Children[:len(f.Children)-1] {
+		if ssix, ok := f.Children[i+1].(*SsixBox); ok && c.Type() == "sidx" {
+			ssixs[c.(*SidxBox)] = ssix
+		}
+	}
+
+	for _, sidx := range sidxs {
+		if removed[sidx] {
+			continue
+		}
+		ssix := ssixs[sidx]
+		if ssix != nil && len(ssix.SubSegments) != len(sidx.SidxRefs) {
+			return fmt.Errorf("ssix has %d subsegments, but sidx has %d references",
+				len(ssix.SubSegments), len(sidx.SidxRefs))
+		}
+		var refs []SidxRef
+		var keptBoxes [][]Box
+		var subSegments []SsixSubSegment
+		for i, ref := range sidx.SidxRefs {
+			boxes := refBoxes[sidx][i]
+			var kept, leading []Box // leading - removed boxes before the first kept one
+			for _, b := range boxes {
+				if !removed[b] {
+					kept = append(kept, b)
+				} else if len(kept) == 0 {
+					leading = append(leading, b)
+				}
+			}
+			if len(kept) == 0 {
+				if len(refs) == 0 {
+					sidx.EarliestPresentationTime += uint64(ref.SubSegmentDuration)
+				}
+				continue
+			}
+			if ssix != nil {
+				subSegments = append(subSegments, ssix.SubSegments[i])
+			}
+			if len(kept) < len(boxes) {
+				ref.SubSegmentDuration = uint32(f.boxesDuration(kept, sidx.ReferenceID))
+				if len(leading) > 0 {
+					ref.StartsWithSAP, ref.SAPType, ref.SAPDeltaTime = f.boxesSAP(kept, sidx.ReferenceID)
+					if len(refs) == 0 {
+						sidx.EarliestPresentationTime += f.boxesDuration(leading, sidx.ReferenceID)
+					}
+				}
+				if ssix != nil { // Level structure is unknown, so keep all data at the highest level
+					subSegments[len(subSegments)-1] = wholeSsixSubSegment(ssix.SubSegments[i])
+				}
+			}
+			refs = append(refs, ref)
+			keptBoxes = append(keptBoxes, kept)
+		}
+		sidx.SidxRefs = refs
+		if ssix != nil {
+			ssix.SubSegments = subSegments
+		}
+		refBoxes[sidx] = keptBoxes
+		if sidx.EarliestPresentationTime >= 1<<32 {
+			sidx.Version = 1
+		}
+	}
+
+	children := f.Children[:0]
+	for _, c := range f.Children {
+		if !removed[c] {
+			children = append(children, c)
+		}
+	}
+	f.Children = children
+	f.Segments = append(f.Segments[:start], f.Segments[end:]...)
+
+	// Sizes are set after all references are dropped, since index references cover other sidx boxes
+	for _, sidx := range sidxs {
+		for i, boxes := range refBoxes[sidx] {
+			var size uint64
+			for _, b := range boxes {
+				size += b.Size()
+			}
+			if size >= 1<<31 {
+				return fmt.Errorf("sidx reference size %d too big", size)
+			}
+			sidx.SidxRefs[i].ReferencedSize = uint32(size)
+		}
+	}
+	newPositions := f.boxPositions()
+	for _, sidx := range sidxs {
+		if removed[sidx] {
+			continue
+		}
+		sidx.FirstOffset = 0
+		if len(refBoxes[sidx]) > 0 {
+			sidx.FirstOffset = newPositions[refBoxes[sidx][0][0]] - newPositions[sidx] - sidx.Size()
+		}
+	}
+	return f.updateOffsets(positions)
+}
+
+// sidxRefBoxes - top-level boxes covered by each reference of sidx given the positions of the boxes.
+// An error is returned if a reference does not start and end at box boundaries.
+func (f *File) sidxRefBoxes(sidx *SidxBox, positions map[Box]uint64) ([][]Box, error) {
+	refBoxes := make([][]Box, len(sidx.SidxRefs))
+	start := positions[sidx] + sidx.Size() + sidx.FirstOffset
+	i := 0
+	for i < len(f.Children) && positions[f.Children[i]] < start {
+		i++
+	}
+	for j, ref := range sidx.SidxRefs {
+		if ref.ReferencedSize == 0 {
+			return nil, fmt.Errorf("reference %d has size 0", j+1)
+		}
+		if i == len(f.Children) || positions[f.Children[i]] != start {
+			return nil, fmt.Errorf("reference %d at offset %d does not start at a box", j+1, start)
+		}
+		end := start + uint64(ref.ReferencedSize)
+		for i < len(f.Children) && positions[f.Children[i]] < end {
+			refBoxes[j] = append(refBoxes[j], f.Children[i])
+			i++
+		}
+		last := refBoxes[j][len(refBoxes[j])-1]
+		if actualEnd := positions[last] + last.Size(); actualEnd != end {
+			return nil, fmt.Errorf("reference %d ends at offset %d, but box data ends at %d", j+1, end, actualEnd)
+		}
+		start = end
+	}
+	return refBoxes, nil
+}
+
+// boxesDuration - sum of sample durations for trackID in the moof boxes among boxes
+func (f *File) boxesDuration(boxes []Box, trackID uint32) uint64 {
+	trex := f.TrexForTrack(trackID)
+	var dur uint64
+	for _, b := range boxes {
+		moof, ok := b.(*MoofBox)
+		if !ok {
+			continue
+		}
+		for _, traf := range moof.Trafs {
+			if traf.Tfhd.TrackID != trackID {
+				continue
+			}
+			for _, trun := range traf.Truns {
+				dur += trun.AddSampleDefaultValues(traf.Tfhd, trex)
+			}
+		}
+	}
+	return dur
+}
+
+// boxesSAP - sidx SAP values given by the first sample of trackID in the moof boxes among boxes.
+// Only sync samples are signaled, as SAP type 1.
+func (f *File) boxesSAP(boxes []Box, trackID uint32) (startsWithSAP, sapType uint8, sapDeltaTime uint32) {
+	trex := f.TrexForTrack(trackID)
+	for _, b := range boxes {
+		moof, ok := b.(*MoofBox)
+		if !ok {
+			continue
+		}
+		for _, traf := range moof.Trafs {
+			if traf.Tfhd.TrackID != trackID {
+				continue
+			}
+			for _, trun := range traf.Truns {
+				if trun.SampleCount() == 0 {
+					continue
+				}
+				trun.AddSampleDefaultValues(traf.Tfhd, trex)
+				if IsSyncSampleFlags(trun.Samples[0].Flags) {
+					return 1, 1, 0
+				}
+				return 0, 0, 0
+			}
+		}
+	}
+	return 0, 0, 0
+}
+
+// segmentBoxes - top-level boxes of a media segment in order
+func segmentBoxes(seg *MediaSegment) []Box {
+	var boxes []Box
+	if seg.Styp != nil {
+		boxes = append(boxes, seg.Styp)
+	}
+	if seg.Sidx != nil {
+		boxes = append(boxes, seg.Sidx)
+	}
+	if seg.Ssix != nil {
+		boxes = append(boxes, seg.Ssix)
+	}
+	for _, frag := range seg.Fragments {
+		boxes = append(boxes, frag.Children...)
+	}
+	return boxes
+}
+
+// wholeSsixSubSegment - subsegment with a single range covering all data at the highest level of ss
+func wholeSsixSubSegment(ss SsixSubSegment) SsixSubSegment {
+	var level uint8
+	for _, r := range ss.Ranges {
+		if r.Level > level {
+			level = r.Level
+		}
+	}
+	return SsixSubSegment{Ranges: []SsixRange{{Level: level, RangeSize: 0}}}
+}
+
+func containsMoof(boxes []Box) bool {
+	for _, b := range boxes {
+		if b.Type() == "moof" {
+			return true
+		}
+	}
+	return false
+}
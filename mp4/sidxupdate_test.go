@@ -0,0 +1,113 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func createTestOnDemandFile(t *testing.T) *File {
+	t.Helper()
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(60, 3600, 500, 25, 0x20) // Subsegments at 0s, 1s, 2s
+	f, err := CreateOnDemandFile(init, NewSliceSampleSource(samples), 90000)
+	assertNoError(t, err)
+	return f
+}
+
+func TestRemoveSegmentsUpdatesSidx(t *testing.T) {
+	f := createTestOnDemandFile(t)
+	assertNoError(t, f.VerifySidx())
+	assertNoError(t, f.RemoveSegments(0, 1))
+	assertNoError(t, f.VerifySidx())
+
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithSegmentPerFragment())
+	assertNoError(t, err)
+	assertNoError(t, decFile.VerifySidx())
+	sidx := decFile.Sidx
+	if len(sidx.SidxRefs) != 2 || len(decFile.Segments) != 2 {
+		t.Fatalf("got %d references and %d segments instead of 2", len(sidx.SidxRefs), len(decFile.Segments))
+	}
+	if sidx.EarliestPresentationTime != 25*3600 {
+		t.Errorf("got earliest presentation time %d instead of %d", sidx.EarliestPresentationTime, 25*3600)
+	}
+	offset := decFile.Ftyp.Size() + decFile.Moov.Size() + sidx.Size() + sidx.FirstOffset
+	for i, ref := range sidx.SidxRefs {
+		if moof := decFile.Segments[i].Fragments[0].Moof; moof.StartPos != offset {
+			t.Errorf("ref %d: moof at %d instead of %d", i+1, moof.StartPos, offset)
+		}
+		offset += uint64(ref.ReferencedSize)
+	}
+	if offset != uint64(buf.Len()) {
+		t.Errorf("sidx covers %d bytes instead of file size %d", offset, buf.Len())
+	}
+}
+
+func TestRemoveSegmentsPartialReference(t *testing.T) {
+	f := createTestOnDemandFile(t)
+	sidx := f.Sidx
+	// Merge the first two references so that removing the first segment leaves half a reference
+	sidx.SidxRefs[0].ReferencedSize += sidx.SidxRefs[1].ReferencedSize
+	sidx.SidxRefs[0].SubSegmentDuration += sidx.SidxRefs[1].SubSegmentDuration
+	sidx.SidxRefs = append(sidx.SidxRefs[:1], sidx.SidxRefs[2])
+	assertNoError(t, f.VerifySidx())
+	wantedSize := f.Segments[1].Size()
+
+	assertNoError(t, f.RemoveSegments(0, 1))
+	assertNoError(t, f.VerifySidx())
+	if len(sidx.SidxRefs) != 2 {
+		t.Fatalf("got %d references instead of 2", len(sidx.SidxRefs))
+	}
+	ref := sidx.SidxRefs[0]
+	if uint64(ref.ReferencedSize) != wantedSize || ref.SubSegmentDuration != 25*3600 {
+		t.Errorf("got size %d and duration %d instead of %d and %d", ref.ReferencedSize, ref.SubSegmentDuration,
+			wantedSize, 25*3600)
+	}
+	if ref.StartsWithSAP != 1 || ref.SAPType != 1 {
+		t.Errorf("reference should start with SAP type 1")
+	}
+	// The earliest presentation time must move to the first kept fragment
+	firstTime := f.Segments[0].Fragments[0].Moof.Traf.Tfdt.BaseMediaDecodeTime
+	if sidx.EarliestPresentationTime != 25*3600 || sidx.EarliestPresentationTime != firstTime {
+		t.Errorf("got earliest presentation time %d instead of %d", sidx.EarliestPresentationTime, firstTime)
+	}
+}
+
+func TestVerifySidxErrors(t *testing.T) {
+	f := createTestOnDemandFile(t)
+	f.Sidx.SidxRefs[1].ReferencedSize++
+	assertError(t, f.VerifySidx(), "wrong reference size should give error")
+	assertError(t, f.RemoveSegments(0, 1), "stale sidx should not be updated")
+
+	f = createTestOnDemandFile(t)
+	f.Sidx.SidxRefs = append(f.Sidx.SidxRefs, SidxRef{ReferencedSize: 100})
+	assertError(t, f.VerifySidx(), "reference beyond end of file should give error")
+	assertError(t, f.RemoveSegments(2, 1), "empty range should give error")
+}
+
+func TestEncodeUsesChangedSidx(t *testing.T) {
+	f := createTestOnDemandFile(t)
+	oldSidx := f.Sidx
+	newSidx := *oldSidx
+	newSidx.Timescale = 1000
+	f.Sidx = &newSidx // Sidxs still has the old sidx box
+
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithSegmentPerFragment())
+	assertNoError(t, err)
+	if len(decFile.Sidxs) != 1 || decFile.Sidx.Timescale != 1000 {
+		t.Errorf("got %d sidx boxes with timescale %d instead of changed sidx", len(decFile.Sidxs), decFile.Sidx.Timescale)
+	}
+
+	f.Sidx = nil
+	buf.Reset()
+	assertNoError(t, f.Encode(&buf))
+	decFile, err = DecodeFile(bytes.NewReader(buf.Bytes()), WithSegmentPerFragment())
+	assertNoError(t, err)
+	if decFile.Sidx != nil || len(decFile.Sidxs) != 0 {
+		t.Errorf("sidx written although Sidx is nil")
+	}
+}
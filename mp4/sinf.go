@@ -53,6 +53,15 @@ func (b *SinfBox) GetChildren() []Box {
 	return b.Children
 }
 
+func (b *SinfBox) setChildren(children []Box) error {
+	n := SinfBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - write minf container to w
 func (b *SinfBox) Encode(w io.Writer) error {
 	return EncodeContainer(b, w)
@@ -0,0 +1,189 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+/*
+Definition according to ISO/IEC 14496-12 Section 8.16.4.2
+aligned(8) class SubsegmentIndexBox extends FullBox(‘ssix’, 0, 0) {
+	unsigned int(32) subsegment_count;
+	for( i=1; i <= subsegment_count; i++) {
+		unsigned int(32) range_count;
+		for ( j=1; j <= range_count; j++) {
+			unsigned int(8)  level;
+			unsigned int(24) range_size;
+		}
+	}
+}
+*/
+
+// SsixBox - SubsegmentIndexBox
+//
+// Follows the sidx box that it documents. There is one subsegment per sidx reference,
+// and each subsegment is partitioned into byte ranges of different levels.
+type SsixBox struct {
+	Version     byte
+	Flags       uint32
+	SubSegments []SsixSubSegment
+}
+
+// SsixSubSegment - byte ranges of one subsegment in SsixBox
+type SsixSubSegment struct {
+	Ranges []SsixRange
+}
+
+// SsixRange - range in SsixSubSegment with level and 24-bit size.
+// A size of 0 for the last range of a subsegment means the rest of the subsegment.
+type SsixRange struct {
+	Level     uint8
+	RangeSize uint32
+}
+
+// ByteRange - byte range [Start, End) in a file
+type ByteRange struct {
+	Start uint64
+	End   uint64
+}
+
+// DecodeSsix - box-specific decode
+func DecodeSsix(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8 {
+		return nil, fmt.Errorf("ssix: payload too short")
+	}
+	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
+	b := &SsixBox{
+		Version: byte(versionAndFlags >> 24),
+		Flags:   versionAndFlags & flagsMask,
+	}
+	subSegmentCount := s.ReadUint32()
+	for i := uint32(0); i < subSegmentCount; i++ {
+		if s.NrRemainingBytes() < 4 {
+			return nil, fmt.Errorf("ssix: payload too short for %d subsegments", subSegmentCount)
+		}
+		rangeCount := int(s.ReadUint32())
+		if s.NrRemainingBytes() < 4*rangeCount {
+			return nil, fmt.Errorf("ssix: payload too short for %d ranges", rangeCount)
+		}
+		ss := SsixSubSegment{Ranges: make([]SsixRange, rangeCount)}
+		for j := range ss.Ranges {
+			levelAndSize := s.ReadUint32()
+			ss.Ranges[j] = SsixRange{Level: uint8(levelAndSize >> 24), RangeSize: levelAndSize & 0xffffff}
+		}
+		b.SubSegments = append(b.SubSegments, ss)
+	}
+	return b, nil
+}
+
+// Type - return box type
+func (b *SsixBox) Type() string {
+	return "ssix"
+}
+
+// Size - return calculated size
+func (b *SsixBox) Size() uint64 {
+	size := uint64(boxHeaderSize + 8)
+	for _, ss := range b.SubSegments {
+		size += 4 + 4*uint64(len(ss.Ranges))
+	}
+	return size
+}
+
+// Encode - write box to w
+func (b *SsixBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint32((uint32(b.Version) << 24) + b.Flags)
+	sw.WriteUint32(uint32(len(b.SubSegments)))
+	for _, ss := range b.SubSegments {
+		sw.WriteUint32(uint32(len(ss.Ranges)))
+		for _, r := range ss.Ranges {
+			if r.RangeSize > 0xffffff {
+				return fmt.Errorf("ssix: range size %d does not fit in 24 bits", r.RangeSize)
+			}
+			sw.WriteUint32(uint32(r.Level)<<24 | r.RangeSize)
+		}
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information. Ranges are shown with ssix:1
+func (b *SsixBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	bd.write(" - subSegmentCount: %d", len(b.SubSegments))
+	if getInfoLevel(b, specificBoxLevels) > 0 {
+		for i, ss := range b.SubSegments {
+			bd.write(" - subSegment[%d]: rangeCount=%d", i+1, len(ss.Ranges))
+			for _, r := range ss.Ranges {
+				bd.write("   level=%d rangeSize=%d", r.Level, r.RangeSize)
+			}
+		}
+	}
+	return bd.err
+}
+
+// LevelByteRanges - byte ranges of all data with level maxLevel or lower, e.g. for partial segment fetching.
+// The subsegments correspond to the references of sidx, and the first subsegment starts at anchor,
+// which is the position of the first byte after sidx plus its FirstOffset. Adjacent ranges are merged.
+func (b *SsixBox) LevelByteRanges(sidx *SidxBox, anchor uint64, maxLevel uint8) ([]ByteRange, error) {
+	if len(b.SubSegments) != len(sidx.SidxRefs) {
+		return nil, fmt.Errorf("ssix has %d subsegments, but sidx has %d references", len(b.SubSegments), len(sidx.SidxRefs))
+	}
+	var ranges []ByteRange
+	start := anchor
+	for i, ss := range b.SubSegments {
+		end := start + uint64(sidx.SidxRefs[i].ReferencedSize)
+		pos := start
+		for j, r := range ss.Ranges {
+			rangeEnd := pos + uint64(r.RangeSize)
+			if r.RangeSize == 0 && j == len(ss.Ranges)-1 {
+				rangeEnd = end
+			}
+			if rangeEnd > end {
+				return nil, fmt.Errorf("subsegment %d: ranges exceed referenced size %d", i+1, sidx.SidxRefs[i].ReferencedSize)
+			}
+			if r.Level <= maxLevel && rangeEnd > pos {
+				if n := len(ranges); n > 0 && ranges[n-1].End == pos {
+					ranges[n-1].End = rangeEnd
+				} else {
+					ranges = append(ranges, ByteRange{Start: pos, End: rangeEnd})
+				}
+			}
+			pos = rangeEnd
+		}
+		start = end
+	}
+	return ranges, nil
+}
+
+// GetLevelByteRanges - byte ranges in the file of all data with level maxLevel or lower.
+// The ranges are given by the top-level ssix box and the sidx box directly preceding it.
+func (f *File) GetLevelByteRanges(maxLevel uint8) ([]ByteRange, error) {
+	if f.Ssix == nil {
+		return nil, fmt.Errorf("no ssix box in file")
+	}
+	for i, c := range f.Children {
+		if c != f.Ssix {
+			continue
+		}
+		if i == 0 || f.Children[i-1].Type() != "sidx" {
+			return nil, fmt.Errorf("ssix box does not follow a sidx box")
+		}
+		sidx := f.Children[i-1].(*SidxBox)
+		anchor := f.boxPositions()[sidx] + sidx.Size() + sidx.FirstOffset
+		return f.Ssix.LevelByteRanges(sidx, anchor, maxLevel)
+	}
+	return nil, fmt.Errorf("ssix box not among top-level boxes")
+}
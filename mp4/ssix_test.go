@@ -0,0 +1,134 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSsix(t *testing.T) {
+	ssix := &SsixBox{
+		SubSegments: []SsixSubSegment{
+			{Ranges: []SsixRange{{Level: 1, RangeSize: 40}, {Level: 2, RangeSize: 60}}},
+			{Ranges: []SsixRange{{Level: 1, RangeSize: 20}, {Level: 2, RangeSize: 0}}},
+		},
+	}
+	boxDiffAfterEncodeAndDecode(t, ssix)
+	if ssix.Size() != 12+4+2*(4+2*4) {
+		t.Errorf("got size %d", ssix.Size())
+	}
+
+	ssix.SubSegments[0].Ranges[0].RangeSize = 1 << 24
+	err := ssix.Encode(&bytes.Buffer{})
+	assertError(t, err, "range size exceeding 24 bits should fail")
+}
+
+func TestSsixLevelByteRanges(t *testing.T) {
+	sidx := &SidxBox{SidxRefs: []SidxRef{{ReferencedSize: 100}, {ReferencedSize: 50}}}
+	ssix := &SsixBox{
+		SubSegments: []SsixSubSegment{
+			{Ranges: []SsixRange{{Level: 1, RangeSize: 40}, {Level: 2, RangeSize: 60}}},
+			{Ranges: []SsixRange{{Level: 1, RangeSize: 20}, {Level: 2, RangeSize: 0}}},
+		},
+	}
+	testCases := []struct {
+		maxLevel uint8
+		wanted   []ByteRange
+	}{
+		{0, nil},
+		{1, []ByteRange{{1000, 1040}, {1100, 1120}}},
+		{2, []ByteRange{{1000, 1150}}},
+	}
+	for _, tc := range testCases {
+		ranges, err := ssix.LevelByteRanges(sidx, 1000, tc.maxLevel)
+		assertNoError(t, err)
+		if len(ranges) != len(tc.wanted) {
+			t.Errorf("level %d: got %v instead of %v", tc.maxLevel, ranges, tc.wanted)
+			continue
+		}
+		for i := range ranges {
+			if ranges[i] != tc.wanted[i] {
+				t.Errorf("level %d: got %v instead of %v", tc.maxLevel, ranges, tc.wanted)
+				break
+			}
+		}
+	}
+
+	ssix.SubSegments[1].Ranges[0].RangeSize = 60
+	_, err := ssix.LevelByteRanges(sidx, 0, 2)
+	assertError(t, err, "ranges exceeding referenced size should fail")
+	ssix.SubSegments = ssix.SubSegments[:1]
+	_, err = ssix.LevelByteRanges(sidx, 0, 2)
+	assertError(t, err, "subsegment count mismatch should fail")
+}
+
+// addTestSsix - insert an ssix box after the sidx of f with moof boxes at level 1 and mdat boxes at level 2
+func addTestSsix(t *testing.T, f *File) {
+	t.Helper()
+	ssix := &SsixBox{}
+	for _, seg := range f.Segments {
+		ssix.SubSegments = append(ssix.SubSegments, SsixSubSegment{
+			Ranges: []SsixRange{
+				{Level: 1, RangeSize: uint32(seg.Fragments[0].Moof.Size())},
+				{Level: 2, RangeSize: 0},
+			},
+		})
+	}
+	for i, c := range f.Children {
+		if c == f.Sidx {
+			f.Children = append(f.Children[:i+1], append([]Box{ssix}, f.Children[i+1:]...)...)
+			break
+		}
+	}
+	f.Ssix = ssix
+	f.Sidx.FirstOffset += ssix.Size()
+}
+
+func TestFileLevelByteRanges(t *testing.T) {
+	f := createTestOnDemandFile(t)
+	_, err := f.GetLevelByteRanges(1)
+	assertError(t, err, "file without ssix should fail")
+	addTestSsix(t, f)
+	assertNoError(t, f.VerifySidx())
+
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()), WithSegmentPerFragment())
+	assertNoError(t, err)
+	if decFile.Ssix == nil {
+		t.Fatalf("ssix not decoded")
+	}
+	ranges, err := decFile.GetLevelByteRanges(1)
+	assertNoError(t, err)
+	if len(ranges) != len(decFile.Segments) {
+		t.Fatalf("got %d ranges instead of %d", len(ranges), len(decFile.Segments))
+	}
+	for i, r := range ranges {
+		moof := decFile.Segments[i].Fragments[0].Moof
+		if r.Start != moof.StartPos || r.End != moof.StartPos+moof.Size() {
+			t.Errorf("range %d: got %v instead of moof at [%d, %d)", i+1, r, moof.StartPos, moof.StartPos+moof.Size())
+		}
+	}
+	ranges, err = decFile.GetLevelByteRanges(2)
+	assertNoError(t, err)
+	if len(ranges) != 1 || ranges[0].End != uint64(buf.Len()) {
+		t.Errorf("got %v instead of one range to end of file", ranges)
+	}
+}
+
+func TestRemoveSegmentsUpdatesSsix(t *testing.T) {
+	f := createTestOnDemandFile(t)
+	addTestSsix(t, f)
+	assertNoError(t, f.RemoveSegments(0, 1))
+	assertNoError(t, f.VerifySidx())
+	if len(f.Ssix.SubSegments) != 2 {
+		t.Fatalf("got %d subsegments instead of 2", len(f.Ssix.SubSegments))
+	}
+	ranges, err := f.GetLevelByteRanges(1)
+	assertNoError(t, err)
+	positions := f.boxPositions()
+	for i, r := range ranges {
+		if moof := f.Segments[i].Fragments[0].Moof; r.Start != positions[moof] {
+			t.Errorf("range %d: got start %d instead of %d", i+1, r.Start, positions[moof])
+		}
+	}
+}
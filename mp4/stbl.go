@@ -14,8 +14,10 @@ type StblBox struct {
 	Stsd  *StsdBox
 	Stts  *SttsBox
 	Ctts  *CttsBox
+	Cslg  *CslgBox
 	Stsc  *StscBox
 	Stsz  *StszBox
+	Stz2  *Stz2Box
 	Stss  *StssBox
 	Stco  *StcoBox
 	Co64  *Co64Box
@@ -46,10 +48,14 @@ func (s *StblBox) AddChild(box Box) {
 		s.Stts = box.(*SttsBox)
 	case "ctts":
 		s.Ctts = box.(*CttsBox)
+	case "cslg":
+		s.Cslg = box.(*CslgBox)
 	case "stsc":
 		s.Stsc = box.(*StscBox)
 	case "stsz":
 		s.Stsz = box.(*StszBox)
+	case "stz2":
+		s.Stz2 = box.(*Stz2Box)
 	case "stss":
 		s.Stss = box.(*StssBox)
 	case "stco":
@@ -104,6 +110,15 @@ func (s *StblBox) GetChildren() []Box {
 	return s.Children
 }
 
+func (s *StblBox) setChildren(children []Box) error {
+	n := StblBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*s = n
+	return nil
+}
+
 // Encode - write stbl container to w
 func (s *StblBox) Encode(w io.Writer) error {
 	return EncodeContainer(s, w)
@@ -113,3 +128,40 @@ func (s *StblBox) Encode(w io.Writer) error {
 func (s *StblBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
 	return ContainerInfo(s, w, specificBoxLevels, indent, indentStep)
 }
+
+// SampleSizeTable - sample sizes given by stsz or stz2
+type SampleSizeTable interface {
+	GetNrSamples() uint32
+	GetSampleSize(i int) uint32
+	GetTotalSampleSize(startNr, endNr uint32) (uint64, error)
+}
+
+// SampleSizes - sample size table from stsz, or from stz2 if there is no stsz. nil if there is none
+func (s *StblBox) SampleSizes() SampleSizeTable {
+	switch {
+	case s.Stsz != nil:
+		return s.Stsz
+	case s.Stz2 != nil:
+		return s.Stz2
+	default:
+		return nil
+	}
+}
+
+// ConvertStcoToCo64 - replace stco by a co64 box with the same chunk offsets, so that offsets beyond 32 bits can be stored
+func (s *StblBox) ConvertStcoToCo64() {
+	if s.Stco == nil {
+		return
+	}
+	co64 := &Co64Box{ChunkOffset: make([]uint64, len(s.Stco.ChunkOffset))}
+	for i, offset := range s.Stco.ChunkOffset {
+		co64.ChunkOffset[i] = uint64(offset)
+	}
+	for i, c := range s.Children {
+		if c == s.Stco {
+			s.Children[i] = co64
+		}
+	}
+	s.Stco = nil
+	s.Co64 = co64
+}
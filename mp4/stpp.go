@@ -5,16 +5,26 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"strings"
+)
+
+// TTML namespace and IMSC1 codecs strings for stpp tracks
+const (
+	TTMLNamespace    = "http://www.w3.org/ns/ttml"
+	IMSC1TextCodecs  = "stpp.ttml.im1t"
+	IMSC1ImageCodecs = "stpp.ttml.im1i"
 )
 
 // StppBox - XMLSubtitleSampleEntryr Box (stpp)
 //
 // Contained in : Media Information Box (minf)
 type StppBox struct {
+	name               string   // stpp or enct
 	Namespace          string   // Mandatory
 	SchemaLocation     string   // Optional
 	AuxiliaryMimeTypes string   // Required if auxiliary types present
 	Btrt               *BtrtBox // Optional
+	Sinf               *SinfBox // Present for encrypted (enct) entries
 	Children           []Box
 	DataReferenceIndex uint16
 }
@@ -36,6 +46,8 @@ func (b *StppBox) AddChild(child Box) {
 	switch box := child.(type) {
 	case *BtrtBox:
 		b.Btrt = box
+	case *SinfBox:
+		b.Sinf = box
 	default:
 		// Other box
 	}
@@ -49,7 +61,7 @@ func DecodeStpp(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	if err != nil {
 		return nil, err
 	}
-	b := &StppBox{}
+	b := &StppBox{name: hdr.name}
 	s := NewSliceReader(data)
 
 	// 14496-12 8.5.2.2 Sample entry (8 bytes)
@@ -99,19 +111,41 @@ func DecodeStpp(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	return b, nil
 }
 
+// IsIMSC1 - true if namespace is TTML, which is required for IMSC1 tracks.
+// The profile is signaled in the samples and in the codecs string (IMSC1TextCodecs or IMSC1ImageCodecs).
+func (b *StppBox) IsIMSC1() bool {
+	for _, ns := range strings.Fields(b.Namespace) {
+		if ns == TTMLNamespace {
+			return true
+		}
+	}
+	return false
+}
+
 // Type - return box type
 func (b *StppBox) Type() string {
-	return "stpp"
+	if b.name == "" {
+		return "stpp"
+	}
+	return b.name
+}
+
+// OriginalFormat - data format given by frma for encrypted (enct) entries, otherwise type
+func (b *StppBox) OriginalFormat() string {
+	if b.Sinf != nil && b.Sinf.Frma != nil {
+		return b.Sinf.Frma.DataFormat
+	}
+	return b.Type()
 }
 
 // Size - return calculated size
 func (b *StppBox) Size() uint64 {
 	nrSampleEntryBytes := 8
 	totalSize := uint64(boxHeaderSize + nrSampleEntryBytes + len(b.Namespace) + 1)
-	if b.SchemaLocation != "" {
+	if b.SchemaLocation != "" || b.AuxiliaryMimeTypes != "" || len(b.Children) > 0 {
 		totalSize += uint64(len(b.SchemaLocation)) + 1
 	}
-	if b.AuxiliaryMimeTypes != "" {
+	if b.AuxiliaryMimeTypes != "" || len(b.Children) > 0 {
 		totalSize += uint64(len(b.AuxiliaryMimeTypes)) + 1
 	}
 	for _, child := range b.Children {
@@ -120,6 +154,21 @@ func (b *StppBox) Size() uint64 {
 	return totalSize
 }
 
+// GetChildren - list of child boxes
+func (b *StppBox) GetChildren() []Box {
+	return b.Children
+}
+
+func (b *StppBox) setChildren(children []Box) error {
+	n := StppBox{name: b.name, Namespace: b.Namespace, SchemaLocation: b.SchemaLocation,
+		AuxiliaryMimeTypes: b.AuxiliaryMimeTypes, DataReferenceIndex: b.DataReferenceIndex}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - write box to w
 func (b *StppBox) Encode(w io.Writer) error {
 	err := EncodeHeader(b, w)
@@ -131,10 +180,11 @@ func (b *StppBox) Encode(w io.Writer) error {
 	sw.WriteZeroBytes(6)
 	sw.WriteUint16(b.DataReferenceIndex)
 	sw.WriteString(b.Namespace, true)
-	if b.SchemaLocation != "" {
+	// Empty optional strings must be written if later fields follow, so that the strings can be parsed
+	if b.SchemaLocation != "" || b.AuxiliaryMimeTypes != "" || len(b.Children) > 0 {
 		sw.WriteString(b.SchemaLocation, true)
 	}
-	if b.AuxiliaryMimeTypes != "" {
+	if b.AuxiliaryMimeTypes != "" || len(b.Children) > 0 {
 		sw.WriteString(b.AuxiliaryMimeTypes, true)
 	}
 	_, err = w.Write(buf[:sw.pos]) // Only write written bytes
@@ -7,7 +7,7 @@ import (
 func TestStpp(t *testing.T) {
 
 	stpp := NewStppBox("The namespace", "schema location", "image/png,image/jpg")
-	btrt := &BtrtBox{} //Note, we only handle btrt box when both optional fields are present
+	btrt := &BtrtBox{}
 	stpp.AddChild(btrt)
 	if stpp.Btrt != btrt {
 		t.Error("Btrt link is broken")
@@ -16,4 +16,22 @@ func TestStpp(t *testing.T) {
 
 	stppWithoutOptionalFields := NewStppBox("The namespace", "", "")
 	boxDiffAfterEncodeAndDecode(t, stppWithoutOptionalFields)
+
+	stppWithoutOptionalFields.AddChild(&BtrtBox{})
+	boxDiffAfterEncodeAndDecode(t, stppWithoutOptionalFields)
+}
+
+func TestIMSC1Init(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(1000, "stpp", "en")
+	assertNoError(t, init.Moov.Trak.SetIMSC1Descriptor(true))
+	decInit := boxAfterEncodeAndDecode(t, init.Moov).(*MoovBox)
+	mdia := decInit.Trak.Mdia
+	if mdia.Hdlr.HandlerType != "subt" || mdia.Minf.Children[0].Type() != "sthd" {
+		t.Errorf("got handler %q instead of subt with sthd", mdia.Hdlr.HandlerType)
+	}
+	stpp := mdia.Minf.Stbl.Stsd.Stpp
+	if stpp == nil || !stpp.IsIMSC1() || stpp.AuxiliaryMimeTypes != "image/png" {
+		t.Errorf("no IMSC1 image profile stpp entry")
+	}
 }
@@ -0,0 +1,106 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+)
+
+// countingWriter - writer that counts the bytes written to w
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// writeTo - encode boxStructure to w and return the number of bytes written
+func writeTo(boxStructure BoxStructure, w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := boxStructure.Encode(cw)
+	return cw.n, err
+}
+
+// WriteTo - write f to w as Encode does. Implements io.WriterTo
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	return writeTo(f, w)
+}
+
+// WriteTo - write s to w as Encode does. Implements io.WriterTo
+func (s *InitSegment) WriteTo(w io.Writer) (int64, error) {
+	return writeTo(s, w)
+}
+
+// WriteTo - write s to w as Encode does. Implements io.WriterTo
+func (s *MediaSegment) WriteTo(w io.Writer) (int64, error) {
+	return writeTo(s, w)
+}
+
+// WriteTo - write f to w as Encode does. Implements io.WriterTo
+func (f *Fragment) WriteTo(w io.Writer) (int64, error) {
+	return writeTo(f, w)
+}
+
+// ReadFrom - decode a file from r into f, replacing its content. The decode and encode settings
+// of f, e.g. from NewFile and options, are kept. Lazy decode mode requires that r is an io.ReadSeeker.
+// The returned count is the total size of the decoded top-level boxes. Implements io.ReaderFrom
+func (f *File) ReadFrom(r io.Reader) (int64, error) {
+	settings := f.settings()
+	decoded, err := DecodeFile(r, func(d *File) { *d = settings })
+	if err != nil {
+		return 0, err
+	}
+	*f = *decoded
+	var n int64
+	for _, c := range f.Children {
+		n += int64(c.Size())
+	}
+	return n, nil
+}
+
+// settings - copy of f with all decode and encode settings, but without boxes and decoding state
+func (f *File) settings() File {
+	s := *f
+	s.Ftyp = nil
+	s.Moov = nil
+	s.Meta = nil
+	s.Mdat = nil
+	s.Init = nil
+	s.Sidx = nil
+	s.Sidxs = nil
+	s.Ssix = nil
+	s.Segments = nil
+	s.Mfra = nil
+	s.Children = nil
+	s.Warnings = nil
+	s.isFragmented = false
+	s.fragPrefix = nil
+	return s
+}
+
+// ReadFrom - decode an init segment from r into s, replacing its content. Implements io.ReaderFrom
+func (s *InitSegment) ReadFrom(r io.Reader) (int64, error) {
+	f, err := DecodeFile(r)
+	if err != nil {
+		return 0, err
+	}
+	if f.Init == nil {
+		return 0, fmt.Errorf("no init segment")
+	}
+	*s = *f.Init
+	return int64(s.Size()), nil
+}
+
+// ReadFrom - decode a media segment from r into s as DecodeMediaSegment, replacing its content.
+// Implements io.ReaderFrom
+func (s *MediaSegment) ReadFrom(r io.Reader) (int64, error) {
+	seg, err := DecodeMediaSegment(r)
+	if err != nil {
+		return 0, err
+	}
+	*s = *seg
+	return int64(s.Size()), nil
+}
@@ -0,0 +1,99 @@
+package mp4
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestWriterToAndReaderFrom(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	seg := NewMediaSegment()
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	for _, s := range createProgTestSamples(4, 3600, 50, 4, 0) {
+		frag.AddFullSample(s)
+	}
+	seg.AddFragment(frag)
+
+	for _, bs := range []interface {
+		BoxStructure
+		io.WriterTo
+	}{init, seg, frag} {
+		encBuf := bytes.Buffer{}
+		assertNoError(t, bs.Encode(&encBuf))
+		buf := bytes.Buffer{}
+		n, err := bs.WriteTo(&buf)
+		assertNoError(t, err)
+		if n != int64(encBuf.Len()) || !bytes.Equal(buf.Bytes(), encBuf.Bytes()) {
+			t.Errorf("%T: WriteTo wrote %d bytes differing from Encode", bs, n)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	_, err = init.WriteTo(&buf)
+	assertNoError(t, err)
+	_, err = seg.WriteTo(&buf)
+	assertNoError(t, err)
+	total := int64(buf.Len())
+
+	f := NewFile()
+	WithTrackIDs(1)(f)
+	n, err := f.ReadFrom(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	if n != total || !f.IsFragmented() || len(f.Segments) != 1 || len(f.decTrackIDs) != 1 {
+		t.Errorf("File.ReadFrom: got %d bytes and %d segments", n, len(f.Segments))
+	}
+
+	decInit := &InitSegment{}
+	n, err = decInit.ReadFrom(bytes.NewReader(buf.Bytes()[:init.Size()]))
+	assertNoError(t, err)
+	if n != int64(init.Size()) || decInit.Moov == nil {
+		t.Errorf("InitSegment.ReadFrom: got %d bytes instead of %d", n, init.Size())
+	}
+
+	decSeg := &MediaSegment{}
+	n, err = decSeg.ReadFrom(bytes.NewReader(buf.Bytes()[init.Size():]))
+	assertNoError(t, err)
+	if n != int64(seg.Size()) || len(decSeg.Fragments) != 1 {
+		t.Errorf("MediaSegment.ReadFrom: got %d bytes instead of %d", n, seg.Size())
+	}
+}
+
+func TestReadFromKeepsOptions(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	for _, s := range createProgTestSamples(4, 3600, 50, 4, 0) {
+		frag.AddFullSample(s)
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	assertNoError(t, frag.Encode(&buf))
+	data := buf.Bytes()
+
+	f := NewFile()
+	WithMaxDecodeSize(uint64(len(data) - 1))(f)
+	_, err = f.ReadFrom(bytes.NewReader(data))
+	var sizeErr *DecodeSizeError
+	if !errors.As(err, &sizeErr) {
+		t.Errorf("WithMaxDecodeSize not applied by ReadFrom: got error %v", err)
+	}
+
+	f = NewFile()
+	WithRedaction(RedactTruncate)(f)
+	f.Warnings = []Warning{{Msg: "stale"}}
+	_, err = f.ReadFrom(bytes.NewReader(data))
+	assertNoError(t, err)
+	if f.Redact != RedactTruncate || len(f.Warnings) != 0 {
+		t.Errorf("ReadFrom did not keep settings or kept old content")
+	}
+	out := bytes.Buffer{}
+	assertNoError(t, f.Encode(&out))
+	if out.Len() >= len(data) {
+		t.Errorf("WithRedaction not applied by ReadFrom: got %d bytes, input %d bytes", out.Len(), len(data))
+	}
+}
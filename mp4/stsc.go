@@ -52,7 +52,7 @@ func DecodeStsc(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 			if sdi != b.singleSampleDescriptionID {
 				if b.singleSampleDescriptionID != 0 {
 					for j := 0; j < i; j++ {
-						b.SampleDescriptionID = append(b.SampleDescriptionID, sdi)
+						b.SampleDescriptionID = append(b.SampleDescriptionID, b.singleSampleDescriptionID)
 					}
 					b.singleSampleDescriptionID = 0
 				}
@@ -118,6 +118,24 @@ func (b *StscBox) GetSampleDescriptionID(sampleNr int) uint32 {
 	return b.SampleDescriptionID[sampleNr-1]
 }
 
+// GetSampleDescriptionIDForChunk - get the sample description ID for chunkNr (one-based)
+func (b *StscBox) GetSampleDescriptionIDForChunk(chunkNr uint32) uint32 {
+	if b.singleSampleDescriptionID != 0 {
+		return b.singleSampleDescriptionID
+	}
+	entryNr := 0
+	for i := range b.FirstChunk {
+		if b.FirstChunk[i] > chunkNr {
+			break
+		}
+		entryNr = i
+	}
+	if entryNr >= len(b.SampleDescriptionID) {
+		return 0
+	}
+	return b.SampleDescriptionID[entryNr]
+}
+
 // SetSingleSampleDescriptionID - use this for efficiency if all samples have same sample description
 func (b *StscBox) SetSingleSampleDescriptionID(sampleDescriptionID uint32) {
 	b.singleSampleDescriptionID = sampleDescriptionID
@@ -78,6 +78,15 @@ func TestStsc(t *testing.T) {
 		stsc.SetSingleSampleDescriptionID(1)
 		boxDiffAfterEncodeAndDecode(t, stsc)
 	})
+
+	t.Run("encode and decode with changing sample description", func(t *testing.T) {
+		stsc := &StscBox{
+			FirstChunk:          []uint32{1, 3, 5},
+			SamplesPerChunk:     []uint32{256, 1000, 1000},
+			SampleDescriptionID: []uint32{1, 1, 2},
+		}
+		boxDiffAfterEncodeAndDecode(t, stsc)
+	})
 }
 
 func TestStscContainingChunks(t *testing.T) {
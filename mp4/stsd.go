@@ -1,6 +1,7 @@
 package mp4
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -18,6 +19,7 @@ type StsdBox struct {
 	HvcX        *VisualSampleEntryBox
 	Mp4a        *AudioSampleEntryBox
 	Wvtt        *WvttBox
+	Stpp        *StppBox
 	Children    []Box
 }
 
@@ -37,6 +39,8 @@ func (s *StsdBox) AddChild(box Box) {
 		s.Mp4a = box.(*AudioSampleEntryBox)
 	case "wvtt":
 		s.Wvtt = box.(*WvttBox)
+	case "stpp":
+		s.Stpp = box.(*StppBox)
 	}
 	s.Children = append(s.Children, box)
 	s.SampleCount++
@@ -74,6 +78,115 @@ func (s *StsdBox) GetSampleDescription(index int) (Box, error) {
 	return s.Children[index], nil
 }
 
+// GetDataReferenceIndex - get data reference index of sample entry with one-based sampleDescriptionID
+func (s *StsdBox) GetDataReferenceIndex(sampleDescriptionID uint32) (uint16, error) {
+	if sampleDescriptionID == 0 || int(sampleDescriptionID) > len(s.Children) {
+		return 0, fmt.Errorf("sampleDescriptionID %d outside range 1-%d", sampleDescriptionID, len(s.Children))
+	}
+	switch e := s.Children[sampleDescriptionID-1].(type) {
+	case *VisualSampleEntryBox:
+		return e.DataReferenceIndex, nil
+	case *AudioSampleEntryBox:
+		return e.DataReferenceIndex, nil
+	case *WvttBox:
+		return e.DataReferenceIndex, nil
+	case *StppBox:
+		return e.DataReferenceIndex, nil
+	case *Tx3gBox:
+		return e.DataReferenceIndex, nil
+	default:
+		return 1, nil // Unknown sample entry types are assumed to refer to first entry
+	}
+}
+
+// GetAVC - get first AVC sample entry (avc1/avc3), or encrypted encv entry with AVC original format.
+// Returns nil if not found.
+func (s *StsdBox) GetAVC() *VisualSampleEntryBox {
+	return s.getVisualSampleEntry("avc1", "avc3")
+}
+
+// GetHEVC - get first HEVC sample entry (hvc1/hev1), or encrypted encv entry with HEVC original format.
+// Returns nil if not found.
+func (s *StsdBox) GetHEVC() *VisualSampleEntryBox {
+	return s.getVisualSampleEntry("hvc1", "hev1")
+}
+
+// GetAV1 - get first AV1 sample entry (av01), or encrypted encv entry with AV1 original format.
+// Returns nil if not found.
+func (s *StsdBox) GetAV1() *VisualSampleEntryBox {
+	return s.getVisualSampleEntry("av01")
+}
+
+// GetDolbyVision - get first sample entry with Dolby Vision configuration (dvcC or dvvC).
+// This includes dvh1/dvhe/dva1/dvav entries as well as hvc1/avc1 entries with backwards-compatible base layer.
+// Returns nil if not found.
+func (s *StsdBox) GetDolbyVision() *VisualSampleEntryBox {
+	for _, c := range s.Children {
+		if v, ok := c.(*VisualSampleEntryBox); ok && v.DvcC != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// GetVP9 - get first VP9 sample entry (vp09), or encrypted encv entry with VP9 original format.
+// Returns nil if not found.
+func (s *StsdBox) GetVP9() *VisualSampleEntryBox {
+	return s.getVisualSampleEntry("vp09")
+}
+
+// GetAAC - get first mp4a sample entry, or encrypted enca entry with mp4a original format.
+// Returns nil if not found.
+func (s *StsdBox) GetAAC() *AudioSampleEntryBox {
+	for _, c := range s.Children {
+		if a, ok := c.(*AudioSampleEntryBox); ok && a.OriginalFormat() == "mp4a" {
+			return a
+		}
+	}
+	return nil
+}
+
+// GetEncrypted - get first protected sample entry (encv, enca, enct, encs or other registered type)
+// and its original format from frma. Returns nil and empty string if not found.
+func (s *StsdBox) GetEncrypted() (entry Box, originalFormat string) {
+	for _, c := range s.Children {
+		if !IsProtectedSampleEntry(c.Type()) {
+			continue
+		}
+		switch e := c.(type) {
+		case *VisualSampleEntryBox:
+			return e, e.OriginalFormat()
+		case *AudioSampleEntryBox:
+			return e, e.OriginalFormat()
+		case *WvttBox:
+			return e, e.OriginalFormat()
+		case *StppBox:
+			return e, e.OriginalFormat()
+		case *UnknownBox:
+			if start, _ := findSinf(e.notDecoded); start >= 0 {
+				return e, string(e.notDecoded[start+16 : start+20])
+			}
+		}
+	}
+	return nil, ""
+}
+
+func (s *StsdBox) getVisualSampleEntry(formats ...string) *VisualSampleEntryBox {
+	for _, c := range s.Children {
+		v, ok := c.(*VisualSampleEntryBox)
+		if !ok {
+			continue
+		}
+		origFormat := v.OriginalFormat()
+		for _, format := range formats {
+			if origFormat == format {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
 // DecodeStsd - box-specific decode
 func DecodeStsd(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	var versionAndFlags, sampleCount uint32
@@ -156,3 +269,20 @@ func (s *StsdBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	}
 	return err
 }
+
+// copyEntries - add copies of all sample entries in src made by encoding and decoding them
+func (s *StsdBox) copyEntries(src *StsdBox) error {
+	for _, entry := range src.Children {
+		buf := bytes.Buffer{}
+		err := entry.Encode(&buf)
+		if err != nil {
+			return err
+		}
+		entryCopy, err := DecodeBox(0, &buf)
+		if err != nil {
+			return err
+		}
+		s.AddChild(entryCopy)
+	}
+	return nil
+}
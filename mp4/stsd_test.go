@@ -0,0 +1,40 @@
+package mp4
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStsdGetters(t *testing.T) {
+	fd, err := os.Open("testdata/init_cenc.cmfv")
+	if err != nil {
+		t.Error(err)
+	}
+	defer fd.Close()
+	f, err := DecodeFile(fd)
+	if err != nil {
+		t.Error(err)
+	}
+	stsd := f.Moov.Trak.Mdia.Minf.Stbl.Stsd
+	avcx := stsd.GetAVC()
+	if avcx == nil || avcx.Type() != "encv" || avcx.AvcC == nil {
+		t.Errorf("did not get encv entry with avcC")
+	}
+	entry, origFormat := stsd.GetEncrypted()
+	if entry != avcx || origFormat != "avc3" {
+		t.Errorf("got %v %s instead of encv avc3", entry, origFormat)
+	}
+	if stsd.GetHEVC() != nil || stsd.GetAAC() != nil {
+		t.Errorf("got unexpected HEVC or AAC entry")
+	}
+
+	audioStsd := NewStsdBox()
+	mp4a := CreateAudioSampleEntryBox("mp4a", 2, 16, 48000, nil)
+	audioStsd.AddChild(mp4a)
+	if audioStsd.GetAAC() != mp4a {
+		t.Errorf("did not get mp4a entry")
+	}
+	if entry, _ := audioStsd.GetEncrypted(); entry != nil {
+		t.Errorf("got encrypted entry for clear mp4a")
+	}
+}
@@ -11,16 +11,27 @@ import (
 //
 // Contained in : Sample Table box (stbl)
 //
-// For each track, either stsz of the more compact stz2 must be present. stz2 variant is not supported.
+// For each track, either stsz or the more compact stz2 (Stz2Box) must be present.
 //
 // This table lists the size of each sample. If all samples have the same size, it can be defined in the
-// SampleUniformSize attribute.
+// SampleUniformSize attribute. A table with equal sizes is encoded as a uniform size without table.
+// The equal-size check is cached for the current SampleSize slice, so entries should be changed
+// by appending or by assigning a new slice rather than by modifying them in place.
 type StszBox struct {
 	Version           byte
 	Flags             uint32
 	SampleUniformSize uint32
 	SampleNumber      uint32
 	SampleSize        []uint32
+	uniform           uniformSizeCache
+}
+
+// uniformSizeCache - result of the equal-size check for a SampleSize slice
+type uniformSizeCache struct {
+	first *uint32
+	len   int
+	size  uint32
+	ok    bool
 }
 
 // DecodeStsz - box-specific decode
@@ -29,6 +40,9 @@ func DecodeStsz(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(data) < 12 {
+		return nil, fmt.Errorf("stsz: payload size %d less than 12", len(data))
+	}
 	versionAndFlags := binary.BigEndian.Uint32(data[0:4])
 
 	b := &StszBox{
@@ -38,7 +52,11 @@ func DecodeStsz(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 		SampleNumber:      binary.BigEndian.Uint32(data[8:12]),
 		SampleSize:        []uint32{},
 	}
-	if len(data) > 12 {
+	if b.SampleUniformSize == 0 {
+		if uint64(len(data)) < 12+4*uint64(b.SampleNumber) {
+			return nil, fmt.Errorf("stsz: payload size %d too small for %d sample sizes", len(data), b.SampleNumber)
+		}
+		b.SampleSize = make([]uint32, 0, b.SampleNumber)
 		for i := 0; i < int(b.SampleNumber); i++ {
 			sz := binary.BigEndian.Uint32(data[(12 + 4*i):(16 + 4*i)])
 			b.SampleSize = append(b.SampleSize, sz)
@@ -54,9 +72,35 @@ func (b *StszBox) Type() string {
 
 // Size - box-specific size
 func (b *StszBox) Size() uint64 {
+	if _, ok := b.uniformSize(); ok {
+		return uint64(boxHeaderSize + 12)
+	}
 	return uint64(boxHeaderSize + 12 + len(b.SampleSize)*4)
 }
 
+// uniformSize - return common size if all samples have the same non-zero size
+func (b *StszBox) uniformSize() (uint32, bool) {
+	if b.SampleUniformSize != 0 || len(b.SampleSize) == 0 {
+		return b.SampleUniformSize, b.SampleUniformSize != 0
+	}
+	c := &b.uniform
+	if c.first == &b.SampleSize[0] && c.len == len(b.SampleSize) {
+		return c.size, c.ok
+	}
+	c.first, c.len = &b.SampleSize[0], len(b.SampleSize)
+	c.size, c.ok = b.SampleSize[0], b.SampleSize[0] != 0
+	for _, s := range b.SampleSize[1:] {
+		if s != c.size {
+			c.ok = false
+			break
+		}
+	}
+	if !c.ok {
+		c.size = 0
+	}
+	return c.size, c.ok
+}
+
 // Encode - write box to w
 func (b *StszBox) Encode(w io.Writer) error {
 	err := EncodeHeader(b, w)
@@ -67,10 +111,14 @@ func (b *StszBox) Encode(w io.Writer) error {
 	sw := NewSliceWriter(buf)
 	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
 	sw.WriteUint32(versionAndFlags)
-	sw.WriteUint32(b.SampleUniformSize)
-	if len(b.SampleSize) == 0 {
+	if size, ok := b.uniformSize(); ok {
+		sw.WriteUint32(size)
+		sw.WriteUint32(b.GetNrSamples())
+	} else if len(b.SampleSize) == 0 {
+		sw.WriteUint32(0)
 		sw.WriteUint32(b.SampleNumber)
 	} else {
+		sw.WriteUint32(0)
 		sw.WriteUint32(uint32(len(b.SampleSize)))
 		for i := range b.SampleSize {
 			sw.WriteUint32(b.SampleSize[i])
@@ -111,7 +159,7 @@ func (b *StszBox) GetNrSamples() uint32 {
 
 // GetSampleSize returns the size (in bytes) of a sample
 func (b *StszBox) GetSampleSize(i int) uint32 {
-	if i > len(b.SampleSize) { // One-based
+	if b.SampleUniformSize != 0 || i > len(b.SampleSize) { // One-based
 		return b.SampleUniformSize
 	}
 	return b.SampleSize[i-1]
@@ -119,8 +167,9 @@ func (b *StszBox) GetSampleSize(i int) uint32 {
 
 // GetTotalSampleSize - get total size of a range [startNr, endNr] of samples
 func (b *StszBox) GetTotalSampleSize(startNr, endNr uint32) (uint64, error) {
-	if startNr <= 0 || endNr > b.SampleNumber {
-		return 0, fmt.Errorf("startNr or calculated endNr outside range 1-%d", b.SampleNumber)
+	nrSamples := b.GetNrSamples()
+	if startNr <= 0 || endNr > nrSamples {
+		return 0, fmt.Errorf("startNr or calculated endNr outside range 1-%d", nrSamples)
 	}
 	if endNr < startNr {
 		return 0, nil
@@ -1,6 +1,9 @@
 package mp4
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+)
 
 func TestStszEncDec(t *testing.T) {
 	stsz := StszBox{
@@ -18,6 +21,51 @@ func TestStszEncDec(t *testing.T) {
 	boxDiffAfterEncodeAndDecode(t, &stsz)
 }
 
+func TestStszUniformSizeEncode(t *testing.T) {
+	stsz := &StszBox{
+		SampleNumber: 4,
+		SampleSize:   []uint32{384, 384, 384, 384},
+	}
+	if stsz.Size() != 20 {
+		t.Errorf("got size %d instead of 20 for uniform sample sizes", stsz.Size())
+	}
+	decBox := boxAfterEncodeAndDecode(t, stsz)
+	dec := decBox.(*StszBox)
+	if dec.SampleUniformSize != 384 || dec.GetNrSamples() != 4 || len(dec.SampleSize) != 0 {
+		t.Errorf("got uniformSize=%d nrSamples=%d tableLen=%d instead of 384, 4, 0",
+			dec.SampleUniformSize, dec.GetNrSamples(), len(dec.SampleSize))
+	}
+	for nr := 1; nr <= 4; nr++ {
+		if dec.GetSampleSize(nr) != 384 {
+			t.Errorf("sample %d: got size %d instead of 384", nr, dec.GetSampleSize(nr))
+		}
+	}
+	totSize, err := dec.GetTotalSampleSize(2, 4)
+	assertNoError(t, err)
+	if totSize != 3*384 {
+		t.Errorf("got total size %d instead of %d", totSize, 3*384)
+	}
+
+	zeroSizes := &StszBox{SampleNumber: 2, SampleSize: []uint32{0, 0}}
+	boxDiffAfterEncodeAndDecode(t, zeroSizes)
+}
+
+func TestStszUniformSizeCache(t *testing.T) {
+	stsz := &StszBox{SampleSize: []uint32{100, 100}, SampleNumber: 2}
+	if stsz.Size() != boxHeaderSize+12 {
+		t.Errorf("got size %d for equal sizes", stsz.Size())
+	}
+	stsz.SampleSize = append(stsz.SampleSize, 101)
+	stsz.SampleNumber = 3
+	if stsz.Size() != boxHeaderSize+12+3*4 {
+		t.Errorf("got size %d after appending a different size", stsz.Size())
+	}
+	stsz.SampleSize = []uint32{101, 101, 101}
+	if stsz.Size() != boxHeaderSize+12 {
+		t.Errorf("got size %d after assigning equal sizes", stsz.Size())
+	}
+}
+
 func TestStszGetTotalSize(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -59,3 +107,11 @@ func TestStszGetTotalSize(t *testing.T) {
 		}
 	}
 }
+
+func TestStszDecodeTruncated(t *testing.T) {
+	data := []byte{0, 0, 0, 24, 's', 't', 's', 'z', 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 1}
+	_, err := DecodeBox(0, bytes.NewReader(data))
+	assertError(t, err, "stsz with more samples than sizes should fail")
+	_, err = DecodeBox(0, bytes.NewReader([]byte{0, 0, 0, 12, 's', 't', 's', 'z', 0, 0, 0, 0}))
+	assertError(t, err, "stsz with short payload should fail")
+}
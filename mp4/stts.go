@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"time"
 )
 
@@ -119,6 +120,59 @@ func (b *SttsBox) GetDur(sampleNr uint32) (dur uint32) {
 	return dur
 }
 
+// AddSampleDur - add duration of next sample. The last entry is extended if the duration is the same.
+func (b *SttsBox) AddSampleDur(dur uint32) {
+	n := len(b.SampleCount)
+	if n > 0 && b.SampleTimeDelta[n-1] == dur {
+		b.SampleCount[n-1]++
+		return
+	}
+	b.SampleCount = append(b.SampleCount, 1)
+	b.SampleTimeDelta = append(b.SampleTimeDelta, dur)
+}
+
+// GetNrSamples - get total number of samples
+func (b *SttsBox) GetNrSamples() uint32 {
+	var nrSamples uint32
+	for _, c := range b.SampleCount {
+		nrSamples += c
+	}
+	return nrSamples
+}
+
+// GetTotalDur - get sum of all sample durations in track timescale
+func (b *SttsBox) GetTotalDur() uint64 {
+	var totDur uint64
+	for i := range b.SampleCount {
+		totDur += uint64(b.SampleCount[i]) * uint64(b.SampleTimeDelta[i])
+	}
+	return totDur
+}
+
+// Optimize - merge consecutive entries with the same duration and drop empty entries.
+// An error is returned if the SampleCount and SampleTimeDelta slices have different lengths.
+func (b *SttsBox) Optimize() error {
+	if len(b.SampleCount) != len(b.SampleTimeDelta) {
+		return fmt.Errorf("stts: %d sample counts but %d sample deltas", len(b.SampleCount), len(b.SampleTimeDelta))
+	}
+	counts := make([]uint32, 0, len(b.SampleCount))
+	deltas := make([]uint32, 0, len(b.SampleCount))
+	for i := range b.SampleCount {
+		if b.SampleCount[i] == 0 {
+			continue
+		}
+		n := len(counts)
+		if n > 0 && deltas[n-1] == b.SampleTimeDelta[i] && counts[n-1] <= math.MaxUint32-b.SampleCount[i] {
+			counts[n-1] += b.SampleCount[i]
+			continue
+		}
+		counts = append(counts, b.SampleCount[i])
+		deltas = append(deltas, b.SampleTimeDelta[i])
+	}
+	b.SampleCount, b.SampleTimeDelta = counts, deltas
+	return nil
+}
+
 // Encode - write box to w
 func (b *SttsBox) Encode(w io.Writer) error {
 	err := EncodeHeader(b, w)
@@ -1,6 +1,11 @@
 package mp4
 
-import "testing"
+import (
+	"math"
+	"testing"
+
+	"github.com/go-test/deep"
+)
 
 func TestSttsEncDec(t *testing.T) {
 	stts := SttsBox{
@@ -78,3 +83,46 @@ func TestGetDecodeTime(t *testing.T) {
 		}
 	}
 }
+
+func TestSttsAddSampleDurAndOptimize(t *testing.T) {
+	stts := &SttsBox{}
+	durs := []uint32{1024, 1024, 1024, 1025, 1024, 1024}
+	for _, dur := range durs {
+		stts.AddSampleDur(dur)
+	}
+	if len(stts.SampleCount) != 3 {
+		t.Errorf("got %d entries instead of 3", len(stts.SampleCount))
+	}
+	if stts.GetNrSamples() != 6 || stts.GetTotalDur() != 5*1024+1025 {
+		t.Errorf("got %d samples and dur %d", stts.GetNrSamples(), stts.GetTotalDur())
+	}
+	for i, dur := range durs {
+		if stts.GetDur(uint32(i+1)) != dur {
+			t.Errorf("sample %d: got dur %d instead of %d", i+1, stts.GetDur(uint32(i+1)), dur)
+		}
+	}
+
+	perSample := &SttsBox{
+		SampleCount:     []uint32{1, 1, 0, 1, 2, 1},
+		SampleTimeDelta: []uint32{10, 10, 12, 10, 14, 14},
+	}
+	assertNoError(t, perSample.Optimize())
+	wanted := &SttsBox{
+		SampleCount:     []uint32{3, 3},
+		SampleTimeDelta: []uint32{10, 14},
+	}
+	if diff := deep.Equal(perSample, wanted); diff != nil {
+		t.Error(diff)
+	}
+
+	large := &SttsBox{
+		SampleCount:     []uint32{math.MaxUint32, 2},
+		SampleTimeDelta: []uint32{10, 10},
+	}
+	assertNoError(t, large.Optimize())
+	if len(large.SampleCount) != 2 {
+		t.Errorf("entries merged although sample count overflows")
+	}
+	bad := &SttsBox{SampleCount: []uint32{1, 2}, SampleTimeDelta: []uint32{10}}
+	assertError(t, bad.Optimize(), "different slice lengths should give error")
+}
@@ -0,0 +1,167 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Stz2Box - Compact Sample Size Box (stz2)
+//
+// Contained in : Sample Table box (stbl)
+//
+// Alternative to StszBox with 4, 8, or 16-bit sample sizes.
+type Stz2Box struct {
+	Version    byte
+	Flags      uint32
+	FieldSize  byte // Number of bits per sample size: 4, 8, or 16
+	SampleSize []uint32
+}
+
+// CreateStz2 - create stz2 box with the smallest field size that fits all sample sizes
+func CreateStz2(sampleSizes []uint32) (*Stz2Box, error) {
+	var maxSize uint32
+	for _, s := range sampleSizes {
+		if s > maxSize {
+			maxSize = s
+		}
+	}
+	var fieldSize byte
+	switch {
+	case maxSize < 1<<4:
+		fieldSize = 4
+	case maxSize < 1<<8:
+		fieldSize = 8
+	case maxSize < 1<<16:
+		fieldSize = 16
+	default:
+		return nil, fmt.Errorf("sample size %d too big for stz2", maxSize)
+	}
+	return &Stz2Box{FieldSize: fieldSize, SampleSize: sampleSizes}, nil
+}
+
+// DecodeStz2 - box-specific decode
+func DecodeStz2(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 {
+		return nil, fmt.Errorf("stz2: too short: %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
+	b := &Stz2Box{
+		Version:   byte(versionAndFlags >> 24),
+		Flags:     versionAndFlags & flagsMask,
+		FieldSize: byte(s.ReadUint32()), // 24 reserved bits before field size
+	}
+	sampleCount := s.ReadUint32()
+	if b.FieldSize != 4 && b.FieldSize != 8 && b.FieldSize != 16 {
+		return nil, fmt.Errorf("stz2: field size %d not supported", b.FieldSize)
+	}
+	if uint64(sampleCount)*uint64(b.FieldSize) > 8*uint64(s.NrRemainingBytes()) {
+		return nil, fmt.Errorf("stz2: %d samples beyond box", sampleCount)
+	}
+	b.SampleSize = make([]uint32, sampleCount)
+	for i := range b.SampleSize {
+		switch b.FieldSize {
+		case 4:
+			if i%2 == 0 {
+				b.SampleSize[i] = uint32(data[12+i/2] >> 4)
+			} else {
+				b.SampleSize[i] = uint32(data[12+i/2] & 0x0f)
+			}
+		case 8:
+			b.SampleSize[i] = uint32(s.ReadUint8())
+		case 16:
+			b.SampleSize[i] = uint32(s.ReadUint16())
+		}
+	}
+	return b, nil
+}
+
+// Type - box-specific type
+func (b *Stz2Box) Type() string {
+	return "stz2"
+}
+
+// Size - box-specific size
+func (b *Stz2Box) Size() uint64 {
+	return uint64(boxHeaderSize+12) + (uint64(len(b.SampleSize))*uint64(b.FieldSize)+7)/8
+}
+
+// Encode - write box to w
+func (b *Stz2Box) Encode(w io.Writer) error {
+	if b.FieldSize != 4 && b.FieldSize != 8 && b.FieldSize != 16 {
+		return fmt.Errorf("stz2: field size %d not supported", b.FieldSize)
+	}
+	for i, size := range b.SampleSize {
+		if size >= 1<<b.FieldSize {
+			return fmt.Errorf("stz2: sample %d size %d does not fit in %d bits", i+1, size, b.FieldSize)
+		}
+	}
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
+	sw.WriteUint32(versionAndFlags)
+	sw.WriteUint32(uint32(b.FieldSize))
+	sw.WriteUint32(uint32(len(b.SampleSize)))
+	for i, size := range b.SampleSize {
+		switch b.FieldSize {
+		case 4:
+			if i%2 == 0 {
+				buf[12+i/2] = byte(size << 4)
+			} else {
+				buf[12+i/2] |= byte(size)
+			}
+		case 8:
+			sw.WriteUint8(byte(size))
+		case 16:
+			sw.WriteUint16(uint16(size))
+		}
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *Stz2Box) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	bd.write(" - fieldSize: %d", b.FieldSize)
+	bd.write(" - sampleCount: %d", len(b.SampleSize))
+	level := getInfoLevel(b, specificBoxLevels)
+	if level >= 1 {
+		for i := range b.SampleSize {
+			bd.write(" - sample[%d] size=%d", i+1, b.SampleSize[i])
+		}
+	}
+	return bd.err
+}
+
+// GetNrSamples - get number of samples
+func (b *Stz2Box) GetNrSamples() uint32 {
+	return uint32(len(b.SampleSize))
+}
+
+// GetSampleSize returns the size (in bytes) of a sample
+func (b *Stz2Box) GetSampleSize(i int) uint32 {
+	return b.SampleSize[i-1] // One-based
+}
+
+// GetTotalSampleSize - get total size of a range [startNr, endNr] of samples
+func (b *Stz2Box) GetTotalSampleSize(startNr, endNr uint32) (uint64, error) {
+	nrSamples := b.GetNrSamples()
+	if startNr <= 0 || endNr > nrSamples {
+		return 0, fmt.Errorf("startNr or calculated endNr outside range 1-%d", nrSamples)
+	}
+	size := uint64(0)
+	for nr := startNr; nr <= endNr; nr++ {
+		size += uint64(b.SampleSize[nr-1])
+	}
+	return size, nil
+}
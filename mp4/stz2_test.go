@@ -0,0 +1,68 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestStz2EncDec(t *testing.T) {
+	testCases := []struct {
+		sizes           []uint32
+		wantedFieldSize byte
+	}{
+		{[]uint32{1, 15, 0, 7, 3}, 4},
+		{[]uint32{255, 16, 0}, 8},
+		{[]uint32{1000, 65535}, 16},
+	}
+	for _, tc := range testCases {
+		b, err := CreateStz2(tc.sizes)
+		assertNoError(t, err)
+		if b.FieldSize != tc.wantedFieldSize {
+			t.Errorf("got field size %d instead of %d", b.FieldSize, tc.wantedFieldSize)
+		}
+		boxDiffAfterEncodeAndDecode(t, b)
+		total, err := b.GetTotalSampleSize(1, b.GetNrSamples())
+		assertNoError(t, err)
+		var wantedTotal uint64
+		for _, s := range tc.sizes {
+			wantedTotal += uint64(s)
+		}
+		if total != wantedTotal {
+			t.Errorf("got total size %d instead of %d", total, wantedTotal)
+		}
+	}
+	_, err := CreateStz2([]uint32{1 << 16})
+	assertError(t, err, "too big sample size for stz2")
+	b := &Stz2Box{FieldSize: 4, SampleSize: []uint32{16}}
+	assertError(t, b.Encode(&bytes.Buffer{}), "sample size does not fit in field size")
+}
+
+func TestProgressiveFileWithStz2(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(48000, "audio", "und")
+	samples := createProgTestSamples(20, 1024, 10, 1, 0x80)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples},
+		Interleaving{Mode: InterleaveByDuration, Duration: 100 * time.Millisecond})
+	assertNoError(t, err)
+	stbl := f.Moov.Trak.Mdia.Minf.Stbl
+	stz2, err := CreateStz2(stbl.Stsz.SampleSize)
+	assertNoError(t, err)
+	// Replacing stsz makes moov smaller, so mdat is moved by ReplaceChild
+	assertNoError(t, f.ReplaceChild(stbl, stbl.Stsz, stz2))
+	buf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&buf))
+	decFile, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	trak := decFile.Moov.Trak
+	if trak.Mdia.Minf.Stbl.Stz2 == nil || trak.GetNrSamples() != 20 {
+		t.Fatalf("stz2 not decoded with 20 samples")
+	}
+	for nr := uint32(1); nr <= 20; nr++ {
+		data := bytes.Buffer{}
+		assertNoError(t, decFile.CopySampleData(&data, nil, trak, nr, nr))
+		if !bytes.Equal(data.Bytes(), samples[nr-1].Data) {
+			t.Errorf("sample %d: data mismatch", nr)
+		}
+	}
+}
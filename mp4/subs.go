@@ -34,6 +34,9 @@ aligned(8) class SubSampleInformationBox
 */
 
 // SubsBox - SubSampleInformationBox
+//
+// Contained in : Sample Table Box (stbl) or Track Fragment Box (traf).
+// In traf, sample numbers start at 1 for the first sample of the track fragment.
 type SubsBox struct {
 	Version byte
 	Flags   uint32
@@ -111,8 +114,51 @@ func (b *SubsBox) Size() uint64 {
 	return uint64(size)
 }
 
+// GetSubSamples - subsamples of sample sampleNr (1-based). nil if the sample has no subsample information
+func (b *SubsBox) GetSubSamples(sampleNr uint32) []SubsSample {
+	var nr uint32
+	for _, e := range b.Entries {
+		nr += e.SampleDelta
+		if nr == sampleNr {
+			return e.SubSamples
+		}
+		if nr > sampleNr {
+			break
+		}
+	}
+	return nil
+}
+
+// AddSubSamples - add subsample information for sample sampleNr (1-based), which must be higher
+// than that of the previous entry. Version is set to 1 if a subsample size does not fit in 16 bits.
+func (b *SubsBox) AddSubSamples(sampleNr uint32, subSamples []SubsSample) error {
+	var lastNr uint32
+	for _, e := range b.Entries {
+		lastNr += e.SampleDelta
+	}
+	if sampleNr <= lastNr {
+		return fmt.Errorf("sample %d not after last sample %d with subsamples", sampleNr, lastNr)
+	}
+	for _, ss := range subSamples {
+		if ss.SubsampleSize > 0xffff {
+			b.Version = 1
+		}
+	}
+	b.Entries = append(b.Entries, SubsEntry{SampleDelta: sampleNr - lastNr, SubSamples: subSamples})
+	return nil
+}
+
 // Encode - write box to w
 func (b *SubsBox) Encode(w io.Writer) error {
+	if b.Version == 0 {
+		for _, e := range b.Entries {
+			for _, s := range e.SubSamples {
+				if s.SubsampleSize > 0xffff {
+					return fmt.Errorf("subs: subsample size %d does not fit in version 0", s.SubsampleSize)
+				}
+			}
+		}
+	}
 	err := EncodeHeader(b, w)
 	if err != nil {
 		return err
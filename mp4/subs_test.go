@@ -1,6 +1,7 @@
 package mp4
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -23,3 +24,58 @@ func TestSubsInfo(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestSubsVersion1AndLookup(t *testing.T) {
+	subs := &SubsBox{}
+	assertNoError(t, subs.AddSubSamples(2, []SubsSample{{SubsampleSize: 100}, {SubsampleSize: 0x10000}}))
+	assertNoError(t, subs.AddSubSamples(5, []SubsSample{{SubsampleSize: 50, Discardable: 1}}))
+	err := subs.AddSubSamples(5, nil)
+	assertError(t, err, "sample numbers must increase")
+	if subs.Version != 1 {
+		t.Errorf("version not set to 1 for 32-bit subsample size")
+	}
+	boxDiffAfterEncodeAndDecode(t, subs)
+	if ss := subs.GetSubSamples(5); len(ss) != 1 || ss[0].SubsampleSize != 50 {
+		t.Errorf("got subsamples %v for sample 5", ss)
+	}
+	if ss := subs.GetSubSamples(3); ss != nil {
+		t.Errorf("got subsamples %v for sample 3 without information", ss)
+	}
+	subs.Version = 0
+	err = subs.Encode(&bytes.Buffer{})
+	assertError(t, err, "32-bit subsample size in version 0 should give error")
+}
+
+func TestFragmentSubSampleRanges(t *testing.T) {
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	for _, s := range createProgTestSamples(3, 3600, 100, 3, 0) {
+		frag.AddFullSample(s)
+	}
+	subs := &SubsBox{}
+	assertNoError(t, subs.AddSubSamples(1, []SubsSample{{SubsampleSize: 10}, {SubsampleSize: 90}}))
+	assertNoError(t, subs.AddSubSamples(3, []SubsSample{{SubsampleSize: 102}}))
+	assertNoError(t, frag.Moof.Traf.AddChild(subs))
+	seg := NewMediaSegment()
+	seg.AddFragment(frag)
+	buf := bytes.Buffer{}
+	assertNoError(t, seg.Encode(&buf))
+	decSeg, err := DecodeMediaSegment(&buf)
+	assertNoError(t, err)
+	decFrag := decSeg.Fragments[0]
+	if decFrag.Moof.Traf.Subs == nil {
+		t.Fatalf("subs not decoded in traf")
+	}
+
+	ranges, err := decFrag.GetSubSampleRanges(1, nil)
+	assertNoError(t, err)
+	if len(ranges) != 3 || len(ranges[0]) != 2 || ranges[1] != nil || len(ranges[2]) != 1 {
+		t.Fatalf("got subsample ranges %v", ranges)
+	}
+	if r := ranges[0][1]; r.Offset != 10 || r.SubsampleSize != 90 {
+		t.Errorf("got second subsample range %+v", r)
+	}
+	decFrag.Moof.Traf.Subs.Entries[1].SubSamples[0].SubsampleSize = 103
+	_, err = decFrag.GetSubSampleRanges(1, nil)
+	assertError(t, err, "subsamples bigger than sample should give error")
+}
@@ -3,6 +3,7 @@ package mp4
 import (
 	"io"
 	"io/ioutil"
+	"time"
 )
 
 // TkhdBox - Track Header Box (tkhd - mandatory)
@@ -76,6 +77,28 @@ func DecodeTkhd(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	return t, nil
 }
 
+// GetCreationTime - creation time as UTC time
+func (b *TkhdBox) GetCreationTime() time.Time {
+	return MP4TimeToTime(b.CreationTime)
+}
+
+// SetCreationTime - set creation time. Version is changed to 1 if needed
+func (b *TkhdBox) SetCreationTime(t time.Time) {
+	b.CreationTime = TimeToMP4Time(t)
+	b.Version = timeVersion(b.Version, b.CreationTime)
+}
+
+// GetModificationTime - modification time as UTC time
+func (b *TkhdBox) GetModificationTime() time.Time {
+	return MP4TimeToTime(b.ModificationTime)
+}
+
+// SetModificationTime - set modification time. Version is changed to 1 if needed
+func (b *TkhdBox) SetModificationTime(t time.Time) {
+	b.ModificationTime = TimeToMP4Time(t)
+	b.Version = timeVersion(b.Version, b.ModificationTime)
+}
+
 // Type - box type
 func (b *TkhdBox) Type() string {
 	return "tkhd"
@@ -100,14 +123,14 @@ func (b *TkhdBox) Encode(w io.Writer) error {
 	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
 	sw.WriteUint32(versionAndFlags)
 	if b.Version == 0 {
-		sw.WriteUint32(uint32(b.CreationTime))
-		sw.WriteUint32(uint32(b.ModificationTime))
+		sw.WriteUint32(uint32(encodedTime(b.CreationTime)))
+		sw.WriteUint32(uint32(encodedTime(b.ModificationTime)))
 		sw.WriteUint32(b.TrackID)
 		sw.WriteZeroBytes(4) // Reserved
 		sw.WriteUint32(uint32(b.Duration))
 	} else {
-		sw.WriteUint64(b.CreationTime)
-		sw.WriteUint64(b.ModificationTime)
+		sw.WriteUint64(encodedTime(b.CreationTime))
+		sw.WriteUint64(encodedTime(b.ModificationTime))
 		sw.WriteUint32(b.TrackID)
 		sw.WriteZeroBytes(4) // Reserved
 		sw.WriteUint64(b.Duration)
@@ -3,6 +3,7 @@ package mp4
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 )
 
 // TopBoxInfo - information about a top-level box
@@ -15,33 +16,109 @@ type TopBoxInfo struct {
 	StartPos uint64
 }
 
-// GetTopBoxInfoList - get top boxes until stopBoxType or end of file
-func GetTopBoxInfoList(rs io.ReadSeeker, stopBoxType string) ([]TopBoxInfo, error) {
-	var pos uint64 = 0
-	var topBoxList []TopBoxInfo
+// GetTopBoxInfoList - get top boxes until stopBoxType or end of file without decoding them.
+// If r is an io.ReadSeeker, box payloads are skipped by seeking, otherwise they are read and discarded.
+// A box with a size smaller than its header results in an error.
+// A last box extending beyond the end of the file is listed with its header size.
+func GetTopBoxInfoList(r io.Reader, stopBoxType string) ([]TopBoxInfo, error) {
+	return getTopBoxInfoList(r, stopBoxType, false)
+}
 
+// getTopBoxInfoList - list top boxes. If strict, a box extending beyond the end of the file is an error.
+func getTopBoxInfoList(r io.Reader, stopBoxType string, strict bool) ([]TopBoxInfo, error) {
+	rs, isSeeker := r.(io.ReadSeeker)
+	var fileSize int64 = -1
+	if isSeeker {
+		startPos, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		fileSize, err = rs.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		fileSize -= startPos
+		_, err = rs.Seek(startPos, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var topBoxList []TopBoxInfo
+	var pos uint64
 	for {
-		h, err := decodeHeader(rs)
+		h, err := decodeHeader(r)
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("box header at pos %d: %w", pos, err)
 		}
 		if h.name == stopBoxType {
 			break
 		}
-		topBoxList = append(topBoxList, TopBoxInfo{h.name, h.size, pos})
-		nextBoxStart := pos + h.size
-		ipos, err := rs.Seek(int64(nextBoxStart), io.SeekStart)
+		if h.size < uint64(h.hdrlen) {
+			return nil, fmt.Errorf("%s box at pos %d with size %d smaller than header", h.name, pos, h.size)
+		}
+		payloadSize := int64(h.size) - int64(h.hdrlen)
+		truncated := false
+		if isSeeker {
+			truncated = fileSize >= 0 && int64(pos+h.size) > fileSize
+			if !truncated {
+				_, err = rs.Seek(payloadSize, io.SeekCurrent)
+			}
+		} else {
+			_, err = io.CopyN(ioutil.Discard, r, payloadSize)
+			if err == io.EOF {
+				truncated, err = true, nil
+			}
+		}
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%s box at pos %d: %w", h.name, pos, err)
 		}
-		pos = uint64(ipos)
-		if pos != nextBoxStart {
-			return nil, fmt.Errorf("seeked pos %d != next box start %d", pos, nextBoxStart)
+		if truncated && strict {
+			return nil, fmt.Errorf("%s box at pos %d with size %d extends beyond end of file", h.name, pos, h.size)
 		}
+		topBoxList = append(topBoxList, TopBoxInfo{h.name, h.size, pos})
+		if truncated {
+			break
+		}
+		pos += h.size
 	}
-
 	return topBoxList, nil
 }
+
+// TopBoxIndex - top-level boxes in file order
+type TopBoxIndex []TopBoxInfo
+
+// ScanFile - make index of all top-level boxes without decoding them, as done by GetTopBoxInfoList.
+// Unlike GetTopBoxInfoList, a box extending beyond the end of the file results in an error.
+func ScanFile(r io.Reader) (TopBoxIndex, error) {
+	return getTopBoxInfoList(r, "", true)
+}
+
+// Find - get all boxes of type boxType
+func (x TopBoxIndex) Find(boxType string) []TopBoxInfo {
+	var found []TopBoxInfo
+	for _, info := range x {
+		if info.Type == boxType {
+			found = append(found, info)
+		}
+	}
+	return found
+}
+
+// DecodeTopBox - decode the top-level box described by info from rs, which is the scanned file
+func DecodeTopBox(rs io.ReadSeeker, info TopBoxInfo) (Box, error) {
+	_, err := rs.Seek(int64(info.StartPos), io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	box, err := DecodeBox(info.StartPos, rs)
+	if err != nil {
+		return nil, err
+	}
+	if box.Type() != info.Type {
+		return nil, fmt.Errorf("got %s box at pos %d instead of %s", box.Type(), info.StartPos, info.Type)
+	}
+	return box, nil
+}
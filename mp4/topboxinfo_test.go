@@ -1,6 +1,9 @@
 package mp4
 
 import (
+	"bytes"
+	"io"
+	"io/ioutil"
 	"os"
 	"reflect"
 	"testing"
@@ -42,3 +45,60 @@ func TestTopBoxInfo(t *testing.T) {
 		fh.Close()
 	}
 }
+
+func TestScanFile(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/prog_8s.mp4")
+	assertNoError(t, err)
+	index, err := ScanFile(bytes.NewReader(data))
+	assertNoError(t, err)
+	streamIndex, err := ScanFile(bytes.NewBuffer(data)) // Not a ReadSeeker
+	assertNoError(t, err)
+	if diff := deep.Equal(index, streamIndex); diff != nil {
+		t.Errorf("seek and stream scanning differ: %v", diff)
+	}
+	end := index[len(index)-1]
+	if end.StartPos+end.Size != uint64(len(data)) {
+		t.Errorf("index does not cover full file")
+	}
+	moovs := index.Find("moov")
+	if len(moovs) != 1 {
+		t.Fatalf("got %d moov boxes", len(moovs))
+	}
+	box, err := DecodeTopBox(bytes.NewReader(data), moovs[0])
+	assertNoError(t, err)
+	if box.Size() != moovs[0].Size {
+		t.Errorf("decoded moov size %d differs from index size %d", box.Size(), moovs[0].Size)
+	}
+	_, err = ScanFile(bytes.NewReader(data[:len(data)-1]))
+	assertError(t, err, "truncated file should give error")
+	_, err = ScanFile(bytes.NewBuffer(data[:len(data)-1]))
+	assertError(t, err, "truncated file should give error when streaming")
+}
+
+func TestGetTopBoxInfoListTruncated(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/prog_8s.mp4")
+	assertNoError(t, err)
+	full, err := GetTopBoxInfoList(bytes.NewReader(data), "")
+	assertNoError(t, err)
+	truncated := data[:len(data)-1]
+	for _, r := range []io.Reader{bytes.NewReader(truncated), bytes.NewBuffer(truncated)} {
+		boxes, err := GetTopBoxInfoList(r, "")
+		assertNoError(t, err)
+		if diff := deep.Equal(boxes, full); diff != nil {
+			t.Errorf("truncated last box not listed: %v", diff)
+		}
+	}
+}
+
+func TestGetTopBoxInfoListBadSize(t *testing.T) {
+	data := []byte{0, 0, 0, 8, 'f', 'r', 'e', 'e', 0, 0, 0, 4, 's', 'k', 'i', 'p'}
+	_, err := GetTopBoxInfoList(bytes.NewReader(data), "")
+	assertError(t, err, "size smaller than header should give error")
+	_, err = GetTopBoxInfoList(bytes.NewBuffer(data), "")
+	assertError(t, err, "size smaller than header should give error when streaming")
+	boxes, err := GetTopBoxInfoList(bytes.NewBuffer(data), "skip")
+	assertNoError(t, err)
+	if len(boxes) != 1 || boxes[0].Type != "free" {
+		t.Errorf("got %v instead of the free box before skip", boxes)
+	}
+}
@@ -0,0 +1,99 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// nrSizeHistogramBins - number of equally wide bins in TrackStats.SizeHistogram
+const nrSizeHistogramBins = 10
+
+// TrackStats - statistics for the samples of a progressive track
+type TrackStats struct {
+	NrSamples      uint32
+	TotalSize      uint64
+	MinSampleSize  uint32
+	MaxSampleSize  uint32
+	MeanSampleSize float64
+	// SizeHistogram - number of samples in equally wide size bins from MinSampleSize to MaxSampleSize
+	SizeHistogram []uint32
+	// SizeBinWidth - width in bytes of each bin in SizeHistogram
+	SizeBinWidth uint32
+	// DurationCounts - number of samples for each sample duration (in track timescale)
+	DurationCounts map[uint32]uint32
+	TotalDuration  uint64
+	NrSyncSamples  uint32
+	// GOPLengths - number of samples in each GOP, where a GOP starts with a sync sample.
+	// Samples before the first sync sample are not included.
+	GOPLengths []uint32
+	// GOPSizes - size in bytes of each GOP
+	GOPSizes []uint64
+}
+
+// Stats - calculate statistics for samples of a progressive track.
+// If there is no stss box, all samples are sync samples.
+func (t *TrakBox) Stats() (*TrackStats, error) {
+	stbl := t.Mdia.Minf.Stbl
+	sizeTable := stbl.SampleSizes()
+	if sizeTable == nil || stbl.Stts == nil {
+		return nil, fmt.Errorf("no stsz or stts box")
+	}
+	nrSamples := sizeTable.GetNrSamples()
+	if nrSamples == 0 {
+		return nil, fmt.Errorf("no samples in track")
+	}
+	if sttsNr := stbl.Stts.GetNrSamples(); sttsNr != nrSamples {
+		return nil, fmt.Errorf("stts has %d samples but stsz has %d", sttsNr, nrSamples)
+	}
+	s := &TrackStats{
+		NrSamples:      nrSamples,
+		DurationCounts: make(map[uint32]uint32),
+	}
+	for i, count := range stbl.Stts.SampleCount {
+		s.DurationCounts[stbl.Stts.SampleTimeDelta[i]] += count
+		s.TotalDuration += uint64(count) * uint64(stbl.Stts.SampleTimeDelta[i])
+	}
+
+	sizes := make([]uint32, nrSamples)
+	for nr := uint32(1); nr <= nrSamples; nr++ {
+		size := sizeTable.GetSampleSize(int(nr))
+		sizes[nr-1] = size
+		if nr == 1 || size < s.MinSampleSize {
+			s.MinSampleSize = size
+		}
+		if size > s.MaxSampleSize {
+			s.MaxSampleSize = size
+		}
+		s.TotalSize += uint64(size)
+
+		isSync := stbl.Stss == nil || stbl.Stss.IsSyncSample(nr)
+		if isSync {
+			s.NrSyncSamples++
+			s.GOPLengths = append(s.GOPLengths, 0)
+			s.GOPSizes = append(s.GOPSizes, 0)
+		}
+		if n := len(s.GOPLengths); n > 0 {
+			s.GOPLengths[n-1]++
+			s.GOPSizes[n-1] += uint64(size)
+		}
+	}
+	s.MeanSampleSize = float64(s.TotalSize) / float64(nrSamples)
+
+	s.SizeBinWidth = (s.MaxSampleSize-s.MinSampleSize)/nrSizeHistogramBins + 1
+	s.SizeHistogram = make([]uint32, nrSizeHistogramBins)
+	for _, size := range sizes {
+		s.SizeHistogram[(size-s.MinSampleSize)/s.SizeBinWidth]++
+	}
+	return s, nil
+}
+
+// MeanGOPLength - mean number of samples per GOP. 0 if no sync samples
+func (s *TrackStats) MeanGOPLength() float64 {
+	if len(s.GOPLengths) == 0 {
+		return 0
+	}
+	total := uint32(0)
+	for _, l := range s.GOPLengths {
+		total += l
+	}
+	return float64(total) / float64(len(s.GOPLengths))
+}
@@ -0,0 +1,47 @@
+package mp4
+
+import (
+	"testing"
+)
+
+func TestTrackStats(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createProgTestSamples(50, 3600, 1000, 20, 0x10)
+	samples[49].Dur = 1800
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 5000})
+	assertNoError(t, err)
+	stats, err := f.Moov.Trak.Stats()
+	assertNoError(t, err)
+	if stats.NrSamples != 50 || stats.MinSampleSize != 1000 || stats.MaxSampleSize != 1002 {
+		t.Errorf("got %d samples with sizes %d-%d instead of 50 with 1000-1002",
+			stats.NrSamples, stats.MinSampleSize, stats.MaxSampleSize)
+	}
+	if stats.DurationCounts[3600] != 49 || stats.DurationCounts[1800] != 1 {
+		t.Errorf("got duration counts %v", stats.DurationCounts)
+	}
+	if stats.TotalDuration != 49*3600+1800 {
+		t.Errorf("got total duration %d", stats.TotalDuration)
+	}
+	wantedGOPLengths := []uint32{20, 20, 10}
+	if stats.NrSyncSamples != 3 || len(stats.GOPLengths) != 3 {
+		t.Fatalf("got %d sync samples and GOPs %v", stats.NrSyncSamples, stats.GOPLengths)
+	}
+	total := uint64(0)
+	nrInHistogram := uint32(0)
+	for i, l := range stats.GOPLengths {
+		if l != wantedGOPLengths[i] {
+			t.Errorf("GOP %d: got length %d instead of %d", i+1, l, wantedGOPLengths[i])
+		}
+		total += stats.GOPSizes[i]
+	}
+	for _, n := range stats.SizeHistogram {
+		nrInHistogram += n
+	}
+	if total != stats.TotalSize || nrInHistogram != stats.NrSamples {
+		t.Errorf("GOP sizes or histogram do not add up")
+	}
+	if got := stats.MeanGOPLength(); got < 16.6 || got > 16.7 {
+		t.Errorf("got mean GOP length %f", got)
+	}
+}
@@ -8,18 +8,27 @@ import (
 // TrafBox - Track Fragment Box (traf)
 //
 // Contained in : Movie Fragment Box (moof)
-//
 type TrafBox struct {
 	Tfhd     *TfhdBox
 	Tfdt     *TfdtBox
 	Trun     *TrunBox // The first TrunBox
 	Truns    []*TrunBox
+	Saizs    []*SaizBox
+	Saios    []*SaioBox
+	Senc     *SencBox
+	Subs     *SubsBox
+	Sbgp     *SbgpBox   // The first
+	Sbgps    []*SbgpBox // All
+	Sgpd     *SgpdBox   // The first
+	Sgpds    []*SgpdBox // All
+	Tfxd     *UUIDBox   // MSS tfxd
+	Tfrf     *UUIDBox   // MSS tfrf
 	Children []Box
 }
 
 // DecodeTraf - box-specific decode
 func DecodeTraf(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
-	children, err := DecodeContainerChildren(hdr, startPos, startPos+hdr.size, r)
+	children, err := DecodeContainerChildren(hdr, startPos+8, startPos+hdr.size, r)
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +54,57 @@ func (t *TrafBox) AddChild(b Box) error {
 			t.Trun = b.(*TrunBox)
 		}
 		t.Truns = append(t.Truns, b.(*TrunBox))
+	case "saiz":
+		t.Saizs = append(t.Saizs, b.(*SaizBox))
+	case "saio":
+		t.Saios = append(t.Saios, b.(*SaioBox))
+	case "senc":
+		t.Senc = b.(*SencBox)
+	case "subs":
+		t.Subs = b.(*SubsBox)
+	case "sbgp":
+		if t.Sbgp == nil {
+			t.Sbgp = b.(*SbgpBox)
+		}
+		t.Sbgps = append(t.Sbgps, b.(*SbgpBox))
+	case "sgpd":
+		if t.Sgpd == nil {
+			t.Sgpd = b.(*SgpdBox)
+		}
+		t.Sgpds = append(t.Sgpds, b.(*SgpdBox))
+	case "uuid":
+		switch u := b.(*UUIDBox); u.SubType {
+		case "tfxd":
+			t.Tfxd = u
+		case "tfrf":
+			t.Tfrf = u
+		}
 	default:
 	}
 	t.Children = append(t.Children, b)
 	return nil
 }
 
+// GetSaizSaio - get saiz and saio boxes for auxInfoType.
+// auxInfoType "" matches boxes without explicit type. Returns nil if not found.
+func (t *TrafBox) GetSaizSaio(auxInfoType string) (*SaizBox, *SaioBox) {
+	var saiz *SaizBox
+	var saio *SaioBox
+	for _, b := range t.Saizs {
+		if b.AuxInfoType == auxInfoType {
+			saiz = b
+			break
+		}
+	}
+	for _, b := range t.Saios {
+		if b.AuxInfoType == auxInfoType {
+			saio = b
+			break
+		}
+	}
+	return saiz, saio
+}
+
 // Type - return box type
 func (t *TrafBox) Type() string {
 	return "traf"
@@ -66,6 +120,17 @@ func (t *TrafBox) GetChildren() []Box {
 	return t.Children
 }
 
+func (t *TrafBox) setChildren(children []Box) error {
+	n := TrafBox{}
+	for _, c := range children {
+		if err := n.AddChild(c); err != nil {
+			return err
+		}
+	}
+	*t = n
+	return nil
+}
+
 // Encode - write box to w
 func (t *TrafBox) Encode(w io.Writer) error {
 	return EncodeContainer(t, w)
@@ -17,6 +17,9 @@ type TrakBox struct {
 	Tkhd     *TkhdBox
 	Mdia     *MdiaBox
 	Edts     *EdtsBox
+	Tref     *TrefBox
+	Trgr     *TrgrBox
+	Udta     *UdtaBox
 	Children []Box
 }
 
@@ -34,6 +37,12 @@ func (t *TrakBox) AddChild(box Box) {
 		t.Mdia = box.(*MdiaBox)
 	case "edts":
 		t.Edts = box.(*EdtsBox)
+	case "tref":
+		t.Tref = box.(*TrefBox)
+	case "trgr":
+		t.Trgr = box.(*TrgrBox)
+	case "udta":
+		t.Udta = box.(*UdtaBox)
 	}
 	t.Children = append(t.Children, box)
 }
@@ -66,6 +75,15 @@ func (t *TrakBox) GetChildren() []Box {
 	return t.Children
 }
 
+func (t *TrakBox) setChildren(children []Box) error {
+	n := TrakBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*t = n
+	return nil
+}
+
 // Encode - write trak container to w
 func (t *TrakBox) Encode(w io.Writer) error {
 	return EncodeContainer(t, w)
@@ -76,17 +94,100 @@ func (t *TrakBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	return ContainerInfo(t, w, specificBoxLevels, indent, indentStep)
 }
 
+// DASHRoleSchemeURI - schemeURI for DASH roles in kind boxes
+const DASHRoleSchemeURI = "urn:mpeg:dash:role:2011"
+
+// Kind - return "video", "audio", "subtitle", "metadata", or "unknown" based on hdlr and sample entry
+func (t *TrakBox) Kind() string {
+	if t.Mdia != nil && t.Mdia.Hdlr != nil {
+		switch t.Mdia.Hdlr.HandlerType {
+		case "vide", "auxv", "pict":
+			return "video"
+		case "soun":
+			return "audio"
+		case "subt", "text", "sbtl", "clcp":
+			return "subtitle"
+		case "meta":
+			return "metadata"
+		}
+	}
+	if t.Mdia == nil || t.Mdia.Minf == nil || t.Mdia.Minf.Stbl == nil || t.Mdia.Minf.Stbl.Stsd == nil {
+		return "unknown"
+	}
+	stsd := t.Mdia.Minf.Stbl.Stsd
+	if len(stsd.Children) == 0 {
+		return "unknown"
+	}
+	switch stsd.Children[0].(type) {
+	case *VisualSampleEntryBox:
+		return "video"
+	case *AudioSampleEntryBox:
+		return "audio"
+	case *WvttBox, *StppBox, *Tx3gBox:
+		return "subtitle"
+	}
+	return "unknown"
+}
+
+// Language - return language from elng box if present, otherwise from mdhd
+func (t *TrakBox) Language() string {
+	if t.Mdia == nil {
+		return ""
+	}
+	if t.Mdia.Elng != nil {
+		return t.Mdia.Elng.Language
+	}
+	if t.Mdia.Mdhd != nil {
+		return t.Mdia.Mdhd.GetLanguage()
+	}
+	return ""
+}
+
+// Roles - return values of all kind boxes in udta with DASH role scheme (like main or caption)
+func (t *TrakBox) Roles() []string {
+	var roles []string
+	for _, kind := range t.GetKinds() {
+		if kind.SchemeURI == DASHRoleSchemeURI {
+			roles = append(roles, kind.Value)
+		}
+	}
+	return roles
+}
+
 // GetNrSamples - get number of samples for this track defined in the parent moov box.
 func (t *TrakBox) GetNrSamples() uint32 {
-	stbl := t.Mdia.Minf.Stbl
-	return stbl.Stsz.GetNrSamples()
+	sizes := t.Mdia.Minf.Stbl.SampleSizes()
+	if sizes == nil {
+		return 0
+	}
+	return sizes.GetNrSamples()
+}
+
+// GetChunkDataLocation - get location of media data for chunkNr (one-based) as given by dref.
+// selfContained is true if the data is in the same file as the moov box.
+func (t *TrakBox) GetChunkDataLocation(chunkNr uint32) (location string, selfContained bool, err error) {
+	minf := t.Mdia.Minf
+	if minf.Dinf == nil || minf.Dinf.Dref == nil || minf.Dinf.Dref.allSelfContained() {
+		return "", true, nil
+	}
+	stbl := minf.Stbl
+	sdi := stbl.Stsc.GetSampleDescriptionIDForChunk(chunkNr)
+	dri, err := stbl.Stsd.GetDataReferenceIndex(sdi)
+	if err != nil {
+		return "", false, err
+	}
+	return minf.Dinf.Dref.GetLocation(dri)
 }
 
 // GetSampleData - get sample metadata for a specific interval of samples defined in moov.
 // If going outside the range of available samples, an error is returned.
 func (t *TrakBox) GetSampleData(startSampleNr, endSampleNr uint32) ([]Sample, error) {
 	stbl := t.Mdia.Minf.Stbl
-	nrSamples := stbl.Stsz.GetNrSamples()
+	sizes := stbl.SampleSizes()
+	if sizes == nil {
+		return nil, fmt.Errorf("no stsz or stz2 box")
+	}
+	nrSamples := sizes.GetNrSamples()
 	if startSampleNr < 1 || endSampleNr > nrSamples {
 		return nil, fmt.Errorf("Samples interval %d-%d not inside available %d-%d", startSampleNr, endSampleNr, 1, nrSamples)
 	}
@@ -104,7 +205,7 @@ func (t *TrakBox) GetSampleData(startSampleNr, endSampleNr uint32) ([]Sample, er
 		samples[nr] = Sample{
 			Flags:                 createSampleFlagsFromProgressiveBoxes(stss, sdtp, nr),
 			Dur:                   stts.GetDur(nr),
-			Size:                  stbl.Stsz.GetSampleSize(int(nr)),
+			Size:                  sizes.GetSampleSize(int(nr)),
 			CompositionTimeOffset: cto,
 		}
 	}
@@ -142,8 +243,11 @@ func (t *TrakBox) GetRangesForSampleInterval(startSampleNr, endSampleNr uint32)
 	stsc := stbl.Stsc
 	stco := stbl.Stco
 	co64 := stbl.Co64
-	stsz := stbl.Stsz
-	nrSamples := stbl.Stsz.GetNrSamples()
+	stsz := stbl.SampleSizes()
+	if stsz == nil {
+		return nil, fmt.Errorf("no stsz or stz2 box")
+	}
+	nrSamples := stsz.GetNrSamples()
 	if startSampleNr < 1 || endSampleNr > nrSamples {
 		return nil, fmt.Errorf("Samples interval %d-%d not inside available %d-%d", startSampleNr, endSampleNr, 1, nrSamples)
 	}
@@ -0,0 +1,40 @@
+package mp4
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestTrakKindLanguageRoles(t *testing.T) {
+	testCases := []struct {
+		mediaType string
+		language  string
+		kind      string
+	}{
+		{"video", "und", "video"},
+		{"audio", "swe", "audio"},
+		{"wvtt", "en-US", "subtitle"},
+		{"subtitle", "fre", "subtitle"},
+		{"meta", "und", "metadata"},
+	}
+	for _, tc := range testCases {
+		trak := CreateEmptyTrak(1, 1000, tc.mediaType, tc.language)
+		if trak.Kind() != tc.kind {
+			t.Errorf("got kind %q instead of %q", trak.Kind(), tc.kind)
+		}
+		if trak.Language() != tc.language {
+			t.Errorf("got language %q instead of %q", trak.Language(), tc.language)
+		}
+	}
+
+	trak := CreateEmptyTrak(1, 1000, "wvtt", "en")
+	udta := &UdtaBox{}
+	udta.AddChild(&KindBox{SchemeURI: DASHRoleSchemeURI, Value: "caption"})
+	udta.AddChild(&KindBox{SchemeURI: "urn:other", Value: "other"})
+	trak.AddChild(udta)
+	trak = boxAfterEncodeAndDecode(t, trak).(*TrakBox)
+	if diff := deep.Equal(trak.Roles(), []string{"caption"}); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -7,7 +7,20 @@ import (
 	"io/ioutil"
 )
 
-// TrefBox -  // TrackReferenceBox - ISO/IEC 14496-12 Ed. 9 Sec. 8.3
+// Track reference types
+const (
+	TrefHint = "hint" // Links a hint track to the media it hints
+	TrefCdsc = "cdsc" // Metadata track that describes the referenced track
+	TrefFont = "font" // Track uses fonts carried in the referenced track
+	TrefHind = "hind" // Track depends on the referenced hint track
+	TrefVdep = "vdep" // Auxiliary depth video for the referenced video track
+	TrefVplx = "vplx" // Auxiliary parallax video for the referenced video track
+	TrefSubt = "subt" // Subtitle, timed text, or overlay graphics for the referenced track
+	TrefChap = "chap" // Chapter track for the referenced track (QuickTime)
+	TrefSync = "sync" // Track is synchronized with the referenced track (ISO/IEC 14496-14)
+)
+
+// TrefBox - TrackReferenceBox - ISO/IEC 14496-12 Ed. 9 Sec. 8.3
 type TrefBox struct {
 	Children []Box
 }
@@ -17,6 +30,16 @@ func (b *TrefBox) AddChild(box Box) {
 	b.Children = append(b.Children, box)
 }
 
+// GetReference - first reference box of type refType, or nil
+func (b *TrefBox) GetReference(refType string) *TrefTypeBox {
+	for _, c := range b.Children {
+		if ref, ok := c.(*TrefTypeBox); ok && ref.Name == refType {
+			return ref
+		}
+	}
+	return nil
+}
+
 // DecodeTref - box-specific decode
 func DecodeTref(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	children, err := DecodeContainerChildren(hdr, startPos+8, startPos+hdr.size, r)
@@ -45,6 +68,15 @@ func (b *TrefBox) GetChildren() []Box {
 	return b.Children
 }
 
+func (b *TrefBox) setChildren(children []Box) error {
+	n := TrefBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - write minf container to w
 func (b *TrefBox) Encode(w io.Writer) error {
 	return EncodeContainer(b, w)
@@ -72,6 +104,9 @@ func DecodeTrefType(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("%s: payload size %d not a multiple of 4", hdr.name, len(data))
+	}
 	for i := 0; i < len(data); i += 4 {
 		trackID := binary.BigEndian.Uint32(data[i : i+4])
 		b.TrackIDs = append(b.TrackIDs, trackID)
@@ -114,3 +149,76 @@ func (b *TrefTypeBox) Info(w io.Writer, specificBoxLevels, indent, indentStep st
 	bd.write(msg)
 	return bd.err
 }
+
+// AddTrackReference - add trackID to track reference of type refType, creating tref after tkhd if needed.
+// A trackID that is already referenced is not added again.
+func (t *TrakBox) AddTrackReference(refType string, trackID uint32) {
+	if t.Tref == nil {
+		t.insertTref(&TrefBox{})
+	}
+	if ref := t.Tref.GetReference(refType); ref != nil {
+		if !containsTrackID(ref.TrackIDs, trackID) {
+			ref.TrackIDs = append(ref.TrackIDs, trackID)
+		}
+		return
+	}
+	t.Tref.AddChild(&TrefTypeBox{Name: refType, TrackIDs: []uint32{trackID}})
+}
+
+// insertTref - insert tref directly after tkhd as in the box order of ISO/IEC 14496-12
+func (t *TrakBox) insertTref(tref *TrefBox) {
+	t.Tref = tref
+	for i, c := range t.Children {
+		if c == t.Tkhd {
+			t.Children = append(t.Children[:i+1], append([]Box{tref}, t.Children[i+1:]...)...)
+			return
+		}
+	}
+	t.Children = append(t.Children, tref)
+}
+
+// GetTrackReference - track IDs referenced by track reference of type refType
+func (t *TrakBox) GetTrackReference(refType string) []uint32 {
+	if t.Tref == nil {
+		return nil
+	}
+	if ref := t.Tref.GetReference(refType); ref != nil {
+		return ref.TrackIDs
+	}
+	return nil
+}
+
+// updateTrackReferences - map referenced track IDs using newTrackID, which returns false for removed tracks.
+// Reference boxes without track IDs are removed, as is tref if it becomes empty.
+func (t *TrakBox) updateTrackReferences(newTrackID func(trackID uint32) (uint32, bool)) {
+	if t.Tref == nil {
+		return
+	}
+	children := t.Tref.Children[:0]
+	for _, c := range t.Tref.Children {
+		if ref, ok := c.(*TrefTypeBox); ok {
+			trackIDs := ref.TrackIDs[:0]
+			for _, trackID := range ref.TrackIDs {
+				if id, ok := newTrackID(trackID); ok {
+					trackIDs = append(trackIDs, id)
+				}
+			}
+			ref.TrackIDs = trackIDs
+			if len(trackIDs) == 0 {
+				continue
+			}
+		}
+		children = append(children, c)
+	}
+	t.Tref.Children = children
+	if len(children) > 0 {
+		return
+	}
+	for i, c := range t.Children {
+		if c == t.Tref {
+			t.Children = append(t.Children[:i], t.Children[i+1:]...)
+			break
+		}
+	}
+	t.Tref = nil
+}
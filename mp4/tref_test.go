@@ -20,3 +20,43 @@ func TestTref(t *testing.T) {
 	tref.AddChild(&TrefTypeBox{Name: "sync", TrackIDs: []uint32{12, 13}})
 	boxDiffAfterEncodeAndDecode(t, &tref)
 }
+
+func TestLinkTracks(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(1000, "wvtt", "en")
+	init.AddEmptyTrack(1000, "meta", "und")
+
+	assertNoError(t, init.LinkSubtitleTrack(2, 1))
+	assertNoError(t, init.LinkSubtitleTrack(2, 1))
+	assertNoError(t, init.LinkMetadataTrack(3, 1))
+	assertError(t, init.LinkSubtitleTrack(2, 2), "self reference should fail")
+	assertError(t, init.LinkMetadataTrack(3, 4), "reference to missing track should fail")
+
+	subTrak := init.Moov.GetTrak(2)
+	if refs := subTrak.GetTrackReference(TrefSubt); len(refs) != 1 || refs[0] != 1 {
+		t.Errorf("got subt references %v instead of [1]", refs)
+	}
+	if subTrak.Children[1] != subTrak.Tref {
+		t.Errorf("tref is not directly after tkhd")
+	}
+	metaTrak := init.Moov.GetTrak(3)
+	if refs := metaTrak.GetTrackReference(TrefCdsc); len(refs) != 1 || refs[0] != 1 {
+		t.Errorf("got cdsc references %v instead of [1]", refs)
+	}
+	boxDiffAfterEncodeAndDecode(t, subTrak.Tref)
+
+	assertNoError(t, init.SetTrackID(1, 5))
+	if refs := subTrak.GetTrackReference(TrefSubt); len(refs) != 1 || refs[0] != 5 {
+		t.Errorf("got subt references %v instead of [5] after SetTrackID", refs)
+	}
+	assertNoError(t, init.RemoveTrack(5))
+	if subTrak.Tref != nil || metaTrak.Tref != nil {
+		t.Errorf("tref not removed with referenced track")
+	}
+	for _, c := range subTrak.Children {
+		if c.Type() == "tref" {
+			t.Errorf("tref still child of trak")
+		}
+	}
+}
@@ -11,11 +11,15 @@ type TrepBox struct {
 	Version  byte
 	Flags    uint32
 	TrackID  uint32
+	Cslg     *CslgBox
 	Children []Box
 }
 
-// AddChild - Add a child box and update SampleCount
+// AddChild - Add a child box
 func (b *TrepBox) AddChild(child Box) {
+	if cslg, ok := child.(*CslgBox); ok {
+		b.Cslg = cslg
+	}
 	b.Children = append(b.Children, child)
 }
 
@@ -86,3 +86,35 @@ func (t *TrexBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string
 	bd.write(" - defaultSampleFlags: %08x (%s)", t.DefaultSampleFlags, DecodeSampleFlags(t.DefaultSampleFlags))
 	return bd.err
 }
+
+// CreateTrexFromTraf - create trex box with defaults taken from the tfhd and first trun of traf.
+// Used for fragmented files without mvex or trex, as produced by some encoders.
+func CreateTrexFromTraf(traf *TrafBox) *TrexBox {
+	tfhd := traf.Tfhd
+	trex := CreateTrex(tfhd.TrackID)
+	if tfhd.HasSampleDescriptionIndex() {
+		trex.DefaultSampleDescriptionIndex = tfhd.SampleDescriptionIndex
+	}
+	trun := traf.Trun
+	hasSamples := trun != nil && trun.SampleCount() > 0
+	switch {
+	case tfhd.HasDefaultSampleDuration():
+		trex.DefaultSampleDuration = tfhd.DefaultSampleDuration
+	case hasSamples && trun.HasSampleDuration():
+		trex.DefaultSampleDuration = trun.Samples[0].Dur
+	}
+	switch {
+	case tfhd.HasDefaultSampleSize():
+		trex.DefaultSampleSize = tfhd.DefaultSampleSize
+	case hasSamples && trun.HasSampleSize():
+		trex.DefaultSampleSize = trun.Samples[0].Size
+	}
+	switch {
+	case tfhd.HasDefaultSampleFlags():
+		trex.DefaultSampleFlags = tfhd.DefaultSampleFlags
+	case hasSamples && trun.HasSampleFlags():
+		// The first sample is often a sync sample with flags differing from the rest
+		trex.DefaultSampleFlags = trun.Samples[trun.SampleCount()-1].Flags
+	}
+	return trex
+}
@@ -0,0 +1,154 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// TrackSampleDefaults - most common sample values of a track, which are the best trex defaults
+type TrackSampleDefaults struct {
+	TrackID        uint32
+	NrSamples      int
+	SampleDuration uint32
+	SampleSize     uint32
+	SampleFlags    uint32 // Most common flags of samples that are not first in their trun
+}
+
+// AnalyzeTrackDefaults - find the most common sample duration, size, and flags of trackID in all fragments.
+// Ties are resolved by taking the smallest value. Missing sample values in trun are filled in from tfhd and trex.
+func (f *File) AnalyzeTrackDefaults(trackID uint32) (*TrackSampleDefaults, error) {
+	trex := f.TrexForTrack(trackID)
+	durs := make(map[uint32]int)
+	sizes := make(map[uint32]int)
+	flags := make(map[uint32]int)
+	d := &TrackSampleDefaults{TrackID: trackID}
+	for _, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			traf := frag.trafForTrack(trackID)
+			if traf == nil {
+				continue
+			}
+			for _, trun := range traf.Truns {
+				trun.AddSampleDefaultValues(traf.Tfhd, trex)
+				for i, s := range trun.Samples {
+					durs[s.Dur]++
+					sizes[s.Size]++
+					if i > 0 {
+						flags[s.Flags]++
+					}
+					d.NrSamples++
+				}
+			}
+		}
+	}
+	if d.NrSamples == 0 {
+		return nil, fmt.Errorf("no samples for trackID=%d", trackID)
+	}
+	d.SampleDuration = mostCommonValue(durs)
+	d.SampleSize = mostCommonValue(sizes)
+	if len(flags) > 0 {
+		d.SampleFlags = mostCommonValue(flags)
+	} else if trex != nil {
+		d.SampleFlags = trex.DefaultSampleFlags
+	}
+	return d, nil
+}
+
+// mostCommonValue - value with highest count, the smallest one if there is a tie
+func mostCommonValue(counts map[uint32]int) uint32 {
+	var best uint32
+	bestCount := 0
+	for v, c := range counts {
+		if c > bestCount || (c == bestCount && v < best) {
+			best, bestCount = v, c
+		}
+	}
+	return best
+}
+
+// OptimizeTrexDefaults - set the trex defaults of all tracks to their most common sample values, and remove
+// values equal to the new defaults from tfhd and trun in all fragments, e.g. when finalizing a recording.
+// Fragments shrink, and data offsets and StartPos of top-level boxes are updated.
+func (f *File) OptimizeTrexDefaults() error {
+	if f.Moov == nil || f.Moov.Mvex == nil {
+		return fmt.Errorf("no mvex box")
+	}
+	positions := f.boxPositions()
+	for _, trak := range f.Moov.Traks {
+		trackID := trak.Tkhd.TrackID
+		trex := f.Moov.Mvex.GetTrex(trackID)
+		if trex == nil {
+			return fmt.Errorf("no trex for trackID=%d", trackID)
+		}
+		d, err := f.AnalyzeTrackDefaults(trackID) // Also fills in sample values from the old defaults
+		if err != nil {
+			continue // No samples
+		}
+		trex.DefaultSampleDuration = d.SampleDuration
+		trex.DefaultSampleSize = d.SampleSize
+		trex.DefaultSampleFlags = d.SampleFlags
+		for _, seg := range f.Segments {
+			for _, frag := range seg.Fragments {
+				if traf := frag.trafForTrack(trackID); traf != nil {
+					traf.OptimizeForTrex(trex)
+				}
+			}
+		}
+	}
+	return f.updateOffsets(positions)
+}
+
+// OptimizeForTrex - remove sample durations, sizes, and flags from tfhd and trun if they all equal the
+// trex defaults. Values that differ and were given by the previous defaults are written explicitly.
+// The sample values in trun must be complete, e.g. after AddSampleDefaultValues or for created fragments.
+func (t *TrafBox) OptimizeForTrex(trex *TrexBox) {
+	tfhd := t.Tfhd
+	allDur, allSize, allFlags := true, true, true
+	for _, trun := range t.Truns {
+		for i, s := range trun.Samples {
+			allDur = allDur && s.Dur == trex.DefaultSampleDuration
+			allSize = allSize && s.Size == trex.DefaultSampleSize
+			if i > 0 {
+				allFlags = allFlags && s.Flags == trex.DefaultSampleFlags
+			}
+		}
+	}
+
+	if allDur {
+		tfhd.Flags &^= defaultSampleDurationPresent
+		tfhd.DefaultSampleDuration = 0
+	}
+	if allSize {
+		tfhd.Flags &^= defaultSampleSizePresent
+		tfhd.DefaultSampleSize = 0
+	}
+	if allFlags {
+		tfhd.Flags &^= defaultSampleFlagsPresent
+		tfhd.DefaultSampleFlags = 0
+	}
+	for _, trun := range t.Truns {
+		switch {
+		case allDur:
+			trun.flags &^= sampleDurationPresentFlag
+		case !tfhd.HasDefaultSampleDuration():
+			trun.flags |= sampleDurationPresentFlag
+		}
+		switch {
+		case allSize:
+			trun.flags &^= sampleSizePresentFlag
+		case !tfhd.HasDefaultSampleSize():
+			trun.flags |= sampleSizePresentFlag
+		}
+		switch {
+		case allFlags:
+			trun.flags &^= sampleFlagsPresentFlag
+			if len(trun.Samples) > 0 && trun.Samples[0].Flags != trex.DefaultSampleFlags {
+				trun.SetFirstSampleFlags(trun.Samples[0].Flags)
+			} else {
+				trun.RemoveFirstSampleFlags()
+			}
+		case !tfhd.HasDefaultSampleFlags() && !trun.HasSampleFlags():
+			trun.flags |= sampleFlagsPresentFlag
+			trun.RemoveFirstSampleFlags()
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOptimizeTrexDefaults(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	for nr := 0; nr < 2; nr++ {
+		frag, err := CreateFragment(uint32(nr+1), 1)
+		assertNoError(t, err)
+		samples := createProgTestSamples(10, 3000, 100, 10, byte(0x10*nr))
+		samples[5].Dur = 3001
+		for _, s := range samples {
+			s.DecodeTime += uint64(nr) * 30000
+			frag.AddFullSample(s)
+		}
+		assertNoError(t, frag.Encode(&buf))
+	}
+	f, err := DecodeFile(bytes.NewReader(buf.Bytes()))
+	assertNoError(t, err)
+	origSize := buf.Len()
+	var origSamples []FullSample
+	for _, frag := range f.Segments[0].Fragments {
+		s, err := frag.GetFullSamples(f.TrexForTrack(1))
+		assertNoError(t, err)
+		origSamples = append(origSamples, s...)
+	}
+
+	d, err := f.AnalyzeTrackDefaults(1)
+	assertNoError(t, err)
+	if d.NrSamples != 20 || d.SampleDuration != 3000 || d.SampleSize != 100 || d.SampleFlags != NonSyncSampleFlags {
+		t.Errorf("got defaults %+v", d)
+	}
+
+	assertNoError(t, f.OptimizeTrexDefaults())
+	trex := f.Moov.Mvex.GetTrex(1)
+	if trex.DefaultSampleDuration != 3000 || trex.DefaultSampleSize != 100 || trex.DefaultSampleFlags != NonSyncSampleFlags {
+		t.Errorf("got trex %+v", trex)
+	}
+	outBuf := bytes.Buffer{}
+	assertNoError(t, f.Encode(&outBuf))
+	if outBuf.Len() >= origSize {
+		t.Errorf("size %d not smaller than %d after optimization", outBuf.Len(), origSize)
+	}
+	decFile, err := DecodeFile(bytes.NewReader(outBuf.Bytes()))
+	assertNoError(t, err)
+	var samples []FullSample
+	for _, frag := range decFile.Segments[0].Fragments {
+		s, err := frag.GetFullSamples(decFile.TrexForTrack(1))
+		assertNoError(t, err)
+		samples = append(samples, s...)
+	}
+	if len(samples) != len(origSamples) {
+		t.Fatalf("got %d samples instead of %d", len(samples), len(origSamples))
+	}
+	for i := range samples {
+		if samples[i].Sample != origSamples[i].Sample || samples[i].DecodeTime != origSamples[i].DecodeTime ||
+			!bytes.Equal(samples[i].Data, origSamples[i].Data) {
+			t.Errorf("sample %d differs after optimization", i+1)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Track group types
+const (
+	TrackGroupMsrc = "msrc" // Tracks of a multi-source presentation, e.g. several camera views
+	TrackGroupSter = "ster" // Left and right views of a stereo pair
+)
+
+// TrgrBox - Track Group Box - ISO/IEC 14496-12 Ed. 9 Sec. 8.3.4
+//
+// Contained in : Track Box (trak)
+type TrgrBox struct {
+	Children []Box
+}
+
+// AddChild - Add a child box
+func (b *TrgrBox) AddChild(box Box) {
+	b.Children = append(b.Children, box)
+}
+
+// GetGroups - all track group boxes of type groupType
+func (b *TrgrBox) GetGroups(groupType string) []*TrackGroupTypeBox {
+	var groups []*TrackGroupTypeBox
+	for _, c := range b.Children {
+		if g, ok := c.(*TrackGroupTypeBox); ok && g.Name == groupType {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// DecodeTrgr - box-specific decode
+func DecodeTrgr(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	children, err := DecodeContainerChildren(hdr, startPos+8, startPos+hdr.size, r)
+	if err != nil {
+		return nil, err
+	}
+	b := TrgrBox{}
+	for _, child := range children {
+		b.AddChild(child)
+	}
+	return &b, nil
+}
+
+// Type - box type
+func (b *TrgrBox) Type() string {
+	return "trgr"
+}
+
+// Size - calculated size of box
+func (b *TrgrBox) Size() uint64 {
+	return containerSize(b.Children)
+}
+
+// GetChildren - list of child boxes
+func (b *TrgrBox) GetChildren() []Box {
+	return b.Children
+}
+
+func (b *TrgrBox) setChildren(children []Box) error {
+	n := TrgrBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
+// Encode - write trgr container to w
+func (b *TrgrBox) Encode(w io.Writer) error {
+	return EncodeContainer(b, w)
+}
+
+// Info - write box-specific information
+func (b *TrgrBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	return ContainerInfo(b, w, specificBoxLevels, indent, indentStep)
+}
+
+// TrackGroupTypeBox - TrackGroupTypeBox - ISO/IEC 14496-12 Ed. 9 Sec. 8.3.4
+// Name is the track group type, e.g. msrc or ster. Tracks with the same Name and TrackGroupID
+// belong to the same group. Data is any type-specific data after the track_group_id.
+type TrackGroupTypeBox struct {
+	Name         string
+	Version      byte
+	Flags        uint32
+	TrackGroupID uint32
+	Data         []byte
+}
+
+// CreateTrackGroupType - create track group box of type groupType
+func CreateTrackGroupType(groupType string, trackGroupID uint32) *TrackGroupTypeBox {
+	return &TrackGroupTypeBox{Name: groupType, TrackGroupID: trackGroupID}
+}
+
+// DecodeTrackGroupType - box-specific decode
+func DecodeTrackGroupType(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8 {
+		return nil, fmt.Errorf("%s: too short: %d bytes", hdr.name, len(data))
+	}
+	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
+	b := &TrackGroupTypeBox{
+		Name:         hdr.name,
+		Version:      byte(versionAndFlags >> 24),
+		Flags:        versionAndFlags & flagsMask,
+		TrackGroupID: s.ReadUint32(),
+	}
+	if s.NrRemainingBytes() > 0 {
+		b.Data = s.ReadBytes(s.NrRemainingBytes())
+	}
+	return b, nil
+}
+
+// Type - box type
+func (b *TrackGroupTypeBox) Type() string {
+	return b.Name
+}
+
+// Size - calculated size of box
+func (b *TrackGroupTypeBox) Size() uint64 {
+	return uint64(boxHeaderSize + 8 + len(b.Data))
+}
+
+// Encode - write box to w
+func (b *TrackGroupTypeBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
+	sw.WriteUint32(versionAndFlags)
+	sw.WriteUint32(b.TrackGroupID)
+	sw.WriteBytes(b.Data)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *TrackGroupTypeBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	bd.write(" - trackGroupID: %d", b.TrackGroupID)
+	if len(b.Data) > 0 {
+		bd.write(" - data: %d bytes", len(b.Data))
+	}
+	return bd.err
+}
+
+// AddTrackGroup - add track to group of type groupType with trackGroupID, creating trgr if needed.
+// Nothing is added if the track is already in the group.
+func (t *TrakBox) AddTrackGroup(groupType string, trackGroupID uint32) {
+	if t.Trgr == nil {
+		t.insertTrgr(&TrgrBox{})
+	}
+	for _, g := range t.Trgr.GetGroups(groupType) {
+		if g.TrackGroupID == trackGroupID {
+			return
+		}
+	}
+	t.Trgr.AddChild(CreateTrackGroupType(groupType, trackGroupID))
+}
+
+// insertTrgr - insert trgr after tkhd and tref as in the box order of ISO/IEC 14496-12
+func (t *TrakBox) insertTrgr(trgr *TrgrBox) {
+	t.Trgr = trgr
+	pos := -1
+	for i, c := range t.Children {
+		if c == t.Tkhd || (t.Tref != nil && c == t.Tref) {
+			pos = i
+		}
+	}
+	if pos < 0 {
+		t.Children = append(t.Children, trgr)
+		return
+	}
+	t.Children = append(t.Children[:pos+1], append([]Box{trgr}, t.Children[pos+1:]...)...)
+}
+
+// GetTrackGroupIDs - IDs of the groups of type groupType that the track belongs to
+func (t *TrakBox) GetTrackGroupIDs(groupType string) []uint32 {
+	if t.Trgr == nil {
+		return nil
+	}
+	var ids []uint32
+	for _, g := range t.Trgr.GetGroups(groupType) {
+		ids = append(ids, g.TrackGroupID)
+	}
+	return ids
+}
+
+// GetTrackGroups - track IDs of all tracks in each group of type groupType, indexed by track group ID
+func (m *MoovBox) GetTrackGroups(groupType string) map[uint32][]uint32 {
+	groups := make(map[uint32][]uint32)
+	for _, trak := range m.Traks {
+		for _, id := range trak.GetTrackGroupIDs(groupType) {
+			groups[id] = append(groups[id], trak.Tkhd.TrackID)
+		}
+	}
+	return groups
+}
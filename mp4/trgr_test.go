@@ -0,0 +1,67 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTrgr(t *testing.T) {
+	trgr := &TrgrBox{}
+	trgr.AddChild(CreateTrackGroupType(TrackGroupMsrc, 1))
+	trgr.AddChild(CreateTrackGroupType(TrackGroupSter, 2))
+	trgr.AddChild(&TrackGroupTypeBox{Name: TrackGroupMsrc, TrackGroupID: 3, Data: []byte{1, 2}})
+	boxDiffAfterEncodeAndDecode(t, trgr)
+
+	var buf bytes.Buffer
+	err := trgr.Encode(&buf)
+	assertNoError(t, err)
+	box, err := DecodeBox(0, &buf)
+	assertNoError(t, err)
+	if groups := box.(*TrgrBox).GetGroups(TrackGroupMsrc); len(groups) != 2 || groups[1].TrackGroupID != 3 {
+		t.Errorf("got msrc groups %v", groups)
+	}
+}
+
+func TestTrackGroups(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(90000, "video", "und")
+	init.AddEmptyTrack(48000, "audio", "und")
+	left, right, audio := init.Moov.Traks[0], init.Moov.Traks[1], init.Moov.Traks[2]
+	left.AddTrackGroup(TrackGroupSter, 1)
+	right.AddTrackGroup(TrackGroupSter, 1)
+	right.AddTrackGroup(TrackGroupSter, 1)
+	for _, trak := range init.Moov.Traks {
+		trak.AddTrackGroup(TrackGroupMsrc, 7)
+	}
+	audio.AddTrackReference(TrefCdsc, 1)
+	audio.AddTrackGroup(TrackGroupMsrc, 8)
+
+	if ids := right.GetTrackGroupIDs(TrackGroupSter); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("got ster group IDs %v instead of [1]", ids)
+	}
+	ster := init.Moov.GetTrackGroups(TrackGroupSter)
+	if len(ster) != 1 || len(ster[1]) != 2 || ster[1][0] != 1 || ster[1][1] != 2 {
+		t.Errorf("got ster groups %v", ster)
+	}
+	msrc := init.Moov.GetTrackGroups(TrackGroupMsrc)
+	if len(msrc) != 2 || len(msrc[7]) != 3 || len(msrc[8]) != 1 || msrc[8][0] != 3 {
+		t.Errorf("got msrc groups %v", msrc)
+	}
+	if left.Children[1] != left.Trgr {
+		t.Errorf("trgr is not directly after tkhd")
+	}
+	if audio.Children[1] != audio.Tref || audio.Children[2] != audio.Trgr {
+		t.Errorf("trgr is not directly after tkhd and tref")
+	}
+
+	var buf bytes.Buffer
+	err := init.Encode(&buf)
+	assertNoError(t, err)
+	decoded, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	msrc = decoded.Init.Moov.GetTrackGroups(TrackGroupMsrc)
+	if len(msrc[7]) != 3 {
+		t.Errorf("got msrc groups %v after decode", msrc)
+	}
+}
@@ -0,0 +1,424 @@
+package mp4
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"unicode/utf16"
+)
+
+// Boxes for 3GPP timed text according to 3GPP TS 26.245
+
+// Tx3gBoxRecord - text box position in pixels relative to the track
+type Tx3gBoxRecord struct {
+	Top    int16
+	Left   int16
+	Bottom int16
+	Right  int16
+}
+
+// Tx3gStyleRecord - style for a range of characters
+type Tx3gStyleRecord struct {
+	StartChar      uint16
+	EndChar        uint16
+	FontID         uint16
+	FaceStyleFlags byte // bold=1, italic=2, underline=4
+	FontSize       byte
+	TextColorRGBA  [4]byte
+}
+
+func decodeTx3gStyleRecord(s *SliceReader) Tx3gStyleRecord {
+	sr := Tx3gStyleRecord{}
+	sr.StartChar = s.ReadUint16()
+	sr.EndChar = s.ReadUint16()
+	sr.FontID = s.ReadUint16()
+	sr.FaceStyleFlags = s.ReadUint8()
+	sr.FontSize = s.ReadUint8()
+	copy(sr.TextColorRGBA[:], s.ReadBytes(4))
+	return sr
+}
+
+func (sr Tx3gStyleRecord) encode(sw *SliceWriter) {
+	sw.WriteUint16(sr.StartChar)
+	sw.WriteUint16(sr.EndChar)
+	sw.WriteUint16(sr.FontID)
+	sw.WriteUint8(sr.FaceStyleFlags)
+	sw.WriteUint8(sr.FontSize)
+	sw.WriteBytes(sr.TextColorRGBA[:])
+}
+
+////////////////////////////// tx3g //////////////////////////////
+
+// Tx3gBox - 3GPP TextSampleEntry (tx3g)
+type Tx3gBox struct {
+	DataReferenceIndex      uint16
+	DisplayFlags            uint32
+	HorizontalJustification int8
+	VerticalJustification   int8
+	BackgroundColorRGBA     [4]byte
+	DefaultTextBox          Tx3gBoxRecord
+	DefaultStyle            Tx3gStyleRecord
+	Ftab                    *FtabBox
+	Btrt                    *BtrtBox
+	Children                []Box
+}
+
+// CreateTx3g - create tx3g sample entry with one font and white text of fontSize on transparent background
+func CreateTx3g(fontName string, fontSize byte) *Tx3gBox {
+	b := &Tx3gBox{
+		DataReferenceIndex: 1,
+		DefaultStyle: Tx3gStyleRecord{
+			FontID:        1,
+			FontSize:      fontSize,
+			TextColorRGBA: [4]byte{0xff, 0xff, 0xff, 0xff},
+		},
+	}
+	b.AddChild(&FtabBox{Fonts: []FontRecord{{FontID: 1, FontName: fontName}}})
+	return b
+}
+
+// AddChild - add a child box
+func (b *Tx3gBox) AddChild(child Box) {
+	switch box := child.(type) {
+	case *FtabBox:
+		b.Ftab = box
+	case *BtrtBox:
+		b.Btrt = box
+	}
+	b.Children = append(b.Children, child)
+}
+
+const nrTx3gBytesBeforeChildren = boxHeaderSize + 8 + 30
+
+// DecodeTx3g - Decode 3GPP TextSampleEntry (tx3g)
+func DecodeTx3g(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < nrTx3gBytesBeforeChildren-boxHeaderSize {
+		return nil, fmt.Errorf("tx3g: too short %d bytes", len(data))
+	}
+	b := &Tx3gBox{}
+	s := NewSliceReader(data)
+	s.SkipBytes(6) // Skip 6 reserved bytes
+	b.DataReferenceIndex = s.ReadUint16()
+	b.DisplayFlags = s.ReadUint32()
+	b.HorizontalJustification = int8(s.ReadUint8())
+	b.VerticalJustification = int8(s.ReadUint8())
+	copy(b.BackgroundColorRGBA[:], s.ReadBytes(4))
+	b.DefaultTextBox.Top = s.ReadInt16()
+	b.DefaultTextBox.Left = s.ReadInt16()
+	b.DefaultTextBox.Bottom = s.ReadInt16()
+	b.DefaultTextBox.Right = s.ReadInt16()
+	b.DefaultStyle = decodeTx3gStyleRecord(s)
+
+	pos := startPos + nrTx3gBytesBeforeChildren
+	restReader := bytes.NewReader(s.RemainingBytes())
+	for pos < startPos+hdr.size {
+		box, err := DecodeBox(pos, restReader)
+		if err != nil {
+			return nil, err
+		}
+		b.AddChild(box)
+		pos += box.Size()
+	}
+	if pos != startPos+hdr.size {
+		return nil, errors.New("Bad size in tx3g")
+	}
+	return b, nil
+}
+
+// Type - return box type
+func (b *Tx3gBox) Type() string {
+	return "tx3g"
+}
+
+// Size - return calculated size
+func (b *Tx3gBox) Size() uint64 {
+	totalSize := uint64(nrTx3gBytesBeforeChildren)
+	for _, child := range b.Children {
+		totalSize += child.Size()
+	}
+	return totalSize
+}
+
+// GetChildren - list of child boxes
+func (b *Tx3gBox) GetChildren() []Box {
+	return b.Children
+}
+
+func (b *Tx3gBox) setChildren(children []Box) error {
+	n := Tx3gBox{DataReferenceIndex: b.DataReferenceIndex, DisplayFlags: b.DisplayFlags,
+		HorizontalJustification: b.HorizontalJustification, VerticalJustification: b.VerticalJustification,
+		BackgroundColorRGBA: b.BackgroundColorRGBA, DefaultTextBox: b.DefaultTextBox, DefaultStyle: b.DefaultStyle}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
+// Encode - write box to w
+func (b *Tx3gBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, nrTx3gBytesBeforeChildren-boxHeaderSize)
+	sw := NewSliceWriter(buf)
+	sw.WriteZeroBytes(6)
+	sw.WriteUint16(b.DataReferenceIndex)
+	sw.WriteUint32(b.DisplayFlags)
+	sw.WriteUint8(byte(b.HorizontalJustification))
+	sw.WriteUint8(byte(b.VerticalJustification))
+	sw.WriteBytes(b.BackgroundColorRGBA[:])
+	sw.WriteInt16(b.DefaultTextBox.Top)
+	sw.WriteInt16(b.DefaultTextBox.Left)
+	sw.WriteInt16(b.DefaultTextBox.Bottom)
+	sw.WriteInt16(b.DefaultTextBox.Right)
+	b.DefaultStyle.encode(sw)
+	_, err = w.Write(buf)
+	if err != nil {
+		return err
+	}
+	for _, child := range b.Children {
+		err = child.Encode(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Info - write box-specific information
+func (b *Tx3gBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	bd.write(" - dataReferenceIndex: %d", b.DataReferenceIndex)
+	bd.write(" - displayFlags: %08x", b.DisplayFlags)
+	bd.write(" - justification: horizontal=%d vertical=%d", b.HorizontalJustification, b.VerticalJustification)
+	bd.write(" - backgroundColorRGBA: %v", b.BackgroundColorRGBA)
+	tb := b.DefaultTextBox
+	bd.write(" - defaultTextBox: top=%d left=%d bottom=%d right=%d", tb.Top, tb.Left, tb.Bottom, tb.Right)
+	st := b.DefaultStyle
+	bd.write(" - defaultStyle: fontID=%d faceStyleFlags=%d fontSize=%d textColorRGBA=%v",
+		st.FontID, st.FaceStyleFlags, st.FontSize, st.TextColorRGBA)
+	if bd.err != nil {
+		return bd.err
+	}
+	for _, child := range b.Children {
+		err := child.Info(w, specificBoxLevels, indent+indentStep, indentStep)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+////////////////////////////// ftab //////////////////////////////
+
+// FontRecord - font in FontTableBox
+type FontRecord struct {
+	FontID   uint16
+	FontName string
+}
+
+// FtabBox - 3GPP FontTableBox (ftab)
+type FtabBox struct {
+	Fonts []FontRecord
+}
+
+// DecodeFtab - box-specific decode
+func DecodeFtab(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 {
+		return nil, fmt.Errorf("ftab: too short %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	b := &FtabBox{}
+	entryCount := int(s.ReadUint16())
+	for i := 0; i < entryCount; i++ {
+		if s.NrRemainingBytes() < 3 {
+			return nil, fmt.Errorf("ftab: too short for %d fonts", entryCount)
+		}
+		fontID := s.ReadUint16()
+		nameLen := int(s.ReadUint8())
+		if s.NrRemainingBytes() < nameLen {
+			return nil, fmt.Errorf("ftab: font name length %d beyond box", nameLen)
+		}
+		b.Fonts = append(b.Fonts, FontRecord{FontID: fontID, FontName: string(s.ReadBytes(nameLen))})
+	}
+	return b, nil
+}
+
+// Type - return box type
+func (b *FtabBox) Type() string {
+	return "ftab"
+}
+
+// Size - return calculated size
+func (b *FtabBox) Size() uint64 {
+	size := uint64(boxHeaderSize + 2)
+	for _, f := range b.Fonts {
+		size += 3 + uint64(len(f.FontName))
+	}
+	return size
+}
+
+// Encode - write box to w
+func (b *FtabBox) Encode(w io.Writer) error {
+	for _, f := range b.Fonts {
+		if len(f.FontName) > 255 {
+			return fmt.Errorf("ftab: font name %q longer than 255 bytes", f.FontName)
+		}
+	}
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint16(uint16(len(b.Fonts)))
+	for _, f := range b.Fonts {
+		sw.WriteUint16(f.FontID)
+		sw.WriteUint8(byte(len(f.FontName)))
+		sw.WriteBytes([]byte(f.FontName))
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *FtabBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	for _, f := range b.Fonts {
+		bd.write(" - font: id=%d name=%q", f.FontID, f.FontName)
+	}
+	return bd.err
+}
+
+////////////////////////////// styl //////////////////////////////
+
+// StylBox - 3GPP TextStyleBox (styl) sample modifier with style records for character ranges
+type StylBox struct {
+	Entries []Tx3gStyleRecord
+}
+
+// DecodeStyl - box-specific decode
+func DecodeStyl(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 {
+		return nil, fmt.Errorf("styl: too short %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	entryCount := int(s.ReadUint16())
+	if s.NrRemainingBytes() != 12*entryCount {
+		return nil, fmt.Errorf("styl: %d bytes left for %d style records", s.NrRemainingBytes(), entryCount)
+	}
+	b := &StylBox{}
+	for i := 0; i < entryCount; i++ {
+		b.Entries = append(b.Entries, decodeTx3gStyleRecord(s))
+	}
+	return b, nil
+}
+
+// Type - return box type
+func (b *StylBox) Type() string {
+	return "styl"
+}
+
+// Size - return calculated size
+func (b *StylBox) Size() uint64 {
+	return uint64(boxHeaderSize + 2 + 12*len(b.Entries))
+}
+
+// Encode - write box to w
+func (b *StylBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	sw.WriteUint16(uint16(len(b.Entries)))
+	for _, e := range b.Entries {
+		e.encode(sw)
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write box-specific information
+func (b *StylBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, -1, 0)
+	for _, e := range b.Entries {
+		bd.write(" - style: chars=%d-%d fontID=%d faceStyleFlags=%d fontSize=%d textColorRGBA=%v",
+			e.StartChar, e.EndChar, e.FontID, e.FaceStyleFlags, e.FontSize, e.TextColorRGBA)
+	}
+	return bd.err
+}
+
+////////////////////////////// samples //////////////////////////////
+
+// Tx3gSample - 3GPP timed text sample with text and trailing modifier boxes (styl, hlit, etc)
+type Tx3gSample struct {
+	Text      string
+	Modifiers []Box
+}
+
+// DecodeTx3gSample - decode 3GPP timed text sample. Text in UTF-16 (with BOM) is converted to UTF-8.
+func DecodeTx3gSample(data []byte) (*Tx3gSample, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("tx3g sample too short: %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	textLen := int(s.ReadUint16())
+	if textLen > s.NrRemainingBytes() {
+		return nil, fmt.Errorf("tx3g sample text length %d beyond sample", textLen)
+	}
+	text := s.ReadBytes(textLen)
+	sample := &Tx3gSample{Text: string(text)}
+	if len(text) >= 2 && text[0] == 0xfe && text[1] == 0xff {
+		u16 := make([]uint16, 0, (len(text)-2)/2)
+		for i := 2; i+1 < len(text); i += 2 {
+			u16 = append(u16, uint16(text[i])<<8|uint16(text[i+1]))
+		}
+		sample.Text = string(utf16.Decode(u16))
+	}
+	pos := uint64(s.GetPos())
+	r := bytes.NewReader(s.RemainingBytes())
+	for r.Len() > 0 {
+		box, err := DecodeBox(pos, r)
+		if err != nil {
+			return nil, err
+		}
+		sample.Modifiers = append(sample.Modifiers, box)
+		pos += box.Size()
+	}
+	return sample, nil
+}
+
+// Encode - encode sample with text in UTF-8 followed by modifier boxes
+func (t *Tx3gSample) Encode() ([]byte, error) {
+	if len(t.Text) > 0xffff {
+		return nil, fmt.Errorf("tx3g sample text length %d too large", len(t.Text))
+	}
+	buf := bytes.Buffer{}
+	buf.Write([]byte{byte(len(t.Text) >> 8), byte(len(t.Text))})
+	buf.WriteString(t.Text)
+	for _, m := range t.Modifiers {
+		err := m.Encode(&buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
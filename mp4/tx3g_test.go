@@ -0,0 +1,57 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTx3g(t *testing.T) {
+	tx3g := CreateTx3g("Serif", 18)
+	tx3g.DefaultTextBox = Tx3gBoxRecord{Top: 200, Left: 0, Bottom: 240, Right: 320}
+	tx3g.VerticalJustification = -1
+	tx3g.AddChild(&BtrtBox{})
+	if tx3g.Ftab == nil || tx3g.Btrt == nil {
+		t.Error("typed child fields not set")
+	}
+	boxDiffAfterEncodeAndDecode(t, tx3g)
+	boxDiffAfterEncodeAndDecode(t, &StylBox{Entries: []Tx3gStyleRecord{{StartChar: 0, EndChar: 5, FontID: 1,
+		FaceStyleFlags: 2, FontSize: 18, TextColorRGBA: [4]byte{255, 255, 0, 255}}}})
+}
+
+func TestTx3gSample(t *testing.T) {
+	styl := &StylBox{Entries: []Tx3gStyleRecord{{EndChar: 5, FontID: 1, FaceStyleFlags: 1, FontSize: 18}}}
+	sample := &Tx3gSample{Text: "Hello world", Modifiers: []Box{styl}}
+	data, err := sample.Encode()
+	assertNoError(t, err)
+	decSample, err := DecodeTx3gSample(data)
+	assertNoError(t, err)
+	if decSample.Text != sample.Text || len(decSample.Modifiers) != 1 || decSample.Modifiers[0].Type() != "styl" {
+		t.Errorf("got %q with %d modifiers", decSample.Text, len(decSample.Modifiers))
+	}
+
+	utf16Data := []byte{0x00, 0x06, 0xfe, 0xff, 0x00, 'H', 0x00, 'i'}
+	decSample, err = DecodeTx3gSample(utf16Data)
+	assertNoError(t, err)
+	if decSample.Text != "Hi" {
+		t.Errorf("got %q instead of Hi from UTF-16", decSample.Text)
+	}
+	_, err = DecodeTx3gSample([]byte{0x00, 0x10, 'a'})
+	assertError(t, err, "text length beyond sample should give error")
+
+	// Empty sample signals no text
+	decSample, err = DecodeTx3gSample([]byte{0x00, 0x00})
+	assertNoError(t, err)
+	if decSample.Text != "" {
+		t.Errorf("got %q for empty sample", decSample.Text)
+	}
+	buf := bytes.Buffer{}
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(1000, "text", "en")
+	init.Moov.Trak.Mdia.Minf.Stbl.Stsd.AddChild(CreateTx3g("Serif", 18))
+	assertNoError(t, init.Encode(&buf))
+	decFile, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	if decFile.Moov.Trak.Kind() != "subtitle" {
+		t.Errorf("tx3g track kind is %q", decFile.Moov.Trak.Kind())
+	}
+}
@@ -7,11 +7,15 @@ import "io"
 // Contained in : moov, trak, moof, or traf
 //
 type UdtaBox struct {
+	Meta     *MetaBox
 	Children []Box
 }
 
 // AddChild - Add a child box
 func (b *UdtaBox) AddChild(box Box) {
+	if meta, ok := box.(*MetaBox); ok {
+		b.Meta = meta
+	}
 	b.Children = append(b.Children, box)
 }
 
@@ -43,6 +47,15 @@ func (b *UdtaBox) GetChildren() []Box {
 	return b.Children
 }
 
+func (b *UdtaBox) setChildren(children []Box) error {
+	n := UdtaBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - write udta container to w
 func (b *UdtaBox) Encode(w io.Writer) error {
 	return EncodeContainer(b, w)
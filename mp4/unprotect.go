@@ -0,0 +1,167 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// SampleEntryRestorer - restore a protected sample entry in place to its original format given by frma.
+// The sinf box is removed from the entry and returned.
+type SampleEntryRestorer func(entry Box) (*SinfBox, error)
+
+// protectedSampleEntries - registry of protected sample entry types and how to restore their original format
+var protectedSampleEntries = map[string]SampleEntryRestorer{
+	"encv": restoreVisualSampleEntry,
+	"enca": restoreAudioSampleEntry,
+	"enct": restoreTextSampleEntry,
+	"encs": restoreUndecodedSampleEntry, // System sample entries are not decoded
+}
+
+// protectedTextFormats - decoders for enct sample entries by original format. The decoded entry keeps
+// the enct type and the sinf box, so that it can be restored by the enct restorer.
+var protectedTextFormats = map[string]BoxDecoder{
+	"wvtt": DecodeWvtt,
+	"stpp": DecodeStpp,
+}
+
+// RegisterProtectedTextFormat - register decoder for enct sample entries with originalFormat in frma.
+// A restorer for the decoded entry type is set with RegisterProtectedSampleEntry("enct", restorer).
+func RegisterProtectedTextFormat(originalFormat string, decoder BoxDecoder) {
+	protectedTextFormats[originalFormat] = decoder
+}
+
+// RegisterProtectedSampleEntry - register restorer for a protected sample entry type.
+// An already registered type is overwritten.
+func RegisterProtectedSampleEntry(protectedType string, restorer SampleEntryRestorer) {
+	protectedSampleEntries[protectedType] = restorer
+}
+
+// IsProtectedSampleEntry - true if boxType is a registered protected sample entry type
+func IsProtectedSampleEntry(boxType string) bool {
+	_, ok := protectedSampleEntries[boxType]
+	return ok
+}
+
+// RestoreOriginalFormat - restore protected sample entry to its original format using the registry.
+// The removed sinf box is returned.
+func RestoreOriginalFormat(entry Box) (*SinfBox, error) {
+	restorer, ok := protectedSampleEntries[entry.Type()]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a registered protected sample entry type", entry.Type())
+	}
+	return restorer(entry)
+}
+
+// RestoreOriginalFormats - restore all protected sample entries in stsd to their original formats.
+// The removed sinf boxes are returned in sample entry order.
+func (s *StsdBox) RestoreOriginalFormats() ([]*SinfBox, error) {
+	var sinfs []*SinfBox
+	for _, c := range s.Children {
+		if !IsProtectedSampleEntry(c.Type()) {
+			continue
+		}
+		sinf, err := RestoreOriginalFormat(c)
+		if err != nil {
+			return nil, err
+		}
+		sinfs = append(sinfs, sinf)
+	}
+	// Update typed fields which depend on the sample entry type
+	n := &StsdBox{Version: s.Version, Flags: s.Flags}
+	for _, c := range s.Children {
+		n.AddChild(c)
+	}
+	*s = *n
+	return sinfs, nil
+}
+
+func restoreVisualSampleEntry(entry Box) (*SinfBox, error) {
+	b, ok := entry.(*VisualSampleEntryBox)
+	if !ok || b.Sinf == nil || b.Sinf.Frma == nil {
+		return nil, fmt.Errorf("%s: no visual sample entry with sinf and frma", entry.Type())
+	}
+	sinf := b.Sinf
+	b.name = sinf.Frma.DataFormat
+	b.Children = removeBox(b.Children, sinf)
+	b.Sinf = nil
+	return sinf, nil
+}
+
+func restoreAudioSampleEntry(entry Box) (*SinfBox, error) {
+	b, ok := entry.(*AudioSampleEntryBox)
+	if !ok || b.Sinf == nil || b.Sinf.Frma == nil {
+		return nil, fmt.Errorf("%s: no audio sample entry with sinf and frma", entry.Type())
+	}
+	sinf := b.Sinf
+	b.name = sinf.Frma.DataFormat
+	b.Children = removeBox(b.Children, sinf)
+	b.Sinf = nil
+	return sinf, nil
+}
+
+func restoreTextSampleEntry(entry Box) (*SinfBox, error) {
+	switch b := entry.(type) {
+	case *WvttBox:
+		if b.Sinf != nil && b.Sinf.Frma != nil {
+			sinf := b.Sinf
+			b.name = sinf.Frma.DataFormat
+			b.Children = removeBox(b.Children, sinf)
+			b.Sinf = nil
+			return sinf, nil
+		}
+	case *StppBox:
+		if b.Sinf != nil && b.Sinf.Frma != nil {
+			sinf := b.Sinf
+			b.name = sinf.Frma.DataFormat
+			b.Children = removeBox(b.Children, sinf)
+			b.Sinf = nil
+			return sinf, nil
+		}
+	case *UnknownBox:
+		return restoreUndecodedSampleEntry(b)
+	}
+	return nil, fmt.Errorf("%s: no text sample entry with sinf and frma", entry.Type())
+}
+
+// restoreUndecodedSampleEntry - remove sinf from the payload of a sample entry kept as UnknownBox.
+// The sinf box is found as a box starting with frma, and the entry is renamed to the frma data format.
+func restoreUndecodedSampleEntry(entry Box) (*SinfBox, error) {
+	b, ok := entry.(*UnknownBox)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an undecoded sample entry", entry.Type())
+	}
+	start, end := findSinf(b.notDecoded)
+	if start < 0 {
+		return nil, fmt.Errorf("%s: no sinf box with frma found", entry.Type())
+	}
+	box, err := DecodeBox(0, bytes.NewReader(b.notDecoded[start:end]))
+	if err != nil {
+		return nil, err
+	}
+	sinf := box.(*SinfBox)
+	if sinf.Frma == nil {
+		return nil, fmt.Errorf("%s: sinf without frma", entry.Type())
+	}
+	payload := make([]byte, 0, len(b.notDecoded)-(end-start))
+	payload = append(payload, b.notDecoded[:start]...)
+	payload = append(payload, b.notDecoded[end:]...)
+	b.name = sinf.Frma.DataFormat
+	b.notDecoded = payload
+	b.size -= uint64(end - start)
+	return sinf, nil
+}
+
+// findSinf - find start and end of a sinf box with frma as first child in data. Returns -1, -1 if not found.
+func findSinf(data []byte) (start, end int) {
+	for i := 0; i+20 <= len(data); i++ {
+		if string(data[i+4:i+8]) != "sinf" || string(data[i+12:i+16]) != "frma" {
+			continue
+		}
+		size := int(binary.BigEndian.Uint32(data[i : i+4]))
+		if size >= 20 && i+size <= len(data) {
+			return i, i + size
+		}
+	}
+	return -1, -1
+}
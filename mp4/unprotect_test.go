@@ -0,0 +1,60 @@
+package mp4
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestRestoreOriginalFormats(t *testing.T) {
+	fd, err := os.Open("testdata/init_cenc.cmfv")
+	assertNoError(t, err)
+	defer fd.Close()
+	f, err := DecodeFile(fd)
+	assertNoError(t, err)
+	stsd := f.Moov.Trak.Mdia.Minf.Stbl.Stsd
+	sinfs, err := stsd.RestoreOriginalFormats()
+	assertNoError(t, err)
+	if len(sinfs) != 1 || sinfs[0].Frma.DataFormat != "avc3" {
+		t.Fatalf("did not get one sinf with avc3 original format")
+	}
+	avc := stsd.GetAVC()
+	if avc == nil || avc.Type() != "avc3" || avc.Sinf != nil || stsd.AvcX != avc {
+		t.Errorf("encv not restored to avc3")
+	}
+	if entry, _ := stsd.GetEncrypted(); entry != nil {
+		t.Errorf("got protected entry after restoration")
+	}
+	_, err = RestoreOriginalFormat(avc)
+	assertError(t, err, "restoring avc3 should give error")
+}
+
+func TestRestoreUndecodedSampleEntry(t *testing.T) {
+	sinf := createSinf("mett", SchemeCENC, &TencBox{DefaultIsProtected: 1, DefaultPerSampleIVSize: 8,
+		DefaultKID: UUID(make([]byte, 16))})
+	sinfBuf := bytes.Buffer{}
+	assertNoError(t, sinf.Encode(&sinfBuf))
+	fields := []byte{0, 0, 0, 0, 0, 0, 0, 1, 't', 'e', 'x', 't', '/', 'x', 'm', 'l', 0}
+	payload := append(append([]byte{}, fields...), sinfBuf.Bytes()...)
+	entryBuf := bytes.Buffer{}
+	assertNoError(t, EncodeHeaderWithSize("encs", uint64(boxHeaderSize+len(payload)), false, &entryBuf))
+	entryBuf.Write(payload)
+	entry, err := DecodeBox(0, &entryBuf)
+	assertNoError(t, err)
+
+	stsd := NewStsdBox()
+	stsd.AddChild(entry)
+	if e, origFormat := stsd.GetEncrypted(); e != entry || origFormat != "mett" {
+		t.Errorf("got original format %q instead of mett", origFormat)
+	}
+	restoredSinf, err := RestoreOriginalFormat(entry)
+	assertNoError(t, err)
+	if restoredSinf.Frma.DataFormat != "mett" || entry.Type() != "mett" || entry.Size() != uint64(boxHeaderSize+len(fields)) {
+		t.Errorf("encs not restored to mett, got %s with size %d", entry.Type(), entry.Size())
+	}
+	buf := bytes.Buffer{}
+	assertNoError(t, entry.Encode(&buf))
+	if !bytes.Equal(buf.Bytes()[boxHeaderSize:], fields) {
+		t.Errorf("restored payload differs from original fields")
+	}
+}
@@ -7,7 +7,7 @@ import (
 
 // URLBox - DataEntryUrlBox ('url ')
 //
-// Contained in : DrefBox (dref
+// Contained in : DrefBox (dref)
 type URLBox struct {
 	Version  byte
 	Flags    uint32
@@ -27,7 +27,7 @@ func DecodeURLBox(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	version := byte(versionAndFlags >> 24)
 	flags := versionAndFlags & flagsMask
 	location := ""
-	if flags != dataIsSelfContainedFlag {
+	if flags&dataIsSelfContainedFlag == 0 {
 		location, err = s.ReadZeroTerminatedString()
 	}
 
@@ -48,6 +48,20 @@ func CreateURLBox() *URLBox {
 	}
 }
 
+// CreateExternalURLBox - Create a URL box referring to media data at location
+func CreateExternalURLBox(location string) *URLBox {
+	return &URLBox{
+		Version:  0,
+		Flags:    0,
+		Location: location,
+	}
+}
+
+// IsSelfContained - true if media data is in the same file as the movie box
+func (u *URLBox) IsSelfContained() bool {
+	return u.Flags&dataIsSelfContainedFlag != 0
+}
+
 // Type - return box type
 func (u *URLBox) Type() string {
 	return "url "
@@ -56,7 +70,7 @@ func (u *URLBox) Type() string {
 // Size - return calculated size
 func (u *URLBox) Size() uint64 {
 	size := uint64(boxHeaderSize + 4)
-	if u.Flags != uint32(dataIsSelfContainedFlag) {
+	if !u.IsSelfContained() {
 		size += uint64(len(u.Location) + 1)
 	}
 	return size
@@ -72,7 +86,7 @@ func (u *URLBox) Encode(w io.Writer) error {
 	sw := NewSliceWriter(buf)
 	versionAndFlags := (uint32(u.Version) << 24) + u.Flags
 	sw.WriteUint32(versionAndFlags)
-	if u.Flags != dataIsSelfContainedFlag {
+	if !u.IsSelfContained() {
 		sw.WriteString(u.Location, true)
 	}
 	_, err = w.Write(buf)
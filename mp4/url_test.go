@@ -13,4 +13,17 @@ func TestUrl(t *testing.T) {
 	}
 
 	boxDiffAfterEncodeAndDecode(t, urlBox)
+
+	selfContained := CreateURLBox()
+	if !selfContained.IsSelfContained() {
+		t.Errorf("url box should be self-contained")
+	}
+	boxDiffAfterEncodeAndDecode(t, selfContained)
+
+	// Other flag bits should not affect self-containment
+	otherFlags := &URLBox{Flags: 0x000002, Location: "http://example.com/media.mp4"}
+	if otherFlags.IsSelfContained() {
+		t.Errorf("url box should not be self-contained")
+	}
+	boxDiffAfterEncodeAndDecode(t, otherFlags)
 }
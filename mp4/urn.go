@@ -0,0 +1,104 @@
+package mp4
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// URNBox - DataEntryUrnBox ('urn ')
+//
+// Contained in : DrefBox (dref)
+type URNBox struct {
+	Version  byte
+	Flags    uint32
+	Name     string // Zero-terminated string
+	Location string // Zero-terminated string. Optional
+}
+
+// DecodeURNBox - box-specific decode
+func DecodeURNBox(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
+	u := &URNBox{
+		Version: byte(versionAndFlags >> 24),
+		Flags:   versionAndFlags & flagsMask,
+	}
+	if u.IsSelfContained() {
+		return u, nil
+	}
+	u.Name, err = s.ReadZeroTerminatedString()
+	if err != nil {
+		return nil, err
+	}
+	if s.NrRemainingBytes() > 0 {
+		u.Location, err = s.ReadZeroTerminatedString()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// CreateURNBox - Create a URN box referring to media data with name and optional location
+func CreateURNBox(name, location string) *URNBox {
+	return &URNBox{
+		Version:  0,
+		Flags:    0,
+		Name:     name,
+		Location: location,
+	}
+}
+
+// IsSelfContained - true if media data is in the same file as the movie box
+func (u *URNBox) IsSelfContained() bool {
+	return u.Flags&dataIsSelfContainedFlag != 0
+}
+
+// Type - return box type
+func (u *URNBox) Type() string {
+	return "urn "
+}
+
+// Size - return calculated size
+func (u *URNBox) Size() uint64 {
+	size := uint64(boxHeaderSize + 4)
+	if !u.IsSelfContained() {
+		size += uint64(len(u.Name) + 1)
+		if u.Location != "" {
+			size += uint64(len(u.Location) + 1)
+		}
+	}
+	return size
+}
+
+// Encode - write box to w
+func (u *URNBox) Encode(w io.Writer) error {
+	err := EncodeHeader(u, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(u)
+	sw := NewSliceWriter(buf)
+	versionAndFlags := (uint32(u.Version) << 24) + u.Flags
+	sw.WriteUint32(versionAndFlags)
+	if !u.IsSelfContained() {
+		sw.WriteString(u.Name, true)
+		if u.Location != "" {
+			sw.WriteString(u.Location, true)
+		}
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - write specific box information
+func (u *URNBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, u, int(u.Version), u.Flags)
+	bd.write(" - name: %q", u.Name)
+	bd.write(" - location: %q", u.Location)
+	return bd.err
+}
@@ -0,0 +1,11 @@
+package mp4
+
+import (
+	"testing"
+)
+
+func TestUrn(t *testing.T) {
+	boxDiffAfterEncodeAndDecode(t, CreateURNBox("urn:example:media", "http://example.com/media.mp4"))
+	boxDiffAfterEncodeAndDecode(t, CreateURNBox("urn:example:media", ""))
+	boxDiffAfterEncodeAndDecode(t, &URNBox{Flags: dataIsSelfContainedFlag})
+}
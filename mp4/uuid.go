@@ -2,6 +2,7 @@ package mp4
 
 import (
 	"encoding/hex"
+	"fmt"
 	"io"
 	"io/ioutil"
 )
@@ -17,6 +18,7 @@ type UUIDBox struct {
 	SubType string
 	Tfxd    *TfxdData
 	Tfrf    *TfrfData
+	Payload []byte // Data after UUID for other subtypes
 }
 
 // TfxdData - MSS TfxdBox data after UUID part
@@ -38,12 +40,44 @@ type TfrfData struct {
 	FragmentAbsoluteDurations []uint64
 }
 
+// NewTfxdBox - create a uuid box with MSS tfxd data, using version 1 if needed for 64-bit values
+func NewTfxdBox(absTime, absDur uint64) *UUIDBox {
+	tfxd := &TfxdData{FragmentAbsoluteTime: absTime, FragmentAbsoluteDuration: absDur}
+	if absTime > 0xffffffff || absDur > 0xffffffff {
+		tfxd.Version = 1
+	}
+	return &UUIDBox{UUID: uuidTfxd, SubType: "tfxd", Tfxd: tfxd}
+}
+
+// NewTfrfBox - create a uuid box with MSS tfrf data for the following fragments,
+// using version 1 if needed for 64-bit values
+func NewTfrfBox(absTimes, absDurs []uint64) (*UUIDBox, error) {
+	if len(absTimes) != len(absDurs) || len(absTimes) > 255 {
+		return nil, fmt.Errorf("tfrf: %d times and %d durations, must be equal and at most 255",
+			len(absTimes), len(absDurs))
+	}
+	tfrf := &TfrfData{
+		FragmentCount:             byte(len(absTimes)),
+		FragmentAbsoluteTimes:     absTimes,
+		FragmentAbsoluteDurations: absDurs,
+	}
+	for i := range absTimes {
+		if absTimes[i] > 0xffffffff || absDurs[i] > 0xffffffff {
+			tfrf.Version = 1
+		}
+	}
+	return &UUIDBox{UUID: uuidTfrf, SubType: "tfrf", Tfrf: tfrf}, nil
+}
+
 // DecodeUUIDBox - decode a UUID box including tfxd or tfrf
 func DecodeUUIDBox(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	data, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
+	if len(data) < 16 {
+		return nil, fmt.Errorf("uuid box too short: %d bytes", len(data))
+	}
 	u := &UUIDBox{}
 	s := NewSliceReader(data)
 	u.UUID = string(s.ReadBytes(16))
@@ -63,8 +97,7 @@ func DecodeUUIDBox(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 		}
 		u.Tfrf = tfrf
 	default:
-		// err := fmt.Errorf("Unknown uuid=%s", u.UUID)
-		// return nil, err
+		u.Payload = s.RemainingBytes()
 	}
 
 	return u, err
@@ -83,6 +116,8 @@ func (u *UUIDBox) Size() uint64 {
 		size += u.Tfxd.size()
 	case "tfrf":
 		size += u.Tfrf.size()
+	default:
+		size += uint64(len(u.Payload))
 	}
 	return size
 }
@@ -94,10 +129,16 @@ func (u *UUIDBox) Encode(w io.Writer) error {
 		return err
 	}
 	_, err = w.Write([]byte(u.UUID))
-	if u.SubType == "tfxd" {
+	if err != nil {
+		return err
+	}
+	switch u.SubType {
+	case "tfxd":
 		err = u.Tfxd.encode(w)
-	} else if u.SubType == "tfrf" {
+	case "tfrf":
 		err = u.Tfrf.encode(w)
+	default:
+		_, err = w.Write(u.Payload)
 	}
 	return err
 }
@@ -69,3 +69,52 @@ func TestTfrf(t *testing.T) {
 		t.Error("Non-matching in and out binaries")
 	}
 }
+
+func TestNewTfxdTfrf(t *testing.T) {
+	tfxd := NewTfxdBox(1<<33, 20000000)
+	if tfxd.Tfxd.Version != 1 {
+		t.Errorf("got tfxd version %d instead of 1", tfxd.Tfxd.Version)
+	}
+	boxDiffAfterEncodeAndDecode(t, tfxd)
+	tfrf, err := NewTfrfBox([]uint64{100, 200}, []uint64{100, 100})
+	assertNoError(t, err)
+	if tfrf.Tfrf.Version != 0 || tfrf.Tfrf.FragmentCount != 2 {
+		t.Errorf("got tfrf %+v", tfrf.Tfrf)
+	}
+	boxDiffAfterEncodeAndDecode(t, tfrf)
+	_, err = NewTfrfBox([]uint64{100}, nil)
+	assertError(t, err, "different number of times and durations should fail")
+
+	other := &UUIDBox{UUID: "0123456789abcdef", Payload: []byte{1, 2, 3}}
+	boxDiffAfterEncodeAndDecode(t, other)
+}
+
+func TestConvertMSSTiming(t *testing.T) {
+	frag, err := CreateFragment(1, 1)
+	assertNoError(t, err)
+	for _, s := range createProgTestSamples(4, 400000, 100, 2, 0) {
+		frag.AddFullSample(s)
+	}
+	traf := frag.Moof.Traf
+	assertNoError(t, RemoveChild(traf, traf.Tfdt))
+	assertNoError(t, traf.AddChild(NewTfxdBox(123450000, 1600000)))
+	tfrf, err := NewTfrfBox([]uint64{125050000}, []uint64{1600000})
+	assertNoError(t, err)
+	assertNoError(t, traf.AddChild(tfrf))
+	frag.SetTrunDataOffsets()
+
+	assertNoError(t, frag.ConvertMSSTiming())
+	if traf.Tfdt == nil || traf.Tfdt.BaseMediaDecodeTime != 123450000 || traf.Children[1] != traf.Tfdt {
+		t.Fatalf("tfdt not set after tfhd")
+	}
+	if traf.Tfxd != nil || traf.Tfrf != nil || len(traf.Children) != 3 {
+		t.Errorf("uuid boxes not removed")
+	}
+	if wanted := int32(frag.Moof.Size() + frag.Mdat.HeaderSize()); traf.Trun.DataOffset != wanted {
+		t.Errorf("got data offset %d instead of %d", traf.Trun.DataOffset, wanted)
+	}
+
+	assertNoError(t, RemoveChild(traf, traf.Tfdt))
+	err = frag.ConvertMSSTiming()
+	assertError(t, err, "traf without timing should fail")
+}
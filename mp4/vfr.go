@@ -0,0 +1,182 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// FrameRateReport - sample duration statistics of a track, and the result of frame rate normalization
+type FrameRateReport struct {
+	NrSamples  int
+	MinDur     uint32
+	MaxDur     uint32
+	TotalDur   uint64
+	TargetDur  uint32 // Constant sample duration used for normalization
+	Normalized bool
+	MaxDrift   int64 // Largest difference between normalized and original decode time of a sample
+	EndDrift   int64 // Difference between normalized and original total duration
+}
+
+// IsVFR - true if sample durations vary
+func (r FrameRateReport) IsVFR() bool {
+	return r.MinDur != r.MaxDur
+}
+
+// AnalyzeFrameRate - get sample duration statistics from a list of durations
+func AnalyzeFrameRate(durs []uint32) FrameRateReport {
+	r := FrameRateReport{NrSamples: len(durs)}
+	for i, dur := range durs {
+		if i == 0 || dur < r.MinDur {
+			r.MinDur = dur
+		}
+		if dur > r.MaxDur {
+			r.MaxDur = dur
+		}
+		r.TotalDur += uint64(dur)
+	}
+	return r
+}
+
+// AnalyzeFrameRate - get sample duration statistics for track trackID in a progressive or fragmented file
+func (f *File) AnalyzeFrameRate(trackID uint32) (FrameRateReport, error) {
+	durs, err := f.sampleDurations(trackID)
+	if err != nil {
+		return FrameRateReport{}, err
+	}
+	return AnalyzeFrameRate(durs), nil
+}
+
+// NormalizeFrameRate - set all sample durations of track trackID to targetDur, given in track timescale.
+// If targetDur is 0, the average sample duration rounded to nearest integer is used.
+// If any duration differs more than tolerance from targetDur, an error is returned, and nothing is changed.
+// For progressive files, stts and the track and movie durations are updated.
+// For fragmented files, trun sample durations and tfdt are updated, while composition time offsets are kept.
+// sidx boxes are not updated.
+// The returned report tells how much the decode times drifted from the original ones.
+func (f *File) NormalizeFrameRate(trackID uint32, targetDur, tolerance uint32) (FrameRateReport, error) {
+	durs, err := f.sampleDurations(trackID)
+	if err != nil {
+		return FrameRateReport{}, err
+	}
+	r := AnalyzeFrameRate(durs)
+	if r.NrSamples == 0 {
+		return r, fmt.Errorf("no samples in track %d", trackID)
+	}
+	if targetDur == 0 {
+		targetDur = uint32((r.TotalDur + uint64(r.NrSamples)/2) / uint64(r.NrSamples))
+	}
+	r.TargetDur = targetDur
+	var origTime, newTime int64
+	for i, dur := range durs {
+		if absDiff(dur, targetDur) > tolerance {
+			return r, fmt.Errorf("sample %d duration %d differs more than %d from %d", i+1, dur, tolerance, targetDur)
+		}
+		origTime += int64(dur)
+		newTime += int64(targetDur)
+		drift := newTime - origTime
+		if drift < 0 {
+			drift = -drift
+		}
+		if drift > r.MaxDrift {
+			r.MaxDrift = drift
+		}
+	}
+	r.EndDrift = newTime - origTime
+	if f.isFragmented {
+		err = f.normalizeFragmentDurations(trackID, targetDur)
+	} else {
+		err = f.normalizeProgressiveDurations(trackID, targetDur)
+	}
+	if err != nil {
+		return r, err
+	}
+	r.Normalized = true
+	return r, nil
+}
+
+// sampleDurations - durations of all samples of trackID in decode order
+func (f *File) sampleDurations(trackID uint32) ([]uint32, error) {
+	if f.Moov == nil {
+		return nil, fmt.Errorf("no moov box")
+	}
+	trak := f.Moov.GetTrak(trackID)
+	if trak == nil {
+		return nil, fmt.Errorf("track %d not found", trackID)
+	}
+	var durs []uint32
+	if !f.isFragmented {
+		stts := trak.Mdia.Minf.Stbl.Stts
+		for i, count := range stts.SampleCount {
+			for j := uint32(0); j < count; j++ {
+				durs = append(durs, stts.SampleTimeDelta[i])
+			}
+		}
+		return durs, nil
+	}
+	trex := f.TrexForTrack(trackID)
+	for _, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			traf := frag.trafForTrack(trackID)
+			if traf == nil {
+				continue
+			}
+			for _, trun := range traf.Truns {
+				trun.AddSampleDefaultValues(traf.Tfhd, trex)
+				for _, s := range trun.Samples {
+					durs = append(durs, s.Dur)
+				}
+			}
+		}
+	}
+	return durs, nil
+}
+
+func (f *File) normalizeProgressiveDurations(trackID uint32, targetDur uint32) error {
+	trak := f.Moov.GetTrak(trackID)
+	stts := trak.Mdia.Minf.Stbl.Stts
+	stts.SampleCount = []uint32{stts.GetNrSamples()}
+	stts.SampleTimeDelta = []uint32{targetDur}
+	setProgDurations(f.Moov, trak)
+	var maxDur uint64
+	for _, t := range f.Moov.Traks {
+		if t.Tkhd.Duration > maxDur {
+			maxDur = t.Tkhd.Duration
+		}
+	}
+	f.Moov.Mvhd.Duration = maxDur
+	return nil
+}
+
+func (f *File) normalizeFragmentDurations(trackID uint32, targetDur uint32) error {
+	var timings []SampleTiming
+	trex := f.TrexForTrack(trackID)
+	for _, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			traf := frag.trafForTrack(trackID)
+			if traf == nil {
+				continue
+			}
+			for _, trun := range traf.Truns {
+				for _, s := range trun.Samples {
+					timings = append(timings, SampleTiming{Dur: targetDur, CompositionTimeOffset: s.CompositionTimeOffset})
+				}
+			}
+		}
+	}
+	retimer := NewSampleRetimer(trackID, timings)
+	for _, seg := range f.Segments {
+		for _, frag := range seg.Fragments {
+			err := retimer.RetimeMoof(frag.Moof, trex)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,90 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func createVFRTestSamples(nrSamples int) []FullSample {
+	samples := createProgTestSamples(nrSamples, 3003, 100, 10, 0x50)
+	var decTime uint64
+	for i := range samples {
+		samples[i].Dur = 3000 + uint32(i%3)*3 // 3000, 3003, 3006
+		samples[i].DecodeTime = decTime
+		decTime += uint64(samples[i].Dur)
+	}
+	return samples
+}
+
+func TestNormalizeFrameRateProgressive(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createVFRTestSamples(30)
+	f, err := CreateProgressiveFile(init, map[uint32][]FullSample{1: samples}, Interleaving{Mode: InterleaveBySize, ChunkSize: 1000})
+	assertNoError(t, err)
+
+	r, err := f.AnalyzeFrameRate(1)
+	assertNoError(t, err)
+	if !r.IsVFR() || r.MinDur != 3000 || r.MaxDur != 3006 || r.NrSamples != 30 {
+		t.Errorf("unexpected analysis %+v", r)
+	}
+	_, err = f.NormalizeFrameRate(1, 3003, 2)
+	assertError(t, err, "durations outside tolerance")
+
+	r, err = f.NormalizeFrameRate(1, 0, 3)
+	assertNoError(t, err)
+	if !r.Normalized || r.TargetDur != 3003 || r.MaxDrift != 3 || r.EndDrift != 0 {
+		t.Errorf("unexpected normalization %+v", r)
+	}
+	stts := f.Moov.Trak.Mdia.Minf.Stbl.Stts
+	if len(stts.SampleCount) != 1 || stts.SampleTimeDelta[0] != 3003 || stts.SampleCount[0] != 30 {
+		t.Errorf("stts not normalized: %v %v", stts.SampleCount, stts.SampleTimeDelta)
+	}
+	if f.Moov.Trak.Mdia.Mdhd.Duration != 30*3003 {
+		t.Errorf("mdhd duration %d", f.Moov.Trak.Mdia.Mdhd.Duration)
+	}
+	r, err = f.AnalyzeFrameRate(1)
+	assertNoError(t, err)
+	if r.IsVFR() {
+		t.Errorf("still VFR after normalization")
+	}
+}
+
+func TestNormalizeFrameRateFragmented(t *testing.T) {
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	samples := createVFRTestSamples(30)
+	samples[5].CompositionTimeOffset = 6006
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	for i := 0; i < 3; i++ {
+		frag, err := CreateFragment(uint32(i+1), 1)
+		assertNoError(t, err)
+		for _, s := range samples[i*10 : (i+1)*10] {
+			frag.AddFullSample(s)
+		}
+		assertNoError(t, frag.Encode(&buf))
+	}
+	f, err := DecodeFile(&buf)
+	assertNoError(t, err)
+
+	r, err := f.NormalizeFrameRate(1, 3003, 3)
+	assertNoError(t, err)
+	if !r.Normalized || r.MaxDrift != 3 {
+		t.Errorf("unexpected normalization %+v", r)
+	}
+	out := bytes.Buffer{}
+	assertNoError(t, f.Encode(&out))
+	decFile, err := DecodeFile(&out)
+	assertNoError(t, err)
+	outSamples, err := fragmentedTrackSamples(decFile, 1)
+	assertNoError(t, err)
+	for i, s := range outSamples {
+		if s.Dur != 3003 || s.DecodeTime != uint64(i)*3003 || s.CompositionTimeOffset != samples[i].CompositionTimeOffset {
+			t.Errorf("sample %d: dur %d time %d cto %d", i+1, s.Dur, s.DecodeTime, s.CompositionTimeOffset)
+		}
+		if !bytes.Equal(s.Data, samples[i].Data) {
+			t.Errorf("sample %d: data differs", i+1)
+		}
+	}
+}
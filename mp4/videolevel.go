@@ -0,0 +1,92 @@
+package mp4
+
+import (
+	"fmt"
+
+	"github.com/edgeware/mp4ff/avc"
+	"github.com/edgeware/mp4ff/hevc"
+)
+
+// ResignalVideoLevel - update size and level signaling of an AVC or HEVC track after a change of
+// picture size or frame rate, e.g. for a trick-play track.
+// tkhd and sample entry width and height are set, and the level is set to the lowest level that supports
+// the size and frame rate, both in avcC or hvcC and in the SPS (and VPS) NAL units they contain.
+// For HEVC, a non-zero AvgFrameRate is also updated. Parameter sets sent in-band in the samples are not changed.
+// A mismatch between the picture size in the SPS and the new size is returned as a warning.
+func (t *TrakBox) ResignalVideoLevel(width, height uint16, frameRate float64) (warnings []string, err error) {
+	stsd := t.Mdia.Minf.Stbl.Stsd
+	if vse := stsd.GetAVC(); vse != nil && vse.AvcC != nil {
+		level, err := avc.MinimumLevel(uint(width), uint(height), frameRate)
+		if err != nil {
+			return nil, err
+		}
+		avcC := vse.AvcC
+		for i, spsNalu := range avcC.SPSnalus {
+			sps, err := avc.ParseSPSNALUnit(spsNalu, false)
+			if err != nil {
+				return nil, err
+			}
+			warnings = append(warnings, checkSPSSize(sps.Width, sps.Height, width, height)...)
+			avcC.SPSnalus[i], err = avc.SetSPSLevel(spsNalu, level)
+			if err != nil {
+				return nil, err
+			}
+		}
+		avcC.AVCLevelIndication = byte(level)
+		if len(avcC.SPSnalus) > 0 {
+			avcC.ProfileCompatibility = avcC.SPSnalus[0][2]
+		}
+		t.setVideoSize(vse, width, height)
+		return warnings, nil
+	}
+	if vse := stsd.GetHEVC(); vse != nil && vse.HvcC != nil {
+		level, err := hevc.MinimumLevel(uint(width), uint(height), frameRate)
+		if err != nil {
+			return nil, err
+		}
+		hvcC := vse.HvcC
+		for _, array := range hvcC.NaluArrays {
+			for i, nalu := range array.Nalus {
+				switch array.NaluType() {
+				case hevc.NALU_VPS:
+					array.Nalus[i], err = hevc.SetVPSLevel(nalu, level)
+				case hevc.NALU_SPS:
+					var sps *hevc.SPS
+					sps, err = hevc.ParseSPSNALUnit(nalu)
+					if err != nil {
+						return nil, err
+					}
+					spsWidth, spsHeight := sps.ImageSize()
+					warnings = append(warnings, checkSPSSize(uint(spsWidth), uint(spsHeight), width, height)...)
+					array.Nalus[i], err = hevc.SetSPSLevel(nalu, level)
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		hvcC.GeneralLevelIDC = level
+		if hvcC.AvgFrameRate != 0 {
+			hvcC.AvgFrameRate = uint16(frameRate*256 + 0.5)
+		}
+		t.setVideoSize(vse, width, height)
+		return warnings, nil
+	}
+	return nil, fmt.Errorf("no AVC or HEVC sample entry with configuration box")
+}
+
+// checkSPSSize - warning for mismatch between picture size in SPS and signaled size
+func checkSPSSize(spsWidth, spsHeight uint, width, height uint16) []string {
+	if spsWidth != uint(width) || spsHeight != uint(height) {
+		return []string{fmt.Sprintf("SPS size %dx%d differs from signaled size %dx%d",
+			spsWidth, spsHeight, width, height)}
+	}
+	return nil
+}
+
+func (t *TrakBox) setVideoSize(vse *VisualSampleEntryBox, width, height uint16) {
+	vse.Width = width
+	vse.Height = height
+	t.Tkhd.Width = Fixed32(uint32(width) << 16)
+	t.Tkhd.Height = Fixed32(uint32(height) << 16)
+}
@@ -0,0 +1,67 @@
+package mp4
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/edgeware/mp4ff/avc"
+	"github.com/edgeware/mp4ff/hevc"
+)
+
+func TestResignalVideoLevel(t *testing.T) {
+	sps, _ := hex.DecodeString(sps1nalu)
+	pps, _ := hex.DecodeString(pps1nalu)
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	trak := init.Moov.Trak
+	assertNoError(t, trak.SetAVCDescriptor("avc1", [][]byte{sps}, [][]byte{pps}))
+
+	warnings, err := trak.ResignalVideoLevel(640, 360, 25)
+	assertNoError(t, err)
+	avcC := trak.Mdia.Minf.Stbl.Stsd.AvcX.AvcC
+	if len(warnings) != 0 || avcC.AVCLevelIndication != 30 {
+		t.Errorf("got level %d and warnings %v", avcC.AVCLevelIndication, warnings)
+	}
+	warnings, err = trak.ResignalVideoLevel(320, 180, 25)
+	assertNoError(t, err)
+	if len(warnings) != 1 || avcC.AVCLevelIndication != 12 {
+		t.Errorf("got level %d and warnings %v", avcC.AVCLevelIndication, warnings)
+	}
+	avcSPS, err := avc.ParseSPSNALUnit(avcC.SPSnalus[0], true)
+	assertNoError(t, err)
+	if avcSPS.Level != 12 || avcSPS.Width != 640 {
+		t.Errorf("got SPS level %d and width %d instead of 12 and 640", avcSPS.Level, avcSPS.Width)
+	}
+	if trak.Tkhd.Width != Fixed32(320<<16) || trak.Mdia.Minf.Stbl.Stsd.AvcX.Height != 180 {
+		t.Errorf("size not updated in tkhd and sample entry")
+	}
+
+	vps, _ := hex.DecodeString("40010c01ffff022000000300b0000003000003007b18b024")
+	hevcSPS, _ := hex.DecodeString("420101022000000300b0000003000003007ba0078200887db6718b92448053888892cf24a69272c9124922dc91aa48fca223ff000100016a02020201")
+	hevcPPS, _ := hex.DecodeString("4401c0252f053240")
+	init = CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	trak = init.Moov.Trak
+	assertNoError(t, trak.SetHEVCDescriptor("hvc1", [][]byte{vps}, [][]byte{hevcSPS}, [][]byte{hevcPPS}))
+	warnings, err = trak.ResignalVideoLevel(960, 540, 50)
+	assertNoError(t, err)
+	hvcC := trak.Mdia.Minf.Stbl.Stsd.HvcX.HvcC
+	if len(warnings) != 0 || hvcC.GeneralLevelIDC != 93 {
+		t.Errorf("got level %d and warnings %v", hvcC.GeneralLevelIDC, warnings)
+	}
+	hevcVPS, err := hevc.ParseVPSNALUnit(hvcC.GetNalusForType(hevc.NALU_VPS)[0])
+	assertNoError(t, err)
+	parsedSPS, err := hevc.ParseSPSNALUnit(hvcC.GetNalusForType(hevc.NALU_SPS)[0])
+	assertNoError(t, err)
+	if hevcVPS.ProfileTierLevel.GeneralLevelIDC != 93 || parsedSPS.ProfileTierLevel.GeneralLevelIDC != 93 {
+		t.Errorf("got VPS level %d and SPS level %d instead of 93", hevcVPS.ProfileTierLevel.GeneralLevelIDC,
+			parsedSPS.ProfileTierLevel.GeneralLevelIDC)
+	}
+	if w, h := parsedSPS.ImageSize(); w != 960 || h != 540 {
+		t.Errorf("SPS changed beyond level: size %dx%d", w, h)
+	}
+
+	init.AddEmptyTrack(48000, "audio", "und")
+	_, err = init.Moov.Traks[1].ResignalVideoLevel(640, 360, 25)
+	assertError(t, err, "track without video sample entry should give error")
+}
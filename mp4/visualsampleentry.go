@@ -19,9 +19,16 @@ type VisualSampleEntryBox struct {
 	CompressorName     string
 	AvcC               *AvcCBox
 	HvcC               *HvcCBox
+	Av1C               *Av1CBox
+	VpcC               *VpcCBox
+	DvcC               *DvcCBox // Dolby Vision configuration (dvcC or dvvC)
 	Btrt               *BtrtBox
 	Clap               *ClapBox
 	Pasp               *PaspBox
+	Colr               *ColrBox
+	Mdcv               *MdcvBox
+	Clli               *ClliBox
+	Sinf               *SinfBox
 	Children           []Box
 }
 
@@ -32,7 +39,7 @@ func NewVisualSampleEntryBox(name string) *VisualSampleEntryBox {
 	return b
 }
 
-// CreateVisualSampleEntryBox - Create new VisualSampleEntry such as avc1, avc3, hev1, hvc1
+// CreateVisualSampleEntryBox - Create new VisualSampleEntry such as avc1, avc3, hev1, hvc1, av01, vp09
 func CreateVisualSampleEntryBox(name string, width, height uint16, sampleEntry Box) *VisualSampleEntryBox {
 	b := &VisualSampleEntryBox{
 		name:               name,
@@ -58,17 +65,85 @@ func (b *VisualSampleEntryBox) AddChild(child Box) {
 		b.AvcC = child.(*AvcCBox)
 	case "hvcC":
 		b.HvcC = child.(*HvcCBox)
+	case "av1C":
+		b.Av1C = child.(*Av1CBox)
+	case "vpcC":
+		b.VpcC = child.(*VpcCBox)
+	case "dvcC", "dvvC":
+		b.DvcC = child.(*DvcCBox)
 	case "btrt":
 		b.Btrt = child.(*BtrtBox)
 	case "clap":
 		b.Clap = child.(*ClapBox)
 	case "pasp":
 		b.Pasp = child.(*PaspBox)
+	case "colr":
+		b.Colr = child.(*ColrBox)
+	case "mdcv":
+		b.Mdcv = child.(*MdcvBox)
+	case "clli":
+		b.Clli = child.(*ClliBox)
+	case "sinf":
+		b.Sinf = child.(*SinfBox)
 	}
 
 	b.Children = append(b.Children, child)
 }
 
+// SetColr - set colour information, replacing any existing colr box
+func (b *VisualSampleEntryBox) SetColr(colr *ColrBox) {
+	if b.Colr != nil {
+		b.Children = removeBox(b.Children, b.Colr)
+	}
+	b.Colr = nil
+	b.AddChild(colr)
+}
+
+// SetHDRStaticMetadata - set mdcv and clli boxes, replacing existing ones. A nil box is not set.
+func (b *VisualSampleEntryBox) SetHDRStaticMetadata(mdcv *MdcvBox, clli *ClliBox) {
+	if mdcv != nil {
+		if b.Mdcv != nil {
+			b.Children = removeBox(b.Children, b.Mdcv)
+		}
+		b.AddChild(mdcv)
+	}
+	if clli != nil {
+		if b.Clli != nil {
+			b.Children = removeBox(b.Children, b.Clli)
+		}
+		b.AddChild(clli)
+	}
+}
+
+// CheckHDR10 - check that colr signals HDR10 (BT.2020 and PQ) and that mdcv and clli are present
+func (b *VisualSampleEntryBox) CheckHDR10() error {
+	switch {
+	case b.Colr == nil:
+		return fmt.Errorf("no colr box")
+	case b.Colr.ColourType != ColourTypeNclx && b.Colr.ColourType != ColourTypeNclc:
+		return fmt.Errorf("colr type %s has no colour code points", b.Colr.ColourType)
+	case b.Colr.ColourPrimaries != ColourPrimariesBT2020:
+		return fmt.Errorf("colour primaries %d instead of BT.2020", b.Colr.ColourPrimaries)
+	case b.Colr.TransferCharacteristics != TransferCharacteristicsPQ:
+		return fmt.Errorf("transfer characteristics %d instead of PQ", b.Colr.TransferCharacteristics)
+	case b.Mdcv == nil:
+		return fmt.Errorf("no mdcv box")
+	case b.Clli == nil:
+		return fmt.Errorf("no clli box")
+	case b.Mdcv.MinLuminance >= b.Mdcv.MaxLuminance:
+		return fmt.Errorf("mdcv min luminance %d not below max luminance %d", b.Mdcv.MinLuminance, b.Mdcv.MaxLuminance)
+	}
+	return nil
+}
+
+// OriginalFormat - box type of entry, or original format from sinf/frma for encrypted (encv) entry
+func (b *VisualSampleEntryBox) OriginalFormat() string {
+	if b.Sinf != nil && b.Sinf.Frma != nil {
+		return b.Sinf.Frma.DataFormat
+	}
+	return b.name
+}
+
 // DecodeVisualSampleEntry - decode avc1/avc3/... box
 func DecodeVisualSampleEntry(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	data, err := ioutil.ReadAll(r)
@@ -144,6 +219,22 @@ func (b *VisualSampleEntryBox) Size() uint64 {
 	return totalSize
 }
 
+// GetChildren - list of child boxes
+func (b *VisualSampleEntryBox) GetChildren() []Box {
+	return b.Children
+}
+
+func (b *VisualSampleEntryBox) setChildren(children []Box) error {
+	n := VisualSampleEntryBox{name: b.name, DataReferenceIndex: b.DataReferenceIndex,
+		Width: b.Width, Height: b.Height, Horizresolution: b.Horizresolution, Vertresolution: b.Vertresolution,
+		FrameCount: b.FrameCount, CompressorName: b.CompressorName}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - write box to w
 func (b *VisualSampleEntryBox) Encode(w io.Writer) error {
 	err := EncodeHeader(b, w)
@@ -191,6 +282,12 @@ func (b *VisualSampleEntryBox) Info(w io.Writer, specificBoxLevels, indent, inde
 	bd.write(" - width: %d", b.Width)
 	bd.write(" - height: %d", b.Height)
 	bd.write(" - compressorName: %q", b.CompressorName)
+	if b.Clap != nil {
+		left, top, cropWidth, cropHeight, err := b.Clap.CropRect(b.Width, b.Height)
+		if err == nil {
+			bd.write(" - crop: %gx%g at (%g,%g)", cropWidth, cropHeight, left, top)
+		}
+	}
 	if bd.err != nil {
 		return bd.err
 	}
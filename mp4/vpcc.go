@@ -0,0 +1,130 @@
+package mp4
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// VpcCBox - VPCodecConfigurationBox (vpcC)
+// Defined in VP Codec ISO Media File Format Binding v1.0 Section 2.2. Only version 1 is supported.
+type VpcCBox struct {
+	Version                 byte
+	Flags                   uint32
+	Profile                 byte
+	Level                   byte
+	BitDepth                byte
+	ChromaSubsampling       byte
+	VideoFullRangeFlag      byte
+	ColourPrimaries         byte
+	TransferCharacteristics byte
+	MatrixCoefficients      byte
+	CodecInitializationData []byte // Must be empty for VP8 and VP9
+}
+
+// CreateVpcC - create vpcC version 1 box with BT.709 colour information
+func CreateVpcC(profile, level, bitDepth byte) *VpcCBox {
+	return &VpcCBox{
+		Version:                 1,
+		Profile:                 profile,
+		Level:                   level,
+		BitDepth:                bitDepth,
+		ChromaSubsampling:       1, // 4:2:0 colocated with luma (0,0)
+		ColourPrimaries:         1,
+		TransferCharacteristics: 1,
+		MatrixCoefficients:      1,
+	}
+}
+
+// DecodeVpcC - box-specific decode
+func DecodeVpcC(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 {
+		return nil, fmt.Errorf("vpcC: too short %d bytes", len(data))
+	}
+	s := NewSliceReader(data)
+	versionAndFlags := s.ReadUint32()
+	b := &VpcCBox{
+		Version: byte(versionAndFlags >> 24),
+		Flags:   versionAndFlags & flagsMask,
+	}
+	if b.Version != 1 {
+		return nil, fmt.Errorf("vpcC: version %d not supported", b.Version)
+	}
+	b.Profile = s.ReadUint8()
+	b.Level = s.ReadUint8()
+	byte2 := s.ReadUint8()
+	b.BitDepth = byte2 >> 4
+	b.ChromaSubsampling = (byte2 >> 1) & 0x7
+	b.VideoFullRangeFlag = byte2 & 1
+	b.ColourPrimaries = s.ReadUint8()
+	b.TransferCharacteristics = s.ReadUint8()
+	b.MatrixCoefficients = s.ReadUint8()
+	initDataSize := int(s.ReadUint16())
+	if initDataSize != s.NrRemainingBytes() {
+		return nil, fmt.Errorf("vpcC: codec initialization data size %d, but %d bytes left",
+			initDataSize, s.NrRemainingBytes())
+	}
+	if initDataSize > 0 {
+		b.CodecInitializationData = s.ReadBytes(initDataSize)
+	}
+	return b, nil
+}
+
+// CodecString - RFC6381 codec string such as vp09.00.10.08
+func (b *VpcCBox) CodecString() string {
+	return fmt.Sprintf("vp09.%02d.%02d.%02d", b.Profile, b.Level, b.BitDepth)
+}
+
+// Type - return box type
+func (b *VpcCBox) Type() string {
+	return "vpcC"
+}
+
+// Size - return calculated size
+func (b *VpcCBox) Size() uint64 {
+	return uint64(boxHeaderSize + 12 + len(b.CodecInitializationData))
+}
+
+// Encode - write box to w
+func (b *VpcCBox) Encode(w io.Writer) error {
+	err := EncodeHeader(b, w)
+	if err != nil {
+		return err
+	}
+	buf := makebuf(b)
+	sw := NewSliceWriter(buf)
+	versionAndFlags := (uint32(b.Version) << 24) + b.Flags
+	sw.WriteUint32(versionAndFlags)
+	sw.WriteUint8(b.Profile)
+	sw.WriteUint8(b.Level)
+	sw.WriteUint8(b.BitDepth<<4 | (b.ChromaSubsampling&0x7)<<1 | b.VideoFullRangeFlag&1)
+	sw.WriteUint8(b.ColourPrimaries)
+	sw.WriteUint8(b.TransferCharacteristics)
+	sw.WriteUint8(b.MatrixCoefficients)
+	sw.WriteUint16(uint16(len(b.CodecInitializationData)))
+	sw.WriteBytes(b.CodecInitializationData)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Info - box-specific Info
+func (b *VpcCBox) Info(w io.Writer, specificBoxLevels, indent, indentStep string) error {
+	bd := newInfoDumper(w, indent, b, int(b.Version), b.Flags)
+	bd.write(" - Profile: %d", b.Profile)
+	bd.write(" - Level: %d", b.Level)
+	bd.write(" - BitDepth: %d", b.BitDepth)
+	bd.write(" - ChromaSubsampling: %d", b.ChromaSubsampling)
+	bd.write(" - VideoFullRangeFlag: %d", b.VideoFullRangeFlag)
+	bd.write(" - ColourPrimaries: %d", b.ColourPrimaries)
+	bd.write(" - TransferCharacteristics: %d", b.TransferCharacteristics)
+	bd.write(" - MatrixCoefficients: %d", b.MatrixCoefficients)
+	if len(b.CodecInitializationData) > 0 {
+		bd.write(" - CodecInitializationData: %s", hex.EncodeToString(b.CodecInitializationData))
+	}
+	return bd.err
+}
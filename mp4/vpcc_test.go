@@ -0,0 +1,39 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVpcC(t *testing.T) {
+	vpcC := CreateVpcC(0, 31, 8)
+	boxDiffAfterEncodeAndDecode(t, vpcC)
+	if got := vpcC.CodecString(); got != "vp09.00.31.08" {
+		t.Errorf("got codec string %s instead of vp09.00.31.08", got)
+	}
+	hdr := &VpcCBox{Version: 1, Profile: 2, Level: 41, BitDepth: 10, ChromaSubsampling: 1,
+		VideoFullRangeFlag: 1, ColourPrimaries: 9, TransferCharacteristics: 16, MatrixCoefficients: 9}
+	boxDiffAfterEncodeAndDecode(t, hdr)
+
+	init := CreateEmptyInit()
+	init.AddEmptyTrack(90000, "video", "und")
+	trak := init.Moov.Trak
+	assertError(t, trak.SetVP9Descriptor(1280, 720, nil), "missing vpcC should give error")
+	assertNoError(t, trak.SetVP9Descriptor(1280, 720, hdr))
+	buf := bytes.Buffer{}
+	assertNoError(t, init.Encode(&buf))
+	decFile, err := DecodeFile(&buf)
+	assertNoError(t, err)
+	vp09 := decFile.Moov.Trak.Mdia.Minf.Stbl.Stsd.GetVP9()
+	if vp09 == nil || vp09.VpcC == nil {
+		t.Fatalf("no vp09 entry with vpcC after decode")
+	}
+	if vp09.Width != 1280 || vp09.VpcC.BitDepth != 10 || vp09.VpcC.TransferCharacteristics != 16 {
+		t.Errorf("bad vp09 entry width %d bit depth %d", vp09.Width, vp09.VpcC.BitDepth)
+	}
+	info := bytes.Buffer{}
+	assertNoError(t, vp09.Info(&info, "", "", "  "))
+	if !bytes.Contains(info.Bytes(), []byte("[vpcC] size=20 version=1 flags=000000")) {
+		t.Errorf("unexpected info output:\n%s", info.String())
+	}
+}
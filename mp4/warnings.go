@@ -0,0 +1,169 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// Warning - non-fatal deviation from the specifications found when decoding a file.
+// Such files can still be processed, but may not play everywhere.
+type Warning struct {
+	Path string // Path to the box starting with the top-level box and its position, e.g. moov@32/trak[1]/mdia/hdlr
+	Msg  string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Path, w.Msg)
+}
+
+// addWarning - add a warning to f.Warnings and log it
+func (f *File) addWarning(path, format string, args ...interface{}) {
+	w := Warning{Path: path, Msg: fmt.Sprintf(format, args...)}
+	logWarnf("%s", w)
+	f.Warnings = append(f.Warnings, w)
+}
+
+// checkTopLevelOrder - warn about top-level boxes in the wrong order. Called when box is added
+// at pos after the boxes already in f.Children.
+func (f *File) checkTopLevelOrder(box Box, pos uint64) {
+	boxType := box.Type()
+	path := fmt.Sprintf("%s@%d", boxType, pos)
+	if len(f.Children) == 0 {
+		switch boxType {
+		case "ftyp", "styp", "free", "skip":
+		default:
+			f.addWarning(path, "first box is not ftyp or styp")
+		}
+		return
+	}
+	switch boxType {
+	case "ftyp":
+		f.addWarning(path, "ftyp is not the first box")
+	case "moov":
+		for _, c := range f.Children {
+			switch c.Type() {
+			case "moov":
+				f.addWarning(path, "more than one moov box")
+				return
+			case "moof":
+				f.addWarning(path, "moov after moof")
+				return
+			}
+		}
+	}
+}
+
+// checkBoxes - warn about problems inside the top-level boxes, like box order in moov and trak,
+// samples with zero duration, and padded or unterminated strings
+func (f *File) checkBoxes() {
+	pos := uint64(0)
+	for _, c := range f.Children {
+		f.checkBoxTree(c, fmt.Sprintf("%s@%d", c.Type(), pos))
+		pos += c.Size()
+	}
+}
+
+// checkBoxTree - apply box-specific checks to b and all its descendants.
+// Children of the same type are numbered from 1 in their paths.
+func (f *File) checkBoxTree(b Box, path string) {
+	switch box := b.(type) {
+	case *MoovBox:
+		if len(box.Children) > 0 && box.Children[0].Type() != "mvhd" {
+			f.addWarning(path, "mvhd is not the first box")
+		}
+	case *TrakBox:
+		if len(box.Children) > 0 && box.Children[0].Type() != "tkhd" {
+			f.addWarning(path, "tkhd is not the first box")
+		}
+	case *MdhdBox:
+		if box.Timescale == 0 {
+			f.addWarning(path, "timescale is 0")
+		}
+	case *SttsBox:
+		f.checkStts(box, path)
+	case *TrafBox:
+		f.checkTraf(box, path)
+	case *HdlrBox:
+		if box.LacksNullTermination {
+			f.addWarning(path, "name %q lacks null termination", box.Name)
+		}
+		if len(box.padding) > 0 {
+			f.addWarning(path, "name %q padded with %d bytes after null termination", box.Name, len(box.padding))
+		}
+	}
+	c, ok := b.(ContainerBox)
+	if !ok {
+		return
+	}
+	children := c.GetChildren()
+	for i, child := range children {
+		f.checkBoxTree(child, childPath(path, children, i))
+	}
+}
+
+// childPath - path of children[i], numbered if there are several children of its type
+func childPath(path string, children []Box, i int) string {
+	boxType := children[i].Type()
+	nr, count := 0, 0
+	for j, c := range children {
+		if c.Type() == boxType {
+			count++
+			if j <= i {
+				nr++
+			}
+		}
+	}
+	if count == 1 {
+		return path + "/" + boxType
+	}
+	return fmt.Sprintf("%s/%s[%d]", path, boxType, nr)
+}
+
+func (f *File) checkStts(stts *SttsBox, path string) {
+	nrSamples := uint32(0)
+	nrZeroDur := uint32(0)
+	for i, count := range stts.SampleCount {
+		nrSamples += count
+		if stts.SampleTimeDelta[i] == 0 {
+			nrZeroDur += count
+			if i == len(stts.SampleCount)-1 && count > 0 {
+				nrZeroDur-- // The last sample may have zero duration
+			}
+		}
+	}
+	if nrZeroDur > 0 {
+		f.addWarning(path, "%d of %d samples have zero duration", nrZeroDur, nrSamples)
+	}
+}
+
+func (f *File) checkTraf(traf *TrafBox, path string) {
+	if traf.Tfhd == nil {
+		return
+	}
+	trex := f.TrexForTrack(traf.Tfhd.TrackID)
+	defaultDur := uint32(0)
+	if traf.Tfhd.HasDefaultSampleDuration() {
+		defaultDur = traf.Tfhd.DefaultSampleDuration
+	} else if trex != nil {
+		defaultDur = trex.DefaultSampleDuration
+	}
+	for i, c := range traf.Children {
+		trun, ok := c.(*TrunBox)
+		if !ok {
+			continue
+		}
+		nrZeroDur := 0
+		for _, s := range trun.Samples {
+			dur := defaultDur
+			if trun.HasSampleDuration() {
+				dur = s.Dur
+			}
+			if dur == 0 {
+				nrZeroDur++
+			}
+		}
+		if nrZeroDur > 0 {
+			f.addWarning(childPath(path, traf.Children, i), "%d of %d samples have zero duration",
+				nrZeroDur, len(trun.Samples))
+		}
+	}
+}
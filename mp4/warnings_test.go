@@ -0,0 +1,75 @@
+package mp4
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func hasWarning(warnings []Warning, path, msg string) bool {
+	for _, w := range warnings {
+		if w.Path == path && strings.Contains(w.Msg, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWarningsProgressive(t *testing.T) {
+	fd, err := os.Open("testdata/prog_8s.mp4")
+	assertNoError(t, err)
+	defer fd.Close()
+	f, err := DecodeFile(fd)
+	assertNoError(t, err)
+	if len(f.Warnings) != 0 {
+		t.Errorf("got warnings %v for valid file", f.Warnings)
+	}
+
+	trak := f.Moov.Traks[0]
+	trak.Children = append(trak.Children[1:], trak.Children[0])
+	trak.Mdia.Hdlr.padding = []byte{0, 0}
+	trak.Mdia.Minf.Stbl.Stts.SampleTimeDelta[0] = 0
+	var buf bytes.Buffer
+	err = f.Encode(&buf)
+	assertNoError(t, err)
+
+	f, err = DecodeFile(&buf)
+	assertNoError(t, err)
+	moovPath := f.Warnings[0].Path[:strings.Index(f.Warnings[0].Path, "/")]
+	wanted := []struct {
+		path string
+		msg  string
+	}{
+		{moovPath + "/trak[1]", "tkhd is not the first box"},
+		{moovPath + "/trak[1]/mdia/hdlr", "padded with 2 bytes after null termination"},
+		{moovPath + "/trak[1]/mdia/minf/stbl/stts", "samples have zero duration"},
+	}
+	for _, w := range wanted {
+		if !hasWarning(f.Warnings, w.path, w.msg) {
+			t.Errorf("missing warning %s: %s in %v", w.path, w.msg, f.Warnings)
+		}
+	}
+	if len(f.Warnings) != len(wanted) {
+		t.Errorf("got %d warnings instead of %d: %v", len(f.Warnings), len(wanted), f.Warnings)
+	}
+}
+
+func TestWarningsTopLevelOrder(t *testing.T) {
+	seg, err := ioutil.ReadFile("testdata/1.m4s")
+	assertNoError(t, err)
+	init, err := ioutil.ReadFile("testdata/init1.cmfv")
+	assertNoError(t, err)
+
+	f, err := DecodeFile(bytes.NewBuffer(append(seg, init...)))
+	assertNoError(t, err)
+	pos := len(seg)
+	if !hasWarning(f.Warnings, "ftyp@"+strconv.Itoa(pos), "ftyp is not the first box") {
+		t.Errorf("missing ftyp warning in %v", f.Warnings)
+	}
+	if !hasWarning(f.Warnings, "moov@"+strconv.Itoa(pos+int(f.Ftyp.Size())), "moov after moof") {
+		t.Errorf("missing moov warning in %v", f.Warnings)
+	}
+}
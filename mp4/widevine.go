@@ -0,0 +1,172 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// WidevinePsshData - Widevine pssh payload (WidevinePsshData protobuf message)
+type WidevinePsshData struct {
+	Algorithm         uint32 // Deprecated: 0 unencrypted, 1 AES-CTR
+	KeyIDs            []UUID
+	Provider          string
+	ContentID         []byte
+	Policy            string
+	CryptoPeriodIndex uint32
+	ProtectionScheme  string // Four-character code such as cenc or cbcs, empty if not present
+}
+
+// Field numbers in WidevinePsshData
+const (
+	wvFieldAlgorithm         = 1
+	wvFieldKeyID             = 2
+	wvFieldProvider          = 3
+	wvFieldContentID         = 4
+	wvFieldPolicy            = 6
+	wvFieldCryptoPeriodIndex = 7
+	wvFieldProtectionScheme  = 9
+)
+
+// Protobuf wire types
+const (
+	pbWireVarint = 0
+	pbWire64Bit  = 1
+	pbWireBytes  = 2
+	pbWire32Bit  = 5
+)
+
+// WidevineSystemID - Widevine system ID as used in pssh boxes
+func WidevineSystemID() UUID {
+	id, _ := hex.DecodeString(strings.Replace(UUIDWidevine, "-", "", -1))
+	return UUID(id)
+}
+
+// IsWidevine - true if the pssh box has the Widevine system ID
+func (b *PsshBox) IsWidevine() bool {
+	return b.SystemID.String() == UUIDWidevine
+}
+
+// WidevineData - parse the Widevine payload of the pssh box
+func (b *PsshBox) WidevineData() (*WidevinePsshData, error) {
+	if !b.IsWidevine() {
+		return nil, fmt.Errorf("pssh system ID %s is not Widevine", b.SystemID)
+	}
+	return ParseWidevinePsshData(b.Data)
+}
+
+// NewWidevinePsshBox - create a version 1 Widevine pssh box with the key IDs of d in both box and payload
+func NewWidevinePsshBox(d *WidevinePsshData) *PsshBox {
+	return &PsshBox{
+		Version:  1,
+		SystemID: WidevineSystemID(),
+		KIDs:     d.KeyIDs,
+		Data:     d.Marshal(),
+	}
+}
+
+// ParseWidevinePsshData - parse a WidevinePsshData protobuf message. Unknown fields are skipped.
+func ParseWidevinePsshData(data []byte) (*WidevinePsshData, error) {
+	d := &WidevinePsshData{}
+	pos := 0
+	for pos < len(data) {
+		key, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return nil, fmt.Errorf("widevine pssh: bad field key at %d", pos)
+		}
+		pos += n
+		field, wireType := key>>3, key&0x7
+		var value uint64
+		var bytesValue []byte
+		switch wireType {
+		case pbWireVarint:
+			value, n = binary.Uvarint(data[pos:])
+			if n <= 0 {
+				return nil, fmt.Errorf("widevine pssh: bad varint for field %d", field)
+			}
+			pos += n
+		case pbWireBytes:
+			length, n := binary.Uvarint(data[pos:])
+			if n <= 0 || length > uint64(len(data)-pos-n) {
+				return nil, fmt.Errorf("widevine pssh: bad length for field %d", field)
+			}
+			pos += n
+			bytesValue = data[pos : pos+int(length)]
+			pos += int(length)
+		case pbWire64Bit, pbWire32Bit:
+			size := 8
+			if wireType == pbWire32Bit {
+				size = 4
+			}
+			if pos+size > len(data) {
+				return nil, fmt.Errorf("widevine pssh: field %d too short", field)
+			}
+			pos += size
+			continue
+		default:
+			return nil, fmt.Errorf("widevine pssh: unsupported wire type %d for field %d", wireType, field)
+		}
+		switch field {
+		case wvFieldAlgorithm:
+			d.Algorithm = uint32(value)
+		case wvFieldKeyID:
+			d.KeyIDs = append(d.KeyIDs, UUID(bytesValue))
+		case wvFieldProvider:
+			d.Provider = string(bytesValue)
+		case wvFieldContentID:
+			d.ContentID = bytesValue
+		case wvFieldPolicy:
+			d.Policy = string(bytesValue)
+		case wvFieldCryptoPeriodIndex:
+			d.CryptoPeriodIndex = uint32(value)
+		case wvFieldProtectionScheme:
+			fourCC := make([]byte, 4)
+			binary.BigEndian.PutUint32(fourCC, uint32(value))
+			d.ProtectionScheme = string(fourCC)
+		}
+	}
+	return d, nil
+}
+
+// Marshal - protobuf encoding of d. Fields with zero values are not written.
+func (d *WidevinePsshData) Marshal() []byte {
+	var buf bytes.Buffer
+	uvarint := make([]byte, binary.MaxVarintLen64)
+	writeUvarint := func(value uint64) {
+		n := binary.PutUvarint(uvarint, value)
+		buf.Write(uvarint[:n])
+	}
+	writeVarint := func(field int, value uint64) {
+		writeUvarint(uint64(field<<3 | pbWireVarint))
+		writeUvarint(value)
+	}
+	writeBytes := func(field int, value []byte) {
+		writeUvarint(uint64(field<<3 | pbWireBytes))
+		writeUvarint(uint64(len(value)))
+		buf.Write(value)
+	}
+	if d.Algorithm != 0 {
+		writeVarint(wvFieldAlgorithm, uint64(d.Algorithm))
+	}
+	for _, kid := range d.KeyIDs {
+		writeBytes(wvFieldKeyID, kid)
+	}
+	if d.Provider != "" {
+		writeBytes(wvFieldProvider, []byte(d.Provider))
+	}
+	if len(d.ContentID) > 0 {
+		writeBytes(wvFieldContentID, d.ContentID)
+	}
+	if d.Policy != "" {
+		writeBytes(wvFieldPolicy, []byte(d.Policy))
+	}
+	if d.CryptoPeriodIndex != 0 {
+		writeVarint(wvFieldCryptoPeriodIndex, uint64(d.CryptoPeriodIndex))
+	}
+	if len(d.ProtectionScheme) == 4 {
+		writeVarint(wvFieldProtectionScheme, uint64(binary.BigEndian.Uint32([]byte(d.ProtectionScheme))))
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,48 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestWidevinePsshData(t *testing.T) {
+	kid1, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	kid2, _ := hex.DecodeString("ffeeddccbbaa99887766554433221100")
+	d := &WidevinePsshData{
+		KeyIDs:           []UUID{kid1, kid2},
+		Provider:         "widevine_test",
+		ContentID:        []byte("content-1"),
+		ProtectionScheme: "cbcs",
+	}
+	pssh := NewWidevinePsshBox(d)
+	if !pssh.IsWidevine() {
+		t.Errorf("pssh box not identified as Widevine")
+	}
+	boxDiffAfterEncodeAndDecode(t, pssh)
+
+	got, err := pssh.WidevineData()
+	assertNoError(t, err)
+	if len(got.KeyIDs) != 2 || !bytes.Equal(got.KeyIDs[0], kid1) || !bytes.Equal(got.KeyIDs[1], kid2) {
+		t.Errorf("got key IDs %v", got.KeyIDs)
+	}
+	if got.Provider != d.Provider || !bytes.Equal(got.ContentID, d.ContentID) ||
+		got.ProtectionScheme != d.ProtectionScheme {
+		t.Errorf("got %+v instead of %+v", got, d)
+	}
+
+	// Unknown fields of all wire types are skipped
+	data := append(append([]byte{}, pssh.Data...), 0x50, 0x01, 0x59, 1, 2, 3, 4, 5, 6, 7, 8, 0x5d, 1, 2, 3, 4)
+	got, err = ParseWidevinePsshData(data)
+	assertNoError(t, err)
+	if got.Provider != d.Provider {
+		t.Errorf("got provider %q after unknown fields", got.Provider)
+	}
+
+	_, err = ParseWidevinePsshData(pssh.Data[:len(pssh.Data)-3])
+	assertError(t, err, "truncated Widevine data")
+
+	other := &PsshBox{SystemID: UUID(make([]byte, 16))}
+	_, err = other.WidevineData()
+	assertError(t, err, "non-Widevine system ID")
+}
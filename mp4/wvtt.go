@@ -15,9 +15,11 @@ import (
 // WvttBox - WVTTSampleEntry (wvtt)
 // Extends PlainTextSampleEntry which extends SampleEntry
 type WvttBox struct {
+	name               string // wvtt or enct
 	VttC               *VttCBox
 	Vlab               *VlabBox
 	Btrt               *BtrtBox
+	Sinf               *SinfBox // Present for encrypted (enct) entries
 	Children           []Box
 	DataReferenceIndex uint16
 }
@@ -36,6 +38,8 @@ func (b *WvttBox) AddChild(child Box) {
 		b.Vlab = box
 	case *BtrtBox:
 		b.Btrt = box
+	case *SinfBox:
+		b.Sinf = box
 	default:
 		// Other box
 	}
@@ -51,7 +55,7 @@ func DecodeWvtt(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 	if err != nil {
 		return nil, err
 	}
-	w := &WvttBox{}
+	w := &WvttBox{name: hdr.name}
 	s := NewSliceReader(data)
 
 	// 14496-12 8.5.2.2 Sample entry (8 bytes)
@@ -84,7 +88,18 @@ func DecodeWvtt(hdr *boxHeader, startPos uint64, r io.Reader) (Box, error) {
 
 // Type - return box type
 func (b *WvttBox) Type() string {
-	return "wvtt"
+	if b.name == "" {
+		return "wvtt"
+	}
+	return b.name
+}
+
+// OriginalFormat - data format given by frma for encrypted (enct) entries, otherwise type
+func (b *WvttBox) OriginalFormat() string {
+	if b.Sinf != nil && b.Sinf.Frma != nil {
+		return b.Sinf.Frma.DataFormat
+	}
+	return b.Type()
 }
 
 // Size - return calculated size
@@ -96,6 +111,20 @@ func (b *WvttBox) Size() uint64 {
 	return totalSize
 }
 
+// GetChildren - list of child boxes
+func (b *WvttBox) GetChildren() []Box {
+	return b.Children
+}
+
+func (b *WvttBox) setChildren(children []Box) error {
+	n := WvttBox{name: b.name, DataReferenceIndex: b.DataReferenceIndex}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - write box to w
 func (b *WvttBox) Encode(w io.Writer) error {
 	err := EncodeHeader(b, w)
@@ -322,6 +351,15 @@ func (b *VttcBox) GetChildren() []Box {
 	return b.Children
 }
 
+func (b *VttcBox) setChildren(children []Box) error {
+	n := VttcBox{}
+	for _, c := range children {
+		n.AddChild(c)
+	}
+	*b = n
+	return nil
+}
+
 // Encode - write mvex container to w
 func (b *VttcBox) Encode(w io.Writer) error {
 	return EncodeContainer(b, w)
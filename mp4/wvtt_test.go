@@ -41,3 +41,41 @@ func TestVtta(t *testing.T) {
 	vtta := &VttaBox{CueAdditionalText: "This is a comment"}
 	boxDiffAfterEncodeAndDecode(t, vtta)
 }
+
+func TestWvttSample(t *testing.T) {
+	cues := []WvttCue{
+		{ID: "1", Settings: "line:20%", Payload: "First line"},
+		{SourceID: 42, HasSourceID: true, CurrentTime: "00:00:00.120", Payload: "Second line"},
+	}
+	data := CreateWvttSample(cues)
+	gotCues, err := ParseWvttSample(data)
+	assertNoError(t, err)
+	if len(gotCues) != len(cues) {
+		t.Fatalf("got %d cues instead of %d", len(gotCues), len(cues))
+	}
+	for i := range cues {
+		if gotCues[i] != cues[i] {
+			t.Errorf("cue %d: got %+v instead of %+v", i, gotCues[i], cues[i])
+		}
+	}
+
+	vttc := NewVttcBox(cues[1])
+	if vttc.Children[0].Type() != "vsid" || vttc.Children[1].Type() != "ctim" {
+		t.Errorf("bad child box order")
+	}
+
+	empty := CreateWvttSample(nil)
+	if len(empty) != 8 || string(empty[4:8]) != "vtte" {
+		t.Errorf("empty sample is not a vtte box")
+	}
+	gotCues, err = ParseWvttSample(empty)
+	assertNoError(t, err)
+	if len(gotCues) != 0 {
+		t.Errorf("got %d cues from empty sample", len(gotCues))
+	}
+
+	_, err = ParseWvttSample(CreateWvttSample([]WvttCue{{Payload: "x"}})[:10])
+	assertError(t, err, "truncated sample should fail")
+	_, err = ParseWvttSample([]byte{0, 0, 0, 8, 'f', 'r', 'e', 'e'})
+	assertError(t, err, "free box in sample should fail")
+}
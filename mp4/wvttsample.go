@@ -0,0 +1,101 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// WvttCue - WebVTT cue carried as a vttc box in a wvtt sample.
+// Empty strings correspond to absent iden, ctim, and sttg boxes.
+type WvttCue struct {
+	SourceID    uint32 // Only signaled if HasSourceID
+	HasSourceID bool
+	ID          string
+	CurrentTime string
+	Settings    string
+	Payload     string
+}
+
+// NewVttcBox - create a vttc box with child boxes in ISO/IEC 14496-30 order
+func NewVttcBox(cue WvttCue) *VttcBox {
+	b := &VttcBox{}
+	if cue.HasSourceID {
+		b.AddChild(&VsidBox{SourceID: cue.SourceID})
+	}
+	if cue.CurrentTime != "" {
+		b.AddChild(&CtimBox{CueCurrentTime: cue.CurrentTime})
+	}
+	if cue.ID != "" {
+		b.AddChild(&IdenBox{CueID: cue.ID})
+	}
+	if cue.Settings != "" {
+		b.AddChild(&SttgBox{Settings: cue.Settings})
+	}
+	b.AddChild(&PaylBox{CueText: cue.Payload})
+	return b
+}
+
+// Cue - cue given by the child boxes of the vttc box
+func (b *VttcBox) Cue() WvttCue {
+	var cue WvttCue
+	if b.Vsid != nil {
+		cue.SourceID = b.Vsid.SourceID
+		cue.HasSourceID = true
+	}
+	if b.Iden != nil {
+		cue.ID = b.Iden.CueID
+	}
+	if b.Ctim != nil {
+		cue.CurrentTime = b.Ctim.CueCurrentTime
+	}
+	if b.Sttg != nil {
+		cue.Settings = b.Sttg.Settings
+	}
+	if b.Payl != nil {
+		cue.Payload = b.Payl.CueText
+	}
+	return cue
+}
+
+// ParseWvttSample - parse the cues of a wvtt sample.
+// An empty sample (vtte) results in no cues. Comments in vtta boxes are skipped.
+func ParseWvttSample(data []byte) ([]WvttCue, error) {
+	var cues []WvttCue
+	r := bytes.NewReader(data)
+	pos := uint64(0)
+	for pos < uint64(len(data)) {
+		box, err := DecodeBox(pos, r)
+		if err != nil {
+			return nil, fmt.Errorf("wvtt sample: %w", err)
+		}
+		switch b := box.(type) {
+		case *VttcBox:
+			if b.Payl == nil {
+				return nil, fmt.Errorf("wvtt sample: vttc box without payl")
+			}
+			cues = append(cues, b.Cue())
+		case *VtteBox, *VttaBox:
+			// No cue
+		default:
+			return nil, fmt.Errorf("wvtt sample: unexpected box %s", box.Type())
+		}
+		pos += box.Size()
+	}
+	return cues, nil
+}
+
+// CreateWvttSample - create wvtt sample data with one vttc box per cue, or a vtte box if there are no cues
+func CreateWvttSample(cues []WvttCue) []byte {
+	var boxes []Box
+	for _, cue := range cues {
+		boxes = append(boxes, NewVttcBox(cue))
+	}
+	if len(boxes) == 0 {
+		boxes = append(boxes, &VtteBox{})
+	}
+	buf := bytes.Buffer{}
+	for _, b := range boxes {
+		_ = b.Encode(&buf) // Cannot fail for bytes.Buffer
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,116 @@
+package mp4
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TimedWvttCue - WebVTT cue with presentation interval [Start, End) in track timescale
+type TimedWvttCue struct {
+	Start uint64
+	End   uint64
+	Cue   WvttCue
+}
+
+// WvttSegmentOption - option for CreateWvttSegmentSamples
+type WvttSegmentOption func(*wvttSegmentConfig)
+
+type wvttSegmentConfig struct {
+	maxVtteDur uint32
+}
+
+// WithMaxVtteDuration - split gaps without cues into empty (vtte) samples of at most maxDur (track timescale).
+// By default, a gap is covered by one vtte sample unless longer than the maximal 32-bit sample duration.
+func WithMaxVtteDuration(maxDur uint32) WvttSegmentOption {
+	return func(c *wvttSegmentConfig) {
+		c.maxVtteDur = maxDur
+	}
+}
+
+// CreateWvttSegmentSamples - create wvtt samples covering [segStart, segEnd) from cues in track timescale.
+//
+// Following ISO/IEC 14496-30, a new sample starts at every cue start and end, and intervals without
+// active cues are covered by empty (vtte) samples. A cue that spans more than one sample, within the
+// segment or across its boundaries, is split into one vttc box per sample. All parts of a split cue
+// have the same vsid, so that players can identify them as the same cue, and a ctim box with the start
+// time of the sample. Unless already set, the source ID of a cue is its index in cues plus one,
+// so the full cue list should be used for every segment to keep source IDs stable across segments.
+// The track is kept continuous, so the sample durations always add up to the segment duration.
+func CreateWvttSegmentSamples(cues []TimedWvttCue, segStart, segEnd uint64, timescale uint32,
+	options ...WvttSegmentOption) ([]FullSample, error) {
+	cfg := wvttSegmentConfig{maxVtteDur: 0xffffffff}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	if cfg.maxVtteDur == 0 {
+		return nil, fmt.Errorf("max vtte duration is zero")
+	}
+	if segEnd <= segStart {
+		return nil, fmt.Errorf("segment end %d not after start %d", segEnd, segStart)
+	}
+	if timescale == 0 {
+		return nil, fmt.Errorf("timescale is zero")
+	}
+	times := []uint64{segStart, segEnd}
+	for i, c := range cues {
+		if c.End <= c.Start {
+			return nil, fmt.Errorf("cue %d: end %d not after start %d", i+1, c.End, c.Start)
+		}
+		if c.Start > segStart && c.Start < segEnd {
+			times = append(times, c.Start)
+		}
+		if c.End > segStart && c.End < segEnd {
+			times = append(times, c.End)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	var samples []FullSample
+	for i := 0; i < len(times)-1; i++ {
+		start, end := times[i], times[i+1]
+		if start == end {
+			continue
+		}
+		var sampleCues []WvttCue
+		for j, c := range cues {
+			if c.End <= start || c.Start >= end {
+				continue
+			}
+			cue := c.Cue
+			if c.Start < start || c.End > end { // Split cue
+				if !cue.HasSourceID {
+					cue.SourceID = uint32(j + 1)
+					cue.HasSourceID = true
+				}
+				cue.CurrentTime = formatVttTimestamp(start, timescale)
+			}
+			sampleCues = append(sampleCues, cue)
+		}
+		data := CreateWvttSample(sampleCues)
+		maxDur := uint64(cfg.maxVtteDur)
+		if len(sampleCues) > 0 {
+			if end-start > 0xffffffff {
+				return nil, fmt.Errorf("cue sample at %d: duration %d does not fit in 32 bits", start, end-start)
+			}
+			maxDur = end - start
+		}
+		for t := start; t < end; t += maxDur {
+			dur := end - t
+			if dur > maxDur {
+				dur = maxDur
+			}
+			samples = append(samples, FullSample{
+				Sample:     NewSample(SyncSampleFlags, uint32(dur), uint32(len(data)), 0),
+				DecodeTime: t,
+				Data:       data,
+			})
+		}
+	}
+	return samples, nil
+}
+
+// formatVttTimestamp - WebVTT timestamp hh:mm:ss.ttt for time t in timescale
+func formatVttTimestamp(t uint64, timescale uint32) string {
+	ms := t * 1000 / uint64(timescale)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,94 @@
+package mp4
+
+import (
+	"testing"
+)
+
+func TestCreateWvttSegmentSamples(t *testing.T) {
+	cues := []TimedWvttCue{
+		{Start: 500, End: 2500, Cue: WvttCue{ID: "a", Payload: "Long cue"}},
+		{Start: 1000, End: 1500, Cue: WvttCue{ID: "b", Payload: "Short cue"}},
+	}
+	type wantedSample struct {
+		decodeTime uint64
+		dur        uint32
+		cues       []WvttCue
+	}
+	splitA := func(currentTime string) WvttCue {
+		return WvttCue{SourceID: 1, HasSourceID: true, ID: "a", CurrentTime: currentTime, Payload: "Long cue"}
+	}
+	testCases := []struct {
+		segStart, segEnd uint64
+		wanted           []wantedSample
+	}{
+		{0, 2000, []wantedSample{
+			{0, 500, nil},
+			{500, 500, []WvttCue{splitA("00:00:00.500")}},
+			{1000, 500, []WvttCue{splitA("00:00:01.000"), cues[1].Cue}},
+			{1500, 500, []WvttCue{splitA("00:00:01.500")}},
+		}},
+		{2000, 4000, []wantedSample{
+			{2000, 500, []WvttCue{splitA("00:00:02.000")}},
+			{2500, 1500, nil},
+		}},
+	}
+	for _, tc := range testCases {
+		samples, err := CreateWvttSegmentSamples(cues, tc.segStart, tc.segEnd, 1000)
+		assertNoError(t, err)
+		if len(samples) != len(tc.wanted) {
+			t.Fatalf("segment %d: got %d samples instead of %d", tc.segStart, len(samples), len(tc.wanted))
+		}
+		for i, s := range samples {
+			w := tc.wanted[i]
+			if s.DecodeTime != w.decodeTime || s.Dur != w.dur || int(s.Size) != len(s.Data) {
+				t.Errorf("segment %d sample %d: got time %d dur %d", tc.segStart, i, s.DecodeTime, s.Dur)
+			}
+			gotCues, err := ParseWvttSample(s.Data)
+			assertNoError(t, err)
+			if len(gotCues) != len(w.cues) {
+				t.Fatalf("segment %d sample %d: got %d cues instead of %d", tc.segStart, i, len(gotCues), len(w.cues))
+			}
+			for j := range gotCues {
+				if gotCues[j] != w.cues[j] {
+					t.Errorf("segment %d sample %d: got cue %+v instead of %+v", tc.segStart, i, gotCues[j], w.cues[j])
+				}
+			}
+		}
+	}
+
+	_, err := CreateWvttSegmentSamples(cues, 1000, 1000, 1000)
+	assertError(t, err, "empty segment should fail")
+	_, err = CreateWvttSegmentSamples([]TimedWvttCue{{Start: 10, End: 10}}, 0, 1000, 1000)
+	assertError(t, err, "empty cue should fail")
+}
+
+func TestFormatVttTimestamp(t *testing.T) {
+	if got := formatVttTimestamp(3723004*90, 90000); got != "01:02:03.004" {
+		t.Errorf("got %s instead of 01:02:03.004", got)
+	}
+}
+
+func TestWvttMaxVtteDuration(t *testing.T) {
+	cues := []TimedWvttCue{{Start: 2500, End: 3000, Cue: WvttCue{Payload: "Cue"}}}
+	samples, err := CreateWvttSegmentSamples(cues, 0, 4000, 1000, WithMaxVtteDuration(1000))
+	assertNoError(t, err)
+	wantedDurs := []uint32{1000, 1000, 500, 500, 1000}
+	if len(samples) != len(wantedDurs) {
+		t.Fatalf("got %d samples instead of %d", len(samples), len(wantedDurs))
+	}
+	decodeTime := uint64(0)
+	for i, s := range samples {
+		if s.Dur != wantedDurs[i] || s.DecodeTime != decodeTime {
+			t.Errorf("sample %d: got time %d dur %d instead of %d %d", i, s.DecodeTime, s.Dur, decodeTime, wantedDurs[i])
+		}
+		gotCues, err := ParseWvttSample(s.Data)
+		assertNoError(t, err)
+		if (len(gotCues) == 1) != (i == 3) {
+			t.Errorf("sample %d: got %d cues", i, len(gotCues))
+		}
+		decodeTime += uint64(s.Dur)
+	}
+
+	_, err = CreateWvttSegmentSamples(cues, 0, 4000, 1000, WithMaxVtteDuration(0))
+	assertError(t, err, "zero max vtte duration should fail")
+}